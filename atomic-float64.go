@@ -0,0 +1,46 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// AtomicFloat64 is a float64 with atomic access
+//   - for gauges like load average or ratio
+//   - stored as IEEE 754 bits in [atomic.Uint64]
+//   - Add uses a CompareAndSwap loop
+//   - initialization-free, thread-safe
+type AtomicFloat64 struct{ bits atomic.Uint64 }
+
+// Load atomically loads and returns the value
+func (a *AtomicFloat64) Load() (value float64) { return math.Float64frombits(a.bits.Load()) }
+
+// Store atomically stores value
+func (a *AtomicFloat64) Store(value float64) { a.bits.Store(math.Float64bits(value)) }
+
+// Swap atomically stores new and returns the previous value
+func (a *AtomicFloat64) Swap(new float64) (old float64) {
+	return math.Float64frombits(a.bits.Swap(math.Float64bits(new)))
+}
+
+// CompareAndSwap stores new if the value is old
+//   - comparison is of bits: NaN may be swapped, 0 and -0 differ
+func (a *AtomicFloat64) CompareAndSwap(old, new float64) (swapped bool) {
+	return a.bits.CompareAndSwap(math.Float64bits(old), math.Float64bits(new))
+}
+
+// Add atomically adds delta and returns the new value
+func (a *AtomicFloat64) Add(delta float64) (new float64) {
+	for {
+		var oldBits = a.bits.Load()
+		new = math.Float64frombits(oldBits) + delta
+		if a.bits.CompareAndSwap(oldBits, math.Float64bits(new)) {
+			return
+		}
+	}
+}
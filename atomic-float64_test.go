@@ -0,0 +1,34 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAtomicFloat64(t *testing.T) {
+	//t.Error("Logging on")
+	var a AtomicFloat64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.Add(0.5)
+		}()
+	}
+	wg.Wait()
+	if v := a.Load(); v != 5 {
+		t.Errorf("Add %g exp 5", v)
+	}
+	if old := a.Swap(1.5); old != 5 {
+		t.Errorf("Swap old %g", old)
+	}
+	if !a.CompareAndSwap(1.5, 2) || a.CompareAndSwap(1.5, 3) || a.Load() != 2 {
+		t.Errorf("CompareAndSwap %g", a.Load())
+	}
+}
@@ -74,6 +74,11 @@ func (a *AtomicMin[T]) Min() (value T, hasValue bool) {
 	return
 }
 
+// Min1 returns current minimum whether zero-value or set by Value
+//   - symmetrical to [AtomicMax.Max1]
+//   - Thread-safe
+func (a *AtomicMin[T]) Min1() (value T) { return T(a.value.Load()) }
+
 // init uses lock to have loser threads wait until winner thread has updated value
 func (a *AtomicMin[T]) init(valueU64 uint64) (didStore bool) {
 	a.initLock.Lock()
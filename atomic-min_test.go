@@ -57,4 +57,7 @@ func TestAtomicMin(t *testing.T) {
 		t.Errorf("7 value %d exp %d", value, value2)
 	}
 
+	if value = min.Min1(); value != value2 {
+		t.Errorf("8 Min1 %d exp %d", value, value2)
+	}
 }
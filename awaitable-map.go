@@ -0,0 +1,223 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrAwaitableMapClosed is returned by [AwaitableMap.Await] and
+// [AwaitableMap.Take] once the map is closed
+var ErrAwaitableMapClosed = errors.New("awaitable map closed")
+
+// ErrAwaitableKeyDeleted is returned by [AwaitableMap.Await] and
+// [AwaitableMap.Take] when the awaited key is deleted or taken
+// by another thread before a value was put
+var ErrAwaitableKeyDeleted = errors.New("awaitable map key deleted")
+
+// AwaitableMap is a key-value store where a consumer can
+// wait for a producer to put a key
+//   - [AwaitableMap.Await] waits for key with context cancelation
+//   - [AwaitableMap.Take] waits for key and removes it, ie.
+//     for request-response correlation
+//   - any number of threads may wait for the same key
+//   - [AwaitableMap.Delete] of a key yet to be put makes
+//     its waiters return [ErrAwaitableKeyDeleted]
+//   - [AwaitableMap.Close] makes waiters return [ErrAwaitableMapClosed]
+//   - initialization-free, thread-safe
+//
+// Usage:
+//
+//	var responses parl.AwaitableMap[uint64, *Response]
+//	// request thread
+//	send(&Request{ID: id})
+//	var response, err = responses.Take(ctx, id)
+//	// response thread
+//	responses.Put(response.ID, response)
+type AwaitableMap[K comparable, V any] struct {
+	// lock makes m and isClosed thread-safe
+	lock sync.Mutex
+	// m are keys with values or waiters, behind lock
+	m map[K]*awaitableEntry[V]
+	// isClosed is true after Close, behind lock
+	isClosed bool
+}
+
+// awaitableEntry is a key of [AwaitableMap]
+type awaitableEntry[V any] struct {
+	// ch closes when value is put or the entry is removed
+	ch chan struct{}
+	// value is valid if hasValue, behind lock
+	value V
+	// hasValue is true once value was put, behind lock
+	hasValue bool
+	// err is why the entry was removed without value, behind lock
+	err error
+	// waiters is the number of threads waiting for value, behind lock
+	waiters int
+}
+
+// Put stores value for key releasing any waiters
+//   - an existing value is replaced
+//   - Put after Close is ignored
+func (m *AwaitableMap[K, V]) Put(key K, value V) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.isClosed {
+		return // closed return
+	}
+	var entry = m.entry(key)
+	entry.value = value
+	if !entry.hasValue {
+		entry.hasValue = true
+		close(entry.ch)
+	}
+}
+
+// Get returns the value of key without waiting
+func (m *AwaitableMap[K, V]) Get(key K) (value V, hasValue bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if entry := m.m[key]; entry != nil && entry.hasValue {
+		value = entry.value
+		hasValue = true
+	}
+	return
+}
+
+// Await returns the value of key once it has been put
+//   - err: ctx error, [ErrAwaitableKeyDeleted] or [ErrAwaitableMapClosed]
+//   - a timeout is provided by [context.WithTimeout]
+func (m *AwaitableMap[K, V]) Await(ctx context.Context, key K) (value V, err error) {
+	return m.await(ctx, key, false)
+}
+
+// Take returns the value of key once it has been put and
+// removes key from the map
+//   - only one of several threads taking the same key receives the value,
+//     others return [ErrAwaitableKeyDeleted]
+//   - err: ctx error, [ErrAwaitableKeyDeleted] or [ErrAwaitableMapClosed]
+func (m *AwaitableMap[K, V]) Take(ctx context.Context, key K) (value V, err error) {
+	return m.await(ctx, key, true)
+}
+
+// Delete removes key
+//   - threads waiting for a value of key return [ErrAwaitableKeyDeleted]
+//   - hadValue: key had a value
+func (m *AwaitableMap[K, V]) Delete(key K) (hadValue bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var entry = m.m[key]
+	if entry == nil {
+		return // key not present return
+	}
+	hadValue = entry.hasValue
+	m.remove(key, entry, ErrAwaitableKeyDeleted)
+
+	return
+}
+
+// Len returns the number of keys with values
+func (m *AwaitableMap[K, V]) Len() (length int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, entry := range m.m {
+		if entry.hasValue {
+			length++
+		}
+	}
+	return
+}
+
+// Close makes waiters return [ErrAwaitableMapClosed] and discards all keys
+//   - idempotent
+func (m *AwaitableMap[K, V]) Close() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.isClosed {
+		return // already closed return
+	}
+	m.isClosed = true
+	for key, entry := range m.m {
+		m.remove(key, entry, ErrAwaitableMapClosed)
+	}
+}
+
+// await waits for key
+//   - isTake: key is removed once its value is returned
+func (m *AwaitableMap[K, V]) await(ctx context.Context, key K, isTake bool) (value V, err error) {
+	m.lock.Lock()
+	if m.isClosed {
+		m.lock.Unlock()
+		err = ErrAwaitableMapClosed
+		return // closed return
+	}
+	var entry = m.entry(key)
+	entry.waiters++
+	m.lock.Unlock()
+
+	var isDone bool
+	select {
+	case <-entry.ch:
+	case <-ctx.Done():
+		isDone = true
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	entry.waiters--
+	if isDone && !entry.hasValue {
+		// remove entry without value or waiters
+		if entry.waiters == 0 && entry.err == nil && m.m[key] == entry {
+			delete(m.m, key)
+		}
+		err = ctx.Err()
+		return // context canceled return
+	} else if !entry.hasValue {
+		err = entry.err
+		return // removed without value return
+	} else if isTake && m.m[key] != entry {
+		err = ErrAwaitableKeyDeleted
+		return // taken by other thread return
+	}
+	value = entry.value
+	if isTake {
+		m.remove(key, entry, ErrAwaitableKeyDeleted)
+	}
+
+	return
+}
+
+// entry returns the entry for key, creating it if missing, behind lock
+func (m *AwaitableMap[K, V]) entry(key K) (entry *awaitableEntry[V]) {
+	if entry = m.m[key]; entry != nil {
+		return // existing entry return
+	}
+	if m.m == nil {
+		m.m = make(map[K]*awaitableEntry[V])
+	}
+	entry = &awaitableEntry[V]{ch: make(chan struct{})}
+	m.m[key] = entry
+
+	return
+}
+
+// remove removes entry of key releasing waiters with err, behind lock
+func (m *AwaitableMap[K, V]) remove(key K, entry *awaitableEntry[V], err error) {
+	delete(m.m, key)
+	if !entry.hasValue {
+		entry.err = err
+		close(entry.ch)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAwaitableMap(t *testing.T) {
+	//t.Error("Logging on")
+	var key1, key2, key3 = 1, 2, 3
+	var value1 = "one"
+	var ctx = context.Background()
+
+	var m AwaitableMap[int, string]
+	var value string
+	var hasValue bool
+	var err error
+
+	// Get of missing key
+	if _, hasValue = m.Get(key1); hasValue {
+		t.Error("Get hasValue")
+	}
+
+	// waiters should receive value put later
+	var results = make(chan string, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			var value, err = m.Await(ctx, key1)
+			if err != nil {
+				value = err.Error()
+			}
+			results <- value
+		}()
+	}
+	awaitWaiters(&m, key1, 2)
+	m.Put(key1, value1)
+	for i := 0; i < 2; i++ {
+		if value = <-results; value != value1 {
+			t.Errorf("Await %q exp %q", value, value1)
+		}
+	}
+	if value, hasValue = m.Get(key1); !hasValue || value != value1 {
+		t.Errorf("Get %q %t exp %q", value, hasValue, value1)
+	}
+
+	// Take removes key
+	if value, err = m.Take(ctx, key1); err != nil || value != value1 {
+		t.Errorf("Take %q %v exp %q", value, err, value1)
+	}
+	if m.Len() != 0 {
+		t.Errorf("Len %d exp 0", m.Len())
+	}
+
+	// context timeout
+	var timeoutCtx, cancel = context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	if _, err = m.Await(timeoutCtx, key2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Await timeout err %v", err)
+	}
+
+	// Delete releases waiters
+	var errCh = make(chan error, 1)
+	go func() {
+		var _, err = m.Await(ctx, key2)
+		errCh <- err
+	}()
+	awaitWaiters(&m, key2, 1)
+	m.Delete(key2)
+	if err = <-errCh; err != ErrAwaitableKeyDeleted {
+		t.Errorf("Delete err %v", err)
+	}
+
+	// Close releases waiters
+	go func() {
+		var _, err = m.Take(ctx, key3)
+		errCh <- err
+	}()
+	awaitWaiters(&m, key3, 1)
+	m.Close()
+	if err = <-errCh; err != ErrAwaitableMapClosed {
+		t.Errorf("Close err %v", err)
+	}
+	m.Put(key1, value1)
+	if _, hasValue = m.Get(key1); hasValue {
+		t.Error("Put after Close")
+	}
+}
+
+// awaitWaiters waits until key has count waiters
+func awaitWaiters[K comparable, V any](m *AwaitableMap[K, V], key K, count int) {
+	for {
+		m.lock.Lock()
+		var entry = m.m[key]
+		var waiters int
+		if entry != nil {
+			waiters = entry.waiters
+		}
+		m.lock.Unlock()
+		if waiters == count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
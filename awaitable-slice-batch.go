@@ -0,0 +1,76 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"time"
+)
+
+// AwaitBatch awaits a batch of values for write-coalescing consumers
+//   - returns when at least minItems values were received or
+//     maxWait has elapsed since the first value was received
+//   - minItems less than 1: 1
+//   - maxWait 0: no wait once a value was received
+//   - values nil: the slice is closed and empty.
+//     Closing is only detected if [AwaitableSlice.EmptyCh] was invoked
+//   - values are obtained using [AwaitableSlice.GetAll],
+//     ie. values can be more than minItems
+//   - values received while waiting are not available to other consumers
+//   - thread-safe
+//
+// Usage:
+//
+//	for {
+//	  var rows = rowQueue.AwaitBatch(100, 50*time.Millisecond)
+//	  if rows == nil {
+//	    return // queue closed
+//	  }
+//	  writeRows(rows)
+//	}
+func (s *AwaitableSlice[T]) AwaitBatch(minItems int, maxWait time.Duration) (values []T) {
+	if minItems < 1 {
+		minItems = 1
+	}
+
+	// endCh awaits close
+	//	- only closes once EmptyCh was invoked
+	var endCh = s.isEmpty.Ch()
+
+	// ensure data-wait is initialized
+	if !s.dataWait.IsActive.Load() {
+		s.DataWaitCh()
+	}
+	var dataWait = &s.dataWait.Cyclic
+
+	// timerC is non-nil once the first value was received
+	var timerC <-chan time.Time
+	for {
+
+		// collect available values
+		if batch := s.GetAll(); len(batch) > 0 {
+			if values == nil {
+				values = batch
+			} else {
+				values = append(values, batch...)
+			}
+			if len(values) >= minItems || maxWait <= 0 {
+				return // batch complete return
+			} else if timerC == nil {
+				var timer = time.NewTimer(maxWait)
+				defer timer.Stop()
+				timerC = timer.C
+			}
+		}
+
+		select {
+		case <-endCh:
+			return // closed return: values may be partial batch or nil
+		case <-timerC:
+			return // maxWait elapsed return
+		case <-dataWait.Ch():
+		}
+	}
+}
@@ -0,0 +1,71 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAwaitableSliceAwaitBatch(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		minItems = 3
+		maxWait  = time.Millisecond
+	)
+	var exp = []int{1, 2, 3}
+
+	var slice AwaitableSlice[int]
+	var values []int
+
+	// minItems available: returns immediately
+	slice.SendSlice(slices.Clone(exp))
+	if values = slice.AwaitBatch(minItems, time.Hour); !slices.Equal(values, exp) {
+		t.Errorf("AwaitBatch %v exp %v", values, exp)
+	}
+
+	// values sent while waiting are coalesced
+	go func() {
+		slice.Send(exp[0])
+		slice.Send(exp[1])
+		slice.Send(exp[2])
+	}()
+	if values = slice.AwaitBatch(minItems, time.Hour); !slices.Equal(values, exp) {
+		t.Errorf("AwaitBatch coalesce %v exp %v", values, exp)
+	}
+
+	// maxWait returns partial batch
+	slice.Send(exp[0])
+	var t0 = time.Now()
+	if values = slice.AwaitBatch(minItems, maxWait); !slices.Equal(values, exp[:1]) {
+		t.Errorf("AwaitBatch partial %v exp %v", values, exp[:1])
+	}
+	if d := time.Since(t0); d < maxWait {
+		t.Errorf("AwaitBatch returned after %s exp %s", d, maxWait)
+	}
+
+	// closed and empty: nil
+	slice.EmptyCh()
+	if values = slice.AwaitBatch(minItems, maxWait); values != nil {
+		t.Errorf("AwaitBatch closed %v exp nil", values)
+	}
+
+	// EmptyCh invoked while awaiting: nil
+	var slice2 AwaitableSlice[int]
+	var result = make(chan []int, 1)
+	go func() { result <- slice2.AwaitBatch(minItems, time.Hour) }()
+	time.Sleep(time.Millisecond)
+	slice2.EmptyCh()
+	select {
+	case values = <-result:
+		if values != nil {
+			t.Errorf("AwaitBatch EmptyCh %v exp nil", values)
+		}
+	case <-time.After(time.Second):
+		t.Error("AwaitBatch did not return on EmptyCh")
+	}
+}
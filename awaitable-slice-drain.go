@@ -0,0 +1,38 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+var _ DrainCloser = &AwaitableSlice[int]{}
+
+// BeginClose stops accepting values: first phase of [DrainCloser]
+//   - subsequent Send SendSlice SendClone discard their values
+//   - values already sent remain available to Get GetSlice GetAll
+//   - BeginClose enables closing of [AwaitableSlice.EmptyCh]
+//   - a Send concurrent with BeginClose is either enqueued or discarded
+//   - thread-safe, idempotent
+func (s *AwaitableSlice[T]) BeginClose() {
+	if !s.isClosing.Load() {
+		s.queueLock.Lock()
+		s.isClosing.Store(true)
+		s.queueLock.Unlock()
+	}
+	s.EmptyCh()
+}
+
+// Drained returns a channel that closes once the slice is closed and empty
+//   - Drained is the channel of [AwaitableSlice.EmptyCh]
+//     without enabling close, ie. it closes following
+//     [AwaitableSlice.BeginClose] or EmptyCh and all values being consumed
+//   - thread-safe
+func (s *AwaitableSlice[T]) Drained() (ch AwaitableCh) { return s.EmptyCh(CloseAwaiter) }
+
+// FinalClose closes the slice discarding any remaining values
+//   - Drained is closed on return
+//   - thread-safe, idempotent
+func (s *AwaitableSlice[T]) FinalClose() {
+	s.BeginClose()
+	s.GetAll()
+}
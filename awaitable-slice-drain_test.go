@@ -0,0 +1,52 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAwaitableSliceDrain(t *testing.T) {
+	//t.Error("Logging on")
+	var exp = []int{1, 2}
+
+	var slice AwaitableSlice[int]
+	var values []int
+
+	// BeginClose stops accepting values
+	slice.SendClone(exp)
+	slice.BeginClose()
+	slice.Send(3)
+	slice.SendSlice([]int{4})
+	slice.SendClone([]int{5})
+	select {
+	case <-slice.Drained():
+		t.Error("Drained with values")
+	default:
+	}
+
+	// values sent prior to BeginClose remain available
+	if values = slice.GetAll(); !slices.Equal(values, exp) {
+		t.Errorf("GetAll %v exp %v", values, exp)
+	}
+	select {
+	case <-slice.Drained():
+	default:
+		t.Error("Drained not closed")
+	}
+
+	// FinalClose discards values
+	slice = AwaitableSlice[int]{}
+	slice.SendClone(exp)
+	slice.FinalClose()
+	if !slice.IsClosed() {
+		t.Error("FinalClose not closed")
+	}
+	if _, hasValue := slice.Get(); hasValue {
+		t.Error("FinalClose value")
+	}
+}
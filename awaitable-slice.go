@@ -26,6 +26,8 @@ import (
 //   - [AwaitableSlice.EmptyCh] returns a channel that closes on slice empty,
 //     configurable to provide close-like behavior
 //   - [AwaitableSlice.SetSize] allows for setting initial slice capacity
+//   - [AwaitableSlice.BeginClose] [AwaitableSlice.Drained] [AwaitableSlice.FinalClose]
+//     implement the two-phase close of [DrainCloser]
 //   - AwaitableSlice benefits:
 //   - — #1 many-to-many thread-synchronization mechanic
 //   - — #2 trouble-free, closable value-sink: non-blocking unbound send, near-non-deadlocking, panic-free and error-free object
@@ -141,12 +143,24 @@ type AwaitableSlice[T any] struct {
 	isEmptyWait Awaitable
 	// true if slice is closed
 	isEmpty Awaitable
+	// isClosing is true if BeginClose was invoked:
+	// Send SendSlice discard values
+	//	- written behind queueLock
+	isClosing atomic.Bool
 }
 
 // Send enqueues a single value. Thread-safe
+//   - after [AwaitableSlice.BeginClose], value is discarded
 func (s *AwaitableSlice[T]) Send(value T) {
-	defer s.postSend()
+	if s.isClosing.Load() {
+		return // discard after BeginClose
+	}
 	s.queueLock.Lock()
+	if s.isClosing.Load() {
+		s.queueLock.Unlock()
+		return // discard after BeginClose
+	}
+	defer s.postSend()
 
 	// add to queue if no slices
 	if len(s.slices) == 0 {
@@ -186,14 +200,19 @@ func (s *AwaitableSlice[T]) Send(value T) {
 
 // SendSlice provides values by transferring ownership of a slice to the queue
 //   - SendSlice may reduce allocations and increase performance by handling multiple values
+//   - after [AwaitableSlice.BeginClose], values are discarded
 //   - Thread-safe
 func (s *AwaitableSlice[T]) SendSlice(values []T) {
-	// ignore empty slice
-	if len(values) == 0 {
+	// ignore empty slice or after BeginClose
+	if len(values) == 0 || s.isClosing.Load() {
 		return
 	}
-	defer s.postSend()
 	s.queueLock.Lock()
+	if s.isClosing.Load() {
+		s.queueLock.Unlock()
+		return // discard after BeginClose
+	}
+	defer s.postSend()
 
 	// append to slices
 	s.slices = append(s.slices, values)
@@ -204,8 +223,8 @@ func (s *AwaitableSlice[T]) SendSlice(values []T) {
 //   - allocation
 //   - Thread-safe
 func (s *AwaitableSlice[T]) SendClone(values []T) {
-	// ignore empty slice
-	if len(values) == 0 {
+	// ignore empty slice or after BeginClose
+	if len(values) == 0 || s.isClosing.Load() {
 		return
 	}
 	s.SendSlice(slices.Clone(values))
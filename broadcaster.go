@@ -0,0 +1,237 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// OverflowDropNewest discards values sent to a full subscriber
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued value of a full subscriber
+	OverflowDropOldest
+	// OverflowDetach closes and detaches a full subscriber
+	OverflowDetach
+)
+
+// OverflowPolicy is what happens when a subscriber’s queue is full
+//   - [OverflowDropNewest] [OverflowDropOldest] [OverflowDetach]
+type OverflowPolicy uint8
+
+// SubscribeConfig configures a subscription of [Broadcaster]
+type SubscribeConfig struct {
+	// MaxQueue is the maximum number of queued values
+	//	- 0: unbound
+	MaxQueue int
+	// Overflow is the policy when MaxQueue values are queued
+	//	- default OverflowDropNewest
+	Overflow OverflowPolicy
+}
+
+// Broadcaster is a fan-out source: every value sent is received by
+// every attached subscriber
+//   - subscribers attach at any time using [Broadcaster.Subscribe] and
+//     receive values sent after that
+//   - each subscriber has its own [AwaitableSlice] queue:
+//     a slow subscriber does not block the producer or other subscribers
+//   - a subscriber may limit its queue with an overflow policy
+//   - a subscriber is detached when it closes its subscription
+//   - [Broadcaster.Close] closes all subscriptions after
+//     queued values are received
+//   - initialization-free, thread-safe
+//
+// Usage:
+//
+//	var statuses parl.Broadcaster[Status]
+//	var subscription = statuses.Subscribe(parl.SubscribeConfig{MaxQueue: 10, Overflow: parl.OverflowDropOldest})
+//	defer subscription.Close()
+//	go func() { statuses.Send(status) }()
+//	for status := subscription.Init(); subscription.Condition(&status); {
+//	  …
+type Broadcaster[T any] struct {
+	// lock makes writes to subscribers and isClosed thread-safe
+	lock sync.Mutex
+	// subscribers is copy-on-write list of subscriptions
+	//	- read atomically, written behind lock
+	subscribers atomic.Pointer[[]*Subscription[T]]
+	// isClosed is true after Close, written behind lock
+	isClosed atomic.Bool
+}
+
+// Subscription receives values from a [Broadcaster]
+//   - methods are similar to [AwaitableSlice]
+//   - thread-safe
+type Subscription[T any] struct {
+	broadcaster *Broadcaster[T]
+	config      SubscribeConfig
+	values      AwaitableSlice[T]
+	// count is number of queued values
+	count atomic.Int64
+	// dropped is number of values discarded by overflow policy
+	dropped atomic.Uint64
+}
+
+// Subscribe attaches a new subscriber
+//   - config: optional queue limit and overflow policy
+//   - on closed Broadcaster, the returned subscription is closed
+func (b *Broadcaster[T]) Subscribe(config ...SubscribeConfig) (subscription *Subscription[T]) {
+	var s = Subscription[T]{broadcaster: b}
+	if len(config) > 0 {
+		s.config = config[0]
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.isClosed.Load() {
+		s.values.EmptyCh()
+		return &s
+	}
+	var subscribers []*Subscription[T]
+	if sp := b.subscribers.Load(); sp != nil {
+		subscribers = slices.Clone(*sp)
+	}
+	subscribers = append(subscribers, &s)
+	b.subscribers.Store(&subscribers)
+
+	return &s
+}
+
+// Send delivers value to all subscribers
+//   - non-blocking
+//   - values sent after Close are discarded
+func (b *Broadcaster[T]) Send(value T) {
+	var sp = b.subscribers.Load()
+	if sp == nil {
+		return // no subscribers return
+	}
+	for _, s := range *sp {
+		if s.send(value) {
+			s.Close()
+		}
+	}
+}
+
+// Subscribers returns the number of attached subscribers
+func (b *Broadcaster[T]) Subscribers() (count int) {
+	if sp := b.subscribers.Load(); sp != nil {
+		count = len(*sp)
+	}
+	return
+}
+
+// Close closes all subscriptions
+//   - subscribers receive queued values prior to their subscription closing
+//   - idempotent
+func (b *Broadcaster[T]) Close() {
+	b.lock.Lock()
+	b.isClosed.Store(true)
+	var sp = b.subscribers.Swap(nil)
+	b.lock.Unlock()
+
+	if sp == nil {
+		return
+	}
+	for _, s := range *sp {
+		s.values.EmptyCh()
+	}
+}
+
+// Get returns one value if any
+func (s *Subscription[T]) Get() (value T, hasValue bool) {
+	if value, hasValue = s.values.Get(); hasValue {
+		s.count.Add(-1)
+	}
+	return
+}
+
+// GetAll returns all queued values
+func (s *Subscription[T]) GetAll() (values []T) {
+	values = s.values.GetAll()
+	s.count.Add(-int64(len(values)))
+	return
+}
+
+// AwaitValue blocks until a value is available or the subscription closes
+//   - hasValue false: the subscription is closed
+func (s *Subscription[T]) AwaitValue() (value T, hasValue bool) {
+	if value, hasValue = s.values.AwaitValue(); hasValue {
+		s.count.Add(-1)
+	}
+	return
+}
+
+// DataWaitCh returns a channel that closes when values are available
+func (s *Subscription[T]) DataWaitCh() (ch AwaitableCh) { return s.values.DataWaitCh() }
+
+// Init allows for Subscription to be used in a for clause
+func (s *Subscription[T]) Init() (value T) { return }
+
+// Condition allows for Subscription to be used in a for clause
+//   - hasValue false: the subscription is closed
+func (s *Subscription[T]) Condition(valuep *T) (hasValue bool) {
+	if hasValue = s.values.Condition(valuep); hasValue {
+		s.count.Add(-1)
+	}
+	return
+}
+
+// EmptyCh returns a channel that closes when the subscription
+// is closed and all values were received
+func (s *Subscription[T]) EmptyCh() (ch AwaitableCh) { return s.values.EmptyCh(CloseAwaiter) }
+
+// IsClosed returns true if the subscription is closed
+func (s *Subscription[T]) IsClosed() (isClosed bool) { return s.values.IsClosed() }
+
+// Dropped returns the number of values discarded due to overflow
+func (s *Subscription[T]) Dropped() (dropped uint64) { return s.dropped.Load() }
+
+// Close detaches the subscription from the broadcaster
+//   - queued values can still be received
+//   - idempotent
+func (s *Subscription[T]) Close() {
+	s.values.EmptyCh()
+
+	var b = s.broadcaster
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var sp = b.subscribers.Load()
+	if sp == nil {
+		return // broadcaster closed return
+	}
+	var index = slices.Index(*sp, s)
+	if index == -1 {
+		return // already detached return
+	}
+	var subscribers = slices.Delete(slices.Clone(*sp), index, index+1)
+	b.subscribers.Store(&subscribers)
+}
+
+// send queues value according to overflow policy
+//   - isDetach: the subscription should be detached
+func (s *Subscription[T]) send(value T) (isDetach bool) {
+	if s.values.IsClosed() {
+		return true // closed by subscriber
+	}
+	if max := s.config.MaxQueue; max > 0 && s.count.Load() >= int64(max) {
+		s.dropped.Add(1)
+		switch s.config.Overflow {
+		case OverflowDropOldest:
+			s.Get()
+		case OverflowDetach:
+			return true
+		default:
+			return // value dropped return
+		}
+	}
+	s.count.Add(1)
+	s.values.Send(value)
+
+	return
+}
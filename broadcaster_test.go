@@ -0,0 +1,70 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBroadcaster(t *testing.T) {
+	//t.Error("Logging on")
+	var b Broadcaster[int]
+
+	// send without subscribers
+	b.Send(0)
+
+	var all = b.Subscribe()
+	var newest = b.Subscribe(SubscribeConfig{MaxQueue: 2})
+	var oldest = b.Subscribe(SubscribeConfig{MaxQueue: 2, Overflow: OverflowDropOldest})
+	var detach = b.Subscribe(SubscribeConfig{MaxQueue: 2, Overflow: OverflowDetach})
+	if n := b.Subscribers(); n != 4 {
+		t.Errorf("Subscribers %d exp 4", n)
+	}
+	for i := 1; i <= 3; i++ {
+		b.Send(i)
+	}
+
+	// every subscriber receives according to policy
+	if values := all.GetAll(); !slices.Equal(values, []int{1, 2, 3}) {
+		t.Errorf("all %v exp [1 2 3]", values)
+	}
+	if values := newest.GetAll(); !slices.Equal(values, []int{1, 2}) {
+		t.Errorf("newest %v exp [1 2]", values)
+	}
+	if values := oldest.GetAll(); !slices.Equal(values, []int{2, 3}) {
+		t.Errorf("oldest %v exp [2 3]", values)
+	}
+	if d := oldest.Dropped(); d != 1 {
+		t.Errorf("Dropped %d exp 1", d)
+	}
+	if values := detach.GetAll(); !slices.Equal(values, []int{1, 2}) || !detach.IsClosed() {
+		t.Errorf("detach %v closed %t exp [1 2] true", values, detach.IsClosed())
+	}
+	if n := b.Subscribers(); n != 3 {
+		t.Errorf("Subscribers after overflow detach %d exp 3", n)
+	}
+
+	// subscriber Close detaches
+	newest.Close()
+	if n := b.Subscribers(); n != 2 {
+		t.Errorf("Subscribers after Close %d exp 2", n)
+	}
+
+	// Close: queued values are received, then closed
+	b.Send(4)
+	b.Close()
+	var value, hasValue = all.AwaitValue()
+	if !hasValue || value != 4 {
+		t.Errorf("AwaitValue %d %t exp 4 true", value, hasValue)
+	}
+	if _, hasValue = all.AwaitValue(); hasValue {
+		t.Error("AwaitValue after Close hasValue")
+	}
+	if s := b.Subscribe(); !s.IsClosed() {
+		t.Error("Subscribe after Close not closed")
+	}
+}
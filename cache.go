@@ -0,0 +1,380 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// CacheConfig configures [NewCache]
+type CacheConfig[K comparable, V any] struct {
+	// MaxEntries is the maximum number of entries, 0: unlimited.
+	// Beyond MaxEntries the least-recently used entry is evicted
+	MaxEntries int
+	// TTL is default time-to-live of entries, 0: no expiry
+	TTL time.Duration
+	// OnEvict is invoked for entries removed due to capacity, expiry,
+	// [Cache.Delete] or [Cache.Clear]
+	//	- not invoked for entries replaced by [Cache.Put]
+	//	- invoked without lock held, may be nil
+	OnEvict func(key K, value V)
+	// Load obtains the value of a key missing in the cache
+	//	- used by [Cache.GetOrLoad]
+	//	- concurrent GetOrLoad of the same key invokes Load once
+	Load func(key K) (value V, err error)
+}
+
+// CacheStats are counters of a [Cache]
+type CacheStats struct {
+	// Hits is the number of lookups that found a valid entry
+	Hits uint64
+	// Misses is the number of lookups that found no entry or an expired entry
+	Misses uint64
+	// Loads is the number of Load invocations
+	Loads uint64
+	// LoadErrors is the number of Load invocations that failed
+	LoadErrors uint64
+	// Evictions is the number of entries evicted by capacity or expiry
+	Evictions uint64
+}
+
+// Cache is a least-recently used cache with optional time-to-live
+//   - a lookup makes the found entry newest.
+//     When full, the oldest entry is evicted
+//   - expired entries are removed on lookup or when they are oldest
+//   - [Cache.GetOrLoad] loads missing keys in single-flight
+//   - evicted entries are reused so that a full cache does not allocate on Put
+//   - thread-safe
+//
+// Usage:
+//
+//	var cache = parl.NewCache(parl.CacheConfig[string, netip.Addr]{
+//	  MaxEntries: 1000,
+//	  TTL:        time.Minute,
+//	  Load:       resolve,
+//	})
+//	…
+//	var addr, err = cache.GetOrLoad(hostname)
+type Cache[K comparable, V any] struct {
+	config CacheConfig[K, V]
+	lock   sync.Mutex
+	// m maps keys to entries, behind lock
+	m map[K]*cacheEntry[K, V]
+	// newest is the most-recently used entry, behind lock
+	newest *cacheEntry[K, V]
+	// oldest is the least-recently used entry, behind lock
+	oldest *cacheEntry[K, V]
+	// loads are Load invocations in progress, behind lock
+	loads map[K]*cacheLoad[V]
+	// stats behind lock
+	stats CacheStats
+}
+
+// cacheEntry is an element of the Cache’s doubly-linked list
+type cacheEntry[K comparable, V any] struct {
+	key   K
+	value V
+	// expires is expiry time, zero-value: no expiry
+	expires time.Time
+	// newer is towards newest, nil for newest
+	newer *cacheEntry[K, V]
+	// older is towards oldest, nil for oldest
+	older *cacheEntry[K, V]
+}
+
+// cacheLoad is a Load in progress
+type cacheLoad[V any] struct {
+	// isDone closes when value and err are valid
+	isDone Awaitable
+	value  V
+	err    error
+}
+
+// NewCache returns a least-recently used cache
+//   - config: optional configuration, default unlimited entries
+//     without expiry
+func NewCache[K comparable, V any](config ...CacheConfig[K, V]) (cache *Cache[K, V]) {
+	cache = &Cache[K, V]{m: make(map[K]*cacheEntry[K, V])}
+	if len(config) > 0 {
+		cache.config = config[0]
+	}
+	return
+}
+
+// Get returns the value for key and makes it newest
+//   - hasValue: false if key is missing or expired
+func (c *Cache[K, V]) Get(key K) (value V, hasValue bool) {
+	var evicted *cacheEntry[K, V]
+	defer c.onEvict(&evicted)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	value, hasValue, evicted = c.get(key, time.Now())
+	return
+}
+
+// Put inserts or replaces the value for key as newest
+//   - ttl: optional time-to-live overriding [CacheConfig.TTL], 0: no expiry
+//   - if the cache is full, the oldest entry is evicted
+func (c *Cache[K, V]) Put(key K, value V, ttl ...time.Duration) {
+	var evicted *cacheEntry[K, V]
+	defer c.onEvict(&evicted)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var d = c.config.TTL
+	if len(ttl) > 0 {
+		d = ttl[0]
+	}
+	evicted = c.put(key, value, d, time.Now())
+}
+
+// GetOrLoad returns the value for key, loading it if missing or expired
+//   - concurrent invocations for the same key await a single Load
+//   - a failed Load is not cached
+//   - err: from Load or a Load panic
+//   - panics if [CacheConfig.Load] is nil
+func (c *Cache[K, V]) GetOrLoad(key K) (value V, err error) {
+	if c.config.Load == nil {
+		panic(NilError("CacheConfig.Load"))
+	}
+	var evicted *cacheEntry[K, V]
+	defer c.onEvict(&evicted)
+	c.lock.Lock()
+
+	// cached value
+	var hasValue bool
+	if value, hasValue, evicted = c.get(key, time.Now()); hasValue {
+		c.lock.Unlock()
+		return // hit return
+	}
+
+	// await Load in progress
+	if load := c.loads[key]; load != nil {
+		c.lock.Unlock()
+		<-load.isDone.Ch()
+		return load.value, load.err
+	}
+
+	// this thread loads
+	var load = &cacheLoad[V]{}
+	if c.loads == nil {
+		c.loads = make(map[K]*cacheLoad[V])
+	}
+	c.loads[key] = load
+	c.stats.Loads++
+	c.lock.Unlock()
+
+	load.value, load.err = c.load(key)
+
+	c.lock.Lock()
+	delete(c.loads, key)
+	if load.err == nil {
+		var e = c.put(key, load.value, c.config.TTL, time.Now())
+		if evicted == nil {
+			evicted = e
+		} else if e != nil {
+			evicted.older = e
+		}
+	} else {
+		c.stats.LoadErrors++
+	}
+	c.lock.Unlock()
+	load.isDone.Close()
+
+	return load.value, load.err
+}
+
+// Delete removes key
+//   - OnEvict is invoked if key was present
+func (c *Cache[K, V]) Delete(key K) {
+	var evicted *cacheEntry[K, V]
+	defer c.onEvict(&evicted)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if entry := c.m[key]; entry != nil {
+		c.remove(entry)
+		evicted = entry
+	}
+}
+
+// Clear removes all entries
+//   - OnEvict is invoked for each entry oldest first
+func (c *Cache[K, V]) Clear() {
+	var evicted *cacheEntry[K, V]
+	defer c.onEvict(&evicted)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// the list is already linked from oldest by newer
+	if c.config.OnEvict != nil {
+		for entry := c.oldest; entry != nil; entry = entry.newer {
+			entry.older = entry.newer
+		}
+		evicted = c.oldest
+	}
+	c.newest, c.oldest = nil, nil
+	clear(c.m)
+}
+
+// Len returns the number of entries, including expired entries
+// not yet removed
+func (c *Cache[K, V]) Len() (length int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.m)
+}
+
+// Stats returns a snapshot of counters
+func (c *Cache[K, V]) Stats() (stats CacheStats) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.stats
+}
+
+// get looks up key making it newest
+//   - evicted: an expired entry, unlinked
+//   - invoked behind lock
+func (c *Cache[K, V]) get(key K, now time.Time) (value V, hasValue bool, evicted *cacheEntry[K, V]) {
+	var entry = c.m[key]
+	if entry == nil {
+		c.stats.Misses++
+		return // miss return
+	} else if c.isExpired(entry, now) {
+		c.remove(entry)
+		c.stats.Misses++
+		c.stats.Evictions++
+		evicted = entry
+		return // expired return
+	}
+	c.stats.Hits++
+	c.makeNewest(entry)
+	value, hasValue = entry.value, true
+
+	return
+}
+
+// put inserts or replaces key as newest
+//   - evicted: list of entries evicted by capacity or expiry linked by older,
+//     entries are copies if the evicted entry was reused
+//   - invoked behind lock
+func (c *Cache[K, V]) put(key K, value V, ttl time.Duration, now time.Time) (evicted *cacheEntry[K, V]) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = now.Add(ttl)
+	}
+
+	// replace
+	if entry := c.m[key]; entry != nil {
+		entry.value, entry.expires = value, expires
+		c.makeNewest(entry)
+		return // replace return
+	}
+
+	// evict expired oldest entries and the oldest entry if full
+	var reuse *cacheEntry[K, V]
+	for c.oldest != nil {
+		var oldest = c.oldest
+		if !c.isExpired(oldest, now) &&
+			(c.config.MaxEntries <= 0 || len(c.m) < c.config.MaxEntries) {
+			break
+		}
+		c.remove(oldest)
+		c.stats.Evictions++
+		if c.config.OnEvict != nil {
+			// OnEvict requires key and value after lock release
+			var e = *oldest
+			e.older = evicted
+			evicted = &e
+		}
+		reuse = oldest
+	}
+
+	// insert
+	var entry = reuse
+	if entry == nil {
+		entry = &cacheEntry[K, V]{}
+	}
+	*entry = cacheEntry[K, V]{key: key, value: value, expires: expires}
+	c.m[key] = entry
+	c.link(entry)
+
+	return
+}
+
+// load invokes Load recovering a panic
+func (c *Cache[K, V]) load(key K) (value V, err error) {
+	defer RecoverErr(func() DA { return A() }, &err)
+
+	if value, err = c.config.Load(key); perrors.IsPF(&err, "Load: %w", err) {
+		return
+	}
+	return
+}
+
+// onEvict invokes OnEvict for a list of entries linked by older
+//   - invoked without lock
+func (c *Cache[K, V]) onEvict(evictedp **cacheEntry[K, V]) {
+	if c.config.OnEvict == nil {
+		return
+	}
+	for entry := *evictedp; entry != nil; entry = entry.older {
+		c.config.OnEvict(entry.key, entry.value)
+	}
+}
+
+// isExpired returns true if entry has expired
+func (c *Cache[K, V]) isExpired(entry *cacheEntry[K, V], now time.Time) (isExpired bool) {
+	return !entry.expires.IsZero() && !now.Before(entry.expires)
+}
+
+// makeNewest moves entry to the newest end of the list
+func (c *Cache[K, V]) makeNewest(entry *cacheEntry[K, V]) {
+	if c.newest == entry {
+		return
+	}
+	c.unlink(entry)
+	c.link(entry)
+}
+
+// remove deletes entry from map and list
+//   - entry.older is nil on return
+func (c *Cache[K, V]) remove(entry *cacheEntry[K, V]) {
+	delete(c.m, entry.key)
+	c.unlink(entry)
+}
+
+// link inserts an unlinked entry as newest
+func (c *Cache[K, V]) link(entry *cacheEntry[K, V]) {
+	entry.older = c.newest
+	if c.newest != nil {
+		c.newest.newer = entry
+	} else {
+		c.oldest = entry
+	}
+	c.newest = entry
+}
+
+// unlink removes entry from the list
+func (c *Cache[K, V]) unlink(entry *cacheEntry[K, V]) {
+	if entry.newer != nil {
+		entry.newer.older = entry.older
+	} else {
+		c.newest = entry.older
+	}
+	if entry.older != nil {
+		entry.older.newer = entry.newer
+	} else {
+		c.oldest = entry.newer
+	}
+	entry.newer, entry.older = nil, nil
+}
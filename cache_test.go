@@ -0,0 +1,134 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	//t.Error("Logging on")
+	var evicted []int
+	var cache = NewCache(CacheConfig[int, string]{
+		MaxEntries: 2,
+		OnEvict:    func(key int, value string) { evicted = append(evicted, key) },
+	})
+
+	cache.Put(1, "one")
+	cache.Put(2, "two")
+	// Get makes 1 newest so 2 is evicted
+	if v, ok := cache.Get(1); !ok || v != "one" {
+		t.Errorf("Get 1: %q %t", v, ok)
+	}
+	cache.Put(3, "three")
+	if _, ok := cache.Get(2); ok {
+		t.Error("2 not evicted")
+	}
+	if len(evicted) != 1 || evicted[0] != 2 {
+		t.Errorf("evicted %v exp [2]", evicted)
+	}
+	if n := cache.Len(); n != 2 {
+		t.Errorf("Len %d exp 2", n)
+	}
+
+	// replace does not evict
+	cache.Put(3, "THREE")
+	if v, _ := cache.Get(3); v != "THREE" || len(evicted) != 1 {
+		t.Errorf("replace %q evicted %v", v, evicted)
+	}
+
+	var stats = cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Evictions != 1 {
+		t.Errorf("stats %+v", stats)
+	}
+
+	// Delete and Clear invoke OnEvict
+	cache.Delete(1)
+	cache.Put(4, "four")
+	cache.Clear()
+	if cache.Len() != 0 || len(evicted) != 4 ||
+		evicted[1] != 1 || evicted[2] != 3 || evicted[3] != 4 {
+		t.Errorf("Len %d evicted %v exp [2 1 3 4]", cache.Len(), evicted)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	//t.Error("Logging on")
+	var cache = NewCache(CacheConfig[int, int]{TTL: time.Millisecond})
+
+	cache.Put(1, 1)
+	cache.Put(2, 2, 0)
+	time.Sleep(2 * time.Millisecond)
+	if _, ok := cache.Get(1); ok {
+		t.Error("1 not expired")
+	}
+	if _, ok := cache.Get(2); !ok {
+		t.Error("2 without ttl expired")
+	}
+	if stats := cache.Stats(); stats.Evictions != 1 {
+		t.Errorf("Evictions %d exp 1", stats.Evictions)
+	}
+}
+
+func TestCacheGetOrLoad(t *testing.T) {
+	//t.Error("Logging on")
+	var errLoad = errors.New("load")
+	var loads atomic.Int32
+	var release = make(chan struct{})
+	var cache = NewCache(CacheConfig[int, int]{
+		Load: func(key int) (value int, err error) {
+			loads.Add(1)
+			<-release
+			if key < 0 {
+				err = errLoad
+			}
+			return key * 10, err
+		},
+	})
+
+	// concurrent loads of the same key invoke Load once
+	var n = 5
+	var wg sync.WaitGroup
+	var values = make([]int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], _ = cache.GetOrLoad(1)
+		}(i)
+	}
+	for cache.Stats().Misses < uint64(n) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if loads.Load() != 1 {
+		t.Errorf("loads %d exp 1", loads.Load())
+	}
+	for i, v := range values {
+		if v != 10 {
+			t.Errorf("value %d: %d exp 10", i, v)
+		}
+	}
+	if v, err := cache.GetOrLoad(1); err != nil || v != 10 || loads.Load() != 1 {
+		t.Errorf("cached %d %v loads %d", v, err, loads.Load())
+	}
+
+	// failure is not cached
+	if _, err := cache.GetOrLoad(-1); !errors.Is(err, errLoad) {
+		t.Errorf("err %v", err)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len %d exp 1", cache.Len())
+	}
+	if stats := cache.Stats(); stats.Loads != 2 || stats.LoadErrors != 1 {
+		t.Errorf("stats %+v", stats)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// FuturesAll: [CombineFutures] awaits all futures
+	FuturesAll FuturesCombine = iota
+	// FuturesAny: [CombineFutures] awaits the first future to resolve
+	FuturesAny
+)
+
+// FuturesCombine is how [CombineFutures] awaits futures
+//   - [FuturesAll] [FuturesAny]
+type FuturesCombine uint8
+
+// CombineFutures awaits all or any of futures
+//   - results: same length and order as futures.
+//     Elements are nil for futures not resolved
+//   - combine [FuturesAll]: on success, all elements of results are non-nil
+//   - combine [FuturesAny]: on success, at least one element of results is non-nil
+//   - err: only context cancel of ctx. Calculation errors are in results
+//   - no futures: returns immediately
+//   - blocks, no threads are launched. Thread-safe
+func CombineFutures[T any](ctx context.Context, combine FuturesCombine, futures ...*Future[T]) (results []*TResult[T], err error) {
+	results = make([]*TResult[T], len(futures))
+	if len(futures) == 0 {
+		return // no futures return
+	}
+	var done = ctx.Done()
+
+	if combine == FuturesAll {
+		for i, future := range futures {
+			select {
+			case <-future.Ch():
+			case <-done:
+				err = perrors.ErrorfPF("CombineFutures: %w", context.Cause(ctx))
+				collectFutures(futures, results)
+				return // context cancel return
+			}
+			results[i] = future.TResult()
+		}
+		return // all resolved return
+	}
+
+	// await any
+	var cases = make([]reflect.SelectCase, len(futures)+1)
+	cases[0] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(done)}
+	for i, future := range futures {
+		cases[i+1] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(future.Ch())}
+	}
+	if chosen, _, _ := reflect.Select(cases); chosen == 0 {
+		err = perrors.ErrorfPF("CombineFutures: %w", context.Cause(ctx))
+	}
+	collectFutures(futures, results)
+
+	return
+}
+
+// collectFutures stores the results of resolved futures
+func collectFutures[T any](futures []*Future[T], results []*TResult[T]) {
+	for i, future := range futures {
+		results[i] = future.TResult()
+	}
+}
@@ -0,0 +1,217 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// MetricCounter is a monotonic [ShardedCounter]
+	MetricCounter MetricKind = iota + 1
+	// MetricGauge is a [Gauge] that can go up and down
+	MetricGauge
+	// MetricHistogram is a [Histogram] of observations
+	MetricHistogram
+)
+
+// MetricKind is the type of a registered metric
+//   - [MetricCounter] [MetricGauge] [MetricHistogram]
+type MetricKind uint8
+
+// CounterRegistry is a thread-safe collection of named, labeled metrics
+//   - counters are sharded to avoid cache-line contention
+//   - labels are key-value pairs: a name with different labels
+//     is a different metric
+//   - [CounterRegistry.Snapshot] exports all metrics in order
+//   - [CounterRegistry.Expvar] adapts to the [expvar] package
+//   - the get-or-create methods are intended to be invoked once
+//     and the returned metric retained for updates
+//   - thread-safe
+//
+// Usage:
+//
+//	var metrics = parl.NewCounterRegistry()
+//	var requests = metrics.Counter("requests", "method", "GET")
+//	var latency = metrics.Histogram("latency_ms", []float64{1, 10, 100})
+//	…
+//	requests.Inc()
+//	latency.Observe(float64(time.Since(t0).Milliseconds()))
+//	…
+//	expvar.Publish("parl", expvar.Func(metrics.Expvar()))
+type CounterRegistry struct {
+	lock sync.RWMutex
+	// metrics is map of metric key to metric, behind lock
+	metrics map[string]*registryMetric
+}
+
+// registryMetric is a registered metric
+type registryMetric struct {
+	name      string
+	labels    []string
+	kind      MetricKind
+	counter   *ShardedCounter
+	gauge     *Gauge
+	histogram *Histogram
+}
+
+// MetricSample is a metric value in a [CounterRegistry.Snapshot]
+type MetricSample struct {
+	// Name is the metric name
+	Name string
+	// Labels are key-value pairs
+	Labels []string `json:",omitempty"`
+	// Kind is counter, gauge or histogram
+	Kind MetricKind
+	// Value is the value of a counter or gauge
+	Value int64
+	// Bounds are upper bounds of histogram buckets
+	Bounds []float64 `json:",omitempty"`
+	// Buckets are counts of histogram buckets
+	Buckets []uint64 `json:",omitempty"`
+	// Count is number of histogram observations
+	Count uint64 `json:",omitempty"`
+	// Sum is the sum of histogram observations
+	Sum float64 `json:",omitempty"`
+}
+
+// NewCounterRegistry returns a collection of metrics
+func NewCounterRegistry() (registry *CounterRegistry) {
+	return &CounterRegistry{metrics: make(map[string]*registryMetric)}
+}
+
+// Counter returns a monotonic counter, creating it if it does not exist
+//   - labels: key-value pairs, must be even length or panic
+//   - a name and labels registered with a different kind panics
+func (r *CounterRegistry) Counter(name string, labels ...string) (counter *ShardedCounter) {
+	return r.getOrCreate(name, labels, MetricCounter, nil).counter
+}
+
+// Gauge returns a gauge, creating it if it does not exist
+//   - labels: key-value pairs, must be even length or panic
+func (r *CounterRegistry) Gauge(name string, labels ...string) (gauge *Gauge) {
+	return r.getOrCreate(name, labels, MetricGauge, nil).gauge
+}
+
+// Histogram returns a histogram, creating it with bounds if it does not exist
+//   - bounds: increasing bucket upper bounds. Ignored if the histogram exists
+//   - labels: key-value pairs, must be even length or panic
+func (r *CounterRegistry) Histogram(name string, bounds []float64, labels ...string) (histogram *Histogram) {
+	return r.getOrCreate(name, labels, MetricHistogram, bounds).histogram
+}
+
+// Snapshot returns the values of all metrics ordered by name and labels
+func (r *CounterRegistry) Snapshot() (samples []MetricSample) {
+	r.lock.RLock()
+	var keys = make([]string, 0, len(r.metrics))
+	for key := range r.metrics {
+		keys = append(keys, key)
+	}
+	var metrics = make([]*registryMetric, len(keys))
+	slices.Sort(keys)
+	for i, key := range keys {
+		metrics[i] = r.metrics[key]
+	}
+	r.lock.RUnlock()
+
+	samples = make([]MetricSample, len(metrics))
+	for i, m := range metrics {
+		var s = &samples[i]
+		s.Name = m.name
+		s.Labels = m.labels
+		s.Kind = m.kind
+		switch m.kind {
+		case MetricCounter:
+			s.Value = m.counter.Value()
+		case MetricGauge:
+			s.Value = m.gauge.Value()
+		case MetricHistogram:
+			s.Bounds = m.histogram.Bounds()
+			s.Buckets, s.Count, s.Sum = m.histogram.Buckets()
+		}
+	}
+
+	return
+}
+
+// Expvar returns a function for [expvar.Func] rendering Snapshot as JSON
+//   - publish using: expvar.Publish(name, expvar.Func(registry.Expvar()))
+//   - parl does not import expvar since it registers an http handler
+func (r *CounterRegistry) Expvar() (expvarFunc func() (value any)) {
+	return func() (value any) { return r.Snapshot() }
+}
+
+// getOrCreate returns a metric, creating it if it does not exist
+func (r *CounterRegistry) getOrCreate(name string, labels []string, kind MetricKind, bounds []float64) (m *registryMetric) {
+	if len(labels)%2 != 0 {
+		panic(perrors.ErrorfPF("metric %q labels not key-value pairs: %v", name, labels))
+	}
+	var key = metricKey(name, labels)
+
+	// fast path: metric exists
+	r.lock.RLock()
+	m = r.metrics[key]
+	r.lock.RUnlock()
+	if m == nil {
+		r.lock.Lock()
+		if m = r.metrics[key]; m == nil {
+			m = &registryMetric{name: name, labels: slices.Clone(labels), kind: kind}
+			switch kind {
+			case MetricCounter:
+				m.counter = &ShardedCounter{}
+			case MetricGauge:
+				m.gauge = &Gauge{}
+			case MetricHistogram:
+				m.histogram = NewHistogram(bounds...)
+			}
+			r.metrics[key] = m
+		}
+		r.lock.Unlock()
+	}
+	if m.kind != kind {
+		panic(perrors.ErrorfPF("metric %s is %s not %s", key, m.kind, kind))
+	}
+
+	return
+}
+
+// metricKey returns “name{key=value,…}”
+func metricKey(name string, labels []string) (key string) {
+	if len(labels) == 0 {
+		return name
+	}
+	var sb strings.Builder
+	sb.WriteString(name + "{")
+	for i := 0; i < len(labels); i += 2 {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(labels[i] + "=" + labels[i+1])
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// "counter" "gauge" "histogram"
+func (k MetricKind) String() (s string) {
+	switch k {
+	case MetricCounter:
+		return "counter"
+	case MetricGauge:
+		return "gauge"
+	case MetricHistogram:
+		return "histogram"
+	}
+	return Sprintf("?metricKind%d", k)
+}
+
+// MarshalJSON renders kind as its string
+func (k MetricKind) MarshalJSON() (data []byte, err error) { return json.Marshal(k.String()) }
@@ -0,0 +1,76 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCounterRegistry(t *testing.T) {
+	//t.Error("Logging on")
+	const threads, increments = 8, 1000
+
+	var registry = NewCounterRegistry()
+
+	// concurrent increments of a sharded counter
+	var wg sync.WaitGroup
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var counter = registry.Counter("requests", "method", "GET")
+			for j := 0; j < increments; j++ {
+				counter.Inc()
+			}
+		}()
+	}
+	wg.Wait()
+	registry.Gauge("open").Set(3)
+	var histogram = registry.Histogram("latency", []float64{1, 10})
+	for _, v := range []float64{0.5, 5, 50, 10} {
+		histogram.Observe(v)
+	}
+
+	var samples = registry.Snapshot()
+	if len(samples) != 3 {
+		t.Fatalf("samples %d exp 3", len(samples))
+	}
+	// order: latency open requests{method=GET}
+	var h, g, c = samples[0], samples[1], samples[2]
+	if c.Kind != MetricCounter || c.Value != threads*increments || !slices.Equal(c.Labels, []string{"method", "GET"}) {
+		t.Errorf("counter %+v", c)
+	}
+	if g.Kind != MetricGauge || g.Value != 3 {
+		t.Errorf("gauge %+v", g)
+	}
+	if h.Kind != MetricHistogram || !slices.Equal(h.Buckets, []uint64{1, 2, 1}) || h.Count != 4 || h.Sum != 65.5 {
+		t.Errorf("histogram %+v", h)
+	}
+
+	// expvar adapter renders JSON
+	var data, err = json.Marshal(registry.Expvar()())
+	if err != nil {
+		t.Fatalf("json err: %s", err)
+	}
+	if s := string(data); !strings.Contains(s, `"Kind":"histogram"`) {
+		t.Errorf("bad json: %s", s)
+	}
+}
+
+func TestCounterRegistryKindPanic(t *testing.T) {
+	var registry = NewCounterRegistry()
+	registry.Counter("x")
+	defer func() {
+		if recover() == nil {
+			t.Error("missing panic")
+		}
+	}()
+	registry.Gauge("x")
+}
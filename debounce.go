@@ -0,0 +1,265 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl/ptime"
+)
+
+const (
+	// max latency when trailing delay is disabled
+	defaultDebounceMaxLatency = time.Second
+)
+
+// DebounceConfig configures [Debounce]
+type DebounceConfig struct {
+	// Trailing is how long there must be no further values
+	// for a batch to be emitted
+	//	- 0: no trailing delay, batches are emitted by MaxLatency
+	Trailing time.Duration
+	// Leading emits the first value after an idle period immediately
+	// as a batch of one
+	//	- values arriving before the following trailing delay elapses
+	//		are batched as usual
+	Leading bool
+	// MaxLatency guarantees that a value is emitted within MaxLatency
+	// even if input never pauses
+	//	- 0: no max latency or if Trailing is 0: 1 s
+	MaxLatency time.Duration
+	// Clock is optional clock, default [ptime.SystemClock]
+	Clock ptime.Clock
+}
+
+// Debounce[T] aggregates values into batches
+//   - generic successor of [Debouncer] with a Send method rather
+//     than an input channel and a single thread
+//   - a batch is emitted when no value has been sent for
+//     [DebounceConfig.Trailing]
+//   - [DebounceConfig.Leading] emits the first value after an idle period
+//     without delay
+//   - [DebounceConfig.MaxLatency] emits the batch once its oldest value
+//     reaches MaxLatency in age
+//   - batches are emitted in order by a single thread to sender.
+//     sender may be the Send method of an [AwaitableSlice] of slices
+//   - Close is required to release resources
+//
+// Usage:
+//
+//	var batches parl.AwaitableSlice[[]int]
+//	var debounce = parl.NewDebounce(batches.Send, errorSink, parl.DebounceConfig{
+//	  Trailing: 100 * time.Millisecond, MaxLatency: time.Second,
+//	})
+//	defer debounce.Close()
+//	debounce.Send(1)
+type Debounce[T any] struct {
+	// trailing is the trailing delay
+	trailing time.Duration
+	// leading is whether leading edge is emitted
+	leading bool
+	// maxLatency is maximum age of a pending value, 0 if not used
+	maxLatency time.Duration
+	// clock provides time and timer
+	clock ptime.Clock
+	// timer is when the next batch may be due
+	timer ptime.Timer
+	// sender receives batches
+	sender func(values []T)
+	// errorSink receives panics in sender
+	errorSink ErrorSink1
+	// wake makes the thread emit a leading value
+	wake chan struct{}
+	// closeCh closes on Close
+	closeCh chan struct{}
+	// exit is awaitable indicating thread exit
+	exit Awaitable
+
+	// lock makes the remaining fields thread-safe
+	lock sync.Mutex
+	// pending are values not yet emitted, behind lock
+	pending []T
+	// first is when the oldest pending value was sent, behind lock
+	first time.Time
+	// last is when a value was most recently sent, behind lock
+	last time.Time
+	// isActive is true from a value being sent until the trailing delay
+	// elapses with no more values, behind lock
+	isActive bool
+	// isLeadingDue indicates pending holds a leading value, behind lock
+	isLeadingDue bool
+	// isClosed indicates Close was invoked, behind lock
+	isClosed bool
+}
+
+// NewDebounce returns a debouncer emitting batches to sender
+//   - sender: receives batches, may be [AwaitableSlice.Send].
+//     Should not be blocking
+//   - errorSink: receives any panic in sender
+//   - config: optional configuration
+//   - —
+//   - NewDebounce launches a thread prior to return
+func NewDebounce[T any](sender func(values []T), errorSink ErrorSink1, config ...DebounceConfig) (debounce *Debounce[T]) {
+	if sender == nil {
+		panic(NilError("sender"))
+	} else if errorSink == nil {
+		panic(NilError("errorSink"))
+	}
+	var c DebounceConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.Trailing <= 0 && c.MaxLatency <= 0 {
+		c.MaxLatency = defaultDebounceMaxLatency
+	}
+	var d = Debounce[T]{
+		trailing:   max(c.Trailing, 0),
+		leading:    c.Leading,
+		maxLatency: max(c.MaxLatency, 0),
+		clock:      ptime.GetClock(c.Clock),
+		sender:     sender,
+		errorSink:  errorSink,
+		wake:       make(chan struct{}, 1),
+		closeCh:    make(chan struct{}),
+	}
+	d.timer = d.clock.NewTimer(time.Second)
+	d.timer.Stop()
+	go d.thread()
+
+	return &d
+}
+
+// Send adds a value to the current batch
+//   - values sent after Close are discarded
+//   - thread-safe
+func (d *Debounce[T]) Send(value T) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.isClosed {
+		return // closed return
+	}
+	var now = d.clock.Now()
+	if len(d.pending) == 0 {
+		d.first = now
+	}
+	d.pending = append(d.pending, value)
+	d.last = now
+
+	// leading edge: emit immediately
+	if !d.isActive && d.leading {
+		d.isActive = true
+		d.isLeadingDue = true
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+		return
+	}
+	d.isActive = true
+
+	// do not disturb a due leading value
+	if d.isLeadingDue {
+		return
+	}
+	d.timer.Reset(d.deadline().Sub(now))
+}
+
+// Close emits any pending values and releases resources
+//   - Close does not return until the final batch was emitted
+//   - idempotent, thread-safe
+func (d *Debounce[T]) Close() {
+	d.lock.Lock()
+	if !d.isClosed {
+		d.isClosed = true
+		close(d.closeCh)
+	}
+	d.lock.Unlock()
+
+	<-d.exit.Ch()
+}
+
+// thread emits batches until Close
+func (d *Debounce[T]) thread() {
+	defer d.exit.Close()
+	defer Recover(func() DA { return A() }, NoErrp, d.errorSink)
+	defer d.timer.Stop()
+
+	for {
+		var isClose bool
+		select {
+		case <-d.wake:
+		case <-d.timer.C():
+		case <-d.closeCh:
+			isClose = true
+		}
+		if values := d.getBatch(isClose); len(values) > 0 {
+			d.emit(values)
+		}
+		if isClose {
+			return
+		}
+	}
+}
+
+// getBatch returns any due values and re-arms the timer
+//   - isClose: all pending values are due
+func (d *Debounce[T]) getBatch(isClose bool) (values []T) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var now = d.clock.Now()
+	if isClose || d.isLeadingDue || len(d.pending) > 0 && !now.Before(d.deadline()) {
+		values = d.pending
+		d.pending = nil
+		if isClose {
+			return // final batch return
+		}
+	}
+
+	if d.isLeadingDue {
+		// leading value emitted, trailing delay now follows
+		d.isLeadingDue = false
+		d.timer.Reset(d.trailing)
+		return
+	}
+
+	// pending values not yet due
+	if len(d.pending) > 0 {
+		d.timer.Reset(d.deadline().Sub(now))
+		return
+	}
+
+	// idle once trailing delay elapsed since the last value
+	if windowEnd := d.last.Add(d.trailing); now.Before(windowEnd) {
+		d.timer.Reset(windowEnd.Sub(now))
+	} else {
+		d.isActive = false
+	}
+
+	return
+}
+
+// deadline returns when pending values are due, behind lock
+func (d *Debounce[T]) deadline() (t time.Time) {
+	if d.trailing > 0 {
+		t = d.last.Add(d.trailing)
+	}
+	if d.maxLatency > 0 {
+		if latest := d.first.Add(d.maxLatency); t.IsZero() || latest.Before(t) {
+			t = latest
+		}
+	}
+	return
+}
+
+// emit invokes sender recovering a panic
+func (d *Debounce[T]) emit(values []T) {
+	defer Recover(func() DA { return A() }, NoErrp, d.errorSink)
+
+	d.sender(values)
+}
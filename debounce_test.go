@@ -0,0 +1,116 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl/ptime"
+)
+
+func TestDebounce(t *testing.T) {
+	var trailing = time.Second
+	var expValues = []int{1, 2}
+	var expValues2 = []int{3}
+
+	var clock = ptime.NewTestClock()
+	var receiver AwaitableSlice[[]int]
+	var debounce = NewDebounce(receiver.Send, newPanicOnError(), DebounceConfig{
+		Trailing: trailing,
+		Clock:    clock,
+	})
+
+	// values are held until trailing delay elapsed
+	debounce.Send(1)
+	debounce.Send(2)
+	clock.Advance(trailing - 1)
+	select {
+	case <-receiver.DataWaitCh():
+		t.Fatal("values emitted before trailing delay")
+	default:
+	}
+	clock.Advance(1)
+	<-receiver.DataWaitCh()
+	if actValues, _ := receiver.Get(); !slices.Equal(actValues, expValues) {
+		t.Errorf("bad batch: %v exp %v", actValues, expValues)
+	}
+
+	// Close emits pending values
+	debounce.Send(3)
+	debounce.Close()
+	if actValues, _ := receiver.Get(); !slices.Equal(actValues, expValues2) {
+		t.Errorf("bad Close batch: %v exp %v", actValues, expValues2)
+	}
+
+	// Send after Close is discarded
+	debounce.Send(4)
+	if _, hasValue := receiver.Get(); hasValue {
+		t.Error("value emitted after Close")
+	}
+}
+
+func TestDebounceMaxLatency(t *testing.T) {
+	var trailing = time.Second
+	var maxLatency = 3 * time.Second
+	var interval = 600 * time.Millisecond
+	var expValues = []int{0, 1, 2, 3, 4}
+
+	var clock = ptime.NewTestClock()
+	var t0 = clock.Now()
+	var receiver AwaitableSlice[[]int]
+	var debounce = NewDebounce(receiver.Send, newPanicOnError(), DebounceConfig{
+		Trailing:   trailing,
+		MaxLatency: maxLatency,
+		Clock:      clock,
+	})
+	defer debounce.Close()
+
+	// constant input never allows trailing delay to elapse
+	for i := 0; i < len(expValues); i++ {
+		debounce.Send(i)
+		clock.Advance(interval)
+	}
+	<-receiver.DataWaitCh()
+	if actValues, _ := receiver.Get(); !slices.Equal(actValues, expValues) {
+		t.Errorf("bad batch: %v exp %v", actValues, expValues)
+	}
+	if d := clock.Now().Sub(t0); d != maxLatency {
+		t.Errorf("emitted at %s exp %s", d, maxLatency)
+	}
+}
+
+func TestDebounceLeading(t *testing.T) {
+	var trailing = time.Second
+	var expValues1 = []int{1}
+	var expValues2 = []int{2, 3}
+
+	var clock = ptime.NewTestClock()
+	var receiver AwaitableSlice[[]int]
+	var debounce = NewDebounce(receiver.Send, newPanicOnError(), DebounceConfig{
+		Trailing: trailing,
+		Leading:  true,
+		Clock:    clock,
+	})
+	defer debounce.Close()
+
+	// first value is emitted without delay
+	debounce.Send(1)
+	<-receiver.DataWaitCh()
+	if actValues, _ := receiver.Get(); !slices.Equal(actValues, expValues1) {
+		t.Errorf("bad leading batch: %v exp %v", actValues, expValues1)
+	}
+
+	// following values are debounced
+	debounce.Send(2)
+	debounce.Send(3)
+	clock.Advance(trailing)
+	<-receiver.DataWaitCh()
+	if actValues, _ := receiver.Get(); !slices.Equal(actValues, expValues2) {
+		t.Errorf("bad trailing batch: %v exp %v", actValues, expValues2)
+	}
+}
@@ -56,7 +56,7 @@ type debouncerIn[T any] struct {
 	debounceInterval time.Duration
 	// how input-thread orders output thread to send
 	// on expired debounce period
-	debounceTimer *time.Timer
+	debounceTimer ptime.Timer
 	// is maxDelay timer is used
 	useMaxDelay bool
 	// when input thread receives a a value and max delay timer is not running,
@@ -65,7 +65,9 @@ type debouncerIn[T any] struct {
 	maxDelayRunning atomic.Bool
 	// how input-thread orders output thread to send
 	// on expired maxDelay period
-	maxDelayTimer ptime.ThreadSafeTimer
+	maxDelayTimer ptime.Timer
+	// maxDelay is duration for maxDelayTimer
+	maxDelay time.Duration
 	// how input thread receives shutdown
 	isShutdown *Awaitable
 	// how input thread emits an unforeseen panic
@@ -87,7 +89,7 @@ type debouncerOut[T any] struct {
 	//	- max delay timer then runs until output thread resets it.
 	maxDelayRunning *atomic.Bool
 	// maxDelayTimer timer expiring when output thread should send
-	maxDelayTimer *ptime.ThreadSafeTimer
+	maxDelayTimer ptime.Timer
 	// indicates that input thread exited
 	isInputExit AwaitableCh
 	// the output function receiving slices of values
@@ -111,6 +113,7 @@ type debouncerOut[T any] struct {
 //   - inputCh sender errFn cannot be nil
 //   - close of input channel or Shutdown is required to release resources
 //   - errFn should not receive any errors but will receive possible runtime panics
+//   - clock: optional clock, default [ptime.SystemClock]
 //   - —
 //   - NewDebouncer launches two threads prior to return
 func NewDebouncer[T any](
@@ -118,6 +121,7 @@ func NewDebouncer[T any](
 	inputCh <-chan T,
 	sender func([]T),
 	errorSink ErrorSink1,
+	clock ...ptime.Clock,
 ) (debouncer *Debouncer[T]) {
 	if inputCh == nil {
 		panic(NilError("inputCh"))
@@ -128,14 +132,12 @@ func NewDebouncer[T any](
 	}
 
 	var isShutdown Awaitable
+	var clock0 = ptime.GetClock(clock...)
 
 	// debounce timer expiring when output thread should send
-	var debounceTimer = time.NewTimer(time.Second)
-	// get timer ready for reset
+	var debounceTimer = clock0.NewTimer(time.Second)
+	// timer Reset includes stop and drain
 	debounceTimer.Stop()
-	if len(debounceTimer.C) > 0 {
-		<-debounceTimer.C
-	}
 
 	// 1 s default for maxDelay
 	if debounceInterval <= 0 && maxDelay <= 0 {
@@ -147,21 +149,18 @@ func NewDebouncer[T any](
 		debounceInterval: debounceInterval,
 		debounceTimer:    debounceTimer,
 		useMaxDelay:      maxDelay > 0,
-		maxDelayTimer:    *ptime.NewThreadSafeTimer(maxDelay),
+		maxDelayTimer:    clock0.NewTimer(time.Second),
+		maxDelay:         maxDelay,
 		isShutdown:       &isShutdown,
 		errorSink:        errorSink,
 	}
-	// get timer ready for reset
 	in.maxDelayTimer.Stop()
-	if len(in.maxDelayTimer.C) > 0 {
-		<-in.maxDelayTimer.C
-	}
 	out := debouncerOut[T]{
 		buffer:          &in.buffer,
-		debounceC:       debounceTimer.C,
+		debounceC:       debounceTimer.C(),
 		useMaxDelay:     in.useMaxDelay,
 		maxDelayRunning: &in.maxDelayRunning,
-		maxDelayTimer:   &in.maxDelayTimer,
+		maxDelayTimer:   in.maxDelayTimer,
 		isInputExit:     in.inputExit.Ch(),
 		sender:          sender,
 		isShutdown:      &isShutdown,
@@ -201,9 +200,6 @@ func (d *debouncerIn[T]) inputThread() {
 	defer d.debounceTimer.Stop()
 	defer d.buffer.EmptyCh() // close of buffer causes output thread to eventually exit
 
-	// debounce timer was started
-	var debounceTimerRunning bool
-
 	// read input channel and save values to unbound buffer
 	for {
 
@@ -226,25 +222,13 @@ func (d *debouncerIn[T]) inputThread() {
 		// a value was received. If max delay is used and not running,
 		// start it
 		if d.useMaxDelay && d.maxDelayRunning.CompareAndSwap(false, true) {
-			d.maxDelayTimer.Reset(0)
+			d.maxDelayTimer.Reset(d.maxDelay)
 		}
 
 		// if debounce timer is used,
 		// start or extend debounce timer
+		//	- Reset stops and drains the timer
 		if d.debounceInterval > 0 {
-			if debounceTimerRunning {
-				// get debounceTimer ready for reset
-				d.debounceTimer.Stop()
-				select {
-				case <-d.debounceTimer.C:
-				default:
-				}
-			} else {
-				debounceTimerRunning = true
-			}
-			// Reset should be invoked only on:
-			//	- stopped or expired timers
-			//	- with drained channels
 			d.debounceTimer.Reset(d.debounceInterval)
 		}
 	}
@@ -272,7 +256,7 @@ func (d *debouncerOut[T]) outputThread() {
 		// input thread starts the max delay timer upon receining a value
 		// and it is not running
 		//	- if it expires prior to debounce timer, it triggers a send here
-		case <-d.maxDelayTimer.C: // send due to max Delay reached
+		case <-d.maxDelayTimer.C(): // send due to max Delay reached
 		case <-d.isInputExit: // input thread did exit
 		case <-d.isShutdown.Ch():
 			return // shutdown received
@@ -99,6 +99,40 @@ func TestDebouncer(t *testing.T) {
 	//t.Fail()
 }
 
+func TestDebouncerClock(t *testing.T) {
+	var value1 = 3
+	var debouncePeriod = time.Second
+	var expValues = []int{value1}
+
+	var clock = ptime.NewTestClock()
+	var inputCh = make(chan int)
+	var receiver AwaitableSlice[[]int]
+	var debouncer = NewDebouncer(
+		debouncePeriod,
+		NoDebounceMaxDelay,
+		inputCh,
+		receiver.Send,
+		newPanicOnError(),
+		clock,
+	)
+	defer debouncer.Shutdown()
+
+	// value is held until debounce period elapsed
+	inputCh <- value1
+	clock.AwaitWaiters(1)
+	clock.Advance(debouncePeriod - 1)
+	select {
+	case <-receiver.DataWaitCh():
+		t.Fatal("value sent before debounce period")
+	default:
+	}
+	clock.Advance(1)
+	<-receiver.DataWaitCh()
+	if actValues, _ := receiver.Get(); !slices.Equal(actValues, expValues) {
+		t.Errorf("bad receive: %v exp %v", actValues, expValues)
+	}
+}
+
 type panicOnError struct{}
 
 func newPanicOnError() (errorSink ErrorSink) { return NewErrorSinkEndable(&panicOnError{}) }
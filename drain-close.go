@@ -0,0 +1,56 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import "context"
+
+// DrainClose closes sinks of a pipeline in order, upstream first
+//   - the first sink is closed by BeginClose.
+//     A thread forwarding values from a sink to the next sink is expected to
+//     BeginClose the next sink once its input is drained and its last value was sent.
+//     This ensures no value is lost in transit
+//   - each sink in order is awaited Drained, then FinalClose
+//   - ctx cancel: remaining sinks are closed by FinalClose without awaiting drain,
+//     discarding their values
+//   - err: nil if all sinks drained, otherwise [context.Cause] of ctx
+//   - nil sinks are ignored
+//
+// Usage:
+//
+//	var input, output parl.AwaitableSlice[*Value]
+//	go func() {
+//	  defer output.BeginClose()
+//	  for value := input.Init(); input.Condition(&value); {
+//	    output.Send(value)
+//	  }
+//	}()
+//	…
+//	err = parl.DrainClose(ctx, &input, &output)
+func DrainClose(ctx context.Context, sinks ...DrainCloser) (err error) {
+	if ctx == nil {
+		panic(NilError("ctx"))
+	}
+	var done = ctx.Done()
+	var isFirst = true
+	for _, sink := range sinks {
+		if sink == nil {
+			continue
+		} else if isFirst {
+			isFirst = false
+			sink.BeginClose()
+		}
+		if err == nil {
+			select {
+			case <-sink.Drained():
+			case <-done:
+				err = context.Cause(ctx)
+			}
+		}
+		sink.FinalClose()
+	}
+
+	return
+}
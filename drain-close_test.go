@@ -0,0 +1,57 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDrainClose(t *testing.T) {
+	//t.Error("Logging on")
+	var exp = []int{1, 2, 3}
+
+	var err error
+
+	// values are forwarded prior to output closing
+	var input, output AwaitableSlice[int]
+	input.SendClone(exp)
+	go func() {
+		defer output.BeginClose()
+		for value := input.Init(); input.Condition(&value); {
+			output.Send(value)
+		}
+	}()
+	var values []int
+	var isDone = make(chan struct{})
+	go func() {
+		defer close(isDone)
+		for value := output.Init(); output.Condition(&value); {
+			values = append(values, value)
+		}
+	}()
+	if err = DrainClose(context.Background(), &input, nil, &output); err != nil {
+		t.Errorf("DrainClose err: %s", err)
+	}
+	<-isDone
+	if !slices.Equal(values, exp) {
+		t.Errorf("values %v exp %v", values, exp)
+	}
+
+	// cancel: values are discarded
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	var slice AwaitableSlice[int]
+	slice.SendClone(exp)
+	if err = DrainClose(ctx, &slice); !errors.Is(err, context.Canceled) {
+		t.Errorf("DrainClose err: %v exp %v", err, context.Canceled)
+	}
+	if !slice.IsClosed() {
+		t.Error("slice not closed")
+	}
+}
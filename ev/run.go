@@ -0,0 +1,148 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+// Package ev provides goroutine-based functions with typed event streams.
+//
+// [Run] executes a function in a supervised thread of a parl thread-group
+// emitting progress, result and error events to an [parl.AwaitableSlice].
+// [Await] consumes the event stream.
+package ev
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+const (
+	// EventProgress is an intermediate value emitted by the function
+	EventProgress EventKind = iota + 1
+	// EventResult is the function’s result, the final event
+	EventResult
+	// EventError is the function’s error or panic, the final event
+	EventError
+)
+
+// EventKind is the type of an [Event]
+//   - [EventProgress] [EventResult] [EventError]
+type EventKind uint8
+
+// Event is an event of the stream returned by [Run]
+type Event[T any] struct {
+	// Kind is progress, result or error
+	Kind EventKind
+	// Value is a progress value or the result
+	Value T
+	// Err is the error for [EventError]
+	Err error
+	// Time is when the event occurred
+	Time time.Time
+}
+
+// Func is a function executed by [Run]
+//   - ctx: canceled when the thread-group is canceled
+//   - progress: emits progress events, thread-safe
+//   - result: emitted as [EventResult] if err is nil
+//   - err: emitted as [EventError]
+type Func[T any] func(ctx context.Context, progress func(value T)) (result T, err error)
+
+// Run executes fn in a new thread of goGen
+//   - events: progress events followed by a final result or error event.
+//     The stream closes after the final event
+//   - the thread is supervised: a panic is recovered and
+//     an error is provided to goGen’s thread-group as the thread’s exit
+//   - fn is canceled by canceling goGen. To cancel fn individually,
+//     provide a SubGo
+//
+// Usage:
+//
+//	var events = ev.Run(goGroup.SubGo(), func(ctx context.Context, progress func(n int64)) (n int64, err error) {
+//	  …
+//	  progress(n)
+//	  …
+//	})
+//	var n, err = ev.Await(events, func(n int64) { parl.Log("progress: %d", n) })
+func Run[T any](goGen parl.GoGen, fn Func[T]) (events *parl.AwaitableSlice[Event[T]]) {
+	if goGen == nil {
+		panic(parl.NilError("goGen"))
+	} else if fn == nil {
+		panic(parl.NilError("fn"))
+	}
+	events = &parl.AwaitableSlice[Event[T]]{}
+	go runThread(fn, events, goGen.Go())
+
+	return
+}
+
+// Await reads events until the stream closes
+//   - progress: optional function receiving progress values
+//   - result err: from the final event
+func Await[T any](events *parl.AwaitableSlice[Event[T]], progress ...func(value T)) (result T, err error) {
+	var progressFn func(value T)
+	if len(progress) > 0 {
+		progressFn = progress[0]
+	}
+	for event := events.Init(); events.Condition(&event); {
+		switch event.Kind {
+		case EventProgress:
+			if progressFn != nil {
+				progressFn(event.Value)
+			}
+		case EventResult:
+			result = event.Value
+		case EventError:
+			err = event.Err
+		}
+	}
+
+	return
+}
+
+// runThread executes fn as a parl thread
+func runThread[T any](fn Func[T], events *parl.AwaitableSlice[Event[T]], g parl.Go) {
+	var err error
+	defer g.Done(&err)
+	defer events.EmptyCh()
+	defer sendFinal(events, &err)
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	var result T
+	if result, err = fn(g.Context(), func(value T) {
+		events.Send(Event[T]{Kind: EventProgress, Value: value, Time: time.Now()})
+	}); err != nil {
+		return
+	}
+	events.Send(Event[T]{Kind: EventResult, Value: result, Time: time.Now()})
+}
+
+// sendFinal emits an error event
+func sendFinal[T any](events *parl.AwaitableSlice[Event[T]], errp *error) {
+	if err := *errp; err != nil {
+		events.Send(Event[T]{Kind: EventError, Err: err, Time: time.Now()})
+	}
+}
+
+// “progress” “result” “error”
+func (k EventKind) String() (s string) {
+	switch k {
+	case EventProgress:
+		return "progress"
+	case EventResult:
+		return "result"
+	case EventError:
+		return "error"
+	}
+	return fmt.Sprintf("?eventKind%d", k)
+}
+
+// “progress 5” “error: bad”
+func (e Event[T]) String() (s string) {
+	if e.Kind == EventError {
+		return e.Kind.String() + ": " + e.Err.Error()
+	}
+	return fmt.Sprintf("%s %v", e.Kind, e.Value)
+}
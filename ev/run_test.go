@@ -0,0 +1,79 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package ev
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/g0"
+)
+
+func TestRun(t *testing.T) {
+	//t.Error("Logging on")
+	var expProgress = []int{1, 2}
+	var expResult = 3
+	var errBad = errors.New("bad")
+
+	var goGroup = g0.NewGoGroup(context.Background())
+	goGroup.EnableTermination(parl.PreventTermination)
+	defer goGroup.Wait()
+	defer goGroup.EnableTermination(parl.AllowTermination)
+	var result int
+	var err error
+
+	// progress then result
+	var progress []int
+	var events = Run(goGroup.SubGo(), func(ctx context.Context, progress func(value int)) (result int, err error) {
+		for _, p := range expProgress {
+			progress(p)
+		}
+		return expResult, nil
+	})
+	result, err = Await(events, func(value int) { progress = append(progress, value) })
+	if err != nil {
+		t.Errorf("Await err %s", err)
+	}
+	if result != expResult {
+		t.Errorf("result %d exp %d", result, expResult)
+	}
+	if !slices.Equal(progress, expProgress) {
+		t.Errorf("progress %v exp %v", progress, expProgress)
+	}
+	if !events.IsClosed() {
+		t.Error("events not closed")
+	}
+
+	// error
+	events = Run(goGroup.SubGo(), func(ctx context.Context, progress func(value int)) (result int, err error) {
+		return 0, errBad
+	})
+	if _, err = Await(events); !errors.Is(err, errBad) {
+		t.Errorf("Await err %v exp %v", err, errBad)
+	}
+
+	// panic
+	events = Run(goGroup.SubGo(), func(ctx context.Context, progress func(value int)) (result int, err error) {
+		panic(errBad)
+	})
+	if _, err = Await(events); !errors.Is(err, errBad) {
+		t.Errorf("Await panic err %v exp %v", err, errBad)
+	}
+
+	// cancel
+	var subGo = goGroup.SubGo()
+	events = Run(subGo, func(ctx context.Context, progress func(value int)) (result int, err error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	subGo.Cancel()
+	if _, err = Await(events); !errors.Is(err, context.Canceled) {
+		t.Errorf("Await cancel err %v exp %v", err, context.Canceled)
+	}
+}
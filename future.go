@@ -6,6 +6,7 @@ ISC License
 package parl
 
 import (
+	"context"
 	"sync/atomic"
 
 	"github.com/haraldrudell/parl/perrors"
@@ -66,6 +67,38 @@ func (f *Future[T]) Result() (result T, hasValue bool) {
 	return
 }
 
+// ResultCtx awaits the future’s result or ctx cancel
+//   - err: the calculation error or on ctx cancel, the context error
+//   - value is valid if err is nil
+//   - may block. Thread-safe
+func (f *Future[T]) ResultCtx(ctx context.Context) (value T, err error) {
+	select {
+	case <-f.await.Ch():
+	case <-ctx.Done():
+		err = perrors.ErrorfPF("Future: %w", context.Cause(ctx))
+		return
+	}
+	var rp = f.result.Load()
+	value = rp.Value
+	err = rp.Err
+
+	return
+}
+
+// TryGet returns the result if the future has resolved
+//   - isResolved false: the future has not resolved, value and err are zero-value
+//   - does not block. Thread-safe
+func (f *Future[T]) TryGet() (value T, isResolved bool, err error) {
+	var rp = f.result.Load()
+	if isResolved = rp != nil; !isResolved {
+		return
+	}
+	value = rp.Value
+	err = rp.Err
+
+	return
+}
+
 // TResult returns a pointer to the future’s result
 //   - nil if future has not resolved
 //   - thread-safe
@@ -90,3 +123,14 @@ func (f *Future[T]) End(value *T, isPanic *bool, errp *error) {
 	// trigger awaitable
 	f.await.Close()
 }
+
+// Resolve provides the result of the calculation
+//   - value is valid if err is nil
+//   - didResolve false: the future was already resolved, value and err are ignored
+//   - thread-safe
+func (f *Future[T]) Resolve(value T, err error) (didResolve bool) {
+	if didResolve = f.result.CompareAndSwap(nil, NewTResult3(&value, nil, &err)); didResolve {
+		f.await.Close()
+	}
+	return
+}
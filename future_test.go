@@ -6,8 +6,11 @@ ISC License
 package parl
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestAwaitableCalculation(t *testing.T) {
@@ -47,3 +50,66 @@ func TestAwaitableCalculation(t *testing.T) {
 		t.Errorf("result bad: %d exp %d", result, value)
 	}
 }
+
+func TestFutureResolve(t *testing.T) {
+	//t.Error("Logging on")
+	var value = 3
+	var errBad = errors.New("bad")
+
+	var future = NewFuture[int]()
+	if _, isResolved, _ := future.TryGet(); isResolved {
+		t.Error("TryGet isResolved true")
+	}
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := future.ResultCtx(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ResultCtx err %v exp %v", err, context.Canceled)
+	}
+
+	if !future.Resolve(value, nil) {
+		t.Error("Resolve false")
+	}
+	if future.Resolve(value+1, errBad) {
+		t.Error("second Resolve true")
+	}
+	if v, isResolved, err := future.TryGet(); !isResolved || err != nil || v != value {
+		t.Errorf("TryGet %d %v %t", v, err, isResolved)
+	}
+	if v, err := future.ResultCtx(context.Background()); err != nil || v != value {
+		t.Errorf("ResultCtx %d %v", v, err)
+	}
+
+	var f2 = NewFuture[int]()
+	f2.Resolve(0, errBad)
+	if _, err := f2.ResultCtx(context.Background()); err != errBad {
+		t.Errorf("ResultCtx err %v exp %v", err, errBad)
+	}
+}
+
+func TestCombineFutures(t *testing.T) {
+	//t.Error("Logging on")
+	var f1, f2 = NewFuture[int](), NewFuture[int]()
+	var futures = []*Future[int]{f1, f2}
+	var shortCtx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	// any
+	if results, err := CombineFutures(shortCtx, FuturesAny, futures...); err == nil || len(results) != 2 {
+		t.Errorf("CombineFutures any err nil or len %d", len(results))
+	}
+	f2.Resolve(2, nil)
+	var results, err = CombineFutures(context.Background(), FuturesAny, futures...)
+	if err != nil || results[0] != nil || results[1] == nil || results[1].Value != 2 {
+		t.Errorf("CombineFutures any %v %v", results, err)
+	}
+
+	// all
+	if _, err = CombineFutures(shortCtx, FuturesAll, futures...); err == nil {
+		t.Error("CombineFutures all err nil")
+	}
+	f1.Resolve(1, nil)
+	results, err = CombineFutures(context.Background(), FuturesAll, futures...)
+	if err != nil || results[0].Value != 1 || results[1].Value != 2 {
+		t.Errorf("CombineFutures all %v %v", results, err)
+	}
+}
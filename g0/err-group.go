@@ -0,0 +1,108 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/haraldrudell/parl"
+)
+
+// ErrGroup is an adapter providing the API of
+// golang.org/x/sync/errgroup.Group on top of a [GoGroup] thread-group
+//   - allows existing errgroup call sites to be retained while
+//     threads become supervised parl threads
+//   - a panic in a thread is recovered and becomes that thread’s error
+//   - the first thread returning error cancels the context
+//   - [ErrGroup.Wait] awaits all threads and returns the first error
+//   - the underlying thread-group is available from [ErrGroup.GoGroup]
+//     for incremental migration to parl thread-groups
+//   - unlike errgroup, zero-value is not usable and
+//     ErrGroup cannot be used after Wait
+//   - thread-safe
+//
+// Usage:
+//
+//	var g, ctx = g0.WithContext(ctx)
+//	for _, url := range urls {
+//	  g.Go(func() (err error) { return fetch(ctx, url) })
+//	}
+//	if err = g.Wait(); err != nil {
+//	  …
+type ErrGroup struct {
+	goGroup *GoGroup
+	// err is the first error returned by a thread
+	err atomic.Pointer[error]
+}
+
+// WithContext returns an [ErrGroup] and a context canceled
+// by the first thread returning error or Wait returning,
+// whichever occurs first
+//   - equivalent of errgroup.WithContext
+func WithContext(ctx context.Context) (errGroup *ErrGroup, ctx2 context.Context) {
+	errGroup = newErrGroup(ctx)
+	ctx2 = errGroup.goGroup.Context()
+	return
+}
+
+// NewErrGroup returns an [ErrGroup] canceled when ctx is canceled
+//   - equivalent of zero-value errgroup.Group
+func NewErrGroup(ctx context.Context) (errGroup *ErrGroup) { return newErrGroup(ctx) }
+
+// Go launches f in a new thread
+//   - a non-nil error returned by f or a panic in f cancels the context
+//     and the first such error is returned by Wait
+//   - Go after Wait panics
+func (e *ErrGroup) Go(f func() (err error)) {
+	if f == nil {
+		panic(parl.NilError("f"))
+	}
+	go e.errGroupThread(f, e.goGroup.FromGoGo())
+}
+
+// Wait blocks until all threads launched by Go have exited
+//   - err: the first non-nil error returned by a thread
+//   - the context is canceled
+func (e *ErrGroup) Wait() (err error) {
+	e.goGroup.EnableTermination(parl.AllowTermination)
+	e.goGroup.Wait()
+	if errp := e.err.Load(); errp != nil {
+		err = *errp
+	}
+	return
+}
+
+// GoGroup returns the underlying thread-group
+func (e *ErrGroup) GoGroup() (goGroup parl.GoGroup) { return e.goGroup }
+
+// newErrGroup returns an ErrGroup whose thread-group does not
+// terminate until Wait
+func newErrGroup(ctx context.Context) (errGroup *ErrGroup) {
+	var e = ErrGroup{goGroup: new(nil, ctx, true, false, fromGoNewFrames)}
+	e.goGroup.EnableTermination(parl.PreventTermination)
+	return &e
+}
+
+// errGroupThread executes f as a parl thread
+func (e *ErrGroup) errGroupThread(f func() (err error), g parl.Go) {
+	var err error
+	defer g.Done(&err)
+	defer e.setErr(&err)
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	err = f()
+}
+
+// setErr stores the first error and cancels the thread-group’s context
+func (e *ErrGroup) setErr(errp *error) {
+	if *errp == nil {
+		return // good thread exit return
+	}
+	if e.err.CompareAndSwap(nil, errp) {
+		e.goGroup.goContext.Cancel()
+	}
+}
@@ -0,0 +1,68 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrGroup(t *testing.T) {
+	//t.Error("Logging on")
+	var errBad = errors.New("bad")
+
+	var err error
+	var errGroup *ErrGroup
+	var ctx context.Context
+
+	// Wait without Go should return nil
+	errGroup = NewErrGroup(context.Background())
+	if err = errGroup.Wait(); err != nil {
+		t.Errorf("Wait err: %s", err)
+	}
+
+	// first error should cancel context and be returned by Wait
+	errGroup, ctx = WithContext(context.Background())
+	var isCanceled = make(chan struct{})
+	errGroup.Go(func() (err error) {
+		<-ctx.Done()
+		close(isCanceled)
+		return
+	})
+	errGroup.Go(func() (err error) { return errBad })
+	if err = errGroup.Wait(); !errors.Is(err, errBad) {
+		t.Errorf("Wait err %v exp %v", err, errBad)
+	}
+	select {
+	case <-isCanceled:
+	default:
+		t.Error("context not canceled")
+	}
+
+	// sequential good threads should not terminate the thread-group early
+	errGroup, ctx = WithContext(context.Background())
+	errGroup.Go(func() (err error) { return })
+	for _, exited := errGroup.goGroup.ThreadCounts(); exited == 0; _, exited = errGroup.goGroup.ThreadCounts() {
+		time.Sleep(time.Millisecond)
+	}
+	errGroup.Go(func() (err error) { return })
+	if err = errGroup.Wait(); err != nil {
+		t.Errorf("Wait err: %s", err)
+	}
+	if ctx.Err() == nil {
+		t.Error("context not canceled after Wait")
+	}
+
+	// a panic should be returned as error
+	errGroup = NewErrGroup(context.Background())
+	errGroup.Go(func() (err error) { panic("panic value") })
+	if err = errGroup.Wait(); err == nil || !strings.Contains(err.Error(), "panic value") {
+		t.Errorf("Wait panic err: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// errorLimit is the non-fatal error rate limit set by [GoGroup.SetErrorLimit]
+type errorLimit struct {
+	// count is the number of non-fatal errors forwarded per interval
+	count int
+	// interval is the length of a rate-limiting period
+	interval time.Duration
+}
+
+// errorLimiter rate limits and deduplicates non-fatal errors of a single Go
+//   - during an interval, count errors are forwarded
+//   - an error whose message is identical to the previously forwarded error
+//     is suppressed during the interval
+//   - suppressed errors are summarized by a single error when a subsequent
+//     interval begins or the thread exits
+//   - thread-safe
+type errorLimiter struct {
+	lock sync.Mutex
+	// intervalStart is when the current interval began, behind lock
+	intervalStart time.Time
+	// forwarded is the number of errors forwarded during the current interval, behind lock
+	forwarded int
+	// lastMessage is the message of the last forwarded error, behind lock
+	lastMessage string
+	// suppressed is the number of suppressed errors, behind lock
+	suppressed int
+	// lastSuppressed is the most recently suppressed error, behind lock
+	lastSuppressed error
+}
+
+// add processes a non-fatal error
+//   - isForward: err should be forwarded
+//   - summary: non-nil error summarizing errors suppressed
+//     during a previous interval
+func (l *errorLimiter) add(err error, limit *errorLimit) (isForward bool, summary error) {
+	var now = time.Now()
+	var message = err.Error()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	// a new interval begins
+	if now.Sub(l.intervalStart) >= limit.interval {
+		summary = l.summary()
+		l.intervalStart = now
+		l.forwarded = 0
+		l.lastMessage = ""
+	}
+
+	if isForward = l.forwarded < limit.count && message != l.lastMessage; isForward {
+		l.forwarded++
+		l.lastMessage = message
+		return
+	}
+	l.suppressed++
+	l.lastSuppressed = err
+
+	return
+}
+
+// flush returns any summary of suppressed errors
+//   - invoked on thread exit
+func (l *errorLimiter) flush() (summary error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.summary()
+}
+
+// summary returns an error summarizing suppressed errors, behind lock
+//   - nil if no errors were suppressed
+func (l *errorLimiter) summary() (summary error) {
+	if l.suppressed == 0 {
+		return // no suppressed errors return
+	}
+	summary = perrors.ErrorfPF("suppressed %d similar errors, last: %w", l.suppressed, l.lastSuppressed)
+	l.suppressed = 0
+	l.lastSuppressed = nil
+
+	return
+}
@@ -0,0 +1,53 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+func TestSetErrorLimit(t *testing.T) {
+	//t.Error("Logging on")
+	var messages = []string{"a", "a", "b", "c", "d"}
+	// a and b are forwarded, second a is duplicate, c d exceed limit
+	var expMessages = []string{"a", "b"}
+	var expSummary = "suppressed 3 similar errors"
+
+	var goGroup = NewGoGroup(context.Background())
+	goGroup.SetErrorLimit(2, time.Hour)
+	// the limit applies to subordinate thread-groups
+	var g = goGroup.SubGo().Go()
+	for _, message := range messages {
+		g.AddError(errors.New(message))
+	}
+	var err error
+	g.Done(&err)
+	goGroup.Wait()
+
+	var nonFatals []string
+	var goErrors = goGroup.GoError()
+	for goError, hasValue := goErrors.Get(); hasValue; goError, hasValue = goErrors.Get() {
+		if goError.ErrContext() == parl.GeNonFatal {
+			nonFatals = append(nonFatals, goError.Err().Error())
+		}
+	}
+	if len(nonFatals) != len(expMessages)+1 {
+		t.Fatalf("non-fatal errors: %q", nonFatals)
+	}
+	if !slices.Equal(nonFatals[:len(expMessages)], expMessages) {
+		t.Errorf("forwarded %q exp %q", nonFatals[:len(expMessages)], expMessages)
+	}
+	if summary := nonFatals[len(expMessages)]; !strings.Contains(summary, expSummary) {
+		t.Errorf("summary %q exp %q", summary, expSummary)
+	}
+}
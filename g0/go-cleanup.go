@@ -0,0 +1,85 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"context"
+	"sync"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// goCleanups are cleanup functions registered by [Go.OnCleanup]
+//   - a cleanup executes exactly once on context cancel or thread exit,
+//     whichever occurs first
+//   - cleanups execute on their own goroutine
+//   - thread exit awaits cleanups in progress so that their panics
+//     are reported before the thread-group may end
+//   - thread-safe
+type goCleanups struct {
+	lock sync.Mutex
+	// stops are context.AfterFunc stop functions of pending cleanups
+	//	- behind lock
+	stops []func() (stopped bool)
+	// cleanups are pending cleanup functions, same index as stops
+	//	- behind lock
+	cleanups []func()
+	// isEnded is true once the thread exited
+	//	- behind lock
+	isEnded bool
+	// running is cleanups registered and not completed
+	running sync.WaitGroup
+}
+
+// add registers cleanup
+//   - panics after thread exit
+func (c *goCleanups) add(ctx context.Context, cleanup func(), g *Go) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isEnded {
+		panic(perrors.NewPF("OnCleanup after Done"))
+	}
+	c.running.Add(1)
+	c.stops = append(c.stops, context.AfterFunc(ctx, func() { c.run(cleanup, g) }))
+	c.cleanups = append(c.cleanups, cleanup)
+}
+
+// end executes cleanups not yet run and awaits cleanups in progress
+//   - invoked once on thread exit
+func (c *goCleanups) end(g *Go) {
+	c.lock.Lock()
+	c.isEnded = true
+	var stops, cleanups = c.stops, c.cleanups
+	c.stops, c.cleanups = nil, nil
+	c.lock.Unlock()
+
+	for i, stop := range stops {
+		// stop false: cleanup was already launched by context cancel
+		if stop() {
+			go c.run(cleanups[i], g)
+		}
+	}
+	c.running.Wait()
+}
+
+// run executes cleanup providing a panic as non-fatal error
+func (c *goCleanups) run(cleanup func(), g *Go) {
+	defer c.running.Done()
+	var err error
+	defer c.runEnd(&err, g)
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	cleanup()
+}
+
+// runEnd reports a cleanup panic
+func (c *goCleanups) runEnd(errp *error, g *Go) {
+	if err := *errp; err != nil {
+		g.ConsumeError(NewGoError(perrors.ErrorfPF("cleanup: %w", err), parl.GeNonFatal, g))
+	}
+}
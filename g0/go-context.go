@@ -85,6 +85,18 @@ func (c *goContext) Context() (ctx context.Context) {
 // 	}
 // }
 
+// setValue layers key value onto the context
+//   - thread-safe
+func (c *goContext) setValue(key, value any) {
+	for {
+		var ctxp0 = c.ctxp.Load()
+		var ctx = context.WithValue(*ctxp0, key, value)
+		if c.ctxp.CompareAndSwap(ctxp0, &ctx) {
+			return
+		}
+	}
+}
+
 func (c *goContext) setCancelListener(f func()) {
 	c.cancelListener.Store(&f)
 }
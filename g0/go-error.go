@@ -87,12 +87,19 @@ func (e *GoError) Go() (g0 parl.Go) {
 	return e.g0
 }
 
+// String is a single-line description of the error
+//   - “error:'message'context:GeExit-at:…”
+//   - for a recovered panic, “-panic:” is followed by the code location
+//     where panic was invoked
 func (e *GoError) String() (s string) {
 	var err = e.err
 	var stack = errorglue.GetInnerMostStack(err)
 	if stack != nil {
 		s = "-at:" + stack.Frames()[0].String()
 	}
+	if location, isPanic := perrors.PanicSite(err); isPanic {
+		s += "-panic:" + location.Short()
+	}
 	var message string
 	if err != nil {
 		message = perrors.Short(err)
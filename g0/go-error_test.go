@@ -8,6 +8,7 @@ package g0
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/haraldrudell/parl"
@@ -68,3 +69,17 @@ func TestGoErrorString(t *testing.T) {
 	}
 	//t.Fail()
 }
+
+func TestGoErrorPanicSite(t *testing.T) {
+	var err error
+	func() {
+		defer parl.Recover(func() parl.DA { return parl.A() }, &err, parl.NoopErrorSink)
+		panic(1)
+	}()
+
+	var s = NewGoError(err, parl.GeExit, nil).String()
+	t.Logf("String: %s", s)
+	if !strings.Contains(s, "-panic:g0.TestGoErrorPanicSite.func1()") {
+		t.Errorf("String no panic site: %q", s)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// GoGroupMetrics is supervision health of a thread-group
+//   - counts include threads of subordinate thread-groups
+//   - obtained from [GoGroup.Metrics] or [GoGroup.PushMetrics]
+//   - does not require thread aggregation by [GoGroup.SetDebug]
+type GoGroupMetrics struct {
+	// Current is the number of currently running threads
+	Current uint64
+	// Peak is the highest number of concurrently running threads
+	Peak uint64
+	// Launched is the total number of threads launched
+	Launched uint64
+	// Exited is the number of threads that exited
+	Exited uint64
+	// FatalExits is the number of threads that exited with error
+	FatalExits uint64
+	// NonFatalErrors is the number of non-fatal errors
+	//	- fatal exits of a SubGroup forwarded as non-fatal are not counted
+	NonFatalErrors uint64
+	// AwaitingSlot is the number of threads awaiting a slot
+	// when limited by [GoGroup.SetMaxConcurrent]
+	AwaitingSlot int
+	// Lifetime is the duration from thread-group creation to now or termination
+	Lifetime time.Duration
+	// IsEnd is true if the thread-group has terminated
+	IsEnd bool
+}
+
+// MetricsFunc receives periodic metrics from [GoGroup.PushMetrics]
+type MetricsFunc func(metrics *GoGroupMetrics)
+
+// Metrics returns current supervision metrics for the thread-group
+//   - thread-safe
+func (g *GoGroup) Metrics() (metrics GoGroupMetrics) {
+	var exited = g.threadsExited.Load()
+	var launched = g.threadsCreated.Load()
+	metrics = GoGroupMetrics{
+		Current:        launched - exited,
+		Peak:           g.peakThreads.Load(),
+		Launched:       launched,
+		Exited:         exited,
+		FatalExits:     g.fatalExits.Load(),
+		NonFatalErrors: g.nonFatalErrors.Load(),
+		AwaitingSlot:   g.slots.awaiting(),
+	}
+	if ended := g.ended.Load(); ended != 0 {
+		metrics.IsEnd = true
+		metrics.Lifetime = time.Unix(0, ended).Sub(g.created)
+	} else {
+		metrics.Lifetime = time.Since(g.created)
+	}
+
+	return
+}
+
+// PushMetrics invokes callback with metrics every interval
+//   - callback is invoked a final time when the thread-group terminates
+//   - stop: ends pushing of metrics, idempotent
+//   - a panic in callback is printed to standard error and ends pushing
+//   - thread-safe
+func (g *GoGroup) PushMetrics(interval time.Duration, callback MetricsFunc) (stop func()) {
+	if callback == nil {
+		panic(parl.NilError("callback"))
+	} else if interval <= 0 {
+		panic(perrors.ErrorfPF("interval must be positive: %s", interval))
+	}
+	var stopCh = make(chan struct{})
+	var stopOnce sync.Once
+	stop = func() { stopOnce.Do(func() { close(stopCh) }) }
+	go g.pushThread(interval, callback, stopCh)
+
+	return
+}
+
+// String returns a printable representation of metrics
+//
+//	threads: 3 peak: 5 launched: 12 fatal: 1 non-fatal: 2 lifetime: 1m2s
+func (m *GoGroupMetrics) String() (s string) {
+	s = fmt.Sprintf("threads: %d peak: %d launched: %d fatal: %d non-fatal: %d lifetime: %s",
+		m.Current, m.Peak, m.Launched, m.FatalExits, m.NonFatalErrors,
+		m.Lifetime.Round(time.Millisecond),
+	)
+	if m.AwaitingSlot > 0 {
+		s += fmt.Sprintf(" awaiting: %d", m.AwaitingSlot)
+	}
+	if m.IsEnd {
+		s += " ended"
+	}
+	return
+}
+
+// pushThread invokes callback every interval until stop or thread-group end
+func (g *GoGroup) pushThread(interval time.Duration, callback MetricsFunc, stopCh chan struct{}) {
+	defer parl.Recover(func() parl.DA { return parl.A() }, nil, parl.Infallible)
+
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var endCh = g.endCh.Ch()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-endCh:
+			var metrics = g.Metrics()
+			callback(&metrics)
+			return
+		case <-ticker.C:
+			var metrics = g.Metrics()
+			callback(&metrics)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGoGroupMetrics(t *testing.T) {
+	//t.Error("Logging on")
+	var errBad = errors.New("bad")
+	var noErr error
+
+	var goGroup = NewGoGroup(context.Background()).(*GoGroup)
+	var pushCount atomic.Int64
+	var lastPush atomic.Pointer[GoGroupMetrics]
+	goGroup.PushMetrics(time.Millisecond, func(metrics *GoGroupMetrics) {
+		pushCount.Add(1)
+		lastPush.Store(metrics)
+	})
+
+	// two threads in a SubGroup, one thread in GoGroup
+	var subGroup = goGroup.SubGroup()
+	var g1 = subGroup.Go()
+	var g2 = subGroup.Go()
+	var g3 = goGroup.Go()
+
+	var metrics = goGroup.Metrics()
+	if metrics.Current != 3 || metrics.Peak != 3 || metrics.Launched != 3 {
+		t.Errorf("Metrics: %s", metrics.String())
+	}
+
+	g1.AddError(errBad)
+	g1.Done(&errBad)
+	g2.Done(&noErr)
+	subGroup.Wait()
+	g3.Done(&noErr)
+	goGroup.Wait()
+
+	// subGroup: fatal exit and non-fatal error
+	metrics = subGroup.(*GoGroup).Metrics()
+	if metrics.FatalExits != 1 || metrics.NonFatalErrors != 1 || !metrics.IsEnd {
+		t.Errorf("subGroup Metrics: %s", metrics.String())
+	}
+
+	// goGroup: SubGroup fatal is neither fatal nor counted as non-fatal
+	metrics = goGroup.Metrics()
+	if metrics.Current != 0 || metrics.Peak != 3 || metrics.Exited != 3 ||
+		metrics.FatalExits != 0 || metrics.NonFatalErrors != 1 || !metrics.IsEnd {
+		t.Errorf("goGroup Metrics: %s", metrics.String())
+	}
+	if lifetime := goGroup.Metrics().Lifetime; lifetime != metrics.Lifetime {
+		t.Errorf("Lifetime after end %s exp %s", lifetime, metrics.Lifetime)
+	}
+
+	// final push on termination
+	for i := 0; pushCount.Load() == 0 || !lastPush.Load().IsEnd; i++ {
+		if i == 1000 {
+			t.Fatal("missing final push")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"context"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// GoGroupOption configures a thread-group at creation
+//   - [WithDebug] [WithFirstFatal] [WithMaxConcurrent] [WithErrorSink] [WithName] [WithValue]
+//   - options are applied prior to the thread-group being returned so that
+//     no Go invocation can precede them
+type GoGroupOption func(g *GoGroup)
+
+// NewGoGroupWith returns a stand-alone thread-group configured by options
+//   - ctx: like [NewGoGroup]
+//   - options are applied in order
+//
+// Usage:
+//
+//	var goGroup = g0.NewGoGroupWith(ctx,
+//	  g0.WithName("server"),
+//	  g0.WithMaxConcurrent(8),
+//	  g0.WithErrorSink(&errs),
+//	)
+func NewGoGroupWith(ctx context.Context, options ...GoGroupOption) (g0 parl.GoGroup) {
+	var g = new(nil, ctx, true, false, goGroupNewObjectFrames)
+	for _, option := range options {
+		if option == nil {
+			panic(perrors.NewPF("option cannot be nil"))
+		}
+		option(g)
+	}
+	return g
+}
+
+// WithDebug is [GoGroup.SetDebug] at creation
+func WithDebug(debug parl.GoDebug, log ...parl.PrintfFunc) (option GoGroupOption) {
+	return func(g *GoGroup) { g.SetDebug(debug, log...) }
+}
+
+// WithFirstFatal is the onFirstFatal argument of [NewGoGroup]:
+// invoked on the first thread exiting with error
+func WithFirstFatal(onFirstFatal parl.GoFatalCallback) (option GoGroupOption) {
+	return func(g *GoGroup) { g.onFirstFatal = onFirstFatal }
+}
+
+// WithMaxConcurrent is [GoGroup.SetMaxConcurrent] at creation
+func WithMaxConcurrent(n int, mode ...parl.ConcurrencyMode) (option GoGroupOption) {
+	return func(g *GoGroup) { g.SetMaxConcurrent(n, mode...) }
+}
+
+// WithErrorSink provides errors of the thread-group to errorSink
+//   - non-fatal errors and thread exits with error are provided as [parl.GoError]
+//   - errors are also sent on the thread-group’s GoError stream
+func WithErrorSink(errorSink parl.ErrorSink1) (option GoGroupOption) {
+	if errorSink == nil {
+		panic(parl.NilError("errorSink"))
+	}
+	return func(g *GoGroup) { g.errorSink = errorSink }
+}
+
+// WithName names the thread-group
+//   - the name is part of String and debug printing: “goGroup#1(server)”
+func WithName(name string) (option GoGroupOption) {
+	return func(g *GoGroup) { g.name = name }
+}
+
+// WithValue is [GoGroup.SetValue] at creation:
+// layers a context value onto the contexts of all threads and
+// subordinate thread-groups
+//   - request-scoped values like request IDs and trace IDs then
+//     propagate through the supervision tree
+//   - retrieved by [parl.GoValue]
+func WithValue(key, value any) (option GoGroupOption) {
+	return func(g *GoGroup) { g.SetValue(key, value) }
+}
+
+// Name returns the name from [WithName], empty if none
+func (g *GoGroup) Name() (name string) { return g.name }
@@ -0,0 +1,104 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/haraldrudell/parl"
+)
+
+func TestNewGoGroupWith(t *testing.T) {
+	//t.Error("Logging on")
+	var name = "server"
+	var errNonFatal = errors.New("non-fatal")
+	var errFatal = errors.New("fatal")
+
+	var errs parl.ErrSlice
+	var firstFatals int
+	var goGroup = NewGoGroupWith(context.Background(),
+		WithName(name),
+		WithDebug(parl.AggregateThread),
+		WithFirstFatal(func(goGen parl.GoGen) { firstFatals++ }),
+		WithMaxConcurrent(1),
+		WithErrorSink(&errs),
+	)
+	var goGroupImpl = goGroup.(*GoGroup)
+
+	// name
+	if goGroupImpl.Name() != name {
+		t.Errorf("Name %q exp %q", goGroupImpl.Name(), name)
+	}
+	if s := goGroupImpl.String(); !strings.Contains(s, "("+name+")") {
+		t.Errorf("String no name: %q", s)
+	}
+
+	// debug and max concurrent
+	if !goGroupImpl.isAggregateThreads.Load() {
+		t.Error("WithDebug not applied")
+	}
+	var g = goGroup.Go()
+	select {
+	case <-goGroup.SlotCh():
+		t.Error("WithMaxConcurrent not applied")
+	default:
+	}
+
+	// errors to errorSink
+	g.AddError(errNonFatal)
+	var err = errFatal
+	g.Done(&err)
+	var errors0 = errs.Errors()
+	if len(errors0) != 2 ||
+		!errors.Is(errors0[0].(parl.GoError).Err(), errNonFatal) ||
+		!errors.Is(errors0[1].(parl.GoError).Err(), errFatal) {
+		t.Errorf("errorSink %v", errors0)
+	}
+	if firstFatals != 1 {
+		t.Errorf("firstFatals %d exp 1", firstFatals)
+	}
+	goGroup.Wait()
+}
+
+func TestWithValue(t *testing.T) {
+	//t.Error("Logging on")
+	type requestIDKey struct{}
+	type traceIDKey struct{}
+	var requestID, traceID = "req-42", "trace-1"
+
+	var goGroup = NewGoGroupWith(context.Background(), WithValue(requestIDKey{}, requestID))
+	var subGo = goGroup.SubGo()
+	subGo.SetValue(traceIDKey{}, traceID)
+
+	// thread of the thread-group should have value
+	var g = goGroup.Go()
+	if value, ok := parl.GoValue[string](g, requestIDKey{}); !ok || value != requestID {
+		t.Errorf("GoValue %t %q exp %q", ok, value, requestID)
+	}
+	// wrong type
+	if _, ok := parl.GoValue[int](g, requestIDKey{}); ok {
+		t.Error("GoValue int ok")
+	}
+	// SubGo value is not visible to parent
+	if _, ok := parl.GoValue[string](g, traceIDKey{}); ok {
+		t.Error("GoValue parent has SubGo value")
+	}
+
+	// thread of SubGo should inherit value and have its own
+	var g2 = subGo.Go()
+	if value, _ := parl.GoValue[string](g2, requestIDKey{}); value != requestID {
+		t.Errorf("GoValue SubGo %q exp %q", value, requestID)
+	}
+	if value, _ := parl.GoValue[string](g2, traceIDKey{}); value != traceID {
+		t.Errorf("GoValue SubGo trace %q exp %q", value, traceID)
+	}
+	g2.Done(nil)
+	g.Done(nil)
+	goGroup.Wait()
+}
@@ -8,9 +8,11 @@ package g0
 import (
 	"context"
 	"fmt"
+	"path"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/haraldrudell/parl"
 	"github.com/haraldrudell/parl/parli"
@@ -52,6 +54,11 @@ type GoGroup struct {
 	isSubGroup bool
 	// invoked on first fatal thread-exit
 	onFirstFatal parl.GoFatalCallback
+	// name is set by [WithName]
+	name string
+	// errorSink receives errors of the error stream, set by [WithErrorSink]
+	//	- nil: none
+	errorSink parl.ErrorSink1
 	// gos is a map from goEntityId to subordinate SubGo SunGroup Go
 	gos parli.ThreadSafeMap[parl.GoEntityID, *ThreadData]
 	// unbound error channel used when instance is GoGroup or SubGroup
@@ -74,8 +81,38 @@ type GoGroup struct {
 	//	- set by SetDebug
 	isAggregateThreads atomic.Bool
 	onceWaiter         atomic.Pointer[parl.OnceWaiter]
+	// threadsCreated counts threads added to this or subordinate thread-groups
+	threadsCreated atomic.Uint64
+	// threadsExited counts threads of this or subordinate thread-groups that exited
+	threadsExited atomic.Uint64
+	// peakThreads is the highest number of concurrently running threads
+	peakThreads atomic.Uint64
+	// fatalExits counts thread exits with error
+	fatalExits atomic.Uint64
+	// nonFatalErrors counts non-fatal errors
+	nonFatalErrors atomic.Uint64
+	// created is when the thread-group was created
+	created time.Time
+	// ended is when the thread-group terminated, unix nanoseconds
+	ended atomic.Int64
+	// isCancelEmitted ensures GoEventCancel is emitted once
+	isCancelEmitted atomic.Bool
 	// debug-log set by SetDebug
 	log atomic.Pointer[parl.PrintfFunc]
+	// debugFilter is label pattern of threads printed when debug,
+	// set by SetDebugFilter
+	//	- nil: all threads
+	debugFilter atomic.Pointer[string]
+	// errorLimit is non-fatal error rate limit set by SetErrorLimit
+	//	- nil: the limit of any parent applies
+	errorLimit atomic.Pointer[errorLimit]
+	// slots limits concurrently running threads set by SetMaxConcurrent
+	slots slotLimiter
+	// events is supervision event stream, non-nil once Events was invoked
+	events atomic.Pointer[parl.AwaitableSlice[parl.GoEvent]]
+	// tracer records thread lifecycle events set by SetTracer
+	//	- nil: the tracer of any parent applies
+	tracer atomic.Pointer[parl.Tracer]
 
 	// doneLock ensures:
 	//	- critical section for:
@@ -133,19 +170,29 @@ func (g *GoGroup) newGo(frames int) (g2 parl.Go) {
 	// At this point, Go invocation is accessible so retrieve it
 	// the goroutine has not been created yet, so there is no creator
 	// instead, use top of the stack, the invocation location for the Go() function call
-	var goInvocation = pruntime.NewCodeLocation(frames)
+	var goInvocation = pruntime.CodeLocationFast(frames)
 
 	if g.isEnd() {
 		panic(perrors.ErrorfPF(g.panicString(".Go(): "+goInvocation.Short(), nil, nil, false, nil)))
 	}
 
+	// thread slot if concurrency is limited
+	var ticket, isBlock = g.slots.ticket()
+
 	// the only location creating Go objects
 	var threadData *ThreadData
 	var goEntityID parl.GoEntityID
-	g2, goEntityID, threadData = newGo(g, goInvocation)
+	g2, goEntityID, threadData = newGo(g, goInvocation, ticket)
 
 	// count the running thread in this thread-group and its parents
 	g.Add(goEntityID, threadData)
+	g.emit(parl.GoEvent{Type: parl.GoEventCreated, Thread: goEntityID})
+
+	// blocking concurrency limit: await slot
+	//	- on cancel, the Go is returned without slot
+	if isBlock {
+		ticket.await(g.Context().Done())
+	}
 
 	return
 }
@@ -205,9 +252,10 @@ func new(
 		ctx = parent.Context()
 	}
 	g := GoGroup{
-		creator: *pruntime.NewCodeLocation(stackOffset),
+		creator: *pruntime.CodeLocationFast(stackOffset),
 		parent:  parent,
 		gos:     pmaps.NewRWMap[parl.GoEntityID, *ThreadData](),
+		created: time.Now(),
 	}
 	newGoContext(&g.goContext, ctx)
 	if parl.IsThisDebug() {
@@ -242,7 +290,10 @@ func (g *GoGroup) Add(goEntityID parl.GoEntityID, threadData *ThreadData) {
 	defer g.doneLock.Unlock()
 
 	g.wg.Add(1)
-	if g.isDebug.Load() {
+	if current := g.threadsCreated.Add(1) - g.threadsExited.Load(); current > g.peakThreads.Load() {
+		g.peakThreads.Store(current) // inside doneLock
+	}
+	if g.isDebugThread(threadData.label) {
 		(*g.log.Load())("goGroup#%s:Add(new:Go#%s.Go():%s)#%d",
 			g.EntityID(),
 			goEntityID, threadData.Short(), g.goContext.wg.Count())
@@ -288,6 +339,10 @@ func (g *GoGroup) GoDone(thread parl.Go, err error) {
 		}
 	}
 
+	// error for errorSink provided outside doneLock
+	var sinkError parl.GoError
+	defer g.toErrorSink(&sinkError)
+
 	// atomic operation: DoneBool and g0.ch.Close
 	g.doneLock.Lock() // GoDone
 	defer g.doneLock.Unlock()
@@ -300,21 +355,28 @@ func (g *GoGroup) GoDone(thread parl.Go, err error) {
 	// debug print termination-start
 	if g.isDebug.Load() {
 		var threadData parl.ThreadData
-		var id string
+		var id, label string
 		if thread != nil {
 			threadData = thread.ThreadInfo()
 			id = thread.EntityID().String()
+			label = threadData.Name()
+		}
+		if g.isDebugThread(label) {
+			(*g.log.Load())("goGroup#%s:GoDone(Label-ThreadID:%sGo#%s_exit:‘%s’)after#:%d",
+				g.EntityID(),
+				threadData.Short(), id, perrors.Short(err),
+				g.goContext.wg.Count()-1,
+			)
 		}
-		(*g.log.Load())("goGroup#%s:GoDone(Label-ThreadID:%sGo#%s_exit:‘%s’)after#:%d",
-			g.EntityID(),
-			threadData.Short(), id, perrors.Short(err),
-			g.goContext.wg.Count()-1,
-		)
 	}
 
 	// indicates that this GoGroup is about to terminate
 	//	- DoneBool invokes Done and returns status
 	var isTermination = g.goContext.wg.DoneBool()
+	g.threadsExited.Add(1)
+	if err != nil {
+		g.fatalExits.Add(1)
+	}
 
 	// delete thread from thread-map
 	g.gos.Delete(thread.EntityID(), parli.MapDeleteWithZeroValue)
@@ -338,7 +400,11 @@ func (g *GoGroup) GoDone(thread parl.Go, err error) {
 		} else {
 			goErrorContext = parl.GePreDoneExit
 		}
-		g.goErrorStream.Send(NewGoError(err, goErrorContext, thread))
+		var goError = NewGoError(err, goErrorContext, thread)
+		g.goErrorStream.Send(goError)
+		if err != nil {
+			sinkError = goError
+		}
 	} else {
 
 		// SubGo: forward error to parent
@@ -380,6 +446,10 @@ func (g *GoGroup) ConsumeError(goError parl.GoError) {
 	}
 
 	// it is a non-fatal error that should be processed
+	//	- a SubGroup’s fatal error is counted by fatalExits
+	if goError.ErrContext() == parl.GeNonFatal {
+		g.nonFatalErrors.Add(1)
+	}
 
 	// if we have a parent GoGroup, send it there
 	if g.parent != nil {
@@ -389,6 +459,17 @@ func (g *GoGroup) ConsumeError(goError parl.GoError) {
 
 	// send the error to the channel of this stand-alone G1Group
 	g.goErrorStream.Send(goError)
+	g.toErrorSink(&goError)
+}
+
+// toErrorSink provides goError to any errorSink
+//   - goErrorp: pointer to possibly nil error
+//   - deferrable
+func (g *GoGroup) toErrorSink(goErrorp *parl.GoError) {
+	if g.errorSink == nil || *goErrorp == nil {
+		return
+	}
+	g.errorSink.AddError(*goErrorp)
 }
 
 // GoError returns a channel sending the all fatal termination errors when
@@ -449,6 +530,7 @@ func (g *GoGroup) EnableTermination(allowTermination ...bool) (mayTerminate bool
 		if g.isNoTermination.CompareAndSwap(false, true) {
 			// add a fake count to parent waitgroup preventing iut from terminating
 			g.CascadeEnableTermination(1)
+			g.emit(parl.GoEvent{Type: parl.GoEventTerminationPrevented})
 		}
 		return // prevent termination complete: mayTerminate: false
 	}
@@ -463,6 +545,7 @@ func (g *GoGroup) EnableTermination(allowTermination ...bool) (mayTerminate bool
 			// remove the fake count from parent
 			delta = -1
 			g.CascadeEnableTermination(delta)
+			g.emit(parl.GoEvent{Type: parl.GoEventTerminationAllowed})
 		}
 	}
 	if delta == 0 {
@@ -524,6 +607,13 @@ func (g *GoGroup) Internals() (
 	return g.isEnd, &g.isAggregateThreads, g.goContext.setCancelListener, endCh
 }
 
+// ThreadCounts returns the number of threads created and exited
+// for this and subordinate thread-groups
+//   - used by [threadprof.Profiler]
+func (g *GoGroup) ThreadCounts() (created, exited uint64) {
+	return g.threadsCreated.Load(), g.threadsExited.Load()
+}
+
 // the available data for all threads
 func (g *GoGroup) Threads() (threads []parl.ThreadData) {
 	// the pointer can be updated at any time, but the value does not change
@@ -536,14 +626,22 @@ func (g *GoGroup) Threads() (threads []parl.ThreadData) {
 }
 
 // threads that have been named ordered by name
-func (g *GoGroup) NamedThreads() (threads []parl.ThreadData) {
+func (g *GoGroup) NamedThreads() (threads []parl.ThreadData) { return g.ThreadsByPrefix("") }
+
+// ThreadsByPrefix returns named threads whose hierarchical label is
+// prefix or is below prefix, ordered by name
+//   - prefix “server” matches “server” and “server/accept/conn-42”
+//     but not “serverX”
+//   - empty prefix: all named threads
+//   - threads are available when aggregating threads, see [GoGroup.SetDebug]
+func (g *GoGroup) ThreadsByPrefix(prefix string) (threads []parl.ThreadData) {
 	// the pointer can be updated at any time, but the value does not change
 	//	- slice of struct pointer
 	var list = g.gos.List()
 
-	// remove unnamed threads
+	// remove unnamed threads and threads outside prefix
 	for i := 0; i < len(list); {
-		if list[i].label == "" {
+		if label := list[i].label; label == "" || !hasLabelPrefix(label, prefix) {
 			list = slices.Delete(list, i, i+1)
 		} else {
 			i++
@@ -595,11 +693,184 @@ func (g *GoGroup) SetDebug(debug parl.GoDebug, log ...parl.PrintfFunc) {
 	g.isAggregateThreads.Store(false)
 }
 
+// SetErrorLimit rate limits non-fatal errors of each thread
+//   - count: number of non-fatal errors forwarded per thread and interval.
+//     An error identical to the previously forwarded error is suppressed.
+//     [parl.NoErrorLimit]: the limit of any parent thread-group applies
+//   - interval: length of rate-limiting period
+//   - suppressed errors are summarized by a single non-fatal
+//     “suppressed M similar errors” error when the thread’s next
+//     interval begins or the thread exits
+//   - applies to threads of this and subordinate thread-groups
+func (g *GoGroup) SetErrorLimit(count int, interval time.Duration) {
+	if count <= parl.NoErrorLimit {
+		g.errorLimit.Store(nil)
+		return
+	} else if interval <= 0 {
+		panic(perrors.ErrorfPF("interval must be positive: %s", interval))
+	}
+	g.errorLimit.Store(&errorLimit{count: count, interval: interval})
+}
+
+// SetMaxConcurrent limits the number of concurrently running threads
+// obtained from Go of this thread-group
+//   - n: [parl.NoMaxConcurrent]: unlimited
+//   - mode [parl.ConcurrencyBlock] default: Go blocks until a thread exits.
+//     On thread-group cancel, Go returns without awaiting a slot
+//   - mode [parl.ConcurrencyQueue]: Go returns immediately.
+//     The new thread awaits a slot on its first invocation of
+//     Register, AddError, Go, SubGo or SubGroup.
+//     A thread not invoking those may run prior to obtaining a slot
+//   - threads of subordinate thread-groups are not limited
+//   - slots are granted in order of Go invocation
+//   - ConcurrencyBlock: a thread of the thread-group invoking Go
+//     while all slots are taken blocks until another thread exits
+func (g *GoGroup) SetMaxConcurrent(n int, mode ...parl.ConcurrencyMode) {
+	var mode0 = parl.ConcurrencyBlock
+	if len(mode) > 0 {
+		mode0 = mode[0]
+	}
+	g.slots.setMax(n, mode0)
+}
+
+// SetDebugFilter limits debug printing of threads to labels matching pattern
+//   - pattern: [path.Match] pattern matched against the label and
+//     its ancestors: “server” and “server/*” match “server/accept/conn-42”
+//   - empty pattern: all threads
+//   - thread-group events are printed regardless of filter.
+//     Threads are matched by label at exit, new threads are printed
+//     if the filter matches an empty label
+//   - err: malformed pattern
+func (g *GoGroup) SetDebugFilter(pattern string) (err error) {
+	if pattern == "" {
+		g.debugFilter.Store(nil)
+		return
+	}
+	if _, err = path.Match(pattern, ""); perrors.IsPF(&err, "pattern %q: %w", pattern, err) {
+		return
+	}
+	g.debugFilter.Store(&pattern)
+
+	return
+}
+
+// isDebugThread returns true if debug printing is enabled for
+// a thread with label
+func (g *GoGroup) isDebugThread(label string) (isDebug bool) {
+	if !g.isDebug.Load() {
+		return
+	}
+	var pattern = g.debugFilter.Load()
+	return pattern == nil || matchLabel(*pattern, label)
+}
+
+// SetTracer records lifecycle events of each thread into tracer
+//   - tracer: nil: the tracer of any parent thread-group applies
+//   - events are recorded to a task named by the thread’s label
+//     provided to Register, otherwise its go-function.
+//     Threads with the same label share a task
+//   - events: “created” “registered” “first error” “done”.
+//     Since the thread ID is unknown until the thread invokes a Go method,
+//     the creation event is recorded on that first invocation
+//   - applies to threads created after SetTracer in this and
+//     subordinate thread-groups
+func (g *GoGroup) SetTracer(tracer parl.Tracer) {
+	if tracer == nil {
+		g.tracer.Store(nil)
+		return
+	}
+	g.tracer.Store(&tracer)
+}
+
+// SetValue layers a context value onto the context of this thread-group
+//   - key: like [context.WithValue], a comparable type defined by the caller
+//   - the value is visible to threads of this thread-group via Go.Context
+//     and [parl.GoValue], typically request IDs or trace IDs
+//   - subordinate thread-groups created after SetValue inherit the value.
+//     Subordinate thread-groups created earlier are unaffected
+//   - thread-safe
+func (g *GoGroup) SetValue(key, value any) { g.goContext.setValue(key, value) }
+
+// SlotCh returns a channel that closes when a thread slot is available
+//   - unlimited thread-group: the channel is closed
+func (g *GoGroup) SlotCh() (ch parl.AwaitableCh) { return g.slots.slotCh() }
+
+// Events returns a stream of supervision events of this and
+// subordinate thread-groups
+//   - events occurring after the first Events invocation are recorded
+//   - the stream closes when this thread-group ends
+//   - provides lifecycle observability to dashboards and tests
+//     without SetDebug text parsing
+//
+// Usage:
+//
+//	var events = goGroup.Events()
+//	…
+//	for event := events.Init(); events.Condition(&event); {
+//	  if event.Type == parl.GoEventExit && event.Err != nil {
+func (g *GoGroup) Events() (events parl.IterableSource[parl.GoEvent]) {
+	if e := g.events.Load(); e != nil {
+		return e // already active return
+	}
+	g.events.CompareAndSwap(nil, &parl.AwaitableSlice[parl.GoEvent]{})
+	var e = g.events.Load()
+	if g.isEnd() {
+		e.EmptyCh() // thread-group already ended
+	}
+
+	return e
+}
+
+// emit sends a supervision event to active event streams of
+// this thread-group and its parents
+func (g *GoGroup) emit(event parl.GoEvent) {
+	event.Group = g.EntityID()
+	event.At = time.Now()
+	for group := g; ; {
+		if events := group.events.Load(); events != nil {
+			events.Send(event)
+		}
+		var parent, ok = group.parent.(*GoGroup)
+		if !ok {
+			return // no more parents return
+		}
+		group = parent
+	}
+}
+
+// getErrorLimit returns the error rate limit of this or a parent thread-group
+//   - nil: errors are not rate limited
+func (g *GoGroup) getErrorLimit() (limit *errorLimit) {
+	if limit = g.errorLimit.Load(); limit != nil {
+		return // limit of this thread-group return
+	} else if parent, ok := g.parent.(*GoGroup); ok {
+		limit = parent.getErrorLimit()
+	}
+	return
+}
+
+// getTracer returns the tracer of this or a parent thread-group
+//   - nil: lifecycle events are not recorded
+func (g *GoGroup) getTracer() (tracer parl.Tracer) {
+	if tp := g.tracer.Load(); tp != nil {
+		return *tp // tracer of this thread-group return
+	} else if parent, ok := g.parent.(*GoGroup); ok {
+		tracer = parent.getTracer()
+	}
+	return
+}
+
 // Cancel signals shutdown to all threads of a thread-group.
 func (g *GoGroup) Cancel() {
 
 	// cancel the context
+	//	- GoEventCancel is emitted once after the context was canceled
+	//		by Cancel
+	var wasActive = g.goContext.Context().Err() == nil
 	g.goContext.Cancel()
+	if wasActive && g.isCancelEmitted.CompareAndSwap(false, true) {
+		g.emit(parl.GoEvent{Type: parl.GoEventCancel})
+	}
 
 	// check outside lock: done if:
 	// - if GoGroup/SubGroup/SubGo already terminated
@@ -673,9 +944,14 @@ func (g *GoGroup) endGoGroup() {
 		g.goErrorStream.EmptyCh() // close local error channel
 	}
 	// mark GoGroup terminated
+	g.ended.Store(time.Now().UnixNano())
 	g.endCh.Close()
 	// cancel the context
 	g.goContext.Cancel()
+	g.emit(parl.GoEvent{Type: parl.GoEventGroupEnd})
+	if events := g.events.Load(); events != nil {
+		events.EmptyCh() // close event stream
+	}
 }
 
 // cmpNames is a slice comparison function for thread names
@@ -715,7 +991,7 @@ func (g *GoGroup) isEnd() (isEnd bool) {
 	}
 }
 
-// "goGroup#1" "subGroup#2" "subGo#3"
+// "goGroup#1" "subGroup#2" "subGo#3" "goGroup#1(name)"
 func (g *GoGroup) typeString() (s string) {
 	if g.parent == nil {
 		s = "goGroup"
@@ -724,7 +1000,11 @@ func (g *GoGroup) typeString() (s string) {
 	} else {
 		s = "subGo"
 	}
-	return s + "#" + g.goEntityID.EntityID().String()
+	s += "#" + g.goEntityID.EntityID().String()
+	if g.name != "" {
+		s += "(" + g.name + ")"
+	}
+	return
 }
 
 // g1Group#3threads:1(1)g0.TestNewG1Group-g1-group_test.go:60
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -629,3 +630,132 @@ func awaitGoError(goErrors parl.IterableSource[parl.GoError], timeout time.Durat
 }
 
 const timeoutYES = 1
+
+func TestGoGroupEvents(t *testing.T) {
+	//t.Error("Logging on")
+	var label = "label"
+	var expTypes = []parl.GoEventType{
+		parl.GoEventTerminationPrevented,
+		parl.GoEventCreated,
+		parl.GoEventExit,
+		// the SubGo ends
+		parl.GoEventGroupEnd,
+		parl.GoEventCancel,
+		parl.GoEventTerminationAllowed,
+		parl.GoEventGroupEnd,
+	}
+
+	var goGroup = NewGoGroup(context.Background())
+	var events = goGroup.Events()
+	goGroup.EnableTermination(parl.PreventTermination)
+	// events of subordinate thread-groups are included
+	var g = goGroup.SubGo().Go()
+	g.Register(label)
+	var err = errors.New("x")
+	g.Done(&err)
+	// concurrent Cancel emits one event
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			goGroup.Cancel()
+		}()
+	}
+	wg.Wait()
+	goGroup.EnableTermination(parl.AllowTermination)
+	goGroup.Wait()
+
+	var types []parl.GoEventType
+	var exit parl.GoEvent
+	for event := events.Init(); events.Condition(&event); {
+		types = append(types, event.Type)
+		if event.Type == parl.GoEventExit {
+			exit = event
+		}
+	}
+	if fmt.Sprint(types) != fmt.Sprint(expTypes) {
+		t.Errorf("events %v exp %v", types, expTypes)
+	}
+	if exit.Label != label || !errors.Is(exit.Err, err) || !exit.ThreadID.IsValid() {
+		t.Errorf("bad exit event: %s", exit)
+	}
+	// the stream closes on thread-group end
+	select {
+	case <-events.EmptyCh():
+	default:
+		t.Error("events not closed")
+	}
+}
+
+func TestGoGroupThreadsByPrefix(t *testing.T) {
+	//t.Error("Logging on")
+	var exp = []string{"server", "server/accept/conn-42"}
+	var logs []string
+	var log = func(format string, a ...any) { logs = append(logs, fmt.Sprintf(format, a...)) }
+
+	var goGroup = NewGoGroup(context.Background())
+	goGroup.SetDebug(parl.DebugPrint, log)
+	if err := goGroup.SetDebugFilter("["); err == nil {
+		t.Error("SetDebugFilter missing error")
+	}
+	if err := goGroup.SetDebugFilter("server/accept"); err != nil {
+		t.Fatalf("SetDebugFilter err %s", err)
+	}
+	// multiple labels are hierarchical
+	var gs = []parl.Go{goGroup.Go(), goGroup.Go(), goGroup.Go()}
+	gs[0].Register("server", "accept", "conn-42")
+	gs[1].Register("server")
+	gs[2].Register("serverX")
+
+	var names []string
+	for _, threadData := range goGroup.ThreadsByPrefix("server") {
+		names = append(names, threadData.Name())
+	}
+	if fmt.Sprint(names) != fmt.Sprint(exp) {
+		t.Errorf("ThreadsByPrefix %v exp %v", names, exp)
+	}
+	if n := len(goGroup.ThreadsByPrefix("server/accept/")); n != 1 {
+		t.Errorf("ThreadsByPrefix server/accept/ %d exp 1", n)
+	}
+	if n := len(goGroup.NamedThreads()); n != 3 {
+		t.Errorf("NamedThreads %d exp 3", n)
+	}
+
+	// only the thread matching the filter is debug printed
+	for _, g := range gs {
+		var err error
+		g.Done(&err)
+	}
+	goGroup.Wait()
+	var threadLogs []string
+	for _, s := range logs {
+		if strings.Contains(s, "GoDone") || strings.Contains(s, ":Add(") {
+			threadLogs = append(threadLogs, s)
+		}
+	}
+	if len(threadLogs) != 1 || !strings.Contains(threadLogs[0], "conn-42") {
+		t.Errorf("bad thread logs: %q", threadLogs)
+	}
+}
+
+func TestMatchLabel(t *testing.T) {
+	//t.Error("Logging on")
+	var tests = []struct {
+		pattern, label string
+		isMatch        bool
+	}{
+		{"server", "server/accept/conn-42", true},
+		{"server/*", "server/accept/conn-42", true},
+		{"*/accept", "server/accept/conn-42", true},
+		{"server/accept/conn-*", "server/accept/conn-42", true},
+		{"client", "server/accept/conn-42", false},
+		{"serv", "server", false},
+		{"server", "", false},
+	}
+	for _, test := range tests {
+		if isMatch := matchLabel(test.pattern, test.label); isMatch != test.isMatch {
+			t.Errorf("matchLabel %q %q: %t exp %t", test.pattern, test.label, isMatch, test.isMatch)
+		}
+	}
+}
@@ -21,4 +21,7 @@ type goParent interface {
 	UpdateThread(goEntityID parl.GoEntityID, threadData *ThreadData)
 	Cancel()
 	Context() (ctx context.Context)
+	getErrorLimit() (limit *errorLimit)
+	getTracer() (tracer parl.Tracer)
+	emit(event parl.GoEvent)
 }
@@ -7,6 +7,9 @@ ISC License
 package g0
 
 import (
+	"strings"
+	"sync/atomic"
+
 	"github.com/haraldrudell/parl"
 	"github.com/haraldrudell/parl/goid"
 	"github.com/haraldrudell/parl/pdebug"
@@ -42,15 +45,27 @@ type Go struct {
 	thread *ThreadSafeThreadData
 	// [parl.AwaitableCh] that closes when this Go ends
 	endCh parl.Awaitable
+	// errorLimiter rate limits non-fatal errors if
+	// enabled by [GoGroup.SetErrorLimit]
+	errorLimiter errorLimiter
+	// ticket is thread slot if enabled by [GoGroup.SetMaxConcurrent]
+	ticket *slotTicket
+	// tracer records lifecycle events if enabled by [GoGroup.SetTracer]
+	tracer parl.Tracer
+	// isFirstError is true once a first error was traced
+	isFirstError atomic.Bool
+	// cleanups are functions registered by OnCleanup
+	cleanups goCleanups
 }
 
 // newGo returns a Go object providing functions to a thread operating in a
 // Go thread-group. Thread-safe
 //   - parent is a GoGroup type configured as GoGroup, SubGo or SubGroup
 //   - goInvocation is the invoker of Go, ie. the parent thread
+//   - ticket: thread slot, nil if concurrency is not limited
 //   - returns Go entity ID and thread data since the parent will
 //     immediately need those
-func newGo(parent goParent, goInvocation *pruntime.CodeLocation) (
+func newGo(parent goParent, goInvocation *pruntime.CodeLocation, ticket *slotTicket) (
 	g0 parl.Go,
 	goEntityID parl.GoEntityID,
 	threadData *ThreadData) {
@@ -62,6 +77,8 @@ func newGo(parent goParent, goInvocation *pruntime.CodeLocation) (
 		goParent:        parent,
 		creatorThreadId: goid.GoID(),
 		thread:          NewThreadSafeThreadData(),
+		ticket:          ticket,
+		tracer:          parent.getTracer(),
 	}
 	g.thread.SetCreator(goInvocation)
 
@@ -83,20 +100,50 @@ func (g *Go) SubGroup(onFirstFatal ...parl.GoFatalCallback) (subGroup parl.SubGr
 }
 
 // AddError emits a non-fatal errors
+//   - errors may be rate limited by [GoGroup.SetErrorLimit]
 func (g *Go) AddError(err error) {
 	g.ensureThreadData()
 
 	if err == nil {
 		return // nil error return
 	}
+	g.traceFirstError(err)
+
+	// rate limiting
+	if limit := g.goParent.getErrorLimit(); limit != nil {
+		var isForward, summary = g.errorLimiter.add(err, limit)
+		if summary != nil {
+			g.ConsumeError(NewGoError(summary, parl.GeNonFatal, g))
+		}
+		if !isForward {
+			return // error suppressed return
+		}
+	}
 
 	g.ConsumeError(NewGoError(perrors.Stack(err), parl.GeNonFatal, g))
 }
 
+// OnCleanup registers cleanup to execute exactly once when the thread’s
+// context is canceled or the thread exits, whichever occurs first
+//   - cleanup executes on its own goroutine
+//   - a cleanup panic is a non-fatal GoError
+//   - Done awaits cleanups in progress
+//   - panics after Done
+func (g *Go) OnCleanup(cleanup func()) {
+	if cleanup == nil {
+		panic(parl.NilError("cleanup"))
+	}
+	g.ensureThreadData().cleanups.add(g.Context(), cleanup, g)
+}
+
 // Done handles thread exit. Deferrable
 //   - *errp contains possible fatalk thread error
 //   - errp can be nil
 func (g *Go) Done(errp *error) {
+
+	// free any thread slot without awaiting it
+	g.ticket.release()
+
 	if !g.ensureThreadData().endCh.Close() {
 		panic(perrors.ErrorfPF("Go received multiple Done: “%s”", perrors.ErrpString(errp)))
 	}
@@ -107,6 +154,30 @@ func (g *Go) Done(errp *error) {
 		err = perrors.Stack(*errp)
 	}
 
+	if err != nil {
+		g.traceFirstError(err)
+	}
+	g.trace("done")
+	var td = g.thread.Get()
+	g.goParent.emit(parl.GoEvent{
+		Type:     parl.GoEventExit,
+		Thread:   g.EntityID(),
+		ThreadID: td.threadID,
+		Label:    td.label,
+		Err:      err,
+	})
+
+	// thread data is no longer provided to panic hooks
+	parl.SetPanicThreadData(g.thread.ThreadID(), nil)
+
+	// cleanups not yet executed
+	g.cleanups.end(g)
+
+	// errors suppressed by rate limiting
+	if summary := g.errorLimiter.flush(); summary != nil {
+		g.ConsumeError(NewGoError(summary, parl.GeNonFatal, g))
+	}
+
 	// notify parent of exit
 	g.goParent.GoDone(g, err)
 }
@@ -135,16 +206,16 @@ func (g *Go) WaitCh() (ch parl.AwaitableCh) { return g.endCh.Ch() }
 func (g *Go) ensureThreadData(label ...string) (g1 *Go) {
 	g1 = g
 
+	// queued concurrency limit: await thread slot
+	g.ticket.await(g.Context().Done())
+
 	// if thread-data has already been collected, do nothing
 	if g.thread.HaveThreadID() {
 		return // already have thread-data return
 	}
 
-	// optional printable thread name
-	var label0 string
-	if len(label) > 0 {
-		label0 = label[0]
-	}
+	// optional printable thread name, hierarchical if multiple labels
+	var label0 = strings.Join(label, parl.LabelSeparator)
 
 	// get stack that contains thread ID, go function, go-function invoker
 	// for the new thread
@@ -157,10 +228,46 @@ func (g *Go) ensureThreadData(label ...string) (g1 *Go) {
 
 	// propagate thread information to parent
 	g.UpdateThread(g.EntityID(), g.thread.Get())
+	// provide thread information to panic hooks
+	parl.SetPanicThreadData(stack.ID(), g.thread.Get())
+
+	g.traceRegister()
 
 	return
 }
 
+// traceRegister assigns the thread to its tracer task and
+// records creation and registration
+func (g *Go) traceRegister() {
+	if g.tracer == nil {
+		return // not tracing return
+	}
+	var td = g.thread.Get()
+	var task = td.label
+	if task == "" {
+		task = td.funcLocation.Short()
+	}
+	g.tracer.AssignTaskToThread(td.threadID, parl.TracerTaskID(task))
+	g.trace(parl.Sprintf("created by thread %s at %s", g.creatorThreadId, td.createLocation.Short()))
+	g.trace(parl.Sprintf("registered thread %s", td.threadID))
+}
+
+// traceFirstError records the first non-fatal or fatal error
+func (g *Go) traceFirstError(err error) {
+	if g.tracer == nil || !g.isFirstError.CompareAndSwap(false, true) {
+		return // not tracing or not first error return
+	}
+	g.trace("first error: " + perrors.Short(err))
+}
+
+// trace records a lifecycle event if tracing
+func (g *Go) trace(text string) {
+	if g.tracer == nil {
+		return // not tracing return
+	}
+	g.tracer.RecordTaskEvent(g.thread.ThreadID(), text)
+}
+
 // g1ID:4:g0.(*g1WaitGroup).Go-g1-thread-group.go:63
 func (g *Go) String() (s string) {
 	td := g.thread.Get()
@@ -15,6 +15,7 @@ import (
 	"github.com/haraldrudell/parl"
 	"github.com/haraldrudell/parl/goid"
 	"github.com/haraldrudell/parl/pruntime"
+	"github.com/haraldrudell/parl/tracer"
 )
 
 func TestGo(t *testing.T) {
@@ -179,3 +180,78 @@ func TestGo_Frames(t *testing.T) {
 		t.Errorf("g0.SubGroup() invoker location: %q expPrefix %q", subGroup.String(), cL.Short())
 	}
 }
+
+func TestGoTracer(t *testing.T) {
+	//t.Error("Logging on")
+	var label = "worker"
+	var expPrefixes = []string{"created by thread", "registered thread", "first error: y", "done"}
+
+	var traceRecorder = tracer.NewTracer()
+	var goGroup = NewGoGroup(context.Background())
+	goGroup.SetTracer(traceRecorder)
+	// the tracer applies to subordinate thread-groups
+	var g = goGroup.SubGo().Go()
+	go func(g parl.Go) {
+		var err = errors.New("x")
+		defer g.Done(&err)
+
+		g.Register(label)
+		g.AddError(errors.New("y"))
+	}(g)
+	goGroup.Wait()
+
+	var records = traceRecorder.Records(false)
+	var recordList = records[parl.TracerTaskID(label)]
+	if len(records) != 1 || len(recordList) != len(expPrefixes) {
+		t.Fatalf("records: %v", records)
+	}
+	for i, record := range recordList {
+		if _, text := record.Values(); !strings.HasPrefix(text, expPrefixes[i]) {
+			t.Errorf("record %d %q exp prefix %q", i, text, expPrefixes[i])
+		}
+	}
+}
+
+func TestGoOnCleanup(t *testing.T) {
+	//t.Error("Logging on")
+	var errPanic = errors.New("cleanup panic")
+
+	var goGroup = NewGoGroup(context.Background())
+	// g2 keeps the thread-group alive
+	var g, g2 = goGroup.Go(), goGroup.Go()
+
+	// cleanup on thread exit: panic is a non-fatal error
+	var exitCount int
+	func() {
+		defer g.Done(nil)
+
+		g.OnCleanup(func() {
+			exitCount++
+			panic(errPanic)
+		})
+	}()
+	// Done awaits the cleanup
+	if exitCount != 1 {
+		t.Errorf("exit cleanup count %d exp 1", exitCount)
+	}
+	var goError, _ = parl.AwaitValue(goGroup.GoError())
+	if !errors.Is(goError.Err(), errPanic) || goError.ErrContext() != parl.GeNonFatal {
+		t.Errorf("goError %v", goError)
+	}
+
+	// cleanup on cancel executes prior to thread exit and only once
+	g = g2
+	var cancelCh = make(chan struct{})
+	var cancelCount int
+	g.OnCleanup(func() {
+		cancelCount++
+		close(cancelCh)
+	})
+	goGroup.Cancel()
+	<-cancelCh
+	g.Done(nil)
+	if cancelCount != 1 {
+		t.Errorf("cancel cleanup count %d exp 1", cancelCount)
+	}
+	goGroup.Wait()
+}
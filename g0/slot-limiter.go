@@ -0,0 +1,167 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/haraldrudell/parl"
+)
+
+// closedSlotCh is returned by SlotCh when a slot is available
+var closedSlotCh = func() (ch parl.AwaitableCh) {
+	var a parl.Awaitable
+	a.Close()
+	return a.Ch()
+}()
+
+// slotLimiter limits the number of concurrently running threads
+// of a thread-group: [GoGroup.SetMaxConcurrent]
+//   - each Go of a limited thread-group holds a [slotTicket]
+//   - tickets are granted in order of request
+//   - initialization-free, thread-safe
+type slotLimiter struct {
+	// lock makes fields thread-safe
+	lock sync.Mutex
+	// max is the number of slots, [parl.NoMaxConcurrent]: unlimited
+	max int
+	// mode is block or queue
+	mode parl.ConcurrencyMode
+	// running is the number of granted tickets
+	running int
+	// queue is tickets awaiting a slot in request order
+	queue []*slotTicket
+	// available is closed when a slot becomes available, nil if not requested
+	available *parl.Awaitable
+}
+
+// slotTicket is a Go’s claim on a thread slot
+type slotTicket struct {
+	limiter *slotLimiter
+	// granted closes when the ticket is granted or released
+	granted parl.Awaitable
+	// state is behind limiter.lock
+	state ticketState
+}
+
+const (
+	ticketWaiting ticketState = iota
+	ticketGranted
+	ticketReleased
+)
+
+// ticketState is waiting granted released
+type ticketState uint8
+
+// setMax updates the limit granting waiting tickets if slots became available
+func (l *slotLimiter) setMax(n int, mode parl.ConcurrencyMode) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.max = max(n, parl.NoMaxConcurrent)
+	l.mode = mode
+	l.grant()
+}
+
+// ticket returns a ticket for a new Go
+//   - ticket nil: the thread-group is not limited
+//   - isBlock: the ticket should be awaited prior to returning the Go
+func (l *slotLimiter) ticket() (ticket *slotTicket, isBlock bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.max == parl.NoMaxConcurrent {
+		return // unlimited return
+	}
+	ticket = &slotTicket{limiter: l}
+	l.queue = append(l.queue, ticket)
+	l.grant()
+	isBlock = l.mode == parl.ConcurrencyBlock
+
+	return
+}
+
+// slotCh returns a channel that closes when a slot is available
+func (l *slotLimiter) slotCh() (ch parl.AwaitableCh) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.isAvailable() {
+		return closedSlotCh
+	} else if l.available == nil {
+		l.available = &parl.Awaitable{}
+	}
+	return l.available.Ch()
+}
+
+// awaiting returns the number of tickets awaiting a slot
+func (l *slotLimiter) awaiting() (count int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return len(l.queue)
+}
+
+// grant grants waiting tickets while slots are available
+//   - behind lock
+func (l *slotLimiter) grant() {
+	for len(l.queue) > 0 && (l.max == parl.NoMaxConcurrent || l.running < l.max) {
+		var ticket = l.queue[0]
+		l.queue[0] = nil
+		l.queue = l.queue[1:]
+		ticket.state = ticketGranted
+		l.running++
+		ticket.granted.Close()
+	}
+	if l.available != nil && l.isAvailable() {
+		l.available.Close()
+		l.available = nil
+	}
+}
+
+// isAvailable returns true if a new ticket would be granted immediately
+//   - behind lock
+func (l *slotLimiter) isAvailable() (isAvailable bool) {
+	return l.max == parl.NoMaxConcurrent || (l.running < l.max && len(l.queue) == 0)
+}
+
+// await blocks until the ticket is granted or released or done closes
+//   - nil ticket: does not block
+func (t *slotTicket) await(done <-chan struct{}) {
+	if t == nil {
+		return
+	}
+	select {
+	case <-t.granted.Ch():
+	case <-done:
+	}
+}
+
+// release frees the ticket’s slot or withdraws a waiting ticket
+//   - idempotent, nil ticket: noop
+func (t *slotTicket) release() {
+	if t == nil {
+		return
+	}
+	var l = t.limiter
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	switch t.state {
+	case ticketGranted:
+		l.running--
+	case ticketWaiting:
+		if i := slices.Index(l.queue, t); i != -1 {
+			l.queue = slices.Delete(l.queue, i, i+1)
+		}
+	default:
+		return // already released return
+	}
+	t.state = ticketReleased
+	t.granted.Close()
+	l.grant()
+}
@@ -0,0 +1,81 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+func TestSetMaxConcurrent(t *testing.T) {
+	//t.Error("Logging on")
+	var shortTime = 10 * time.Millisecond
+
+	var goGroup = NewGoGroup(context.Background())
+	goGroup.EnableTermination(parl.PreventTermination)
+	goGroup.SetMaxConcurrent(1)
+
+	// the only slot is taken
+	var g1 = goGroup.Go()
+	select {
+	case <-goGroup.SlotCh():
+		t.Error("SlotCh closed with all slots taken")
+	default:
+	}
+
+	// blocking Go awaits Done of g1
+	var goCh = make(chan parl.Go, 1)
+	go func() { goCh <- goGroup.Go() }()
+	select {
+	case <-goCh:
+		t.Fatal("Go did not block")
+	case <-time.After(shortTime):
+	}
+	if m := goGroup.(*GoGroup).Metrics(); m.AwaitingSlot != 1 {
+		t.Errorf("AwaitingSlot %d exp 1", m.AwaitingSlot)
+	}
+	var err error
+	g1.Done(&err)
+	var g2 = <-goCh
+
+	// queued Go returns immediately, Register awaits a slot
+	goGroup.SetMaxConcurrent(1, parl.ConcurrencyQueue)
+	var g3 = goGroup.Go()
+	var registerCh = make(chan struct{})
+	go func() {
+		defer close(registerCh)
+		g3.Register()
+	}()
+	select {
+	case <-registerCh:
+		t.Fatal("Register did not block")
+	case <-time.After(shortTime):
+	}
+	var slotCh = goGroup.SlotCh()
+	g2.Done(&err)
+	<-registerCh
+	select {
+	case <-slotCh:
+		t.Error("SlotCh closed while g3 runs")
+	default:
+	}
+	g3.Done(&err)
+	<-slotCh
+
+	// removing the limit
+	goGroup.SetMaxConcurrent(parl.NoMaxConcurrent)
+	select {
+	case <-goGroup.SlotCh():
+	default:
+		t.Error("SlotCh not closed unlimited")
+	}
+	goGroup.Go().Done(&err)
+	goGroup.EnableTermination(parl.AllowTermination)
+	goGroup.Wait()
+}
@@ -0,0 +1,40 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package g0
+
+import (
+	"path"
+	"strings"
+
+	"github.com/haraldrudell/parl"
+)
+
+// matchLabel returns true if pattern matches label or any of its ancestors
+//   - pattern: [path.Match] pattern, previously validated
+//   - “server” and “server/*” match “server/accept/conn-42”
+func matchLabel(pattern, label string) (isMatch bool) {
+	for {
+		if isMatch, _ = path.Match(pattern, label); isMatch {
+			return // match return
+		}
+		var index = strings.LastIndex(label, parl.LabelSeparator)
+		if index == -1 {
+			return // no match return
+		}
+		label = label[:index]
+	}
+}
+
+// hasLabelPrefix returns true if label is prefix or below prefix
+//   - prefix “server” matches “server” and “server/accept/conn-42”
+//     but not “serverX”
+//   - empty prefix matches any label
+func hasLabelPrefix(label, prefix string) (hasPrefix bool) {
+	prefix = strings.TrimSuffix(prefix, parl.LabelSeparator)
+	return prefix == "" ||
+		label == prefix ||
+		strings.HasPrefix(label, prefix) && strings.HasPrefix(label[len(prefix):], parl.LabelSeparator)
+}
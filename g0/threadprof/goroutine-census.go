@@ -0,0 +1,79 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package threadprof
+
+import (
+	"bytes"
+	"runtime"
+
+	"github.com/haraldrudell/parl/pruntime"
+	"github.com/haraldrudell/parl/pruntime/pruntimelib"
+)
+
+const (
+	// initial buffer size for all-goroutine stack traces
+	censusAllocationStep = 64 * 1024
+	// runtime.Stack argument for all goroutines
+	censusAllGoroutines = true
+)
+
+var (
+	// runtime.Stack separates goroutines with an empty line
+	censusGoroutineSeparator = []byte("\n\n")
+	// runtime.Stack lines
+	censusNewline = []byte{'\n'}
+	// prefix of the line identifying the go statement
+	censusCreatedBy = []byte("created by ")
+)
+
+// GoroutineCensus counts all goroutines of the process by
+// the code location of the go statement that created them
+//   - total: the number of goroutines including the main thread
+//   - creators: key is creator code location like
+//     “g0.(*GoGroup).Go()-go-group.go:51”, value is number of
+//     goroutines created at that location that are still running
+//   - GoroutineCensus briefly stops the world
+func GoroutineCensus() (total int, creators map[string]int) {
+	creators = make(map[string]int)
+	for _, trace := range bytes.Split(allGoroutineTraces(), censusGoroutineSeparator) {
+		if trace = bytes.TrimSpace(trace); len(trace) == 0 {
+			continue
+		}
+		total++
+
+		// a goroutine’s trace ends with two lines: created by and file-line
+		var lines = bytes.Split(trace, censusNewline)
+		if len(lines) < 2 {
+			continue
+		}
+		var createdLine = lines[len(lines)-2]
+		if !bytes.HasPrefix(createdLine, censusCreatedBy) {
+			continue // main thread
+		}
+		var creator pruntime.CodeLocation
+		creator.FuncName, _, _ = pruntimelib.ParseCreatedLine(createdLine)
+		// runtime.Stack file-line is indented by tab
+		creator.File, creator.Line = pruntimelib.ParseFileLine(append([]byte{'\t'}, bytes.TrimSpace(lines[len(lines)-1])...))
+		creators[creator.Short()]++
+	}
+
+	return
+}
+
+// allGoroutineTraces returns stack traces for all goroutines
+func allGoroutineTraces() (traces []byte) {
+	var buf []byte
+	var bytesWritten int
+	for size := censusAllocationStep; ; size *= 2 {
+		buf = make([]byte, size)
+		if bytesWritten = runtime.Stack(buf, censusAllGoroutines); bytesWritten < size {
+			break
+		}
+	}
+	traces = buf[:bytesWritten]
+
+	return
+}
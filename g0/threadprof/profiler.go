@@ -0,0 +1,329 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+// Package threadprof provides a sampling CPU and scheduling profiler for
+// the threads of a g0 thread-group, runtime metrics sampling and
+// snapshots reporting counter and goroutine activity between two points in time
+package threadprof
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/g0"
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/pruntime/pruntimelib"
+)
+
+const (
+	// DefaultInterval is the default time between samples
+	DefaultInterval = 10 * time.Millisecond
+	// maxStackDepth is the number of innermost frames retained per sample
+	maxStackDepth = 8
+	// initial size of buffer for all goroutine stacks
+	stackBufferSize = 64 * 1024
+	// goroutine status prefixes from runtime.Stack
+	statusRunning  = "running"
+	statusRunnable = "runnable"
+	statusSyscall  = "syscall"
+)
+
+// Profiler samples the goroutine stacks of the threads of a g0 thread-group
+//   - samples are attributed to thread labels. Unnamed threads are attributed to
+//     their go-function
+//   - on-CPU time is estimated from samples in status “running”,
+//     scheduling delay from status “runnable”
+//   - thread creations and exits are counted by the thread-group
+//   - profiling is toggled at runtime by [Profiler.SetProfiling]
+//   - [Profiler.Report] returns a snapshot that renders as a table or
+//     folded stacks for flame graphs
+//   - thread-safe
+//
+// Usage:
+//
+//	var threadGroup = g0.NewGoGroup(ctx)
+//	var profiler = threadprof.NewProfiler(threadGroup, 0)
+//	profiler.SetProfiling(true)
+//	…
+//	parl.Log(profiler.Report().String())
+type Profiler struct {
+	goGroup  *g0.GoGroup
+	interval time.Duration
+
+	// lock makes fields below thread-safe
+	lock sync.Mutex
+	// stop is non-nil while sampling thread runs
+	stop chan struct{}
+	// done closes when sampling thread exits
+	done chan struct{}
+	// profiles is per-label accumulation
+	profiles map[string]*ThreadProfile
+	// threadIDs are the thread IDs seen for each label
+	threadIDs map[string]map[parl.ThreadID]struct{}
+	// samples is the number of samples taken
+	samples uint64
+	// duration is the time profiling has been enabled, excluding current period
+	duration time.Duration
+	// started is when the current profiling period began
+	started time.Time
+	// created0 exited0 are thread-group counters at start of profiling
+	created0, exited0 uint64
+	// wasAggregateThreads is the thread-group’s thread aggregation
+	// prior to profiling, restored when profiling stops
+	wasAggregateThreads bool
+	// buffer for runtime.Stack
+	buffer []byte
+}
+
+// NewProfiler returns a profiler for a thread-group
+//   - goGen: [parl.GoGroup] [parl.SubGo] or [parl.SubGroup] implemented by [g0.GoGroup]
+//   - interval: time between samples, 0 is [DefaultInterval]
+//   - profiling begins by [Profiler.SetProfiling]
+func NewProfiler(goGen parl.GoGen, interval time.Duration) (profiler *Profiler) {
+	var goGroup, ok = goGen.(*g0.GoGroup)
+	if !ok {
+		panic(perrors.ErrorfPF("type assertion failed, need GoGroup SubGo or SubGroup, received: %T", goGen))
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Profiler{
+		goGroup:   goGroup,
+		interval:  interval,
+		profiles:  make(map[string]*ThreadProfile),
+		threadIDs: make(map[string]map[parl.ThreadID]struct{}),
+	}
+}
+
+// SetProfiling starts or stops sampling
+//   - enabling profiling makes the thread-group collect thread information.
+//     Stopping profiling restores the prior setting
+//   - accumulated data is retained when profiling is stopped
+//   - sampling uses a thread that exits when profiling is stopped
+func (p *Profiler) SetProfiling(isProfiling bool) {
+	p.lock.Lock()
+	if isProfiling == (p.stop != nil) {
+		p.lock.Unlock()
+		return // no change return
+	}
+	var done = p.done
+	if isProfiling {
+		var _, isAggregateThreads, _, _ = p.goGroup.Internals()
+		p.wasAggregateThreads = isAggregateThreads.Swap(true)
+		p.stop = make(chan struct{})
+		p.done = make(chan struct{})
+		p.started = time.Now()
+		if p.samples == 0 && p.duration == 0 {
+			p.created0, p.exited0 = p.goGroup.ThreadCounts()
+		}
+		go p.sampleThread(p.stop, p.done)
+	} else {
+		close(p.stop)
+		p.stop = nil
+		p.duration += time.Since(p.started)
+		var _, isAggregateThreads, _, _ = p.goGroup.Internals()
+		isAggregateThreads.Store(p.wasAggregateThreads)
+	}
+	p.lock.Unlock()
+
+	// await sampling thread exit
+	if !isProfiling {
+		<-done
+	}
+}
+
+// IsProfiling returns whether sampling is ongoing
+func (p *Profiler) IsProfiling() (isProfiling bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.stop != nil
+}
+
+// Sample takes a single sample
+//   - used by the sampling thread and for on-demand sampling
+func (p *Profiler) Sample() {
+
+	// thread IDs of the thread-group
+	var labels = make(map[parl.ThreadID]string)
+	for _, threadData := range p.goGroup.ThreadsInternal().List() {
+		if threadID := threadData.ThreadID(); threadID.IsValid() {
+			labels[threadID] = threadLabel(threadData)
+		}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.samples++
+	if len(labels) == 0 {
+		return // no threads return
+	}
+
+	// stacks of all goroutines
+	if p.buffer == nil {
+		p.buffer = make([]byte, stackBufferSize)
+	}
+	var n int
+	for {
+		if n = runtime.Stack(p.buffer, true); n < len(p.buffer) {
+			break
+		}
+		p.buffer = make([]byte, 2*len(p.buffer))
+	}
+
+	// goroutine stacks are separated by an empty line
+	for _, goroutine := range bytes.Split(p.buffer[:n], []byte("\n\n")) {
+		var ID, status, err = pruntimelib.ParseFirstLine(goroutine)
+		if err != nil {
+			continue
+		}
+		var threadID = parl.ThreadID(ID)
+		var label, ok = labels[threadID]
+		if !ok {
+			continue // goroutine not in thread-group
+		}
+		p.record(label, threadID, status, foldStack(goroutine))
+	}
+}
+
+// Reset discards accumulated data
+func (p *Profiler) Reset() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	clear(p.profiles)
+	clear(p.threadIDs)
+	p.samples = 0
+	p.duration = 0
+	p.started = time.Now()
+	p.created0, p.exited0 = p.goGroup.ThreadCounts()
+}
+
+// Report returns a snapshot of accumulated profiling data
+//   - threads are ordered by descending on-CPU estimate
+func (p *Profiler) Report() (report *Report) {
+	var created, exited = p.goGroup.ThreadCounts()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	report = &Report{
+		Duration: p.duration,
+		Interval: p.interval,
+		Samples:  p.samples,
+		Created:  created - p.created0,
+		Exited:   exited - p.exited0,
+		Threads:  make([]ThreadProfile, 0, len(p.profiles)),
+	}
+	if p.stop != nil {
+		report.Duration += time.Since(p.started)
+	}
+	for label, profile := range p.profiles {
+		var threadProfile = *profile
+		threadProfile.Threads = len(p.threadIDs[label])
+		threadProfile.OnCPU = time.Duration(profile.Running) * p.interval
+		threadProfile.Scheduling = time.Duration(profile.Runnable) * p.interval
+		threadProfile.Stacks = make(map[string]uint64, len(profile.Stacks))
+		for stack, count := range profile.Stacks {
+			threadProfile.Stacks[stack] = count
+		}
+		report.Threads = append(report.Threads, threadProfile)
+	}
+	report.sort()
+
+	return
+}
+
+// sampleThread samples every interval until stop closes or the thread-group ends
+func (p *Profiler) sampleThread(stop, done chan struct{}) {
+	defer close(done)
+	var err error
+	defer parl.Recover(func() parl.DA { return parl.A() }, &err, parl.NoopErrorSink)
+
+	var _, _, _, endCh = p.goGroup.Internals()
+	var ticker = time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-endCh:
+			return
+		case <-ticker.C:
+		}
+		p.Sample()
+	}
+}
+
+// record accumulates a goroutine sample while holding lock
+func (p *Profiler) record(label string, threadID parl.ThreadID, status, stack string) {
+	var profile = p.profiles[label]
+	if profile == nil {
+		profile = &ThreadProfile{Label: label, Stacks: make(map[string]uint64)}
+		p.profiles[label] = profile
+		p.threadIDs[label] = make(map[parl.ThreadID]struct{})
+	}
+	p.threadIDs[label][threadID] = struct{}{}
+
+	profile.Samples++
+	// status may have suffix: “running, locked to thread”
+	if index := strings.IndexByte(status, ','); index != -1 {
+		status = status[:index]
+	}
+	switch status {
+	case statusRunning:
+		profile.Running++
+	case statusRunnable:
+		profile.Runnable++
+	case statusSyscall:
+		profile.Syscall++
+	default:
+		profile.Waiting++
+	}
+	if stack != "" {
+		profile.Stacks[stack]++
+	}
+}
+
+// threadLabel returns the label samples are attributed to
+//   - thread name or go-function
+func threadLabel(threadData *g0.ThreadData) (label string) {
+	if label = threadData.Name(); label != "" {
+		return
+	} else if funcLocation := threadData.Func(); funcLocation.IsSet() {
+		return funcLocation.PackFunc()
+	}
+	return threadData.ThreadID().String()
+}
+
+// foldStack returns function names of a goroutine stack outermost first
+// separated by semicolon
+//   - “main.main;main.f”
+func foldStack(goroutine []byte) (stack string) {
+	// first line is goroutine ID, then pairs of function and file lines
+	var lines = bytes.Split(goroutine, []byte("\n"))
+	var funcNames []string
+	for i := 1; i < len(lines) && len(funcNames) < maxStackDepth; i += 2 {
+		var line = lines[i]
+		if len(line) == 0 || line[0] == '\t' || bytes.HasPrefix(line, []byte("created by ")) {
+			break
+		} else if bytes.IndexByte(line, '(') < 1 {
+			break
+		}
+		var funcName, _ = pruntimelib.ParseFuncLine(line)
+		funcNames = append(funcNames, funcName)
+	}
+	// reverse to outermost first
+	for i, j := 0, len(funcNames)-1; i < j; i, j = i+1, j-1 {
+		funcNames[i], funcNames[j] = funcNames[j], funcNames[i]
+	}
+
+	return strings.Join(funcNames, ";")
+}
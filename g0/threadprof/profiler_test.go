@@ -0,0 +1,104 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package threadprof
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/g0"
+)
+
+func TestProfiler(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		label     = "sleeper"
+		sampleCnt = 3
+	)
+	var (
+		goGroup  = g0.NewGoGroup(context.Background())
+		profiler = NewProfiler(goGroup, time.Hour)
+		isReady  = make(chan struct{})
+	)
+
+	// enable profiling prior to launching threads
+	profiler.SetProfiling(true)
+	if !profiler.IsProfiling() {
+		t.Error("IsProfiling false")
+	}
+
+	// a named thread awaiting cancel
+	go func(g parl.Go) {
+		var err error
+		defer g.Done(&err)
+		g.Register(label)
+		close(isReady)
+		<-g.Context().Done()
+	}(goGroup.Go())
+	<-isReady
+
+	for i := 0; i < sampleCnt; i++ {
+		profiler.Sample()
+	}
+	goGroup.Cancel()
+	goGroup.Wait()
+	profiler.SetProfiling(false)
+
+	// stopping profiling should restore thread aggregation
+	var _, isAggregateThreads, _, _ = goGroup.(*g0.GoGroup).Internals()
+	if isAggregateThreads.Load() {
+		t.Error("isAggregateThreads not restored")
+	}
+
+	var report = profiler.Report()
+	if report.Samples != sampleCnt {
+		t.Errorf("Samples %d exp %d", report.Samples, sampleCnt)
+	}
+	if report.Created != 1 || report.Exited != 1 {
+		t.Errorf("Created %d Exited %d exp 1 1", report.Created, report.Exited)
+	}
+	if len(report.Threads) != 1 {
+		t.Fatalf("Threads %d exp 1", len(report.Threads))
+	}
+	var threadProfile = report.Threads[0]
+	if threadProfile.Label != label {
+		t.Errorf("Label %q exp %q", threadProfile.Label, label)
+	}
+	if threadProfile.Samples != sampleCnt || threadProfile.Threads != 1 {
+		t.Errorf("Samples %d Threads %d exp %d 1", threadProfile.Samples, threadProfile.Threads, sampleCnt)
+	}
+	if len(threadProfile.TopStacks(1)) != 1 {
+		t.Errorf("no stacks")
+	}
+	if s := report.String(); !strings.Contains(s, label) {
+		t.Errorf("String missing label:\n%s", s)
+	}
+	if s := report.Folded(); !strings.HasPrefix(s, label+";") {
+		t.Errorf("Folded %q", s)
+	}
+
+	// Reset
+	profiler.Reset()
+	if report = profiler.Report(); report.Samples != 0 || len(report.Threads) != 0 {
+		t.Errorf("Reset Samples %d Threads %d", report.Samples, len(report.Threads))
+	}
+}
+
+func TestFoldStack(t *testing.T) {
+	//t.Error("Logging on")
+	var goroutine = []byte("goroutine 7 [chan receive]:\n" +
+		"main.inner(0x1)\n\t/src/main.go:10 +0x20\n" +
+		"main.outer(...)\n\t/src/main.go:20\n" +
+		"created by main.main in goroutine 1\n\t/src/main.go:30 +0x40")
+	var exp = "main.outer;main.inner"
+
+	if stack := foldStack(goroutine); stack != exp {
+		t.Errorf("foldStack %q exp %q", stack, exp)
+	}
+}
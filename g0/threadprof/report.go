@@ -0,0 +1,152 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package threadprof
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/pstrings"
+)
+
+const (
+	// number of top stacks rendered per thread label
+	reportTopStacks = 3
+	// max width of the thread-label column
+	reportLabelWidth = 40
+)
+
+// Report is a snapshot of [Profiler] data
+type Report struct {
+	// Duration is the time profiling was enabled
+	Duration time.Duration
+	// Interval is the time between samples
+	Interval time.Duration
+	// Samples is the number of samples taken
+	Samples uint64
+	// Created is the number of threads created in the thread-group during profiling
+	Created uint64
+	// Exited is the number of threads that exited during profiling
+	Exited uint64
+	// Threads are per-label profiles by descending on-CPU estimate
+	Threads []ThreadProfile
+}
+
+// ThreadProfile is profiling data for the threads of a label
+type ThreadProfile struct {
+	// Label is thread name or go-function for unnamed threads
+	Label string
+	// Threads is the number of distinct threads sampled
+	Threads int
+	// Samples is the number of goroutine samples
+	Samples uint64
+	// Running is samples executing on a CPU
+	Running uint64
+	// Runnable is samples awaiting a CPU
+	Runnable uint64
+	// Syscall is samples in a system call
+	Syscall uint64
+	// Waiting is samples blocked on channels, locks, I/O or sleep
+	Waiting uint64
+	// OnCPU is estimated cumulative on-CPU time
+	OnCPU time.Duration
+	// Scheduling is estimated cumulative scheduling delay
+	Scheduling time.Duration
+	// Stacks are sample counts per folded stack “main.main;main.f”
+	Stacks map[string]uint64
+}
+
+// TopStacks returns the n most sampled folded stacks
+func (t *ThreadProfile) TopStacks(n int) (stacks []string) {
+	for stack := range t.Stacks {
+		stacks = append(stacks, stack)
+	}
+	slices.SortFunc(stacks, func(a, b string) (result int) {
+		if ca, cb := t.Stacks[a], t.Stacks[b]; ca != cb {
+			if ca > cb {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	if len(stacks) > n {
+		stacks = stacks[:n]
+	}
+
+	return
+}
+
+// Folded returns stacks in folded format for flame-graph tools
+//   - one line per stack: “label;outer;inner count”
+func (r *Report) Folded() (s string) {
+	var sL []string
+	for i := range r.Threads {
+		var t = &r.Threads[i]
+		for stack, count := range t.Stacks {
+			sL = append(sL, parl.Sprintf("%s;%s %d", t.Label, stack, count))
+		}
+	}
+	slices.Sort(sL)
+	if len(sL) > 0 {
+		s = strings.Join(sL, "\n") + "\n"
+	}
+
+	return
+}
+
+// String renders the report as a table followed by top stacks
+//
+//	threadprof 1.5s 150 samples interval 10ms threads created 3 exited 1
+//	label    threads  on-cpu  sched  running  runnable  syscall  waiting
+//	worker         2   400ms   20ms       40         2        0      108
+func (r *Report) String() (s string) {
+	var sb strings.Builder
+	sb.WriteString(parl.Sprintf("threadprof %s %d samples interval %s threads created %d exited %d\n",
+		r.Duration.Round(time.Millisecond), r.Samples, r.Interval, r.Created, r.Exited))
+
+	var table = pstrings.NewTable(
+		pstrings.TableColumn{Heading: "label", MaxWidth: reportLabelWidth},
+		pstrings.TableColumn{Heading: "threads", Align: pstrings.AlignRight},
+		pstrings.TableColumn{Heading: "on-cpu", Align: pstrings.AlignRight},
+		pstrings.TableColumn{Heading: "sched", Align: pstrings.AlignRight},
+		pstrings.TableColumn{Heading: "running", Align: pstrings.AlignRight},
+		pstrings.TableColumn{Heading: "runnable", Align: pstrings.AlignRight},
+		pstrings.TableColumn{Heading: "syscall", Align: pstrings.AlignRight},
+		pstrings.TableColumn{Heading: "waiting", Align: pstrings.AlignRight},
+	)
+	for i := range r.Threads {
+		var t = &r.Threads[i]
+		table.AddRow(t.Label, t.Threads, t.OnCPU.String(), t.Scheduling.String(),
+			t.Running, t.Runnable, t.Syscall, t.Waiting)
+	}
+	sb.WriteString(table.String())
+
+	// top stacks
+	for i := range r.Threads {
+		var t = &r.Threads[i]
+		for _, stack := range t.TopStacks(reportTopStacks) {
+			sb.WriteString(parl.Sprintf("%s %d %s\n", t.Label, t.Stacks[stack], stack))
+		}
+	}
+
+	return sb.String()
+}
+
+// sort orders threads by descending on-CPU estimate, then label
+func (r *Report) sort() {
+	slices.SortFunc(r.Threads, func(a, b ThreadProfile) (result int) {
+		if a.Running != b.Running {
+			if a.Running > b.Running {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Label, b.Label)
+	})
+}
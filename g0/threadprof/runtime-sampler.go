@@ -0,0 +1,404 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package threadprof
+
+import (
+	"math"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+const (
+	// DefaultSamplerInterval is the default time between runtime samples
+	DefaultSamplerInterval = time.Second
+	// DefaultSamplerHistory is the default number of retained samples
+	DefaultSamplerHistory = 60
+)
+
+const (
+	metricHeapLive     = "/gc/heap/live:bytes"
+	metricHeapObjects  = "/gc/heap/objects:objects"
+	metricHeapGoal     = "/gc/heap/goal:bytes"
+	metricGCCycles     = "/gc/cycles/total:gc-cycles"
+	metricGoroutines   = "/sched/goroutines:goroutines"
+	metricGCPauses     = "/gc/pauses:seconds"
+	metricSchedLatency = "/sched/latencies:seconds"
+)
+
+// builtinMetrics are runtime/metrics names read into [RuntimeSample] fields
+var builtinMetrics = []string{
+	metricHeapLive, metricHeapObjects, metricHeapGoal, metricGCCycles,
+	metricGoroutines, metricGCPauses, metricSchedLatency,
+}
+
+// SamplerConfig configures [RuntimeSampler]
+type SamplerConfig struct {
+	// Interval is time between samples
+	//	- 0: [DefaultSamplerInterval]
+	Interval time.Duration
+	// History is the number of samples retained
+	//	- 0: [DefaultSamplerHistory]
+	History int
+	// Names are additional runtime/metrics names of uint64 or float64 kind
+	//	- values are in [RuntimeSample.Values]
+	Names []string
+	// Log prints each sampled interval’s delta using [parl.Log]
+	Log bool
+}
+
+// RuntimeSample is a reading of Go runtime metrics
+//   - counters are cumulative since process start
+//   - metrics unsupported by the Go version read as zero
+type RuntimeSample struct {
+	// At is when the sample was taken
+	At time.Time
+	// HeapLive is bytes of live heap after the last GC
+	HeapLive uint64
+	// HeapObjects is number of heap objects
+	HeapObjects uint64
+	// HeapGoal is the heap size target of the current GC cycle
+	HeapGoal uint64
+	// GCCycles is number of completed GC cycles
+	GCCycles uint64
+	// Goroutines is the current number of goroutines
+	Goroutines uint64
+	// GCPauses is the distribution of stop-the-world GC pauses
+	GCPauses LatencyHistogram
+	// SchedLatencies is the distribution of time goroutines spent runnable
+	// prior to running
+	SchedLatencies LatencyHistogram
+	// Values are values of [SamplerConfig.Names]
+	Values map[string]float64
+}
+
+// LatencyHistogram is a cumulative runtime latency distribution
+type LatencyHistogram struct {
+	// Counts are observations per bucket
+	Counts []uint64
+	// Buckets are bucket boundaries in seconds, one longer than Counts
+	Buckets []float64
+}
+
+// RuntimeDelta is the change between two [RuntimeSample]
+type RuntimeDelta struct {
+	// Elapsed is time between the samples
+	Elapsed time.Duration
+	// GCCycles is GC cycles completed in the interval
+	GCCycles uint64
+	// HeapLive is the change in live heap bytes
+	HeapLive int64
+	// Goroutines is the change in goroutine count
+	Goroutines int64
+	// GCPauses is number of GC pauses in the interval
+	GCPauses uint64
+	// GCPauseP99 GCPauseMax are GC pause 99th percentile and max in the interval
+	GCPauseP99, GCPauseMax time.Duration
+	// SchedP99 SchedMax are scheduling latency 99th percentile and max in the interval
+	SchedP99, SchedMax time.Duration
+}
+
+// RuntimeSampler periodically reads Go runtime/metrics
+//   - retains a ring buffer of samples: [RuntimeSampler.History]
+//   - [RuntimeSampler.Delta] is the change over the last interval,
+//     including GC-pause and scheduling-latency percentiles
+//   - complements the halt package’s HaltDetector: a detected halt can be related to
+//     GC pauses or scheduling latency of the same interval
+//   - sampling is toggled by [RuntimeSampler.SetSampling]
+//   - thread-safe
+//
+// Usage:
+//
+//	var sampler = threadprof.NewRuntimeSampler(threadprof.SamplerConfig{Log: true})
+//	sampler.SetSampling(true)
+//	defer sampler.SetSampling(false)
+//	…
+//	var delta, _ = sampler.Delta()
+type RuntimeSampler struct {
+	interval time.Duration
+	isLog    bool
+	// names are additional metric names
+	names []string
+
+	// lock makes fields below thread-safe
+	lock sync.Mutex
+	// samples is reused runtime/metrics read buffer
+	samples []metrics.Sample
+	// history is a ring buffer of samples
+	history []RuntimeSample
+	// next is index in history for the next sample
+	next int
+	// count is number of samples in history
+	count int
+	// stop is non-nil while sampling thread runs
+	stop chan struct{}
+	// done closes when sampling thread exits
+	done chan struct{}
+}
+
+// NewRuntimeSampler returns a runtime/metrics sampler
+//   - sampling begins by [RuntimeSampler.SetSampling]
+//   - config: optional interval, history length, metric names and logging
+func NewRuntimeSampler(config ...SamplerConfig) (sampler *RuntimeSampler) {
+	var c SamplerConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.Interval <= 0 {
+		c.Interval = DefaultSamplerInterval
+	}
+	if c.History <= 0 {
+		c.History = DefaultSamplerHistory
+	}
+	sampler = &RuntimeSampler{
+		interval: c.Interval,
+		isLog:    c.Log,
+		names:    c.Names,
+		history:  make([]RuntimeSample, c.History),
+	}
+	for _, name := range builtinMetrics {
+		sampler.samples = append(sampler.samples, metrics.Sample{Name: name})
+	}
+	for _, name := range c.Names {
+		sampler.samples = append(sampler.samples, metrics.Sample{Name: name})
+	}
+
+	return
+}
+
+// SetSampling starts or stops periodic sampling
+//   - sampling uses a thread that exits when sampling is stopped
+func (r *RuntimeSampler) SetSampling(isSampling bool) {
+	r.lock.Lock()
+	if isSampling == (r.stop != nil) {
+		r.lock.Unlock()
+		return // no change return
+	}
+	var done = r.done
+	if isSampling {
+		r.stop = make(chan struct{})
+		r.done = make(chan struct{})
+		go r.sampleThread(r.stop, r.done)
+	} else {
+		close(r.stop)
+		r.stop = nil
+	}
+	r.lock.Unlock()
+
+	// await sampling thread exit
+	if !isSampling {
+		<-done
+	}
+}
+
+// IsSampling returns whether periodic sampling is ongoing
+func (r *RuntimeSampler) IsSampling() (isSampling bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.stop != nil
+}
+
+// Sample reads runtime metrics and stores the sample in history
+//   - used by the sampling thread and for on-demand sampling
+func (r *RuntimeSampler) Sample() (sample RuntimeSample) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	metrics.Read(r.samples)
+	sample = RuntimeSample{At: time.Now()}
+	for i := range r.samples {
+		var s = &r.samples[i]
+		switch s.Name {
+		case metricHeapLive:
+			sample.HeapLive = uint64Value(s.Value)
+		case metricHeapObjects:
+			sample.HeapObjects = uint64Value(s.Value)
+		case metricHeapGoal:
+			sample.HeapGoal = uint64Value(s.Value)
+		case metricGCCycles:
+			sample.GCCycles = uint64Value(s.Value)
+		case metricGoroutines:
+			sample.Goroutines = uint64Value(s.Value)
+		case metricGCPauses:
+			sample.GCPauses = latencyHistogram(s.Value)
+		case metricSchedLatency:
+			sample.SchedLatencies = latencyHistogram(s.Value)
+		}
+		if i < len(builtinMetrics) {
+			continue
+		}
+		if sample.Values == nil {
+			sample.Values = make(map[string]float64, len(r.names))
+		}
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			sample.Values[s.Name] = float64(s.Value.Uint64())
+		case metrics.KindFloat64:
+			sample.Values[s.Name] = s.Value.Float64()
+		}
+	}
+	r.history[r.next] = sample
+	r.next = (r.next + 1) % len(r.history)
+	r.count = min(r.count+1, len(r.history))
+
+	return
+}
+
+// Latest returns the most recent sample
+//   - hasValue false: no samples were taken
+func (r *RuntimeSampler) Latest() (sample RuntimeSample, hasValue bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if hasValue = r.count > 0; hasValue {
+		sample = r.history[(r.next-1+len(r.history))%len(r.history)]
+	}
+	return
+}
+
+// History returns retained samples, oldest first
+func (r *RuntimeSampler) History() (samples []RuntimeSample) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	samples = make([]RuntimeSample, r.count)
+	var start = r.next - r.count + len(r.history)
+	for i := range samples {
+		samples[i] = r.history[(start+i)%len(r.history)]
+	}
+	return
+}
+
+// Delta returns the change between the two most recent samples
+//   - hasValue false: fewer than two samples were taken
+func (r *RuntimeSampler) Delta() (delta RuntimeDelta, hasValue bool) {
+	var samples = r.History()
+	if hasValue = len(samples) >= 2; !hasValue {
+		return
+	}
+	delta = samples[len(samples)-1].Delta(&samples[len(samples)-2])
+	return
+}
+
+// sampleThread samples every interval until stop closes
+func (r *RuntimeSampler) sampleThread(stop, done chan struct{}) {
+	defer close(done)
+	var err error
+	defer parl.Recover(func() parl.DA { return parl.A() }, &err, parl.NoopErrorSink)
+
+	var ticker = time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.Sample()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		r.Sample()
+		if !r.isLog {
+			continue
+		}
+		if delta, hasValue := r.Delta(); hasValue {
+			parl.Log("runtime: %s", delta)
+		}
+	}
+}
+
+// Delta returns the change from the earlier sample prev
+func (s *RuntimeSample) Delta(prev *RuntimeSample) (delta RuntimeDelta) {
+	delta = RuntimeDelta{
+		Elapsed:    s.At.Sub(prev.At),
+		GCCycles:   s.GCCycles - prev.GCCycles,
+		HeapLive:   int64(s.HeapLive) - int64(prev.HeapLive),
+		Goroutines: int64(s.Goroutines) - int64(prev.Goroutines),
+	}
+	var pauses = s.GCPauses.Sub(&prev.GCPauses)
+	delta.GCPauses = pauses.Count()
+	delta.GCPauseP99 = pauses.Quantile(0.99)
+	delta.GCPauseMax = pauses.Quantile(1)
+	var sched = s.SchedLatencies.Sub(&prev.SchedLatencies)
+	delta.SchedP99 = sched.Quantile(0.99)
+	delta.SchedMax = sched.Quantile(1)
+
+	return
+}
+
+// Count returns the number of observations
+func (h *LatencyHistogram) Count() (count uint64) {
+	for _, c := range h.Counts {
+		count += c
+	}
+	return
+}
+
+// Quantile returns the upper boundary of the bucket containing quantile q
+//   - q: 0…1, 1 is the greatest observation’s bucket
+//   - no observations: 0
+func (h *LatencyHistogram) Quantile(q float64) (d time.Duration) {
+	var total = h.Count()
+	if total == 0 {
+		return
+	}
+	var rank = uint64(math.Ceil(q * float64(total)))
+	var cumulative uint64
+	for i, c := range h.Counts {
+		if cumulative += c; cumulative >= rank && c > 0 {
+			var seconds = h.Buckets[i+1]
+			if math.IsInf(seconds, 1) {
+				seconds = h.Buckets[i]
+			}
+			return time.Duration(seconds * float64(time.Second))
+		}
+	}
+	return
+}
+
+// Sub returns the observations since the earlier histogram prev
+//   - prev with different buckets: h is returned
+func (h *LatencyHistogram) Sub(prev *LatencyHistogram) (delta LatencyHistogram) {
+	delta = LatencyHistogram{Counts: make([]uint64, len(h.Counts)), Buckets: h.Buckets}
+	copy(delta.Counts, h.Counts)
+	if len(prev.Counts) != len(h.Counts) {
+		return
+	}
+	for i, c := range prev.Counts {
+		delta.Counts[i] -= c
+	}
+	return
+}
+
+// “1s gc: 2 pauses: 4 p99: 120µs max: 250µs sched p99: 80µs max: 1ms heap: +1,024 goroutines: +3”
+func (d RuntimeDelta) String() (s string) {
+	return parl.Sprintf("%s gc: %d pauses: %d p99: %s max: %s sched p99: %s max: %s heap: %+d goroutines: %+d",
+		d.Elapsed.Round(time.Millisecond), d.GCCycles, d.GCPauses, d.GCPauseP99, d.GCPauseMax,
+		d.SchedP99, d.SchedMax, d.HeapLive, d.Goroutines,
+	)
+}
+
+// uint64Value returns value if of uint64 kind
+func uint64Value(value metrics.Value) (u64 uint64) {
+	if value.Kind() == metrics.KindUint64 {
+		u64 = value.Uint64()
+	}
+	return
+}
+
+// latencyHistogram returns a copy of a float64-histogram value
+func latencyHistogram(value metrics.Value) (h LatencyHistogram) {
+	if value.Kind() != metrics.KindFloat64Histogram {
+		return
+	}
+	var histogram = value.Float64Histogram()
+	h.Counts = append([]uint64(nil), histogram.Counts...)
+	h.Buckets = histogram.Buckets
+
+	return
+}
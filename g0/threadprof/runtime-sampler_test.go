@@ -0,0 +1,61 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package threadprof
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRuntimeSampler(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		history     = 2
+		extraName   = "/gc/heap/allocs:bytes"
+		sampleCount = 3
+	)
+
+	var sampler = NewRuntimeSampler(SamplerConfig{History: history, Names: []string{extraName}})
+	if _, hasValue := sampler.Delta(); hasValue {
+		t.Error("Delta without samples")
+	}
+	for i := 0; i < sampleCount; i++ {
+		runtime.GC()
+		sampler.Sample()
+	}
+	var samples = sampler.History()
+	if len(samples) != history {
+		t.Fatalf("History length %d exp %d", len(samples), history)
+	}
+	if !samples[0].At.Before(samples[1].At) && !samples[0].At.Equal(samples[1].At) {
+		t.Error("History not oldest first")
+	}
+	var latest, _ = sampler.Latest()
+	if latest.Goroutines == 0 || latest.HeapGoal == 0 || latest.Values[extraName] == 0 {
+		t.Errorf("sample %+v", latest)
+	}
+	var delta, hasValue = sampler.Delta()
+	if !hasValue || delta.GCCycles == 0 {
+		t.Errorf("Delta %t %s", hasValue, delta)
+	}
+	if s := delta.String(); !strings.Contains(s, "gc:") {
+		t.Errorf("String %q", s)
+	}
+
+	// sampling thread
+	sampler = NewRuntimeSampler(SamplerConfig{Interval: time.Millisecond})
+	sampler.SetSampling(true)
+	if !sampler.IsSampling() {
+		t.Error("IsSampling false")
+	}
+	time.Sleep(10 * time.Millisecond)
+	sampler.SetSampling(false)
+	if _, hasValue = sampler.Latest(); !hasValue {
+		t.Error("no sample from thread")
+	}
+}
@@ -0,0 +1,208 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package threadprof
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+const (
+	// number of lines per section for [ActivityReport.String]
+	defaultReportTopN = 10
+)
+
+// Snapshot is a point-in-time record of counter values and goroutines
+//   - [Snapshot.Since] returns activity from the snapshot until now
+//   - [Snapshot.Diff] returns activity between two snapshots
+//   - used to track down gradual goroutine and work leaks in
+//     long-running services
+type Snapshot struct {
+	// At is when the snapshot was taken
+	At time.Time
+	// Counters are values of counters and datapoints
+	Counters map[parl.CounterID]CounterSample
+	// Goroutines is the number of goroutines including the main thread
+	Goroutines int
+	// Creators are goroutine counts by creator code location
+	Creators map[string]int
+	// counterSet is the counter set snapshotted, possibly nil
+	counterSet parl.CounterSet
+}
+
+// CounterSample is the value of a counter or datapoint in a [Snapshot]
+type CounterSample struct {
+	// Value is the monotonic value of a counter or
+	// the current value of a datapoint
+	Value uint64
+	// Running is the running value of a counter
+	Running uint64
+	// IsDatapoint is true for a datapoint
+	IsDatapoint bool
+}
+
+// ActivityReport is the difference between two snapshots
+type ActivityReport struct {
+	// Duration is the time elapsed between the two snapshots
+	Duration time.Duration
+	// Goroutines is the change in number of goroutines
+	Goroutines int
+	// Creators are goroutine-count changes by creator code location,
+	// greatest increase first
+	//	- creators with no change are omitted
+	Creators []CreatorDelta
+	// Counters are counter changes, fastest growing first
+	//	- counters with no change are omitted
+	Counters []CounterDelta
+}
+
+// CreatorDelta is the change in goroutines created at a code location
+type CreatorDelta struct {
+	// Creator is code location like “g0.(*GoGroup).Go()-go-group.go:51”
+	Creator       string
+	Before, After int
+	// Delta is After less Before
+	Delta int
+}
+
+// CounterDelta is the change in value of a counter or datapoint
+type CounterDelta struct {
+	ID            parl.CounterID
+	Before, After uint64
+	// Delta is After less Before
+	Delta int64
+	// Rate is change per second
+	Rate float64
+}
+
+// NewSnapshot records counter values and goroutines
+//   - counterSet: optional counters to snapshot, typically counters of package counter
+//   - goroutine creators are obtained from stack traces
+//     which briefly stops the world
+func NewSnapshot(counterSet parl.CounterSet) (snapshot *Snapshot) {
+	var s = Snapshot{
+		At:         time.Now(),
+		Counters:   make(map[parl.CounterID]CounterSample),
+		counterSet: counterSet,
+	}
+	s.Goroutines, s.Creators = GoroutineCensus()
+	if counterSet == nil {
+		return &s
+	}
+
+	var _, m = counterSet.GetCounters()
+	for id, item := range m {
+		if counter, ok := item.(parl.CounterValues); ok {
+			var value, running, _ = counter.Get()
+			s.Counters[id] = CounterSample{Value: value, Running: running}
+		} else if datapoint, ok := item.(parl.DatapointValue); ok {
+			s.Counters[id] = CounterSample{Value: datapoint.DatapointValue(), IsDatapoint: true}
+		}
+	}
+
+	return &s
+}
+
+// Since returns activity from the snapshot until now
+//   - a new snapshot is taken of the same counter set
+func (s *Snapshot) Since() (report *ActivityReport) { return s.Diff(NewSnapshot(s.counterSet)) }
+
+// Diff returns activity from s until later
+func (s *Snapshot) Diff(later *Snapshot) (report *ActivityReport) {
+	var r = ActivityReport{
+		Duration:   later.At.Sub(s.At),
+		Goroutines: later.Goroutines - s.Goroutines,
+	}
+	var seconds = r.Duration.Seconds()
+
+	// goroutine creators present in either snapshot
+	for creator, after := range later.Creators {
+		if before := s.Creators[creator]; after != before {
+			r.Creators = append(r.Creators, CreatorDelta{Creator: creator, Before: before, After: after, Delta: after - before})
+		}
+	}
+	for creator, before := range s.Creators {
+		if _, ok := later.Creators[creator]; !ok {
+			r.Creators = append(r.Creators, CreatorDelta{Creator: creator, Before: before, Delta: -before})
+		}
+	}
+	slices.SortFunc(r.Creators, compareCreatorDelta)
+
+	// counters present in the later snapshot
+	for id, after := range later.Counters {
+		var before = s.Counters[id]
+		if after.Value == before.Value {
+			continue
+		}
+		var c = CounterDelta{ID: id, Before: before.Value, After: after.Value, Delta: int64(after.Value - before.Value)}
+		if seconds > 0 {
+			c.Rate = float64(c.Delta) / seconds
+		}
+		r.Counters = append(r.Counters, c)
+	}
+	slices.SortFunc(r.Counters, compareCounterDelta)
+
+	return &r
+}
+
+// Report lists the topN greatest goroutine creators and
+// fastest-growing counters
+//   - topN 0 or less: all
+func (r *ActivityReport) Report(topN int) (s string) {
+	var sL = []string{parl.Sprintf("activity during %s goroutines: %+d", r.Duration.Round(time.Millisecond), r.Goroutines)}
+
+	var creators = r.Creators
+	if topN > 0 && len(creators) > topN {
+		creators = creators[:topN]
+	}
+	if len(creators) > 0 {
+		sL = append(sL, "goroutine creators:")
+		for _, c := range creators {
+			sL = append(sL, parl.Sprintf("%+d (%d→%d) %s", c.Delta, c.Before, c.After, c.Creator))
+		}
+	}
+
+	var counters = r.Counters
+	if topN > 0 && len(counters) > topN {
+		counters = counters[:topN]
+	}
+	if len(counters) > 0 {
+		sL = append(sL, "counters:")
+		for _, c := range counters {
+			sL = append(sL, parl.Sprintf("%.1f/s %+d (%d→%d) %s", c.Rate, c.Delta, c.Before, c.After, c.ID))
+		}
+	}
+
+	return strings.Join(sL, "\n")
+}
+
+// String returns a report of the top 10 goroutine creators and counters
+func (r *ActivityReport) String() (s string) { return r.Report(defaultReportTopN) }
+
+// compareCreatorDelta orders greatest increase first
+func compareCreatorDelta(a, b CreatorDelta) (result int) {
+	if a.Delta != b.Delta {
+		if a.Delta > b.Delta {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a.Creator, b.Creator)
+}
+
+// compareCounterDelta orders greatest increase first
+func compareCounterDelta(a, b CounterDelta) (result int) {
+	if a.Delta != b.Delta {
+		if a.Delta > b.Delta {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(string(a.ID), string(b.ID))
+}
@@ -0,0 +1,76 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package threadprof
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/counter"
+)
+
+func TestSnapshot(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		counterID    parl.CounterID = "requests"
+		expDelta                    = 3
+		expCreated                  = 2
+		expCreatorIn                = "snapshot_test.go"
+	)
+	var (
+		counters = counter.CountersFactory.NewCounters(true, nil)
+		counter  = counters.GetOrCreateCounter(counterID)
+		exitCh   = make(chan struct{})
+		wg       sync.WaitGroup
+		report   *ActivityReport
+	)
+	defer wg.Wait()
+	defer close(exitCh)
+
+	var snapshot = NewSnapshot(counters.(parl.CounterSet))
+
+	// activity: counter increments and leaked goroutines
+	for i := 0; i < expDelta; i++ {
+		counter.Inc()
+	}
+	for i := 0; i < expCreated; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-exitCh
+		}()
+	}
+
+	report = snapshot.Since()
+	t.Logf("report:\n%s", report)
+
+	// Goroutines
+	if report.Goroutines < expCreated {
+		t.Errorf("Goroutines %d exp at least %d", report.Goroutines, expCreated)
+	}
+
+	// Creators
+	var creator CreatorDelta
+	for _, c := range report.Creators {
+		if strings.Contains(c.Creator, expCreatorIn) {
+			creator = c
+			break
+		}
+	}
+	if creator.Delta != expCreated {
+		t.Errorf("creator delta %d exp %d: %v", creator.Delta, expCreated, report.Creators)
+	}
+
+	// Counters
+	if len(report.Counters) != 1 {
+		t.Fatalf("Counters len %d exp 1", len(report.Counters))
+	}
+	if c := report.Counters[0]; c.ID != counterID || c.Delta != expDelta {
+		t.Errorf("counter %q delta %d exp %q %d", c.ID, c.Delta, counterID, expDelta)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import "time"
+
+const (
+	// GoEventCreated: a Go object was created by Go of the thread-group
+	GoEventCreated GoEventType = iota + 1
+	// GoEventExit: a thread invoked Done
+	GoEventExit
+	// GoEventCancel: Cancel was invoked on a thread-group whose
+	// context was not canceled
+	GoEventCancel
+	// GoEventTerminationPrevented: EnableTermination PreventTermination
+	// changed termination state
+	GoEventTerminationPrevented
+	// GoEventTerminationAllowed: EnableTermination AllowTermination
+	// changed termination state
+	GoEventTerminationAllowed
+	// GoEventGroupEnd: the thread-group terminated
+	GoEventGroupEnd
+)
+
+// GoEventType is the kind of a supervision event [GoEvent]
+type GoEventType uint8
+
+// GoEvent is a supervision event of a thread-group provided by
+// [GoGroup.Events]
+//   - allows observers and tests to follow thread and thread-group
+//     lifecycle without parsing debug output
+type GoEvent struct {
+	// Type is the kind of event
+	Type GoEventType
+	// Group is the entity ID of the thread-group where the event occurred
+	Group GoEntityID
+	// Thread is the entity ID of the Go for thread events
+	Thread GoEntityID
+	// ThreadID is the thread ID for GoEventExit, otherwise zero
+	ThreadID ThreadID
+	// Label is the label provided to Go.Register for GoEventExit
+	Label string
+	// Err is fatal error of GoEventExit
+	Err error
+	// At is when the event occurred
+	At time.Time
+}
+
+// “exit 12 label”
+func (e GoEvent) String() (s string) {
+	s = e.Type.String()
+	if e.ThreadID.IsValid() {
+		s += " " + e.ThreadID.String()
+	}
+	if e.Label != "" {
+		s += " " + e.Label
+	}
+	if e.Err != nil {
+		s += " err: " + e.Err.Error()
+	}
+	return
+}
+
+// “created” “exit” “cancel” “prevent” “allow” “end”
+func (t GoEventType) String() (s string) {
+	switch t {
+	case GoEventCreated:
+		return "created"
+	case GoEventExit:
+		return "exit"
+	case GoEventCancel:
+		return "cancel"
+	case GoEventTerminationPrevented:
+		return "prevent"
+	case GoEventTerminationAllowed:
+		return "allow"
+	case GoEventGroupEnd:
+		return "end"
+	}
+	return Sprintf("?goEventType%d", t)
+}
@@ -19,6 +19,7 @@ package parl
 //   - — [GoResult.SetIsError]() sets the error flag manually
 //   - — [GoResult.Remaining]() (remaining int) number of goroutines that have yet to exit
 //   - —
+//   - [ResultCollector] also collects typed results and cancels on error
 //   - passed by value
 //   - getting around that receiver cannot be interface
 //   - receiver is value struct with pointer in the form of an interface
@@ -0,0 +1,27 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+// GoValue retrieves a typed context value of a thread
+//   - key: the key provided to SetValue of the thread-group or
+//     to [context.WithValue] of a context the thread-group derives from
+//   - hasValue false: key is not present or its value is not of type T
+//
+// Usage:
+//
+//	type requestIDKey struct{}
+//	var goGroup = g0.NewGoGroupWith(ctx, g0.WithValue(requestIDKey{}, "req-42"))
+//	go thread(goGroup.Go())
+//	…
+//	func thread(g parl.Go) {
+//	  var requestID, _ = parl.GoValue[string](g, requestIDKey{})
+func GoValue[T any](g Go, key any) (value T, hasValue bool) {
+	if g == nil {
+		panic(NilError("g"))
+	}
+	value, hasValue = g.Context().Value(key).(T)
+	return
+}
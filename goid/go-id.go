@@ -4,6 +4,7 @@ ISC License
 */
 
 // Package goid obtaines a thread’s parl.ThreadID unique goroutine identifier.
+// LeakDetector finds goroutines that persist after a checkpoint.
 package goid
 
 import (
@@ -0,0 +1,307 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package goid
+
+import (
+	"bytes"
+	"cmp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/pruntime"
+	"github.com/haraldrudell/parl/pruntime/pruntimelib"
+)
+
+const (
+	// default time new goroutines have to exit
+	defaultLeakGrace = time.Second
+	// how often Await checks for exited goroutines
+	leakPollInterval = 10 * time.Millisecond
+	// initial buffer size for all-goroutine stack traces
+	leakStackSize = 64 * 1024
+)
+
+// LeakConfig configures [LeakDetector]
+type LeakConfig struct {
+	// Grace is how long a new goroutine may exist before
+	// it is considered leaked
+	//	- default 1 s
+	Grace time.Duration
+	// Ignore are substrings of function names. A goroutine with
+	// a matching function in its stack trace is not reported
+	Ignore []string
+}
+
+// Goroutine is a goroutine found by [LeakDetector]
+type Goroutine struct {
+	// ID is goroutine ID
+	ID parl.ThreadID
+	// Status is “chan receive” or similar
+	Status string
+	// Function is the most recent function of the goroutine
+	Function pruntime.CodeLocation
+	// GoFunction is the function of the go statement
+	GoFunction pruntime.CodeLocation
+	// Creator is the location of the go statement
+	Creator pruntime.CodeLocation
+	// Stack is the goroutine’s stack trace
+	Stack string
+}
+
+// LeakDetector finds goroutines launched after a checkpoint
+// that persist beyond a grace period
+//   - goroutines existing at the checkpoint and
+//     the goroutine invoking the detector are not reported
+//   - tests: [VerifyNoLeaks] or [LeakDetector.Await]
+//   - long-running services: periodic [LeakDetector.Leaks]
+//   - thread-safe
+//
+// Usage:
+//
+//	var detector = goid.NewLeakDetector()
+//	…
+//	for _, g := range detector.Leaks() {
+//	  parl.Log("leaked goroutine: %s", g)
+type LeakDetector struct {
+	grace  time.Duration
+	ignore []string
+	// lock makes baseline and seen thread-safe
+	lock sync.Mutex
+	// baseline are goroutines of the checkpoint, behind lock
+	baseline map[parl.ThreadID]struct{}
+	// seen is when new goroutines were first observed by Leaks, behind lock
+	seen map[parl.ThreadID]time.Time
+}
+
+// LeakT is the part of [testing.TB] used by [VerifyNoLeaks]
+type LeakT interface {
+	Helper()
+	Cleanup(f func())
+	Errorf(format string, args ...any)
+}
+
+// NewLeakDetector returns a leak detector with a checkpoint of
+// the current goroutines
+//   - config: optional grace period and ignored functions
+func NewLeakDetector(config ...LeakConfig) (detector *LeakDetector) {
+	var d = LeakDetector{grace: defaultLeakGrace}
+	if len(config) > 0 {
+		if c := config[0]; c.Grace > 0 {
+			d.grace = c.Grace
+		}
+		d.ignore = slices.Clone(config[0].Ignore)
+	}
+	d.Checkpoint()
+
+	return &d
+}
+
+// VerifyNoLeaks fails the test if goroutines launched during the test
+// persist beyond the grace period after the test ends
+//   - invoked at the beginning of a test function
+//   - uses t.Cleanup so checking takes place after deferred functions
+//
+// Usage:
+//
+//	func TestX(t *testing.T) {
+//	  goid.VerifyNoLeaks(t)
+//	  …
+func VerifyNoLeaks(t LeakT, config ...LeakConfig) {
+	t.Helper()
+	var detector = NewLeakDetector(config...)
+	t.Cleanup(func() {
+		for _, g := range detector.Await() {
+			t.Errorf("leaked goroutine: %s\n%s", g, g.Stack)
+		}
+	})
+}
+
+// Checkpoint makes the current goroutines the baseline
+func (d *LeakDetector) Checkpoint() {
+	var goroutines = AllGoroutines()
+	var baseline = make(map[parl.ThreadID]struct{}, len(goroutines))
+	for _, g := range goroutines {
+		baseline[g.ID] = struct{}{}
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.baseline = baseline
+	d.seen = make(map[parl.ThreadID]time.Time)
+}
+
+// Leaks returns new goroutines that have been observed by Leaks
+// for at least the grace period
+//   - does not block: a goroutine is first reported by an
+//     invocation at least grace after the invocation first observing it
+//   - intended for periodic checks in long-running services
+func (d *LeakDetector) Leaks() (leaks []Goroutine) {
+	var goroutines = d.newGoroutines()
+	var now = time.Now()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var seen = make(map[parl.ThreadID]time.Time, len(goroutines))
+	for _, g := range goroutines {
+		var t, ok = d.seen[g.ID]
+		if !ok {
+			t = now
+		}
+		seen[g.ID] = t
+		if now.Sub(t) >= d.grace {
+			leaks = append(leaks, g)
+		}
+	}
+	// forget goroutines that exited
+	d.seen = seen
+
+	return
+}
+
+// Await waits up to the grace period for new goroutines to exit
+//   - leaks: goroutines that remain, empty if none
+//   - returns as soon as no new goroutines remain
+//   - intended for the end of tests
+func (d *LeakDetector) Await() (leaks []Goroutine) {
+	var deadline = time.Now().Add(d.grace)
+	for {
+		if leaks = d.newGoroutines(); len(leaks) == 0 || !time.Now().Before(deadline) {
+			return
+		}
+		time.Sleep(leakPollInterval)
+	}
+}
+
+// newGoroutines returns goroutines not in baseline
+func (d *LeakDetector) newGoroutines() (goroutines []Goroutine) {
+	var self = GoID()
+	d.lock.Lock()
+	var baseline = d.baseline
+	d.lock.Unlock()
+
+	for _, g := range AllGoroutines() {
+		if _, ok := baseline[g.ID]; ok || g.ID == self || d.isIgnored(&g) {
+			continue
+		}
+		goroutines = append(goroutines, g)
+	}
+
+	return
+}
+
+// isIgnored returns true if the stack trace of g has an ignored function
+func (d *LeakDetector) isIgnored(g *Goroutine) (isIgnored bool) {
+	for _, ignore := range d.ignore {
+		if strings.Contains(g.Stack, ignore) {
+			return true
+		}
+	}
+	return
+}
+
+// AllGoroutines returns all goroutines of the process
+//   - ordered by goroutine ID
+func AllGoroutines() (goroutines []Goroutine) {
+	var buf []byte
+	for size := leakStackSize; ; size *= 2 {
+		buf = make([]byte, size)
+		if n := runtime.Stack(buf, true); n < size {
+			buf = buf[:n]
+			break
+		}
+	}
+
+	for _, block := range bytes.Split(bytes.TrimSpace(buf), []byte("\n\n")) {
+		if g, ok := parseGoroutine(block); ok {
+			goroutines = append(goroutines, g)
+		}
+	}
+	slices.SortFunc(goroutines, func(a, b Goroutine) (result int) {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return
+}
+
+// parseGoroutine parses the stack trace of one goroutine
+//
+//	goroutine 7 [chan receive]:
+//	main.worker(…)
+//	␉/path/main.go:20 +0x2c
+//	created by main.main in goroutine 1
+//	␉/path/main.go:12 +0x40
+func parseGoroutine(block []byte) (g Goroutine, ok bool) {
+	var lines = bytes.Split(block, []byte{'\n'})
+	var id uint64
+	var err error
+	if id, g.Status, err = pruntimelib.ParseFirstLine(lines[0]); err != nil {
+		return // not a goroutine return
+	}
+	g.ID = parl.ThreadID(id)
+	g.Stack = string(block)
+
+	// frames are line pairs following the first line
+	var frameLines = lines[1:]
+	if len(frameLines) >= 2 && bytes.HasPrefix(frameLines[len(frameLines)-2], []byte("created by ")) {
+		var creator = frameLines[len(frameLines)-2:]
+		g.Creator.FuncName, _, _ = pruntimelib.ParseCreatedLine(creator[0])
+		g.Creator.File, g.Creator.Line = parseFileLine(creator[1])
+		frameLines = frameLines[:len(frameLines)-2]
+		// goroutine function is the last frame
+		if n := len(frameLines); n >= 2 {
+			g.GoFunction.FuncName, _ = parseFuncLine(frameLines[n-2])
+			g.GoFunction.File, g.GoFunction.Line = parseFileLine(frameLines[n-1])
+		}
+	}
+	if len(frameLines) >= 2 {
+		g.Function.FuncName, _ = parseFuncLine(frameLines[0])
+		g.Function.File, g.Function.Line = parseFileLine(frameLines[1])
+	}
+	ok = true
+
+	return
+}
+
+// parseFuncLine is [pruntimelib.ParseFuncLine] tolerating
+// elided frame lines
+func parseFuncLine(line []byte) (funcName, args string) {
+	if bytes.IndexByte(line, '(') < 1 {
+		return string(line), ""
+	}
+	return pruntimelib.ParseFuncLine(line)
+}
+
+// parseFileLine is [pruntimelib.ParseFileLine] tolerating
+// lines that are not file lines
+func parseFileLine(line []byte) (file string, lineNo int) {
+	if len(line) == 0 || line[0] != '\t' {
+		return
+	}
+	return pruntimelib.ParseFileLine(line)
+}
+
+// “goroutine 7 [chan receive] main.worker-main.go:20
+// go main.worker-main.go:18 created by main.main-main.go:12”
+func (g Goroutine) String() (s string) {
+	s = "goroutine " + strconv.FormatUint(uint64(g.ID), 10) + " [" + g.Status + "]"
+	if g.Function.IsSet() {
+		s += " " + g.Function.Short()
+	}
+	if g.GoFunction.IsSet() {
+		s += " go " + g.GoFunction.Short()
+	}
+	if g.Creator.IsSet() {
+		s += " created by " + g.Creator.Short()
+	}
+	return
+}
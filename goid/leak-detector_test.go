@@ -0,0 +1,81 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package goid
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeakDetector(t *testing.T) {
+	var grace = 10 * time.Millisecond
+	var expFunc = "leakingGoroutine"
+	var expCreator = "TestLeakDetector"
+
+	var detector = NewLeakDetector(LeakConfig{Grace: grace})
+	var ch = make(chan struct{})
+	var exitCh = make(chan struct{})
+	go leakingGoroutine(ch, exitCh)
+
+	// Await should report the blocked goroutine
+	var leaks = detector.Await()
+	if len(leaks) != 1 {
+		t.Fatalf("Await leaks %d exp 1: %v", len(leaks), leaks)
+	}
+	var g = leaks[0]
+	//t.Log(g.String())
+	if !strings.HasSuffix(g.GoFunction.FuncName, expFunc) {
+		t.Errorf("GoFunction %q exp suffix %q", g.GoFunction.FuncName, expFunc)
+	}
+	if !strings.HasSuffix(g.Creator.FuncName, expCreator) {
+		t.Errorf("Creator %q exp suffix %q", g.Creator.FuncName, expCreator)
+	}
+	if g.Creator.Line == 0 {
+		t.Error("Creator.Line zero")
+	}
+
+	// Leaks reports once grace has passed since first observed
+	if leaks = detector.Leaks(); len(leaks) != 0 {
+		t.Errorf("Leaks first %d exp 0", len(leaks))
+	}
+	time.Sleep(grace)
+	if leaks = detector.Leaks(); len(leaks) != 1 {
+		t.Errorf("Leaks second %d exp 1", len(leaks))
+	}
+
+	// ignored functions are not reported
+	var ignoring = NewLeakDetector(LeakConfig{Grace: grace, Ignore: []string{expFunc}})
+	go leakingGoroutine(ch, make(chan struct{}))
+	if leaks = ignoring.Await(); len(leaks) != 0 {
+		t.Errorf("ignoring leaks %d exp 0", len(leaks))
+	}
+
+	// exited goroutines are not reported
+	close(ch)
+	<-exitCh
+	if leaks = detector.Await(); len(leaks) != 0 {
+		t.Errorf("Await after exit %d exp 0: %v", len(leaks), leaks)
+	}
+}
+
+func TestVerifyNoLeaks(t *testing.T) {
+	VerifyNoLeaks(t, LeakConfig{Grace: 10 * time.Millisecond})
+
+	// a goroutine that exits is not a leak
+	var exitCh = make(chan struct{})
+	var ch = make(chan struct{})
+	close(ch)
+	go leakingGoroutine(ch, exitCh)
+	<-exitCh
+}
+
+// leakingGoroutine blocks until ch closes
+func leakingGoroutine(ch, exitCh chan struct{}) {
+	defer close(exitCh)
+
+	<-ch
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/ptime"
 )
 
 const (
@@ -23,6 +24,7 @@ const (
 // HaltDetector sends detected Go runtime execution halts on channel ch.
 type HaltDetector struct {
 	reportingThreshold time.Duration
+	clock              ptime.Clock
 	ch                 parl.AwaitableSlice[*HaltReport]
 }
 
@@ -35,6 +37,15 @@ type HaltReport struct {
 
 // NewHaltDetector returns an object that sends detected Go runtime execution halts on a channel
 func NewHaltDetector(reportingThreshold ...time.Duration) (haltDetector *HaltDetector) {
+	return NewHaltDetector2(ptime.SystemClock, reportingThreshold...)
+}
+
+// NewHaltDetector2 returns a halt detector using clock
+//   - clock: [ptime.TestClock] makes halt detection testable
+func NewHaltDetector2(clock ptime.Clock, reportingThreshold ...time.Duration) (haltDetector *HaltDetector) {
+	if clock == nil {
+		panic(parl.NilError("clock"))
+	}
 	var t time.Duration
 	if len(reportingThreshold) > 0 {
 		t = reportingThreshold[0]
@@ -42,7 +53,7 @@ func NewHaltDetector(reportingThreshold ...time.Duration) (haltDetector *HaltDet
 	if t < defaultReportingThreshold {
 		t = defaultReportingThreshold
 	}
-	return &HaltDetector{reportingThreshold: t}
+	return &HaltDetector{reportingThreshold: t, clock: clock}
 }
 
 // Thread detects execution halts and sends them on h.ch
@@ -51,16 +62,16 @@ func (h *HaltDetector) Thread(g0 parl.Go) {
 	defer g0.Register().Done(&err)
 	defer parl.PanicToErr(&err)
 
-	timeTicker := time.NewTicker(time.Millisecond)
+	timeTicker := h.clock.NewTicker(time.Millisecond)
 	defer timeTicker.Stop()
 
 	var done = g0.Context().Done()
 	var elapsed time.Duration
 	var t0 time.Time
-	var t1 = time.Now()
+	var t1 = h.clock.Now()
 	var reportingThreshold = h.reportingThreshold
 	var n int
-	var C = timeTicker.C
+	var C = timeTicker.C()
 	for {
 
 		// sleep
@@ -70,7 +81,7 @@ func (h *HaltDetector) Thread(g0 parl.Go) {
 			return // g0 context cancel return
 		case <-C:
 		}
-		t1 = time.Now()
+		t1 = h.clock.Now()
 		elapsed = t1.Sub(t0)
 
 		// report
@@ -12,6 +12,7 @@ import (
 
 	"github.com/haraldrudell/parl"
 	"github.com/haraldrudell/parl/g0"
+	"github.com/haraldrudell/parl/ptime"
 )
 
 func TestHaltDetector(t *testing.T) {
@@ -68,3 +69,27 @@ func TestHaltDetector(t *testing.T) {
 		t.Errorf("goGroup.Ch did not close: %s", goError.String())
 	}
 }
+
+func TestHaltDetectorClock(t *testing.T) {
+	var clock = ptime.NewTestClock()
+	var t0 = clock.Now()
+	var expD = 10 * time.Millisecond
+
+	var haltDetector = NewHaltDetector2(clock)
+	var ch = haltDetector.Ch()
+	var goGroup = g0.NewGoGroup(context.Background())
+	go haltDetector.Thread(goGroup.Go())
+
+	// a 10 ms tick interval is a halt
+	clock.AwaitWaiters(1)
+	clock.Advance(expD)
+	<-ch.DataWaitCh()
+	var haltReport, _ = ch.Get()
+	if haltReport.N != 1 || !haltReport.T.Equal(t0) || haltReport.D != expD {
+		t.Errorf("bad report: N %d T %s D %s", haltReport.N, haltReport.T, haltReport.D)
+	}
+
+	goGroup.Cancel()
+	<-goGroup.Context().Done()
+	goGroup.Wait()
+}
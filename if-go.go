@@ -44,6 +44,8 @@ type Go interface {
 	// Register performs no function but allows the Go object to collect
 	// information on the new thread.
 	// - label is an optional name that can be assigned to a Go goroutine thread
+	// - multiple labels are joined by [LabelSeparator] into a
+	//   hierarchical label “server/accept/conn-42”
 	Register(label ...string) (g0 Go)
 	// AddError emits a non-fatal errors
 	AddError(err error)
@@ -73,6 +75,12 @@ type Go interface {
 	//   - the SubGroup thread-group terminates when both its own threads have exited and
 	//	- the threads of its subordinate thread-groups.
 	SubGroup(onFirstFatal ...GoFatalCallback) (subGroup SubGroup)
+	// OnCleanup registers cleanup to execute exactly once when the
+	// thread’s context is canceled or the thread exits, whichever occurs first
+	//	- cleanup executes on its own goroutine, a panic is a non-fatal GoError
+	//	- Done awaits cleanups in progress
+	//	- replaces defer and select cleanup patterns in threads
+	OnCleanup(cleanup func())
 	// Done indicates that this goroutine is exiting
 	//	- err == nil means successful exit
 	//	- non-nil err indicates fatal error
@@ -248,11 +256,51 @@ type GoGroup interface {
 	Threads() (threads []ThreadData)
 	// threads that have been named ordered by name
 	NamedThreads() (threads []ThreadData)
+	// ThreadsByPrefix returns named threads whose hierarchical label is
+	// prefix or is below prefix, ordered by name
+	//   - prefix “server” matches “server” and “server/accept/conn-42”
+	ThreadsByPrefix(prefix string) (threads []ThreadData)
 	// SetDebug enables debug logging on this particular instance
 	//	- parl.NoDebug
 	//	- parl.DebugPrint
 	//	- parl.AggregateThread
 	SetDebug(debug GoDebug, log ...PrintfFunc)
+	// SetDebugFilter limits debug printing of threads to labels matching pattern
+	//   - pattern: [path.Match] pattern matched against the label and
+	//     its ancestors: “server” and “server/*” match “server/accept/conn-42”
+	//   - empty pattern: all threads
+	//   - err: malformed pattern
+	SetDebugFilter(pattern string) (err error)
+	// SetErrorLimit rate limits non-fatal errors of each thread
+	// in this and subordinate thread-groups
+	//   - count: errors forwarded per thread and interval,
+	//     [NoErrorLimit]: the limit of any parent applies
+	//   - suppressed errors are summarized by a single non-fatal error
+	SetErrorLimit(count int, interval time.Duration)
+	// SetMaxConcurrent limits the number of concurrently running threads
+	// obtained from Go of this thread-group
+	//   - n: [NoMaxConcurrent]: unlimited
+	//   - mode: [ConcurrencyBlock] default: Go blocks until a slot is available.
+	//     [ConcurrencyQueue]: Go returns a queued Go whose thread awaits a slot
+	//     on its first Go method invocation, typically Register
+	SetMaxConcurrent(n int, mode ...ConcurrencyMode)
+	// SlotCh returns a channel that closes when a thread slot is available
+	SlotCh() (ch AwaitableCh)
+	// SetTracer records lifecycle events of threads in this and
+	// subordinate thread-groups into tracer tasks named by thread label
+	//   - events: “created” “registered” “first error” “done”
+	//   - nil: the tracer of any parent applies
+	SetTracer(tracer Tracer)
+	// SetValue layers a context value onto the context of this thread-group
+	//   - key: like [context.WithValue], a comparable type defined by the caller
+	//   - the value is visible to threads via Go.Context and [GoValue]
+	//   - subordinate thread-groups created after SetValue inherit the value
+	SetValue(key, value any)
+	// Events returns a stream of supervision events of this and
+	// subordinate thread-groups
+	//   - events occurring after the first Events invocation are recorded
+	//   - the stream closes when this thread-group ends
+	Events() (events IterableSource[GoEvent])
 	fmt.Stringer
 }
 
@@ -296,11 +344,51 @@ type SubGo interface {
 	Threads() (threads []ThreadData)
 	// threads that have been named ordered by name
 	NamedThreads() (threads []ThreadData)
+	// ThreadsByPrefix returns named threads whose hierarchical label is
+	// prefix or is below prefix, ordered by name
+	//   - prefix “server” matches “server” and “server/accept/conn-42”
+	ThreadsByPrefix(prefix string) (threads []ThreadData)
 	// SetDebug enables debug logging on this particular instance
 	//   - parl.NoDebug
 	//   - parl.DebugPrint
 	//   - parl.AggregateThread
 	SetDebug(debug GoDebug, log ...PrintfFunc)
+	// SetDebugFilter limits debug printing of threads to labels matching pattern
+	//   - pattern: [path.Match] pattern matched against the label and
+	//     its ancestors: “server” and “server/*” match “server/accept/conn-42”
+	//   - empty pattern: all threads
+	//   - err: malformed pattern
+	SetDebugFilter(pattern string) (err error)
+	// SetErrorLimit rate limits non-fatal errors of each thread
+	// in this and subordinate thread-groups
+	//   - count: errors forwarded per thread and interval,
+	//     [NoErrorLimit]: the limit of any parent applies
+	//   - suppressed errors are summarized by a single non-fatal error
+	SetErrorLimit(count int, interval time.Duration)
+	// SetMaxConcurrent limits the number of concurrently running threads
+	// obtained from Go of this thread-group
+	//   - n: [NoMaxConcurrent]: unlimited
+	//   - mode: [ConcurrencyBlock] default: Go blocks until a slot is available.
+	//     [ConcurrencyQueue]: Go returns a queued Go whose thread awaits a slot
+	//     on its first Go method invocation, typically Register
+	SetMaxConcurrent(n int, mode ...ConcurrencyMode)
+	// SlotCh returns a channel that closes when a thread slot is available
+	SlotCh() (ch AwaitableCh)
+	// SetTracer records lifecycle events of threads in this and
+	// subordinate thread-groups into tracer tasks named by thread label
+	//   - events: “created” “registered” “first error” “done”
+	//   - nil: the tracer of any parent applies
+	SetTracer(tracer Tracer)
+	// SetValue layers a context value onto the context of this thread-group
+	//   - key: like [context.WithValue], a comparable type defined by the caller
+	//   - the value is visible to threads via Go.Context and [GoValue]
+	//   - subordinate thread-groups created after SetValue inherit the value
+	SetValue(key, value any)
+	// Events returns a stream of supervision events of this and
+	// subordinate thread-groups
+	//   - events occurring after the first Events invocation are recorded
+	//   - the stream closes when this thread-group ends
+	Events() (events IterableSource[GoEvent])
 	fmt.Stringer
 }
 
@@ -444,3 +532,27 @@ const (
 )
 
 type GoDebug uint8
+
+// LabelSeparator separates the levels of a hierarchical thread label
+// “server/accept/conn-42” provided to [Go.Register]
+const LabelSeparator = "/"
+
+// NoErrorLimit as count to [GoGroup.SetErrorLimit] removes
+// the rate limit of a thread-group
+const NoErrorLimit = 0
+
+// NoMaxConcurrent as n to [GoGroup.SetMaxConcurrent] removes
+// the concurrency limit of a thread-group
+const NoMaxConcurrent = 0
+
+const (
+	// ConcurrencyBlock: Go blocks until a thread slot is available
+	ConcurrencyBlock ConcurrencyMode = iota
+	// ConcurrencyQueue: Go returns immediately and the new thread
+	// awaits a slot on its first Go method invocation
+	ConcurrencyQueue
+)
+
+// ConcurrencyMode is how [GoGroup.SetMaxConcurrent] delays threads
+//   - [ConcurrencyBlock] [ConcurrencyQueue]
+type ConcurrencyMode uint8
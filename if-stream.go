@@ -87,3 +87,25 @@ type ClosableSourceSink[T any] interface {
 	SourceSink[T]
 	EmptyCh(doNotInitialize ...bool) (ch AwaitableCh)
 }
+
+// DrainCloser is two-phase close of a sink
+//   - BeginClose: the sink stops accepting values.
+//     Values already sent remain available to the consumer
+//   - Drained: awaitable that closes once BeginClose was invoked
+//     and all values were consumed
+//   - FinalClose: the sink is closed discarding any remaining values.
+//     Drained is closed
+//   - the phases separate close from discarding values that
+//     Close-only sinks combine in different ways.
+//     [DrainClose] closes a sequence of sinks without losing values
+//   - all methods are thread-safe and idempotent
+//   - implemented by [AwaitableSlice]
+type DrainCloser interface {
+	// BeginClose stops accepting values
+	BeginClose()
+	// Drained returns a channel that closes once
+	// BeginClose was invoked and all values were consumed
+	Drained() (ch AwaitableCh)
+	// FinalClose closes discarding any remaining values
+	FinalClose()
+}
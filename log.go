@@ -7,6 +7,7 @@ package parl
 
 import (
 	"os"
+	"strings"
 
 	"github.com/haraldrudell/parl/plog"
 )
@@ -163,6 +164,41 @@ func SetDebug(debug bool) {
 	stderrLogger.SetDebug(debug)
 }
 
+// SetLogRing records the size most recent log lines in memory
+//   - size 0: recording is disabled
+//   - recorded are lines to standard error and standard out, including
+//     suppressed Debug and Info invocations
+//   - lines are formatted when recorded, suppressed lines are truncated to 1 KiB
+//   - retrieve using [LogRingLines] or [CrashContext]
+func SetLogRing(size int) {
+	var ring *plog.LogRing
+	if size > 0 {
+		ring = plog.NewLogRing(size)
+	}
+	stderrLogger.SetRing(ring)
+	stdoutLogger.SetRing(ring)
+}
+
+// LogRingLines returns recently recorded log lines, oldest first
+//   - nil if [SetLogRing] was not invoked
+func LogRingLines() (lines []string) {
+	if ring := stderrLogger.Ring(); ring != nil {
+		lines = ring.Lines()
+	}
+	return
+}
+
+// CrashContext returns recently recorded log lines as a printable block
+//   - empty string if [SetLogRing] was not invoked or no lines were recorded
+//   - intended to be appended to panic and fatal-error printouts
+func CrashContext() (s string) {
+	var lines = LogRingLines()
+	if len(lines) == 0 {
+		return
+	}
+	return Sprintf("recent log lines: %d\n%s", len(lines), strings.Join(lines, "\n"))
+}
+
 // D always prints to stderr with code location. Thread-safe
 //   - D is meant for temporary output intended to be removed
 //     prior to check-in
@@ -13,6 +13,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -118,10 +119,29 @@ type Executable struct {
 	IsLongErrors bool
 	// adds a code location to errors if not IsLongErrors
 	IsErrorLocation bool
+	// ErrorFormat is how Recover reports errors and exit
+	//	- empty: the “-error-format” option from [ErrorFormatOptionData] or text
+	//	- [ErrorFormatJSON] [ErrorFormatJSONOnly] emit an [ExitReport]
+	ErrorFormat ErrorFormat
 	// optionsWereParsed signals that parsing completed without panic
 	optionsWereParsed atomic.Bool
+	// isCrashContextPrinted ensures recent log lines from
+	// [parl.SetLogRing] are printed only once
+	isCrashContextPrinted atomic.Bool
 	// a specific status code to use on exit
 	statusCode parl.Atomic64[int]
+	// configLayers resolves option values from command line,
+	// environment, yaml and defaults
+	//	- set by [Executable.PrintBannerAndParseOptions]
+	configLayers *pflags.ConfigLayers
+	// pprof is profiling endpoints started by [Executable.StartPprof]
+	pprof atomic.Pointer[pprofServer]
+	// panicValue is a recovered main-thread panic for the exit report
+	panicValue atomic.Pointer[pruntime.PanicValue]
+	// restoreLock makes restores thread-safe
+	restoreLock sync.Mutex
+	// restores are functions from [Executable.AddRestore], behind restoreLock
+	restores []func()
 }
 
 // Executable is an error sink
@@ -219,6 +239,7 @@ func (x *Executable) PrintBannerAndParseOptions(optionsList []pflags.OptionData)
 	}
 
 	pflags.NewArgParser(optionsList, x.usage).Parse()
+	x.configLayers = pflags.NewConfigLayers(x.Program, optionsList)
 	if BaseOptions.Version {
 		os.Exit(0)
 	}
@@ -259,6 +280,38 @@ func (x *Executable) PrintBannerAndParseOptions(optionsList []pflags.OptionData)
 	return
 }
 
+// ConfigLayers returns the configuration layering of parsed options
+//   - provided to [yamlo.ApplyYaml] to record options updated from yaml
+//   - [pflags.ConfigLayers.Report] shows the source of each option value
+//   - nil prior to [Executable.PrintBannerAndParseOptions]
+func (x *Executable) ConfigLayers() (layers *pflags.ConfigLayers) { return x.configLayers }
+
+// ApplyEnvironment updates options not provided on the command line from
+// environment variables like “GONET_DEBUG=true”
+//   - priority is: command line > environment variables > yaml > defaults
+//   - invoked after yaml was applied and prior to [Executable.ConfigureLog]
+//   - on bad environment value, the process exits with status code 2
+//
+// ApplyEnvironment supports functional chaining like:
+//
+//	exe.Init().
+//	  PrintBannerAndParseOptions(optionData)
+//	err = yamlo.ApplyYaml(…, optionData, exe.ConfigLayers())
+//	exe.ApplyEnvironment().
+//	  ConfigureLog()
+func (x *Executable) ApplyEnvironment() (ex1 *Executable) {
+	ex1 = x
+	if x.configLayers == nil {
+		panic(perrors.NewPF("invoked prior to PrintBannerAndParseOptions"))
+	}
+	if err := x.configLayers.ApplyEnvironment(); err != nil {
+		pos.Exit(pos.StatusCodeUsage, err)
+	}
+	parl.Debug("exe.ApplyEnvironment option sources:\n%s", x.configLayers.Report())
+
+	return
+}
+
 // ConfigureLog configures the default log such as parl.Log parl.Out parl.D
 // for silent, debug and regExp.
 // Settings come from BaseOptions.Silent and BaseOptions.Debug.
@@ -281,6 +334,9 @@ func (x *Executable) ConfigureLog() (ex1 *Executable) {
 			pos.Exit(pos.StatusCodeUsage, err)
 		}
 	}
+	if !ErrorFormat(ErrorFormatOption).isValid() {
+		pos.Exit(pos.StatusCodeUsage, perrors.ErrorfPF("bad -%s: %q", errorFormatOption, ErrorFormatOption))
+	}
 	parl.Debug("exe.ConfigureLog silent: %t debug: %t verbosity: %q\n",
 		BaseOptions.Silent, BaseOptions.Debug, BaseOptions.Verbosity)
 	return x
@@ -343,44 +399,22 @@ func (x *Executable) processPanicValue(panicValue any) (wasPanic bool) {
 	}
 	// a panic was recovered in panicValue
 
-	// determine if panicValue implements error
-	var err, recoverValueIsError = panicValue.(error)
-	// the innermost error if panicValue is error
-	var error0 error
+	// classify panicValue and locate panic and recovery sites
+	var panicInfo = pruntime.NewPanicValue(panicValue)
+	x.panicValue.CompareAndSwap(nil, panicInfo)
+	var err = panicInfo.Err
+	var recoverValueIsError = err != nil
 
 	// debug print panicValue
+	//	- the packFunc for this function
+	//	- the type returned by recover() and its classification
+	//	- innermost error type if panicValue implements error
+	//	- panic site and recovery site
+	//	- any stack trace attached to an error value
 	isDebug := parl.IsThisDebug()
 	if isDebug {
-		hasStack := false
-		var valueString string
-		var error0type string
-		if !recoverValueIsError {
-			valueString = parl.Sprintf(" “%+v”", panicValue)
-		} else {
-			error0 = perrors.Error0(err)
-			error0type = parl.Sprintf(" panic error type: %T", error0)
-			hasStack = perrors.HasStack(err)
-			error0value := fmt.Sprintf("error0: %+v", error0)
-			if hasStack {
-				valueString = parl.Sprintf(" error-value:\n\n%s\n\n%s\n\n", perrors.Long(err), error0value)
-			} else {
-				valueString = err.Error() + "\n" + error0value
-			}
-		}
-		// panic printout:
-		//	- the packFunc for this function
-		//	- the type returned by recover()
-		//	- innermost error type if panicValue implements error
-		//	- true if panicValue was an error with a stack trace
-		//	- the value returned by recover():
-		//	- — if recover() did not return an error: printed using “%+v”
-		//	- — if recover() returned an error with stack: the error with stack and
-		//		the innermost error printed using “%+v”
-		//	- — if recover() returned an error without stack: the message and
-		//		the innermost error printed using “%+v”
-		parl.Debug("%s: panic with -debug: recover-value type: %T%s hasStack: %t%s",
-			pruntime.NewCodeLocation(0).PackFunc(),
-			panicValue, error0type, hasStack, valueString)
+		parl.Debug("%s: panic with -debug: %s",
+			pruntime.NewCodeLocation(0).PackFunc(), panicInfo.Long())
 	}
 
 	// print recovery stack trace
@@ -392,7 +426,9 @@ func (x *Executable) processPanicValue(panicValue any) (wasPanic bool) {
 	if x.Program != "" {
 		programString = "\x20" + x.Program
 	}
-	parl.Log("\n\nProgram%s Recovered a main-thread panic:%s", programString, stackString)
+	if x.isTextOutput() {
+		parl.Log("\n\nProgram%s Recovered a main-thread panic:%s", programString, stackString)
+	}
 
 	// store recovery value as error
 	var prepend string
@@ -404,13 +440,14 @@ func (x *Executable) processPanicValue(panicValue any) (wasPanic bool) {
 		postpend = "”"
 		if isDebug {
 			// put error0 type name in error message
-			postpend += parl.Sprintf(" type: %T", error0)
+			postpend += parl.Sprintf(" type: %T", panicInfo.Error0)
 		}
 	}
 	// always add a stack trace after panic
 	//	- must contain one frame after panic
 	err = perrors.Stackn(err, doPanicFrames)
 	err = perrors.Errorf("main-thread %s%w%s", prepend, err, postpend)
+	parl.InvokePanicHooks(err)
 	x.AddError(err)
 
 	return
@@ -441,7 +478,7 @@ func (x *Executable) AddError(err error) {
 	}
 
 	// if the first error, immediately print it
-	if x.err.Count() == 0 {
+	if x.err.Count() == 0 && x.isTextOutput() {
 
 		// print and store the first error
 		if x.printErr(err, checkForPanic(err)) {
@@ -477,6 +514,8 @@ func (x *Executable) SetStatusCode(statusCode *int) { x.statusCode.Store(*status
 //   - — the last printed line is a timestamped exit message:
 //   - — “240524 21:32:08-07 gtee: exit status 1”
 //   - — exit status code is any non-zero status code provided to [Executable.SetStatusCode] or 1
+//   - [Executable.ErrorFormat] JSON emits an [ExitReport] as the last line of standard error
+//   - functions from [Executable.AddRestore] are invoked prior to any output
 //
 // Usage:
 //
@@ -486,6 +525,9 @@ func (x *Executable) SetStatusCode(statusCode *int) { x.statusCode.Store(*status
 //	  …
 func (x *Executable) Recover(errp ...*error) {
 
+	// restore terminal state prior to output
+	x.runRestores()
+
 	// process remaining error data
 	//	— get error from *errp and store in x.err
 	if len(errp) > 0 {
@@ -498,6 +540,9 @@ func (x *Executable) Recover(errp ...*error) {
 	// recover any ongoing panic
 	x.processPanicValue(recover())
 
+	// shut down any profiling endpoints
+	x.stopPprof()
+
 	// exit status code, zero on success:
 	//	- errCount zero and
 	//	- no non-zero status code set by [Executable.SetStatusCode]
@@ -525,7 +570,7 @@ func (x *Executable) Recover(errp ...*error) {
 	if statusCode == 0 {
 		// print timestamped success message if not suppressed
 		//	- “gtee completed successfully at 240524 17:26:50-07”
-		if x.OKtext != NoOK {
+		if x.OKtext != NoOK && x.isTextOutput() {
 			var program string
 			var completedSuccessfully string
 			now := "at " + parl.ShortSpace() // time now second precision
@@ -538,9 +583,14 @@ func (x *Executable) Recover(errp ...*error) {
 			sList := []string{program, completedSuccessfully, now}
 			parl.Log(pstrings.FilteredJoin(sList)) // to stderr
 		}
+		x.printExitReport(statusCode)
 		// exit status zero
 		pos.OsExit(statusCode)
 	}
+	if !x.isTextOutput() {
+		x.printExitReport(statusCode)
+		pos.Exit(statusCode, nil)
+	}
 
 	// err0 is the first occuring error if any
 	var err0 error
@@ -575,6 +625,7 @@ func (x *Executable) Recover(errp ...*error) {
 	// timestamped staus-code message
 	//	- “240524 21:32:08-07 gtee: exit status 1”
 	parl.Log(parl.ShortSpace() + "\x20" + x.Program + ": exit status " + strconv.Itoa(statusCode)) // outputs "060102 15:04:05Z07 " without newline to stderr
+	x.printExitReport(statusCode)
 
 	// exit with non-zero status code
 	pos.Exit(statusCode, nil)
@@ -619,10 +670,27 @@ func (x *Executable) printErr(err error, panicString ...string) (printedLong boo
 	} else if err != nil {
 		s = err.Error()
 	}
+
+	// panics and fatal thread-exits are followed by recent log lines
+	if isCrash(err, panicString...) && x.isCrashContextPrinted.CompareAndSwap(false, true) {
+		if crashContext := parl.CrashContext(); crashContext != "" {
+			s += "\n" + crashContext + "\n— — —"
+		}
+	}
 	parl.Log(s)
 	return
 }
 
+// isCrash returns true if err is a panic or a fatal thread-exit
+//   - panicString: non-empty for panic, see checkForPanic
+func isCrash(err error, panicString ...string) (isCrash bool) {
+	if len(panicString) > 0 && panicString[0] != "" {
+		return true
+	}
+	var goError parl.GoError
+	return errors.As(err, &goError) && goError.IsFatal()
+}
+
 // usage prints options usage
 func (x *Executable) usage() {
 	writer := flag.CommandLine.Output()
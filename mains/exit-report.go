@@ -0,0 +1,183 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package mains
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/perrors/errorglue"
+	"github.com/haraldrudell/parl/pflags"
+	"github.com/haraldrudell/parl/pruntime"
+)
+
+const (
+	// ErrorFormatText is human-readable error and exit output
+	ErrorFormatText ErrorFormat = "text"
+	// ErrorFormatJSON is human-readable output followed by
+	// a JSON exit report on the last line of standard error
+	ErrorFormatJSON ErrorFormat = "json"
+	// ErrorFormatJSONOnly is a JSON exit report instead of
+	// human-readable error and exit output
+	ErrorFormatJSONOnly ErrorFormat = "json-only"
+	// name of the error-format option
+	errorFormatOption = "error-format"
+)
+
+// ErrorFormat is how [Executable.Recover] reports errors and exit
+//   - [ErrorFormatText] [ErrorFormatJSON] [ErrorFormatJSONOnly]
+type ErrorFormat string
+
+// ErrorFormatOption is the effective value of the “-error-format” option
+var ErrorFormatOption string
+
+// ErrorFormatOptionData returns the “-error-format=json” option
+//   - appended to option data of an executable
+//   - the option value is validated by [Executable.ConfigureLog] and
+//     used by [Executable.Recover] unless [Executable.ErrorFormat] is set
+func ErrorFormatOptionData() (optionData []pflags.OptionData) {
+	return []pflags.OptionData{{
+		P: &ErrorFormatOption, Name: errorFormatOption, Value: "",
+		Usage: "exit report on standard error: text json json-only",
+	}}
+}
+
+// ExitReport is the JSON document emitted by [Executable.Recover]
+// for [ErrorFormatJSON] and [ErrorFormatJSONOnly]
+type ExitReport struct {
+	Program string `json:"program"`
+	Version string `json:"version,omitempty"`
+	Host    string `json:"host,omitempty"`
+	// StatusCode is process exit status, 0 for success
+	StatusCode int `json:"statusCode"`
+	// Launch is process start time
+	Launch time.Time `json:"launch"`
+	// Exit is time of exit
+	Exit time.Time `json:"exit"`
+	// Seconds is duration of execution
+	Seconds float64 `json:"seconds"`
+	// Errors are errors in order of occurrence
+	Errors []ExitReportError `json:"errors"`
+	// Panic describes a main-thread panic
+	Panic *ExitReportPanic `json:"panic,omitempty"`
+}
+
+// ExitReportError is an error of [ExitReport]
+type ExitReportError struct {
+	// Message is the error message
+	Message string `json:"message"`
+	// Location is the innermost code location of the error
+	Location string `json:"location,omitempty"`
+	// Stack is stack trace and error chain
+	Stack string `json:"stack,omitempty"`
+	// Associated are messages of associated errors
+	Associated []string `json:"associated,omitempty"`
+}
+
+// ExitReportPanic is a main-thread panic of [ExitReport]
+type ExitReportPanic struct {
+	// Value is the panic value as string
+	Value string `json:"value"`
+	// Kind classifies the panic value, see [pruntime.PanicKind]
+	Kind string `json:"kind"`
+	// Type is the type of the panic value
+	Type string `json:"type"`
+	// PanicSite is the code location that panicked
+	PanicSite string `json:"panicSite,omitempty"`
+	// RecoverySite is the code location that recovered the panic
+	RecoverySite string `json:"recoverySite,omitempty"`
+}
+
+// isValid returns true if format is a known format
+//   - empty is text
+func (f ErrorFormat) isValid() (isValid bool) {
+	switch f {
+	case "", ErrorFormatText, ErrorFormatJSON, ErrorFormatJSONOnly:
+		return true
+	}
+	return
+}
+
+// errorFormat returns the effective error format
+func (x *Executable) errorFormat() (format ErrorFormat) {
+	if format = x.ErrorFormat; format == "" {
+		if format = ErrorFormat(ErrorFormatOption); !format.isValid() {
+			format = ErrorFormatText
+		}
+	}
+	return
+}
+
+// isTextOutput returns true if human-readable error output is to be printed
+func (x *Executable) isTextOutput() (isText bool) { return x.errorFormat() != ErrorFormatJSONOnly }
+
+// printExitReport prints a single-line JSON exit report to standard error
+// if the error format is JSON
+func (x *Executable) printExitReport(statusCode int) {
+	if format := x.errorFormat(); format != ErrorFormatJSON && format != ErrorFormatJSONOnly {
+		return // no JSON report return
+	}
+	var data, err = json.Marshal(x.exitReport(statusCode, time.Now()))
+	if perrors.IsPF(&err, "json.Marshal %w", err) {
+		parl.Log(err.Error())
+		return
+	}
+	parl.Log(string(data))
+}
+
+// exitReport returns the exit report
+func (x *Executable) exitReport(statusCode int, now time.Time) (report *ExitReport) {
+	report = &ExitReport{
+		Program:    x.Program,
+		Version:    x.Version,
+		Host:       x.Host,
+		StatusCode: statusCode,
+		Launch:     x.Launch,
+		Exit:       now,
+		Errors:     []ExitReportError{},
+	}
+	if !x.Launch.IsZero() {
+		report.Seconds = now.Sub(x.Launch).Seconds()
+	}
+	for _, err := range x.err.Get() {
+		var e = ExitReportError{Message: err.Error()}
+		if location := errorglue.GetInnerMostStack(err); location != nil {
+			if frames := location.Frames(); len(frames) > 0 {
+				e.Location = frames[0].Loc().Short()
+			}
+			e.Stack = perrors.Long(err)
+		}
+		if associated := errorglue.ErrorList(err); len(associated) > 1 {
+			for _, a := range associated[1:] {
+				e.Associated = append(e.Associated, a.Error())
+			}
+		}
+		report.Errors = append(report.Errors, e)
+	}
+	if panicValue := x.panicValue.Load(); panicValue != nil {
+		report.Panic = newExitReportPanic(panicValue)
+	}
+
+	return
+}
+
+// newExitReportPanic returns panic details
+func newExitReportPanic(panicValue *pruntime.PanicValue) (p *ExitReportPanic) {
+	p = &ExitReportPanic{
+		Value: panicValue.Short(),
+		Kind:  panicValue.Kind.String(),
+		Type:  parl.Sprintf("%T", panicValue.Value),
+	}
+	if panicValue.PanicSite.IsSet() {
+		p.PanicSite = panicValue.PanicSite.Short()
+	}
+	if panicValue.RecoverySite.IsSet() {
+		p.RecoverySite = panicValue.RecoverySite.Short()
+	}
+	return
+}
@@ -0,0 +1,76 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package mains
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestExitReport(t *testing.T) {
+	//t.Error("Logging on")
+	var program, message, panicMessage = "prog", "bad thing", "panic thing"
+	var statusCode = 3
+	var launch = time.Now().Add(-time.Second).Truncate(time.Second)
+
+	// record log lines to observe text output
+	parl.SetLogRing(10)
+	defer parl.SetLogRing(0)
+
+	var x = Executable{Program: program, Launch: launch, ErrorFormat: ErrorFormatJSONOnly}
+	x.AddError(perrors.NewPF(message))
+	if !x.processPanicValue(errors.New(panicMessage)) {
+		t.Fatal("processPanicValue false")
+	}
+	if lines := parl.LogRingLines(); len(lines) != 0 {
+		t.Errorf("json-only text output: %q", lines)
+	}
+	x.printExitReport(statusCode)
+	var lines = parl.LogRingLines()
+	if len(lines) != 1 {
+		t.Fatalf("exit report lines %d exp 1: %q", len(lines), lines)
+	}
+
+	// the exit report is a single JSON line
+	//	- recorded lines are prefixed with a timestamp
+	var _, jsonLine, _ = strings.Cut(lines[0], " ")
+	var report ExitReport
+	if err := json.Unmarshal([]byte(jsonLine), &report); err != nil {
+		t.Fatalf("json.Unmarshal err %s: %q", err, jsonLine)
+	}
+	if report.Program != program || report.StatusCode != statusCode || !report.Launch.Equal(launch) {
+		t.Errorf("report %s %d %s", report.Program, report.StatusCode, report.Launch)
+	}
+	if report.Seconds < 1 || report.Exit.Before(launch) {
+		t.Errorf("report seconds %f exit %s", report.Seconds, report.Exit)
+	}
+	if len(report.Errors) != 2 {
+		t.Fatalf("errors %d exp 2", len(report.Errors))
+	}
+	if e := report.Errors[0]; !strings.Contains(e.Message, message) || e.Location == "" || e.Stack == "" {
+		t.Errorf("error %+v", e)
+	}
+	if p := report.Panic; p == nil {
+		t.Error("panic missing")
+	} else if p.Value == "" || p.Kind == "" || p.Type != "*errors.errorString" {
+		t.Errorf("panic %+v", p)
+	}
+
+	// text format has text output and no exit report
+	parl.SetLogRing(10)
+	x = Executable{Program: program, ErrorFormat: ErrorFormatText}
+	x.AddError(perrors.NewPF(message))
+	x.printExitReport(statusCode)
+	if lines = parl.LogRingLines(); len(lines) != 1 || !strings.Contains(lines[0], message) {
+		t.Errorf("text output %q", lines)
+	}
+}
@@ -0,0 +1,230 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package mains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/g0"
+	"github.com/haraldrudell/parl/halt"
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/pflags"
+	"github.com/haraldrudell/parl/pos"
+)
+
+const (
+	// PprofDefaultAddr is the listener address for “-pprof” without value
+	PprofDefaultAddr = "localhost:6060"
+	// name of the pprof option
+	pprofOption = "pprof"
+	// how long Recover waits for profiling requests to complete
+	pprofShutdownTimeout = time.Second
+)
+
+// PprofOption is the effective value of the opt-in “-pprof[=addr]” option
+var PprofOption = pflags.NewOptionalString(PprofDefaultAddr)
+
+// PprofOptionData returns the opt-in “-pprof[=addr]” option
+//   - appended to option data of an executable using [Executable.StartPprof]
+func PprofOptionData() (optionData []pflags.OptionData) {
+	return []pflags.OptionData{{
+		P: PprofOption, Name: pprofOption,
+		Usage: "serve runtime profiling on local listener -pprof[=" + PprofDefaultAddr + "]",
+	}}
+}
+
+// pprofServer serves profiling endpoints
+type pprofServer struct {
+	server *http.Server
+	subGo  parl.SubGo
+	// goGen is the thread-group for which threads are reported
+	goGen parl.GoGen
+	// isAggregateThreads is thread aggregation of a g0 thread-group, nil otherwise
+	isAggregateThreads *atomic.Bool
+	// wasAggregateThreads is thread aggregation prior to StartPprof,
+	// restored by stopPprof
+	wasAggregateThreads bool
+	// lock makes halt statistics thread-safe
+	lock sync.Mutex
+	// haltCount is the number of detected Go runtime halts, behind lock
+	haltCount int
+	// haltMax is the longest halt, behind lock
+	haltMax time.Duration
+	// haltLast is the most recent halt, behind lock
+	haltLast *halt.HaltReport
+}
+
+// StartPprof starts profiling endpoints if “-pprof[=addr]” was provided
+//   - requires option data from [PprofOptionData]
+//   - endpoints:
+//   - — /debug/pprof/ from [net/http/pprof]
+//   - — /debug/parl/threads thread-group threads and metrics
+//   - — /debug/parl/halt Go runtime execution halts detected by [halt.HaltDetector]
+//   - threads are managed by a SubGo of goGen.
+//     for a [g0.GoGroup], thread data is aggregated while profiling is served.
+//     Debug mode is unchanged and aggregation is restored by Recover
+//   - the listener is shut down by [Executable.Recover]
+//   - on listen failure, the process exits with status code 2
+//
+// StartPprof supports functional chaining like:
+//
+//	var optionData = append(mains.BaseOptionData(exe.Program, mains.YamlNo), mains.PprofOptionData()...)
+//	exe.Init().
+//	  PrintBannerAndParseOptions(optionData).
+//	  ConfigureLog().
+//	  StartPprof(goGroup)
+func (x *Executable) StartPprof(goGen parl.GoGen) (ex1 *Executable) {
+	ex1 = x
+	if !PprofOption.IsSet() {
+		return // no -pprof option return
+	}
+	if goGen == nil {
+		panic(parl.NilError("goGen"))
+	}
+
+	var p = &pprofServer{goGen: goGen}
+	if !x.pprof.CompareAndSwap(nil, p) {
+		panic(perrors.NewPF("StartPprof invoked more than once"))
+	}
+	var listener, err = net.Listen("tcp", PprofOption.Value())
+	if perrors.IsPF(&err, "-%s listen %q: %w", pprofOption, PprofOption.Value(), err) {
+		pos.Exit(pos.StatusCodeUsage, err)
+	}
+	if goGroup, ok := goGen.(*g0.GoGroup); ok {
+		_, p.isAggregateThreads, _, _ = goGroup.Internals()
+		p.wasAggregateThreads = p.isAggregateThreads.Swap(true)
+	}
+	p.subGo = goGen.SubGo()
+	p.server = &http.Server{Handler: p.mux()}
+
+	var haltDetector = halt.NewHaltDetector()
+	go p.serveThread(p.subGo.Go(), listener)
+	go haltDetector.Thread(p.subGo.Go())
+	go p.haltThread(p.subGo.Go(), haltDetector)
+	parl.Info("profiling: http://%s/debug/pprof/", listener.Addr())
+
+	return
+}
+
+// stopPprof shuts down profiling endpoints if started
+//   - invoked by [Executable.Recover]
+func (x *Executable) stopPprof() {
+	var p = x.pprof.Swap(nil)
+	if p == nil {
+		return // profiling not started return
+	}
+	var ctx, cancel = context.WithTimeout(context.Background(), pprofShutdownTimeout)
+	defer cancel()
+
+	if err := p.server.Shutdown(ctx); err != nil {
+		p.server.Close()
+	}
+	p.subGo.Cancel()
+	select {
+	case <-p.subGo.WaitCh():
+	case <-ctx.Done():
+	}
+	if p.isAggregateThreads != nil {
+		p.isAggregateThreads.Store(p.wasAggregateThreads)
+	}
+}
+
+// mux returns the handler for all endpoints
+func (p *pprofServer) mux() (mux *http.ServeMux) {
+	mux = http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/parl/threads", p.threadsHandler)
+	mux.HandleFunc("/debug/parl/halt", p.haltHandler)
+	return
+}
+
+// serveThread serves http requests until shutdown
+func (p *pprofServer) serveThread(g parl.Go, listener net.Listener) {
+	var err error
+	defer g.Register("pprof").Done(&err)
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	if err = p.server.Serve(listener); errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	} else {
+		err = perrors.ErrorfPF("Serve: %w", err)
+	}
+}
+
+// haltThread collects halt statistics
+func (p *pprofServer) haltThread(g parl.Go, haltDetector *halt.HaltDetector) {
+	var err error
+	defer g.Register("pprof-halt").Done(&err)
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	var reports = haltDetector.Ch()
+	var done = g.Context().Done()
+	for {
+		select {
+		case <-done:
+			return // context cancel return
+		case <-reports.DataWaitCh():
+		}
+		for report, hasValue := reports.Get(); hasValue; report, hasValue = reports.Get() {
+			p.lock.Lock()
+			p.haltCount++
+			if report.D > p.haltMax {
+				p.haltMax = report.D
+			}
+			p.haltLast = report
+			p.lock.Unlock()
+		}
+	}
+}
+
+// threadsHandler writes the thread-group, its metrics and threads
+func (p *pprofServer) threadsHandler(w http.ResponseWriter, r *http.Request) {
+	var sb strings.Builder
+	if stringer, ok := p.goGen.(fmt.Stringer); ok {
+		sb.WriteString(stringer.String() + "\n")
+	}
+	if metricser, ok := p.goGen.(interface{ Metrics() g0.GoGroupMetrics }); ok {
+		var metrics = metricser.Metrics()
+		sb.WriteString(metrics.String() + "\n")
+	}
+	if threadser, ok := p.goGen.(interface{ Threads() []parl.ThreadData }); ok {
+		for _, thread := range threadser.Threads() {
+			sb.WriteString(thread.Short() + "\t" + thread.Func().Short() + "\n")
+		}
+	}
+	writePlainText(w, sb.String())
+}
+
+// haltHandler writes halt statistics
+func (p *pprofServer) haltHandler(w http.ResponseWriter, r *http.Request) {
+	p.lock.Lock()
+	var s = fmt.Sprintf("halts: %d max: %s\n", p.haltCount, p.haltMax)
+	if last := p.haltLast; last != nil {
+		s += fmt.Sprintf("last: %s %s\n", last.T.Format(time.RFC3339Nano), last.D)
+	}
+	p.lock.Unlock()
+	writePlainText(w, s)
+}
+
+// writePlainText writes a text/plain response
+func writePlainText(w http.ResponseWriter, s string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(s))
+}
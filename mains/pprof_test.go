@@ -0,0 +1,67 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package mains
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/g0"
+	"github.com/haraldrudell/parl/halt"
+)
+
+func TestPprofMux(t *testing.T) {
+	//t.Error("Logging on")
+	var label = "pprof-test"
+
+	var goGroup = g0.NewGoGroup(context.Background())
+	goGroup.SetDebug(parl.AggregateThread)
+	var done = make(chan struct{})
+	var started = make(chan struct{})
+	go pprofTestThread(goGroup.Go(), label, started, done)
+	<-started
+	defer func() {
+		close(done)
+		goGroup.Cancel()
+		goGroup.Wait()
+	}()
+	var p = &pprofServer{goGen: goGroup}
+	p.haltCount, p.haltMax = 2, time.Second
+	p.haltLast = &halt.HaltReport{N: 2, T: time.Now(), D: time.Millisecond}
+	var mux = p.mux()
+
+	// threads should list metrics and the thread
+	var recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/parl/threads", nil))
+	var body = recorder.Body.String()
+	if recorder.Code != 200 || !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("threads status %d content-type %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(body, "threads: 1") || !strings.Contains(body, label) {
+		t.Errorf("threads body %q", body)
+	}
+
+	// halt should show statistics
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/parl/halt", nil))
+	if body = recorder.Body.String(); recorder.Code != 200 ||
+		!strings.HasPrefix(body, "halts: 2 max: 1s\nlast: ") {
+		t.Errorf("halt status %d body %q", recorder.Code, body)
+	}
+}
+
+// pprofTestThread is a thread-group thread running until done closes
+func pprofTestThread(g parl.Go, label string, started, done chan struct{}) {
+	var err error
+	defer g.Register(label).Done(&err)
+
+	close(started)
+	<-done
+}
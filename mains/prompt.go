@@ -0,0 +1,205 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package mains
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// ErrNotInteractive is returned by [Prompt] methods when input
+// cannot be obtained interactively and there is no default
+//   - standard input is not a terminal, -silent or [Prompt.NonInteractive]
+var ErrNotInteractive = errors.New("not interactive")
+
+// Prompt asks questions on the terminal
+//   - the zero-value is usable: standard input and standard error
+//   - without a terminal, with -silent or NonInteractive,
+//     a provided default is returned without asking, otherwise [ErrNotInteractive]
+//   - not thread-safe
+//
+// Usage:
+//
+//	var prompt mains.Prompt
+//	if yes, err := prompt.Confirm("delete 3 files"); err != nil {
+//	  return
+//	} else if !yes {
+//	  return // canceled
+//	}
+//	…
+//	var p = mains.Prompt{Password: pterm.NewPassword("API key")}
+//	var key []byte
+//	if key, err = p.Secret(); err != nil {
+type Prompt struct {
+	// Input provides lines of answers
+	//	- default os.Stdin
+	//	- an Input that is not [os.File] is treated as interactive
+	Input io.Reader
+	// Output is where questions are printed
+	//	- default os.Stderr
+	Output io.Writer
+	// Password reads secret input for [Prompt.Secret]
+	//	- typically [github.com/haraldrudell/parl/pterm.NewPassword]
+	//	- nil: secret input unavailable
+	Password parl.Password
+	// NonInteractive true: defaults are used without asking
+	NonInteractive bool
+	// reader buffers Input
+	reader *bufio.Reader
+}
+
+// IsInteractive returns whether questions are asked
+//   - false if NonInteractive, -silent or standard input is not a terminal
+func (p *Prompt) IsInteractive() (isInteractive bool) {
+	if p.NonInteractive || parl.IsSilent() {
+		return // non-interactive configured return
+	}
+	var input = p.Input
+	if input == nil {
+		input = os.Stdin
+	}
+	var file, ok = input.(*os.File)
+	if !ok {
+		return true // not a file return
+	}
+	var fileInfo, err = file.Stat()
+	isInteractive = err == nil && fileInfo.Mode()&os.ModeCharDevice != 0
+
+	return
+}
+
+// Confirm asks a yes/no question
+//   - prints “question [y/N]: ”
+//   - defaultValue: answer for empty input or when not interactive.
+//     Missing: an answer is required
+//   - err: [ErrNotInteractive] or input failure
+func (p *Prompt) Confirm(question string, defaultValue ...bool) (yes bool, err error) {
+	var hasDefault = len(defaultValue) > 0
+	if hasDefault {
+		yes = defaultValue[0]
+	}
+	if !p.IsInteractive() {
+		if !hasDefault {
+			err = perrors.ErrorfPF("confirm %q: %w", question, ErrNotInteractive)
+		}
+		return
+	}
+	var choices = "[y/n]"
+	if hasDefault && yes {
+		choices = "[Y/n]"
+	} else if hasDefault {
+		choices = "[y/N]"
+	}
+	for {
+		var answer string
+		if answer, err = p.ask(question + " " + choices + ": "); err != nil {
+			return
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			if hasDefault {
+				return // default answer return
+			}
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// Select asks for one of choices
+//   - prints choices numbered from 1 followed by “question [1-3]: ”
+//   - index: zero-based index into choices
+//   - defaultIndex: answer for empty input or when not interactive
+//   - err: [ErrNotInteractive] or input failure
+func (p *Prompt) Select(question string, choices []string, defaultIndex ...int) (index int, err error) {
+	if len(choices) == 0 {
+		panic(parl.NilError("choices"))
+	}
+	var hasDefault = len(defaultIndex) > 0
+	if hasDefault {
+		if index = defaultIndex[0]; index < 0 || index >= len(choices) {
+			panic(perrors.ErrorfPF("defaultIndex %d out of range 0-%d", index, len(choices)-1))
+		}
+	}
+	if !p.IsInteractive() {
+		if !hasDefault {
+			err = perrors.ErrorfPF("select %q: %w", question, ErrNotInteractive)
+		}
+		return
+	}
+	var sL = make([]string, len(choices))
+	for i, choice := range choices {
+		sL[i] = strconv.Itoa(i+1) + ") " + choice + "\n"
+	}
+	var question2 = parl.Sprintf("%s%s [1-%d]: ", strings.Join(sL, ""), question, len(choices))
+	if hasDefault {
+		question2 = parl.Sprintf("%s%s [1-%d, default %d]: ", strings.Join(sL, ""), question, len(choices), index+1)
+	}
+	for {
+		var answer string
+		if answer, err = p.ask(question2); err != nil {
+			return
+		} else if answer == "" && hasDefault {
+			return // default answer return
+		}
+		if n, e := strconv.Atoi(answer); e == nil && n >= 1 && n <= len(choices) {
+			index = n - 1
+			return
+		}
+	}
+}
+
+// Secret reads secret input without echo using Password
+//   - err: [ErrNotInteractive], secret input unavailable or input failure
+func (p *Prompt) Secret() (secret []byte, err error) {
+	if !p.IsInteractive() {
+		err = perrors.ErrorfPF("secret: %w", ErrNotInteractive)
+		return
+	} else if p.Password == nil || !p.Password.HasPassword() {
+		err = perrors.NewPF("secret input unavailable")
+		return
+	}
+	return p.Password.Password()
+}
+
+// ask prints question and reads a line of input
+//   - answer: trimmed line
+func (p *Prompt) ask(question string) (answer string, err error) {
+	var output = p.Output
+	if output == nil {
+		output = os.Stderr
+	}
+	if _, err = io.WriteString(output, question); perrors.IsPF(&err, "prompt write %w", err) {
+		return
+	}
+	if p.reader == nil {
+		var input = p.Input
+		if input == nil {
+			input = os.Stdin
+		}
+		p.reader = bufio.NewReader(input)
+	}
+	var line string
+	line, err = p.reader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if perrors.IsPF(&err, "prompt read %w", err) {
+		return
+	}
+	answer = strings.TrimSpace(line)
+
+	return
+}
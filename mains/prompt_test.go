@@ -0,0 +1,122 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package mains
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPromptConfirm(t *testing.T) {
+	//t.Error("Logging on")
+	var tests = []struct {
+		input        string
+		defaultValue []bool
+		expYes       bool
+		expPrompts   int
+	}{
+		{"y\n", nil, true, 1},
+		{"YES\n", nil, true, 1},
+		{"n\n", nil, false, 1},
+		{"\n", []bool{true}, true, 1},
+		{"\n", []bool{false}, false, 1},
+		// bad answer and empty without default re-prompt
+		{"maybe\n\nyes\n", nil, true, 3},
+		// EOF without newline
+		{"y", nil, true, 1},
+	}
+
+	for _, test := range tests {
+		var output bytes.Buffer
+		var p = Prompt{Input: strings.NewReader(test.input), Output: &output}
+		var yes, err = p.Confirm("proceed", test.defaultValue...)
+		if err != nil {
+			t.Errorf("%q Confirm err %s", test.input, err)
+		} else if yes != test.expYes {
+			t.Errorf("%q yes %t exp %t", test.input, yes, test.expYes)
+		}
+		if n := strings.Count(output.String(), "proceed ["); n != test.expPrompts {
+			t.Errorf("%q prompts %d exp %d: %q", test.input, n, test.expPrompts, output.String())
+		}
+	}
+
+	// default marker
+	var output bytes.Buffer
+	var p = Prompt{Input: strings.NewReader("\n"), Output: &output}
+	p.Confirm("proceed", true)
+	if s := output.String(); s != "proceed [Y/n]: " {
+		t.Errorf("prompt %q", s)
+	}
+
+	// EOF before an answer
+	p = Prompt{Input: strings.NewReader(""), Output: io.Discard}
+	if _, err := p.Confirm("proceed"); !errors.Is(err, io.EOF) {
+		t.Errorf("EOF err %v", err)
+	}
+}
+
+func TestPromptSelect(t *testing.T) {
+	//t.Error("Logging on")
+	var choices = []string{"red", "green", "blue"}
+
+	// out of range and non-numeric answers re-prompt
+	var output bytes.Buffer
+	var p = Prompt{Input: strings.NewReader("4\n0\nx\n2\n"), Output: &output}
+	var index, err = p.Select("color", choices)
+	if err != nil {
+		t.Fatalf("Select err %s", err)
+	} else if index != 1 {
+		t.Errorf("index %d exp 1", index)
+	}
+	if n := strings.Count(output.String(), "color [1-3]: "); n != 4 {
+		t.Errorf("prompts %d exp 4: %q", n, output.String())
+	}
+	if !strings.HasPrefix(output.String(), "1) red\n2) green\n3) blue\n") {
+		t.Errorf("choices %q", output.String())
+	}
+
+	// empty answer is default
+	p = Prompt{Input: strings.NewReader("\n"), Output: io.Discard}
+	if index, err = p.Select("color", choices, 2); err != nil || index != 2 {
+		t.Errorf("default index %d err %v", index, err)
+	}
+}
+
+func TestPromptNonInteractive(t *testing.T) {
+	//t.Error("Logging on")
+	var p = Prompt{Input: strings.NewReader("n\n"), Output: io.Discard, NonInteractive: true}
+
+	if p.IsInteractive() {
+		t.Error("IsInteractive true")
+	}
+	if yes, err := p.Confirm("proceed", true); err != nil || !yes {
+		t.Errorf("Confirm default %t err %v", yes, err)
+	}
+	if _, err := p.Confirm("proceed"); !errors.Is(err, ErrNotInteractive) {
+		t.Errorf("Confirm err %v", err)
+	}
+	if index, err := p.Select("color", []string{"red"}, 0); err != nil || index != 0 {
+		t.Errorf("Select default %d err %v", index, err)
+	}
+	if _, err := p.Select("color", []string{"red"}); !errors.Is(err, ErrNotInteractive) {
+		t.Errorf("Select err %v", err)
+	}
+	if _, err := p.Secret(); !errors.Is(err, ErrNotInteractive) {
+		t.Errorf("Secret err %v", err)
+	}
+
+	// a reader that is not a file is interactive
+	p.NonInteractive = false
+	if !p.IsInteractive() {
+		t.Error("IsInteractive false")
+	}
+	if _, err := p.Secret(); err == nil || errors.Is(err, ErrNotInteractive) {
+		t.Errorf("Secret without Password err %v", err)
+	}
+}
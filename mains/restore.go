@@ -0,0 +1,60 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package mains
+
+import (
+	"slices"
+
+	"github.com/haraldrudell/parl"
+)
+
+// AddRestore registers a function invoked by [Executable.Recover]
+// prior to printing errors and exit messages
+//   - used to restore terminal state, like leaving alternate screen and raw mode,
+//     so that errors and panic stack traces are legible
+//   - restore functions are invoked in reverse order of registration
+//   - a restore function should be idempotent: it may also be invoked
+//     by the component itself
+//   - a panic in a restore function is added as an error
+//   - thread-safe
+//
+// Usage:
+//
+//	defer ex.Recover(&err)
+//	…
+//	var dashboard = pterm.NewDashboard()
+//	ex.AddRestore(dashboard.Restore)
+func (x *Executable) AddRestore(restore func()) {
+	if restore == nil {
+		panic(parl.NilError("restore"))
+	}
+	x.restoreLock.Lock()
+	defer x.restoreLock.Unlock()
+
+	x.restores = append(x.restores, restore)
+}
+
+// runRestores invokes and removes restore functions
+//   - invoked by [Executable.Recover]
+func (x *Executable) runRestores() {
+	x.restoreLock.Lock()
+	var restores = x.restores
+	x.restores = nil
+	x.restoreLock.Unlock()
+
+	slices.Reverse(restores)
+	for _, restore := range restores {
+		x.AddError(runRestore(restore))
+	}
+}
+
+// runRestore invokes restore recovering a panic
+func runRestore(restore func()) (err error) {
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	restore()
+	return
+}
@@ -0,0 +1,162 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package mains
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/yamlo"
+)
+
+const (
+	// defaultYamlKey is top-level yaml dictionary key when -yamlKey is empty
+	defaultYamlKey = "options"
+)
+
+// YamlReloader re-reads the yaml options file on SIGHUP so that
+// long-running services can hot-reload tunables
+//   - the file is located as at startup using
+//     BaseOptions -yamlFile -yamlKey -no-yaml
+//   - after the yaml was unmarshaled into the yaml value struct,
+//     apply is invoked to update [parl.Setting] values
+//   - a failed reload is provided to errorSink and previous settings remain
+//   - thread-safe
+//
+// Usage:
+//
+//	var maxConns = parl.NewSetting(y.MaxConns, validateMaxConns)
+//	var reloader = mains.NewYamlReloader(program, yamler.NewUnmarshaler(&y), func() (err error) {
+//	  return maxConns.Set(y.MaxConns)
+//	}, errorSink)
+//	reloader.Notify()
+//	defer reloader.Close()
+type YamlReloader struct {
+	program     string
+	genericYaml yamlo.GenericYaml
+	apply       func() (err error)
+	errorSink   parl.ErrorSink1
+	// reloadLock makes reloads sequential
+	reloadLock sync.Mutex
+	lock       sync.Mutex
+	// signalCh receives signals, behind lock
+	signalCh chan os.Signal
+	// isClosed ends the signal thread
+	isClosed parl.Awaitable
+}
+
+// NewYamlReloader returns a reloader of yaml options
+//   - program: app name “date” used to locate the yaml file
+//   - genericYaml: unmarshaler for the yaml value struct,
+//     typically [github.com/haraldrudell/parl/yamler.NewUnmarshaler]
+//   - apply: updates settings from the yaml value struct
+//   - errorSink: receives reload failures from the signal thread
+func NewYamlReloader(program string, genericYaml yamlo.GenericYaml, apply func() (err error), errorSink parl.ErrorSink1) (reloader *YamlReloader) {
+	if genericYaml == nil {
+		panic(parl.NilError("genericYaml"))
+	} else if apply == nil {
+		panic(parl.NilError("apply"))
+	} else if errorSink == nil {
+		panic(parl.NilError("errorSink"))
+	}
+	return &YamlReloader{
+		program:     program,
+		genericYaml: genericYaml,
+		apply:       apply,
+		errorSink:   errorSink,
+	}
+}
+
+// Notify reloads on signal
+//   - sig: default SIGHUP
+//   - reload is executed by a new thread
+func (r *YamlReloader) Notify(sig ...os.Signal) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.isClosed.IsClosed() {
+		return // closed return
+	} else if r.signalCh != nil {
+		signal.Notify(r.signalCh, sig...)
+		return // additional signals return
+	}
+	r.signalCh = make(chan os.Signal, 1)
+	signal.Notify(r.signalCh, sig...)
+	go r.signalThread(r.signalCh)
+}
+
+// Reload re-reads the yaml file and applies it
+//   - no file, -no-yaml or missing top-level key: no-op
+//   - err: read, unmarshal or apply failure
+func (r *YamlReloader) Reload() (err error) {
+	if !BaseOptions.DoYaml {
+		return // yaml disabled return
+	}
+	r.reloadLock.Lock()
+	defer r.reloadLock.Unlock()
+
+	var filename string
+	var yamlText []byte
+	if filename, yamlText, err = yamlo.FindFile(BaseOptions.YamlFile, r.program); err != nil {
+		return // read failure return
+	} else if filename == "" || len(yamlText) == 0 {
+		return // no yaml file return
+	}
+	var yamlKey = BaseOptions.YamlKey
+	if yamlKey == "" {
+		yamlKey = defaultYamlKey
+	}
+	var hasData bool
+	if hasData, err = r.genericYaml.Unmarshal(yamlText, yamlKey); perrors.IsPF(&err, "filename: %q: %w", filename, err) {
+		return // unmarshal failure return
+	} else if !hasData {
+		return // no data return
+	}
+	if err = r.apply(); perrors.IsPF(&err, "apply yaml %q: %w", filename, err) {
+		return
+	}
+	parl.Debug("reloaded yaml: %q", filename)
+
+	return
+}
+
+// Close stops reloading on signal
+//   - idempotent
+func (r *YamlReloader) Close() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.isClosed.Close() {
+		return // already closed return
+	}
+	if r.signalCh != nil {
+		signal.Stop(r.signalCh)
+	}
+}
+
+// signalThread reloads on each signal until Close
+func (r *YamlReloader) signalThread(signalCh <-chan os.Signal) {
+	var err error
+	defer parl.Recover(func() parl.DA { return parl.A() }, &err, r.errorSink)
+
+	for {
+		select {
+		case <-signalCh:
+			if e := r.Reload(); e != nil {
+				r.errorSink.AddError(e)
+			}
+		case <-r.isClosed.Ch():
+			return
+		}
+	}
+}
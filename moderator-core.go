@@ -6,10 +6,12 @@ ISC License
 package parl
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 const (
@@ -55,6 +57,14 @@ type ModeratorCore struct {
 	//	- behind lock
 	//	- atomic so it can be inspected
 	transferBehindLock atomic.Uint64
+	// waits is the number of tickets obtained after waiting
+	waits atomic.Uint64
+	// waitTotal is the sum of wait times in ns for waits
+	waitTotal atomic.Int64
+	// waitMax is the longest wait for a ticket
+	waitMax AtomicMax[time.Duration]
+	// canceled is the number of waits aborted by context
+	canceled atomic.Uint64
 }
 
 // moderatorCore is a parl-private version of ModeratorCore
@@ -75,6 +85,7 @@ func NewModeratorCore(parallelism uint64) (m *ModeratorCore) {
 
 // Ticket returns a ticket possibly blocking until one is available
 //   - Ticket returns the function for returning the ticket
+//   - [ModeratorCore.TicketCtx] aborts waiting on context cancel
 //
 // Usage:
 //
@@ -83,15 +94,38 @@ func (m *ModeratorCore) Ticket() (returnTicket func()) {
 	returnTicket = m.returnTicket
 
 	// try available ticket at atomic performance
+	if m.atomicTicket() {
+		return // got atomic ticket return
+	}
+
+	// enter lock mode
+	m.lockTicket(nil)
+
+	return
+}
+
+// atomicTicket attempts to obtain a ticket by atomic access
+//   - isTicket false: moderator is in lock mode
+func (m *ModeratorCore) atomicTicket() (isTicket bool) {
 	for {
 		if tickets := m.active.Load(); tickets == m.parallelism {
-			break // it’s lock mode
+			return // it’s lock mode
 		} else if m.active.CompareAndSwap(tickets, tickets+1) {
-			return // got atomic ticket return
+			return true // got atomic ticket return
 		}
 	}
+}
 
-	// enter lock mode
+// lockTicket obtains a ticket in lock mode
+//   - ctx nil: lockTicket blocks until a ticket is obtained
+//   - ctx non-nil: err is [*TicketError] if ctx is canceled prior to
+//     a ticket being obtained
+func (m *ModeratorCore) lockTicket(ctx context.Context) (err error) {
+	var t0 = time.Now()
+	if ctx != nil {
+		// wake up waiting threads on cancel
+		defer context.AfterFunc(ctx, m.broadcast)()
+	}
 	m.queue.L.Lock()
 	defer m.queue.L.Unlock()
 	defer m.lastWaitCheck()
@@ -101,20 +135,27 @@ func (m *ModeratorCore) Ticket() (returnTicket func()) {
 	for {
 
 		// attempt atomic ticket
-		for {
-			if tickets := m.active.Load(); tickets == m.parallelism {
-				break // still lock mode
-			} else if m.active.CompareAndSwap(tickets, tickets+1) {
-				return // got atomic ticket return
-			}
+		if m.atomicTicket() {
+			m.waitTime(t0, isWaiting)
+			return // got atomic ticket return
 		}
 
 		// attempt transfer-behind-lock ticket
 		if m.transferBehindLock.Load() > 0 {
 			m.transferBehindLock.Add(math.MaxUint64)
+			m.waitTime(t0, isWaiting)
 			return // ticket transfer successful return
 		}
 
+		// check for cancel
+		//	- a ticket transferred to this thread was consumed above,
+		//		so no ticket is lost on cancel
+		if ctx != nil && ctx.Err() != nil {
+			m.canceled.Add(1)
+			err = &TicketError{Cause: context.Cause(ctx), Waited: time.Since(t0)}
+			return // canceled return
+		}
+
 		// wait for ticket to become available
 		if !isWaiting {
 			isWaiting = true
@@ -126,6 +167,25 @@ func (m *ModeratorCore) Ticket() (returnTicket func()) {
 	}
 }
 
+// broadcast wakes all waiting threads
+func (m *ModeratorCore) broadcast() {
+	m.queue.L.Lock()
+	defer m.queue.L.Unlock()
+
+	m.queue.Broadcast()
+}
+
+// waitTime records the wait time of a thread that obtained a ticket
+func (m *ModeratorCore) waitTime(t0 time.Time, isWaiting bool) {
+	if !isWaiting {
+		return
+	}
+	var d = time.Since(t0)
+	m.waits.Add(1)
+	m.waitTotal.Add(int64(d))
+	m.waitMax.Value(d)
+}
+
 // lastWaitCheck prevents tickets from getting stuck as transfers
 //   - invoked while holding lock
 //   - this can happen if 1 thread is waiting and multiple threads transfer tickets
@@ -0,0 +1,128 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTicketCanceled indicates that waiting for a [ModeratorCore] ticket
+// was aborted by context cancel or deadline
+//   - errors.Is(err, parl.ErrTicketCanceled)
+//   - the error is [*TicketError]
+var ErrTicketCanceled = errors.New("moderator ticket wait canceled")
+
+// TicketError is returned when a context aborts waiting for a ticket
+//   - errors.Is matches [ErrTicketCanceled] and the context cause,
+//     eg. [context.DeadlineExceeded]
+type TicketError struct {
+	// Cause is [context.Cause] of the context
+	Cause error
+	// Waited is how long the thread waited prior to cancel
+	Waited time.Duration
+}
+
+// Error returns “moderator ticket wait canceled after 1s: context deadline exceeded”
+func (e *TicketError) Error() (s string) {
+	return ErrTicketCanceled.Error() + " after " + e.Waited.String() + ": " + e.Cause.Error()
+}
+
+// Unwrap returns [ErrTicketCanceled] and Cause
+func (e *TicketError) Unwrap() (errs []error) { return []error{ErrTicketCanceled, e.Cause} }
+
+// ModeratorWaits is wait-time observability of [ModeratorCore]
+//   - values may lack integrity
+type ModeratorWaits struct {
+	// Waiting is the number of threads currently waiting for a ticket
+	Waiting uint64
+	// Waits is the number of tickets obtained after waiting
+	Waits uint64
+	// Canceled is the number of waits aborted by context
+	Canceled uint64
+	// Total is the sum of wait times for Waits
+	Total time.Duration
+	// Max is the longest wait for a ticket
+	Max time.Duration
+}
+
+// Average returns the average wait time, 0 if no waits
+func (w ModeratorWaits) Average() (average time.Duration) {
+	if w.Waits == 0 {
+		return
+	}
+	return w.Total / time.Duration(w.Waits)
+}
+
+// TicketCtx returns a ticket possibly blocking until one is available
+// or ctx is canceled
+//   - returnTicket: the function for returning the ticket, nil on error
+//   - err: [*TicketError] if ctx canceled or its deadline passed
+//     prior to a ticket being obtained
+//   - an already canceled ctx returns error without obtaining a ticket
+//
+// Usage:
+//
+//	var returnTicket, err = moderator.TicketCtx(ctx)
+//	if err != nil {
+//	  return
+//	}
+//	defer returnTicket()
+func (m *ModeratorCore) TicketCtx(ctx context.Context) (returnTicket func(), err error) {
+	if ctx == nil {
+		panic(NilError("ctx"))
+	} else if ctx.Err() != nil {
+		m.canceled.Add(1)
+		err = &TicketError{Cause: context.Cause(ctx)}
+		return // canceled return
+	}
+
+	// try available ticket at atomic performance
+	if !m.atomicTicket() {
+		// enter lock mode
+		if err = m.lockTicket(ctx); err != nil {
+			return // canceled return
+		}
+	}
+	returnTicket = m.returnTicket
+
+	return
+}
+
+// DoCtx invokes fn holding a ticket
+//   - err: [*TicketError] if ctx canceled prior to a ticket being obtained,
+//     fn is then not invoked
+//   - err: error returned by fn or a panic in fn
+//   - the ticket is returned when fn returns or panics
+func (m *ModeratorCore) DoCtx(ctx context.Context, fn func() (err error)) (err error) {
+	if fn == nil {
+		panic(NilError("fn"))
+	}
+	var returnTicket func()
+	if returnTicket, err = m.TicketCtx(ctx); err != nil {
+		return
+	}
+	defer returnTicket()
+	defer RecoverErr(func() DA { return A() }, &err)
+
+	err = fn()
+
+	return
+}
+
+// Waits returns wait-time observability for capacity planning
+//   - Waiting is queue length
+//   - thread-safe
+func (m *ModeratorCore) Waits() (waits ModeratorWaits) {
+	return ModeratorWaits{
+		Waiting:  m.waiting.Load(),
+		Waits:    m.waits.Load(),
+		Canceled: m.canceled.Load(),
+		Total:    time.Duration(m.waitTotal.Load()),
+		Max:      m.waitMax.Max1(),
+	}
+}
@@ -0,0 +1,105 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestModeratorCoreTicketCtx(t *testing.T) {
+	//t.Error("Logging on")
+	var (
+		m                   = NewModeratorCore(1)
+		returnTicket        func()
+		err                 error
+		ticketError         *TicketError
+		waits               ModeratorWaits
+		ctx, cancel         = context.WithCancel(context.Background())
+		isDone              = make(chan struct{})
+		timeoutCtx, cancel2 = context.WithTimeout(context.Background(), shortTime)
+	)
+	defer cancel()
+	defer cancel2()
+
+	// TicketCtx should obtain available ticket
+	if returnTicket, err = m.TicketCtx(ctx); err != nil {
+		t.Fatalf("TicketCtx err %s", err)
+	}
+
+	// deadline should abort wait with typed error
+	if _, err = m.TicketCtx(timeoutCtx); !errors.As(err, &ticketError) {
+		t.Fatalf("TicketCtx timeout err %v", err)
+	} else if !errors.Is(err, ErrTicketCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("TicketCtx timeout err not Is: %s", err)
+	} else if ticketError.Waited == 0 {
+		t.Error("TicketCtx Waited zero")
+	}
+
+	// cancel should abort a waiting DoCtx
+	go func() {
+		defer close(isDone)
+		if err := m.DoCtx(ctx, func() (err error) { return }); !errors.Is(err, context.Canceled) {
+			t.Errorf("DoCtx err %v", err)
+		}
+	}()
+	for m.Waits().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-isDone
+
+	// returned ticket should be available to waiter
+	returnTicket()
+	var isInvoked bool
+	if err = m.DoCtx(context.Background(), func() (err error) { isInvoked = true; return }); err != nil || !isInvoked {
+		t.Errorf("DoCtx %t err %v", isInvoked, err)
+	}
+	if _, active, _ := m.Status(); active != 0 {
+		t.Errorf("active %d exp 0", active)
+	}
+
+	// observability
+	waits = m.Waits()
+	if waits.Canceled != 2 || waits.Waiting != 0 {
+		t.Errorf("Waits %+v", waits)
+	}
+}
+
+func TestModeratorCoreTicketCtxTransfer(t *testing.T) {
+	//t.Error("Logging on")
+	var (
+		m            = NewModeratorCore(1)
+		returnTicket = m.Ticket()
+		isDone       = make(chan struct{})
+		waits        ModeratorWaits
+	)
+
+	// a waiting TicketCtx should receive a transferred ticket
+	go func() {
+		defer close(isDone)
+		var r, err = m.TicketCtx(context.Background())
+		if err != nil {
+			t.Errorf("TicketCtx err %s", err)
+			return
+		}
+		r()
+	}()
+	for m.Waits().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	returnTicket()
+	<-isDone
+
+	if waits = m.Waits(); waits.Waits != 1 || waits.Max == 0 || waits.Average() == 0 {
+		t.Errorf("Waits %+v", waits)
+	}
+	if _, active, _ := m.Status(); active != 0 {
+		t.Errorf("active %d exp 0", active)
+	}
+}
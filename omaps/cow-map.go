@@ -0,0 +1,138 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package omaps
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/btree"
+	"golang.org/x/exp/constraints"
+)
+
+// COWMap is a key-ordered copy-on-write map for lock-free readers
+//   - readers never lock: Get Length Range List Snapshot are
+//     a single atomic load
+//   - writers clone the current map, modify the clone and
+//     atomically swap it in. Writers are serialized by a lock
+//   - a write is O(n) for the Go map and O(log n) amortized for
+//     the copy-on-write B-tree
+//   - [COWMap.Update] applies many modifications using a single clone
+//   - suited for read-mostly data like configuration tables and
+//     thread registries
+//   - thread-safe
+type COWMap[K constraints.Ordered, V any] struct {
+	// newMap returns an empty map
+	newMap func() (m *KeyOrderedMap[K, V])
+	// writeLock serializes writers
+	writeLock sync.Mutex
+	// m is the current immutable map
+	m atomic.Pointer[KeyOrderedMap[K, V]]
+}
+
+// COWSnapshot is an immutable view of a [COWMap]
+//   - a snapshot is unaffected by later writes
+type COWSnapshot[K constraints.Ordered, V any] struct {
+	m *KeyOrderedMap[K, V]
+}
+
+// NewCOWMap returns a key-ordered copy-on-write map
+func NewCOWMap[K btree.Ordered, V any]() (cowMap *COWMap[K, V]) {
+	var c = COWMap[K, V]{newMap: NewKeyOrderedMap[K, V]}
+	c.m.Store(c.newMap())
+	return &c
+}
+
+// NewCOWMapOrdered returns a key-ordered copy-on-write map
+//   - [constraints.Ordered] is [btree.Ordered] plus uintptr
+//   - if K type does not have uintptr, use [NewCOWMap]
+func NewCOWMapOrdered[K constraints.Ordered, V any]() (cowMap *COWMap[K, V]) {
+	var c = COWMap[K, V]{newMap: NewKeyOrderedMapOrdered[K, V]}
+	c.m.Store(c.newMap())
+	return &c
+}
+
+// Get returns the value mapped by key
+//   - ok: true if a mapping was found
+//   - lock-free O(1)
+func (c *COWMap[K, V]) Get(key K) (value V, ok bool) { return c.m.Load().Get(key) }
+
+// Length returns the number of mappings
+//   - lock-free
+func (c *COWMap[K, V]) Length() (length int) { return c.m.Load().Length() }
+
+// Range traverses mappings of the current snapshot
+//   - order is undefined
+//   - rangeFunc may write to the map
+func (c *COWMap[K, V]) Range(rangeFunc func(key K, value V) (keepGoing bool)) (rangedAll bool) {
+	return c.m.Load().Range(rangeFunc)
+}
+
+// List returns keys in order
+//   - n zero or missing means all items
+//   - n non-zero means this many items capped by length
+func (c *COWMap[K, V]) List(n ...int) (list []K) { return c.m.Load().List(n...) }
+
+// Snapshot returns an immutable view of the map
+//   - used for multiple consistent reads
+//   - lock-free O(1)
+func (c *COWMap[K, V]) Snapshot() (snapshot COWSnapshot[K, V]) {
+	return COWSnapshot[K, V]{m: c.m.Load()}
+}
+
+// Put creates or replaces a mapping
+//   - O(n) copy. For multiple writes, use [COWMap.Update]
+func (c *COWMap[K, V]) Put(key K, value V) {
+	c.Update(func(m *KeyOrderedMap[K, V]) { m.Put(key, value) })
+}
+
+// Delete removes a mapping
+//   - no-op if mapping does not exist
+func (c *COWMap[K, V]) Delete(key K) {
+	if _, ok := c.Get(key); !ok {
+		return // mapping does not exist return
+	}
+	c.Update(func(m *KeyOrderedMap[K, V]) { m.Delete(key) })
+}
+
+// Clear removes all mappings
+//   - O(1): no copy is made
+func (c *COWMap[K, V]) Clear() {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.m.Store(c.newMap())
+}
+
+// Update applies modifications to a private clone that is then
+// atomically made current
+//   - updater: modifies the map. updater must not retain m
+//   - readers observe all or none of the modifications
+//   - concurrent writers are serialized
+func (c *COWMap[K, V]) Update(updater func(m *KeyOrderedMap[K, V])) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	var m = c.m.Load().Clone()
+	updater(m)
+	c.m.Store(m)
+}
+
+// Get returns the value mapped by key
+func (s COWSnapshot[K, V]) Get(key K) (value V, ok bool) { return s.m.Get(key) }
+
+// Length returns the number of mappings
+func (s COWSnapshot[K, V]) Length() (length int) { return s.m.Length() }
+
+// Range traverses mappings
+//   - order is undefined
+func (s COWSnapshot[K, V]) Range(rangeFunc func(key K, value V) (keepGoing bool)) (rangedAll bool) {
+	return s.m.Range(rangeFunc)
+}
+
+// List returns keys in order
+//   - n zero or missing means all items
+func (s COWSnapshot[K, V]) List(n ...int) (list []K) { return s.m.List(n...) }
@@ -0,0 +1,66 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package omaps
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCOWMap(t *testing.T) {
+	//t.Error("Logging on")
+	var expList = []int{1, 2, 3}
+
+	var m = NewCOWMap[int, string]()
+	m.Put(3, "c")
+	var snapshot = m.Snapshot()
+	m.Update(func(batch *KeyOrderedMap[int, string]) {
+		batch.Put(1, "a")
+		batch.Put(2, "b")
+	})
+
+	// the snapshot is unaffected by later writes
+	if n := snapshot.Length(); n != 1 {
+		t.Errorf("snapshot Length %d exp 1", n)
+	}
+	if list := m.List(); !slices.Equal(list, expList) {
+		t.Errorf("List %v exp %v", list, expList)
+	}
+	if v, ok := m.Get(2); !ok || v != "b" {
+		t.Errorf("Get %q %t", v, ok)
+	}
+
+	m.Delete(2)
+	if _, ok := m.Get(2); ok {
+		t.Error("Delete failed")
+	}
+	m.Clear()
+	if n := m.Length(); n != 0 {
+		t.Errorf("Length %d exp 0", n)
+	}
+	if list := snapshot.List(); !slices.Equal(list, []int{3}) {
+		t.Errorf("snapshot List %v", list)
+	}
+}
+
+func TestCOWMapConcurrent(t *testing.T) {
+	var m = NewCOWMap[int, int]()
+	var done = make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			m.Put(i, i)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		m.List()
+		m.Get(i)
+	}
+	<-done
+	if n := m.Length(); n != 100 {
+		t.Errorf("Length %d exp 100", n)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// [NewOnceValue] a failed initialization is cached like [sync.OnceValues]
+	OnceCacheError = false
+	// [NewOnceValue] a failed initialization is not cached,
+	// the next Get invokes the initializer again
+	OnceRetryError = true
+)
+
+// OnceValue is a memoizing initializer
+//   - the initializer executes once and its result is cached
+//   - retry mode [OnceRetryError]: a failed initialization is not cached
+//     and the next [OnceValue.Get] retries
+//   - concurrent callers await an initialization in progress
+//     rather than duplicating work
+//   - a panic in the initializer is recovered as an error
+//   - unlike [sync.OnceValues]: observable, retry on error, panic-free
+//   - thread-safe
+//
+// Usage:
+//
+//	var config = parl.NewOnceValue(readConfig, parl.OnceRetryError)
+//	…
+//	var c, err = config.Get()
+type OnceValue[T any] struct {
+	// initializer provides the value
+	initializer func() (value T, err error)
+	// retryOnError true: failed initialization is not cached
+	retryOnError bool
+	// result is the cached outcome
+	//	- nil: not initialized or retrying
+	result atomic.Pointer[TResult[T]]
+	// lock makes attempt thread-safe
+	lock sync.Mutex
+	// attempt is an initialization in progress
+	//	- nil if no initialization in progress
+	//	- behind lock
+	attempt *onceValueAttempt[T]
+}
+
+// onceValueAttempt is a single execution of the initializer
+type onceValueAttempt[T any] struct {
+	// done closes when result is available
+	done Awaitable
+	// result is written prior to done closing
+	result *TResult[T]
+}
+
+// NewOnceValue returns a memoizing initializer
+//   - initializer: function providing the value, invoked on first Get
+//   - retryOnError: [OnceCacheError] [OnceRetryError]
+func NewOnceValue[T any](initializer func() (value T, err error), retryOnError bool) (onceValue *OnceValue[T]) {
+	if initializer == nil {
+		panic(NilError("initializer"))
+	}
+	return &OnceValue[T]{initializer: initializer, retryOnError: retryOnError}
+}
+
+// Get returns the value, invoking the initializer if required
+//   - if an initialization is in progress, Get awaits its result
+//   - err: initializer error or panic
+//   - may block. Thread-safe
+func (o *OnceValue[T]) Get() (value T, err error) {
+	var result = o.getResult()
+	return result.Value, result.Err
+}
+
+// IsDone returns true if a result is cached
+//   - retry mode: true only after successful initialization
+func (o *OnceValue[T]) IsDone() (isDone bool) { return o.result.Load() != nil }
+
+// InProgress returns an awaitable channel if an initialization is in progress
+//   - isInProgress false: ch is nil
+//   - ch closes when the in-progress initialization completes
+func (o *OnceValue[T]) InProgress() (ch AwaitableCh, isInProgress bool) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if isInProgress = o.attempt != nil; isInProgress {
+		ch = o.attempt.done.Ch()
+	}
+	return
+}
+
+// TResult returns the cached result
+//   - nil if no result is cached
+func (o *OnceValue[T]) TResult() (tResult *TResult[T]) { return o.result.Load() }
+
+// getResult returns cached result or result of an awaited or executed attempt
+func (o *OnceValue[T]) getResult() (result *TResult[T]) {
+
+	// fast path: cached
+	if result = o.result.Load(); result != nil {
+		return // cached result return
+	}
+
+	o.lock.Lock()
+	if result = o.result.Load(); result != nil {
+		o.lock.Unlock()
+		return // result cached by other thread return
+	}
+	var attempt = o.attempt
+	if attempt != nil {
+		o.lock.Unlock()
+		<-attempt.done.Ch()
+		return attempt.result // result from other thread’s attempt return
+	}
+	attempt = &onceValueAttempt[T]{}
+	o.attempt = attempt
+	o.lock.Unlock()
+
+	return o.execute(attempt)
+}
+
+// execute invokes the initializer
+func (o *OnceValue[T]) execute(attempt *onceValueAttempt[T]) (result *TResult[T]) {
+	result = NewTResult(o.initializer)
+	attempt.result = result
+
+	o.lock.Lock()
+	if result.Err == nil || !o.retryOnError {
+		o.result.Store(result)
+	}
+	o.attempt = nil
+	o.lock.Unlock()
+
+	attempt.done.Close()
+
+	return
+}
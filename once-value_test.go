@@ -0,0 +1,106 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOnceValue(t *testing.T) {
+	//t.Error("Logging on")
+	const expValue = 3
+	var errBad = errors.New("bad")
+
+	// cache error
+	var init1 = newOnceValueTester(errBad)
+	var onceValue = NewOnceValue(init1.initializer, OnceCacheError)
+	if _, err := onceValue.Get(); err != errBad {
+		t.Errorf("Get err %v exp %v", err, errBad)
+	}
+	onceValue.Get()
+	if n := init1.count.Load(); n != 1 {
+		t.Errorf("cache invocations %d exp 1", n)
+	}
+	if !onceValue.IsDone() {
+		t.Error("IsDone false")
+	}
+
+	// retry error
+	var init2 = newOnceValueTester(errBad)
+	onceValue = NewOnceValue(init2.initializer, OnceRetryError)
+	if _, err := onceValue.Get(); err != errBad {
+		t.Errorf("Get err %v exp %v", err, errBad)
+	}
+	if onceValue.IsDone() {
+		t.Error("IsDone true")
+	}
+	init2.err = nil
+	if v, err := onceValue.Get(); err != nil || v != expValue {
+		t.Errorf("Get %d %v", v, err)
+	}
+	onceValue.Get()
+	if n := init2.count.Load(); n != 2 {
+		t.Errorf("retry invocations %d exp 2", n)
+	}
+
+	// concurrent callers await in-progress initialization
+	var init3 = newOnceValueTester(nil)
+	init3.block = make(chan struct{})
+	init3.started = make(chan struct{})
+	onceValue = NewOnceValue(init3.initializer, OnceRetryError)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		onceValue.Get()
+	}()
+	<-init3.started
+	var ch, isInProgress = onceValue.InProgress()
+	if !isInProgress {
+		t.Error("InProgress false")
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if v, _ := onceValue.Get(); v != expValue {
+			t.Errorf("waiter value %d exp %d", v, expValue)
+		}
+	}()
+	close(init3.block)
+	<-ch
+	wg.Wait()
+	if n := init3.count.Load(); n != 1 {
+		t.Errorf("concurrent invocations %d exp 1", n)
+	}
+
+	// panic
+	onceValue = NewOnceValue(func() (value int, err error) { panic(1) }, OnceCacheError)
+	if _, err := onceValue.Get(); err == nil {
+		t.Error("panic err nil")
+	}
+}
+
+// onceValueTester is an initializer counting invocations
+type onceValueTester struct {
+	count   atomic.Int64
+	err     error
+	started chan struct{}
+	block   chan struct{}
+}
+
+func newOnceValueTester(err error) (o *onceValueTester) { return &onceValueTester{err: err} }
+
+func (o *onceValueTester) initializer() (value int, err error) {
+	o.count.Add(1)
+	if o.started != nil {
+		close(o.started)
+		<-o.block
+	}
+	return 3, o.err
+}
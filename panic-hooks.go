@@ -0,0 +1,129 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// PanicEvent describes a recovered panic provided to a [PanicHook]
+type PanicEvent struct {
+	// Err is the recovered panic as error with stack trace
+	Err error
+	// ThreadID is the goroutine that recovered the panic
+	//	- may be invalid: ThreadID.IsValid
+	ThreadID ThreadID
+	// ThreadData is information on a g0 Go thread
+	//	- nil if the goroutine was not launched by a g0 Go object
+	ThreadData ThreadData
+}
+
+// PanicHook observes panics recovered by [Recover] [Recover2] [RecoverErr]
+// [RecoverAnnotation] [PanicToErr] and mains
+//   - used for centralized crash metrics and external reporting
+//   - hooks are invoked synchronously by the goroutine that recovered
+//     the panic and should be fast
+//   - a panic in a hook is logged and does not affect recovery
+type PanicHook func(event *PanicEvent)
+
+// panicHooks is the process-wide panic-hook registry
+var panicHooks panicHookRegistry
+
+// panicHookRegistry is a copy-on-write list of panic hooks
+type panicHookRegistry struct {
+	// lock serializes AddPanicHook and remove
+	lock sync.Mutex
+	// hooks is read without lock
+	hooks atomic.Pointer[[]*PanicHook]
+	// threads is map[ThreadID]ThreadData of registered threads
+	threads sync.Map
+}
+
+// AddPanicHook adds a process-wide panic observer
+//   - remove: removes the hook, idempotent
+//   - thread-safe
+func AddPanicHook(hook PanicHook) (remove func()) {
+	if hook == nil {
+		panic(NilError("hook"))
+	}
+	var hookp = &hook
+	panicHooks.lock.Lock()
+	defer panicHooks.lock.Unlock()
+
+	var hooks []*PanicHook
+	if hp := panicHooks.hooks.Load(); hp != nil {
+		hooks = append(hooks, *hp...)
+	}
+	hooks = append(hooks, hookp)
+	panicHooks.hooks.Store(&hooks)
+
+	return func() { panicHooks.remove(hookp) }
+}
+
+// InvokePanicHooks invokes registered panic hooks with a recovered panic
+//   - err: the recovered panic as error
+//   - invoked by parl’s Recover functions and mains.
+//     Used by other code invoking recover() directly
+//   - thread-safe
+func InvokePanicHooks(err error) {
+	var hp = panicHooks.hooks.Load()
+	if hp == nil || len(*hp) == 0 {
+		return // no hooks return
+	}
+	var event = PanicEvent{Err: err}
+	if event.ThreadID = goID(); event.ThreadID.IsValid() {
+		if value, ok := panicHooks.threads.Load(event.ThreadID); ok {
+			event.ThreadData = value.(ThreadData)
+		}
+	}
+	for _, hook := range *hp {
+		invokePanicHook(*hook, &event)
+	}
+}
+
+// SetPanicThreadData provides thread data for panics recovered by threadID
+//   - used by g0 Go objects
+//   - threadData nil: removes threadID
+//   - thread-safe
+func SetPanicThreadData(threadID ThreadID, threadData ThreadData) {
+	if !threadID.IsValid() {
+		return
+	} else if threadData == nil {
+		panicHooks.threads.Delete(threadID)
+		return
+	}
+	panicHooks.threads.Store(threadID, threadData)
+}
+
+// remove removes hookp from the registry
+func (r *panicHookRegistry) remove(hookp *PanicHook) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var hp = r.hooks.Load()
+	if hp == nil {
+		return
+	}
+	var hooks = make([]*PanicHook, 0, len(*hp))
+	for _, h := range *hp {
+		if h != hookp {
+			hooks = append(hooks, h)
+		}
+	}
+	r.hooks.Store(&hooks)
+}
+
+// invokePanicHook invokes hook recovering any panic
+func invokePanicHook(hook PanicHook, event *PanicEvent) {
+	defer func() {
+		if recoverValue := recover(); recoverValue != nil {
+			Log("panic hook panic: %v recovering: %s", recoverValue, event.Err)
+		}
+	}()
+
+	hook(event)
+}
@@ -0,0 +1,75 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddPanicHook(t *testing.T) {
+	//t.Error("Logging on")
+	var panicValue = errors.New("bad")
+
+	var events []*PanicEvent
+	var remove = AddPanicHook(func(event *PanicEvent) { events = append(events, event) })
+	// a panicking hook does not affect recovery
+	var removePanicking = AddPanicHook(func(event *PanicEvent) { panic(1) })
+	defer remove()
+
+	// thread data for this goroutine
+	var threadID = goID()
+	var threadData = &testThreadData{}
+	SetPanicThreadData(threadID, threadData)
+	defer SetPanicThreadData(threadID, nil)
+
+	// Recover invokes hooks
+	var err error
+	func() {
+		defer Recover(func() DA { return A() }, &err, NoopErrorSink)
+
+		panic(panicValue)
+	}()
+	removePanicking()
+	if len(events) != 1 {
+		t.Fatalf("events %d exp 1", len(events))
+	}
+	var event = events[0]
+	if !errors.Is(event.Err, panicValue) {
+		t.Errorf("Err %v exp %v", event.Err, panicValue)
+	}
+	if event.ThreadID != threadID {
+		t.Errorf("ThreadID %s exp %s", event.ThreadID, threadID)
+	}
+	if event.ThreadData != threadData {
+		t.Errorf("ThreadData %v exp %v", event.ThreadData, threadData)
+	}
+
+	// PanicToErr invokes hooks
+	err = nil
+	func() {
+		defer PanicToErr(&err)
+
+		panic(panicValue)
+	}()
+	if len(events) != 2 {
+		t.Errorf("events %d exp 2", len(events))
+	}
+
+	// removed hook is not invoked
+	remove()
+	func() {
+		defer PanicToErr(&err)
+
+		panic(panicValue)
+	}()
+	if len(events) != 2 {
+		t.Errorf("events after remove %d exp 2", len(events))
+	}
+}
+
+// testThreadData is a distinguishable ThreadData
+type testThreadData struct{ ThreadData }
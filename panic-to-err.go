@@ -63,5 +63,7 @@ func PanicToErr(errp *error, isPanic ...*bool) {
 	//	- because PanicToErr is invoked directly by the runtime, possibly runtime.gopanic,
 	//		the PanicToErr stack frame must be included.
 	//		Therefore, 0 argument to processRecover
-	*errp = perrors.AppendError(*errp, processRecoverValue(panicToErrAnnotation, panicValue, 0))
+	var panicError = processRecoverValue(panicToErrAnnotation, panicValue, 0)
+	InvokePanicHooks(panicError)
+	*errp = perrors.AppendError(*errp, panicError)
 }
@@ -77,12 +77,26 @@ parl package features:
 	atomic.Bool — Thread-safe boolean
 	Closer — Deferrable, panic-free channel close
 	ClosableChan — Initialization-free channel with observable deferrable panic-free close
-	Moderator — A ticketing system for limited parallelism
+	Moderator — A ticketing system for limited parallelism, TicketCtx DoCtx abort waiting on context
 	NBChan — A non-blocking channel with trillion-size dynamic buffer
 	SerialDo — Serialization of invocations
 	WaitGroup —Observable WaitGroup
 	Debouncer — Invocation debouncer, pre-generics
+	Debounce — Batching debouncer with leading edge and max latency
+	AwaitableMap — Key-value store with per-key waiters
+	RingBuffer — Bounded awaitable queue overwriting oldest or rejecting newest
+	Setting — Hot-reloadable validated configuration value with change channel
+	SignalValue — One-shot awaitable event carrying a typed value
+	Cache — Least-recently used cache with time-to-live, eviction callback and single-flight loading
+	FromSeq ToSeq FromChan ToChan — Adapters between iterable sources, Go iterators and channels
+	DrainCloser DrainClose — Two-phase close of sinks and in-order close of a pipeline of sinks
+	WaitAll WaitAny AwaitAll AwaitAny — Composite awaitables closing when all or any of several awaitables close
+	StateMachine — Declared state transitions with hooks, awaitable states and transition history
+	Shutdowner — Ordered shutdown hooks with timeouts and deadline
+	Pool — Generic object pool with lifetime validation
+	Topics — Typed publish-subscribe topic registry
 	Sprintf — Supporting thousands separator
+	Resumable — Cursor checkpointing resuming long scans after restart
 
 # Parl is about 15,000 lines of Go code with first line written on November 21, 2018
 
@@ -0,0 +1,496 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parlca
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// LetsEncryptURL is the directory of the Let’s Encrypt production ACME server
+	LetsEncryptURL = "https://acme-v02.api.letsencrypt.org/directory"
+	// LetsEncryptStagingURL is the directory of the Let’s Encrypt staging ACME server
+	//	- issued certificates are not publicly trusted
+	//	- rate limits are higher
+	LetsEncryptStagingURL = "https://acme-staging-v02.api.letsencrypt.org/directory"
+	// ChallengeHTTP01 proves domain control by an http response on port 80
+	ChallengeHTTP01 = "http-01"
+	// ChallengeTLSALPN01 proves domain control by a TLS handshake on port 443
+	ChallengeTLSALPN01 = "tls-alpn-01"
+)
+
+const (
+	// ACME status values
+	acmeStatusPending    = "pending"
+	acmeStatusProcessing = "processing"
+	acmeStatusValid      = "valid"
+	acmeStatusInvalid    = "invalid"
+	// content types
+	acmeContentType   = "application/jose+json"
+	acmePEMChainType  = "application/pem-certificate-chain"
+	acmeProblemPrefix = "urn:ietf:params:acme:error:"
+	acmeBadNonce      = acmeProblemPrefix + "badNonce"
+	// header fields
+	acmeNonceHeader      = "Replay-Nonce"
+	acmeLocationHeader   = "Location"
+	acmeRetryAfterHeader = "Retry-After"
+	// interval polling authorizations and orders
+	acmePollInterval = time.Second
+	// max response size read
+	acmeMaxResponse = 1 << 20
+)
+
+// ACMEClient obtains certificates from an ACME server like Let’s Encrypt
+//   - RFC 8555 Automatic Certificate Management Environment
+//   - account key is ECDSA P-256, signatures are ES256
+//   - domain control is proven by [ChallengeHTTP01] or [ChallengeTLSALPN01]
+//     served by [ACMEResponder]
+//   - [ACMEManager] provides certificates for [tls.Config] with renewal
+//   - thread-safe
+type ACMEClient struct {
+	directoryURL string
+	accountKey   *ecdsa.PrivateKey
+	contact      []string
+	httpClient   *http.Client
+	// lock serializes account and nonce state
+	lock sync.Mutex
+	// directory is the server’s endpoints, behind lock
+	directory *acmeDirectory
+	// kid is the account URL, behind lock
+	kid string
+	// nonce is the most recent unused nonce, behind lock
+	nonce string
+}
+
+// acmeDirectory is the ACME server’s endpoint URLs
+type acmeDirectory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+// acmeOrder is an order for a certificate
+type acmeOrder struct {
+	Status         string   `json:"status"`
+	Authorizations []string `json:"authorizations"`
+	Finalize       string   `json:"finalize"`
+	Certificate    string   `json:"certificate"`
+}
+
+// acmeAuthorization is proof of control for one identifier
+type acmeAuthorization struct {
+	Status     string `json:"status"`
+	Identifier struct {
+		Value string `json:"value"`
+	} `json:"identifier"`
+	Challenges []acmeChallenge `json:"challenges"`
+}
+
+// acmeChallenge is a way to prove control of an identifier
+type acmeChallenge struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Token  string `json:"token"`
+	Status string `json:"status"`
+}
+
+// ACMEProblem is an error response from an ACME server
+//   - RFC 7807 problem document
+type ACMEProblem struct {
+	Type       string `json:"type"`
+	Detail     string `json:"detail"`
+	HTTPStatus int    `json:"status"`
+}
+
+// Error: “acme: 400 urn:ietf:params:acme:error:badNonce: JWS has an invalid anti-replay nonce”
+func (p *ACMEProblem) Error() (s string) {
+	return "acme: " + strconv.Itoa(p.HTTPStatus) + "\x20" + p.Type + ": " + p.Detail
+}
+
+// NewACMEClient returns a client for the ACME server at directoryURL
+//   - directoryURL: [LetsEncryptURL] [LetsEncryptStagingURL] or other
+//   - accountKey: identifies the account. Persist using [ACMEManager] or
+//     [x509.MarshalPKCS8PrivateKey]
+//   - contact: optional account contacts like “mailto:admin@example.com”
+//   - httpClient: nil is [http.DefaultClient]
+func NewACMEClient(directoryURL string, accountKey *ecdsa.PrivateKey, contact []string, httpClient *http.Client) (client *ACMEClient) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &ACMEClient{
+		directoryURL: directoryURL,
+		accountKey:   accountKey,
+		contact:      contact,
+		httpClient:   httpClient,
+	}
+}
+
+// Register creates or looks up the account for the account key
+//   - agrees to the server’s terms of service
+//   - Register is invoked implicitly by [ACMEClient.Obtain]
+func (c *ACMEClient) Register(ctx context.Context) (err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.kid != "" {
+		return // already registered return
+	} else if err = c.getDirectory(ctx); err != nil {
+		return
+	}
+	var payload = struct {
+		TermsOfServiceAgreed bool     `json:"termsOfServiceAgreed"`
+		Contact              []string `json:"contact,omitempty"`
+	}{TermsOfServiceAgreed: true, Contact: c.contact}
+	var header http.Header
+	if _, header, err = c.post(ctx, c.directory.NewAccount, payload); err != nil {
+		return
+	}
+	if c.kid = header.Get(acmeLocationHeader); c.kid == "" {
+		err = perrors.NewPF("newAccount: no account URL")
+	}
+
+	return
+}
+
+// Obtain has the ACME server issue a certificate for domains
+//   - domains: the first domain is certificate common name
+//   - certKey: the certificate’s private key, not the account key
+//   - responder: serves challenge responses while validation is in progress
+//   - chain: DER certificates, leaf first
+//   - Obtain blocks until issued, failed or ctx is canceled
+func (c *ACMEClient) Obtain(ctx context.Context, domains []string, certKey crypto.Signer, responder *ACMEResponder) (chain [][]byte, err error) {
+	if len(domains) == 0 {
+		err = perrors.NewPF("no domains")
+		return
+	} else if err = c.Register(ctx); err != nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// create order
+	type identifier struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	}
+	var payload struct {
+		Identifiers []identifier `json:"identifiers"`
+	}
+	for _, domain := range domains {
+		payload.Identifiers = append(payload.Identifiers, identifier{Type: "dns", Value: domain})
+	}
+	var body []byte
+	var header http.Header
+	if body, header, err = c.post(ctx, c.directory.NewOrder, payload); err != nil {
+		return
+	}
+	var order acmeOrder
+	if err = json.Unmarshal(body, &order); perrors.IsPF(&err, "newOrder json.Unmarshal %w", err) {
+		return
+	}
+	var orderURL = header.Get(acmeLocationHeader)
+
+	// prove control of each identifier
+	for _, authzURL := range order.Authorizations {
+		if err = c.authorize(ctx, authzURL, responder); err != nil {
+			return
+		}
+	}
+
+	// finalize with certificate signing request
+	var csr []byte
+	var template = x509.CertificateRequest{Subject: pkix.Name{CommonName: domains[0]}, DNSNames: domains}
+	if csr, err = x509.CreateCertificateRequest(rand.Reader, &template, certKey); perrors.IsPF(&err, "x509.CreateCertificateRequest %w", err) {
+		return
+	}
+	var finalize = struct {
+		CSR string `json:"csr"`
+	}{CSR: base64.RawURLEncoding.EncodeToString(csr)}
+	if body, _, err = c.post(ctx, order.Finalize, finalize); err != nil {
+		return
+	} else if err = json.Unmarshal(body, &order); perrors.IsPF(&err, "finalize json.Unmarshal %w", err) {
+		return
+	}
+
+	// await issuance
+	for order.Status != acmeStatusValid {
+		if order.Status == acmeStatusInvalid {
+			err = perrors.ErrorfPF("order invalid: %s", orderURL)
+			return
+		} else if err = c.sleep(ctx, header); err != nil {
+			return
+		}
+		if body, header, err = c.post(ctx, orderURL, nil); err != nil {
+			return
+		} else if err = json.Unmarshal(body, &order); perrors.IsPF(&err, "order json.Unmarshal %w", err) {
+			return
+		}
+	}
+
+	// download certificate chain
+	if body, _, err = c.post(ctx, order.Certificate, nil); err != nil {
+		return
+	}
+	for block, rest := pem.Decode(body); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == pemCertificateType {
+			chain = append(chain, block.Bytes)
+		}
+	}
+	if len(chain) == 0 {
+		err = perrors.NewPF("no certificates in response")
+	}
+
+	return
+}
+
+// KeyAuthorization returns the value proving control for a challenge token
+//   - token “.” base64url of SHA-256 JWK thumbprint of the account key
+func (c *ACMEClient) KeyAuthorization(token string) (keyAuthorization string) {
+	var thumbprint = sha256.Sum256([]byte(c.jwk()))
+	return token + "." + base64.RawURLEncoding.EncodeToString(thumbprint[:])
+}
+
+// authorize completes the authorization at authzURL
+//   - invoked while holding lock
+func (c *ACMEClient) authorize(ctx context.Context, authzURL string, responder *ACMEResponder) (err error) {
+	var body []byte
+	var header http.Header
+	if body, header, err = c.post(ctx, authzURL, nil); err != nil {
+		return
+	}
+	var authz acmeAuthorization
+	if err = json.Unmarshal(body, &authz); perrors.IsPF(&err, "authorization json.Unmarshal %w", err) {
+		return
+	} else if authz.Status == acmeStatusValid {
+		return // already authorized return
+	}
+
+	// select a challenge supported by responder
+	var challenge *acmeChallenge
+	for _, challengeType := range responder.ChallengeTypes() {
+		for i := range authz.Challenges {
+			if authz.Challenges[i].Type == challengeType {
+				challenge = &authz.Challenges[i]
+				break
+			}
+		}
+		if challenge != nil {
+			break
+		}
+	}
+	if challenge == nil {
+		err = perrors.ErrorfPF("no supported challenge for %q", authz.Identifier.Value)
+		return
+	}
+
+	// present the challenge response until validation completes
+	var domain = authz.Identifier.Value
+	if err = responder.present(challenge.Type, domain, challenge.Token, c.KeyAuthorization(challenge.Token)); err != nil {
+		return
+	}
+	defer responder.remove(challenge.Type, domain, challenge.Token)
+
+	// inform server that the challenge is ready
+	if _, _, err = c.post(ctx, challenge.URL, struct{}{}); err != nil {
+		return
+	}
+
+	// await validation
+	for {
+		switch authz.Status {
+		case acmeStatusValid:
+			return // authorized return
+		case acmeStatusPending, acmeStatusProcessing:
+		default:
+			err = perrors.ErrorfPF("authorization %q %s challenge %s: status %q",
+				domain, challenge.Type, challenge.URL, authz.Status)
+			return
+		}
+		if err = c.sleep(ctx, header); err != nil {
+			return
+		}
+		if body, header, err = c.post(ctx, authzURL, nil); err != nil {
+			return
+		} else if err = json.Unmarshal(body, &authz); perrors.IsPF(&err, "authorization json.Unmarshal %w", err) {
+			return
+		}
+	}
+}
+
+// getDirectory fetches the server’s endpoints
+//   - invoked while holding lock
+func (c *ACMEClient) getDirectory(ctx context.Context) (err error) {
+	if c.directory != nil {
+		return
+	}
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodGet, c.directoryURL, nil); perrors.IsPF(&err, "http.NewRequest %w", err) {
+		return
+	}
+	var body []byte
+	if body, _, err = c.do(req); err != nil {
+		return
+	}
+	var directory acmeDirectory
+	if err = json.Unmarshal(body, &directory); perrors.IsPF(&err, "directory json.Unmarshal %w", err) {
+		return
+	}
+	c.directory = &directory
+
+	return
+}
+
+// post sends a JWS-signed request
+//   - payload nil: POST-as-GET
+//   - a badNonce error is retried once
+//   - invoked while holding lock
+func (c *ACMEClient) post(ctx context.Context, url string, payload any) (body []byte, header http.Header, err error) {
+	var payloadJSON []byte
+	if payload != nil {
+		if payloadJSON, err = json.Marshal(payload); perrors.IsPF(&err, "json.Marshal %w", err) {
+			return
+		}
+	}
+	for isRetry := false; ; isRetry = true {
+		var jws []byte
+		if jws, err = c.sign(ctx, url, payloadJSON); err != nil {
+			return
+		}
+		var req *http.Request
+		if req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jws)); perrors.IsPF(&err, "http.NewRequest %w", err) {
+			return
+		}
+		req.Header.Set("Content-Type", acmeContentType)
+		req.Header.Set("Accept", acmePEMChainType+", application/json")
+		body, header, err = c.do(req)
+		if problem, ok := err.(*ACMEProblem); ok && problem.Type == acmeBadNonce && !isRetry {
+			continue
+		}
+		return
+	}
+}
+
+// do executes an http request, storing any nonce and converting problems to error
+func (c *ACMEClient) do(req *http.Request) (body []byte, header http.Header, err error) {
+	var resp *http.Response
+	if resp, err = c.httpClient.Do(req); perrors.IsPF(&err, "http %s %s %w", req.Method, req.URL, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	header = resp.Header
+	if nonce := header.Get(acmeNonceHeader); nonce != "" {
+		c.nonce = nonce
+	}
+	if body, err = io.ReadAll(io.LimitReader(resp.Body, acmeMaxResponse)); perrors.IsPF(&err, "read response %w", err) {
+		return
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var problem = ACMEProblem{HTTPStatus: resp.StatusCode}
+		if json.Unmarshal(body, &problem) != nil || problem.Type == "" {
+			problem.Type = resp.Status
+			problem.Detail = string(body)
+		}
+		err = &problem
+	}
+
+	return
+}
+
+// sign returns a flattened JWS for url and payload
+//   - payload nil: empty payload for POST-as-GET
+//   - invoked while holding lock
+func (c *ACMEClient) sign(ctx context.Context, url string, payload []byte) (jws []byte, err error) {
+
+	// obtain nonce
+	if c.nonce == "" {
+		var req *http.Request
+		if req, err = http.NewRequestWithContext(ctx, http.MethodHead, c.directory.NewNonce, nil); perrors.IsPF(&err, "http.NewRequest %w", err) {
+			return
+		} else if _, _, err = c.do(req); err != nil {
+			return
+		} else if c.nonce == "" {
+			err = perrors.NewPF("newNonce: no nonce")
+			return
+		}
+	}
+	var nonce = c.nonce
+	c.nonce = ""
+
+	// protected header identifies the key by jwk until the account exists
+	var keyField = `"kid":` + strconv.Quote(c.kid)
+	if c.kid == "" {
+		keyField = `"jwk":` + c.jwk()
+	}
+	var protected = `{"alg":"ES256",` + keyField + `,"nonce":` + strconv.Quote(nonce) + `,"url":` + strconv.Quote(url) + `}`
+	var protected64 = base64.RawURLEncoding.EncodeToString([]byte(protected))
+	var payload64 = base64.RawURLEncoding.EncodeToString(payload)
+
+	// ES256 signature is fixed-size r ‖ s
+	var digest = sha256.Sum256([]byte(protected64 + "." + payload64))
+	var r, s, e = ecdsa.Sign(rand.Reader, c.accountKey, digest[:])
+	if err = e; perrors.IsPF(&err, "ecdsa.Sign %w", err) {
+		return
+	}
+	var signature = make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	jws, err = json.Marshal(struct {
+		Protected string `json:"protected"`
+		Payload   string `json:"payload"`
+		Signature string `json:"signature"`
+	}{protected64, payload64, base64.RawURLEncoding.EncodeToString(signature)})
+	perrors.IsPF(&err, "json.Marshal %w", err)
+
+	return
+}
+
+// jwk returns the account public key as JSON Web Key
+//   - members in lexical order without whitespace, as required for thumbprint
+func (c *ACMEClient) jwk() (jwk string) {
+	var ecdhKey, err = c.accountKey.PublicKey.ECDH()
+	if err != nil {
+		panic(perrors.ErrorfPF("ECDH %w", err))
+	}
+	// uncompressed point: 0x04 ‖ x ‖ y
+	var point = ecdhKey.Bytes()
+	var size = (len(point) - 1) / 2
+	return `{"crv":"P-256","kty":"EC","x":"` + base64.RawURLEncoding.EncodeToString(point[1:1+size]) +
+		`","y":"` + base64.RawURLEncoding.EncodeToString(point[1+size:]) + `"}`
+}
+
+// sleep waits for Retry-After or poll interval
+func (c *ACMEClient) sleep(ctx context.Context, header http.Header) (err error) {
+	var d = acmePollInterval
+	if seconds, e := strconv.Atoi(strings.TrimSpace(header.Get(acmeRetryAfterHeader))); e == nil && seconds > 0 {
+		d = time.Duration(seconds) * time.Second
+	}
+	if err = parl.SleepCtx(ctx, d); err != nil {
+		err = perrors.ErrorfPF("acme canceled: %w", err)
+	}
+
+	return
+}
@@ -0,0 +1,384 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parlca
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// default time ahead of expiry when certificates are renewed
+	defaultRenewBefore = 30 * 24 * time.Hour
+	// retry interval after failed renewal or while using fallback certificate
+	acmeRetryInterval = time.Hour
+	// validity of fallback certificates signed by the self-signed certificate authority
+	fallbackValidity = 90 * 24 * time.Hour
+	// storage key of the ACME account key
+	acmeAccountKey = "acme-account.key"
+	// storage key suffixes of certificate and its private key
+	certificateSuffix = ".crt"
+	privateKeySuffix  = ".key"
+)
+
+// ACMEConfig configures [ACMEManager]
+type ACMEConfig struct {
+	// DirectoryURL is the ACME server, empty: [LetsEncryptURL]
+	DirectoryURL string
+	// Domains are the certificate’s DNS names, first is common name
+	Domains []string
+	// Contact is optional account contacts like “mailto:admin@example.com”
+	Contact []string
+	// Storage persists account key, certificate and private key, required
+	Storage CertStorage
+	// FallbackCA is optional self-signed certificate authority used when
+	// the ACME server cannot be reached and no valid certificate is stored
+	//	- see [NewSelfSigned]
+	FallbackCA parl.CertificateAuthority
+	// RenewBefore is how long ahead of expiry a certificate is renewed
+	//	- 0: 30 days
+	RenewBefore time.Duration
+	// ChallengeTypes are challenges in order of preference
+	//	- empty: tls-alpn-01 then http-01
+	ChallengeTypes []string
+	// HTTPClient is used for ACME requests, nil: [http.DefaultClient]
+	HTTPClient *http.Client
+}
+
+// ACMEManager provides publicly trusted certificates for a TLS server
+//   - certificates are obtained from an ACME server like Let’s Encrypt,
+//     persisted to storage and renewed ahead of expiry
+//   - when offline, a certificate signed by a self-signed certificate authority
+//     is used until the ACME server can be reached
+//   - thread-safe
+//
+// Usage:
+//
+//	var m, err = parlca.NewACMEManager(ctx, parlca.ACMEConfig{
+//	  Domains: []string{"example.com"},
+//	  Storage: parlca.NewDirStorage("/var/lib/myapp/certs"),
+//	})
+//	go m.RenewLoop(ctx, errorSink)
+//	go http.ListenAndServe(":80", m.HTTPHandler(nil))
+//	var listener, err = tls.Listen("tcp", ":443", m.TLSConfig())
+type ACMEManager struct {
+	domains     []string
+	storage     CertStorage
+	fallbackCA  parl.CertificateAuthority
+	renewBefore time.Duration
+	client      *ACMEClient
+	responder   *ACMEResponder
+	// certificate is the certificate in use, may be nil
+	certificate atomic.Pointer[managedCertificate]
+	// renewLock serializes renewals
+	renewLock sync.Mutex
+}
+
+// managedCertificate is a certificate in use
+type managedCertificate struct {
+	tlsCertificate *tls.Certificate
+	notAfter       time.Time
+	// isFallback indicates signed by the fallback certificate authority
+	isFallback bool
+}
+
+// NewACMEManager returns a manager of certificates for config.Domains
+//   - the account key is read from storage or created and stored
+//   - any stored certificate is loaded
+//   - certificates are obtained on first TLS handshake or by
+//     [ACMEManager.Renew] or [ACMEManager.RenewLoop]
+func NewACMEManager(ctx context.Context, config ACMEConfig) (manager *ACMEManager, err error) {
+	if len(config.Domains) == 0 {
+		err = perrors.NewPF("no domains")
+		return
+	} else if config.Storage == nil {
+		panic(parl.NilError("config.Storage"))
+	}
+	var m = ACMEManager{
+		domains:     config.Domains,
+		storage:     config.Storage,
+		fallbackCA:  config.FallbackCA,
+		renewBefore: config.RenewBefore,
+		responder:   NewACMEResponder(config.ChallengeTypes...),
+	}
+	if m.renewBefore <= 0 {
+		m.renewBefore = defaultRenewBefore
+	}
+	var directoryURL = config.DirectoryURL
+	if directoryURL == "" {
+		directoryURL = LetsEncryptURL
+	}
+
+	// account key
+	var accountKey *ecdsa.PrivateKey
+	if accountKey, err = m.accountKey(ctx); err != nil {
+		return
+	}
+	m.client = NewACMEClient(directoryURL, accountKey, config.Contact, config.HTTPClient)
+
+	// stored certificate
+	if err = m.load(ctx); err != nil {
+		return
+	}
+	manager = &m
+
+	return
+}
+
+// GetCertificate provides certificates for [tls.Config.GetCertificate]
+//   - tls-alpn-01 validation requests are served
+//   - if no certificate is available, one is obtained
+func (m *ACMEManager) GetCertificate(hello *tls.ClientHelloInfo) (certificate *tls.Certificate, err error) {
+	if certificate = m.responder.GetCertificate(hello); certificate != nil {
+		return // tls-alpn-01 validation return
+	}
+	if c := m.certificate.Load(); c != nil {
+		certificate = c.tlsCertificate
+		return // certificate in use return
+	}
+	err = m.Renew(hello.Context())
+	if c := m.certificate.Load(); c != nil {
+		certificate, err = c.tlsCertificate, nil
+	}
+
+	return
+}
+
+// TLSConfig returns a server configuration using the manager’s certificates
+//   - NextProtos includes [ACMETLSALPNProto] for tls-alpn-01 validation
+func (m *ACMEManager) TLSConfig() (tlsConfig *tls.Config) {
+	return &tls.Config{
+		GetCertificate: m.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1", ACMETLSALPNProto},
+	}
+}
+
+// HTTPHandler serves http-01 validation requests on port 80
+//   - fallback: handles other requests, nil: 404 Not Found
+func (m *ACMEManager) HTTPHandler(fallback http.Handler) (handler http.Handler) {
+	return m.responder.HTTPHandler(fallback)
+}
+
+// NeedsRenewal returns true if the certificate is missing, a fallback certificate or
+// within RenewBefore of expiry
+func (m *ACMEManager) NeedsRenewal() (needsRenewal bool) {
+	var c = m.certificate.Load()
+	return c == nil || c.isFallback || time.Until(c.notAfter) < m.renewBefore
+}
+
+// IsFallback returns true if the certificate in use is signed by the fallback
+// certificate authority
+func (m *ACMEManager) IsFallback() (isFallback bool) {
+	var c = m.certificate.Load()
+	return c != nil && c.isFallback
+}
+
+// Renew obtains a new certificate from the ACME server if renewal is due
+//   - on success, the certificate is stored and used
+//   - on failure, if no valid certificate is in use, a fallback certificate
+//     is issued by FallbackCA.
+//     err is the ACME failure
+func (m *ACMEManager) Renew(ctx context.Context) (err error) {
+	m.renewLock.Lock()
+	defer m.renewLock.Unlock()
+
+	if !m.NeedsRenewal() {
+		return // another thread renewed return
+	}
+
+	if err = m.obtain(ctx); err == nil {
+		return // renewed return
+	}
+
+	// use fallback if no valid certificate
+	if c := m.certificate.Load(); c != nil && time.Now().Before(c.notAfter) {
+		return // current certificate still valid return
+	} else if m.fallbackCA == nil {
+		return // no fallback return
+	}
+	if e := m.fallback(); e != nil {
+		err = perrors.AppendError(err, e)
+	}
+
+	return
+}
+
+// RenewLoop renews certificates ahead of expiry until ctx is canceled
+//   - errorSink: optional, receives renewal failures
+//   - failed renewals and fallback certificates are retried hourly
+//   - RenewLoop blocks, typically invoked in a goroutine
+func (m *ACMEManager) RenewLoop(ctx context.Context, errorSink parl.ErrorSink1) {
+	for {
+		var wait = acmeRetryInterval
+		if m.NeedsRenewal() {
+			if err := m.Renew(ctx); err != nil && errorSink != nil && ctx.Err() == nil {
+				errorSink.AddError(err)
+			}
+		}
+		if c := m.certificate.Load(); c != nil && !c.isFallback {
+			if untilRenew := time.Until(c.notAfter) - m.renewBefore; untilRenew > wait {
+				wait = untilRenew
+			}
+		}
+
+		if parl.SleepCtx(ctx, wait) != nil {
+			return // canceled return
+		}
+	}
+}
+
+// obtain gets a certificate from the ACME server
+func (m *ACMEManager) obtain(ctx context.Context) (err error) {
+	var privateKey parl.PrivateKey
+	if privateKey, err = NewEcdsa(); err != nil {
+		return
+	}
+	var chain [][]byte
+	if chain, err = m.client.Obtain(ctx, m.domains, privateKey, m.responder); err != nil {
+		return
+	}
+
+	// store
+	var chainPEM []byte
+	for _, der := range chain {
+		chainPEM = append(chainPEM, pem.EncodeToMemory(&pem.Block{Type: pemCertificateType, Bytes: der})...)
+	}
+	var keyPEM parl.PemBytes
+	if keyPEM, err = privateKey.PEM(); err != nil {
+		return
+	} else if err = m.storage.Put(ctx, m.domains[0]+privateKeySuffix, keyPEM); err != nil {
+		return
+	} else if err = m.storage.Put(ctx, m.domains[0]+certificateSuffix, chainPEM); err != nil {
+		return
+	}
+
+	var c *managedCertificate
+	if c, err = newManagedCertificate(chainPEM, keyPEM); err != nil {
+		return
+	}
+	m.certificate.Store(c)
+
+	return
+}
+
+// fallback issues a certificate signed by the fallback certificate authority
+//   - the certificate is not stored
+func (m *ACMEManager) fallback() (err error) {
+	var privateKey parl.PrivateKey
+	if privateKey, err = NewEcdsa(); err != nil {
+		return
+	}
+	var now = time.Now()
+	var template = x509.Certificate{
+		Subject:   pkix.Name{CommonName: m.domains[0]},
+		DNSNames:  m.domains,
+		NotBefore: now.Add(-time.Minute),
+		NotAfter:  now.Add(fallbackValidity),
+	}
+	EnsureServer(&template)
+	var der parl.CertificateDer
+	if der, err = m.fallbackCA.Sign(&template, privateKey.Public()); err != nil {
+		return
+	}
+	var leaf *x509.Certificate
+	if leaf, err = x509.ParseCertificate(der); perrors.IsPF(&err, "x509.ParseCertificate %w", err) {
+		return
+	}
+	m.certificate.Store(&managedCertificate{
+		tlsCertificate: &tls.Certificate{
+			Certificate: [][]byte{der, m.fallbackCA.DER()},
+			PrivateKey:  privateKey,
+			Leaf:        leaf,
+		},
+		notAfter:   leaf.NotAfter,
+		isFallback: true,
+	})
+
+	return
+}
+
+// accountKey reads or creates the ACME account key
+func (m *ACMEManager) accountKey(ctx context.Context) (accountKey *ecdsa.PrivateKey, err error) {
+	var data []byte
+	if data, err = m.storage.Get(ctx, acmeAccountKey); err == nil {
+		var block, _ = pem.Decode(data)
+		if block == nil {
+			err = perrors.ErrorfPF("no PEM block in %q", acmeAccountKey)
+			return
+		}
+		var key any
+		if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); perrors.IsPF(&err, "x509.ParsePKCS8PrivateKey %w", err) {
+			return
+		}
+		var ok bool
+		if accountKey, ok = key.(*ecdsa.PrivateKey); !ok {
+			err = perrors.ErrorfPF("account key not ECDSA: %T", key)
+		}
+		return // read account key return
+	} else if !errors.Is(err, ErrStorageMiss) {
+		return // storage failure return
+	}
+
+	// create account key
+	if accountKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); perrors.IsPF(&err, "ecdsa.GenerateKey %w", err) {
+		return
+	}
+	var der []byte
+	if der, err = x509.MarshalPKCS8PrivateKey(accountKey); perrors.IsPF(&err, "x509.MarshalPKCS8PrivateKey %w", err) {
+		return
+	}
+	err = m.storage.Put(ctx, acmeAccountKey, pem.EncodeToMemory(&pem.Block{Type: pemPrivateKeyType, Bytes: der}))
+
+	return
+}
+
+// load reads any stored certificate
+func (m *ACMEManager) load(ctx context.Context) (err error) {
+	var chainPEM, keyPEM []byte
+	if chainPEM, err = m.storage.Get(ctx, m.domains[0]+certificateSuffix); err == nil {
+		keyPEM, err = m.storage.Get(ctx, m.domains[0]+privateKeySuffix)
+	}
+	if errors.Is(err, ErrStorageMiss) {
+		err = nil
+		return // no stored certificate return
+	} else if err != nil {
+		return // storage failure return
+	}
+	var c *managedCertificate
+	if c, err = newManagedCertificate(chainPEM, keyPEM); err != nil {
+		return
+	}
+	m.certificate.Store(c)
+
+	return
+}
+
+// newManagedCertificate parses a PEM certificate chain and private key
+func newManagedCertificate(chainPEM, keyPEM []byte) (c *managedCertificate, err error) {
+	var tlsCertificate tls.Certificate
+	if tlsCertificate, err = tls.X509KeyPair(chainPEM, keyPEM); perrors.IsPF(&err, "tls.X509KeyPair %w", err) {
+		return
+	} else if tlsCertificate.Leaf, err = x509.ParseCertificate(tlsCertificate.Certificate[0]); perrors.IsPF(&err, "x509.ParseCertificate %w", err) {
+		return
+	}
+	c = &managedCertificate{tlsCertificate: &tlsCertificate, notAfter: tlsCertificate.Leaf.NotAfter}
+
+	return
+}
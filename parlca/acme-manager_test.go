@@ -0,0 +1,137 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parlca
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestACMEManagerFallback(t *testing.T) {
+	//t.Error("Logging on")
+	const domain = "example.com"
+	var (
+		ctx     = context.Background()
+		storage = NewDirStorage(t.TempDir())
+		// ACME server that is offline
+		offline = httptest.NewServer(http.NotFoundHandler())
+	)
+	offline.Close()
+
+	var ca, err = NewSelfSigned("", x509.ECDSA)
+	if err != nil {
+		t.Fatalf("NewSelfSigned err: %s", perrors.Short(err))
+	}
+	var m *ACMEManager
+	if m, err = NewACMEManager(ctx, ACMEConfig{
+		DirectoryURL: offline.URL,
+		Domains:      []string{domain},
+		Storage:      storage,
+		FallbackCA:   ca,
+	}); err != nil {
+		t.Fatalf("NewACMEManager err: %s", perrors.Short(err))
+	}
+
+	// account key was stored
+	if _, err = storage.Get(ctx, acmeAccountKey); err != nil {
+		t.Errorf("account key Get err: %s", perrors.Short(err))
+	}
+	// missing key is storage miss
+	if _, err = storage.Get(ctx, "missing"); !errors.Is(err, ErrStorageMiss) {
+		t.Errorf("Get missing err %v exp %v", err, ErrStorageMiss)
+	}
+
+	// offline: GetCertificate returns fallback certificate
+	var certificate *tls.Certificate
+	if certificate, err = m.GetCertificate(&tls.ClientHelloInfo{ServerName: domain}); err != nil {
+		t.Fatalf("GetCertificate err: %s", perrors.Short(err))
+	}
+	if !m.IsFallback() {
+		t.Error("IsFallback false")
+	}
+	if !m.NeedsRenewal() {
+		t.Error("NeedsRenewal false")
+	}
+
+	// fallback certificate verifies against the certificate authority
+	var caCert *x509.Certificate
+	if caCert, err = ca.Check(); err != nil {
+		t.Fatalf("Check err: %s", perrors.Short(err))
+	}
+	var roots = x509.NewCertPool()
+	roots.AddCert(caCert)
+	if _, err = certificate.Leaf.Verify(x509.VerifyOptions{DNSName: domain, Roots: roots}); err != nil {
+		t.Errorf("Verify err: %s", err)
+	}
+}
+
+func TestACMEResponder(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		token            = "token1"
+		keyAuthorization = "token1.thumbprint"
+		domain           = "example.com"
+	)
+	var responder = NewACMEResponder()
+
+	// http-01
+	if err := responder.present(ChallengeHTTP01, domain, token, keyAuthorization); err != nil {
+		t.Fatalf("present err: %s", perrors.Short(err))
+	}
+	var recorder = httptest.NewRecorder()
+	responder.HTTPHandler(nil).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, acmeHTTPChallengePath+token, nil))
+	if s := recorder.Body.String(); s != keyAuthorization {
+		t.Errorf("http-01 %q exp %q", s, keyAuthorization)
+	}
+
+	// tls-alpn-01
+	if err := responder.present(ChallengeTLSALPN01, domain, token, keyAuthorization); err != nil {
+		t.Fatalf("present err: %s", perrors.Short(err))
+	}
+	var hello = tls.ClientHelloInfo{ServerName: domain, SupportedProtos: []string{ACMETLSALPNProto}}
+	if responder.GetCertificate(&hello) == nil {
+		t.Error("tls-alpn-01 certificate nil")
+	}
+	responder.remove(ChallengeTLSALPN01, domain, token)
+	if responder.GetCertificate(&hello) != nil {
+		t.Error("tls-alpn-01 certificate not removed")
+	}
+}
+
+func TestACMEClientKeyAuthorization(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		token = "token1"
+		// base64url of 32-byte SHA-256 without padding
+		expThumbprintLength = 43
+	)
+	var accountKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey err: %s", err)
+	}
+	var client = NewACMEClient(LetsEncryptStagingURL, accountKey, nil, nil)
+
+	var keyAuthorization = client.KeyAuthorization(token)
+	var thumbprint, hasToken = strings.CutPrefix(keyAuthorization, token+".")
+	if !hasToken || len(thumbprint) != expThumbprintLength {
+		t.Errorf("KeyAuthorization %q", keyAuthorization)
+	}
+	// deterministic for the same account key
+	if k := client.KeyAuthorization(token); k != keyAuthorization {
+		t.Errorf("KeyAuthorization %q exp %q", k, keyAuthorization)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parlca
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// ACMETLSALPNProto is the ALPN protocol of tls-alpn-01 validation
+	//	- must be present in [tls.Config.NextProtos] for [ChallengeTLSALPN01]
+	ACMETLSALPNProto = "acme-tls/1"
+	// url path prefix of http-01 challenge
+	acmeHTTPChallengePath = "/.well-known/acme-challenge/"
+	// validity of tls-alpn-01 certificates
+	acmeALPNValidity = 24 * time.Hour
+)
+
+// oidACMEIdentifier is the id-pe-acmeIdentifier certificate extension
+//   - RFC 8737
+var oidACMEIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// ACMEResponder serves ACME challenge responses during validation
+//   - [ChallengeHTTP01]: [ACMEResponder.HTTPHandler] must serve port 80
+//   - [ChallengeTLSALPN01]: [ACMEResponder.GetCertificate] must be used by
+//     the TLS listener on port 443 with [ACMETLSALPNProto] in NextProtos
+//   - thread-safe
+type ACMEResponder struct {
+	challengeTypes []string
+	// lock makes maps thread-safe
+	lock sync.Mutex
+	// http01 is key authorization by token
+	http01 map[string]string
+	// alpn01 is validation certificate by domain
+	alpn01 map[string]*tls.Certificate
+}
+
+// NewACMEResponder returns a responder for challengeTypes in order of preference
+//   - challengeTypes: [ChallengeHTTP01] [ChallengeTLSALPN01]
+//   - no challengeTypes: both, tls-alpn-01 first
+func NewACMEResponder(challengeTypes ...string) (responder *ACMEResponder) {
+	if len(challengeTypes) == 0 {
+		challengeTypes = []string{ChallengeTLSALPN01, ChallengeHTTP01}
+	}
+	return &ACMEResponder{
+		challengeTypes: slices.Clone(challengeTypes),
+		http01:         make(map[string]string),
+		alpn01:         make(map[string]*tls.Certificate),
+	}
+}
+
+// ChallengeTypes returns the challenge types in order of preference
+func (r *ACMEResponder) ChallengeTypes() (challengeTypes []string) {
+	return slices.Clone(r.challengeTypes)
+}
+
+// HTTPHandler serves http-01 challenge responses
+//   - fallback: handles other requests, nil: 404 Not Found
+func (r *ACMEResponder) HTTPHandler(fallback http.Handler) (handler http.Handler) {
+	if fallback == nil {
+		fallback = http.NotFoundHandler()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, acmeHTTPChallengePath) {
+			fallback.ServeHTTP(w, req)
+			return
+		}
+		r.lock.Lock()
+		var keyAuthorization, ok = r.http01[strings.TrimPrefix(req.URL.Path, acmeHTTPChallengePath)]
+		r.lock.Unlock()
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte(keyAuthorization))
+	})
+}
+
+// GetCertificate returns a tls-alpn-01 validation certificate
+//   - certificate nil: hello is not a validation request
+func (r *ACMEResponder) GetCertificate(hello *tls.ClientHelloInfo) (certificate *tls.Certificate) {
+	if !slices.Contains(hello.SupportedProtos, ACMETLSALPNProto) {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.alpn01[hello.ServerName]
+}
+
+// present makes a challenge response available
+func (r *ACMEResponder) present(challengeType, domain, token, keyAuthorization string) (err error) {
+	var certificate *tls.Certificate
+	switch challengeType {
+	case ChallengeHTTP01:
+	case ChallengeTLSALPN01:
+		if certificate, err = alpnCertificate(domain, keyAuthorization); err != nil {
+			return
+		}
+	default:
+		err = perrors.ErrorfPF("unsupported challenge type: %q", challengeType)
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if certificate != nil {
+		r.alpn01[domain] = certificate
+	} else {
+		r.http01[token] = keyAuthorization
+	}
+
+	return
+}
+
+// remove ends a challenge response
+func (r *ACMEResponder) remove(challengeType, domain, token string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if challengeType == ChallengeTLSALPN01 {
+		delete(r.alpn01, domain)
+	} else {
+		delete(r.http01, token)
+	}
+}
+
+// alpnCertificate returns a self-signed certificate for tls-alpn-01
+//   - critical acmeIdentifier extension is SHA-256 of key authorization
+func alpnCertificate(domain, keyAuthorization string) (certificate *tls.Certificate, err error) {
+	var privateKey, e = NewEcdsa()
+	if err = e; err != nil {
+		return
+	}
+	var digest = sha256.Sum256([]byte(keyAuthorization))
+	var extensionValue []byte
+	if extensionValue, err = asn1.Marshal(digest[:]); perrors.IsPF(&err, "asn1.Marshal %w", err) {
+		return
+	}
+	var now = time.Now()
+	var template = x509.Certificate{
+		SerialNumber: uuidSerialNumber(),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(acmeALPNValidity),
+		ExtraExtensions: []pkix.Extension{{
+			Id:       oidACMEIdentifier,
+			Critical: true,
+			Value:    extensionValue,
+		}},
+	}
+	var der []byte
+	if der, err = x509.CreateCertificate(rand.Reader, &template, &template, privateKey.Public(), privateKey); perrors.IsPF(&err, "x509.CreateCertificate %w", err) {
+		return
+	}
+	certificate = &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: privateKey}
+
+	return
+}
@@ -0,0 +1,90 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parlca
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// permissions for stored keys and certificates
+	storageFilePerm = 0o600
+	// permissions for a created storage directory
+	storageDirPerm = 0o700
+)
+
+// ErrStorageMiss is returned by [CertStorage.Get] for a key not present
+//   - test using errors.Is
+var ErrStorageMiss = errors.New("storage miss")
+
+// CertStorage persists ACME account keys, private keys and certificates
+//   - keys are file-name-like strings: “acme-account.key” “example.com.crt”
+//   - values are PEM-encoded
+//   - [NewDirStorage] provides a directory-based implementation
+//   - implementation must be thread-safe
+type CertStorage interface {
+	// Get returns the data stored for key
+	//	- err: [ErrStorageMiss] if key is not present
+	Get(ctx context.Context, key string) (data []byte, err error)
+	// Put stores data for key, replacing any existing data
+	Put(ctx context.Context, key string, data []byte) (err error)
+}
+
+// DirStorage is a [CertStorage] storing each key as a file in a directory
+type DirStorage struct {
+	dir string
+}
+
+// DirStorage is a certificate storage
+var _ CertStorage = &DirStorage{}
+
+// NewDirStorage returns storage for keys and certificates in directory dir
+//   - dir is created with permissions 0700 on first Put
+//   - files are written with permissions 0600
+func NewDirStorage(dir string) (storage *DirStorage) { return &DirStorage{dir: dir} }
+
+// Get returns the content of the file key
+func (s *DirStorage) Get(ctx context.Context, key string) (data []byte, err error) {
+	if data, err = os.ReadFile(s.filename(key)); err == nil {
+		return // good return
+	} else if errors.Is(err, fs.ErrNotExist) {
+		err = perrors.ErrorfPF("%w: %q: %w", ErrStorageMiss, key, err)
+		return // miss return
+	}
+	err = perrors.ErrorfPF("os.ReadFile %w", err)
+
+	return
+}
+
+// Put writes data to the file key
+//   - written to a temporary file that is then renamed
+func (s *DirStorage) Put(ctx context.Context, key string, data []byte) (err error) {
+	if err = os.MkdirAll(s.dir, storageDirPerm); perrors.IsPF(&err, "os.MkdirAll %w", err) {
+		return
+	}
+	var filename = s.filename(key)
+	var tempName = filename + ".tmp"
+	if err = os.WriteFile(tempName, data, storageFilePerm); perrors.IsPF(&err, "os.WriteFile %w", err) {
+		return
+	}
+	if err = os.Rename(tempName, filename); perrors.IsPF(&err, "os.Rename %w", err) {
+		return
+	}
+
+	return
+}
+
+// filename returns the path for key
+//   - key is reduced to its base name
+func (s *DirStorage) filename(key string) (filename string) {
+	return filepath.Join(s.dir, filepath.Base(key))
+}
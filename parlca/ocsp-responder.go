@@ -0,0 +1,349 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parlca
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// OCSPRequestContentType is the content type of POST OCSP requests
+	OCSPRequestContentType = "application/ocsp-request"
+	// OCSPResponseContentType is the content type of OCSP responses
+	OCSPResponseContentType = "application/ocsp-response"
+	// default validity of OCSP responses
+	defaultOCSPValidity = time.Hour
+	// max size of an OCSP request
+	maxOCSPRequest = 64 << 10
+)
+
+const (
+	// OCSP response status successful
+	ocspSuccessful asn1.Enumerated = 0
+	// OCSP response status malformedRequest
+	ocspMalformed asn1.Enumerated = 1
+	// OCSP response status internalError
+	ocspInternalError asn1.Enumerated = 2
+)
+
+var (
+	// id-pkix-ocsp-basic RFC 6960
+	oidOCSPBasic = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	// id-pkix-ocsp-nonce RFC 6960
+	oidOCSPNonce = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
+	// ocspHashes are hash algorithms of OCSP certificate identifiers
+	ocspHashes = []struct {
+		oid  asn1.ObjectIdentifier
+		hash crypto.Hash
+	}{
+		{asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}, crypto.SHA1},
+		{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}, crypto.SHA256},
+		{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}, crypto.SHA384},
+		{asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}, crypto.SHA512},
+	}
+	// ocspSignatures are OCSP response signature algorithms by
+	// certificate authority signature algorithm
+	ocspSignatures = map[x509.SignatureAlgorithm]struct {
+		algorithm pkix.AlgorithmIdentifier
+		hash      crypto.Hash
+	}{
+		x509.SHA256WithRSA: {pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11},
+			Parameters: asn1.NullRawValue,
+		}, crypto.SHA256},
+		x509.ECDSAWithSHA256: {pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}}, crypto.SHA256},
+		x509.ECDSAWithSHA384: {pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}}, crypto.SHA384},
+		x509.PureEd25519:     {pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 101, 112}}, 0},
+	}
+)
+
+// OCSPResponder is a minimal OCSP responder for certificates of
+// a certificate authority backed by its [RevocationList]
+//   - RFC 6960 requests by POST or GET with basic responses
+//     signed by the certificate authority
+//   - status is revoked for revoked serial numbers, good for other serial numbers
+//     and unknown for other issuers
+//   - a nonce of the request is included in the response
+//   - [OCSPResponder.ServeHTTP] implements [http.Handler]
+//   - thread-safe
+//
+// Usage:
+//
+//	var list = parlca.NewRevocationList(ca)
+//	http.Handle("/ocsp/", parlca.NewOCSPResponder(list))
+//	…
+//	list.Revoke(serialNumber, parlca.RevokeKeyCompromise)
+type OCSPResponder struct {
+	list *RevocationList
+	// validity is time from thisUpdate to nextUpdate of responses
+	validity time.Duration
+}
+
+// ocspRequest is OCSPRequest, optionalSignature is ignored
+type ocspRequest struct {
+	TBSRequest ocspTBSRequest
+}
+
+// ocspTBSRequest is TBSRequest
+type ocspTBSRequest struct {
+	Version       int           `asn1:"explicit,tag:0,default:0,optional"`
+	RequestorName asn1.RawValue `asn1:"explicit,tag:1,optional"`
+	RequestList   []ocspSingleRequest
+	Extensions    []pkix.Extension `asn1:"explicit,tag:2,optional"`
+}
+
+// ocspSingleRequest is Request
+type ocspSingleRequest struct {
+	CertID     ocspCertID
+	Extensions []pkix.Extension `asn1:"explicit,tag:0,optional"`
+}
+
+// ocspCertID is CertID
+type ocspCertID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+// ocspResponse is OCSPResponse
+type ocspResponse struct {
+	Status        asn1.Enumerated
+	ResponseBytes ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+// ocspResponseBytes is ResponseBytes
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+// ocspBasicResponse is BasicOCSPResponse
+type ocspBasicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+}
+
+// ocspResponseData is ResponseData
+type ocspResponseData struct {
+	Version     int `asn1:"explicit,tag:0,default:0,optional"`
+	ResponderID asn1.RawValue
+	ProducedAt  time.Time `asn1:"generalized"`
+	Responses   []ocspSingleResponse
+	Extensions  []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+// ocspSingleResponse is SingleResponse
+//   - exactly one of Good Revoked Unknown
+type ocspSingleResponse struct {
+	CertID     ocspCertID
+	Good       asn1.Flag       `asn1:"tag:0,optional"`
+	Revoked    ocspRevokedInfo `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag       `asn1:"tag:2,optional"`
+	ThisUpdate time.Time       `asn1:"generalized"`
+	NextUpdate time.Time       `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+// ocspRevokedInfo is RevokedInfo
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// NewOCSPResponder returns an OCSP responder for the certificate authority of list
+//   - validity: time until clients should request status again, default 1 h
+func NewOCSPResponder(list *RevocationList, validity ...time.Duration) (responder *OCSPResponder) {
+	if list == nil {
+		panic(parl.NilError("list"))
+	}
+	var v = defaultOCSPValidity
+	if len(validity) > 0 && validity[0] > 0 {
+		v = validity[0]
+	}
+	return &OCSPResponder{list: list, validity: v}
+}
+
+// ServeHTTP answers an OCSP request
+//   - POST: request is body
+//   - GET: request is base64 encoded final path segment
+func (o *OCSPResponder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var requestDER []byte
+	var err error
+	switch req.Method {
+	case http.MethodPost:
+		requestDER, err = io.ReadAll(io.LimitReader(req.Body, maxOCSPRequest))
+	case http.MethodGet:
+		var segment string
+		if segment, err = url.PathUnescape(path.Base(req.URL.EscapedPath())); err == nil {
+			requestDER, err = base64.StdEncoding.DecodeString(segment)
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", OCSPResponseContentType)
+	w.Write(o.Respond(requestDER))
+}
+
+// Respond returns the der-format OCSP response to a der-format OCSP request
+//   - malformed requests and internal errors are OCSP responses
+//     without response bytes
+func (o *OCSPResponder) Respond(requestDER []byte) (responseDER []byte) {
+	var request ocspRequest
+	if rest, err := asn1.Unmarshal(requestDER, &request); err != nil || len(rest) > 0 ||
+		len(request.TBSRequest.RequestList) == 0 {
+		return ocspStatus(ocspMalformed)
+	}
+	var basicDER, err = o.basicResponse(&request.TBSRequest)
+	if err != nil {
+		return ocspStatus(ocspInternalError)
+	}
+	if responseDER, err = asn1.Marshal(ocspResponse{
+		Status: ocspSuccessful,
+		ResponseBytes: ocspResponseBytes{
+			ResponseType: oidOCSPBasic,
+			Response:     basicDER,
+		},
+	}); err != nil {
+		return ocspStatus(ocspInternalError)
+	}
+
+	return
+}
+
+// basicResponse returns a signed BasicOCSPResponse for request
+func (o *OCSPResponder) basicResponse(request *ocspTBSRequest) (basicDER []byte, err error) {
+	var caCert *x509.Certificate
+	if caCert, err = o.list.ca.Check(); err != nil {
+		return
+	}
+	var signature, ok = ocspSignatures[caCert.SignatureAlgorithm]
+	if !ok {
+		err = perrors.ErrorfPF("unsupported signature algorithm: %s", caCert.SignatureAlgorithm)
+		return
+	}
+	var issuerKey []byte
+	if issuerKey, err = subjectPublicKey(caCert); err != nil {
+		return
+	}
+
+	// certificate status
+	var now = time.Now().UTC().Truncate(time.Second)
+	var data = ocspResponseData{
+		ResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: caCert.RawSubject},
+		ProducedAt:  now,
+		Responses:   make([]ocspSingleResponse, len(request.RequestList)),
+	}
+	for i, single := range request.RequestList {
+		var response = &data.Responses[i]
+		response.CertID = single.CertID
+		response.ThisUpdate = now
+		response.NextUpdate = now.Add(o.validity)
+		if !isIssuer(&single.CertID, caCert.RawSubject, issuerKey) {
+			response.Unknown = true
+		} else if entry, isRevoked := o.list.IsRevoked(single.CertID.SerialNumber); isRevoked {
+			response.Revoked = ocspRevokedInfo{
+				RevocationTime: entry.RevocationTime,
+				Reason:         asn1.Enumerated(entry.ReasonCode),
+			}
+		} else {
+			response.Good = true
+		}
+	}
+	for _, extension := range request.Extensions {
+		if extension.Id.Equal(oidOCSPNonce) {
+			data.Extensions = append(data.Extensions, pkix.Extension{Id: oidOCSPNonce, Value: extension.Value})
+		}
+	}
+
+	// sign
+	var dataDER []byte
+	if dataDER, err = asn1.Marshal(data); perrors.IsPF(&err, "asn1.Marshal: %w", err) {
+		return
+	}
+	var signed = dataDER
+	if signature.hash != 0 {
+		var hash = signature.hash.New()
+		hash.Write(dataDER)
+		signed = hash.Sum(nil)
+	}
+	var signatureBytes []byte
+	if signatureBytes, err = o.list.ca.Private().Sign(rand.Reader, signed, signature.hash); perrors.IsPF(&err, "Sign: %w", err) {
+		return
+	}
+	if basicDER, err = asn1.Marshal(ocspBasicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: dataDER},
+		SignatureAlgorithm: signature.algorithm,
+		Signature:          asn1.BitString{Bytes: signatureBytes, BitLength: 8 * len(signatureBytes)},
+	}); perrors.IsPF(&err, "asn1.Marshal: %w", err) {
+		return
+	}
+
+	return
+}
+
+// isIssuer returns true if certID identifies the issuer
+// with subject and public key issuerKey
+func isIssuer(certID *ocspCertID, subject, issuerKey []byte) (isIssuer bool) {
+	for _, h := range ocspHashes {
+		if !certID.HashAlgorithm.Algorithm.Equal(h.oid) {
+			continue
+		}
+		var hash = h.hash.New()
+		hash.Write(subject)
+		if !bytes.Equal(hash.Sum(nil), certID.IssuerNameHash) {
+			return
+		}
+		hash.Reset()
+		hash.Write(issuerKey)
+		return bytes.Equal(hash.Sum(nil), certID.IssuerKeyHash)
+	}
+	return // unsupported hash algorithm return
+}
+
+// subjectPublicKey returns the subjectPublicKey bits of cert
+func subjectPublicKey(cert *x509.Certificate) (publicKey []byte, err error) {
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err = asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &publicKeyInfo); perrors.IsPF(&err, "asn1.Unmarshal: %w", err) {
+		return
+	}
+	publicKey = publicKeyInfo.PublicKey.RightAlign()
+
+	return
+}
+
+// ocspStatus returns an OCSP response without response bytes
+func ocspStatus(status asn1.Enumerated) (responseDER []byte) {
+	responseDER, _ = asn1.Marshal(ocspResponse{Status: status})
+	return
+}
@@ -4,6 +4,11 @@ ISC License
 */
 
 // Package parlca provides a self-signed certificate authority
+//   - [ACMEManager] obtains and renews publicly trusted certificates from
+//     ACME servers like Let’s Encrypt, falling back to the self-signed
+//     certificate authority when offline
+//   - [RevocationList] revokes issued certificates and produces signed CRLs,
+//     [OCSPResponder] is an OCSP responder [http.Handler] backed by the revocation list
 package parlca
 
 const (
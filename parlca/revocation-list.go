@@ -0,0 +1,207 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parlca
+
+import (
+	"cmp"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// RevokeUnspecified is CRL reason code unspecified
+	RevokeUnspecified RevocationReason = 0
+	// RevokeKeyCompromise: the private key of the certificate was compromised
+	RevokeKeyCompromise RevocationReason = 1
+	// RevokeCACompromise: the private key of the certificate authority was compromised
+	RevokeCACompromise RevocationReason = 2
+	// RevokeAffiliationChanged: the subject’s name or other information changed
+	RevokeAffiliationChanged RevocationReason = 3
+	// RevokeSuperseded: the certificate was replaced
+	RevokeSuperseded RevocationReason = 4
+	// RevokeCessationOfOperation: the certificate is no longer needed
+	RevokeCessationOfOperation RevocationReason = 5
+)
+
+const (
+	// pem block type of certificate revocation list
+	pemCRLType = "X509 CRL"
+	// file mode of CRL files: public information
+	crlFileMode = 0644
+)
+
+// RevocationReason is CRL reason code of a revoked certificate
+//   - RFC 5280 5.3.1
+//   - [RevokeUnspecified] [RevokeKeyCompromise] [RevokeCACompromise]
+//     [RevokeAffiliationChanged] [RevokeSuperseded] [RevokeCessationOfOperation]
+type RevocationReason int
+
+// RevocationList holds certificates revoked by a certificate authority
+//   - [RevocationList.Revoke] revokes by serial number
+//   - [RevocationList.CRL] produces a certificate revocation list signed by
+//     the certificate authority, [RevocationList.WriteCRL] writes it to a file
+//   - [NewOCSPResponder] answers OCSP requests from the revocation list
+//   - for test and internal PKI exercising revocation paths
+//   - thread-safe
+type RevocationList struct {
+	// ca signs certificate revocation lists and OCSP responses
+	ca parl.CertificateAuthority
+	// lock makes m and number thread-safe
+	lock sync.RWMutex
+	// m is revoked certificates by decimal serial number, behind lock
+	m map[string]x509.RevocationListEntry
+	// number is CRL number of the most recent CRL, behind lock
+	number int64
+}
+
+// NewRevocationList returns an empty revocation list for ca
+//   - ca must have key usage [x509.KeyUsageCRLSign],
+//     ensured by [NewSelfSigned]
+func NewRevocationList(ca parl.CertificateAuthority) (list *RevocationList) {
+	if ca == nil {
+		panic(parl.NilError("ca"))
+	}
+	return &RevocationList{
+		ca: ca,
+		m:  make(map[string]x509.RevocationListEntry),
+	}
+}
+
+// Revoke revokes the certificate with serialNumber
+//   - didRevoke false: serialNumber was already revoked
+//   - the revocation time is now
+func (r *RevocationList) Revoke(serialNumber *big.Int, reason RevocationReason) (didRevoke bool) {
+	if serialNumber == nil {
+		panic(parl.NilError("serialNumber"))
+	}
+	var key = serialNumber.String()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, isRevoked := r.m[key]; isRevoked {
+		return // already revoked return
+	}
+	r.m[key] = x509.RevocationListEntry{
+		SerialNumber:   new(big.Int).Set(serialNumber),
+		RevocationTime: time.Now().UTC().Truncate(time.Second),
+		ReasonCode:     int(reason),
+	}
+
+	return true
+}
+
+// RevokeCertificate revokes certificate
+//   - err: certificate is not issued by the certificate authority
+func (r *RevocationList) RevokeCertificate(certificate parl.Certificate, reason RevocationReason) (didRevoke bool, err error) {
+	var cert, caCert *x509.Certificate
+	if cert, err = certificate.ParseCertificate(); err != nil {
+		return
+	} else if caCert, err = r.ca.Check(); err != nil {
+		return
+	} else if err = cert.CheckSignatureFrom(caCert); perrors.IsPF(&err, "certificate not issued by ca: %w", err) {
+		return
+	}
+	didRevoke = r.Revoke(cert.SerialNumber, reason)
+
+	return
+}
+
+// IsRevoked returns the revocation of serialNumber
+//   - isRevoked false: serialNumber is not revoked
+func (r *RevocationList) IsRevoked(serialNumber *big.Int) (entry x509.RevocationListEntry, isRevoked bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	entry, isRevoked = r.m[serialNumber.String()]
+	return
+}
+
+// Entries returns revoked certificates in order of revocation
+func (r *RevocationList) Entries() (entries []x509.RevocationListEntry) {
+	r.lock.RLock()
+	entries = make([]x509.RevocationListEntry, 0, len(r.m))
+	for _, entry := range r.m {
+		entries = append(entries, entry)
+	}
+	r.lock.RUnlock()
+
+	slices.SortFunc(entries, func(a, b x509.RevocationListEntry) (result int) {
+		if result = a.RevocationTime.Compare(b.RevocationTime); result != 0 {
+			return
+		}
+		return cmp.Compare(a.SerialNumber.String(), b.SerialNumber.String())
+	})
+
+	return
+}
+
+// CRL returns a certificate revocation list signed by the certificate authority
+//   - validity: time until the next CRL is to be issued
+//   - crlDER: binary der asn.1 format
+//   - each CRL has a CRL number greater than the previous
+func (r *RevocationList) CRL(validity time.Duration) (crlDER []byte, err error) {
+	var caCert *x509.Certificate
+	if caCert, err = r.ca.Check(); err != nil {
+		return
+	}
+	var entries = r.Entries()
+	r.lock.Lock()
+	r.number++
+	var number = r.number
+	r.lock.Unlock()
+
+	var now = time.Now().UTC().Truncate(time.Second)
+	var template = x509.RevocationList{
+		RevokedCertificateEntries: entries,
+		Number:                    big.NewInt(number),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(validity),
+	}
+	if crlDER, err = x509.CreateRevocationList(rand.Reader, &template, caCert, r.ca.Private()); err != nil {
+		err = perrors.ErrorfPF("x509.CreateRevocationList: %w", err)
+	}
+
+	return
+}
+
+// CRLPEM returns a certificate revocation list in pem format
+//   - “-----BEGIN X509 CRL-----”
+func (r *RevocationList) CRLPEM(validity time.Duration) (pemBytes parl.PemBytes, err error) {
+	var crlDER []byte
+	if crlDER, err = r.CRL(validity); err != nil {
+		return
+	}
+	pemBytes = append(
+		[]byte(PemText(crlDER)),
+		pem.EncodeToMemory(&pem.Block{Type: pemCRLType, Bytes: crlDER})...,
+	)
+
+	return
+}
+
+// WriteCRL writes a certificate revocation list in der format to filename
+//   - an existing file is replaced
+//   - openssl crl -inform der -in filename -noout -text
+func (r *RevocationList) WriteCRL(filename string, validity time.Duration) (err error) {
+	var crlDER []byte
+	if crlDER, err = r.CRL(validity); err != nil {
+		return
+	}
+	if err = os.WriteFile(filename, crlDER, crlFileMode); err != nil {
+		err = perrors.ErrorfPF("os.WriteFile: %w", err)
+	}
+
+	return
+}
@@ -0,0 +1,149 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parlca
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+func TestRevocationList(t *testing.T) {
+	//t.Error("Logging on")
+	for _, algo := range []x509.PublicKeyAlgorithm{x509.Ed25519, x509.RSA, x509.ECDSA} {
+		var ca, caCert, certificate = revocationTestCA(t, algo)
+		var list = NewRevocationList(ca)
+		var cert, _ = certificate.ParseCertificate()
+
+		// revoked certificate should be in signed CRL
+		if didRevoke, err := list.RevokeCertificate(certificate, RevokeKeyCompromise); err != nil || !didRevoke {
+			t.Fatalf("%s RevokeCertificate %t err %v", algo, didRevoke, err)
+		} else if list.Revoke(cert.SerialNumber, RevokeKeyCompromise) {
+			t.Errorf("%s second Revoke true", algo)
+		}
+		var crlDER, err = list.CRL(time.Hour)
+		if err != nil {
+			t.Fatalf("%s CRL err %s", algo, err)
+		}
+		var crl *x509.RevocationList
+		if crl, err = x509.ParseRevocationList(crlDER); err != nil {
+			t.Fatalf("%s ParseRevocationList err %s", algo, err)
+		} else if err = crl.CheckSignatureFrom(caCert); err != nil {
+			t.Errorf("%s CRL signature err %s", algo, err)
+		}
+		if len(crl.RevokedCertificateEntries) != 1 {
+			t.Fatalf("%s CRL entries %d exp 1", algo, len(crl.RevokedCertificateEntries))
+		} else if e := crl.RevokedCertificateEntries[0]; e.SerialNumber.Cmp(cert.SerialNumber) != 0 ||
+			e.ReasonCode != int(RevokeKeyCompromise) {
+			t.Errorf("%s CRL entry %d %d", algo, e.SerialNumber, e.ReasonCode)
+		}
+		if crl.Number.Int64() != 1 {
+			t.Errorf("%s CRL number %d exp 1", algo, crl.Number)
+		}
+
+		// OCSP should be revoked for certificate, good for other serial numbers
+		var server = httptest.NewServer(NewOCSPResponder(list))
+		var response = revocationTestOCSP(t, server.URL, caCert, cert)
+		if response.Revoked.RevocationTime.IsZero() || response.Revoked.Reason != asn1.Enumerated(RevokeKeyCompromise) {
+			t.Errorf("%s OCSP not revoked: %+v", algo, response)
+		}
+		cert.SerialNumber = uuidSerialNumber()
+		if response = revocationTestOCSP(t, server.URL, caCert, cert); !response.Good {
+			t.Errorf("%s OCSP not good: %+v", algo, response)
+		}
+		server.Close()
+	}
+}
+
+// revocationTestCA returns a certificate authority and a certificate it issued
+func revocationTestCA(t *testing.T, algo x509.PublicKeyAlgorithm) (
+	ca parl.CertificateAuthority, caCert *x509.Certificate, certificate parl.Certificate,
+) {
+	var err error
+	if ca, err = NewSelfSigned("", algo); err != nil {
+		t.Fatalf("NewSelfSigned err %s", err)
+	} else if caCert, err = ca.Check(); err != nil {
+		t.Fatalf("Check err %s", err)
+	}
+	var privateKey parl.PrivateKey
+	if privateKey, err = NewPrivateKey(algo); err != nil {
+		t.Fatalf("NewPrivateKey err %s", err)
+	}
+	var template x509.Certificate
+	EnsureServer(&template)
+	var certificateDer parl.CertificateDer
+	if certificateDer, err = ca.Sign(&template, privateKey.Public()); err != nil {
+		t.Fatalf("Sign err %s", err)
+	}
+	certificate = NewCertificate(certificateDer)
+
+	return
+}
+
+// revocationTestOCSP posts an OCSP request for cert and returns
+// its verified single response
+func revocationTestOCSP(t *testing.T, url string, caCert, cert *x509.Certificate) (response ocspSingleResponse) {
+	t.Helper()
+
+	// request using sha1 certificate identifier and nonce
+	var issuerKey, _ = subjectPublicKey(caCert)
+	var nameHash, keyHash = sha1.Sum(caCert.RawSubject), sha1.Sum(issuerKey)
+	var nonce, _ = asn1.Marshal([]byte("nonce"))
+	var requestDER, err = asn1.Marshal(ocspRequest{TBSRequest: ocspTBSRequest{
+		RequestList: []ocspSingleRequest{{CertID: ocspCertID{
+			HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: ocspHashes[0].oid, Parameters: asn1.NullRawValue},
+			IssuerNameHash: nameHash[:],
+			IssuerKeyHash:  keyHash[:],
+			SerialNumber:   cert.SerialNumber,
+		}}},
+		Extensions: []pkix.Extension{{Id: oidOCSPNonce, Value: nonce}},
+	}})
+	if err != nil {
+		t.Fatalf("Marshal err %s", err)
+	}
+	var resp *http.Response
+	if resp, err = http.Post(url, OCSPRequestContentType, bytes.NewReader(requestDER)); err != nil {
+		t.Fatalf("Post err %s", err)
+	}
+	defer resp.Body.Close()
+	var body bytes.Buffer
+	body.ReadFrom(resp.Body)
+
+	// parse and verify response
+	var ocsp ocspResponse
+	var basic ocspBasicResponse
+	var data ocspResponseData
+	if _, err = asn1.Unmarshal(body.Bytes(), &ocsp); err != nil || ocsp.Status != ocspSuccessful {
+		t.Fatalf("response status %d err %v", ocsp.Status, err)
+	} else if _, err = asn1.Unmarshal(ocsp.ResponseBytes.Response, &basic); err != nil {
+		t.Fatalf("basic response err %s", err)
+	} else if _, err = asn1.Unmarshal(basic.TBSResponseData.FullBytes, &data); err != nil {
+		t.Fatalf("response data err %s", err)
+	}
+	if err = caCert.CheckSignature(caCert.SignatureAlgorithm, basic.TBSResponseData.FullBytes, basic.Signature.Bytes); err != nil {
+		t.Errorf("response signature err %s", err)
+	}
+	if len(data.Extensions) != 1 || !bytes.Equal(data.Extensions[0].Value, nonce) {
+		t.Errorf("nonce missing %v", data.Extensions)
+	}
+	if len(data.Responses) != 1 {
+		t.Fatalf("responses %d exp 1", len(data.Responses))
+	}
+	response = data.Responses[0]
+	if response.CertID.SerialNumber.Cmp(cert.SerialNumber) != 0 || response.NextUpdate.IsZero() {
+		t.Errorf("bad response %+v", response)
+	}
+
+	return
+}
@@ -0,0 +1,195 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pencoding
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// CanonicalJSON returns the canonical form of a JSON document
+//   - object keys are sorted by byte value, duplicate keys: last wins
+//   - no insignificant whitespace
+//   - numbers are formatted like ECMAScript: integers without fraction
+//     or exponent, “1.5” “1e+21” “1e-7”. Integers beyond float64
+//     precision retain all digits
+//   - strings escape only quote, backslash and control characters
+//   - the form follows RFC 8785 JSON Canonicalization Scheme except that
+//     object keys are sorted by UTF-8 rather than UTF-16 code units
+//   - err: data is not a single valid JSON value
+func CanonicalJSON(data []byte) (canonicalJSON []byte, err error) {
+	var value any
+	if value, err = decodeJSON(data); err != nil {
+		return
+	}
+	return canonical(value)
+}
+
+// MarshalCanonical returns the canonical JSON form of value
+//   - value is marshaled using [json.Marshal]
+//   - see [CanonicalJSON]
+func MarshalCanonical(value any) (canonicalJSON []byte, err error) {
+	var data []byte
+	if data, err = json.Marshal(value); perrors.IsPF(&err, "json.Marshal: %w", err) {
+		return
+	}
+	return CanonicalJSON(data)
+}
+
+// decodeJSON decodes a single JSON value with numbers as [json.Number]
+func decodeJSON(data []byte) (value any, err error) {
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&value); perrors.IsPF(&err, "json: %w", err) {
+		return
+	}
+	if _, e := decoder.Token(); !errors.Is(e, io.EOF) {
+		err = perrors.NewPF("json: data after value")
+	}
+	return
+}
+
+// canonical returns canonical JSON of a decoded value
+func canonical(value any) (data []byte, err error) {
+	var buffer bytes.Buffer
+	if err = writeCanonical(&buffer, value); err != nil {
+		return
+	}
+	data = buffer.Bytes()
+
+	return
+}
+
+// writeCanonical writes value decoded by decodeJSON in canonical form
+func writeCanonical(buffer *bytes.Buffer, value any) (err error) {
+	switch v := value.(type) {
+	case nil:
+		buffer.WriteString("null")
+	case bool:
+		buffer.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		var s string
+		if s, err = canonicalNumber(v); err != nil {
+			return
+		}
+		buffer.WriteString(s)
+	case string:
+		writeString(buffer, v)
+	case []any:
+		buffer.WriteByte('[')
+		for i, element := range v {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			if err = writeCanonical(buffer, element); err != nil {
+				return
+			}
+		}
+		buffer.WriteByte(']')
+	case map[string]any:
+		var keys = make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buffer.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeString(buffer, key)
+			buffer.WriteByte(':')
+			if err = writeCanonical(buffer, v[key]); err != nil {
+				return
+			}
+		}
+		buffer.WriteByte('}')
+	default:
+		err = perrors.ErrorfPF("json: unexpected type %T", value)
+	}
+
+	return
+}
+
+// canonicalNumber formats a JSON number like ECMAScript Number.toString
+//   - an integer literal beyond float64 precision retains its digits
+func canonicalNumber(number json.Number) (s string, err error) {
+	var literal = number.String()
+
+	// integer literal: exact
+	if !strings.ContainsAny(literal, ".eE") {
+		var digits = strings.TrimPrefix(literal, "-")
+		if digits == "0" {
+			return "0", nil // “-0” return
+		} else if len(digits) > 15 {
+			return literal, nil // beyond float64 precision return
+		}
+	}
+
+	var f float64
+	if f, err = number.Float64(); perrors.IsPF(&err, "json number %q: %w", literal, err) {
+		return
+	} else if math.IsInf(f, 0) || math.IsNaN(f) {
+		err = perrors.ErrorfPF("json number out of range: %q", literal)
+		return
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	var abs = math.Abs(f)
+	if abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	// “1e-07” → “1e-7”, “1e+21”
+	s = strconv.FormatFloat(f, 'e', -1, 64)
+	var mantissa, exponent, _ = strings.Cut(s, "e")
+	var sign = exponent[:1]
+	exponent = strings.TrimLeft(exponent[1:], "0")
+	s = mantissa + "e" + sign + exponent
+
+	return
+}
+
+// writeString writes s as a JSON string
+//   - escapes quote, backslash and control characters
+//   - invalid UTF-8 is replaced with U+FFFD
+func writeString(buffer *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	buffer.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			buffer.WriteString(`\"`)
+		case r == '\\':
+			buffer.WriteString(`\\`)
+		case r == '\b':
+			buffer.WriteString(`\b`)
+		case r == '\f':
+			buffer.WriteString(`\f`)
+		case r == '\n':
+			buffer.WriteString(`\n`)
+		case r == '\r':
+			buffer.WriteString(`\r`)
+		case r == '\t':
+			buffer.WriteString(`\t`)
+		case r < 0x20:
+			buffer.WriteString(`\u00`)
+			buffer.WriteByte(hex[r>>4])
+			buffer.WriteByte(hex[r&0xf])
+		default:
+			buffer.WriteRune(r)
+		}
+	}
+	buffer.WriteByte('"')
+}
@@ -0,0 +1,186 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pencoding
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ChangeAdded: the path exists only in the new document
+	ChangeAdded ChangeKind = iota + 1
+	// ChangeRemoved: the path exists only in the old document
+	ChangeRemoved
+	// ChangeModified: the value at path differs
+	ChangeModified
+)
+
+// ChangeKind is the type of a [JSONChange]
+//   - [ChangeAdded] [ChangeRemoved] [ChangeModified]
+type ChangeKind uint8
+
+// JSONChange is a difference found by [DiffJSON]
+type JSONChange struct {
+	Kind ChangeKind
+	// Path is RFC 6901 JSON Pointer “/a/0/b”, empty for the document
+	Path string
+	// Old is canonical JSON of the old value, nil for [ChangeAdded]
+	Old json.RawMessage
+	// New is canonical JSON of the new value, nil for [ChangeRemoved]
+	New json.RawMessage
+}
+
+// DiffJSON returns the structural differences from document a to b
+//   - objects are compared by key, key order is insignificant
+//   - arrays are compared by index. Elements beyond the shorter
+//     array are added or removed
+//   - numbers and strings are compared in canonical form:
+//     “1.0” equals “1”
+//   - a value changing type is a single [ChangeModified]
+//   - changes: in path order, empty if the documents are equal
+//   - err: a or b is not a single valid JSON value
+func DiffJSON(a, b []byte) (changes []JSONChange, err error) {
+	var valueA, valueB any
+	if valueA, err = decodeJSON(a); err != nil {
+		return
+	} else if valueB, err = decodeJSON(b); err != nil {
+		return
+	}
+	err = diffValue("", valueA, valueB, &changes)
+
+	return
+}
+
+// diffValue appends changes from a to b at path
+func diffValue(path string, a, b any, changes *[]JSONChange) (err error) {
+	switch va := a.(type) {
+	case map[string]any:
+		if vb, ok := b.(map[string]any); ok {
+			return diffObject(path, va, vb, changes)
+		}
+	case []any:
+		if vb, ok := b.([]any); ok {
+			return diffArray(path, va, vb, changes)
+		}
+	}
+
+	// scalars or type change
+	var oldData, newData []byte
+	if oldData, err = canonical(a); err != nil {
+		return
+	} else if newData, err = canonical(b); err != nil {
+		return
+	}
+	if !bytes.Equal(oldData, newData) {
+		*changes = append(*changes, JSONChange{Kind: ChangeModified, Path: path, Old: oldData, New: newData})
+	}
+
+	return
+}
+
+// diffObject compares objects by key in key order
+func diffObject(path string, a, b map[string]any, changes *[]JSONChange) (err error) {
+	var keys = make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var p = path + "/" + escapePointer(key)
+		var va, inA = a[key]
+		var vb, inB = b[key]
+		switch {
+		case !inB:
+			err = appendChange(ChangeRemoved, p, va, changes)
+		case !inA:
+			err = appendChange(ChangeAdded, p, vb, changes)
+		default:
+			err = diffValue(p, va, vb, changes)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// diffArray compares arrays by index
+func diffArray(path string, a, b []any, changes *[]JSONChange) (err error) {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		var p = path + "/" + strconv.Itoa(i)
+		switch {
+		case i >= len(b):
+			err = appendChange(ChangeRemoved, p, a[i], changes)
+		case i >= len(a):
+			err = appendChange(ChangeAdded, p, b[i], changes)
+		default:
+			err = diffValue(p, a[i], b[i], changes)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// appendChange appends an added or removed value
+func appendChange(kind ChangeKind, path string, value any, changes *[]JSONChange) (err error) {
+	var data []byte
+	if data, err = canonical(value); err != nil {
+		return
+	}
+	var change = JSONChange{Kind: kind, Path: path}
+	if kind == ChangeAdded {
+		change.New = data
+	} else {
+		change.Old = data
+	}
+	*changes = append(*changes, change)
+
+	return
+}
+
+// escapePointer escapes a JSON Pointer reference token
+//   - “~” → “~0” “/” → “~1”
+func escapePointer(key string) (token string) {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// “modified /a/0: 1 → 2”
+func (c JSONChange) String() (s string) {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("%s %s: %s", c.Kind, c.Path, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("%s %s: %s", c.Kind, c.Path, c.Old)
+	}
+	return fmt.Sprintf("%s %s: %s → %s", c.Kind, c.Path, c.Old, c.New)
+}
+
+// “added” “removed” “modified”
+func (k ChangeKind) String() (s string) {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	}
+	return fmt.Sprintf("?changeKind%d", k)
+}
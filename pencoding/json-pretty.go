@@ -0,0 +1,149 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pencoding
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// DefaultIndent is the indentation of [Pretty]
+	DefaultIndent = "  "
+)
+
+// Pretty reformats a stream of JSON values with one element per line
+//   - indent: optional indentation per level, default [DefaultIndent]
+//   - key order and number literals are retained.
+//     Empty objects and arrays are printed “{}” “[]”
+//   - each top-level value is followed by newline
+//   - streaming: memory use is independent of document size
+//   - err: read, write or syntax error
+func Pretty(writer io.Writer, reader io.Reader, indent ...string) (err error) {
+	var i = DefaultIndent
+	if len(indent) > 0 {
+		i = indent[0]
+	}
+	return reformatJSON(writer, reader, i, true)
+}
+
+// Minify reformats a stream of JSON values removing insignificant whitespace
+//   - key order and number literals are retained
+//   - each top-level value is followed by newline
+//   - streaming: memory use is independent of document size
+//   - err: read, write or syntax error
+func Minify(writer io.Writer, reader io.Reader) (err error) {
+	return reformatJSON(writer, reader, "", false)
+}
+
+// jsonLevel is an open object or array
+type jsonLevel struct {
+	// isObject is true for object, false for array
+	isObject bool
+	// count is number of elements, for objects keys and values
+	count int
+}
+
+// reformatJSON streams tokens from reader to writer
+func reformatJSON(writer io.Writer, reader io.Reader, indent string, isPretty bool) (err error) {
+	var decoder = json.NewDecoder(reader)
+	decoder.UseNumber()
+	var w = bufio.NewWriter(writer)
+	// buffer formats strings
+	var buffer bytes.Buffer
+	var levels []jsonLevel
+
+	// newline writes newline and indentation for the current depth
+	var newline = func(depth int) {
+		w.WriteByte('\n')
+		for i := 0; i < depth; i++ {
+			w.WriteString(indent)
+		}
+	}
+
+	for {
+		var token json.Token
+		if token, err = decoder.Token(); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil
+				if len(levels) > 0 {
+					err = perrors.NewPF("json: unexpected end of input")
+				}
+				break
+			}
+			err = perrors.ErrorfPF("json: %w", err)
+			return
+		}
+
+		// separator preceding the token
+		var delim, isDelim = token.(json.Delim)
+		var isClose = isDelim && (delim == '}' || delim == ']')
+		if n := len(levels); n > 0 {
+			var level = &levels[n-1]
+			var isValue = level.isObject && level.count%2 == 1
+			if isClose {
+				if level.count > 0 && isPretty {
+					newline(n - 1)
+				}
+			} else if isValue {
+				w.WriteByte(':')
+				if isPretty {
+					w.WriteByte(' ')
+				}
+			} else {
+				if level.count > 0 {
+					w.WriteByte(',')
+				}
+				if isPretty {
+					newline(n)
+				}
+			}
+			if !isClose {
+				level.count++
+			}
+		}
+
+		// the token
+		switch t := token.(type) {
+		case json.Delim:
+			w.WriteByte(byte(t))
+			if isClose {
+				levels = levels[:len(levels)-1]
+			} else {
+				levels = append(levels, jsonLevel{isObject: t == '{'})
+			}
+		case nil:
+			w.WriteString("null")
+		case bool:
+			if t {
+				w.WriteString("true")
+			} else {
+				w.WriteString("false")
+			}
+		case json.Number:
+			w.WriteString(t.String())
+		case string:
+			buffer.Reset()
+			writeString(&buffer, t)
+			w.Write(buffer.Bytes())
+		}
+
+		// end of top-level value
+		if len(levels) == 0 {
+			w.WriteByte('\n')
+		}
+	}
+	if e := w.Flush(); e != nil && err == nil {
+		err = perrors.ErrorfPF("write: %w", e)
+	}
+
+	return
+}
@@ -0,0 +1,107 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pencoding
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	//t.Error("Logging on")
+	var tests = []struct{ in, exp string }{
+		{`{ "b": 1, "a": [true, null, "x"] }`, `{"a":[true,null,"x"],"b":1}`},
+		{`[1.0, -0, 1.50, 1e21, 1E-7, 0.000001, 123456789012345678901234]`,
+			`[1,0,1.5,1e+21,1e-7,0.000001,123456789012345678901234]`},
+		{`"<\u00e9\n\u0001\/>"`, "\"<é\\n\\u0001/>\""},
+		{`{"a":1,"a":2}`, `{"a":2}`},
+	}
+	for _, test := range tests {
+		var canonical, err = CanonicalJSON([]byte(test.in))
+		if err != nil {
+			t.Errorf("%s: err %s", test.in, err)
+		} else if string(canonical) != test.exp {
+			t.Errorf("%s:\n%s exp\n%s", test.in, canonical, test.exp)
+		}
+	}
+
+	for _, bad := range []string{``, `{`, `1 2`, `1e999`} {
+		if _, err := CanonicalJSON([]byte(bad)); err == nil {
+			t.Errorf("%q: missing error", bad)
+		}
+	}
+
+	var canonical, err = MarshalCanonical(map[string]any{"z": 1, "y": []int{}})
+	if err != nil || string(canonical) != `{"y":[],"z":1}` {
+		t.Errorf("MarshalCanonical %s %v", canonical, err)
+	}
+}
+
+func TestPretty(t *testing.T) {
+	//t.Error("Logging on")
+	var in = `{"b":1.0,"a":[1,{}],"c":[]} [ ]`
+	var exp = strings.Join([]string{
+		`{`,
+		`  "b": 1.0,`,
+		`  "a": [`,
+		`    1,`,
+		`    {}`,
+		`  ],`,
+		`  "c": []`,
+		`}`,
+		`[]`,
+		``,
+	}, "\n")
+
+	var buffer bytes.Buffer
+	if err := Pretty(&buffer, strings.NewReader(in)); err != nil {
+		t.Fatalf("Pretty err %s", err)
+	} else if buffer.String() != exp {
+		t.Errorf("Pretty:\n%s\nexp:\n%s", buffer.String(), exp)
+	}
+
+	var minified bytes.Buffer
+	if err := Minify(&minified, &buffer); err != nil {
+		t.Fatalf("Minify err %s", err)
+	} else if s := minified.String(); s != `{"b":1.0,"a":[1,{}],"c":[]}`+"\n[]\n" {
+		t.Errorf("Minify: %q", s)
+	}
+
+	if err := Minify(&minified, strings.NewReader(`[1,`)); err == nil {
+		t.Error("Minify missing error")
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	//t.Error("Logging on")
+	var a = `{"a":1.0,"b":[1,2,3],"c":{"d":"x"},"e/f":true,"g":1}`
+	var b = `{"b":[1,5],"c":{"d":"y"},"e/f":true,"g":{"h":null},"a":1,"i":[]}`
+	var exp = []string{
+		`modified /b/1: 2 → 5`,
+		`removed /b/2: 3`,
+		`modified /c/d: "x" → "y"`,
+		`modified /g: 1 → {"h":null}`,
+		`added /i: []`,
+	}
+
+	var changes, err = DiffJSON([]byte(a), []byte(b))
+	if err != nil {
+		t.Fatalf("DiffJSON err %s", err)
+	}
+	if len(changes) != len(exp) {
+		t.Fatalf("changes %d exp %d: %v", len(changes), len(exp), changes)
+	}
+	for i, change := range changes {
+		if s := change.String(); s != exp[i] {
+			t.Errorf("change %d: %s exp %s", i, s, exp[i])
+		}
+	}
+
+	if changes, err = DiffJSON([]byte(a), []byte(a)); err != nil || len(changes) != 0 {
+		t.Errorf("equal: %v %v", changes, err)
+	}
+}
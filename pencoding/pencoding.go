@@ -0,0 +1,9 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+// Package pencoding provides encoding utilities.
+//   - JSON: [CanonicalJSON] [MarshalCanonical] canonical form for hashing and signing,
+//     [Pretty] [Minify] streaming reformatting, [DiffJSON] structural diff
+package pencoding
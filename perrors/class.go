@@ -0,0 +1,150 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package perrors
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/haraldrudell/parl/perrors/errorglue"
+)
+
+const (
+	// ClassTimeout: an operation did not complete in time
+	ClassTimeout Class = "timeout"
+	// ClassNotFound: a file, row, host or other entity does not exist
+	ClassNotFound Class = "not-found"
+	// ClassConflict: an entity already exists or was concurrently modified
+	ClassConflict Class = "conflict"
+	// ClassUnavailable: a remote service or resource is temporarily unavailable
+	ClassUnavailable Class = "unavailable"
+	// ClassPermission: the operation is not permitted
+	ClassPermission Class = "permission"
+	// ClassCanceled: the operation was canceled
+	ClassCanceled Class = "canceled"
+)
+
+// Class is a machine-checkable error classification
+//   - [ClassTimeout] [ClassNotFound] [ClassConflict] [ClassUnavailable]
+//     [ClassPermission] [ClassCanceled]
+//   - packages may define additional classes
+//   - errors are tagged using [WithClass] and tested using [HasClass]
+type Class string
+
+// WithClass tags err with one or more classes
+//   - the error message is unchanged
+//   - err nil: nil
+func WithClass(err error, classes ...Class) (err2 error) {
+	if err == nil || len(classes) == 0 {
+		return err
+	}
+	var cs = make([]string, len(classes))
+	for i, class := range classes {
+		cs[i] = string(class)
+	}
+	return errorglue.NewClassError(err, cs)
+}
+
+// HasClass returns true if err is of class
+//   - explicit tags from [WithClass] anywhere in err’s error chain
+//   - classes derived from common standard library errors:
+//   - — timeout: [context.DeadlineExceeded] [os.ErrDeadlineExceeded] ETIMEDOUT
+//     and errors with Timeout method returning true like [net.Error]
+//   - — not-found: [fs.ErrNotExist] DNS not found
+//   - — conflict: [fs.ErrExist]
+//   - — unavailable: ECONNREFUSED ECONNRESET EHOSTUNREACH ENETUNREACH and
+//     temporary DNS errors
+//   - — permission: [fs.ErrPermission]
+//   - — canceled: [context.Canceled]
+func HasClass(err error, class Class) (hasClass bool) {
+	if err == nil {
+		return
+	}
+	for _, c := range Classes(err) {
+		if c == class {
+			return true
+		}
+	}
+
+	return
+}
+
+// Classes returns all classes of err, explicit and derived
+//   - order: explicit classes outermost first, then derived
+//   - classes are unique
+func Classes(err error) (classes []Class) {
+	if err == nil {
+		return
+	}
+	var m = make(map[Class]bool)
+	var add = func(class Class) {
+		if !m[class] {
+			m[class] = true
+			classes = append(classes, class)
+		}
+	}
+
+	// explicit classes
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if classError, ok := e.(*errorglue.ClassError); ok {
+			for _, c := range classError.Classes() {
+				add(Class(c))
+			}
+		}
+	}
+
+	// derived classes
+	for _, class := range stdlibClasses(err) {
+		add(class)
+	}
+
+	return
+}
+
+// IsTransient returns true if err is a timeout or unavailable error
+// that may succeed on retry
+func IsTransient(err error) (isTransient bool) {
+	return HasClass(err, ClassTimeout) || HasClass(err, ClassUnavailable)
+}
+
+// stdlibClasses returns classes derived from standard library errors
+func stdlibClasses(err error) (classes []Class) {
+	var timeouter interface{ Timeout() bool }
+	var dnsError *net.DNSError
+	errors.As(err, &dnsError)
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, os.ErrDeadlineExceeded) ||
+		errors.Is(err, syscall.ETIMEDOUT) ||
+		errors.As(err, &timeouter) && timeouter.Timeout() {
+		classes = append(classes, ClassTimeout)
+	}
+	if errors.Is(err, fs.ErrNotExist) ||
+		dnsError != nil && dnsError.IsNotFound {
+		classes = append(classes, ClassNotFound)
+	}
+	if errors.Is(err, fs.ErrExist) {
+		classes = append(classes, ClassConflict)
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EHOSTUNREACH) ||
+		errors.Is(err, syscall.ENETUNREACH) ||
+		dnsError != nil && dnsError.IsTemporary {
+		classes = append(classes, ClassUnavailable)
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		classes = append(classes, ClassPermission)
+	}
+	if errors.Is(err, context.Canceled) {
+		classes = append(classes, ClassCanceled)
+	}
+
+	return
+}
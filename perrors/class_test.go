@@ -0,0 +1,65 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package perrors
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"syscall"
+	"testing"
+)
+
+func TestClass(t *testing.T) {
+	//t.Error("Logging on")
+	var message = "message"
+	var err = errors.New(message)
+
+	// nil
+	if WithClass(nil, ClassConflict) != nil {
+		t.Error("WithClass nil not nil")
+	}
+	if HasClass(nil, ClassConflict) {
+		t.Error("HasClass nil true")
+	}
+
+	// explicit class through error chain
+	var e2 = Errorf("wrap: %w", WithClass(err, ClassConflict, ClassUnavailable))
+	if !HasClass(e2, ClassConflict) || !HasClass(e2, ClassUnavailable) {
+		t.Errorf("HasClass false: %v", Classes(e2))
+	}
+	if HasClass(e2, ClassNotFound) {
+		t.Error("HasClass not-found true")
+	}
+	if !errors.Is(e2, err) {
+		t.Error("errors.Is false")
+	}
+	if s := WithClass(err, ClassConflict).Error(); s != message {
+		t.Errorf("Error %q exp %q", s, message)
+	}
+	if !IsTransient(e2) {
+		t.Error("IsTransient false")
+	}
+
+	// derived classes
+	for _, tc := range []struct {
+		err   error
+		class Class
+	}{
+		{Errorf("f: %w", fs.ErrNotExist), ClassNotFound},
+		{context.DeadlineExceeded, ClassTimeout},
+		{context.Canceled, ClassCanceled},
+		{&fs.PathError{Op: "open", Path: "/", Err: syscall.ECONNREFUSED}, ClassUnavailable},
+		{fs.ErrPermission, ClassPermission},
+	} {
+		if !HasClass(tc.err, tc.class) {
+			t.Errorf("HasClass %v %s false: %v", tc.err, tc.class, Classes(tc.err))
+		}
+	}
+	if IsTransient(err) {
+		t.Error("IsTransient true")
+	}
+}
@@ -21,8 +21,18 @@ const (
 )
 
 // ChainString() gets a string representation of a single error chain
+//   - other than DefaultFormat, output is subject to any [StackPolicy]
+//
 // TODO 220319 finish comment
 func ChainString(err error, format CSFormat) (s string) {
+	if s = chainString(err, format); format != DefaultFormat {
+		s = applyTextPolicy(s)
+	}
+	return
+}
+
+// chainString renders err in format without text policy
+func chainString(err error, format CSFormat) (s string) {
 
 	// no error case
 	if err == nil {
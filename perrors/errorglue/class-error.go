@@ -0,0 +1,24 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package errorglue
+
+// ClassError tags an error with machine-checkable classes
+//   - the error message is not modified
+type ClassError struct {
+	ErrorChain
+	classes []string
+}
+
+var _ error = &ClassError{}   // ClassError behaves like an error
+var _ Wrapper = &ClassError{} // ClassError has an error chain
+
+// NewClassError returns err tagged with classes
+func NewClassError(err error, classes []string) (e2 error) {
+	return &ClassError{ErrorChain: *newErrorChain(err), classes: classes}
+}
+
+// Classes returns the classes of this error instance
+func (e *ClassError) Classes() (classes []string) { return e.classes }
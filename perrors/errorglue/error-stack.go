@@ -88,12 +88,12 @@ func (errorStackValue *errorStack) ChainString(format CSFormat) (s string) {
 		}
 		s = fmt.Sprintf("%s [%T]%s\n%s",
 			errorStackValue.Error(), errorStackValue, // “error-message [errors.Type]”
-			s,                          // “ at runtime.gopanic:17”
-			errorStackValue.s.String(), // multiple-line stack-trace
+			s,                              // “ at runtime.gopanic:17”
+			stackString(errorStackValue.s), // multiple-line stack-trace
 		)
 		return
 	case LongSuffix:
-		s = errorStackValue.s.String()
+		s = stackString(errorStackValue.s)
 		return
 	default:
 		return // unknown format
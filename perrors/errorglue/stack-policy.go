@@ -0,0 +1,113 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package errorglue
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/haraldrudell/parl/pruntime"
+)
+
+const (
+	// RedactedText replaces redacted text “‹redacted›”
+	RedactedText = "‹redacted›"
+	// runtimePrefix is function-name prefix of runtime frames
+	runtimePrefix = "runtime."
+)
+
+// StackPolicy controls how stack traces and code locations are rendered
+// by [ChainString]
+//   - the zero-value renders stacks unchanged
+type StackPolicy struct {
+	// TrimPrefixes are removed from file paths,
+	// typically GOPATH “/home/user/go/pkg/mod/” or a module root
+	TrimPrefixes []string
+	// DropRuntime omits frames of package runtime from stack traces
+	DropRuntime bool
+	// Redact are patterns whose matches in rendered text are replaced with
+	// [RedactedText], typically file paths for log shipping
+	Redact []*regexp.Regexp
+	// MaxFrames is the maximum number of frames rendered per stack trace
+	//	- 0: no limit
+	//	- omitted frames are summarized “… 7 more frames”
+	MaxFrames int
+}
+
+// stackPolicy is the process-wide stack policy
+//   - nil: stacks are rendered unchanged
+var stackPolicy atomic.Pointer[StackPolicy]
+
+// SetStackPolicy sets the process-wide stack policy
+//   - nil: stacks are rendered unchanged
+func SetStackPolicy(policy *StackPolicy) {
+	if policy != nil {
+		var p = *policy
+		policy = &p
+	}
+	stackPolicy.Store(policy)
+}
+
+// GetStackPolicy returns the process-wide stack policy, possibly nil
+func GetStackPolicy() (policy *StackPolicy) { return stackPolicy.Load() }
+
+// stackString renders stack according to any stack policy
+func stackString(stack pruntime.Stack) (s string) {
+	s = stack.String()
+	var policy = stackPolicy.Load()
+	if policy == nil || !policy.DropRuntime && policy.MaxFrames <= 0 {
+		return // no frame policy return
+	}
+
+	// stack.String: header line, two lines per frame, possible creator lines
+	var frames = stack.Frames()
+	var header, rest, _ = strings.Cut(s, "\n")
+	var sL = make([]string, len(frames))
+	for i, frame := range frames {
+		sL[i] = frame.String()
+	}
+	var trailer = strings.TrimPrefix(rest, strings.Join(sL, "\n"))
+
+	sL = append(sL[:0], header)
+	var count, omitted int
+	for _, frame := range frames {
+		if policy.DropRuntime && strings.HasPrefix(frame.Loc().FuncName, runtimePrefix) {
+			continue
+		} else if policy.MaxFrames > 0 && count == policy.MaxFrames {
+			omitted++
+			continue
+		}
+		count++
+		sL = append(sL, frame.String())
+	}
+	if omitted > 0 {
+		sL = append(sL, "… "+strconv.Itoa(omitted)+" more frames")
+	}
+	s = strings.Join(sL, "\n") + trailer
+
+	return
+}
+
+// applyTextPolicy trims and redacts paths in rendered text
+func applyTextPolicy(s string) (s2 string) {
+	s2 = s
+	var policy = stackPolicy.Load()
+	if policy == nil {
+		return // no policy return
+	}
+	for _, prefix := range policy.TrimPrefixes {
+		if prefix != "" {
+			s2 = strings.ReplaceAll(s2, prefix, "")
+		}
+	}
+	for _, regExp := range policy.Redact {
+		s2 = regExp.ReplaceAllString(s2, RedactedText)
+	}
+
+	return
+}
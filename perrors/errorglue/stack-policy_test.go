@@ -0,0 +1,52 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package errorglue
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/haraldrudell/parl/pruntime"
+)
+
+func TestStackPolicy(t *testing.T) {
+	//t.Error("Logging on")
+	var err = NewErrorStack(errors.New("x"), pruntime.NewStack(0))
+	var long = ChainString(err, LongFormat)
+	var file = pruntime.NewCodeLocation(0).File
+	var dir = file[:strings.LastIndex(file, "/")+1]
+	if !strings.Contains(long, dir) {
+		t.Fatalf("LongFormat missing %q: %s", dir, long)
+	}
+
+	SetStackPolicy(&StackPolicy{
+		TrimPrefixes: []string{dir},
+		DropRuntime:  true,
+		Redact:       []*regexp.Regexp{regexp.MustCompile(`stack-policy_test`)},
+		MaxFrames:    1,
+	})
+	defer SetStackPolicy(nil)
+
+	var policyLong = ChainString(err, LongFormat)
+	if strings.Contains(policyLong, dir) {
+		t.Errorf("prefix not trimmed: %s", policyLong)
+	}
+	if strings.Contains(policyLong, "stack-policy_test") || !strings.Contains(policyLong, RedactedText) {
+		t.Errorf("not redacted: %s", policyLong)
+	}
+	if strings.Contains(policyLong, "\n"+runtimePrefix) {
+		t.Errorf("runtime frame not dropped: %s", policyLong)
+	}
+	if !strings.Contains(policyLong, "more frames") {
+		t.Errorf("frames not capped: %s", policyLong)
+	}
+	// DefaultFormat is unaffected
+	if s := ChainString(err, DefaultFormat); s != "x" {
+		t.Errorf("DefaultFormat %q", s)
+	}
+}
@@ -21,3 +21,26 @@ func IsPanic(err error) (isPanic bool, stack pruntime.Stack, recoveryIndex, pani
 	isPanic, stack, recoveryIndex, panicIndex, _, _ = errorglue.FirstPanicStack(err)
 	return
 }
+
+// PanicSite returns the code location where panic was invoked or
+// a runtime error occurred for an error from a recovered panic
+//   - isPanic false: err is not from a panic, location is zero-value
+//   - unlike the location of [Short], location is never the
+//     deferred function that recovered the panic
+//   - err must have stack trace from [parl.Recover] [perrors.ErrorfPF] or similar
+//   - thread-safe
+//
+// Usage:
+//
+//	if location, isPanic := perrors.PanicSite(err); isPanic {
+//	  log.Printf("panic at %s", location.Short())
+func PanicSite(err error) (location pruntime.CodeLocation, isPanic bool) {
+	var stack pruntime.Stack
+	var panicIndex int
+	if isPanic, stack, _, panicIndex = IsPanic(err); !isPanic {
+		return // not a panic return
+	}
+	location = *stack.Frames()[panicIndex].Loc()
+
+	return
+}
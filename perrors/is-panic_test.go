@@ -48,3 +48,29 @@ func TestIsPanic(t *testing.T) {
 		t.Error("panicIndex zero exp non-zero")
 	}
 }
+
+func TestPanicSite(t *testing.T) {
+	var panicLine int
+	var errPanic = func() (err error) {
+		defer func() {
+			recover()
+			err = New("panic")
+		}()
+		panicLine = pruntime.NewCodeLocation(0).Line + 1
+		panic(1)
+	}()
+
+	var location, isPanic = PanicSite(errPanic)
+	t.Logf("location: %s", location.Short())
+	if !isPanic {
+		t.Fatal("PanicSite isPanic false")
+	}
+	if location.Line != panicLine {
+		t.Errorf("PanicSite line %d exp %d", location.Line, panicLine)
+	}
+
+	// non-panic error
+	if location, isPanic = PanicSite(New("message")); isPanic || location.IsSet() {
+		t.Errorf("PanicSite non-panic isPanic %t", isPanic)
+	}
+}
@@ -29,7 +29,7 @@ func New(s string) error {
 }
 
 func NewPF(s string) error {
-	packFunc := pruntime.NewCodeLocation(perrNewFrames).PackFunc()
+	packFunc := pruntime.PackFuncFast(perrNewFrames)
 	if s == "" {
 		s = packFunc
 	} else {
@@ -51,7 +51,7 @@ func Errorf(format string, a ...interface{}) (err error) {
 func ErrorfPF(format string, a ...interface{}) (err error) {
 	// format may include %w directives, meaning fmt.Errorf must be used
 	// format may include numeric indices like %[1]s, meaning values cannot be prepended to a
-	format = pruntime.NewCodeLocation(perrNewFrames).PackFunc() + "\x20" + format
+	format = pruntime.PackFuncFast(perrNewFrames) + "\x20" + format
 	err = fmt.Errorf(format, a...)
 	if HasStack(err) {
 		return
@@ -0,0 +1,29 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package perrors
+
+import (
+	"testing"
+)
+
+// NewPF obtains its package and function name from the
+// symbolization cache [pruntime.PackFuncFast]
+//   - allocations are those of the error value and its stack trace
+//   - 27 allocs/op, previously 31 using [pruntime.NewCodeLocation]
+func BenchmarkNewPF(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = NewPF("message")
+	}
+}
+
+// ErrorfPF obtains its package and function name from the
+// symbolization cache [pruntime.PackFuncFast]
+//   - 30 allocs/op, previously 33 using [pruntime.NewCodeLocation]
+func BenchmarkErrorfPF(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = ErrorfPF("value: %d", i)
+	}
+}
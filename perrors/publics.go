@@ -81,7 +81,7 @@ func TagErr(e error, tags ...string) (err error) {
 	}
 
 	// values to print
-	s := pruntime.NewCodeLocation(frames).PackFunc()
+	s := pruntime.PackFuncFast(frames)
 	if tagString := strings.Join(tags, "\x20"); tagString != "" {
 		s += "\x20" + tagString
 	}
@@ -0,0 +1,31 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package perrors
+
+import "github.com/haraldrudell/parl/perrors/errorglue"
+
+// StackPolicy controls rendering of stack traces by [Long] [Short]
+//   - TrimPrefixes: file-path prefixes removed like GOPATH or module root
+//   - DropRuntime: omit frames of package runtime
+//   - Redact: regexp matches replaced with “‹redacted›” for log shipping
+//   - MaxFrames: cap on frames per stack trace
+//   - applies to all rich-error printing including “%+v” “%-v”,
+//     mains error printing and [parl.GoError] String
+type StackPolicy = errorglue.StackPolicy
+
+// SetStackPolicy sets the process-wide policy for rendering stack traces
+//   - nil: stack traces are rendered unchanged
+//   - policy is copied
+//
+// Usage:
+//
+//	perrors.SetStackPolicy(&perrors.StackPolicy{
+//	  TrimPrefixes: []string{build.Default.GOPATH + "/pkg/mod/"},
+//	  DropRuntime:  true,
+//	  Redact:       []*regexp.Regexp{regexp.MustCompile(`/home/[^/]+`)},
+//	  MaxFrames:    10,
+//	})
+func SetStackPolicy(policy *StackPolicy) { errorglue.SetStackPolicy(policy) }
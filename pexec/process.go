@@ -0,0 +1,310 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// DefaultGracePeriod is time from SIGTERM to SIGKILL on context cancel
+	DefaultGracePeriod = 5 * time.Second
+)
+
+// ProcessConfig configures [Spawn]
+type ProcessConfig struct {
+	// Env is the environment of the process, nil: current process’ environment
+	Env []string
+	// Dir is working directory, empty: current directory
+	Dir string
+	// Stdin is the process’ standard input, nil: /dev/null
+	Stdin io.Reader
+	// GracePeriod is time from SIGTERM to SIGKILL on context cancel
+	//	- 0: [DefaultGracePeriod]
+	GracePeriod time.Duration
+	// Pty allocates a pseudo-terminal for the process, typically
+	// [github.com/haraldrudell/parl/pterm.OpenPty]
+	//	- nil: standard output and standard error are pipes
+	//	- stdin, stdout and stderr are the terminal and
+	//		all output is streamed to [Process.Stdout]
+	//	- the process is session leader with the terminal as controlling terminal
+	Pty func() (pty, tty *os.File, err error)
+}
+
+// Process is a child process whose output is streamed line-by-line
+//   - [Process.Stdout] and [Process.Stderr] stream lines without line terminator.
+//     The streams close when the process has exited and output was read
+//   - on context cancel the process receives SIGTERM,
+//     then SIGKILL after the grace period
+//   - [Process.Wait] returns the exit status with an error classified
+//     by [perrors.Classes]
+//   - thread-safe
+//
+// Usage:
+//
+//	var process, err = pexec.Spawn(ctx, pexec.ProcessConfig{}, "ping", "-c", "3", "localhost")
+//	…
+//	var lines = process.Stdout()
+//	for line := lines.Init(); lines.Condition(&line); {
+//	  parl.Log(line)
+//	}
+//	var statusCode int
+//	statusCode, err = process.Wait()
+type Process struct {
+	cmd *exec.Cmd
+	// ctx is the context provided to Spawn
+	ctx context.Context
+	// args are the command and arguments
+	args []string
+	// stdout receives lines of standard output or the terminal
+	stdout parl.AwaitableSlice[string]
+	// stderr receives lines of standard error, empty for a terminal
+	stderr parl.AwaitableSlice[string]
+	// stdoutWriter and stderrWriter split output into lines, nil for a terminal
+	stdoutWriter, stderrWriter *lineWriter
+	// pty is terminal master, nil if no terminal
+	pty *os.File
+	// ptyRead closes when terminal output was read
+	ptyRead parl.Awaitable
+	// isExit closes when the process exited and output was read
+	isExit parl.Awaitable
+	// statusCode is exit status, valid after isExit
+	statusCode int
+	// err is exit error, valid after isExit
+	err error
+}
+
+// Spawn starts a child process
+//   - ctx: cancel terminates the process: SIGTERM, then SIGKILL after
+//     the grace period
+//   - args: the command and its arguments.
+//     If args[0] does not contain path, the command is resolved using
+//     the parent process’ env.PATH
+//   - err: the process was not started
+func Spawn(ctx context.Context, config ProcessConfig, args ...string) (process *Process, err error) {
+	if ctx == nil {
+		panic(parl.NilError("ctx"))
+	} else if len(args) == 0 {
+		err = perrors.ErrorfPF("%w", ErrArgsListEmpty)
+		return
+	}
+	var p = Process{ctx: ctx, args: args}
+	var cmd = exec.CommandContext(ctx, args[0], args[1:]...)
+	p.cmd = cmd
+	cmd.Env = config.Env
+	cmd.Dir = config.Dir
+	cmd.Cancel = func() (err error) { return cmd.Process.Signal(unix.SIGTERM) }
+	if cmd.WaitDelay = config.GracePeriod; cmd.WaitDelay <= 0 {
+		cmd.WaitDelay = DefaultGracePeriod
+	}
+
+	// standard streams
+	var tty *os.File
+	if config.Pty == nil {
+		cmd.Stdin = config.Stdin
+		p.stdoutWriter = &lineWriter{lines: &p.stdout}
+		p.stderrWriter = &lineWriter{lines: &p.stderr}
+		cmd.Stdout = p.stdoutWriter
+		cmd.Stderr = p.stderrWriter
+	} else {
+		if p.pty, tty, err = config.Pty(); perrors.IsPF(&err, "pty: %w", err) {
+			return
+		}
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	}
+
+	// start
+	err = cmd.Start()
+	if tty != nil {
+		// the child has its copy
+		tty.Close()
+	}
+	if err != nil {
+		if p.pty != nil {
+			p.pty.Close()
+		}
+		err = p.classify(perrors.ErrorfPF("start %q: %w", args[0], err))
+		return
+	}
+	if p.pty != nil {
+		go p.ptyReadThread()
+		if config.Stdin != nil {
+			go p.ptyWriteThread(config.Stdin)
+		}
+	}
+	go p.waitThread()
+	process = &p
+
+	return
+}
+
+// Stdout returns lines of standard output
+//   - with a pseudo-terminal, all terminal output
+//   - closes when the process exited and output was read
+func (p *Process) Stdout() (lines *parl.AwaitableSlice[string]) { return &p.stdout }
+
+// Stderr returns lines of standard error
+//   - empty with a pseudo-terminal
+//   - closes when the process exited and output was read
+func (p *Process) Stderr() (lines *parl.AwaitableSlice[string]) { return &p.stderr }
+
+// Pid returns the process ID
+func (p *Process) Pid() (pid int) { return p.cmd.Process.Pid }
+
+// Signal sends a signal to the process
+func (p *Process) Signal(signal os.Signal) (err error) {
+	if err = p.cmd.Process.Signal(signal); perrors.IsPF(&err, "signal %s: %w", signal, err) {
+		return
+	}
+	return
+}
+
+// ExitCh returns a channel that closes when the process exited
+// and output was read
+func (p *Process) ExitCh() (ch parl.AwaitableCh) { return p.isExit.Ch() }
+
+// Wait awaits process exit
+//   - statusCode: exit status, [TerminatedBySignal] -1 if terminated by signal
+//   - err: nil on status code 0. Otherwise an error wrapping [exec.ExitError]
+//     interpreted by [NewExitErrorData]. Classes: [perrors.ClassCanceled] on context cancel,
+//     [perrors.ClassNotFound] if the command was not found
+func (p *Process) Wait() (statusCode int, err error) {
+	<-p.isExit.Ch()
+	return p.statusCode, p.err
+}
+
+// waitThread awaits process exit
+func (p *Process) waitThread() {
+	defer p.isExit.Close()
+
+	p.end(p.cmd.Wait())
+}
+
+// end closes output streams and stores exit status
+func (p *Process) end(err error) {
+	if p.pty != nil {
+		// await terminal output, bounded by grace period
+		var timer = time.NewTimer(p.cmd.WaitDelay)
+		select {
+		case <-p.ptyRead.Ch():
+		case <-timer.C:
+		}
+		timer.Stop()
+		p.pty.Close()
+		<-p.ptyRead.Ch()
+	} else {
+		p.stdoutWriter.flush()
+		p.stderrWriter.flush()
+	}
+	p.stdout.EmptyCh()
+	p.stderr.EmptyCh()
+
+	if state := p.cmd.ProcessState; state != nil {
+		p.statusCode = state.ExitCode()
+	}
+	if err != nil {
+		err = p.classify(perrors.ErrorfPF("%q: %w", p.args[0], err))
+	}
+	p.err = err
+}
+
+// classify tags err with classes
+func (p *Process) classify(err error) (err2 error) {
+	err2 = err
+	if p.ctx.Err() != nil {
+		err2 = perrors.WithClass(err2, perrors.ClassCanceled)
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		err2 = perrors.WithClass(err2, perrors.ClassNotFound)
+	}
+	return
+}
+
+// ptyReadThread streams terminal output into stdout
+//   - ends on read error: EIO once the process exited or pty close
+func (p *Process) ptyReadThread() {
+	var err error
+	defer p.ptyRead.Close()
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	var writer = lineWriter{lines: &p.stdout}
+	defer writer.flush()
+	var buffer = make([]byte, 4096)
+	for {
+		var n int
+		n, err = p.pty.Read(buffer)
+		if n > 0 {
+			writer.Write(buffer[:n])
+		}
+		if err != nil {
+			err = nil // EIO or closed
+			return
+		}
+	}
+}
+
+// ptyWriteThread copies stdin to the terminal
+func (p *Process) ptyWriteThread(stdin io.Reader) {
+	var err error
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	io.Copy(p.pty, stdin)
+}
+
+// lineWriter is an io.Writer sending lines to an AwaitableSlice
+//   - line terminators “\n” “\r\n” are removed
+//   - thread-safe
+type lineWriter struct {
+	lines *parl.AwaitableSlice[string]
+	lock  sync.Mutex
+	// partial is an incomplete last line, behind lock
+	partial []byte
+}
+
+// Write sends complete lines
+func (w *lineWriter) Write(p []byte) (n int, err error) {
+	n = len(p)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for len(p) > 0 {
+		var index = bytes.IndexByte(p, '\n')
+		if index == -1 {
+			w.partial = append(w.partial, p...)
+			return
+		}
+		var line = append(w.partial, p[:index]...)
+		w.partial = w.partial[:0]
+		w.lines.Send(string(bytes.TrimSuffix(line, []byte{'\r'})))
+		p = p[index+1:]
+	}
+
+	return
+}
+
+// flush sends any incomplete last line
+func (w *lineWriter) flush() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(w.partial) > 0 {
+		w.lines.Send(string(bytes.TrimSuffix(w.partial, []byte{'\r'})))
+		w.partial = nil
+	}
+}
@@ -0,0 +1,68 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pexec
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestSpawn(t *testing.T) {
+	//t.Error("Logging on")
+	var expStdout = []string{"a", "c"}
+	var expStderr = []string{"b"}
+
+	// output streaming
+	var process, err = Spawn(context.Background(), ProcessConfig{}, "sh", "-c", "echo a; echo b >&2; printf c")
+	if err != nil {
+		t.Fatalf("Spawn err: %s", err)
+	}
+	var statusCode int
+	if statusCode, err = process.Wait(); err != nil || statusCode != 0 {
+		t.Fatalf("Wait %d err: %s", statusCode, err)
+	}
+	if lines := process.Stdout().GetAll(); !slices.Equal(lines, expStdout) {
+		t.Errorf("stdout %q exp %q", lines, expStdout)
+	}
+	if lines := process.Stderr().GetAll(); !slices.Equal(lines, expStderr) {
+		t.Errorf("stderr %q exp %q", lines, expStderr)
+	}
+	if !process.Stdout().IsClosed() {
+		t.Error("stdout not closed")
+	}
+
+	// exit status
+	process, _ = Spawn(context.Background(), ProcessConfig{}, "sh", "-c", "exit 3")
+	if statusCode, err = process.Wait(); statusCode != 3 || !NewExitErrorData(err).IsExitError() {
+		t.Errorf("exit 3: %d err: %v", statusCode, err)
+	}
+
+	// command not found
+	if _, err = Spawn(context.Background(), ProcessConfig{}, "/nonexistent-command"); !perrors.HasClass(err, perrors.ClassNotFound) {
+		t.Errorf("not found err: %v", err)
+	}
+
+	// SIGTERM ignored: SIGKILL after grace period
+	var ctx, cancel = context.WithCancel(context.Background())
+	process, err = Spawn(ctx, ProcessConfig{GracePeriod: 100 * time.Millisecond}, "sh", "-c", `trap "" TERM; echo ready; sleep 10`)
+	if err != nil {
+		t.Fatalf("Spawn err: %s", err)
+	}
+	process.Stdout().AwaitValue()
+	var t0 = time.Now()
+	cancel()
+	statusCode, err = process.Wait()
+	if elapsed := time.Since(t0); elapsed > 5*time.Second {
+		t.Errorf("kill took %s", elapsed)
+	}
+	if statusCode != TerminatedBySignal || !perrors.HasClass(err, perrors.ClassCanceled) {
+		t.Errorf("cancel: %d err: %v", statusCode, err)
+	}
+}
@@ -0,0 +1,250 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pflags
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/pstrings"
+)
+
+const (
+	// SourceDefault: the option has its default value
+	SourceDefault ConfigSource = iota
+	// SourceYaml: the option value is from a yaml file
+	SourceYaml
+	// SourceEnvironment: the option value is from an environment variable
+	SourceEnvironment
+	// SourceCommandLine: the option value is from the command line
+	SourceCommandLine
+)
+
+// ConfigSource is where an option’s effective value came from
+//   - [SourceDefault] [SourceYaml] [SourceEnvironment] [SourceCommandLine]
+type ConfigSource uint8
+
+var configSourceStrings = map[ConfigSource]string{
+	SourceDefault:     "default",
+	SourceYaml:        "yaml",
+	SourceEnvironment: "env",
+	SourceCommandLine: "command-line",
+}
+
+func (s ConfigSource) String() (s2 string) {
+	if s2 = configSourceStrings[s]; s2 == "" {
+		s2 = "?" + strconv.Itoa(int(s))
+	}
+	return
+}
+
+// ConfigLayers resolves option values in priority order:
+// command line > environment variables > yaml > defaults
+//   - environment variable names are derived from program and option name:
+//     program “gonet” option “yamlFile” is “GONET_YAML_FILE”
+//   - an off-flag “no-stdin” is “GONET_NO_STDIN=true”
+//   - slice options from environment are comma-separated
+//   - [ConfigLayers.Report] shows which source supplied each value
+//
+// Usage:
+//
+//	pflags.NewArgParser(optionData, usage).Parse()
+//	var layers = pflags.NewConfigLayers(program, optionData)
+//	err = yamlo.ApplyYaml(program, …, optionData, layers)
+//	err = layers.ApplyEnvironment()
+//	parl.Debug(layers.Report())
+type ConfigLayers struct {
+	// envPrefix is uppercase program name with trailing underscore “GONET_”
+	envPrefix  string
+	optionData []OptionData
+	// sources is the source of each option by name
+	sources map[string]ConfigSource
+	// lookupEnv is [os.LookupEnv]
+	lookupEnv func(key string) (value string, ok bool)
+}
+
+// NewConfigLayers returns configuration layering for optionData
+//   - invoked after options were parsed by [ArgParser.Parse]:
+//     options present on the command line have [SourceCommandLine]
+//   - program: app name like “gonet” used as environment-variable prefix.
+//     empty: no prefix
+func NewConfigLayers(program string, optionData []OptionData) (layers *ConfigLayers) {
+	layers = &ConfigLayers{
+		optionData: optionData,
+		sources:    make(map[string]ConfigSource, len(optionData)),
+		lookupEnv:  os.LookupEnv,
+	}
+	if program != "" {
+		layers.envPrefix = envWord(program) + "_"
+	}
+	for name := range NewVisitedOptions().Map() {
+		layers.sources[name] = SourceCommandLine
+	}
+
+	return
+}
+
+// EnvName returns the environment-variable name for an option
+//   - “yamlFile” → “GONET_YAML_FILE”
+func (c *ConfigLayers) EnvName(optionName string) (envName string) {
+	return c.envPrefix + envWord(optionName)
+}
+
+// SetSource records the source of an option’s effective value
+//   - used by [yamlo.ApplyYaml]
+func (c *ConfigLayers) SetSource(optionName string, source ConfigSource) {
+	c.sources[optionName] = source
+}
+
+// Source returns where an option’s effective value came from
+func (c *ConfigLayers) Source(optionName string) (source ConfigSource) {
+	return c.sources[optionName]
+}
+
+// IsCommandLine returns true if the option was provided on the command line
+func (c *ConfigLayers) IsCommandLine(optionName string) (isCommandLine bool) {
+	return c.sources[optionName] == SourceCommandLine
+}
+
+// ApplyEnvironment updates options not provided on the command line
+// from environment variables
+//   - invoked after yaml was applied so that environment overrides yaml
+//   - err: an environment variable value could not be parsed
+func (c *ConfigLayers) ApplyEnvironment() (err error) {
+	for i := range c.optionData {
+		var o = &c.optionData[i]
+		if c.IsCommandLine(o.Name) {
+			continue // command line has priority
+		}
+		var envName = c.EnvName(o.Name)
+		var value, ok = c.lookupEnv(envName)
+		if !ok {
+			continue
+		}
+		if err = o.setFromString(value); err != nil {
+			err = perrors.ErrorfPF("environment %s: %w", envName, err)
+			return
+		}
+		c.sources[o.Name] = SourceEnvironment
+	}
+
+	return
+}
+
+// Report returns a table of option values and their source
+//
+//	option   value  source  env
+//	debug    true   env     GONET_DEBUG
+func (c *ConfigLayers) Report() (report string) {
+	var table = pstrings.NewTable(
+		pstrings.TableColumn{Heading: "option"},
+		pstrings.TableColumn{Heading: "value", MaxWidth: 40},
+		pstrings.TableColumn{Heading: "source"},
+		pstrings.TableColumn{Heading: "env"},
+	)
+	for i := range c.optionData {
+		var o = &c.optionData[i]
+		table.AddRow(o.Name, o.ValueDump(), c.sources[o.Name].String(), c.EnvName(o.Name))
+	}
+
+	return table.String()
+}
+
+// setFromString assigns the effective value from a string
+//   - an off-flag, ie. boolean option with default value true,
+//     is set to false by “true”
+func (o *OptionData) setFromString(s string) (err error) {
+	switch valuePointer := o.P.(type) {
+	case *bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err != nil {
+			break
+		}
+		if isOffFlag, _ := o.Value.(bool); isOffFlag {
+			b = !b
+		}
+		*valuePointer = b
+	case *time.Duration:
+		var d time.Duration
+		if d, err = time.ParseDuration(s); err == nil {
+			*valuePointer = d
+		}
+	case *float64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, 64); err == nil {
+			*valuePointer = f
+		}
+	case *int64:
+		var i int64
+		if i, err = strconv.ParseInt(s, 0, 64); err == nil {
+			*valuePointer = i
+		}
+	case *int:
+		var i int64
+		if i, err = strconv.ParseInt(s, 0, strconv.IntSize); err == nil {
+			*valuePointer = int(i)
+		}
+	case *string:
+		*valuePointer = s
+	case *uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(s, 0, 64); err == nil {
+			*valuePointer = u
+		}
+	case *uint:
+		var u uint64
+		if u, err = strconv.ParseUint(s, 0, strconv.IntSize); err == nil {
+			*valuePointer = uint(u)
+		}
+	case *[]string:
+		var values []string
+		if s != "" {
+			values = strings.Split(s, ",")
+		}
+		*valuePointer = values
+	case flag.Value:
+		err = valuePointer.Set(s)
+	default:
+		err = perrors.Errorf("option %s: unknown value type: %T", o.Name, o.P)
+		return
+	}
+	if err != nil {
+		err = perrors.Errorf("option %s: %w", o.Name, err)
+	}
+
+	return
+}
+
+// envWord returns s as an uppercase environment-variable word
+//   - camel-case is split by underscore, other characters become underscore
+//   - “yamlFile” → “YAML_FILE” “no-stdin” → “NO_STDIN”
+func envWord(s string) (word string) {
+	var sb strings.Builder
+	var prevLower bool
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			if prevLower {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(r)
+			prevLower = false
+		case unicode.IsLower(r) || unicode.IsDigit(r):
+			sb.WriteRune(unicode.ToUpper(r))
+			prevLower = true
+		default:
+			sb.WriteByte('_')
+			prevLower = false
+		}
+	}
+
+	return sb.String()
+}
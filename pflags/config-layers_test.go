@@ -0,0 +1,93 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pflags
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigLayers(t *testing.T) {
+	//t.Error("Logging on")
+	const program = "gonet"
+	var (
+		options struct {
+			timeout time.Duration
+			doStdin bool
+			names   []string
+			count   int
+		}
+		env = map[string]string{
+			"GONET_TIMEOUT":  "3s",
+			"GONET_NO_STDIN": "true",
+			"GONET_NAMES":    "a,b",
+		}
+		expNames = []string{"a", "b"}
+	)
+	var optionData = []OptionData{
+		{P: &options.timeout, Name: "timeout", Value: time.Second},
+		{P: &options.doStdin, Name: "no-stdin", Value: true},
+		{P: &options.names, Name: "names", Value: []string(nil)},
+		{P: &options.count, Name: "count", Value: 0},
+	}
+	options.doStdin = true
+
+	var layers = NewConfigLayers(program, optionData)
+	layers.lookupEnv = func(key string) (value string, ok bool) {
+		value, ok = env[key]
+		return
+	}
+
+	// EnvName
+	if s, exp := layers.EnvName("yamlFile"), "GONET_YAML_FILE"; s != exp {
+		t.Errorf("EnvName %q exp %q", s, exp)
+	}
+
+	// yaml then environment
+	layers.SetSource("count", SourceYaml)
+	if err := layers.ApplyEnvironment(); err != nil {
+		t.Fatalf("ApplyEnvironment err: %s", err)
+	}
+	if options.timeout != 3*time.Second {
+		t.Errorf("timeout %s exp 3s", options.timeout)
+	}
+	if options.doStdin {
+		t.Error("off-flag no-stdin not applied")
+	}
+	if !slices.Equal(options.names, expNames) {
+		t.Errorf("names %v exp %v", options.names, expNames)
+	}
+	if s := layers.Source("timeout"); s != SourceEnvironment {
+		t.Errorf("Source timeout %s exp %s", s, SourceEnvironment)
+	}
+	if s := layers.Source("count"); s != SourceYaml {
+		t.Errorf("Source count %s exp %s", s, SourceYaml)
+	}
+
+	// command line has priority
+	layers.SetSource("timeout", SourceCommandLine)
+	options.timeout = time.Minute
+	if err := layers.ApplyEnvironment(); err != nil {
+		t.Fatalf("ApplyEnvironment err: %s", err)
+	}
+	if options.timeout != time.Minute {
+		t.Errorf("timeout %s exp 1m", options.timeout)
+	}
+
+	// bad value
+	env["GONET_COUNT"] = "x"
+	if err := layers.ApplyEnvironment(); err == nil {
+		t.Error("ApplyEnvironment missing error")
+	}
+
+	// Report
+	var report = layers.Report()
+	if !strings.Contains(report, "GONET_NO_STDIN") || !strings.Contains(report, "command-line") {
+		t.Errorf("Report:\n%s", report)
+	}
+}
@@ -139,6 +139,13 @@ func (o *OptionData) AddOption() {
 				o.yamlPointerPanic()
 			}
 		}
+	case flag.Value:
+		// custom value type like [OptionalString]
+		//	- default value is the flag.Value’s initial state
+		flag.Var(effectiveValuep, o.Name, o.Usage)
+		if o.Y != nil {
+			o.yamlPointerPanic()
+		}
 	default:
 		panic(perrors.ErrorfPF("option '%s' Unknown options type: %T", o.Name, effectiveValuep))
 	}
@@ -245,6 +252,8 @@ func (o *OptionData) ValueDump() (valueS string) {
 		return fmt.Sprintf("%v", *effectiveValuep)
 	case *[]string:
 		return fmt.Sprintf("%v", *effectiveValuep)
+	case flag.Value:
+		return effectiveValuep.String()
 	default:
 		panic(perrors.ErrorfPF("option '%s' Unknown options type: %T", o.Name, effectiveValuep))
 	}
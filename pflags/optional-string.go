@@ -0,0 +1,82 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pflags
+
+import "flag"
+
+const (
+	// boolFlagTrue is the value the flag package provides to Set for
+	// a boolean flag without value
+	boolFlagTrue = "true"
+	// boolFlagFalse “-pprof=false” unsets the option
+	boolFlagFalse = "false"
+)
+
+// OptionalString is a string option whose value is optional
+//   - “-pprof” sets the option using its default value
+//   - “-pprof=localhost:6060” sets the option using the provided value
+//   - usable as [OptionData.P] with Value nil
+//   - “-pprof=false” unsets the option. Values “true” and “false” cannot be provided
+//
+// Usage:
+//
+//	var pprof = pflags.NewOptionalString("localhost:6060")
+//	var optionData = []pflags.OptionData{
+//	  {P: pprof, Name: "pprof", Usage: "serve profiling: -pprof[=addr]"},
+//	}
+//	…
+//	if pprof.IsSet() {
+//	  listen(pprof.Value())
+type OptionalString struct {
+	defaultValue string
+	value        string
+	isSet        bool
+}
+
+// NewOptionalString returns a string option with optional value
+//   - defaultValue: the value used when option is present without value
+func NewOptionalString(defaultValue string) (optionalString *OptionalString) {
+	return &OptionalString{defaultValue: defaultValue}
+}
+
+// OptionalString is a boolean-like flag.Value
+var _ interface {
+	flag.Value
+	IsBoolFlag() (isBoolFlag bool)
+} = &OptionalString{}
+
+// IsSet returns true if the option was provided
+func (o *OptionalString) IsSet() (isSet bool) { return o.isSet }
+
+// Value returns the provided value or default value
+//   - empty string if the option was not provided
+func (o *OptionalString) Value() (value string) { return o.value }
+
+// Set is invoked by the flag package for each occurrence of the option
+func (o *OptionalString) Set(value string) (err error) {
+	switch value {
+	case boolFlagTrue:
+		value = o.defaultValue
+	case boolFlagFalse:
+		o.value = ""
+		o.isSet = false
+		return
+	}
+	o.value = value
+	o.isSet = true
+	return
+}
+
+// String returns the value for printing
+func (o *OptionalString) String() (s string) {
+	if o == nil {
+		return
+	}
+	return o.value
+}
+
+// IsBoolFlag allows the option to be present without value
+func (o *OptionalString) IsBoolFlag() (isBoolFlag bool) { return true }
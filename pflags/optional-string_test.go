@@ -0,0 +1,36 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pflags
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestOptionalString(t *testing.T) {
+	//t.Error("Logging on")
+	const defaultValue, value = "localhost:6060", ":7070"
+	for _, tc := range []struct {
+		args     []string
+		isSet    bool
+		expValue string
+	}{
+		{nil, false, ""},
+		{[]string{"-pprof"}, true, defaultValue},
+		{[]string{"-pprof=" + value}, true, value},
+		{[]string{"-pprof=false"}, false, ""},
+	} {
+		var o = NewOptionalString(defaultValue)
+		var flagSet = flag.NewFlagSet("test", flag.ContinueOnError)
+		flagSet.Var(o, "pprof", "")
+		if err := flagSet.Parse(tc.args); err != nil {
+			t.Fatalf("Parse %v err: %s", tc.args, err)
+		}
+		if o.IsSet() != tc.isSet || o.Value() != tc.expValue {
+			t.Errorf("%v: IsSet %t Value %q exp %t %q", tc.args, o.IsSet(), o.Value(), tc.isSet, tc.expValue)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfmt
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// cappedNil is printed for nil values like %v
+	cappedNil = "<nil>"
+	// defaultCappedDepth is the depth used for maxDepth 0
+	defaultCappedDepth = 32
+)
+
+// errCappedLength aborts formatting once maxLength is exceeded
+var errCappedLength = errors.New("length exceeded")
+
+// Capped returns %v-like output of value limited in depth and length
+//   - used for logging large or deeply nested structures safely
+//   - maxDepth: nested structs, slices, maps and pointers beyond maxDepth
+//     are printed “…”. 0: 32
+//   - maxLength: output longer than maxLength bytes is truncated and
+//     ends with [Ellipsis]. 0: no limit
+//   - like [NoRecurseVPrint], String and Error methods are not invoked,
+//     so Capped can be used inside String methods
+//   - pointers are followed with prefix “&”, cycles are bounded by maxDepth
+//   - map keys are sorted by their printed form
+func Capped(value any, maxDepth, maxLength int) (s string) {
+	if maxDepth < 1 {
+		maxDepth = defaultCappedDepth
+	}
+	var c = capped{maxDepth: maxDepth, maxLength: maxLength}
+	if err := c.print(reflect.ValueOf(value), 0); err != nil {
+		// truncate at rune boundary
+		var out = c.b.String()[:maxLength]
+		for len(out) > 0 && !utf8.ValidString(out) {
+			out = out[:len(out)-1]
+		}
+		return out + Ellipsis
+	}
+	return c.b.String()
+}
+
+// capped is the state of a [Capped] invocation
+type capped struct {
+	maxDepth, maxLength int
+	b                   strings.Builder
+}
+
+// print writes value at depth
+//   - err: errCappedLength if maxLength was exceeded
+func (c *capped) print(value reflect.Value, depth int) (err error) {
+	if !value.IsValid() {
+		return c.write(cappedNil)
+	}
+	switch value.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map, reflect.Pointer, reflect.Interface:
+		if depth >= c.maxDepth {
+			return c.write(Ellipsis)
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return c.write(strconv.FormatBool(value.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return c.write(strconv.FormatInt(value.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return c.write(strconv.FormatUint(value.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		return c.write(strconv.FormatFloat(value.Float(), 'g', -1, value.Type().Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		return c.write(strconv.FormatComplex(value.Complex(), 'g', -1, value.Type().Bits()))
+	case reflect.String:
+		return c.write(value.String())
+	case reflect.Interface:
+		if value.IsNil() {
+			return c.write(cappedNil)
+		}
+		return c.print(value.Elem(), depth)
+	case reflect.Pointer:
+		if value.IsNil() {
+			return c.write(cappedNil)
+		}
+		if err = c.write("&"); err != nil {
+			return
+		}
+		// a pointer to a composite value is at the depth of the pointer
+		switch value.Elem().Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+			return c.print(value.Elem(), depth)
+		}
+		return c.print(value.Elem(), depth+1)
+	case reflect.Struct:
+		if err = c.write("{"); err != nil {
+			return
+		}
+		for i := 0; i < value.NumField(); i++ {
+			if i > 0 {
+				if err = c.write(" "); err != nil {
+					return
+				}
+			}
+			if err = c.print(value.Field(i), depth+1); err != nil {
+				return
+			}
+		}
+		return c.write("}")
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return c.write("[]")
+		}
+		if err = c.write("["); err != nil {
+			return
+		}
+		for i := 0; i < value.Len(); i++ {
+			if i > 0 {
+				if err = c.write(" "); err != nil {
+					return
+				}
+			}
+			if err = c.print(value.Index(i), depth+1); err != nil {
+				return
+			}
+		}
+		return c.write("]")
+	case reflect.Map:
+		return c.printMap(value, depth)
+	}
+
+	// Chan Func UnsafePointer
+	if value.IsNil() {
+		return c.write(cappedNil)
+	}
+	return c.write("0x" + strconv.FormatUint(uint64(value.Pointer()), 16))
+}
+
+// printMap writes a map with keys sorted by printed form
+func (c *capped) printMap(value reflect.Value, depth int) (err error) {
+	type entry struct{ key, value string }
+	var entries = make([]entry, 0, value.Len())
+	for iter := value.MapRange(); iter.Next(); {
+		var k = capped{maxDepth: c.maxDepth, maxLength: c.maxLength}
+		var v = capped{maxDepth: c.maxDepth, maxLength: c.maxLength}
+		// errors are ignored: entries are truncated when written
+		k.print(iter.Key(), depth+1)
+		v.print(iter.Value(), depth+1)
+		entries = append(entries, entry{key: k.b.String(), value: v.b.String()})
+	}
+	slices.SortFunc(entries, func(a, b entry) (result int) { return strings.Compare(a.key, b.key) })
+
+	if err = c.write("map["); err != nil {
+		return
+	}
+	for i, e := range entries {
+		if i > 0 {
+			if err = c.write(" "); err != nil {
+				return
+			}
+		}
+		if err = c.write(e.key + ":" + e.value); err != nil {
+			return
+		}
+	}
+	return c.write("]")
+}
+
+// write appends s to output
+//   - err: errCappedLength if maxLength was exceeded
+func (c *capped) write(s string) (err error) {
+	c.b.WriteString(s)
+	if c.maxLength > 0 && c.b.Len() > c.maxLength {
+		err = errCappedLength
+	}
+	return
+}
@@ -0,0 +1,51 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfmt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// cappedNode has a String method that would recurse with %v
+type cappedNode struct {
+	Name  string
+	Next  *cappedNode
+	Items []int
+	m     map[string]int
+}
+
+func (n *cappedNode) String() (s string) { return Capped(n, 2, 0) }
+
+func TestCapped(t *testing.T) {
+	//t.Error("Logging on")
+	var node = &cappedNode{Name: "a", Items: []int{1, 2}, m: map[string]int{"y": 2, "x": 1}}
+	node.Next = node // cycle
+
+	// unlimited depth for plain values should be like %v
+	var plain = struct {
+		a int
+		b []string
+		c any
+	}{1, []string{"x"}, nil}
+	if s, exp := Capped(plain, 0, 0), fmt.Sprintf("%v", plain); s != exp {
+		t.Errorf("Capped %q exp %q", s, exp)
+	}
+
+	// depth should bound the cycle and String should not recurse
+	var s = node.String()
+	var exp = "&{a &{a … … …} [1 2] map[x:1 y:2]}"
+	if s != exp {
+		t.Errorf("Capped depth %q exp %q", s, exp)
+	}
+
+	// length
+	var long = strings.Repeat("x", 100)
+	if s = Capped([]string{long}, 0, 10); s != "["+long[:9]+Ellipsis {
+		t.Errorf("Capped length %q", s)
+	}
+}
@@ -3,7 +3,9 @@
 ISC License
 */
 
-// Package pfmt provides an [fmt.Printf] %v function that does not use the [fmt.Stringer.String] method
+// Package pfmt provides an [fmt.Printf] %v function that does not use the [fmt.Stringer.String] method,
+// depth and length capped %v [Capped], grapheme-aware column width [Width] [PadRight] [Truncate]
+// and locale digit grouping [Locale] [Grouped]
 package pfmt
 
 import (
@@ -0,0 +1,59 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfmt
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// printers is a cache of message printers by language tag
+var printers sync.Map // map[language.Tag]*message.Printer
+
+// Locale formats value with the digit grouping and decimal mark of tag
+//   - language.English: “1,234,567.5”
+//   - language.German: “1.234.567,5”
+//   - language.French: “1 234 567,5”
+//   - value is typically integer or floating-point.
+//     Other values are formatted like %v
+//   - thread-safe, printers are cached
+func Locale(value any, tag language.Tag) (s string) {
+	var p, ok = printers.Load(tag)
+	if !ok {
+		p, _ = printers.LoadOrStore(tag, message.NewPrinter(tag))
+	}
+	return p.(*message.Printer).Sprint(value)
+}
+
+// Grouped formats n with separator between groups of three digits
+//   - separator: “'” “_” “ ” or any other string
+//   - Grouped(-1234567, "'"): “-1'234'567”
+func Grouped(n int64, separator string) (s string) {
+	var digits = strconv.FormatInt(n, 10)
+	var sign string
+	if n < 0 {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if len(digits) <= 3 {
+		return sign + digits
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	var first = len(digits) % 3
+	if first == 0 {
+		first = 3
+	}
+	b.WriteString(digits[:first])
+	for i := first; i < len(digits); i += 3 {
+		b.WriteString(separator)
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
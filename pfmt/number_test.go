@@ -0,0 +1,40 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfmt
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestGrouped(t *testing.T) {
+	//t.Error("Logging on")
+	for _, tc := range []struct {
+		n   int64
+		exp string
+	}{
+		{0, "0"},
+		{-123, "-123"},
+		{1234, "1'234"},
+		{-1234567, "-1'234'567"},
+		{123456, "123'456"},
+	} {
+		if s := Grouped(tc.n, "'"); s != tc.exp {
+			t.Errorf("Grouped %d %q exp %q", tc.n, s, tc.exp)
+		}
+	}
+}
+
+func TestLocale(t *testing.T) {
+	//t.Error("Logging on")
+	if s := Locale(1234567, language.English); s != "1,234,567" {
+		t.Errorf("Locale English %q", s)
+	}
+	if s := Locale(1234567, language.German); s != "1.234.567" {
+		t.Errorf("Locale German %q", s)
+	}
+}
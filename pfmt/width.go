@@ -0,0 +1,143 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfmt
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+const (
+	// zeroWidthJoiner joins emoji into a single grapheme: “👩‍💻”
+	zeroWidthJoiner = '\u200d'
+	// Ellipsis marks truncated output “…”
+	Ellipsis = "…"
+)
+
+// Graphemes returns the number of grapheme clusters in s
+//   - a grapheme cluster is what a user perceives as a character:
+//     “é” as “e” and a combining accent is one grapheme
+//   - clusters are an approximation of Unicode extended grapheme clusters:
+//     combining marks, variation selectors, emoji modifiers,
+//     zero-width-joiner sequences, regional-indicator flags and CRLF
+func Graphemes(s string) (n int) {
+	for len(s) > 0 {
+		s = s[nextGrapheme(s):]
+		n++
+	}
+	return
+}
+
+// Width returns the number of terminal columns of s
+//   - each grapheme cluster is one column
+//   - East Asian wide and full-width grapheme clusters are two columns
+func Width(s string) (columns int) {
+	for len(s) > 0 {
+		var size = nextGrapheme(s)
+		columns += graphemeWidth(s[:size])
+		s = s[size:]
+	}
+	return
+}
+
+// PadRight left-aligns s in a column of columns width
+//   - s wider than columns is returned unchanged
+func PadRight(s string, columns int) (padded string) {
+	if pad := columns - Width(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// PadLeft right-aligns s in a column of columns width
+//   - s wider than columns is returned unchanged
+func PadLeft(s string, columns int) (padded string) {
+	if pad := columns - Width(s); pad > 0 {
+		return strings.Repeat(" ", pad) + s
+	}
+	return s
+}
+
+// Truncate returns s shortened to at most columns width
+//   - a truncated s ends with [Ellipsis]
+//   - grapheme clusters are not split
+func Truncate(s string, columns int) (truncated string) {
+	if Width(s) <= columns {
+		return s
+	} else if columns < 1 {
+		return
+	}
+	var used int
+	var end int
+	for rest := s; len(rest) > 0; {
+		var size = nextGrapheme(rest)
+		var w = graphemeWidth(rest[:size])
+		if used+w > columns-1 {
+			break // room for ellipsis
+		}
+		used += w
+		end += size
+		rest = rest[size:]
+	}
+	return s[:end] + Ellipsis
+}
+
+// nextGrapheme returns the byte length of the grapheme cluster beginning s
+func nextGrapheme(s string) (size int) {
+	var r, n = utf8.DecodeRuneInString(s)
+	size = n
+
+	// CRLF
+	if r == '\r' && strings.HasPrefix(s[size:], "\n") {
+		return size + 1
+	}
+	// a flag is a pair of regional indicators
+	if isRegionalIndicator(r) {
+		if r2, n2 := utf8.DecodeRuneInString(s[size:]); isRegionalIndicator(r2) {
+			size += n2
+		}
+	}
+
+	// extending runes
+	var isJoined bool
+	for size < len(s) {
+		var r2, n2 = utf8.DecodeRuneInString(s[size:])
+		if !isJoined && !isExtend(r2) {
+			break
+		}
+		isJoined = r2 == zeroWidthJoiner
+		size += n2
+	}
+
+	return
+}
+
+// graphemeWidth returns the columns of a single grapheme cluster
+func graphemeWidth(grapheme string) (columns int) {
+	var r, _ = utf8.DecodeRuneInString(grapheme)
+	if unicode.IsControl(r) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// isExtend returns true if r extends the preceding grapheme cluster
+func isExtend(r rune) (is bool) {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == zeroWidthJoiner ||
+		r >= '\ufe00' && r <= '\ufe0f' || // variation selectors
+		r >= '\U0001f3fb' && r <= '\U0001f3ff' // emoji skin-tone modifiers
+}
+
+// isRegionalIndicator returns true for runes of flag pairs
+func isRegionalIndicator(r rune) (is bool) { return r >= '\U0001f1e6' && r <= '\U0001f1ff' }
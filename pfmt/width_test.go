@@ -0,0 +1,54 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfmt
+
+import "testing"
+
+func TestWidth(t *testing.T) {
+	//t.Error("Logging on")
+	var (
+		// “e” with combining acute accent
+		combining = "é"
+		// woman technologist: zero-width-joiner sequence
+		zwj = "\U0001f469‍\U0001f4bb"
+		// Swedish flag: regional-indicator pair
+		flag = "\U0001f1f8\U0001f1ea"
+		// East Asian wide
+		wide = "日本"
+	)
+
+	for _, tc := range []struct {
+		s                 string
+		graphemes, widthN int
+	}{
+		{"abc", 3, 3},
+		{combining + "x", 2, 2},
+		{zwj, 1, 2},
+		{flag + "!", 2, 2},
+		{wide, 2, 4},
+		{"a\r\nb", 3, 2},
+	} {
+		if n := Graphemes(tc.s); n != tc.graphemes {
+			t.Errorf("Graphemes %q %d exp %d", tc.s, n, tc.graphemes)
+		}
+		if n := Width(tc.s); n != tc.widthN {
+			t.Errorf("Width %q %d exp %d", tc.s, n, tc.widthN)
+		}
+	}
+
+	if s := PadRight(combining, 3); s != combining+"  " {
+		t.Errorf("PadRight %q", s)
+	}
+	if s := PadLeft(wide, 5); s != " "+wide {
+		t.Errorf("PadLeft %q", s)
+	}
+	if s := Truncate("abc"+combining+"def", 5); s != "abc"+combining+Ellipsis {
+		t.Errorf("Truncate %q", s)
+	}
+	if s := Truncate("abc", 3); s != "abc" {
+		t.Errorf("Truncate %q", s)
+	}
+}
@@ -0,0 +1,481 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+var (
+	// ErrUnsafePath is returned by [ExtractArchive] for an entry or
+	// link target outside the destination directory: zip slip
+	ErrUnsafePath = errors.New("unsafe archive path")
+	// ErrArchiveTooLarge is returned by [ExtractArchive] when
+	// content exceeds [ExtractConfig.MaxBytes]
+	ErrArchiveTooLarge = errors.New("archive content too large")
+)
+
+const (
+	// extractDirPerm is permissions of directories created implicitly
+	extractDirPerm fs.FileMode = 0o755
+)
+
+// ExtractConfig configures [ExtractArchive]
+type ExtractConfig struct {
+	// Format is the archive format, default [ArchiveTar]
+	Format ArchiveFormat
+	// PreservePermissions applies archived permissions exactly,
+	// including setuid setgid sticky bits
+	//	- default: permission bits are subject to umask
+	PreservePermissions bool
+	// PreserveOwner applies archived user and group IDs
+	//	- tar only, typically requires privileges
+	PreserveOwner bool
+	// PreserveXattrs applies extended attributes stored as PAX records
+	//	- tar on Linux only
+	PreserveXattrs bool
+	// MaxBytes is the limit of extracted file content
+	//	- 0: no limit
+	//	- protects against decompression bombs
+	MaxBytes int64
+	// Progress is optionally invoked after each entry
+	Progress func(progress ArchiveProgress)
+}
+
+// ExtractArchive extracts a tar or zip archive into directory dir
+//   - dir is created if it does not exist
+//   - entries and link targets resolving outside dir fail with [ErrUnsafePath]
+//     prior to any file being written for the entry: zip-slip protection
+//   - modification times are restored
+//   - sockets, devices and named pipes are skipped
+//   - r: zip requires r to implement [io.ReaderAt] and have
+//     Size like [bytes.Reader] or Stat like [os.File]
+//   - ctx: canceling ctx aborts with ctx’s error
+//   - config: optional format, preservation options, size limit and progress
+func ExtractArchive(ctx context.Context, r io.Reader, dir string, config ...ExtractConfig) (err error) {
+	var c ExtractConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if err = os.MkdirAll(dir, extractDirPerm); perrors.IsPF(&err, "extract %w", err) {
+		return
+	}
+	var x = extractor{ctx: ctx, dir: dir, config: &c}
+	if x.realDir, err = filepath.EvalSymlinks(dir); perrors.IsPF(&err, "extract %w", err) {
+		return
+	}
+	switch c.Format {
+	case ArchiveTar:
+		err = x.tar(r)
+	case ArchiveTarGzip:
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(r); err != nil {
+			break
+		}
+		err = x.tar(gz)
+	case ArchiveZip:
+		err = x.zip(r)
+	default:
+		err = perrors.Errorf("bad format %s", c.Format)
+	}
+	if perrors.IsPF(&err, "extract %q: %w", dir, err) {
+		return
+	}
+
+	// directory times are restored last: extracting entries modifies them
+	for _, d := range x.dirTimes {
+		if err = os.Chtimes(d.path, d.modTime, d.modTime); perrors.IsPF(&err, "extract %w", err) {
+			return
+		}
+	}
+
+	return
+}
+
+// extractor extracts entries of one archive
+type extractor struct {
+	ctx    context.Context
+	dir    string
+	config *ExtractConfig
+	// realDir is dir with symlinks resolved
+	realDir string
+	// progress is accumulated progress
+	progress ArchiveProgress
+	// dirTimes are directory modification times restored at end
+	dirTimes []dirTime
+}
+
+// dirTime is a directory modification time to restore
+type dirTime struct {
+	path    string
+	modTime time.Time
+}
+
+// archiveEntry is a format-independent archive entry
+type archiveEntry struct {
+	name    string
+	mode    fs.FileMode
+	modTime time.Time
+	// link is symlink target or hard-link name
+	link       string
+	isHardLink bool
+	uid, gid   int
+	xattrs     map[string]string
+	// content is file content or zip symlink target
+	content io.Reader
+}
+
+// tar extracts a tar stream
+func (x *extractor) tar(r io.Reader) (err error) {
+	var tr = tar.NewReader(r)
+	for {
+		var header *tar.Header
+		if header, err = tr.Next(); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return
+		}
+		var entry = archiveEntry{
+			name:    header.Name,
+			mode:    header.FileInfo().Mode(),
+			modTime: header.ModTime,
+			uid:     header.Uid,
+			gid:     header.Gid,
+			content: tr,
+		}
+		switch header.Typeflag {
+		case tar.TypeSymlink:
+			entry.link = header.Linkname
+		case tar.TypeLink:
+			entry.link = header.Linkname
+			entry.isHardLink = true
+		}
+		for key, value := range header.PAXRecords {
+			if name, ok := strings.CutPrefix(key, xattrPAXPrefix); ok {
+				if entry.xattrs == nil {
+					entry.xattrs = make(map[string]string)
+				}
+				entry.xattrs[name] = value
+			}
+		}
+		if err = x.extract(&entry); err != nil {
+			return
+		}
+	}
+}
+
+// zip extracts a zip archive
+func (x *extractor) zip(r io.Reader) (err error) {
+	var readerAt, ok = r.(io.ReaderAt)
+	if !ok {
+		err = perrors.NewPF("zip requires io.ReaderAt")
+		return
+	}
+	var size int64
+	switch sizer := r.(type) {
+	case interface{ Size() int64 }:
+		size = sizer.Size()
+	case interface{ Stat() (fs.FileInfo, error) }:
+		var info fs.FileInfo
+		if info, err = sizer.Stat(); err != nil {
+			return
+		}
+		size = info.Size()
+	default:
+		err = perrors.NewPF("zip requires Size or Stat")
+		return
+	}
+	var zr *zip.Reader
+	if zr, err = zip.NewReader(readerAt, size); err != nil {
+		return
+	}
+	for _, file := range zr.File {
+		if err = x.zipFile(file); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// zipFile extracts a zip entry
+func (x *extractor) zipFile(file *zip.File) (err error) {
+	var entry = archiveEntry{
+		name:    file.Name,
+		mode:    file.Mode(),
+		modTime: file.Modified,
+		uid:     -1,
+		gid:     -1,
+	}
+	if !entry.mode.IsDir() {
+		var rc io.ReadCloser
+		if rc, err = file.Open(); err != nil {
+			return
+		}
+		defer parl.Close(rc, &err)
+		entry.content = rc
+	}
+	if entry.mode.Type() == fs.ModeSymlink {
+		// zip stores the link target as content
+		var target []byte
+		if target, err = io.ReadAll(io.LimitReader(entry.content, 4096)); err != nil {
+			return
+		}
+		entry.link = string(target)
+	}
+
+	return x.extract(&entry)
+}
+
+// extract writes one entry to the file system
+func (x *extractor) extract(entry *archiveEntry) (err error) {
+	if err = x.ctx.Err(); err != nil {
+		err = context.Cause(x.ctx)
+		return
+	}
+	var path string
+	if path, err = x.safePath(entry.name); err != nil {
+		return
+	}
+	if path == x.dir {
+		return // the root entry “./”
+	}
+	if err = x.safeParent(entry.name, path); err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(path), extractDirPerm); err != nil {
+		return
+	}
+
+	var n int64
+	var mode = entry.mode
+	switch {
+	case mode.IsDir():
+		if info, e := os.Lstat(path); e == nil && !info.IsDir() {
+			if err = os.Remove(path); err != nil {
+				return
+			}
+		}
+		if err = os.Mkdir(path, mode.Perm()|0o700); err != nil && !errors.Is(err, fs.ErrExist) {
+			return
+		}
+		err = nil
+		x.dirTimes = append(x.dirTimes, dirTime{path: path, modTime: entry.modTime})
+	case entry.isHardLink:
+		var target string
+		if target, err = x.safePath(entry.link); err != nil {
+			return
+		} else if target, err = x.safeTarget(entry.link, target); err != nil {
+			return
+		}
+		if err = x.remove(path); err != nil {
+			return
+		}
+		if err = os.Link(target, path); err != nil {
+			return
+		}
+	case mode.Type() == fs.ModeSymlink:
+		if err = x.safeLink(entry.name, entry.link); err != nil {
+			return
+		}
+		if err = x.remove(path); err != nil {
+			return
+		}
+		if err = os.Symlink(entry.link, path); err != nil {
+			return
+		}
+		if x.config.PreserveOwner && entry.uid >= 0 {
+			err = os.Lchown(path, entry.uid, entry.gid)
+		}
+		return x.done(entry, n, err)
+	case mode.IsRegular():
+		if n, err = x.writeFile(path, entry); err != nil {
+			return
+		}
+	default:
+		return // special file return
+	}
+
+	// metadata
+	if x.config.PreserveOwner && entry.uid >= 0 {
+		if err = os.Lchown(path, entry.uid, entry.gid); err != nil {
+			return
+		}
+	}
+	if x.config.PreservePermissions && !entry.isHardLink {
+		if err = os.Chmod(path, fsModeToOs(mode)); err != nil {
+			return
+		}
+	}
+	if x.config.PreserveXattrs && len(entry.xattrs) > 0 {
+		if err = writeXattrs(path, entry.xattrs); err != nil {
+			return
+		}
+	}
+	if !mode.IsDir() && !entry.isHardLink {
+		if err = os.Chtimes(path, entry.modTime, entry.modTime); err != nil {
+			return
+		}
+	}
+
+	return x.done(entry, n, nil)
+}
+
+// writeFile writes regular file content enforcing MaxBytes
+func (x *extractor) writeFile(path string, entry *archiveEntry) (n int64, err error) {
+	if err = x.remove(path); err != nil {
+		return
+	}
+	var f *os.File
+	if f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, entry.mode.Perm()); err != nil {
+		return
+	}
+	defer parl.Close(f, &err)
+
+	var r io.Reader = &ctxReader{ctx: x.ctx, r: entry.content}
+	if limit := x.config.MaxBytes; limit > 0 {
+		// read one byte beyond the limit to detect excess
+		r = io.LimitReader(r, limit-x.progress.Bytes+1)
+	}
+	if n, err = io.Copy(f, r); err != nil {
+		return
+	}
+	if limit := x.config.MaxBytes; limit > 0 && x.progress.Bytes+n > limit {
+		err = perrors.ErrorfPF("%q: %w: limit %d", entry.name, ErrArchiveTooLarge, limit)
+	}
+
+	return
+}
+
+// remove removes an existing non-directory at path
+//   - a symlink is replaced rather than written through
+func (x *extractor) remove(path string) (err error) {
+	var info fs.FileInfo
+	if info, err = os.Lstat(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return
+	} else if info.IsDir() {
+		err = perrors.ErrorfPF("%q: is a directory", path)
+		return
+	}
+	return os.Remove(path)
+}
+
+// done updates progress
+func (x *extractor) done(entry *archiveEntry, n int64, err error) (err2 error) {
+	if err != nil {
+		return err
+	}
+	x.progress.Path = strings.TrimSuffix(entry.name, "/")
+	x.progress.Entries++
+	x.progress.Bytes += n
+	if x.config.Progress != nil {
+		x.config.Progress(x.progress)
+	}
+	return
+}
+
+// safePath returns the file-system path of an archive name within dir
+//   - err: [ErrUnsafePath] for absolute names or names escaping dir
+func (x *extractor) safePath(name string) (path string, err error) {
+	var local = filepath.FromSlash(strings.TrimSuffix(name, "/"))
+	if local == "" || local == "." {
+		path = x.dir
+		return // the root entry
+	} else if !filepath.IsLocal(local) {
+		err = perrors.ErrorfPF("%q: %w", name, ErrUnsafePath)
+		return
+	}
+	path = filepath.Join(x.dir, local)
+
+	return
+}
+
+// safeParent verifies that the existing part of path’s parent
+// directories resolves within dir
+//   - prevents writing through links whose targets were lexically local
+//     but resolve outside dir
+func (x *extractor) safeParent(name, path string) (err error) {
+	for parent := filepath.Dir(path); ; parent = filepath.Dir(parent) {
+		var real string
+		if real, err = filepath.EvalSymlinks(parent); err != nil {
+			if errors.Is(err, fs.ErrNotExist) && parent != x.dir {
+				continue // parent not yet created
+			}
+			return
+		}
+		var rel string
+		if rel, err = filepath.Rel(x.realDir, real); err != nil {
+			return
+		} else if rel != "." && !filepath.IsLocal(rel) {
+			err = perrors.ErrorfPF("%q: %w", name, ErrUnsafePath)
+		}
+		return
+	}
+}
+
+// safeTarget returns the hard-link target path with symlinks resolved
+//   - err: [ErrUnsafePath] if target resolves outside dir or
+//     is not a regular file
+//   - prevents linking files outside dir via links whose targets were
+//     lexically local but resolve outside dir
+func (x *extractor) safeTarget(name, path string) (realPath string, err error) {
+	if realPath, err = filepath.EvalSymlinks(path); err != nil {
+		return
+	}
+	var rel string
+	if rel, err = filepath.Rel(x.realDir, realPath); err != nil {
+		return
+	} else if !filepath.IsLocal(rel) {
+		err = perrors.ErrorfPF("link %q: %w", name, ErrUnsafePath)
+		return
+	}
+	var info fs.FileInfo
+	if info, err = os.Lstat(realPath); err != nil {
+		return
+	} else if !info.Mode().IsRegular() {
+		err = perrors.ErrorfPF("link %q: not a regular file: %w", name, ErrUnsafePath)
+	}
+
+	return
+}
+
+// safeLink verifies that the symlink name with target resolves within dir
+//   - as all links are within dir, no entry can be written outside dir
+//     via a previously extracted link
+func (x *extractor) safeLink(name, target string) (err error) {
+	if filepath.IsAbs(target) || strings.HasPrefix(target, "/") {
+		err = perrors.ErrorfPF("%q link %q: %w", name, target, ErrUnsafePath)
+		return
+	}
+	var resolved = filepath.Join(filepath.Dir(filepath.FromSlash(strings.TrimSuffix(name, "/"))), filepath.FromSlash(target))
+	if resolved != "." && !filepath.IsLocal(resolved) {
+		err = perrors.ErrorfPF("%q link %q: %w", name, target, ErrUnsafePath)
+	}
+
+	return
+}
+
+// fsModeToOs returns permissions including setuid setgid sticky for Chmod
+func fsModeToOs(mode fs.FileMode) (osMode fs.FileMode) {
+	return mode & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+}
@@ -0,0 +1,69 @@
+//go:build linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// readXattrs returns the extended attributes of path
+//   - file systems without extended attributes return none
+func readXattrs(path string) (xattrs map[string]string, err error) {
+	var size int
+	if size, err = syscall.Listxattr(path, nil); err != nil {
+		if errors.Is(err, syscall.ENOTSUP) {
+			err = nil
+			return // unsupported file system return
+		}
+		err = perrors.ErrorfPF("listxattr %q: %w", path, err)
+		return
+	} else if size == 0 {
+		return // no attributes return
+	}
+	var names = make([]byte, size)
+	if size, err = syscall.Listxattr(path, names); err != nil {
+		err = perrors.ErrorfPF("listxattr %q: %w", path, err)
+		return
+	}
+	for _, name := range strings.Split(strings.TrimRight(string(names[:size]), "\x00"), "\x00") {
+		if name == "" {
+			continue
+		}
+		var n int
+		if n, err = syscall.Getxattr(path, name, nil); err != nil {
+			err = perrors.ErrorfPF("getxattr %q %s: %w", path, name, err)
+			return
+		}
+		var value = make([]byte, n)
+		if n, err = syscall.Getxattr(path, name, value); err != nil {
+			err = perrors.ErrorfPF("getxattr %q %s: %w", path, name, err)
+			return
+		}
+		if xattrs == nil {
+			xattrs = make(map[string]string)
+		}
+		xattrs[name] = string(value[:n])
+	}
+
+	return
+}
+
+// writeXattrs sets extended attributes of path
+func writeXattrs(path string, xattrs map[string]string) (err error) {
+	for name, value := range xattrs {
+		if err = syscall.Setxattr(path, name, []byte(value), 0); err != nil {
+			err = perrors.ErrorfPF("setxattr %q %s: %w", path, name, err)
+			return
+		}
+	}
+	return
+}
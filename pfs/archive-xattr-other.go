@@ -0,0 +1,14 @@
+//go:build !linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+// readXattrs returns no extended attributes on this platform
+func readXattrs(path string) (xattrs map[string]string, err error) { return }
+
+// writeXattrs ignores extended attributes on this platform
+func writeXattrs(path string, xattrs map[string]string) (err error) { return }
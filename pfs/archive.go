@@ -0,0 +1,333 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// ArchiveTar is an uncompressed tar archive “.tar”
+	ArchiveTar ArchiveFormat = iota
+	// ArchiveTarGzip is a gzip-compressed tar archive “.tar.gz” “.tgz”
+	ArchiveTarGzip
+	// ArchiveZip is a zip archive “.zip”
+	ArchiveZip
+)
+
+// ArchiveFormat is the format of [WriteArchive] [ExtractArchive]
+//   - [ArchiveTar] [ArchiveTarGzip] [ArchiveZip]
+type ArchiveFormat uint8
+
+const (
+	// xattrPAXPrefix is the PAX record prefix of extended attributes
+	xattrPAXPrefix = "SCHILY.xattr."
+)
+
+// ArchiveConfig configures [WriteArchive]
+type ArchiveConfig struct {
+	// Format is the archive format, default [ArchiveTar]
+	Format ArchiveFormat
+	// Include are [path.Match] patterns of files to archive
+	//	- matched against slash-separated relative path and base name
+	//	- empty: all files
+	//	- directories are not subject to Include
+	Include []string
+	// Exclude are [path.Match] patterns of entries not archived
+	//	- matched against slash-separated relative path and base name
+	//	- an excluded directory is not traversed
+	Exclude []string
+	// Xattrs stores extended attributes as PAX records
+	//	- tar on Linux only
+	Xattrs bool
+	// Progress is optionally invoked after each entry
+	Progress func(progress ArchiveProgress)
+}
+
+// ArchiveProgress is a progress event of [WriteArchive] [ExtractArchive]
+type ArchiveProgress struct {
+	// Path is slash-separated relative path of the entry just processed
+	Path string
+	// Entries is number of entries processed so far
+	Entries int
+	// Bytes is number of file-content bytes processed so far
+	Bytes int64
+}
+
+// WriteArchive writes the directory tree root as a tar or zip archive to w
+//   - entries are written in lexical order: equal trees produce equal
+//     entry order
+//   - entry names are slash-separated paths relative to root, root itself
+//     is not an entry
+//   - symbolic links are archived as links, not followed
+//   - sockets, devices and named pipes are skipped
+//   - ctx: canceling ctx aborts with ctx’s error.
+//     w then holds an incomplete archive
+//   - config: optional format, filters and progress callback
+//
+// Usage:
+//
+//	var f *os.File
+//	…
+//	if err = pfs.WriteArchive(ctx, f, "src", pfs.ArchiveConfig{
+//	  Format: pfs.ArchiveZip, Exclude: []string{".git", "*.tmp"},
+//	}); err != nil {
+func WriteArchive(ctx context.Context, w io.Writer, root string, config ...ArchiveConfig) (err error) {
+	var c ArchiveConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	var writer archiveWriter
+	switch c.Format {
+	case ArchiveTar:
+		writer = &tarArchiveWriter{tw: tar.NewWriter(w), xattrs: c.Xattrs}
+	case ArchiveTarGzip:
+		var gz = gzip.NewWriter(w)
+		writer = &tarArchiveWriter{tw: tar.NewWriter(gz), gz: gz, xattrs: c.Xattrs}
+	case ArchiveZip:
+		writer = &zipArchiveWriter{zw: zip.NewWriter(w)}
+	default:
+		err = perrors.ErrorfPF("bad format %s", c.Format)
+		return
+	}
+	defer writer.close(&err)
+
+	var progress ArchiveProgress
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, e error) (err error) {
+		if e != nil {
+			return e
+		} else if err = ctx.Err(); err != nil {
+			return context.Cause(ctx)
+		} else if path == root {
+			return // root is not an entry
+		}
+		var rel string
+		if rel, err = filepath.Rel(root, path); err != nil {
+			return
+		}
+		rel = filepath.ToSlash(rel)
+		var isDir = d.IsDir()
+		if matchAny(c.Exclude, rel) {
+			if isDir {
+				return fs.SkipDir
+			}
+			return // excluded return
+		} else if !isDir && len(c.Include) > 0 && !matchAny(c.Include, rel) {
+			return // not included return
+		}
+		var info fs.FileInfo
+		if info, err = d.Info(); err != nil {
+			return
+		}
+		var mode = info.Mode()
+		if !mode.IsRegular() && !isDir && mode.Type() != fs.ModeSymlink {
+			return // special file return
+		}
+		var n int64
+		if n, err = writer.write(ctx, path, rel, info); err != nil {
+			return
+		}
+		progress.Path = rel
+		progress.Entries++
+		progress.Bytes += n
+		if c.Progress != nil {
+			c.Progress(progress)
+		}
+		return
+	})
+	if perrors.IsPF(&err, "archive %q: %w", root, err) {
+		return
+	}
+
+	return
+}
+
+// archiveWriter writes entries of a format
+type archiveWriter interface {
+	// write writes the entry for path returning number of content bytes
+	write(ctx context.Context, path, rel string, info fs.FileInfo) (n int64, err error)
+	// close flushes the archive
+	close(errp *error)
+}
+
+// tarArchiveWriter writes tar entries
+type tarArchiveWriter struct {
+	tw *tar.Writer
+	// gz is gzip compressor, nil for ArchiveTar
+	gz     *gzip.Writer
+	xattrs bool
+}
+
+// write writes a tar header and any file content
+func (a *tarArchiveWriter) write(ctx context.Context, path, rel string, info fs.FileInfo) (n int64, err error) {
+	var link string
+	if info.Mode().Type() == fs.ModeSymlink {
+		if link, err = os.Readlink(path); err != nil {
+			return
+		}
+	}
+	var header *tar.Header
+	if header, err = tar.FileInfoHeader(info, link); err != nil {
+		return
+	}
+	header.Name = rel
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	if a.xattrs && header.Typeflag != tar.TypeSymlink {
+		var xattrs map[string]string
+		if xattrs, err = readXattrs(path); err != nil {
+			return
+		}
+		for name, value := range xattrs {
+			if header.PAXRecords == nil {
+				header.PAXRecords = make(map[string]string, len(xattrs))
+			}
+			header.PAXRecords[xattrPAXPrefix+name] = value
+		}
+	}
+	if err = a.tw.WriteHeader(header); err != nil || header.Typeflag != tar.TypeReg {
+		return
+	}
+
+	return copyFileTo(ctx, a.tw, path)
+}
+
+// close flushes tar and gzip writers
+func (a *tarArchiveWriter) close(errp *error) {
+	if err := a.tw.Close(); err != nil {
+		*errp = perrors.AppendError(*errp, perrors.ErrorfPF("tar %w", err))
+	}
+	if a.gz == nil {
+		return
+	}
+	if err := a.gz.Close(); err != nil {
+		*errp = perrors.AppendError(*errp, perrors.ErrorfPF("gzip %w", err))
+	}
+}
+
+// zipArchiveWriter writes zip entries
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+// write writes a zip header and file content or link target
+func (a *zipArchiveWriter) write(ctx context.Context, path, rel string, info fs.FileInfo) (n int64, err error) {
+	var header *zip.FileHeader
+	if header, err = zip.FileInfoHeader(info); err != nil {
+		return
+	}
+	header.Name = rel
+	if info.IsDir() {
+		header.Name += "/"
+	} else if info.Mode().IsRegular() {
+		header.Method = zip.Deflate
+	}
+	var w io.Writer
+	if w, err = a.zw.CreateHeader(header); err != nil {
+		return
+	}
+	switch {
+	case info.Mode().Type() == fs.ModeSymlink:
+		// zip stores the link target as content
+		var link string
+		if link, err = os.Readlink(path); err != nil {
+			return
+		}
+		_, err = io.WriteString(w, link)
+	case info.Mode().IsRegular():
+		n, err = copyFileTo(ctx, w, path)
+	}
+
+	return
+}
+
+// close flushes the zip central directory
+func (a *zipArchiveWriter) close(errp *error) {
+	if err := a.zw.Close(); err != nil {
+		*errp = perrors.AppendError(*errp, perrors.ErrorfPF("zip %w", err))
+	}
+}
+
+// copyFileTo copies the content of file path to w
+func copyFileTo(ctx context.Context, w io.Writer, path string) (n int64, err error) {
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		return
+	}
+	defer parl.Close(f, &err)
+
+	return io.Copy(w, &ctxReader{ctx: ctx, r: f})
+}
+
+// matchAny returns true if any pattern matches rel or its base name
+func matchAny(patterns []string, rel string) (isMatch bool) {
+	var base = pathpkg.Base(rel)
+	for _, pattern := range patterns {
+		if m, _ := pathpkg.Match(pattern, rel); m {
+			return true
+		} else if m, _ = pathpkg.Match(pattern, base); m {
+			return true
+		}
+	}
+	return
+}
+
+// ctxReader is a reader failing once ctx is canceled
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read reads unless ctx is canceled
+func (r *ctxReader) Read(p []byte) (n int, err error) {
+	if r.ctx.Err() != nil {
+		err = context.Cause(r.ctx)
+		return
+	}
+	return r.r.Read(p)
+}
+
+// FormatFromName returns the archive format from a file name extension
+//   - “.zip” “.tar” “.tar.gz” “.tgz”
+//   - isKnown false: the extension is not an archive format
+func FormatFromName(name string) (format ArchiveFormat, isKnown bool) {
+	var lower = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip, true
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGzip, true
+	case strings.HasSuffix(lower, ".tar"):
+		return ArchiveTar, true
+	}
+	return
+}
+
+// “tar” “tar.gz” “zip”
+func (f ArchiveFormat) String() (s string) {
+	switch f {
+	case ArchiveTar:
+		return "tar"
+	case ArchiveTarGzip:
+		return "tar.gz"
+	case ArchiveZip:
+		return "zip"
+	}
+	return parl.Sprintf("?archiveFormat%d", f)
+}
@@ -0,0 +1,155 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	//t.Error("Logging on")
+	var content = "hello"
+	var expPaths = []string{"a.txt", "link", "sub", "sub/b.txt"}
+
+	var root = t.TempDir()
+	var src = filepath.Join(root, "src")
+	for _, dir := range []string{src, filepath.Join(src, "sub"), filepath.Join(src, ".git")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"a.txt", "sub/b.txt", "skip.tmp", ".git/config"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("a.txt", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+	var exclude = []string{".git", "*.tmp"}
+
+	for _, format := range []ArchiveFormat{ArchiveTar, ArchiveTarGzip, ArchiveZip} {
+		var buffer bytes.Buffer
+		var paths []string
+		if err := WriteArchive(context.Background(), &buffer, src, ArchiveConfig{
+			Format:   format,
+			Exclude:  exclude,
+			Progress: func(progress ArchiveProgress) { paths = append(paths, progress.Path) },
+		}); err != nil {
+			t.Fatalf("%s WriteArchive err %s", format, err)
+		}
+		if !slices.Equal(paths, expPaths) {
+			t.Errorf("%s paths %q exp %q", format, paths, expPaths)
+		}
+
+		var dst = filepath.Join(root, format.String())
+		var entries int
+		if err := ExtractArchive(context.Background(), bytes.NewReader(buffer.Bytes()), dst, ExtractConfig{
+			Format:   format,
+			Progress: func(progress ArchiveProgress) { entries = progress.Entries },
+		}); err != nil {
+			t.Fatalf("%s ExtractArchive err %s", format, err)
+		}
+		if entries != len(expPaths) {
+			t.Errorf("%s entries %d exp %d", format, entries, len(expPaths))
+		}
+		if b, err := os.ReadFile(filepath.Join(dst, "sub", "b.txt")); err != nil || string(b) != content {
+			t.Errorf("%s b.txt %q err %v", format, b, err)
+		}
+		if target, err := os.Readlink(filepath.Join(dst, "link")); err != nil || target != "a.txt" {
+			t.Errorf("%s link %q err %v", format, target, err)
+		}
+		if _, err := os.Lstat(filepath.Join(dst, "skip.tmp")); err == nil {
+			t.Errorf("%s excluded file extracted", format)
+		}
+	}
+
+	// deterministic ordering and MaxBytes
+	var tar1, tar2 bytes.Buffer
+	for _, b := range []*bytes.Buffer{&tar1, &tar2} {
+		if err := WriteArchive(context.Background(), b, src, ArchiveConfig{Include: []string{"*.txt"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(tar1.Bytes(), tar2.Bytes()) {
+		t.Error("archives differ")
+	}
+	var err = ExtractArchive(context.Background(), &tar1, filepath.Join(root, "max"), ExtractConfig{MaxBytes: 7})
+	if !errors.Is(err, ErrArchiveTooLarge) {
+		t.Errorf("MaxBytes err %v", err)
+	}
+}
+
+func TestArchiveUnsafe(t *testing.T) {
+	//t.Error("Logging on")
+	var headers = []*tar.Header{
+		{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0o644},
+		{Name: "/abs", Typeflag: tar.TypeReg, Mode: 0o644},
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../outside", Mode: 0o777},
+		{Name: "hard", Typeflag: tar.TypeLink, Linkname: "../../etc/passwd", Mode: 0o644},
+	}
+
+	var root = t.TempDir()
+	for i, header := range headers {
+		var buffer bytes.Buffer
+		var tw = tar.NewWriter(&buffer)
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		var err = ExtractArchive(context.Background(), &buffer, filepath.Join(root, "dst"))
+		if !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("%d %q err %v", i, header.Name, err)
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(root, "evil")); err == nil {
+		t.Error("file written outside destination")
+	}
+
+	// hard link through symlinks resolving outside dir
+	var outside = filepath.Join(root, "outside")
+	if err := os.WriteFile(outside, []byte("outside"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	var tw = tar.NewWriter(&buffer)
+	for _, header := range []*tar.Header{
+		{Name: "s/", Typeflag: tar.TypeDir, Mode: 0o755},
+		{Name: "s/y", Typeflag: tar.TypeSymlink, Linkname: "..", Mode: 0o777},
+		{Name: "s/x", Typeflag: tar.TypeSymlink, Linkname: "y/../..", Mode: 0o777},
+		{Name: "hard", Typeflag: tar.TypeLink, Linkname: "s/x/outside", Mode: 0o644},
+	} {
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var dst = filepath.Join(root, "a", "dst")
+	if err := ExtractArchive(context.Background(), &buffer, dst); !errors.Is(err, ErrUnsafePath) {
+		t.Errorf("symlinked hard link err %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dst, "hard")); err == nil {
+		t.Error("hard link to file outside destination")
+	}
+
+	// canceled
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := WriteArchive(ctx, &bytes.Buffer{}, root); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled err %v", err)
+	}
+}
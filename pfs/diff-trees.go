@@ -0,0 +1,373 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// CompareSizeModTime considers files equal if size and modification time are equal
+	CompareSizeModTime CompareMode = iota
+	// CompareSize considers files equal if size is equal
+	CompareSize
+	// CompareHash considers files equal if size and SHA-256 hash of content are equal
+	CompareHash
+)
+
+// CompareMode is how [DiffTrees] determines whether files differ
+//   - [CompareSizeModTime] [CompareSize] [CompareHash]
+type CompareMode uint8
+
+const (
+	// SyncCreate creates an entry missing in the destination
+	SyncCreate SyncAction = iota + 1
+	// SyncUpdate overwrites a differing destination entry
+	SyncUpdate
+	// SyncDelete removes a destination entry missing in the source
+	SyncDelete
+)
+
+// SyncAction is the operation of a [SyncOp]
+//   - [SyncCreate] [SyncUpdate] [SyncDelete]
+type SyncAction uint8
+
+// DiffConfig configures [DiffTrees]
+type DiffConfig struct {
+	// Compare is how files are compared
+	//	- default [CompareSizeModTime]
+	Compare CompareMode
+	// NoDelete omits delete operations for destination entries
+	// missing in the source
+	NoDelete bool
+}
+
+// SyncOp is an operation of a sync plan produced by [DiffTrees]
+type SyncOp struct {
+	// Action is create update or delete
+	Action SyncAction
+	// Path is slash-separated path relative to the roots
+	Path string
+	// Mode is type and permissions of the source entry
+	//	- for delete, the mode of the destination entry
+	Mode fs.FileMode
+	// Size is source file size
+	Size int64
+	// ModTime is source modification time
+	ModTime time.Time
+}
+
+// ApplyConfig configures [Apply]
+type ApplyConfig struct {
+	// DryRun does not modify the file system
+	DryRun bool
+	// Log optionally receives each operation prior to execution
+	Log func(op SyncOp)
+}
+
+// treeEntry is a file-system entry found by walking a tree
+type treeEntry struct {
+	mode    fs.FileMode
+	size    int64
+	modTime time.Time
+	// target is symlink target
+	target string
+}
+
+// DiffTrees compares tree src with tree dst producing
+// operations that make dst equal to src
+//   - src and dst are walked concurrently. Symlinks are not followed
+//   - dst not existing is an empty tree
+//   - operations are sent to plan in executable order:
+//     creates and updates parent-first, then deletes child-first.
+//     A directory delete is recursive and its children are omitted
+//   - an entry changing type is deleted then created
+//   - config: optional compare mode and delete suppression
+//   - err: failure to walk either tree. Hash read failures are
+//     treated as the files differing
+func DiffTrees(src, dst string, plan parl.Sink[SyncOp], config ...DiffConfig) (err error) {
+	if plan == nil {
+		panic(parl.NilError("plan"))
+	}
+	var cfg DiffConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	// walk both trees concurrently
+	var srcEntries, dstEntries map[string]*treeEntry
+	var srcErr, dstErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		srcEntries, srcErr = walkTree(src, false)
+	}()
+	dstEntries, dstErr = walkTree(dst, true)
+	wg.Wait()
+	if err = perrors.AppendError(srcErr, dstErr); err != nil {
+		return
+	}
+
+	// deleted are destination paths deleted recursively
+	var deleted = make(map[string]bool)
+
+	// creates and updates parent-first
+	var srcPaths = sortedKeys(srcEntries)
+	for _, path := range srcPaths {
+		var s = srcEntries[path]
+		var op = SyncOp{Path: path, Mode: s.mode, Size: s.size, ModTime: s.modTime}
+		var d = dstEntries[path]
+		if d == nil {
+			op.Action = SyncCreate
+		} else if d.mode.Type() != s.mode.Type() {
+			plan.Send(SyncOp{Action: SyncDelete, Path: path, Mode: d.mode})
+			deleted[path] = true
+			op.Action = SyncCreate
+		} else if differs(s, d, filepath.Join(src, filepath.FromSlash(path)), filepath.Join(dst, filepath.FromSlash(path)), cfg.Compare) {
+			op.Action = SyncUpdate
+		} else {
+			continue // equal entries
+		}
+		plan.Send(op)
+	}
+	if cfg.NoDelete {
+		return // no deletes return
+	}
+
+	// deletes of extraneous entries, child-first
+	var deletes []string
+	for _, path := range sortedKeys(dstEntries) {
+		if deleted[path] || srcEntries[path] != nil || isParentDeleted(path, deleted) {
+			continue
+		}
+		deleted[path] = true
+		deletes = append(deletes, path)
+	}
+	for i := len(deletes) - 1; i >= 0; i-- {
+		var path = deletes[i]
+		plan.Send(SyncOp{Action: SyncDelete, Path: path, Mode: dstEntries[path].mode})
+	}
+
+	return
+}
+
+// Apply executes sync operations copying from src to dst
+//   - ops: typically produced by [DiffTrees]
+//   - files are copied via a temporary file and renamed into place.
+//     Permissions and modification time are copied
+//   - config: optional dry-run and logging
+//   - Apply ends on first error
+func Apply(src, dst string, ops []SyncOp, config ...ApplyConfig) (err error) {
+	var cfg ApplyConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	for _, op := range ops {
+		if cfg.Log != nil {
+			cfg.Log(op)
+		}
+		if cfg.DryRun {
+			continue
+		}
+		var srcPath = filepath.Join(src, filepath.FromSlash(op.Path))
+		var dstPath = filepath.Join(dst, filepath.FromSlash(op.Path))
+		if op.Action == SyncDelete {
+			err = os.RemoveAll(dstPath)
+		} else if op.Mode.IsDir() {
+			if err = os.MkdirAll(dstPath, op.Mode.Perm()); err == nil {
+				err = os.Chmod(dstPath, op.Mode.Perm())
+			}
+		} else if op.Mode.Type() == fs.ModeSymlink {
+			err = copySymlink(srcPath, dstPath)
+		} else {
+			err = copyFile(srcPath, dstPath, op.Mode.Perm())
+		}
+		if perrors.IsPF(&err, "%s %q: %w", op.Action, op.Path, err) {
+			return
+		}
+	}
+
+	return
+}
+
+// "create" "update" "delete"
+func (a SyncAction) String() (s string) {
+	switch a {
+	case SyncCreate:
+		return "create"
+	case SyncUpdate:
+		return "update"
+	case SyncDelete:
+		return "delete"
+	}
+	return parl.Sprintf("?syncAction%d", a)
+}
+
+// walkTree returns entries of root by slash-separated relative path
+//   - mayNotExist: a non-existing root is empty tree
+func walkTree(root string, mayNotExist bool) (entries map[string]*treeEntry, err error) {
+	entries = make(map[string]*treeEntry)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, e error) (err error) {
+		if e != nil {
+			if path == root && mayNotExist && errors.Is(e, fs.ErrNotExist) {
+				return // destination does not exist
+			}
+			return e
+		} else if path == root {
+			return // root itself is not compared
+		}
+		var info fs.FileInfo
+		if info, err = d.Info(); err != nil {
+			return
+		}
+		var rel string
+		if rel, err = filepath.Rel(root, path); err != nil {
+			return
+		}
+		var entry = treeEntry{mode: info.Mode(), size: info.Size(), modTime: info.ModTime()}
+		if entry.mode.Type() == fs.ModeSymlink {
+			if entry.target, err = os.Readlink(path); err != nil {
+				return
+			}
+		}
+		entries[filepath.ToSlash(rel)] = &entry
+		return
+	})
+	if perrors.IsPF(&err, "walk %q: %w", root, err) {
+		return
+	}
+
+	return
+}
+
+// differs returns true if entries of the same type differ
+func differs(s, d *treeEntry, srcPath, dstPath string, compare CompareMode) (isDifferent bool) {
+	if s.mode.Perm() != d.mode.Perm() {
+		return true
+	}
+	switch s.mode.Type() {
+	case fs.ModeDir:
+		return false
+	case fs.ModeSymlink:
+		return s.target != d.target
+	}
+	if s.size != d.size {
+		return true
+	}
+	switch compare {
+	case CompareSize:
+		return false
+	case CompareHash:
+		var sHash, e1 = hashFile(srcPath)
+		var dHash, e2 = hashFile(dstPath)
+		return e1 != nil || e2 != nil || !bytes.Equal(sHash, dHash)
+	}
+	return !s.modTime.Equal(d.modTime)
+}
+
+// hashFile returns SHA-256 of file content
+func hashFile(path string) (hash []byte, err error) {
+	var file *os.File
+	if file, err = os.Open(path); err != nil {
+		return
+	}
+	defer file.Close()
+
+	var h = sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return
+	}
+	hash = h.Sum(nil)
+
+	return
+}
+
+// copyFile copies src to dst via a temporary file
+func copyFile(src, dst string, perm fs.FileMode) (err error) {
+	var in *os.File
+	if in, err = os.Open(src); err != nil {
+		return
+	}
+	defer in.Close()
+	var info fs.FileInfo
+	if info, err = in.Stat(); err != nil {
+		return
+	}
+	var out *os.File
+	if out, err = os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*"); err != nil {
+		return
+	}
+	var tempName = out.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tempName)
+		}
+	}()
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return
+	}
+	if err = out.Close(); err != nil {
+		return
+	}
+	if err = os.Chmod(tempName, perm); err != nil {
+		return
+	}
+	if err = os.Chtimes(tempName, info.ModTime(), info.ModTime()); err != nil {
+		return
+	}
+	err = os.Rename(tempName, dst)
+
+	return
+}
+
+// copySymlink recreates symlink src at dst
+func copySymlink(src, dst string) (err error) {
+	var target string
+	if target, err = os.Readlink(src); err != nil {
+		return
+	}
+	if err = os.Remove(dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	err = os.Symlink(target, dst)
+
+	return
+}
+
+// isParentDeleted returns true if a parent directory of path is deleted
+func isParentDeleted(path string, deleted map[string]bool) (isDeleted bool) {
+	for dir := pathpkg.Dir(path); dir != "."; dir = pathpkg.Dir(dir) {
+		if deleted[dir] {
+			return true
+		}
+	}
+	return
+}
+
+// sortedKeys returns map keys in ascending order
+func sortedKeys(m map[string]*treeEntry) (keys []string) {
+	keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return
+}
@@ -0,0 +1,156 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+func TestDiffTrees(t *testing.T) {
+	//t.Error("Logging on")
+	var src, dst = t.TempDir(), t.TempDir()
+	var modTime = time.Now().Add(-time.Hour).Truncate(time.Second)
+	var expOps = []string{
+		"create a.txt",
+		"update changed.txt",
+		"delete kind",
+		"create kind",
+		"create sub",
+		"create sub/b.txt",
+		"delete extra",
+	}
+
+	// src: a.txt changed.txt kind/ sub/b.txt same.txt
+	writeTestFile(t, src, "a.txt", "a", modTime)
+	writeTestFile(t, src, "changed.txt", "newer", modTime)
+	writeTestFile(t, src, "same.txt", "same", modTime)
+	mkdirTest(t, src, "kind")
+	mkdirTest(t, src, "sub")
+	writeTestFile(t, src, "sub/b.txt", "b", modTime)
+
+	// dst: changed.txt extra/c.txt kind same.txt
+	writeTestFile(t, dst, "changed.txt", "old", modTime)
+	writeTestFile(t, dst, "same.txt", "same", modTime)
+	writeTestFile(t, dst, "kind", "file", modTime)
+	mkdirTest(t, dst, "extra")
+	writeTestFile(t, dst, "extra/c.txt", "c", modTime)
+
+	var err error
+	var plan parl.AwaitableSlice[SyncOp]
+	var ops []SyncOp
+
+	// DiffTrees should produce ops in order
+	if err = DiffTrees(src, dst, &plan); err != nil {
+		t.Fatalf("DiffTrees err: %s", err)
+	}
+	ops = plan.GetAll()
+	if len(ops) != len(expOps) {
+		t.Fatalf("ops %d exp %d: %v", len(ops), len(expOps), opStrings(ops))
+	}
+	for i, s := range opStrings(ops) {
+		if s != expOps[i] {
+			t.Errorf("op#%d %q exp %q", i, s, expOps[i])
+		}
+	}
+
+	// DryRun should log but not modify
+	var logged int
+	if err = Apply(src, dst, ops, ApplyConfig{DryRun: true, Log: func(op SyncOp) { logged++ }}); err != nil {
+		t.Fatalf("Apply DryRun err: %s", err)
+	}
+	if logged != len(ops) {
+		t.Errorf("logged %d exp %d", logged, len(ops))
+	}
+	if _, err = os.Stat(filepath.Join(dst, "a.txt")); err == nil {
+		t.Error("DryRun created a.txt")
+	}
+
+	// Apply should make trees equal
+	if err = Apply(src, dst, ops); err != nil {
+		t.Fatalf("Apply err: %s", err)
+	}
+	if err = DiffTrees(src, dst, &plan); err != nil {
+		t.Fatalf("DiffTrees err: %s", err)
+	}
+	if ops = plan.GetAll(); len(ops) != 0 {
+		t.Errorf("ops after Apply: %v", opStrings(ops))
+	}
+}
+
+func TestDiffTreesCompare(t *testing.T) {
+	//t.Error("Logging on")
+	var src, dst = t.TempDir(), t.TempDir()
+	var modTime = time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	// same size and time but different content
+	writeTestFile(t, src, "f", "abc", modTime)
+	writeTestFile(t, dst, "f", "xyz", modTime)
+	// same content but different time
+	writeTestFile(t, src, "g", "abc", modTime)
+	writeTestFile(t, dst, "g", "abc", modTime.Add(time.Minute))
+
+	var err error
+	var plan parl.AwaitableSlice[SyncOp]
+	var ops []string
+
+	// CompareSizeModTime should detect g
+	if err = DiffTrees(src, dst, &plan); err != nil {
+		t.Fatalf("DiffTrees err: %s", err)
+	}
+	if ops = opStrings(plan.GetAll()); len(ops) != 1 || ops[0] != "update g" {
+		t.Errorf("CompareSizeModTime ops %v exp [update g]", ops)
+	}
+
+	// CompareHash should detect f
+	if err = DiffTrees(src, dst, &plan, DiffConfig{Compare: CompareHash}); err != nil {
+		t.Fatalf("DiffTrees err: %s", err)
+	}
+	if ops = opStrings(plan.GetAll()); len(ops) != 1 || ops[0] != "update f" {
+		t.Errorf("CompareHash ops %v exp [update f]", ops)
+	}
+
+	// non-existing dst should be empty tree
+	if err = DiffTrees(src, filepath.Join(dst, "none"), &plan, DiffConfig{NoDelete: true}); err != nil {
+		t.Fatalf("DiffTrees err: %s", err)
+	}
+	if ops = opStrings(plan.GetAll()); len(ops) != 2 {
+		t.Errorf("missing dst ops %v exp 2", ops)
+	}
+}
+
+// writeTestFile creates file rel in dir with data and modification time
+func writeTestFile(t *testing.T, dir, rel, data string, modTime time.Time) {
+	t.Helper()
+	var path = filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mkdirTest creates directory rel in dir
+func mkdirTest(t *testing.T, dir, rel string) {
+	t.Helper()
+	if err := os.Mkdir(filepath.Join(dir, rel), 0o700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// opStrings returns “action path” for ops
+func opStrings(ops []SyncOp) (s []string) {
+	s = make([]string, len(ops))
+	for i, op := range ops {
+		s[i] = op.Action.String() + " " + op.Path
+	}
+	return
+}
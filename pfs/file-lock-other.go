@@ -0,0 +1,26 @@
+//go:build !unix
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"os"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// tryFlock: file locking is not supported on this platform
+func tryFlock(file *os.File, mode LockMode) (isLocked bool, err error) {
+	err = perrors.ErrorfPF("%w", ErrLockUnsupported)
+	return
+}
+
+// unflock: file locking is not supported on this platform
+func unflock(file *os.File) (err error) { return }
+
+// processExists: process existence cannot be determined
+func processExists(pid int) (exists, isKnown bool) { return }
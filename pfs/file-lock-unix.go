@@ -0,0 +1,64 @@
+//go:build unix
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// tryFlock acquires a non-blocking flock
+//   - isLocked false: the lock is held by another owner
+func tryFlock(file *os.File, mode LockMode) (isLocked bool, err error) {
+	var how = syscall.LOCK_EX | syscall.LOCK_NB
+	if mode == LockShared {
+		how = syscall.LOCK_SH | syscall.LOCK_NB
+	}
+	for {
+		if err = syscall.Flock(int(file.Fd()), how); err != syscall.EINTR {
+			break
+		}
+	}
+	if err == nil {
+		isLocked = true
+		return // acquired return
+	} else if errors.Is(err, syscall.EWOULDBLOCK) {
+		err = nil
+		return // held by other return
+	}
+	err = perrors.ErrorfPF("flock %w", err)
+
+	return
+}
+
+// unflock releases a flock
+func unflock(file *os.File) (err error) {
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_UN); perrors.IsPF(&err, "flock unlock %w", err) {
+		return
+	}
+	return
+}
+
+// processExists determines if process pid exists using signal 0
+//   - isKnown false: existence could not be determined
+func processExists(pid int) (exists, isKnown bool) {
+	if pid <= 0 {
+		return
+	}
+	var err = syscall.Kill(pid, 0)
+	switch {
+	case err == nil, errors.Is(err, syscall.EPERM):
+		return true, true // exists, possibly other user
+	case errors.Is(err, syscall.ESRCH):
+		return false, true
+	}
+	return
+}
@@ -0,0 +1,305 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// LockExclusive is a lock held by a single owner
+	//	- the owner’s process ID is written to the lock file
+	LockExclusive LockMode = iota
+	// LockShared is a lock held by any number of owners,
+	// excluding exclusive owners
+	LockShared
+)
+
+const (
+	// DefaultLockPoll is how often [Lock] retries a held lock
+	DefaultLockPoll = 50 * time.Millisecond
+	// lock-file permissions
+	lockFilePerm = 0o644
+)
+
+// ErrLocked indicates that a lock is held by another owner
+//   - returned by [TryLock]
+var ErrLocked = errors.New("file is locked")
+
+// ErrLockUnsupported indicates a platform without file locking
+var ErrLockUnsupported = errors.New("file locking not supported on this platform")
+
+// LockMode is exclusive or shared
+//   - [LockExclusive] [LockShared]
+type LockMode uint8
+
+// LockConfig configures [Lock] and [TryLock]
+type LockConfig struct {
+	// Mode is [LockExclusive] default or [LockShared]
+	Mode LockMode
+	// Poll is how often a blocking Lock retries
+	//	- 0: [DefaultLockPoll]
+	Poll time.Duration
+}
+
+// LockOwner is the process recorded in a lock file
+type LockOwner struct {
+	// PID is process ID of the exclusive owner
+	PID int
+	// Host is the owner’s hostname
+	Host string
+	// IsAlive is false if the process is known to no longer exist
+	//	- true if the process exists or existence cannot be determined,
+	//		like for another host
+	IsAlive bool
+}
+
+// FileLock is an advisory lock on a file
+//   - obtained from [Lock] or [TryLock]
+//   - released by [FileLock.Unlock] or on process exit
+//
+// Platform behavior:
+//   - unix: flock(2). The lock belongs to the open file description
+//     so is not released when another descriptor of the same file is closed,
+//     unlike fcntl record locks.
+//     A lock is released by the kernel when the process exits,
+//     so a crashed owner cannot leave a held lock
+//   - Linux NFS: flock is emulated using fcntl byte-range locks
+//   - locks are advisory: processes not using locks may still access the file
+//   - other platforms: [ErrLockUnsupported]
+//
+// PID metadata:
+//   - an exclusive owner writes “pid host” to the lock file.
+//     Unlock truncates the file
+//   - a lock file with metadata of a process that no longer exists
+//     means that the previous owner exited without unlocking:
+//     [FileLock.WasStale] is true, indicating that recovery of
+//     on-disk state may be required
+//   - the lock file is not removed on Unlock:
+//     removing a lock file allows two owners to lock different files
+type FileLock struct {
+	path string
+	mode LockMode
+	// stale is previous owner metadata of an exited process
+	stale *LockOwner
+	// lock makes file thread-safe
+	lock sync.Mutex
+	// file is the open lock file, nil after Unlock
+	file *os.File
+}
+
+// Lock acquires an advisory lock on path awaiting other owners
+//   - path: the lock file is created if it does not exist
+//   - ctx: canceling ctx abandons the wait with ctx’s error
+//   - config: optional mode and poll interval
+//   - a lock file is typically dedicated to locking,
+//     like “/var/run/app.lock” for a single-instance daemon
+func Lock(ctx context.Context, path string, config ...LockConfig) (lock *FileLock, err error) {
+	var c = lockConfig(config)
+	for {
+		if lock, err = TryLock(path, c); !errors.Is(err, ErrLocked) {
+			break // acquired or failure
+		}
+		if err = parl.SleepCtx(ctx, c.Poll); err != nil {
+			err = perrors.ErrorfPF("lock %q: %w", path, err)
+			return // canceled return
+		}
+	}
+
+	return
+}
+
+// TryLock acquires an advisory lock on path if available
+//   - err: [ErrLocked] if held by another owner.
+//     The error message includes any owner process ID
+func TryLock(path string, config ...LockConfig) (lock *FileLock, err error) {
+	var c = lockConfig(config)
+	var file *os.File
+	if file, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE, lockFilePerm); perrors.IsPF(&err, "open %w", err) {
+		return
+	}
+	defer closeOnError(file, &err)
+
+	var isLocked bool
+	if isLocked, err = tryFlock(file, c.Mode); err != nil {
+		return
+	} else if !isLocked {
+		var ownerText string
+		if owner, e := readOwner(file); e == nil && owner != nil {
+			ownerText = " by " + owner.String()
+		}
+		err = perrors.ErrorfPF("%q%s: %w", path, ownerText, ErrLocked)
+		return
+	}
+
+	lock = &FileLock{path: path, mode: c.Mode, file: file}
+	if c.Mode != LockExclusive {
+		return // shared: no metadata return
+	}
+	if owner, e := readOwner(file); e == nil && owner != nil && !owner.IsAlive {
+		lock.stale = owner
+	}
+	if err = writeOwner(file); err != nil {
+		unflock(file)
+		lock = nil
+	}
+
+	return
+}
+
+// ReadLockOwner returns the exclusive owner recorded in a lock file
+//   - owner: nil if no owner is recorded
+//   - the file is not locked
+func ReadLockOwner(path string) (owner *LockOwner, err error) {
+	var file *os.File
+	if file, err = os.Open(path); perrors.IsPF(&err, "open %w", err) {
+		return
+	}
+	defer file.Close()
+
+	return readOwner(file)
+}
+
+// Unlock releases the lock
+//   - exclusive: PID metadata is removed
+//   - idempotent, thread-safe
+func (l *FileLock) Unlock() (err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var file = l.file
+	if file == nil {
+		return // already unlocked return
+	}
+	l.file = nil
+	if l.mode == LockExclusive {
+		if e := file.Truncate(0); e != nil {
+			err = perrors.ErrorfPF("truncate %w", e)
+		}
+	}
+	if e := unflock(file); e != nil {
+		err = perrors.AppendError(err, e)
+	}
+	if e := file.Close(); e != nil {
+		err = perrors.AppendError(err, perrors.ErrorfPF("close %w", e))
+	}
+
+	return
+}
+
+// WasStale returns metadata of a previous exclusive owner that
+// exited without unlocking
+//   - owner nil: the lock was not stale
+func (l *FileLock) WasStale() (owner *LockOwner) { return l.stale }
+
+// Path returns the lock file
+func (l *FileLock) Path() (path string) { return l.path }
+
+// Mode returns exclusive or shared
+func (l *FileLock) Mode() (mode LockMode) { return l.mode }
+
+// “/var/run/app.lock exclusive”
+func (l *FileLock) String() (s string) { return l.path + "\x20" + l.mode.String() }
+
+// “exclusive” “shared”
+func (m LockMode) String() (s string) {
+	switch m {
+	case LockExclusive:
+		return "exclusive"
+	case LockShared:
+		return "shared"
+	}
+	return fmt.Sprintf("?lockMode%d", m)
+}
+
+// “pid 1234 on host”
+func (o *LockOwner) String() (s string) {
+	s = "pid " + strconv.Itoa(o.PID)
+	if o.Host != "" {
+		s += " on " + o.Host
+	}
+	if !o.IsAlive {
+		s += " (exited)"
+	}
+	return
+}
+
+// lockConfig returns effective configuration
+func lockConfig(config []LockConfig) (c LockConfig) {
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.Poll <= 0 {
+		c.Poll = DefaultLockPoll
+	}
+	return
+}
+
+// readOwner parses “pid host” metadata
+//   - owner: nil if the file has no metadata
+func readOwner(file *os.File) (owner *LockOwner, err error) {
+	var buffer = make([]byte, 256)
+	var n int
+	if n, err = file.ReadAt(buffer, 0); n == 0 {
+		err = nil
+		return // no metadata return
+	}
+	err = nil
+	var fields = strings.Fields(string(buffer[:n]))
+	if len(fields) == 0 {
+		return // blank metadata return
+	}
+	var pid int
+	if pid, err = strconv.Atoi(fields[0]); perrors.IsPF(&err, "lock-file pid %w", err) {
+		return
+	}
+	owner = &LockOwner{PID: pid, IsAlive: true}
+	if len(fields) > 1 {
+		owner.Host = fields[1]
+	}
+	var host, _ = os.Hostname()
+	if owner.Host == "" || owner.Host == host {
+		if exists, isKnown := processExists(pid); isKnown && !exists {
+			owner.IsAlive = false
+		}
+	}
+
+	return
+}
+
+// writeOwner writes “pid host” metadata
+func writeOwner(file *os.File) (err error) {
+	var host, _ = os.Hostname()
+	var metadata = strconv.Itoa(os.Getpid()) + "\x20" + host + "\n"
+	if err = file.Truncate(0); perrors.IsPF(&err, "truncate %w", err) {
+		return
+	} else if _, err = file.WriteAt([]byte(metadata), 0); perrors.IsPF(&err, "write %w", err) {
+		return
+	} else if err = file.Sync(); perrors.IsPF(&err, "sync %w", err) {
+		return
+	}
+	return
+}
+
+// closeOnError closes file if *errp is non-nil
+func closeOnError(file *os.File, errp *error) {
+	if *errp == nil {
+		return
+	}
+	if err := file.Close(); err != nil {
+		*errp = perrors.AppendError(*errp, perrors.ErrorfPF("close %w", err))
+	}
+}
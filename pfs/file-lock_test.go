@@ -0,0 +1,77 @@
+//go:build unix
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileLock(t *testing.T) {
+	//t.Error("Logging on")
+	var path = filepath.Join(t.TempDir(), "app.lock")
+	// a process ID that does not exist
+	var stalePID = "2147483646"
+
+	// stale metadata from an exited owner
+	if err := os.WriteFile(path, []byte(stalePID+"\x20\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// exclusive lock
+	var lock, err = TryLock(path)
+	if err != nil {
+		t.Fatalf("TryLock err %s", err)
+	}
+	if owner := lock.WasStale(); owner == nil || owner.IsAlive {
+		t.Errorf("WasStale %v", owner)
+	}
+	var owner *LockOwner
+	if owner, err = ReadLockOwner(path); err != nil || owner == nil || owner.PID != os.Getpid() || !owner.IsAlive {
+		t.Errorf("ReadLockOwner %v err %v", owner, err)
+	}
+
+	// held lock
+	if _, err = TryLock(path, LockConfig{Mode: LockShared}); !errors.Is(err, ErrLocked) {
+		t.Errorf("TryLock held err %v", err)
+	}
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = Lock(ctx, path, LockConfig{Poll: time.Millisecond}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lock timeout err %v", err)
+	}
+
+	// unlock clears metadata
+	if err = lock.Unlock(); err != nil {
+		t.Errorf("Unlock err %s", err)
+	}
+	if err = lock.Unlock(); err != nil {
+		t.Errorf("second Unlock err %s", err)
+	}
+	if owner, err = ReadLockOwner(path); err != nil || owner != nil {
+		t.Errorf("ReadLockOwner after Unlock %v err %v", owner, err)
+	}
+
+	// two shared locks
+	var shared1, shared2 *FileLock
+	if shared1, err = Lock(context.Background(), path, LockConfig{Mode: LockShared}); err != nil {
+		t.Fatalf("shared1 err %s", err)
+	}
+	defer shared1.Unlock()
+	if shared2, err = TryLock(path, LockConfig{Mode: LockShared}); err != nil {
+		t.Fatalf("shared2 err %s", err)
+	}
+	defer shared2.Unlock()
+	if _, err = TryLock(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("exclusive while shared err %v", err)
+	}
+}
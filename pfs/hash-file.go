@@ -0,0 +1,228 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// DefaultHashChunkSize is chunk size of [HashFile]: 4 MiB
+	//	- large enough for sequential NVMe throughput per read
+	DefaultHashChunkSize = 4 * 1024 * 1024
+)
+
+// HashConfig configures [HashFile]
+type HashConfig struct {
+	// ChunkSize is size of each hashed chunk
+	//	- default [DefaultHashChunkSize]
+	//	- the whole-file digest depends on chunk size
+	ChunkSize int64
+	// Workers is the number of threads reading and hashing chunks
+	//	- default [runtime.NumCPU]
+	Workers int
+	// Moderator optionally limits parallelism across several
+	// concurrent hashings, ie. one moderator for a directory tree
+	Moderator *parl.ModeratorCore
+	// NewHash returns the hash function
+	//	- default SHA-256
+	NewHash func() (h hash.Hash)
+	// Progress is invoked as each chunk has been hashed
+	//	- invoked by worker threads, must be thread-safe
+	Progress func(progress HashProgress)
+}
+
+// HashProgress is a progress event of [HashFile]
+type HashProgress struct {
+	// Chunk is the index of the chunk just hashed
+	Chunk int
+	// Chunks is the number of chunks
+	Chunks int
+	// Bytes is the number of bytes hashed so far
+	Bytes int64
+	// Size is file size
+	Size int64
+}
+
+// FileHash is the result of [HashFile]
+type FileHash struct {
+	// Path is the hashed file
+	Path string
+	// Size is file size in bytes
+	Size int64
+	// ChunkSize is the size of chunks, the last chunk may be shorter
+	ChunkSize int64
+	// Digest is the content address of the file:
+	// the hash of the concatenated chunk digests
+	Digest []byte
+	// Chunks are the digests of each chunk in file order
+	Chunks [][]byte
+}
+
+// HashFile hashes a file in parallel chunks
+//   - ctx: cancels hashing
+//   - fileHash: whole-file and per-chunk digests
+//   - chunks are read using ReadAt by multiple threads,
+//     each worker reusing one chunk-size buffer
+//   - the whole-file digest is the hash of chunk digests, a
+//     content address that only equals the digest of another file if
+//     chunk size and hash function are the same
+func HashFile(ctx context.Context, path string, config ...HashConfig) (fileHash *FileHash, err error) {
+	var c HashConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.ChunkSize <= 0 {
+		c.ChunkSize = DefaultHashChunkSize
+	}
+	if c.Workers <= 0 {
+		c.Workers = runtime.NumCPU()
+	}
+	if c.NewHash == nil {
+		c.NewHash = sha256.New
+	}
+	if c.Moderator == nil {
+		c.Moderator = parl.NewModeratorCore(uint64(c.Workers))
+	}
+
+	var file *os.File
+	if file, err = os.Open(path); err != nil {
+		err = perrors.ErrorfPF("os.Open %w", err)
+		return
+	}
+	defer parl.Close(file, &err)
+	var fileInfo os.FileInfo
+	if fileInfo, err = file.Stat(); err != nil {
+		err = perrors.ErrorfPF("Stat %w", err)
+		return
+	}
+
+	var size = fileInfo.Size()
+	var chunkCount = int((size + c.ChunkSize - 1) / c.ChunkSize)
+	var h = hashJob{
+		ctx:    ctx,
+		file:   file,
+		config: &c,
+		size:   size,
+		chunks: make([][]byte, chunkCount),
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < min(c.Workers, chunkCount); i++ {
+		wg.Add(1)
+		go h.worker(&wg)
+	}
+	wg.Wait()
+	if e, hasValue := h.err.Error(); hasValue {
+		err = e
+		return
+	}
+
+	// whole-file digest
+	var digest = c.NewHash()
+	for _, chunk := range h.chunks {
+		digest.Write(chunk)
+	}
+	fileHash = &FileHash{
+		Path:      path,
+		Size:      size,
+		ChunkSize: c.ChunkSize,
+		Digest:    digest.Sum(nil),
+		Chunks:    h.chunks,
+	}
+
+	return
+}
+
+// hashJob is the shared state of worker threads
+type hashJob struct {
+	ctx    context.Context
+	file   *os.File
+	config *HashConfig
+	size   int64
+	// chunks are chunk digests, each written by one worker
+	chunks [][]byte
+	// next is the index of the next chunk to hash
+	next atomic.Int64
+	// bytes is number of bytes hashed
+	bytes atomic.Int64
+	// isFailed makes workers exit on error
+	isFailed atomic.Bool
+	// err is first error
+	err parl.AtomicError
+}
+
+// worker hashes chunks until all chunks are hashed, error or
+// context cancelation
+func (h *hashJob) worker(wg *sync.WaitGroup) {
+	var err error
+	defer wg.Done()
+	defer h.end(&err)
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	var buffer = make([]byte, h.config.ChunkSize)
+	var hasher = h.config.NewHash()
+	for !h.isFailed.Load() {
+		var index = int(h.next.Add(1) - 1)
+		if index >= len(h.chunks) {
+			return // all chunks hashed return
+		} else if err = h.ctx.Err(); err != nil {
+			return // context canceled return
+		}
+		if err = h.hashChunk(index, buffer, hasher); err != nil {
+			return
+		}
+	}
+}
+
+// hashChunk hashes one chunk holding a moderator ticket
+func (h *hashJob) hashChunk(index int, buffer []byte, hasher hash.Hash) (err error) {
+	defer h.config.Moderator.Ticket()()
+
+	var offset = int64(index) * h.config.ChunkSize
+	var n int
+	if n, err = h.file.ReadAt(buffer[:min(h.config.ChunkSize, h.size-offset)], offset); err != nil && err != io.EOF {
+		err = perrors.ErrorfPF("ReadAt %w", err)
+		return
+	}
+	err = nil
+	hasher.Reset()
+	hasher.Write(buffer[:n])
+	h.chunks[index] = hasher.Sum(nil)
+
+	var bytes = h.bytes.Add(int64(n))
+	if progress := h.config.Progress; progress != nil {
+		progress(HashProgress{
+			Chunk:  index,
+			Chunks: len(h.chunks),
+			Bytes:  bytes,
+			Size:   h.size,
+		})
+	}
+
+	return
+}
+
+// end stores a worker error
+func (h *hashJob) end(errp *error) {
+	if err := *errp; err != nil {
+		h.isFailed.Store(true)
+		h.err.AddErrorSwap(nil, err)
+	}
+}
+
+// String returns the whole-file digest as hexadecimal
+func (f *FileHash) String() (s string) { return hex.EncodeToString(f.Digest) }
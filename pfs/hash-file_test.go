@@ -0,0 +1,90 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHashFile(t *testing.T) {
+	//t.Error("Logging on")
+	var chunkSize int64 = 1000
+	var data = bytes.Repeat([]byte("0123456789"), 250) // 2500 bytes: 3 chunks
+	var expChunks = 3
+	var ctx = context.Background()
+
+	var path = filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// expected digests
+	var expDigest = sha256.New()
+	var expChunkDigests [][]byte
+	for offset := 0; offset < len(data); offset += int(chunkSize) {
+		var sum = sha256.Sum256(data[offset:min(offset+int(chunkSize), len(data))])
+		expChunkDigests = append(expChunkDigests, sum[:])
+		expDigest.Write(sum[:])
+	}
+
+	var progressCount, progressBytes atomic.Int64
+	var fileHash, err = HashFile(ctx, path, HashConfig{
+		ChunkSize: chunkSize,
+		Workers:   2,
+		Progress: func(progress HashProgress) {
+			progressCount.Add(1)
+			for {
+				var b = progressBytes.Load()
+				if progress.Bytes <= b || progressBytes.CompareAndSwap(b, progress.Bytes) {
+					break
+				}
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("HashFile err: %s", err)
+	}
+	if fileHash.Size != int64(len(data)) {
+		t.Errorf("Size %d exp %d", fileHash.Size, len(data))
+	}
+	if len(fileHash.Chunks) != expChunks {
+		t.Fatalf("Chunks %d exp %d", len(fileHash.Chunks), expChunks)
+	}
+	for i, chunk := range fileHash.Chunks {
+		if !bytes.Equal(chunk, expChunkDigests[i]) {
+			t.Errorf("chunk %d bad digest", i)
+		}
+	}
+	if !bytes.Equal(fileHash.Digest, expDigest.Sum(nil)) {
+		t.Errorf("bad Digest %s", fileHash)
+	}
+	if progressCount.Load() != int64(expChunks) || progressBytes.Load() != int64(len(data)) {
+		t.Errorf("progress %d %d exp %d %d", progressCount.Load(), progressBytes.Load(), expChunks, len(data))
+	}
+
+	// canceled context
+	var cancelCtx, cancel = context.WithCancel(ctx)
+	cancel()
+	if _, err = HashFile(cancelCtx, path, HashConfig{ChunkSize: chunkSize}); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled err %v", err)
+	}
+
+	// empty file
+	var emptyPath = filepath.Join(t.TempDir(), "empty")
+	if err = os.WriteFile(emptyPath, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if fileHash, err = HashFile(ctx, emptyPath); err != nil || len(fileHash.Chunks) != 0 {
+		t.Errorf("empty file err %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pio
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// writeDeadliner is a writer with write deadline like [net.Conn]
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) (err error)
+}
+
+// BufferedReader is [bufio.Reader] whose blocking reads are canceled by a context
+//   - on context cancel, an ongoing read is interrupted:
+//   - — a reader with SetReadDeadline like [net.Conn] or [os.File] gets
+//     a past read deadline
+//   - — otherwise a reader implementing [io.Closer] is closed
+//   - — otherwise the read in progress completes and subsequent reads fail
+//   - on context cancel, errors.Is(err, context.Canceled) is true for the returned error,
+//     as opposed to a timeout or closed error from the reader
+//   - [BufferedReader.Release] ends context monitoring
+//   - Reset must not be used
+//   - protocol code in GoGroup threads can be canceled by the thread’s context
+type BufferedReader struct {
+	*bufio.Reader
+	contextStream
+}
+
+// BufferedWriter is [bufio.Writer] whose blocking writes are canceled by a context
+//   - on context cancel, an ongoing write is interrupted:
+//   - — a writer with SetWriteDeadline like [net.Conn] or [os.File] gets
+//     a past write deadline
+//   - — otherwise a writer implementing [io.Closer] is closed
+//   - — otherwise the write in progress completes and subsequent writes fail
+//   - on context cancel, errors.Is(err, context.Canceled) is true for the returned error
+//   - bufio.Writer errors are sticky: after cancel, Write and Flush keep failing
+//   - [BufferedWriter.Release] ends context monitoring
+//   - Reset must not be used
+type BufferedWriter struct {
+	*bufio.Writer
+	contextStream
+}
+
+// contextStream interrupts blocking operations of a stream on context cancel
+type contextStream struct {
+	ctx context.Context
+	// stop ends context monitoring, nil if the stream cannot be interrupted
+	stop func() (stopped bool)
+}
+
+// contextReader is the [io.Reader] read by [bufio.Reader]
+type contextReader struct {
+	reader io.Reader
+	ctx    context.Context
+}
+
+// contextWriter is the [io.Writer] written by [bufio.Writer]
+type contextWriter struct {
+	writer io.Writer
+	ctx    context.Context
+}
+
+// NewBufferedReader returns a buffered reader whose blocking reads are
+// canceled by ctx
+//   - size: optional buffer size, default 4 KiB
+func NewBufferedReader(reader io.Reader, ctx context.Context, size ...int) (bufferedReader *BufferedReader) {
+	if reader == nil {
+		panic(parl.NilError("reader"))
+	} else if ctx == nil {
+		panic(parl.NilError("ctx"))
+	}
+	var b = BufferedReader{contextStream: contextStream{ctx: ctx}}
+	var r io.Reader = &contextReader{reader: reader, ctx: ctx}
+	if len(size) > 0 {
+		b.Reader = bufio.NewReaderSize(r, size[0])
+	} else {
+		b.Reader = bufio.NewReader(r)
+	}
+	if d, ok := reader.(deadliner); ok {
+		b.stop = context.AfterFunc(ctx, func() { d.SetReadDeadline(time.Unix(1, 0)) })
+	} else if c, ok := reader.(io.Closer); ok {
+		b.stop = context.AfterFunc(ctx, func() { c.Close() })
+	}
+
+	return &b
+}
+
+// NewBufferedWriter returns a buffered writer whose blocking writes are
+// canceled by ctx
+//   - size: optional buffer size, default 4 KiB
+func NewBufferedWriter(writer io.Writer, ctx context.Context, size ...int) (bufferedWriter *BufferedWriter) {
+	if writer == nil {
+		panic(parl.NilError("writer"))
+	} else if ctx == nil {
+		panic(parl.NilError("ctx"))
+	}
+	var b = BufferedWriter{contextStream: contextStream{ctx: ctx}}
+	var w io.Writer = &contextWriter{writer: writer, ctx: ctx}
+	if len(size) > 0 {
+		b.Writer = bufio.NewWriterSize(w, size[0])
+	} else {
+		b.Writer = bufio.NewWriter(w)
+	}
+	if d, ok := writer.(writeDeadliner); ok {
+		b.stop = context.AfterFunc(ctx, func() { d.SetWriteDeadline(time.Unix(1, 0)) })
+	} else if c, ok := writer.(io.Closer); ok {
+		b.stop = context.AfterFunc(ctx, func() { c.Close() })
+	}
+
+	return &b
+}
+
+// Release ends context monitoring
+//   - after Release, context cancel no longer interrupts the stream
+//   - didRelease false: the context was already canceled or
+//     Release was already invoked
+//   - idempotent thread-safe
+func (s *contextStream) Release() (didRelease bool) {
+	if s.stop == nil {
+		return
+	}
+	return s.stop()
+}
+
+// Read reads from the underlying reader
+//   - on context cancel, err is context cause
+func (r *contextReader) Read(p []byte) (n int, err error) {
+	if err = r.ctx.Err(); err != nil {
+		err = perrors.Stack(context.Cause(r.ctx))
+		return // canceled before read return
+	}
+	if n, err = r.reader.Read(p); err != nil && r.ctx.Err() != nil {
+		err = perrors.ErrorfPF("%w: %w", context.Cause(r.ctx), err)
+	}
+
+	return
+}
+
+// Write writes to the underlying writer
+//   - on context cancel, err is context cause
+func (w *contextWriter) Write(p []byte) (n int, err error) {
+	if err = w.ctx.Err(); err != nil {
+		err = perrors.Stack(context.Cause(w.ctx))
+		return // canceled before write return
+	}
+	if n, err = w.writer.Write(p); err != nil && w.ctx.Err() != nil {
+		err = perrors.ErrorfPF("%w: %w", context.Cause(w.ctx), err)
+	}
+
+	return
+}
@@ -0,0 +1,81 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBufferedReader(t *testing.T) {
+	//t.Error("Logging on")
+	var line = "line\n"
+
+	var err error
+
+	// deadline-capable reader
+	var conn, peer = net.Pipe()
+	defer conn.Close()
+	defer peer.Close()
+	var ctx, cancel = context.WithCancel(context.Background())
+	var reader = NewBufferedReader(conn, ctx)
+	go peer.Write([]byte(line))
+	var s string
+	if s, err = reader.ReadString('\n'); err != nil || s != line {
+		t.Fatalf("ReadString %q err %v", s, err)
+	}
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err = reader.ReadString('\n'); !errors.Is(err, context.Canceled) {
+		t.Errorf("blocked ReadString err %v", err)
+	}
+	if reader.Release() {
+		t.Error("Release after cancel true")
+	}
+
+	// closable reader
+	var pipeReader, pipeWriter = io.Pipe()
+	defer pipeWriter.Close()
+	ctx, cancel = context.WithCancel(context.Background())
+	reader = NewBufferedReader(pipeReader, ctx)
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err = reader.ReadByte(); !errors.Is(err, context.Canceled) {
+		t.Errorf("blocked ReadByte err %v", err)
+	}
+
+	// Release should be idempotent
+	reader = NewBufferedReader(peer, context.Background())
+	if !reader.Release() {
+		t.Error("Release false")
+	} else if reader.Release() {
+		t.Error("second Release true")
+	}
+}
+
+func TestBufferedWriter(t *testing.T) {
+	//t.Error("Logging on")
+	var err error
+
+	// a net.Pipe write blocks until read
+	var conn, peer = net.Pipe()
+	defer conn.Close()
+	defer peer.Close()
+	var ctx, cancel = context.WithCancel(context.Background())
+	var writer = NewBufferedWriter(conn, ctx)
+	if _, err = writer.WriteString("data"); err != nil {
+		t.Fatalf("WriteString err %s", err)
+	}
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err = writer.Flush(); !errors.Is(err, context.Canceled) {
+		t.Errorf("blocked Flush err %v", err)
+	}
+	if _, err = writer.WriteString("more"); err == nil {
+		t.Error("Write after cancel no error")
+	}
+}
@@ -3,7 +3,7 @@
 ISC License
 */
 
-// Package pio provides a context-cancelable stream copier, a closable buffer, line-based reader
+// Package pio provides a context-cancelable stream copier, a sparse-file copier, a closable buffer, line-based reader
 // and other io functions
 package pio
 
@@ -6,43 +6,120 @@ ISC License
 package pio
 
 import (
+	"context"
 	"errors"
 	"io"
+	"time"
 
 	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
 	"github.com/haraldrudell/parl/pslices"
 	"golang.org/x/exp/slices"
 )
 
 const (
 	newLine           = byte('\n')
+	carriageReturn    = byte('\r')
 	notFound          = -1
 	defaultAllocation = 1024
 	minBuffer         = 512
 	maxLine           = 1024 * 1024
 )
 
+const (
+	// DelimiterLF: lines end with newline “\n”, default
+	DelimiterLF LineDelimiter = iota
+	// DelimiterCRLF: lines end with “\r\n”, a lone newline is part of the line
+	DelimiterCRLF
+	// DelimiterNUL: lines end with a zero byte, like “find -print0”
+	DelimiterNUL
+)
+
+// LineDelimiter is the line ending of [LineReader]
+//   - [DelimiterLF] [DelimiterCRLF] [DelimiterNUL]
+type LineDelimiter uint8
+
+// ErrLineTooLong is returned by [LineReader.ReadLine] for a line longer
+// than [LineReaderConfig.MaxLength]
+//   - the remainder of the line is discarded
+//   - test for ErrLineTooLong using [errors.Is]
+var ErrLineTooLong = errors.New("line too long")
+
+// LineReaderConfig configures [LineReader]
+type LineReaderConfig struct {
+	// MaxLength is maximum line length including delimiter
+	//	- longer lines cause [ErrLineTooLong]
+	//	- 0: lines longer than 1 MiB are returned in parts without error
+	MaxLength int
+	// Delimiter is line ending, default [DelimiterLF]
+	Delimiter LineDelimiter
+	// ReadTimeout is timeout for each read from the stream
+	//	- requires a reader with SetReadDeadline like [net.Conn] or [os.File]
+	//	- 0: no timeout
+	ReadTimeout time.Duration
+}
+
+// deadliner is a reader with read deadline like [net.Conn]
+type deadliner interface {
+	SetReadDeadline(t time.Time) (err error)
+}
+
 // LineReader reads a [io.Reader] stream returing one line per Read invocation
 //   - operates on efficient byte
 //   - does not implement [io.WriteTo] or [io.Closer]
 //   - alternative to using [bufio.Scanner]
+//   - [LineReaderConfig] provides maximum length, delimiter and read timeout
+//   - [LineReader.SendLines] delivers lines to an [parl.AwaitableSlice]
+//     for multi-consumer processing
 type LineReader struct {
 	reader           io.Reader
 	isEof            bool
 	byts             []byte
 	searchStartIndex int
 	nextNewlineIndex int
+	// delimiter is the last byte of the line ending
+	delimiter byte
+	// isCRLF means delimiter must be preceded by carriage return
+	isCRLF bool
+	// maxLength is configured max line length, 0 for none
+	maxLength int
+	// readTimeout is timeout for each read, 0 for none
+	readTimeout time.Duration
+	// deadliner is reader if it supports read deadline
+	deadliner deadliner
+	// isDiscard means the remainder of a too long line is to be discarded
+	isDiscard bool
 }
 
 // NewLineReader reads a [io.Reader] stream returing one line per Read invocation
 //   - operates on efficient byte
 //   - does not implement [io.WriteTo] or [io.Closer]
 //   - alternative to using [bufio.Scanner]
-func NewLineReader(reader io.Reader) (lineReader *LineReader) {
+//   - config: optional max length, delimiter and read timeout
+func NewLineReader(reader io.Reader, config ...*LineReaderConfig) (lineReader *LineReader) {
 	if reader == nil {
 		panic(parl.NilError("reader"))
 	}
-	return &LineReader{reader: reader, byts: []byte{}, nextNewlineIndex: notFound}
+	lineReader = &LineReader{reader: reader, byts: []byte{}, nextNewlineIndex: notFound, delimiter: newLine}
+	if len(config) == 0 || config[0] == nil {
+		return
+	}
+	var c = config[0]
+	switch c.Delimiter {
+	case DelimiterCRLF:
+		lineReader.isCRLF = true
+	case DelimiterNUL:
+		lineReader.delimiter = 0
+	}
+	lineReader.maxLength = c.MaxLength
+	if c.ReadTimeout > 0 {
+		if lineReader.deadliner, _ = reader.(deadliner); lineReader.deadliner == nil {
+			panic(perrors.ErrorfPF("ReadTimeout: reader does not implement SetReadDeadline: %T", reader))
+		}
+		lineReader.readTimeout = c.ReadTimeout
+	}
+
+	return
 }
 
 // Read returns a byte-sequence ending with newline if size of p is sufficient.
@@ -58,7 +135,7 @@ func (rr *LineReader) Read(p []byte) (n int, err error) {
 			var isEofLast bool
 			if index = rr.nextNewlineIndex; index != notFound {
 				rr.nextNewlineIndex = notFound
-			} else if index = slices.Index(rr.byts[rr.searchStartIndex:], newLine); index != -1 {
+			} else if index = slices.Index(rr.byts[rr.searchStartIndex:], rr.delimiter); index != -1 {
 				index += rr.searchStartIndex + 1 // include newline
 				rr.searchStartIndex = index + 1
 			} else if rr.isEof {
@@ -101,14 +178,14 @@ func (rr *LineReader) Read(p []byte) (n int, err error) {
 		}
 
 		// read from rr.reader into p
-		if n, err = rr.reader.Read(p); err != nil {
+		if n, err = rr.read(p); err != nil {
 			if rr.isEof = errors.Is(err, io.EOF); rr.isEof {
 				err = nil
 			} else {
 				return // rr.reader.Read error return
 			}
 		}
-		if index := slices.Index(p[:n], newLine); index != -1 {
+		if index := slices.Index(p[:n], rr.delimiter); index != -1 {
 			index++ // include newline
 			if index < n {
 
@@ -127,9 +204,11 @@ func (rr *LineReader) Read(p []byte) (n int, err error) {
 
 // ReadLine returns full lines, extending p as necessary
 //   - len(line) is number of bytes
-//   - max line length 1 MiB
-//   - line will end with newLine unless 1 MiB or isEOF
+//   - max line length 1 MiB or configured MaxLength
+//   - line will end with delimiter unless 1 MiB or isEOF
 //   - EOF is returned as isEOF true
+//   - a line longer than configured MaxLength returns its first MaxLength bytes
+//     and [ErrLineTooLong]. The remainder of the line is discarded
 func (rr *LineReader) ReadLine(p []byte) (line []byte, isEOF bool, err error) {
 
 	// get line from p
@@ -146,19 +225,46 @@ func (rr *LineReader) ReadLine(p []byte) (line []byte, isEOF bool, err error) {
 	for {
 
 		// read appending to line
+		//	- read is bounded by configured max length
+		var end = cap(line)
+		if rr.maxLength > 0 && end > rr.maxLength {
+			end = rr.maxLength
+		}
 		var n0 int
-		n0, err = rr.Read(line[n:])
+		n0, err = rr.Read(line[n:end])
 		n += n0
 		if err != nil {
 			if isEOF = errors.Is(err, io.EOF); isEOF {
 				err = nil // io.EOF is returned in isEOF, not in err
 			}
+			if rr.isDiscard {
+				rr.isDiscard = false
+				n = 0
+			}
 			return // read error or EOF return
-		} else if n0 > 0 && line[n-1] == newLine {
+		} else if n0 > 0 && rr.isLineEnd(line[:n]) {
+			if rr.isDiscard {
+				// end of too long line
+				rr.isDiscard = false
+				n = 0
+				continue
+			}
 			return // full line return
+		} else if rr.maxLength > 0 {
+			if n < rr.maxLength {
+				// continue below
+			} else if rr.isDiscard {
+				n = 0 // discard and keep reading
+				continue
+			} else {
+				rr.isDiscard = true
+				err = perrors.ErrorfPF("%w: max %d bytes", ErrLineTooLong, rr.maxLength)
+				return // too long line return
+			}
 		} else if cap(line) >= maxLine {
 			return // 1 MiB line return
-		} else if requiredLength := n + minBuffer; requiredLength > cap(line) {
+		}
+		if requiredLength := n + minBuffer; requiredLength > cap(line) {
 			newSlice := make([]byte, requiredLength+(defaultAllocation-requiredLength%defaultAllocation)%defaultAllocation)
 			copy(newSlice, line[:n])
 			line = newSlice
@@ -166,6 +272,101 @@ func (rr *LineReader) ReadLine(p []byte) (line []byte, isEOF bool, err error) {
 	}
 }
 
+// ReadString returns the next line without delimiter
+//   - isEOF true: the stream ended, line may be a final non-terminated line
+//   - err: read error or [ErrLineTooLong]
+func (rr *LineReader) ReadString() (line string, isEOF bool, err error) {
+	var byts []byte
+	if byts, isEOF, err = rr.ReadLine(nil); err != nil && !errors.Is(err, ErrLineTooLong) {
+		return
+	}
+	if rr.isLineEnd(byts) {
+		byts = byts[:len(byts)-1]
+		if rr.isCRLF {
+			byts = byts[:len(byts)-1]
+		}
+	}
+	line = string(byts)
+
+	return
+}
+
+// SendLines reads lines without delimiter and sends them to lines
+//   - returns on end of stream, read error or context cancel
+//   - err nil: end of stream
+//   - on context cancel, a reader with SetReadDeadline is interrupted,
+//     otherwise SendLines returns after the next read completes
+//   - lines is not closed allowing multiple streams to be merged
+//   - too long lines are not sent: with lineErrors non-nil,
+//     their [ErrLineTooLong] is sent there, otherwise it is returned
+func (rr *LineReader) SendLines(ctx context.Context, lines *parl.AwaitableSlice[string], lineErrors ...parl.ErrorSink1) (err error) {
+	if lines == nil {
+		panic(parl.NilError("lines"))
+	}
+	var errorSink parl.ErrorSink1
+	if len(lineErrors) > 0 {
+		errorSink = lineErrors[0]
+	}
+
+	// interrupt ongoing read on context cancel
+	if rr.deadliner == nil {
+		rr.deadliner, _ = rr.reader.(deadliner)
+	}
+	if d := rr.deadliner; d != nil {
+		var stop = context.AfterFunc(ctx, func() { d.SetReadDeadline(time.Unix(1, 0)) })
+		defer stop()
+	}
+
+	for {
+		if err = ctx.Err(); err != nil {
+			err = perrors.Stack(context.Cause(ctx))
+			return // context cancel return
+		}
+		var line string
+		var isEOF bool
+		if line, isEOF, err = rr.ReadString(); err != nil {
+			if ctx.Err() != nil {
+				err = perrors.ErrorfPF("%w: %w", context.Cause(ctx), err)
+				return // context cancel interrupted read
+			} else if !errors.Is(err, ErrLineTooLong) {
+				return // read error return
+			} else if errorSink == nil {
+				return // too long line return
+			}
+			errorSink.AddError(err)
+			err = nil
+			continue
+		}
+		if !isEOF || line != "" {
+			lines.Send(line)
+		}
+		if isEOF {
+			return // end of stream return
+		}
+	}
+}
+
+// isLineEnd returns true if line ends with delimiter
+func (rr *LineReader) isLineEnd(line []byte) (isLineEnd bool) {
+	var n = len(line)
+	if n == 0 || line[n-1] != rr.delimiter {
+		return
+	} else if !rr.isCRLF {
+		return true
+	}
+	return n > 1 && line[n-2] == carriageReturn
+}
+
+// read reads from reader applying any read timeout
+func (rr *LineReader) read(p []byte) (n int, err error) {
+	if rr.readTimeout > 0 {
+		if err = rr.deadliner.SetReadDeadline(time.Now().Add(rr.readTimeout)); perrors.IsPF(&err, "SetReadDeadline %w", err) {
+			return
+		}
+	}
+	return rr.reader.Read(p)
+}
+
 // readToByts returns n and err after reading into rr.byts
 //   - lengthP is max number of bytes to be read
 //   - rr.isEof is updated. io.EOF is not returned
@@ -184,7 +385,7 @@ func (rr *LineReader) readToByts(lengthP int) (n int, err error) {
 		rr.byts = rr.byts[:requiredLength]
 	}
 
-	if n, err = rr.reader.Read(rr.byts[lengthByts:]); err != nil {
+	if n, err = rr.read(rr.byts[lengthByts:]); err != nil {
 		if rr.isEof = errors.Is(err, io.EOF); rr.isEof {
 			err = nil
 		}
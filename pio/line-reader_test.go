@@ -7,8 +7,15 @@ package pio
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/haraldrudell/parl"
 	"github.com/haraldrudell/parl/perrors"
 	"golang.org/x/exp/slices"
 )
@@ -105,3 +112,64 @@ func TestLineReader4k(t *testing.T) {
 		t.Errorf("byts1: %d exp %d", len(byts), len(line1))
 	}
 }
+
+func TestLineReaderConfig(t *testing.T) {
+	//t.Error("Logging on")
+	var (
+		line    string
+		isEOF   bool
+		err     error
+		lines   parl.AwaitableSlice[string]
+		tooLong lineErrorSink
+	)
+
+	// CRLF: lone newline is part of the line
+	var lineReader = NewLineReader(strings.NewReader("a\nb\r\nc"), &LineReaderConfig{Delimiter: DelimiterCRLF})
+	if line, isEOF, err = lineReader.ReadString(); err != nil || isEOF || line != "a\nb" {
+		t.Errorf("CRLF %q %t %v exp %q", line, isEOF, err, "a\nb")
+	}
+	if line, isEOF, err = lineReader.ReadString(); err != nil || !isEOF || line != "c" {
+		t.Errorf("CRLF last %q %t %v exp %q", line, isEOF, err, "c")
+	}
+
+	// NUL
+	lineReader = NewLineReader(strings.NewReader("a\nb\x00c\x00"), &LineReaderConfig{Delimiter: DelimiterNUL})
+	if line, _, err = lineReader.ReadString(); err != nil || line != "a\nb" {
+		t.Errorf("NUL %q %v exp %q", line, err, "a\nb")
+	}
+
+	// MaxLength: too long line is discarded
+	lineReader = NewLineReader(strings.NewReader("12345678\nabc\n"), &LineReaderConfig{MaxLength: 4})
+	if _, _, err = lineReader.ReadString(); !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("MaxLength err %v exp %v", err, ErrLineTooLong)
+	}
+	if line, _, err = lineReader.ReadString(); err != nil || line != "abc" {
+		t.Errorf("after too long %q %v exp %q", line, err, "abc")
+	}
+
+	// SendLines
+	lineReader = NewLineReader(strings.NewReader("a\n123456\nb\n"), &LineReaderConfig{MaxLength: 4})
+	if err = lineReader.SendLines(context.Background(), &lines, &tooLong); err != nil {
+		t.Errorf("SendLines err: %s", perrors.Short(err))
+	}
+	if s := lines.GetAll(); !slices.Equal(s, []string{"a", "b"}) {
+		t.Errorf("SendLines %q exp %q", s, []string{"a", "b"})
+	}
+	if len(tooLong) != 1 {
+		t.Errorf("too long errors %d exp 1", len(tooLong))
+	}
+
+	// ReadTimeout
+	var conn, peer = net.Pipe()
+	defer conn.Close()
+	defer peer.Close()
+	lineReader = NewLineReader(conn, &LineReaderConfig{ReadTimeout: time.Millisecond})
+	if _, _, err = lineReader.ReadString(); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("ReadTimeout err %v exp %v", err, os.ErrDeadlineExceeded)
+	}
+}
+
+// lineErrorSink collects errors
+type lineErrorSink []error
+
+func (s *lineErrorSink) AddError(err error) { *s = append(*s, err) }
@@ -0,0 +1,16 @@
+//go:build !darwin && !linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pio
+
+import "os"
+
+// nextData: locating holes is not supported on this platform
+func nextData(file *os.File, offset, size int64) (dataStart, dataEnd int64, hasData bool, err error) {
+	err = errSparseUnsupported
+	return
+}
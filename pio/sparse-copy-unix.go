@@ -0,0 +1,45 @@
+//go:build darwin || linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pio
+
+import (
+	"errors"
+	"os"
+
+	"github.com/haraldrudell/parl/perrors"
+	"golang.org/x/sys/unix"
+)
+
+// nextData locates the data region at or after offset
+//   - hasData false: the remainder of the file is a hole
+//   - err: errSparseUnsupported if the file system cannot locate holes
+func nextData(file *os.File, offset, size int64) (dataStart, dataEnd int64, hasData bool, err error) {
+	if dataStart, err = file.Seek(offset, unix.SEEK_DATA); err != nil {
+		if errors.Is(err, unix.ENXIO) {
+			err = nil
+			return // no more data return
+		} else if errors.Is(err, unix.EINVAL) || errors.Is(err, unix.ENOTSUP) {
+			err = errSparseUnsupported
+			return // unsupported return
+		}
+		err = perrors.ErrorfPF("Seek SEEK_DATA %w", err)
+		return
+	}
+	if dataEnd, err = file.Seek(dataStart, unix.SEEK_HOLE); err != nil {
+		if !errors.Is(err, unix.ENXIO) {
+			err = perrors.ErrorfPF("Seek SEEK_HOLE %w", err)
+			return
+		}
+		err = nil
+		dataEnd = size
+	}
+	dataEnd = min(dataEnd, size)
+	hasData = dataStart < dataEnd
+
+	return
+}
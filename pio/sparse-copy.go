@@ -0,0 +1,115 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// errSparseUnsupported: the platform or file system cannot locate holes
+var errSparseUnsupported = errors.New("sparse files not supported")
+
+// SparseStats is the result of [CopySparse]
+type SparseStats struct {
+	// Size is the size of the source file
+	Size int64
+	// Copied is the number of bytes read and written
+	Copied int64
+	// Skipped is the number of bytes in holes that were not written
+	Skipped int64
+	// IsSparse is true if holes were located using SEEK_HOLE SEEK_DATA
+	//	- false: the platform or file system does not support locating holes
+	//		and the copy was dense
+	IsSparse bool
+}
+
+// CopySparse copies src to dst preserving holes of a sparse source file
+//   - holes are located using lseek SEEK_DATA and SEEK_HOLE.
+//     Only data regions are read and written using ReadAt WriteAt,
+//     so that holes remain holes at the destination
+//   - if the platform or file system does not support locating holes,
+//     a dense copy is made
+//   - dst is truncated and then extended to the size of src,
+//     so that a trailing hole is preserved
+//   - the file offsets of src and dst are undefined on return
+//   - buf: a buffer that can be used, [NoBuffer]: 1 MiB is allocated
+//   - ctx: cancel aborts copying between buffer-size chunks
+//   - stats: bytes copied and skipped, important for VM images and database files
+func CopySparse(dst, src *os.File, buf []byte, ctx context.Context) (stats SparseStats, err error) {
+	if len(buf) == 0 {
+		buf = make([]byte, copyContextBufferSize)
+	}
+	var fileInfo os.FileInfo
+	if fileInfo, err = src.Stat(); perrors.IsPF(&err, "src.Stat %w", err) {
+		return
+	}
+	stats.Size = fileInfo.Size()
+	if err = dst.Truncate(0); perrors.IsPF(&err, "dst.Truncate %w", err) {
+		return
+	}
+
+	// copy data regions
+	var offset int64
+	for offset < stats.Size {
+		var dataStart, dataEnd int64
+		var hasData bool
+		if dataStart, dataEnd, hasData, err = nextData(src, offset, stats.Size); err != nil {
+			if !errors.Is(err, errSparseUnsupported) || offset > 0 {
+				return
+			}
+			// dense fallback
+			err = nil
+			dataStart, dataEnd, hasData = 0, stats.Size, true
+		} else if offset == 0 {
+			stats.IsSparse = true
+		}
+		if !hasData {
+			break // remainder is a hole
+		}
+		if err = copyRange(dst, src, dataStart, dataEnd, buf, ctx); err != nil {
+			return
+		}
+		stats.Copied += dataEnd - dataStart
+		offset = dataEnd
+	}
+	stats.Skipped = stats.Size - stats.Copied
+
+	// trailing hole
+	if err = dst.Truncate(stats.Size); perrors.IsPF(&err, "dst.Truncate %w", err) {
+		return
+	}
+
+	return
+}
+
+// copyRange copies bytes from start to end using ReadAt WriteAt
+func copyRange(dst io.WriterAt, src io.ReaderAt, start, end int64, buf []byte, ctx context.Context) (err error) {
+	for start < end {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		var p = buf[:min(int64(len(buf)), end-start)]
+		var n int
+		if n, err = src.ReadAt(p, start); err != nil {
+			if !errors.Is(err, io.EOF) || n < len(p) {
+				err = perrors.ErrorfPF("ReadAt %w", err)
+				return
+			}
+			err = nil
+		}
+		if _, err = dst.WriteAt(p[:n], start); perrors.IsPF(&err, "WriteAt %w", err) {
+			return
+		}
+		start += int64(n)
+	}
+
+	return
+}
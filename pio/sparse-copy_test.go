@@ -0,0 +1,74 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pio
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestCopySparse(t *testing.T) {
+	//t.Error("Logging on")
+	var (
+		dir       = t.TempDir()
+		data      = []byte("data")
+		holeSize  = int64(4 * 1024 * 1024)
+		trailSize = holeSize
+		expSize   = holeSize + int64(len(data)) + trailSize
+		ctx       = context.Background()
+	)
+
+	var (
+		src, dst *os.File
+		stats    SparseStats
+		err      error
+	)
+
+	// source: hole, data, trailing hole
+	if src, err = os.Create(filepath.Join(dir, "src")); err != nil {
+		t.Fatalf("os.Create err %s", err)
+	}
+	defer src.Close()
+	if _, err = src.WriteAt(data, holeSize); err != nil {
+		t.Fatalf("WriteAt err %s", err)
+	}
+	if err = src.Truncate(expSize); err != nil {
+		t.Fatalf("Truncate err %s", err)
+	}
+	if dst, err = os.Create(filepath.Join(dir, "dst")); err != nil {
+		t.Fatalf("os.Create err %s", err)
+	}
+	defer dst.Close()
+
+	// CopySparse should copy contents
+	if stats, err = CopySparse(dst, src, NoBuffer, ctx); err != nil {
+		t.Fatalf("CopySparse err %s", perrors.Short(err))
+	}
+	t.Logf("stats %+v", stats)
+	if stats.Size != expSize || stats.Copied+stats.Skipped != expSize {
+		t.Errorf("stats %+v exp Size %d", stats, expSize)
+	}
+	if stats.IsSparse && stats.Skipped == 0 {
+		t.Error("IsSparse without Skipped")
+	}
+	var srcBytes, _ = os.ReadFile(src.Name())
+	var dstBytes, _ = os.ReadFile(dst.Name())
+	if !bytes.Equal(srcBytes, dstBytes) {
+		t.Errorf("dst bad length %d exp %d", len(dstBytes), len(srcBytes))
+	}
+
+	// canceled context should abort
+	var cancelCtx, cancel = context.WithCancel(ctx)
+	cancel()
+	if _, err = CopySparse(dst, src, NoBuffer, cancelCtx); err == nil {
+		t.Error("CopySparse canceled missing error")
+	}
+}
@@ -0,0 +1,236 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// default number of worker threads of a pipeline stage
+	defaultStageWorkers = 1
+)
+
+// StageFunc is the function of a pipeline stage
+//   - err non-nil: the value is dropped and err is sent to the pipeline’s error sink
+//   - a panic is recovered as an error
+type StageFunc[T, U any] func(value T) (result U, err error)
+
+// StageConfig configures a pipeline stage
+type StageConfig struct {
+	// Name is used in errors “stage name: …”
+	//	- empty: “stage1” “stage2” …
+	Name string
+	// Workers is the number of threads executing the stage function
+	//	- 0: 1. With more than one worker, values may be reordered
+	Workers int
+}
+
+// Pipeline connects stages each executed by worker threads with
+// [AwaitableSlice] queues between stages
+//   - T is pipeline input type, U is output type of the last stage
+//   - [NewPipeline] creates a single-stage pipeline, [AddStage] appends
+//   - values are sent by [Pipeline.Send] and
+//     received from [Pipeline.Output]
+//   - [Pipeline.Close] drains: queued values are processed
+//     and each stage closes its output once its input is closed and empty
+//   - [Pipeline.Cancel] discards queued values and ends all stages
+//   - stage errors and panics are sent to the error sink
+//   - [Pipeline.DoneCh] closes once all stages have exited and
+//     Output is closed
+//   - thread-safe
+//
+// Usage:
+//
+//	var errs parl.ErrSlice
+//	var p1 = parl.NewPipeline(parse, &errs, parl.StageConfig{Name: "parse", Workers: 4})
+//	var p = parl.AddStage(p1, store, parl.StageConfig{Name: "store"})
+//	for _, line := range lines {
+//	  p.Send(line)
+//	}
+//	p.Close()
+//	var output = p.Output()
+//	for result := output.Init(); output.Condition(&result); {
+//	  …
+//	}
+type Pipeline[T, U any] struct {
+	// core is shared by all stages
+	core *pipelineCore
+	// input is the input queue of the first stage
+	input *AwaitableSlice[T]
+	// output is the output queue of the last stage
+	output *AwaitableSlice[U]
+	// done closes when the last stage has exited
+	done *Awaitable
+}
+
+// pipelineCore is state shared by all stages of a pipeline
+type pipelineCore struct {
+	errorSink  ErrorSink1
+	isCanceled atomic.Bool
+	// lock makes closers thread-safe
+	lock sync.Mutex
+	// closers close the input of each stage, behind lock
+	closers []func()
+}
+
+// pipelineStage is a stage executed by worker threads
+type pipelineStage[T, U any] struct {
+	core   *pipelineCore
+	name   string
+	fn     StageFunc[T, U]
+	input  *AwaitableSlice[T]
+	output *AwaitableSlice[U]
+	// done closes when all workers have exited
+	done *Awaitable
+	// workers is the number of running workers
+	workers atomic.Int64
+}
+
+// NewPipeline returns a single-stage pipeline
+//   - fn: the stage function
+//   - errorSink: receives stage errors, eg. [ErrSlice]
+//   - config: optional stage name and number of workers
+func NewPipeline[T, U any](fn StageFunc[T, U], errorSink ErrorSink1, config ...StageConfig) (pipeline *Pipeline[T, U]) {
+	if errorSink == nil {
+		panic(NilError("errorSink"))
+	}
+	var core = pipelineCore{errorSink: errorSink}
+	var input AwaitableSlice[T]
+	var output, done = startStage(&core, &input, fn, config...)
+	return &Pipeline[T, U]{core: &core, input: &input, output: output, done: done}
+}
+
+// AddStage returns a pipeline with fn appended as a stage
+//   - the output of pipeline becomes the input of the new stage
+//   - pipeline should not be used after AddStage other than through
+//     the returned pipeline
+//   - config: optional stage name and number of workers
+func AddStage[T, U, V any](pipeline *Pipeline[T, U], fn StageFunc[U, V], config ...StageConfig) (pipeline2 *Pipeline[T, V]) {
+	var output, done = startStage(pipeline.core, pipeline.output, fn, config...)
+	return &Pipeline[T, V]{core: pipeline.core, input: pipeline.input, output: output, done: done}
+}
+
+// Send sends a value into the pipeline
+//   - values sent after Close or Cancel may be discarded
+func (p *Pipeline[T, U]) Send(value T) { p.input.Send(value) }
+
+// SendSlice sends values into the pipeline
+//   - ownership of values is relinquished
+func (p *Pipeline[T, U]) SendSlice(values []T) { p.input.SendSlice(values) }
+
+// Output returns the output queue of the last stage
+//   - Output closes once the pipeline is closed and drained
+func (p *Pipeline[T, U]) Output() (output *AwaitableSlice[U]) { return p.output }
+
+// Close closes pipeline input
+//   - queued values are processed and the pipeline ends
+//     once all stages are drained
+//   - idempotent
+func (p *Pipeline[T, U]) Close() { p.input.EmptyCh() }
+
+// Cancel ends the pipeline discarding queued values
+//   - values in Output are not discarded
+//   - idempotent
+func (p *Pipeline[T, U]) Cancel() {
+	p.core.isCanceled.Store(true)
+	p.core.lock.Lock()
+	var closers = p.core.closers
+	p.core.lock.Unlock()
+
+	for _, closer := range closers {
+		closer()
+	}
+}
+
+// DoneCh returns a channel closing once all stages have exited
+func (p *Pipeline[T, U]) DoneCh() (ch AwaitableCh) { return p.done.Ch() }
+
+// Wait blocks until all stages have exited
+func (p *Pipeline[T, U]) Wait() { <-p.done.Ch() }
+
+// startStage launches the workers of a stage
+func startStage[T, U any](core *pipelineCore, input *AwaitableSlice[T], fn StageFunc[T, U], config ...StageConfig) (
+	output *AwaitableSlice[U],
+	done *Awaitable,
+) {
+	if fn == nil {
+		panic(NilError("fn"))
+	}
+	var cfg StageConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = defaultStageWorkers
+	}
+	output = &AwaitableSlice[U]{}
+	done = &Awaitable{}
+	var s = pipelineStage[T, U]{
+		core:   core,
+		fn:     fn,
+		input:  input,
+		output: output,
+		done:   done,
+	}
+
+	// register input closer
+	core.lock.Lock()
+	core.closers = append(core.closers, func() { input.EmptyCh() })
+	if s.name = cfg.Name; s.name == "" {
+		s.name = "stage" + strconv.Itoa(len(core.closers))
+	}
+	core.lock.Unlock()
+	if core.isCanceled.Load() {
+		input.EmptyCh()
+	}
+
+	s.workers.Store(int64(cfg.Workers))
+	for i := 0; i < cfg.Workers; i++ {
+		go s.worker()
+	}
+
+	return
+}
+
+// worker processes values until input is closed and empty
+func (s *pipelineStage[T, U]) worker() {
+	defer s.workerExit()
+
+	for {
+		var value, hasValue = s.input.AwaitValue()
+		if !hasValue {
+			return // input closed and empty return
+		} else if s.core.isCanceled.Load() {
+			continue // discard value
+		}
+		if result, err := s.invoke(value); err != nil {
+			s.core.errorSink.AddError(perrors.ErrorfPF("stage %s: %w", s.name, err))
+		} else {
+			s.output.Send(result)
+		}
+	}
+}
+
+// invoke executes fn recovering a panic
+func (s *pipelineStage[T, U]) invoke(value T) (result U, err error) {
+	defer RecoverErr(func() DA { return A() }, &err)
+
+	return s.fn(value)
+}
+
+// workerExit closes output once the last worker exits
+func (s *pipelineStage[T, U]) workerExit() {
+	if s.workers.Add(-1) > 0 {
+		return // other workers remain
+	}
+	s.output.EmptyCh()
+	s.done.Close()
+}
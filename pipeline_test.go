@@ -0,0 +1,86 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	//t.Error("Logging on")
+	var errBad = errors.New("bad")
+
+	var errs ErrSlice
+	var p1 = NewPipeline(func(s string) (value int, err error) {
+		if s == "bad" {
+			err = errBad
+			return
+		}
+		return strconv.Atoi(s)
+	}, &errs, StageConfig{Name: "parse", Workers: 3})
+	var p = AddStage(p1, func(value int) (result string, err error) {
+		if value == 0 {
+			panic("zero")
+		}
+		return strconv.Itoa(value * 2), nil
+	})
+
+	// send and drain
+	for _, s := range []string{"1", "bad", "2", "0", "3"} {
+		p.Send(s)
+	}
+	p.Close()
+	p.Wait()
+
+	// output is closed and holds results
+	var output = p.Output()
+	var results []string
+	for value := output.Init(); output.Condition(&value); {
+		results = append(results, value)
+	}
+	slices.Sort(results)
+	if !slices.Equal(results, []string{"2", "4", "6"}) {
+		t.Errorf("results %v exp [2 4 6]", results)
+	}
+	if !output.IsClosed() {
+		t.Error("Output not closed")
+	}
+
+	// errors: stage name and panic
+	var errList = errs.Errors()
+	if len(errList) != 2 {
+		t.Fatalf("errors %d exp 2", len(errList))
+	}
+	if !errors.Is(errList[0], errBad) && !errors.Is(errList[1], errBad) {
+		t.Errorf("errors missing errBad: %v", errList)
+	}
+	var messages = errList[0].Error() + errList[1].Error()
+	if !strings.Contains(messages, "stage parse:") || !strings.Contains(messages, "stage stage2:") {
+		t.Errorf("errors bad stage names: %q", messages)
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	var errs ErrSlice
+	var block = make(chan struct{})
+	var p = NewPipeline(func(value int) (result int, err error) {
+		<-block
+		return value, nil
+	}, &errs)
+	p.Send(1)
+	p.Send(2)
+
+	p.Cancel()
+	close(block)
+	p.Wait()
+	if n := len(p.Output().GetAll()); n > 1 {
+		t.Errorf("output after cancel %d exp max 1", n)
+	}
+}
@@ -43,6 +43,9 @@ type LogInstance struct {
 	// updated by [LogInstance.SetRegexp]
 	//	- used to determine function-level debug
 	infoRegexp atomic.Pointer[regexp.Regexp]
+	// ring is optional recording of recent log lines
+	//	- updated by [LogInstance.SetRing]
+	ring atomic.Pointer[LogRing]
 
 	// outLock protects writer and output ensuring thread-safety
 	outLock sync.Mutex
@@ -104,6 +107,7 @@ func (g *LogInstance) Log(format string, a ...interface{}) {
 // Logw always prints
 //   - Logw does not ensure ending newline
 func (g *LogInstance) Logw(format string, a ...interface{}) {
+	g.record(false, format, a...)
 	g.invokeWriter(Sprintf(format, a...))
 }
 
@@ -112,6 +116,7 @@ func (g *LogInstance) Logw(format string, a ...interface{}) {
 //   - if debug is enabled, code location is appended
 func (g *LogInstance) Info(format string, a ...interface{}) {
 	if g.isSilence.Load() {
+		g.record(true, format, a...)
 		return
 	}
 	g.doLog(format, a...)
@@ -124,15 +129,18 @@ func (g *LogInstance) Debug(format string, a ...any) {
 	if !g.isDebug.Load() {
 		regExp := g.infoRegexp.Load()
 		if regExp == nil {
+			g.record(true, format, a...)
 			return // debug: false regexp: nil return: noop
 		}
 		cloc = pruntime.NewCodeLocation(g.stackFramesToSkip + logInstDebugFrameDelta)
 		if !regExp.MatchString(cloc.FuncName) {
+			g.record(true, format, a...)
 			return // debug: false regexp: no match return: noop
 		}
 	} else {
 		cloc = pruntime.NewCodeLocation(g.stackFramesToSkip + logInstDebugFrameDelta)
 	}
+	g.record(false, format, a...)
 	g.invokeOutput(pruntime.AppendLocation(Sprintf(format, a...), cloc))
 }
 
@@ -158,6 +166,10 @@ func (g *LogInstance) GetDebug(skipFrames int) (debug func(format string, a ...a
 		doPrint = regExp != nil && regExp.MatchString(cloc.FuncName)
 	}
 	if !doPrint {
+		// with a ring, suppressed debug is recorded
+		if ring := g.ring.Load(); ring != nil {
+			return func(format string, a ...any) { ring.Record(true, format, a...) }
+		}
 		return NoPrint // no debug return: no-op function
 	}
 
@@ -188,6 +200,7 @@ func (g *LogInstance) GetD(skipFrames int) (debug func(format string, a ...inter
 //   - D is meant for temporary output intended to be removed
 //     prior to check-in
 func (g *LogInstance) D(format string, a ...interface{}) {
+	g.record(false, format, a...)
 	g.invokeOutput(
 		pruntime.AppendLocation(
 			Sprintf(format, a...),
@@ -287,6 +300,20 @@ func (g *LogInstance) IsThisDebugN(skipFrames int) (isDebug bool) {
 	return
 }
 
+// SetRing enables recording of recent log lines into ring
+//   - ring nil: recording is disabled
+//   - suppressed Debug and Info invocations are recorded, too
+//   - Thread-safe
+func (g *LogInstance) SetRing(ring *LogRing) {
+	g.ring.Store(ring)
+}
+
+// Ring returns any ring recording recent log lines
+//   - nil if recording is not enabled
+func (g *LogInstance) Ring() (ring *LogRing) {
+	return g.ring.Load()
+}
+
 // IsSilent if true it means that Info does not print
 func (g *LogInstance) IsSilent() (isSilent bool) {
 	return g.isSilence.Load()
@@ -312,8 +339,16 @@ func (g *LogInstance) invokeWriter(s string) {
 	}
 }
 
+// record adds a log invocation to any ring
+func (g *LogInstance) record(isSuppressed bool, format string, a ...any) {
+	if ring := g.ring.Load(); ring != nil {
+		ring.Record(isSuppressed, format, a...)
+	}
+}
+
 // doLog invokes the writer’s output function for Log and Info
 func (g *LogInstance) doLog(format string, a ...interface{}) {
+	g.record(false, format, a...)
 	s := Sprintf(format, a...)
 	if g.isDebug.Load() {
 		s = pruntime.AppendLocation(s, pruntime.NewCodeLocation(g.stackFramesToSkip))
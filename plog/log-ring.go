@@ -0,0 +1,115 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package plog
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// time format for materialized log-ring lines
+	logRingTimeFormat = "15:04:05.000"
+	// annotation for lines that were not output
+	logRingSuppressed = "(suppressed)"
+	// maximum length in bytes of a recorded suppressed line
+	logRingMaxSuppressed = 1024
+	// appended to a truncated line
+	logRingEllipsis = "…"
+)
+
+// LogRing is a bounded in-memory ring of the most recent log lines
+//   - LogRing records all log invocations including suppressed Debug and Info
+//   - lines are formatted when recorded so that arguments are not retained
+//     and later modification of arguments does not affect recorded lines
+//   - suppressed lines are truncated to 1 KiB
+//   - intended to provide post-mortem context in crash reports
+//   - thread-safe
+type LogRing struct {
+	// lock makes entries and next thread-safe
+	lock sync.Mutex
+	// entries is the ring, len at most capacity
+	entries []logRingEntry
+	// next is index in entries for next write once the ring is full
+	next int
+	// capacity is the maximum number of entries
+	capacity int
+}
+
+// logRingEntry is a recorded log invocation
+type logRingEntry struct {
+	at time.Time
+	// isSuppressed indicates that the line was not output
+	isSuppressed bool
+	// line is the formatted log line without trailing newline
+	line string
+}
+
+// NewLogRing returns a ring of the size most recent log lines
+//   - size less than 1 is 1
+func NewLogRing(size int) (ring *LogRing) {
+	if size < 1 {
+		size = 1
+	}
+	return &LogRing{capacity: size}
+}
+
+// Record adds a log invocation to the ring
+//   - isSuppressed: true if the line was not output, such as Debug when
+//     debug is not enabled
+//   - Thread-safe
+func (r *LogRing) Record(isSuppressed bool, format string, a ...any) {
+	var entry = logRingEntry{
+		at:           time.Now(),
+		isSuppressed: isSuppressed,
+		line:         strings.TrimSuffix(Sprintf(format, a...), "\n"),
+	}
+	if isSuppressed && len(entry.line) > logRingMaxSuppressed {
+		// truncate at rune boundary
+		var n = logRingMaxSuppressed
+		for n > 0 && !utf8.RuneStart(entry.line[n]) {
+			n--
+		}
+		entry.line = entry.line[:n] + logRingEllipsis
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.entries) < r.capacity {
+		r.entries = append(r.entries, entry)
+		return
+	}
+	r.entries[r.next] = entry
+	if r.next++; r.next == r.capacity {
+		r.next = 0
+	}
+}
+
+// Lines returns the recorded log lines, oldest first
+//   - each line is prefixed with time of day, suppressed lines are annotated
+//   - a trailing newline is removed
+//   - Thread-safe
+func (r *LogRing) Lines() (lines []string) {
+	r.lock.Lock()
+	var entries = make([]logRingEntry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	entries = append(entries, r.entries[:r.next]...)
+	r.lock.Unlock()
+
+	lines = make([]string, len(entries))
+	for i, entry := range entries {
+		var sL = []string{entry.at.Format(logRingTimeFormat)}
+		if entry.isSuppressed {
+			sL = append(sL, logRingSuppressed)
+		}
+		sL = append(sL, entry.line)
+		lines[i] = strings.Join(sL, "\x20")
+	}
+
+	return
+}
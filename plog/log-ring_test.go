@@ -0,0 +1,78 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package plog
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLogRing(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		size       = 2
+		text1      = "one"
+		text2      = "two %d"
+		value2     = 2
+		text3      = "three"
+		expLine2   = "two 2"
+		expLengths = size
+	)
+	var (
+		ring  = NewLogRing(size)
+		log   = NewLog(io.Discard)
+		lines []string
+	)
+
+	log.SetRing(ring)
+	if log.Ring() != ring {
+		t.Error("Ring bad")
+	}
+
+	// text1 is overwritten, text2 is output, text3 suppressed
+	log.Log(text1)
+	log.Log(text2, value2)
+	log.Debug(text3)
+	lines = ring.Lines()
+	t.Logf("lines:\n%s", strings.Join(lines, "\n"))
+
+	if len(lines) != expLengths {
+		t.Fatalf("lines len %d exp %d", len(lines), expLengths)
+	}
+	if !strings.HasSuffix(lines[0], expLine2) || strings.Contains(lines[0], logRingSuppressed) {
+		t.Errorf("line 0 %q exp suffix %q", lines[0], expLine2)
+	}
+	if !strings.HasSuffix(lines[1], text3) || !strings.Contains(lines[1], logRingSuppressed) {
+		t.Errorf("line 1 %q exp suppressed %q", lines[1], text3)
+	}
+}
+
+func TestLogRingRecord(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		format  = "buffer: %s"
+		expLine = "buffer: abc"
+	)
+	var ring = NewLogRing(2)
+
+	// modifying an argument after Record should not affect the line
+	var buffer = []byte("abc")
+	ring.Record(false, format, buffer)
+	copy(buffer, "xyz")
+	var lines = ring.Lines()
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], expLine) {
+		t.Errorf("lines %q exp suffix %q", lines, expLine)
+	}
+
+	// a long suppressed line should be truncated
+	ring.Record(true, strings.Repeat("ä", logRingMaxSuppressed))
+	lines = ring.Lines()
+	if line := lines[1]; len(line) > logRingMaxSuppressed+len(logRingTimeFormat)+len(logRingSuppressed)+len(logRingEllipsis)+2 ||
+		!strings.HasSuffix(line, "ä"+logRingEllipsis) {
+		t.Errorf("truncated line length %d", len(line))
+	}
+}
@@ -0,0 +1,332 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// PreferSystem uses addresses in resolver order, default
+	PreferSystem IPPreference = iota
+	// PreferIPv4 orders IPv4 addresses first
+	PreferIPv4
+	// PreferIPv6 orders IPv6 addresses first
+	PreferIPv6
+	// IPv4Only excludes IPv6 addresses
+	IPv4Only
+	// IPv6Only excludes IPv4 addresses
+	IPv6Only
+)
+
+// IPPreference is IPv4/IPv6 preference of [AddressPolicy]
+//   - [PreferSystem] [PreferIPv4] [PreferIPv6] [IPv4Only] [IPv6Only]
+type IPPreference uint8
+
+const (
+	// ScopeLoopback is “127.0.0.1” “::1”
+	ScopeLoopback AddressScope = 1 << iota
+	// ScopeLinkLocal is “169.254.0.0/16” “fe80::/10” and
+	// link-local and interface-local multicast
+	ScopeLinkLocal
+	// ScopePrivate is IPv6 unique local addresses ULA “fc00::/7” and
+	// IPv4 RFC 1918 private addresses “10.0.0.0/8” “172.16.0.0/12” “192.168.0.0/16”
+	ScopePrivate
+	// ScopeGlobal is any other address
+	ScopeGlobal
+	// ScopeAll allows addresses of any scope
+	ScopeAll = ScopeLoopback | ScopeLinkLocal | ScopePrivate | ScopeGlobal
+)
+
+// AddressScope is a bit-field of address scopes
+//   - [ScopeLoopback] [ScopeLinkLocal] [ScopePrivate] [ScopeGlobal] [ScopeAll]
+type AddressScope uint8
+
+// AddressPolicy controls address selection for dialing, listening and
+// interface addresses
+//   - IPv4/IPv6 preference, address-scope filtering and
+//     source-address selection for outgoing connections
+//   - the zero-value policy and a nil policy allow all addresses
+//     in resolver order
+//   - a process-wide policy is set by [SetAddressPolicy]. It is applied by
+//     [Listen] and [SocketListener.Listen]
+type AddressPolicy struct {
+	// Preference is IPv4/IPv6 ordering and exclusion
+	Preference IPPreference
+	// Scopes are allowed address scopes
+	//	- 0: [ScopeAll]
+	Scopes AddressScope
+	// Source is optional local address for outgoing connections
+	//	- must be assigned to a local interface
+	Source netip.Addr
+	// SourceInterface is optional interface name like “eth0” whose
+	// addresses are used as source for outgoing connections
+	//	- ignored if Source is valid
+	SourceInterface string
+}
+
+// addressPolicy is the process-wide policy
+var addressPolicy atomic.Pointer[AddressPolicy]
+
+// SetAddressPolicy sets the process-wide address policy
+//   - policy nil: all addresses are allowed
+//   - thread-safe
+func SetAddressPolicy(policy *AddressPolicy) { addressPolicy.Store(policy) }
+
+// GetAddressPolicy returns the process-wide address policy
+//   - policy may be nil, which allows all addresses
+//   - thread-safe
+func GetAddressPolicy() (policy *AddressPolicy) { return addressPolicy.Load() }
+
+// AddrScope returns the scope of addr
+//   - IPv4-mapped IPv6 addresses have IPv4 scope
+//   - unspecified and invalid addresses are [ScopeGlobal]
+func AddrScope(addr netip.Addr) (scope AddressScope) {
+	addr = addr.Unmap()
+	switch {
+	case addr.IsLoopback():
+		return ScopeLoopback
+	case addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast(), addr.IsInterfaceLocalMulticast():
+		return ScopeLinkLocal
+	case addr.IsPrivate():
+		return ScopePrivate
+	}
+	return ScopeGlobal
+}
+
+// Allows returns whether addr may be used
+//   - the unspecified address “0.0.0.0” “::” is allowed if its family is allowed
+//   - nil policy: true
+func (p *AddressPolicy) Allows(addr netip.Addr) (isAllowed bool) {
+	if p == nil {
+		return true
+	}
+	addr = addr.Unmap()
+	if p.Preference == IPv4Only && !addr.Is4() ||
+		p.Preference == IPv6Only && !addr.Is6() {
+		return false // excluded family return
+	} else if addr.IsUnspecified() {
+		return true // all addresses of family return
+	}
+	var scopes = p.Scopes
+	if scopes == 0 {
+		scopes = ScopeAll
+	}
+	return scopes&AddrScope(addr) != 0
+}
+
+// Filter returns allowed addresses ordered by preference
+//   - order among addresses of the same family is retained
+//   - addrs is not modified
+func (p *AddressPolicy) Filter(addrs []netip.Addr) (allowed []netip.Addr) {
+	for _, addr := range addrs {
+		if p.Allows(addr) {
+			allowed = append(allowed, addr)
+		}
+	}
+	if p != nil && (p.Preference == PreferIPv4 || p.Preference == PreferIPv6) {
+		var want4First = p.Preference == PreferIPv4
+		slices.SortStableFunc(allowed, func(a, b netip.Addr) (result int) {
+			var a4, b4 = a.Unmap().Is4(), b.Unmap().Is4()
+			if a4 == b4 {
+				return 0
+			} else if a4 == want4First {
+				return -1
+			}
+			return 1
+		})
+	}
+
+	return
+}
+
+// FilterPrefixes returns prefixes whose address is allowed, ordered by preference
+func (p *AddressPolicy) FilterPrefixes(prefixes []netip.Prefix) (allowed []netip.Prefix) {
+	var addrs = make([]netip.Addr, len(prefixes))
+	var m = make(map[netip.Addr][]netip.Prefix, len(prefixes))
+	for i, prefix := range prefixes {
+		addrs[i] = prefix.Addr()
+		m[addrs[i]] = append(m[addrs[i]], prefix)
+	}
+	for _, addr := range p.Filter(addrs) {
+		allowed = append(allowed, m[addr][0])
+		m[addr] = m[addr][1:]
+	}
+
+	return
+}
+
+// InterfaceAddrs returns allowed addresses assigned to netInterface
+//   - ordered by preference
+func (p *AddressPolicy) InterfaceAddrs(netInterface *net.Interface) (prefixes []netip.Prefix, err error) {
+	var i4, i6 []netip.Prefix
+	if i4, i6, err = InterfaceAddrs(netInterface); err != nil {
+		return
+	}
+	prefixes = p.FilterPrefixes(append(i4, i6...))
+
+	return
+}
+
+// Network narrows network to a single IP family for IPv4Only and IPv6Only
+//   - “tcp” becomes “tcp4” for IPv4Only
+//   - other networks are returned unchanged
+func (p *AddressPolicy) Network(network Network) (network2 Network) {
+	network2 = network
+	if p == nil {
+		return
+	}
+	switch p.Preference {
+	case IPv4Only:
+		switch network {
+		case NetworkTCP, NetworkDefault:
+			network2 = NetworkTCP4
+		case NetworkUDP:
+			network2 = NetworkUDP4
+		case NetworkIP:
+			network2 = NetworkIP4
+		}
+	case IPv6Only:
+		switch network {
+		case NetworkTCP, NetworkDefault:
+			network2 = NetworkTCP6
+		case NetworkUDP:
+			network2 = NetworkUDP6
+		case NetworkIP:
+			network2 = NetworkIP6
+		}
+	}
+
+	return
+}
+
+// ListenNetwork checks that the policy allows listening on socketAddress
+//   - network: the network narrowed by policy
+//   - err: the literal address of socketAddress is not allowed
+func (p *AddressPolicy) ListenNetwork(socketAddress SocketAddress) (network Network, err error) {
+	network = p.Network(socketAddress.Network())
+	if addrPort := socketAddress.AddrPort(); addrPort.IsValid() && !p.Allows(addrPort.Addr()) {
+		err = perrors.ErrorfPF("address policy does not allow listening on: %s", addrPort)
+	}
+
+	return
+}
+
+// SourceAddr returns the local address to use for connecting to remote
+//   - addr invalid: no source address is configured, the kernel selects
+//   - Source is used if of remote’s family
+//   - for SourceInterface, an allowed address of remote’s family is used,
+//     preferring the scope of remote
+func (p *AddressPolicy) SourceAddr(remote netip.Addr) (addr netip.Addr, err error) {
+	if p == nil {
+		return
+	}
+	remote = remote.Unmap()
+	if p.Source.IsValid() {
+		if p.Source.Unmap().Is4() == remote.Is4() {
+			addr = p.Source
+		}
+		return
+	} else if p.SourceInterface == "" {
+		return
+	}
+
+	var netInterface *net.Interface
+	if netInterface, err = net.InterfaceByName(p.SourceInterface); perrors.IsPF(&err, "net.InterfaceByName %q %w", p.SourceInterface, err) {
+		return
+	}
+	var prefixes []netip.Prefix
+	if prefixes, err = p.InterfaceAddrs(netInterface); err != nil {
+		return
+	}
+	var remoteScope = AddrScope(remote)
+	for _, prefix := range prefixes {
+		var a = prefix.Addr().Unmap()
+		if a.Is4() != remote.Is4() {
+			continue
+		} else if !addr.IsValid() {
+			addr = a
+		}
+		if AddrScope(a) == remoteScope {
+			addr = a
+			break
+		}
+	}
+	// link-local IPv6 source requires zone
+	if addr.IsValid() && IsNonGlobalIPv6(addr) && addr.Zone() == "" {
+		addr = addr.WithZone(netInterface.Name)
+	}
+
+	return
+}
+
+// DialContext connects to address using the policy
+//   - network: “tcp” “tcp4” “tcp6” “udp” “udp4” “udp6”
+//   - address: “example.com:443” or literal “1.2.3.4:443”
+//   - host names are resolved and resulting addresses filtered and
+//     ordered by policy, then attempted in order
+//   - the source address is selected by [AddressPolicy.SourceAddr]
+//   - nil policy: all resolved addresses in resolver order
+func (p *AddressPolicy) DialContext(ctx context.Context, network, address string) (conn net.Conn, err error) {
+	var host, portString string
+	if host, portString, err = net.SplitHostPort(address); perrors.IsPF(&err, "net.SplitHostPort %w", err) {
+		return
+	}
+	var port uint64
+	if port, err = strconv.ParseUint(portString, 10, 16); perrors.IsPF(&err, "port %q %w", portString, err) {
+		return
+	}
+
+	// candidate addresses
+	var addrs []netip.Addr
+	if addr, e := netip.ParseAddr(host); e == nil {
+		addrs = []netip.Addr{addr}
+	} else if addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host); perrors.IsPF(&err, "LookupNetIP %q %w", host, err) {
+		return
+	}
+	if addrs = p.Filter(addrs); len(addrs) == 0 {
+		err = perrors.ErrorfPF("address policy allows no address for: %q", host)
+		return
+	}
+	var network2 = p.Network(Network(network)).String()
+
+	// attempt addresses in order
+	for _, addr := range addrs {
+		var dialer net.Dialer
+		var source netip.Addr
+		var e error
+		if source, e = p.SourceAddr(addr); e != nil {
+			err = perrors.AppendError(err, e)
+			continue
+		} else if source.IsValid() {
+			var sourcePort = netip.AddrPortFrom(source, 0)
+			switch Network(network) {
+			case NetworkUDP, NetworkUDP4, NetworkUDP6:
+				dialer.LocalAddr = net.UDPAddrFromAddrPort(sourcePort)
+			default:
+				dialer.LocalAddr = net.TCPAddrFromAddrPort(sourcePort)
+			}
+		}
+		var remote = netip.AddrPortFrom(addr, uint16(port)).String()
+		if conn, e = dialer.DialContext(ctx, network2, remote); e == nil {
+			err = nil
+			return // connected return
+		}
+		err = perrors.AppendError(err, perrors.ErrorfPF("dial %s %s: %w", network2, remote, e))
+		if ctx.Err() != nil {
+			return // context canceled return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,95 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestAddressPolicy(t *testing.T) {
+	//t.Error("Logging on")
+	var (
+		v4Global    = netip.MustParseAddr("1.2.3.4")
+		v4Private   = netip.MustParseAddr("192.168.1.1")
+		v6Global    = netip.MustParseAddr("2001:db8::1")
+		v6ULA       = netip.MustParseAddr("fd00::1")
+		v6LinkLocal = netip.MustParseAddr("fe80::1")
+		addrs       = []netip.Addr{v6Global, v4Global, v6ULA, v4Private, v6LinkLocal}
+		nilPolicy   *AddressPolicy
+	)
+
+	// nil policy allows all in order
+	if allowed := nilPolicy.Filter(addrs); !slices.Equal(allowed, addrs) {
+		t.Errorf("nil Filter %v exp %v", allowed, addrs)
+	}
+
+	// scopes
+	if s := AddrScope(v6LinkLocal); s != ScopeLinkLocal {
+		t.Errorf("AddrScope %d exp %d", s, ScopeLinkLocal)
+	}
+	if s := AddrScope(v4Private); s != ScopePrivate {
+		t.Errorf("AddrScope %d exp %d", s, ScopePrivate)
+	}
+
+	// PreferIPv4 with global and private
+	var policy = AddressPolicy{Preference: PreferIPv4, Scopes: ScopeGlobal | ScopePrivate}
+	var exp = []netip.Addr{v4Global, v4Private, v6Global, v6ULA}
+	if allowed := policy.Filter(addrs); !slices.Equal(allowed, exp) {
+		t.Errorf("PreferIPv4 Filter %v exp %v", allowed, exp)
+	}
+
+	// IPv6Only global
+	policy = AddressPolicy{Preference: IPv6Only, Scopes: ScopeGlobal}
+	exp = []netip.Addr{v6Global}
+	if allowed := policy.Filter(addrs); !slices.Equal(allowed, exp) {
+		t.Errorf("IPv6Only Filter %v exp %v", allowed, exp)
+	}
+	if n := policy.Network(NetworkTCP); n != NetworkTCP6 {
+		t.Errorf("Network %s exp %s", n, NetworkTCP6)
+	}
+	// unspecified address is allowed for its family
+	if !policy.Allows(netip.IPv6Unspecified()) {
+		t.Error("Allows IPv6Unspecified false")
+	}
+	if _, err := policy.ListenNetwork(NewSocketAddressLiteral(NetworkTCP, netip.AddrPortFrom(v4Global, 0))); err == nil {
+		t.Error("ListenNetwork IPv4 no error")
+	}
+}
+
+func TestAddressPolicyDial(t *testing.T) {
+	//t.Error("Logging on")
+	var (
+		ctx    = context.Background()
+		policy = AddressPolicy{Preference: IPv4Only, Scopes: ScopeLoopback}
+	)
+
+	var listener, err = net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen err: %s", err)
+	}
+	defer listener.Close()
+	go func() {
+		if conn, e := listener.Accept(); e == nil {
+			conn.Close()
+		}
+	}()
+
+	var conn net.Conn
+	if conn, err = policy.DialContext(ctx, "tcp", listener.Addr().String()); err != nil {
+		t.Fatalf("DialContext err: %s", err)
+	}
+	conn.Close()
+
+	// global-only policy rejects loopback
+	policy.Scopes = ScopeGlobal
+	if _, err = policy.DialContext(ctx, "tcp", listener.Addr().String()); err == nil {
+		t.Error("DialContext global-only no error")
+	}
+}
@@ -0,0 +1,398 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/netip"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// default overall request timeout including reading the body
+	defaultHTTPTimeout = 30 * time.Second
+	// default timeout for establishing a TCP connection
+	defaultHTTPDialTimeout = 10 * time.Second
+	// default timeout for TLS handshake
+	defaultHTTPTLSTimeout = 10 * time.Second
+	// default timeout awaiting response headers
+	defaultHTTPResponseHeaderTimeout = 30 * time.Second
+	// default time an idle connection remains in the pool
+	defaultHTTPIdleTimeout = 90 * time.Second
+	// default number of idle connections kept per host
+	defaultHTTPMaxIdlePerHost = 8
+	// TCP keep-alive period
+	httpKeepAlive = 30 * time.Second
+)
+
+// HTTPClientConfig configures [NewHTTPClient]
+//   - zero-value fields use defaults
+type HTTPClientConfig struct {
+	// Timeout is overall request timeout, [http.Client.Timeout]
+	//	- 0: 30 s, negative: none
+	Timeout time.Duration
+	// DialTimeout is timeout for establishing TCP connections
+	//	- 0: 10 s
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout is timeout for TLS handshake
+	//	- 0: 10 s
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout is timeout after writing a request
+	// awaiting response headers
+	//	- 0: 30 s
+	ResponseHeaderTimeout time.Duration
+	// IdleConnTimeout is how long an idle connection remains in the pool
+	//	- 0: 90 s
+	IdleConnTimeout time.Duration
+	// MaxIdleConnsPerHost is idle connections kept per host
+	//	- 0: 8
+	MaxIdleConnsPerHost int
+	// TLSConfig is optional TLS configuration
+	TLSConfig *tls.Config
+	// Resolver is optional caching resolver used for dialing
+	//	- nil: [net.Dialer] resolves
+	Resolver *Resolver
+	// Policy is optional address policy filtering and ordering
+	// resolved addresses when Resolver is present
+	Policy *AddressPolicy
+	// Dialer is optional dialer for connections, eg. [ProxyDialer]
+	//	- when present, Resolver and Policy are not used and
+	//		proxy environment variables are not applied by the transport
+	Dialer ContextDialer
+	// OnTiming optionally receives timings of each request
+	OnTiming func(timing HTTPTiming)
+	// TimingSink optionally receives timings of each request,
+	// ie. [parl.AwaitableSlice]
+	TimingSink parl.Sink[HTTPTiming]
+}
+
+// HTTPTiming is connection and latency information for a request
+//   - DNS Dial and TLS are zero for reused connections
+type HTTPTiming struct {
+	// Host is request host “example.com:443”
+	Host string
+	// Remote is the connection’s remote address
+	Remote string
+	// Reused is true if an idle pooled connection was used
+	Reused bool
+	// DNS is duration of name resolution
+	DNS time.Duration
+	// Dial is duration of TCP connect
+	Dial time.Duration
+	// TLS is duration of TLS handshake
+	TLS time.Duration
+	// FirstByte is the duration from request start
+	// until the first response byte was received
+	FirstByte time.Duration
+	// Err is the request error if any
+	Err error
+}
+
+// HTTPClientStats are connection-pool metrics of [HTTPClient]
+type HTTPClientStats struct {
+	// Requests is number of requests
+	Requests uint64
+	// Dials is number of connections attempted
+	Dials uint64
+	// DialErrors is number of failed connection attempts
+	DialErrors uint64
+	// Reused is number of requests using an idle pooled connection
+	Reused uint64
+	// OpenConns is the current number of open connections
+	OpenConns int64
+}
+
+// HTTPClient is a factory for [http.Client] with observability
+//   - clients share a pooled transport with sane timeouts
+//   - dialing optionally uses a caching [Resolver] and [AddressPolicy]
+//   - per-request connection timings are provided to a callback or sink
+//   - [HTTPClient.Stats] returns connection-pool metrics
+//   - thread-safe
+//
+// Usage:
+//
+//	var timings parl.AwaitableSlice[pnet.HTTPTiming]
+//	var factory = pnet.NewHTTPClient(&pnet.HTTPClientConfig{
+//	  Resolver:   pnet.NewResolver(nil, 0, 0),
+//	  TimingSink: &timings,
+//	})
+//	defer factory.CloseIdleConnections()
+//	var resp, err = factory.Client().Get(url)
+type HTTPClient struct {
+	config    HTTPClientConfig
+	transport *http.Transport
+	// metrics
+	requests, dials, dialErrors, reused atomic.Uint64
+	openConns                           atomic.Int64
+}
+
+// httpClientTrace collects timings of a single request
+type httpClientTrace struct {
+	lock   sync.Mutex
+	timing HTTPTiming
+	// start times behind lock
+	t0, dnsStart, connectStart, tlsStart time.Time
+}
+
+// httpClientTransport is [http.RoundTripper] adding tracing
+type httpClientTransport struct{ c *HTTPClient }
+
+// httpClientConn is a connection counted by [HTTPClient]
+type httpClientConn struct {
+	net.Conn
+	c         *HTTPClient
+	closeOnce sync.Once
+}
+
+// NewHTTPClient returns a factory for http clients
+//   - config: optional configuration, nil or missing is defaults
+func NewHTTPClient(config ...*HTTPClientConfig) (httpClient *HTTPClient) {
+	var c = HTTPClient{}
+	if len(config) > 0 && config[0] != nil {
+		c.config = *config[0]
+	}
+	var cfg = &c.config
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultHTTPTimeout
+	} else if cfg.Timeout < 0 {
+		cfg.Timeout = 0
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = defaultHTTPDialTimeout
+	}
+	if cfg.TLSHandshakeTimeout <= 0 {
+		cfg.TLSHandshakeTimeout = defaultHTTPTLSTimeout
+	}
+	if cfg.ResponseHeaderTimeout <= 0 {
+		cfg.ResponseHeaderTimeout = defaultHTTPResponseHeaderTimeout
+	}
+	if cfg.IdleConnTimeout <= 0 {
+		cfg.IdleConnTimeout = defaultHTTPIdleTimeout
+	}
+	if cfg.MaxIdleConnsPerHost <= 0 {
+		cfg.MaxIdleConnsPerHost = defaultHTTPMaxIdlePerHost
+	}
+	var proxy = http.ProxyFromEnvironment
+	if cfg.Dialer != nil {
+		proxy = nil // the dialer proxies
+	}
+	c.transport = &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           c.dialContext,
+		ForceAttemptHTTP2:     true,
+		TLSClientConfig:       cfg.TLSConfig,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &c
+}
+
+// Client returns an http client using the shared transport
+func (c *HTTPClient) Client() (client *http.Client) {
+	return &http.Client{
+		Transport: &httpClientTransport{c: c},
+		Timeout:   c.config.Timeout,
+	}
+}
+
+// Transport returns the shared transport without tracing
+func (c *HTTPClient) Transport() (transport *http.Transport) { return c.transport }
+
+// Stats returns connection-pool metrics
+func (c *HTTPClient) Stats() (stats HTTPClientStats) {
+	return HTTPClientStats{
+		Requests:   c.requests.Load(),
+		Dials:      c.dials.Load(),
+		DialErrors: c.dialErrors.Load(),
+		Reused:     c.reused.Load(),
+		OpenConns:  c.openConns.Load(),
+	}
+}
+
+// CloseIdleConnections closes pooled idle connections
+func (c *HTTPClient) CloseIdleConnections() { c.transport.CloseIdleConnections() }
+
+// RoundTrip executes a request with tracing
+func (t *httpClientTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	var c = t.c
+	c.requests.Add(1)
+	if c.config.OnTiming == nil && c.config.TimingSink == nil {
+		return c.transport.RoundTrip(req)
+	}
+
+	var trace = httpClientTrace{t0: time.Now()}
+	trace.timing.Host = req.URL.Host
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace(c)))
+	resp, err = c.transport.RoundTrip(req)
+
+	// deliver timing
+	trace.lock.Lock()
+	trace.timing.Err = err
+	var timing = trace.timing
+	trace.lock.Unlock()
+	if f := c.config.OnTiming; f != nil {
+		f(timing)
+	}
+	if sink := c.config.TimingSink; sink != nil {
+		sink.Send(timing)
+	}
+
+	return
+}
+
+// dialContext connects using optional resolver and address policy
+func (c *HTTPClient) dialContext(ctx context.Context, network, address string) (conn net.Conn, err error) {
+	c.dials.Add(1)
+	defer func() {
+		if err != nil {
+			c.dialErrors.Add(1)
+			return
+		}
+		c.openConns.Add(1)
+		conn = &httpClientConn{Conn: conn, c: c}
+	}()
+	if c.config.Dialer != nil {
+		conn, err = c.config.Dialer.DialContext(ctx, network, address)
+		return
+	}
+	var dialer = net.Dialer{Timeout: c.config.DialTimeout, KeepAlive: httpKeepAlive}
+
+	// without resolver, net.Dialer resolves
+	if c.config.Resolver == nil {
+		conn, err = dialer.DialContext(ctx, network, address)
+		return
+	}
+
+	// resolve using the caching resolver
+	var host, portString string
+	if host, portString, err = net.SplitHostPort(address); perrors.IsPF(&err, "net.SplitHostPort %w", err) {
+		return
+	}
+	var port uint64
+	if port, err = strconv.ParseUint(portString, 10, 16); perrors.IsPF(&err, "port %q %w", portString, err) {
+		return
+	}
+	var trace = httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+	var addrs []netip.Addr
+	addrs, err = c.config.Resolver.LookupNetIP(ctx, "ip", host)
+	if trace != nil && trace.DNSDone != nil {
+		trace.DNSDone(httptrace.DNSDoneInfo{Addrs: addrsToIPAddrs(addrs), Err: err})
+	}
+	if err != nil {
+		return
+	}
+	if addrs = c.config.Policy.Filter(addrs); len(addrs) == 0 {
+		err = perrors.ErrorfPF("address policy allows no address for: %q", host)
+		return
+	}
+
+	// attempt addresses in order
+	for _, addr := range addrs {
+		var e error
+		var remote = netip.AddrPortFrom(addr, uint16(port)).String()
+		if conn, e = dialer.DialContext(ctx, network, remote); e == nil {
+			err = nil
+			return // connected return
+		}
+		err = perrors.AppendError(err, perrors.ErrorfPF("dial %s %s: %w", network, remote, e))
+		if ctx.Err() != nil {
+			return // context canceled return
+		}
+	}
+
+	return
+}
+
+// clientTrace returns hooks updating t
+func (t *httpClientTrace) clientTrace(c *HTTPClient) (trace *httptrace.ClientTrace) {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+
+			t.timing.DNS = time.Since(t.dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+
+			t.connectStart = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+
+			t.timing.Dial = time.Since(t.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+
+			t.timing.TLS = time.Since(t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				c.reused.Add(1)
+			}
+			t.lock.Lock()
+			defer t.lock.Unlock()
+
+			t.timing.Reused = info.Reused
+			if a := info.Conn.RemoteAddr(); a != nil {
+				t.timing.Remote = a.String()
+			}
+		},
+		GotFirstResponseByte: func() {
+			t.lock.Lock()
+			defer t.lock.Unlock()
+
+			t.timing.FirstByte = time.Since(t.t0)
+		},
+	}
+}
+
+// Close closes the connection updating open-connection count
+func (c *httpClientConn) Close() (err error) {
+	c.closeOnce.Do(func() { c.c.openConns.Add(-1) })
+	return c.Conn.Close()
+}
+
+// addrsToIPAddrs converts for [httptrace.DNSDoneInfo]
+func addrsToIPAddrs(addrs []netip.Addr) (ipAddrs []net.IPAddr) {
+	ipAddrs = make([]net.IPAddr, len(addrs))
+	for i, addr := range addrs {
+		ipAddrs[i] = net.IPAddr{IP: addr.AsSlice(), Zone: addr.Zone()}
+	}
+	return
+}
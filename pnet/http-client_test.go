@@ -0,0 +1,67 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/haraldrudell/parl"
+)
+
+func TestHTTPClient(t *testing.T) {
+	//t.Error("Logging on")
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "OK")
+	}))
+	defer server.Close()
+
+	var timings parl.AwaitableSlice[HTTPTiming]
+	var callbacks int
+	var factory = NewHTTPClient(&HTTPClientConfig{
+		Resolver:   NewResolver(nil, 0, 0),
+		OnTiming:   func(timing HTTPTiming) { callbacks++ },
+		TimingSink: &timings,
+	})
+	defer factory.CloseIdleConnections()
+	var client = factory.Client()
+
+	// two requests: the second reuses the connection
+	for i := 0; i < 2; i++ {
+		var resp, err = client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get err: %s", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if callbacks != 2 {
+		t.Errorf("callbacks %d exp 2", callbacks)
+	}
+	var values = timings.GetAll()
+	if len(values) != 2 {
+		t.Fatalf("timings %d exp 2", len(values))
+	}
+	if values[0].Reused || !values[1].Reused {
+		t.Errorf("Reused %t %t exp false true", values[0].Reused, values[1].Reused)
+	}
+	if values[0].FirstByte <= 0 || values[0].Remote == "" {
+		t.Errorf("timing %+v", values[0])
+	}
+	var stats = factory.Stats()
+	if stats.Requests != 2 || stats.Dials != 1 || stats.Reused != 1 || stats.OpenConns != 1 {
+		t.Errorf("Stats %+v exp 2 requests 1 dial 1 reused 1 open", stats)
+	}
+
+	// closing idle connections updates open count
+	factory.CloseIdleConnections()
+	if stats = factory.Stats(); stats.OpenConns != 0 {
+		t.Errorf("OpenConns %d exp 0", stats.OpenConns)
+	}
+}
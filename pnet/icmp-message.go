@@ -0,0 +1,150 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"encoding/binary"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// ICMPv4 message types RFC792
+	icmp4EchoReply        = 0
+	icmp4Unreachable      = 3
+	icmp4EchoRequest      = 8
+	icmp4TimeExceeded     = 11
+	icmp6Unreachable      = 1
+	icmp6TimeExceeded     = 3
+	icmp6EchoRequest      = 128
+	icmp6EchoReply        = 129
+	icmpHeaderLength      = 8
+	ipv4MinHeaderLength   = 20
+	ipv6HeaderLength      = 40
+	icmpDefaultPayloadLen = 56
+)
+
+// icmpKind is the meaning of a received ICMP message
+type icmpKind uint8
+
+const (
+	// icmpOther: unrelated message
+	icmpOther icmpKind = iota
+	// icmpEchoReply: reply from the destination
+	icmpEchoReply
+	// icmpTimeExceeded: hop limit reached at a router
+	icmpTimeExceeded
+	// icmpUnreachable: destination unreachable
+	icmpUnreachable
+)
+
+// icmpMessage is a parsed ICMP echo or error message
+type icmpMessage struct {
+	kind icmpKind
+	// id and seq are of the echo request or reply
+	//	- for error messages, from the embedded original datagram
+	id, seq uint16
+}
+
+// marshalEcho returns an ICMP echo request
+//   - isIPv6 false: checksum is calculated
+//   - isIPv6 true: checksum is calculated by the kernel
+func marshalEcho(isIPv6 bool, id, seq uint16, payloadLength int) (b []byte) {
+	b = make([]byte, icmpHeaderLength+payloadLength)
+	if isIPv6 {
+		b[0] = icmp6EchoRequest
+	} else {
+		b[0] = icmp4EchoRequest
+	}
+	binary.BigEndian.PutUint16(b[4:], id)
+	binary.BigEndian.PutUint16(b[6:], seq)
+	for i := icmpHeaderLength; i < len(b); i++ {
+		b[i] = byte(i)
+	}
+	if !isIPv6 {
+		binary.BigEndian.PutUint16(b[2:], icmpChecksum(b))
+	}
+
+	return
+}
+
+// parseICMP parses a received ICMP message
+//   - b may begin with an IPv4 header that is skipped
+//   - err: message is truncated
+func parseICMP(isIPv6 bool, b []byte) (message icmpMessage, err error) {
+	if !isIPv6 {
+		b = skipIPv4Header(b)
+	}
+	if len(b) < icmpHeaderLength {
+		err = perrors.ErrorfPF("ICMP message truncated: %d bytes", len(b))
+		return
+	}
+	switch icmpType := b[0]; {
+	case !isIPv6 && icmpType == icmp4EchoReply, isIPv6 && icmpType == icmp6EchoReply:
+		message.kind = icmpEchoReply
+	case !isIPv6 && icmpType == icmp4TimeExceeded, isIPv6 && icmpType == icmp6TimeExceeded:
+		message.kind = icmpTimeExceeded
+	case !isIPv6 && icmpType == icmp4Unreachable, isIPv6 && icmpType == icmp6Unreachable:
+		message.kind = icmpUnreachable
+	default:
+		return // other message return
+	}
+	if message.kind == icmpEchoReply {
+		message.id = binary.BigEndian.Uint16(b[4:])
+		message.seq = binary.BigEndian.Uint16(b[6:])
+		return // echo reply return
+	}
+
+	// error message: original datagram follows the header
+	var original = b[icmpHeaderLength:]
+	if isIPv6 {
+		if len(original) < ipv6HeaderLength {
+			err = perrors.ErrorfPF("ICMPv6 error message truncated: %d bytes", len(b))
+			return
+		}
+		original = original[ipv6HeaderLength:]
+	} else if original = skipIPv4Header(original); len(original) == len(b)-icmpHeaderLength {
+		err = perrors.ErrorfPF("ICMP error message missing IPv4 header: %d bytes", len(b))
+		return
+	}
+	if len(original) < icmpHeaderLength {
+		err = perrors.ErrorfPF("ICMP error message truncated: %d bytes", len(b))
+		return
+	}
+	message.id = binary.BigEndian.Uint16(original[4:])
+	message.seq = binary.BigEndian.Uint16(original[6:])
+
+	return
+}
+
+// skipIPv4Header returns b without a leading IPv4 header
+//   - raw sockets on some platforms and datagram sockets on darwin
+//     deliver the IPv4 header
+func skipIPv4Header(b []byte) (b2 []byte) {
+	if len(b) < ipv4MinHeaderLength || b[0]>>4 != 4 {
+		return b // no IPv4 header return
+	}
+	var headerLength = int(b[0]&0x0f) * 4
+	if headerLength < ipv4MinHeaderLength || headerLength > len(b) {
+		return b
+	}
+	return b[headerLength:]
+}
+
+// icmpChecksum is the RFC1071 internet checksum
+func icmpChecksum(b []byte) (checksum uint16) {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,27 @@
+//go:build !darwin && !linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"net"
+
+	"github.com/haraldrudell/parl/iana"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// listenICMPDatagram: unprivileged ICMP is not available on this platform
+func listenICMPDatagram(isIPv6 bool, protocol iana.Protocol) (conn net.PacketConn, err error) {
+	err = perrors.NewPF("unprivileged ICMP not supported on this platform: use ICMPConfig.Raw")
+	return
+}
+
+// setICMPHopLimit: hop limit cannot be set on this platform
+func setICMPHopLimit(conn net.PacketConn, isIPv6 bool, ttl int) (err error) {
+	err = perrors.NewPF("hop limit not supported on this platform")
+	return
+}
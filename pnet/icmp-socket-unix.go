@@ -0,0 +1,67 @@
+//go:build darwin || linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/haraldrudell/parl/iana"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// listenICMPDatagram returns an unprivileged datagram ICMP socket
+//   - the socket is SOCK_DGRAM with protocol ICMP or ICMPv6
+//   - the returned connection is [net.UDPConn] addressed by [net.UDPAddr]
+func listenICMPDatagram(isIPv6 bool, protocol iana.Protocol) (conn net.PacketConn, err error) {
+	var family = syscall.AF_INET
+	if isIPv6 {
+		family = syscall.AF_INET6
+	}
+	var fd int
+	if fd, err = syscall.Socket(family, syscall.SOCK_DGRAM, protocol.Int()); err != nil {
+		err = perrors.ErrorfPF("socket SOCK_DGRAM %s: %w", protocol, err)
+		return
+	}
+	var file = os.NewFile(uintptr(fd), "icmp")
+	defer file.Close()
+
+	if conn, err = net.FilePacketConn(file); perrors.IsPF(&err, "FilePacketConn: %w", err) {
+		return
+	}
+
+	return
+}
+
+// setICMPHopLimit sets the TTL or IPv6 hop limit of outgoing packets
+func setICMPHopLimit(conn net.PacketConn, isIPv6 bool, ttl int) (err error) {
+	var syscallConn, ok = conn.(syscall.Conn)
+	if !ok {
+		err = perrors.ErrorfPF("connection not syscall.Conn: %T", conn)
+		return
+	}
+	var rawConn syscall.RawConn
+	if rawConn, err = syscallConn.SyscallConn(); perrors.IsPF(&err, "SyscallConn: %w", err) {
+		return
+	}
+	var level, option = syscall.IPPROTO_IP, syscall.IP_TTL
+	if isIPv6 {
+		level, option = syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS
+	}
+	var e error
+	if err = rawConn.Control(func(fd uintptr) {
+		e = syscall.SetsockoptInt(int(fd), level, option, ttl)
+	}); perrors.IsPF(&err, "Control: %w", err) {
+		return
+	} else if e != nil {
+		err = perrors.ErrorfPF("setsockopt hop limit %d: %w", ttl, e)
+	}
+
+	return
+}
@@ -0,0 +1,314 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/iana"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// default time to await a reply
+	icmpDefaultTimeout = time.Second
+	// default max hops for Traceroute
+	icmpDefaultMaxHops = 30
+	// receive buffer size
+	icmpReadSize = 1500
+)
+
+// ICMPConfig configures [Ping] and [Traceroute]
+type ICMPConfig struct {
+	// Raw uses a raw socket requiring privileges
+	//	- false: unprivileged datagram ICMP socket, “UDP mode”
+	//		available on Linux and macOS.
+	//		On Linux, the group must be allowed by sysctl net.ipv4.ping_group_range
+	//	- true: raw socket requiring root or CAP_NET_RAW
+	Raw bool
+	// Timeout is how long to await each reply or hop
+	//	- 0: 1 s
+	Timeout time.Duration
+	// PayloadLength is the number of bytes following the ICMP header
+	//	- 0: 56
+	PayloadLength int
+}
+
+// PingReply is the outcome of a single echo request
+type PingReply struct {
+	// Seq is the ICMP sequence number
+	Seq int
+	// Addr is the replying host
+	Addr netip.Addr
+	// Latency is the round-trip time
+	Latency time.Duration
+	// IsTimeout is true if no reply was received within Timeout
+	IsTimeout bool
+}
+
+// TraceHop is a hop reported by [Traceroute]
+type TraceHop struct {
+	// TTL is the hop limit of the probe, 1 is the first router
+	TTL int
+	// Addr is the replying router or destination.
+	// zero-value on timeout
+	Addr netip.Addr
+	// Latency is the round-trip time
+	Latency time.Duration
+	// IsTimeout is true if no reply was received within Timeout
+	IsTimeout bool
+	// IsDestination is true if the destination replied
+	IsDestination bool
+	// IsUnreachable is true if the hop reported destination unreachable
+	IsUnreachable bool
+}
+
+// icmpID provides distinct echo identifiers for raw sockets
+var icmpID atomic.Uint32
+
+// Ping sends count ICMP echo requests to addr interval apart
+//   - replies: receives an event for each reply or timeout.
+//     replies is closed on return using [parl.AwaitableSlice.EmptyCh]
+//   - count: 0 pings until ctx is canceled
+//   - config: optional. nil: unprivileged UDP mode, 1 s timeout
+//   - protocol is [iana.IPicmp] or [iana.IPv6icmp] depending on addr
+//   - err: socket failure, not timeouts. ctx cancel is not an error
+//   - blocks until done
+func Ping(
+	ctx context.Context, addr netip.Addr, count int, interval time.Duration,
+	replies *parl.AwaitableSlice[*PingReply], config ...*ICMPConfig,
+) (err error) {
+	if replies == nil {
+		panic(parl.NilError("replies"))
+	}
+	defer replies.EmptyCh()
+
+	var conn *icmpConn
+	if conn, err = newICMPConn(addr, config...); err != nil {
+		return
+	}
+	defer parl.Close(conn, &err)
+
+	for seq := 1; count == 0 || seq <= count; seq++ {
+		if seq > 1 {
+			if !sleepCtx(ctx, interval) {
+				return // context cancel return
+			}
+		}
+		var reply = PingReply{Seq: seq}
+		if reply.Addr, reply.Latency, _, reply.IsTimeout, err = conn.probe(ctx, uint16(seq), 0); err != nil {
+			return // socket error return
+		} else if ctx.Err() != nil {
+			return // context cancel return
+		}
+		replies.Send(&reply)
+	}
+
+	return
+}
+
+// Traceroute determines the routers on the path to addr
+//   - hops: receives an event for each hop.
+//     hops is closed on return using [parl.AwaitableSlice.EmptyCh]
+//   - maxHops: 0: 30
+//   - config: optional. nil: unprivileged UDP mode, 1 s per-hop timeout
+//   - Traceroute ends when the destination replies, a hop reports
+//     unreachable, maxHops is reached or ctx is canceled
+//   - in UDP mode on Linux, routers’ time-exceeded messages are not delivered
+//     to the socket and intermediate hops appear as timeouts.
+//     Use Raw for complete results
+//   - err: socket failure
+//   - blocks until done
+func Traceroute(
+	ctx context.Context, addr netip.Addr, maxHops int,
+	hops *parl.AwaitableSlice[*TraceHop], config ...*ICMPConfig,
+) (err error) {
+	if hops == nil {
+		panic(parl.NilError("hops"))
+	}
+	defer hops.EmptyCh()
+	if maxHops <= 0 {
+		maxHops = icmpDefaultMaxHops
+	}
+
+	var conn *icmpConn
+	if conn, err = newICMPConn(addr, config...); err != nil {
+		return
+	}
+	defer parl.Close(conn, &err)
+
+	for ttl := 1; ttl <= maxHops; ttl++ {
+		var hop = TraceHop{TTL: ttl}
+		var message icmpMessage
+		if hop.Addr, hop.Latency, message, hop.IsTimeout, err = conn.probe(ctx, uint16(ttl), ttl); err != nil {
+			return // socket error return
+		} else if ctx.Err() != nil {
+			return // context cancel return
+		}
+		hop.IsDestination = !hop.IsTimeout && message.kind == icmpEchoReply
+		hop.IsUnreachable = !hop.IsTimeout && message.kind == icmpUnreachable
+		hops.Send(&hop)
+		if hop.IsDestination || hop.IsUnreachable {
+			return // trace complete return
+		}
+	}
+
+	return
+}
+
+// icmpConn is an ICMP socket for a single destination
+type icmpConn struct {
+	conn    net.PacketConn
+	addr    netip.Addr
+	dst     net.Addr
+	isIPv6  bool
+	isRaw   bool
+	id      uint16
+	timeout time.Duration
+	payload int
+	buffer  []byte
+}
+
+// newICMPConn opens a raw or datagram ICMP socket
+func newICMPConn(addr netip.Addr, config ...*ICMPConfig) (c *icmpConn, err error) {
+	if !addr.IsValid() {
+		err = perrors.NewPF("invalid address")
+		return
+	}
+	var cfg ICMPConfig
+	if len(config) > 0 && config[0] != nil {
+		cfg = *config[0]
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = icmpDefaultTimeout
+	}
+	if cfg.PayloadLength <= 0 {
+		cfg.PayloadLength = icmpDefaultPayloadLen
+	}
+	addr = addr.Unmap()
+	c = &icmpConn{
+		addr:    addr,
+		isIPv6:  addr.Is6(),
+		isRaw:   cfg.Raw,
+		id:      uint16(os.Getpid()) + uint16(icmpID.Add(1)),
+		timeout: cfg.Timeout,
+		payload: cfg.PayloadLength,
+		buffer:  make([]byte, icmpReadSize),
+	}
+	var protocol = iana.IPicmp
+	if c.isIPv6 {
+		protocol = iana.IPv6icmp
+	}
+	if cfg.Raw {
+		var network = "ip4:" + strconv.Itoa(protocol.Int())
+		if c.isIPv6 {
+			network = "ip6:" + strconv.Itoa(protocol.Int())
+		}
+		if c.conn, err = net.ListenPacket(network, ""); perrors.IsPF(&err, "ListenPacket %s: %w", network, err) {
+			return
+		}
+		c.dst = &net.IPAddr{IP: addr.AsSlice(), Zone: addr.Zone()}
+		return
+	}
+	if c.conn, err = listenICMPDatagram(c.isIPv6, protocol); err != nil {
+		return
+	}
+	c.dst = &net.UDPAddr{IP: addr.AsSlice(), Zone: addr.Zone()}
+
+	return
+}
+
+// probe sends an echo request and awaits a matching reply or error message
+//   - ttl: 0: default hop limit
+//   - isTimeout: no matching message within timeout or ctx canceled
+func (c *icmpConn) probe(ctx context.Context, seq uint16, ttl int) (
+	from netip.Addr, latency time.Duration, message icmpMessage, isTimeout bool, err error,
+) {
+	if ttl > 0 {
+		if err = setICMPHopLimit(c.conn, c.isIPv6, ttl); err != nil {
+			return
+		}
+	}
+	var t0 = time.Now()
+	var deadline = t0.Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if _, err = c.conn.WriteTo(marshalEcho(c.isIPv6, c.id, seq, c.payload), c.dst); perrors.IsPF(&err, "WriteTo %s: %w", c.addr, err) {
+		return
+	}
+	if err = c.conn.SetReadDeadline(deadline); perrors.IsPF(&err, "SetReadDeadline: %w", err) {
+		return
+	}
+	var stop = context.AfterFunc(ctx, func() { c.conn.SetReadDeadline(time.Unix(1, 0)) })
+	defer stop()
+
+	for {
+		var n int
+		var netAddr net.Addr
+		if n, netAddr, err = c.conn.ReadFrom(c.buffer); err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				err = nil
+				isTimeout = true
+				return // timeout or context cancel return
+			}
+			err = perrors.ErrorfPF("ReadFrom: %w", err)
+			return
+		}
+		var e error
+		if message, e = parseICMP(c.isIPv6, c.buffer[:n]); e != nil || message.kind == icmpOther {
+			continue // malformed or unrelated message
+		} else if message.seq != seq || c.isRaw && message.id != c.id {
+			continue // not our probe: datagram sockets have kernel-assigned id
+		}
+		latency = time.Since(t0)
+		from = netAddrToAddr(netAddr)
+		return
+	}
+}
+
+// Close closes the socket
+func (c *icmpConn) Close() (err error) {
+	if err = c.conn.Close(); err != nil {
+		err = perrors.ErrorfPF("Close: %w", err)
+	}
+	return
+}
+
+// netAddrToAddr returns the address of an [net.IPAddr] or [net.UDPAddr]
+func netAddrToAddr(netAddr net.Addr) (addr netip.Addr) {
+	switch a := netAddr.(type) {
+	case *net.IPAddr:
+		addr, _ = netip.AddrFromSlice(a.IP)
+		addr = addr.WithZone(a.Zone)
+	case *net.UDPAddr:
+		addr, _ = netip.AddrFromSlice(a.IP)
+		addr = addr.WithZone(a.Zone)
+	}
+	return addr.Unmap()
+}
+
+// sleepCtx sleeps for d
+//   - isOk false: ctx was canceled
+func sleepCtx(ctx context.Context, d time.Duration) (isOk bool) {
+	var timer = time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
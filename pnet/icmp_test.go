@@ -0,0 +1,76 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+func TestICMPMessage(t *testing.T) {
+	//t.Error("Logging on")
+	const id, seq = 0x1234, 7
+
+	// echo request checksum verifies to zero
+	var echo = marshalEcho(false, id, seq, 5)
+	if c := icmpChecksum(echo); c != 0 {
+		t.Errorf("checksum verify %#x exp 0", c)
+	}
+
+	// echo reply
+	var reply = append([]byte{}, echo...)
+	reply[0] = icmp4EchoReply
+	var message, err = parseICMP(false, reply)
+	if err != nil || message.kind != icmpEchoReply || message.id != id || message.seq != seq {
+		t.Errorf("parse reply %+v %v", message, err)
+	}
+
+	// time exceeded with embedded IPv4 header and echo request
+	var ipHeader = make([]byte, ipv4MinHeaderLength)
+	ipHeader[0] = 0x45
+	var exceeded = append([]byte{icmp4TimeExceeded, 0, 0, 0, 0, 0, 0, 0}, ipHeader...)
+	exceeded = append(exceeded, echo[:icmpHeaderLength]...)
+	if message, err = parseICMP(false, append(ipHeader, exceeded...)); err != nil ||
+		message.kind != icmpTimeExceeded || message.id != id || message.seq != seq {
+		t.Errorf("parse time exceeded %+v %v", message, err)
+	}
+
+	// IPv6 echo reply
+	var echo6 = marshalEcho(true, id, seq, 0)
+	echo6[0] = icmp6EchoReply
+	if message, err = parseICMP(true, echo6); err != nil || message.kind != icmpEchoReply {
+		t.Errorf("parse IPv6 reply %+v %v", message, err)
+	}
+
+	// truncated
+	if _, err = parseICMP(false, echo[:3]); err == nil {
+		t.Error("parse truncated missing error")
+	}
+}
+
+func TestPing(t *testing.T) {
+	//t.Error("Logging on")
+	var replies parl.AwaitableSlice[*PingReply]
+	var config = ICMPConfig{Timeout: 100 * time.Millisecond}
+	var err = Ping(context.Background(), netip.MustParseAddr("127.0.0.1"), 1, 0, &replies, &config)
+	if err != nil {
+		t.Skipf("unprivileged ICMP unavailable: %s", err)
+	}
+	var reply, hasValue = replies.Get()
+	if !hasValue {
+		t.Fatal("Ping no reply event")
+	}
+	if !reply.IsTimeout && reply.Addr != netip.MustParseAddr("127.0.0.1") {
+		t.Errorf("reply addr %s", reply.Addr)
+	}
+	if !replies.IsClosed() {
+		t.Error("replies not closed")
+	}
+}
@@ -24,6 +24,8 @@ import (
 //   - — if IPv6 is supported, “localhost” typically becomes “::” not “::1”
 //   - cancel: optional pointer that is set to a cancel function
 //     during listen invocation
+//   - the process-wide [AddressPolicy] may narrow network and
+//     disallow the address
 //   - invokes [net.ListenConfig.Listen]
 //   - network value is not used by the kernel, it is a standard-library scoped
 //     helper
@@ -48,7 +50,11 @@ func Listen(
 	}
 
 	var listenConfig = net.ListenConfig{}
-	var network = socketAddress.Network().String()
+	var policyNetwork Network
+	if policyNetwork, err = GetAddressPolicy().ListenNetwork(socketAddress); err != nil {
+		return
+	}
+	var network = policyNetwork.String()
 	var addr = socketAddress.String()
 	if listener, err = listenConfig.Listen(ctx, network, addr); err != nil {
 		err = perrors.ErrorfPF("net.Listen %s %s: “%w”", network, addr, err)
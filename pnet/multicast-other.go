@@ -0,0 +1,29 @@
+//go:build !darwin && !linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"net/netip"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// setMulticastOptions: multicast options are not supported on this platform
+func setMulticastOptions(fd uintptr, isIPv6 bool, o MulticastOptions) (err error) {
+	err = perrors.NewPF("multicast socket options not supported on this platform")
+	return
+}
+
+// joinGroup: group membership is not supported on this platform
+func joinGroup(fd uintptr, group netip.Addr, ifIndex IfIndex, isJoin bool) (err error) {
+	err = perrors.NewPF("multicast group membership not supported on this platform")
+	return
+}
+
+// parsePacketInfo: packet info is not supported on this platform
+func parsePacketInfo(oob []byte, isIPv6 bool) (group netip.Addr, ifIndex IfIndex) { return }
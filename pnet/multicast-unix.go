@@ -0,0 +1,117 @@
+//go:build darwin || linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"net/netip"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// setMulticastOptions sets packet info, hop limit, loopback and sending interface
+func setMulticastOptions(fd uintptr, isIPv6 bool, o MulticastOptions) (err error) {
+	var s = int(fd)
+	if isIPv6 {
+		if err = unix.SetsockoptInt(s, unix.IPPROTO_IPV6, unix.IPV6_RECVPKTINFO, 1); perrors.IsPF(&err, "IPV6_RECVPKTINFO %w", err) {
+			return
+		}
+		if o.HopLimit > 0 {
+			if err = unix.SetsockoptInt(s, unix.IPPROTO_IPV6, unix.IPV6_MULTICAST_HOPS, o.HopLimit); perrors.IsPF(&err, "IPV6_MULTICAST_HOPS %w", err) {
+				return
+			}
+		}
+		if o.NoLoopback {
+			if err = unix.SetsockoptInt(s, unix.IPPROTO_IPV6, unix.IPV6_MULTICAST_LOOP, 0); perrors.IsPF(&err, "IPV6_MULTICAST_LOOP %w", err) {
+				return
+			}
+		}
+		if o.IfIndex.IsValid() {
+			if err = unix.SetsockoptInt(s, unix.IPPROTO_IPV6, unix.IPV6_MULTICAST_IF, int(o.IfIndex)); perrors.IsPF(&err, "IPV6_MULTICAST_IF %w", err) {
+				return
+			}
+		}
+		return
+	}
+
+	// IPv4: byte-sized options are accepted by both Linux and macOS
+	if err = unix.SetsockoptInt(s, unix.IPPROTO_IP, unix.IP_PKTINFO, 1); perrors.IsPF(&err, "IP_PKTINFO %w", err) {
+		return
+	}
+	if o.HopLimit > 0 {
+		if err = unix.SetsockoptByte(s, unix.IPPROTO_IP, unix.IP_MULTICAST_TTL, byte(min(o.HopLimit, 255))); perrors.IsPF(&err, "IP_MULTICAST_TTL %w", err) {
+			return
+		}
+	}
+	if o.NoLoopback {
+		if err = unix.SetsockoptByte(s, unix.IPPROTO_IP, unix.IP_MULTICAST_LOOP, 0); perrors.IsPF(&err, "IP_MULTICAST_LOOP %w", err) {
+			return
+		}
+	}
+	if o.IfIndex.IsValid() {
+		var mreqn = unix.IPMreqn{Ifindex: int32(o.IfIndex)}
+		if err = unix.SetsockoptIPMreqn(s, unix.IPPROTO_IP, unix.IP_MULTICAST_IF, &mreqn); perrors.IsPF(&err, "IP_MULTICAST_IF %w", err) {
+			return
+		}
+	}
+	return
+}
+
+// joinGroup joins or leaves group on interface ifIndex
+func joinGroup(fd uintptr, group netip.Addr, ifIndex IfIndex, isJoin bool) (err error) {
+	var s = int(fd)
+	if group.Is6() {
+		var option, name = unix.IPV6_JOIN_GROUP, "IPV6_JOIN_GROUP"
+		if !isJoin {
+			option, name = unix.IPV6_LEAVE_GROUP, "IPV6_LEAVE_GROUP"
+		}
+		var mreq = unix.IPv6Mreq{Multiaddr: group.As16(), Interface: uint32(ifIndex)}
+		if err = unix.SetsockoptIPv6Mreq(s, unix.IPPROTO_IPV6, option, &mreq); err != nil {
+			err = perrors.ErrorfPF("%s %s %d %w", name, group, ifIndex, err)
+		}
+		return
+	}
+	var option, name = unix.IP_ADD_MEMBERSHIP, "IP_ADD_MEMBERSHIP"
+	if !isJoin {
+		option, name = unix.IP_DROP_MEMBERSHIP, "IP_DROP_MEMBERSHIP"
+	}
+	var mreqn = unix.IPMreqn{Multiaddr: group.As4(), Ifindex: int32(ifIndex)}
+	if err = unix.SetsockoptIPMreqn(s, unix.IPPROTO_IP, option, &mreqn); err != nil {
+		err = perrors.ErrorfPF("%s %s %d %w", name, group, ifIndex, err)
+	}
+	return
+}
+
+// parsePacketInfo returns destination address and interface from
+// IP_PKTINFO or IPV6_PKTINFO control messages
+//   - group invalid: no packet info
+func parsePacketInfo(oob []byte, isIPv6 bool) (group netip.Addr, ifIndex IfIndex) {
+	var messages, err = unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return
+	}
+	for _, message := range messages {
+		if isIPv6 {
+			if message.Header.Level != unix.IPPROTO_IPV6 || message.Header.Type != unix.IPV6_PKTINFO ||
+				len(message.Data) < unix.SizeofInet6Pktinfo {
+				continue
+			}
+			var info = (*unix.Inet6Pktinfo)(unsafe.Pointer(&message.Data[0]))
+			return netip.AddrFrom16(info.Addr), IfIndex(info.Ifindex)
+		}
+		if message.Header.Level != unix.IPPROTO_IP || message.Header.Type != unix.IP_PKTINFO ||
+			len(message.Data) < unix.SizeofInet4Pktinfo {
+			continue
+		}
+		var info = (*unix.Inet4Pktinfo)(unsafe.Pointer(&message.Data[0]))
+		return netip.AddrFrom4(info.Addr), IfIndex(uint32(info.Ifindex))
+	}
+	return
+}
@@ -0,0 +1,358 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// default max size of received multicast datagrams
+	multicastDefaultMaxSize = 9000
+	// size of control-message buffer for packet info
+	multicastOobSize = 64
+)
+
+var (
+	// MDNSGroupIPv4 is the mDNS group “224.0.0.251” port 5353
+	MDNSGroupIPv4 = netip.MustParseAddrPort("224.0.0.251:5353")
+	// MDNSGroupIPv6 is the mDNS group “[ff02::fb]:5353”
+	MDNSGroupIPv6 = netip.MustParseAddrPort("[ff02::fb]:5353")
+	// SSDPGroupIPv4 is the SSDP group “239.255.255.250:1900”
+	SSDPGroupIPv4 = netip.MustParseAddrPort("239.255.255.250:1900")
+	// SSDPGroupIPv6 is the link-local SSDP group “[ff02::c]:1900”
+	SSDPGroupIPv6 = netip.MustParseAddrPort("[ff02::c]:1900")
+)
+
+// MulticastOptions are options of a multicast socket
+//   - the zero-value uses system defaults: hop limit 1, loopback enabled
+//   - options are unsupported on platforms other than Linux and macOS
+type MulticastOptions struct {
+	// HopLimit sets IP_MULTICAST_TTL or IPV6_MULTICAST_HOPS of
+	// sent datagrams, 0: system default 1
+	HopLimit int
+	// NoLoopback clears IP_MULTICAST_LOOP or IPV6_MULTICAST_LOOP so that
+	// sent datagrams are not received by the local host
+	NoLoopback bool
+	// IfIndex sets IP_MULTICAST_IF or IPV6_MULTICAST_IF the
+	// interface of sent datagrams, 0: system default
+	IfIndex IfIndex
+	// MaxSize is the max size of received datagrams, 0: 9000
+	MaxSize int
+}
+
+// MulticastDatagram is a datagram received for a multicast group
+type MulticastDatagram struct {
+	// Data is the datagram payload
+	Data []byte
+	// Source is the sender of the datagram
+	Source netip.AddrPort
+	// Group is the group the datagram was sent to
+	Group netip.Addr
+	// IfIndex is the interface the datagram was received on
+	IfIndex IfIndex
+}
+
+// MulticastGroup is a joined group on an interface
+type MulticastGroup struct {
+	Group   netip.Addr
+	IfIndex IfIndex
+}
+
+// MulticastConn is a UDP socket receiving traffic of joined multicast groups
+//   - [ListenMulticast] creates the socket and its read thread
+//   - [MulticastConn.Join] joins a group on an interface and returns
+//     the group’s queue of received datagrams
+//   - datagrams are dispatched by destination address to per-group
+//     [parl.AwaitableSlice] queues. Other datagrams are counted by
+//     [MulticastConn.Dropped]
+//   - SO_REUSEADDR and SO_REUSEPORT are set so that a port like
+//     mDNS 5353 can be shared with other processes
+//   - thread-safe
+//
+// Usage:
+//
+//	var conn, err = pnet.ListenMulticast("udp4", pnet.MDNSGroupIPv4.Port(), &errs)
+//	…
+//	defer parl.Close(conn, &err)
+//	var queue parl.IterableSource[pnet.MulticastDatagram]
+//	if queue, err = conn.Join(pnet.MDNSGroupIPv4.Addr(), ifIndex); err != nil {
+//	  return
+//	}
+//	err = conn.WriteTo(query, pnet.MDNSGroupIPv4)
+//	for datagram := queue.Init(); queue.Condition(&datagram); {
+//	  …
+type MulticastConn struct {
+	conn      *net.UDPConn
+	rawConn   syscall.RawConn
+	isIPv6    bool
+	maxSize   int
+	errorSink parl.ErrorSink1
+	// dropped is number of received datagrams for groups not joined
+	dropped atomic.Uint64
+	// readEnd closes when the read thread exits
+	readEnd parl.Awaitable
+	// lock makes groups thread-safe
+	lock sync.Mutex
+	// groups are joined groups, behind lock
+	groups map[netip.Addr]*multicastGroup
+}
+
+// multicastGroup is the queue and interfaces of a joined group
+type multicastGroup struct {
+	queue parl.AwaitableSlice[MulticastDatagram]
+	// ifIndexes are interfaces the group is joined on
+	ifIndexes []IfIndex
+}
+
+// ListenMulticast returns a UDP socket for receiving multicast traffic
+//   - network: “udp4” “udp6”
+//   - port: the port of the groups, eg. 5353 for mDNS
+//   - errorSink: receives read errors
+//   - options: optional hop limit, loopback and sending interface
+//   - a read thread runs until [MulticastConn.Close]
+func ListenMulticast(network string, port uint16, errorSink parl.ErrorSink1, options ...MulticastOptions) (conn *MulticastConn, err error) {
+	if errorSink == nil {
+		panic(parl.NilError("errorSink"))
+	}
+	var o MulticastOptions
+	if len(options) > 0 {
+		o = options[0]
+	}
+	var c = MulticastConn{
+		errorSink: errorSink,
+		maxSize:   o.MaxSize,
+		groups:    make(map[netip.Addr]*multicastGroup),
+	}
+	if c.maxSize <= 0 {
+		c.maxSize = multicastDefaultMaxSize
+	}
+	var address string
+	switch network {
+	case "udp4":
+		address = netip.AddrPortFrom(netip.IPv4Unspecified(), port).String()
+	case "udp6":
+		c.isIPv6 = true
+		address = netip.AddrPortFrom(netip.IPv6Unspecified(), port).String()
+	default:
+		err = perrors.ErrorfPF("network not udp4 or udp6: %q", network)
+		return
+	}
+
+	// bind with SO_REUSEADDR SO_REUSEPORT
+	var listenConfig = SocketOptions{ReuseAddr: true, ReusePort: true}.ListenConfig()
+	var packetConn net.PacketConn
+	if packetConn, err = listenConfig.ListenPacket(context.Background(), network, address); perrors.IsPF(&err, "listen %s %s: %w", network, address, err) {
+		return
+	}
+	c.conn = packetConn.(*net.UDPConn)
+	if c.rawConn, err = c.conn.SyscallConn(); perrors.IsPF(&err, "SyscallConn %w", err) {
+		c.conn.Close()
+		return
+	}
+	if err = c.control(func(fd uintptr) (err error) { return setMulticastOptions(fd, c.isIPv6, o) }); err != nil {
+		c.conn.Close()
+		return
+	}
+	conn = &c
+	go c.readThread()
+
+	return
+}
+
+// Join joins group on the interface ifIndex
+//   - ifIndex 0: the system selects the interface
+//   - queue: receives datagrams sent to group on any joined interface.
+//     Joining the same group on multiple interfaces returns the same queue
+//   - queue closes when the group is left on all interfaces or
+//     on [MulticastConn.Close]
+func (c *MulticastConn) Join(group netip.Addr, ifIndex IfIndex) (queue parl.IterableSource[MulticastDatagram], err error) {
+	if group = group.Unmap(); !group.IsMulticast() || group.Is6() != c.isIPv6 {
+		err = perrors.ErrorfPF("not an IPv%s multicast address: %s", c.ipVersion(), group)
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.groups == nil {
+		err = perrors.NewPF("Join after Close")
+		return
+	}
+	var g = c.groups[group]
+	if g != nil && slices.Contains(g.ifIndexes, ifIndex) {
+		queue = &g.queue
+		return // already joined return
+	}
+	if err = c.control(func(fd uintptr) (err error) { return joinGroup(fd, group, ifIndex, true) }); err != nil {
+		return
+	}
+	if g == nil {
+		g = &multicastGroup{}
+		c.groups[group] = g
+	}
+	g.ifIndexes = append(g.ifIndexes, ifIndex)
+	queue = &g.queue
+
+	return
+}
+
+// Leave leaves group on the interface ifIndex
+//   - leaving a group not joined is not an error
+//   - the group’s queue closes once it was left on all interfaces.
+//     Received datagrams remain available
+func (c *MulticastConn) Leave(group netip.Addr, ifIndex IfIndex) (err error) {
+	group = group.Unmap()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var g = c.groups[group]
+	var index = -1
+	if g != nil {
+		index = slices.Index(g.ifIndexes, ifIndex)
+	}
+	if index == -1 {
+		return // not joined return
+	}
+	if err = c.control(func(fd uintptr) (err error) { return joinGroup(fd, group, ifIndex, false) }); err != nil {
+		return
+	}
+	if g.ifIndexes = slices.Delete(g.ifIndexes, index, index+1); len(g.ifIndexes) == 0 {
+		delete(c.groups, group)
+		g.queue.EmptyCh()
+	}
+
+	return
+}
+
+// Groups returns joined groups sorted by group and interface
+func (c *MulticastConn) Groups() (groups []MulticastGroup) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for group, g := range c.groups {
+		for _, ifIndex := range g.ifIndexes {
+			groups = append(groups, MulticastGroup{Group: group, IfIndex: ifIndex})
+		}
+	}
+	slices.SortFunc(groups, func(a, b MulticastGroup) (result int) {
+		if result = a.Group.Compare(b.Group); result == 0 {
+			result = int(a.IfIndex) - int(b.IfIndex)
+		}
+		return
+	})
+
+	return
+}
+
+// WriteTo sends data to a group or any address
+//   - the sending interface is [MulticastOptions.IfIndex]
+func (c *MulticastConn) WriteTo(data []byte, addrPort netip.AddrPort) (err error) {
+	if _, err = c.conn.WriteToUDPAddrPort(data, addrPort); perrors.IsPF(&err, "WriteTo %s: %w", addrPort, err) {
+		return
+	}
+	return
+}
+
+// LocalAddr returns the bound address
+func (c *MulticastConn) LocalAddr() (addrPort netip.AddrPort) {
+	return c.conn.LocalAddr().(*net.UDPAddr).AddrPort()
+}
+
+// Dropped returns the number of received datagrams for groups not joined
+func (c *MulticastConn) Dropped() (dropped uint64) { return c.dropped.Load() }
+
+// Close closes the socket and all group queues
+//   - Close awaits the read thread exit
+//   - idempotent
+func (c *MulticastConn) Close() (err error) {
+	if err = c.conn.Close(); err != nil && IsErrClosed(err) {
+		err = nil
+	} else if err != nil {
+		err = perrors.ErrorfPF("Close %w", err)
+	}
+	<-c.readEnd.Ch()
+
+	return
+}
+
+// readThread reads datagrams and dispatches them to group queues
+func (c *MulticastConn) readThread() {
+	var err error
+	defer c.readEnd.Close()
+	defer c.closeGroups()
+	defer parl.Recover(func() parl.DA { return parl.A() }, &err, c.errorSink)
+
+	var oob = make([]byte, multicastOobSize)
+	for {
+		var b = make([]byte, c.maxSize)
+		var n, oobn int
+		var source netip.AddrPort
+		if n, oobn, _, source, err = c.conn.ReadMsgUDPAddrPort(b, oob); err != nil {
+			if IsErrClosed(err) {
+				err = nil
+				return // socket closed return
+			}
+			c.errorSink.AddError(perrors.ErrorfPF("ReadMsgUDPAddrPort %w", err))
+			err = nil
+			continue
+		}
+		var group, ifIndex = parsePacketInfo(oob[:oobn], c.isIPv6)
+		c.dispatch(MulticastDatagram{
+			Data:    b[:n],
+			Source:  netip.AddrPortFrom(source.Addr().Unmap(), source.Port()),
+			Group:   group,
+			IfIndex: ifIndex,
+		})
+	}
+}
+
+// dispatch sends datagram to its group queue
+func (c *MulticastConn) dispatch(datagram MulticastDatagram) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if g := c.groups[datagram.Group]; g != nil {
+		g.queue.Send(datagram)
+		return
+	}
+	c.dropped.Add(1)
+}
+
+// closeGroups closes all group queues
+func (c *MulticastConn) closeGroups() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, g := range c.groups {
+		g.queue.EmptyCh()
+	}
+	c.groups = nil
+}
+
+// control invokes fn with the socket’s file descriptor
+func (c *MulticastConn) control(fn func(fd uintptr) (err error)) (err error) {
+	if e := c.rawConn.Control(func(fd uintptr) { err = fn(fd) }); e != nil {
+		err = perrors.AppendError(err, perrors.ErrorfPF("Control %w", e))
+	}
+	return
+}
+
+// ipVersion returns “4” or “6”
+func (c *MulticastConn) ipVersion() (s string) {
+	if c.isIPv6 {
+		return "6"
+	}
+	return "4"
+}
@@ -0,0 +1,84 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"net/netip"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestMulticastConn(t *testing.T) {
+	//t.Error("Logging on")
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("multicast not supported")
+	}
+	var group = netip.MustParseAddr("239.255.77.1")
+	var otherGroup = netip.MustParseAddr("239.255.77.2")
+	var expText = "hello"
+
+	var errs parl.ErrSlice
+	var conn, err = ListenMulticast("udp4", 0, &errs, MulticastOptions{HopLimit: 1})
+	if err != nil {
+		t.Fatalf("ListenMulticast err: %s", perrors.Short(err))
+	}
+	defer conn.Close()
+
+	// IPv6 group on IPv4 socket fails
+	if _, err = conn.Join(netip.MustParseAddr("ff02::fb"), 0); err == nil {
+		t.Error("Join IPv6 no error")
+	}
+
+	var queue parl.IterableSource[MulticastDatagram]
+	if queue, err = conn.Join(group, 0); err != nil {
+		t.Skipf("no multicast interface: %s", perrors.Short(err))
+	}
+	if groups := conn.Groups(); !slices.Equal(groups, []MulticastGroup{{Group: group}}) {
+		t.Errorf("Groups %v", groups)
+	}
+
+	// datagram is dispatched to the group’s queue
+	var port = conn.LocalAddr().Port()
+	if err = conn.WriteTo([]byte(expText), netip.AddrPortFrom(group, port)); err != nil {
+		t.Fatalf("WriteTo err: %s", perrors.Short(err))
+	}
+	select {
+	case <-queue.DataWaitCh():
+	case <-time.After(5 * time.Second):
+		t.Skip("no multicast loopback")
+	}
+	var datagram, _ = queue.Get()
+	if string(datagram.Data) != expText || datagram.Group != group {
+		t.Errorf("datagram %q group %s", datagram.Data, datagram.Group)
+	}
+
+	// Leave closes the queue
+	if err = conn.Leave(group, 0); err != nil {
+		t.Errorf("Leave err: %s", perrors.Short(err))
+	}
+	if !queue.IsClosed() {
+		t.Error("queue not closed")
+	}
+	if _, err = conn.Join(otherGroup, 0); err != nil {
+		t.Errorf("Join err: %s", perrors.Short(err))
+	}
+
+	// Close closes queues
+	if err = conn.Close(); err != nil {
+		t.Errorf("Close err: %s", perrors.Short(err))
+	}
+	if groups := conn.Groups(); len(groups) != 0 {
+		t.Errorf("Groups after Close %v", groups)
+	}
+	if err, _ = errs.Error(); err != nil {
+		t.Errorf("read err: %s", perrors.Short(err))
+	}
+}
@@ -71,3 +71,27 @@ func AddrPortToTCPAddr(addrPort netip.AddrPort) (addrInterface net.Addr) {
 	IP, port, zone := SplitAddrPort(addrPort)
 	return &net.TCPAddr{IP: IP, Port: port, Zone: zone}
 }
+
+// AddrsToIPs converts [netip.Addr] to legacy [net.IP]
+//   - IPv4 addresses become 4-byte net.IP
+//   - zones are lost, use [AddrToIPAddr] to retain zone
+func AddrsToIPs(addrs []netip.Addr) (IPs []net.IP) {
+	IPs = make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		IPs[i] = addr.Unmap().AsSlice()
+	}
+	return
+}
+
+// IPsToAddrs converts legacy [net.IP] to [netip.Addr]
+//   - IPv4-mapped IPv6 addresses become IPv4
+//   - invalid net.IP are omitted
+func IPsToAddrs(IPs []net.IP) (addrs []netip.Addr) {
+	addrs = make([]netip.Addr, 0, len(IPs))
+	for _, IP := range IPs {
+		if addr, ok := netip.AddrFromSlice(IP); ok {
+			addrs = append(addrs, addr.Unmap())
+		}
+	}
+	return
+}
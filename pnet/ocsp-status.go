@@ -0,0 +1,132 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+const (
+	// OCSPNone: no OCSP response was stapled
+	OCSPNone OCSPStatus = iota
+	// OCSPGood: the certificate is not revoked
+	OCSPGood
+	// OCSPRevoked: the certificate is revoked
+	OCSPRevoked
+	// OCSPUnknown: the responder does not know the certificate
+	OCSPUnknown
+	// OCSPMalformed: the response could not be parsed or
+	// the responder returned an error status
+	OCSPMalformed
+)
+
+// OCSPStatus is certificate status of an OCSP response
+//   - [OCSPNone] [OCSPGood] [OCSPRevoked] [OCSPUnknown] [OCSPMalformed]
+type OCSPStatus uint8
+
+// ocspBasicOID is id-pkix-ocsp-basic
+var ocspBasicOID = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+
+// ocspResponse is OCSPResponse of RFC 6960
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+// ocspResponseBytes is ResponseBytes of RFC 6960
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+// ocspBasicResponse is BasicOCSPResponse of RFC 6960
+type ocspBasicResponse struct {
+	TBSResponseData    ocspResponseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+// ocspResponseData is ResponseData of RFC 6960
+type ocspResponseData struct {
+	Raw            asn1.RawContent
+	Version        int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID asn1.RawValue
+	ProducedAt     time.Time `asn1:"generalized"`
+	Responses      []ocspSingleResponse
+}
+
+// ocspSingleResponse is SingleResponse of RFC 6960
+type ocspSingleResponse struct {
+	CertID           asn1.RawValue
+	Good             asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked          ocspRevokedInfo  `asn1:"tag:1,optional"`
+	Unknown          asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate       time.Time        `asn1:"generalized"`
+	NextUpdate       time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	SingleExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+// ocspRevokedInfo is RevokedInfo of RFC 6960
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// ParseOCSPStatus returns the certificate status of a DER OCSP response
+//   - der: a stapled response [tls.ConnectionState.OCSPResponse]
+//   - empty der: [OCSPNone]
+//   - the status of the first single response is returned
+//   - the response signature is not verified: the status is diagnostic
+func ParseOCSPStatus(der []byte) (status OCSPStatus) {
+	if len(der) == 0 {
+		return // not stapled return
+	}
+	status = OCSPMalformed
+	var response ocspResponse
+	if rest, err := asn1.Unmarshal(der, &response); err != nil || len(rest) > 0 {
+		return
+	} else if response.Status != 0 || !response.Response.ResponseType.Equal(ocspBasicOID) {
+		return // responder error return
+	}
+	var basic ocspBasicResponse
+	if rest, err := asn1.Unmarshal(response.Response.Response, &basic); err != nil || len(rest) > 0 {
+		return
+	} else if len(basic.TBSResponseData.Responses) == 0 {
+		return
+	}
+	switch single := basic.TBSResponseData.Responses[0]; {
+	case bool(single.Good):
+		status = OCSPGood
+	case bool(single.Unknown):
+		status = OCSPUnknown
+	case !single.Revoked.RevocationTime.IsZero():
+		status = OCSPRevoked
+	}
+
+	return
+}
+
+// “none” “good” “revoked” “unknown” “malformed”
+func (s OCSPStatus) String() (s2 string) {
+	switch s {
+	case OCSPNone:
+		return "none"
+	case OCSPGood:
+		return "good"
+	case OCSPRevoked:
+		return "revoked"
+	case OCSPUnknown:
+		return "unknown"
+	case OCSPMalformed:
+		return "malformed"
+	}
+	return parl.Sprintf("?ocspStatus%d", s)
+}
@@ -4,6 +4,16 @@ ISC License
 */
 
 // Package pnet provides IP-related functions with few dependencies beyond the net package
+//   - [AddressPolicy] controls IPv4/IPv6 preference, address scopes and
+//     source-address selection for dialing, listening and interface addresses
+//   - [Resolver] is a caching DNS resolver with deduplication of concurrent lookups
+//   - [HTTPClient] provides http clients with connection timings and pool metrics
+//   - [ProxyDialer] connects through SOCKS5 or HTTP CONNECT proxies
+//   - [Ping] and [Traceroute] probe hosts using ICMP or ICMPv6
+//   - [SocketOptions] listens with typed socket options,
+//     [NewListenerGroup] shards accept across SO_REUSEPORT listeners
+//   - [ListenMulticast] joins multicast groups on interfaces dispatching
+//     received datagrams to per-group queues
 package pnet
 
 import (
@@ -0,0 +1,276 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// PortTCP reserves a TCP port
+	PortTCP PortProtocol = 1 << iota
+	// PortUDP reserves a UDP port
+	PortUDP
+	// PortTCPUDP reserves the same port for TCP and UDP
+	PortTCPUDP = PortTCP | PortUDP
+)
+
+// PortProtocol is the protocols a port is reserved for
+//   - [PortTCP] [PortUDP] [PortTCPUDP]
+type PortProtocol uint8
+
+const (
+	// maxPortAttempts is the number of ports tried before giving up
+	maxPortAttempts = 100
+)
+
+// handedOut are ports provided by [PortReserver] in this process
+//   - an ephemeral port previously handed out is skipped so that
+//     parallel tests are not provided the same port
+var handedOut = struct {
+	lock  sync.Mutex
+	ports map[uint16]struct{}
+}{ports: make(map[uint16]struct{})}
+
+// FreePort returns a currently free TCP port on the loopback interface
+//   - the port is not held: another process may bind it before use.
+//     Prefer [PortReserver] and [PortReservation.Listener]
+func FreePort() (port uint16, err error) {
+	var reservation *PortReservation
+	if reservation, err = NewPortReserver().Reserve(PortTCP); err != nil {
+		return
+	}
+	port = reservation.Port()
+	err = reservation.Release()
+
+	return
+}
+
+// PortReserver finds free ports by binding them
+//   - a reserved port is bound until handoff or release,
+//     preventing other tests and processes from taking it
+//   - [PortReservation.Listener] and [PortReservation.PacketConn] hand off
+//     the bound socket without a window for another process
+//   - ports are not provided twice within the process
+//   - thread-safe
+//
+// Usage:
+//
+//	var reserver = pnet.NewPortReserver()
+//	defer parl.Close(reserver, &err)
+//	var reservation, err = reserver.Reserve(pnet.PortTCP)
+//	…
+//	var listener = reservation.Listener()
+//	go http.Serve(listener, handler)
+//	client.Get(parl.Sprintf("http://127.0.0.1:%d/", reservation.Port()))
+type PortReserver struct {
+	// addr is the interface address ports are bound on
+	addr netip.Addr
+	lock sync.Mutex
+	// reservations are reservations not yet released
+	//	- behind lock
+	reservations []*PortReservation
+}
+
+// NewPortReserver returns a port reserver
+//   - addr: interface address to bind, default 127.0.0.1
+func NewPortReserver(addr ...netip.Addr) (reserver *PortReserver) {
+	var a = netip.AddrFrom4([4]byte{127, 0, 0, 1})
+	if len(addr) > 0 && addr[0].IsValid() {
+		a = addr[0]
+	}
+	return &PortReserver{addr: a}
+}
+
+// Reserve binds a free port for protocols
+//   - protocols: [PortTCP] [PortUDP] or [PortTCPUDP] for the same port number
+func (r *PortReserver) Reserve(protocols PortProtocol) (reservation *PortReservation, err error) {
+	var reservations []*PortReservation
+	if reservations, err = r.ReserveRange(1, protocols); err != nil {
+		return
+	}
+	reservation = reservations[0]
+
+	return
+}
+
+// ReserveRange binds n contiguous free ports for protocols
+//   - reservations: n reservations in port order
+func (r *PortReserver) ReserveRange(n int, protocols PortProtocol) (reservations []*PortReservation, err error) {
+	if n < 1 {
+		panic(perrors.ErrorfPF("n must be positive: %d", n))
+	} else if protocols&PortTCPUDP == 0 {
+		panic(perrors.ErrorfPF("bad protocols: %d", protocols))
+	}
+	for attempt := 0; attempt < maxPortAttempts; attempt++ {
+		// the first port is ephemeral
+		var first *PortReservation
+		if first, err = r.bind(0, protocols); err != nil {
+			return
+		}
+		reservations = append(reservations[:0], first)
+		for i := 1; i < n; i++ {
+			var port = int(first.port) + i
+			if port > 65535 {
+				break
+			}
+			var reservation *PortReservation
+			if reservation, err = r.bind(uint16(port), protocols); err != nil {
+				// port is in use: try another range
+				err = nil
+				break
+			}
+			reservations = append(reservations, reservation)
+		}
+		if len(reservations) == n && handOut(reservations) {
+			r.lock.Lock()
+			r.reservations = append(r.reservations, reservations...)
+			r.lock.Unlock()
+			return // success return
+		}
+		for _, reservation := range reservations {
+			reservation.Release()
+		}
+	}
+	reservations = nil
+	err = perrors.ErrorfPF("failed to reserve %d ports in %d attempts", n, maxPortAttempts)
+
+	return
+}
+
+// Close releases all reservations that were not handed off
+func (r *PortReserver) Close() (err error) {
+	r.lock.Lock()
+	var reservations = r.reservations
+	r.reservations = nil
+	r.lock.Unlock()
+
+	for _, reservation := range reservations {
+		if e := reservation.Release(); e != nil {
+			err = perrors.AppendError(err, e)
+		}
+	}
+
+	return
+}
+
+// bind binds port for protocols
+//   - port 0: ephemeral port
+func (r *PortReserver) bind(port uint16, protocols PortProtocol) (reservation *PortReservation, err error) {
+	reservation = &PortReservation{port: port}
+	var ip = net.IP(r.addr.AsSlice())
+	if protocols&PortTCP != 0 {
+		if reservation.listener, err = net.ListenTCP("tcp", &net.TCPAddr{IP: ip, Port: int(port)}); err != nil {
+			err = perrors.ErrorfPF("listen tcp %s: %w", netip.AddrPortFrom(r.addr, port), err)
+			return
+		}
+		reservation.port = uint16(reservation.listener.Addr().(*net.TCPAddr).Port)
+	}
+	if protocols&PortUDP != 0 {
+		if reservation.packetConn, err = net.ListenUDP("udp", &net.UDPAddr{IP: ip, Port: int(reservation.port)}); err != nil {
+			err = perrors.ErrorfPF("listen udp %s: %w", netip.AddrPortFrom(r.addr, reservation.port), err)
+			reservation.Release()
+			return
+		}
+		reservation.port = uint16(reservation.packetConn.LocalAddr().(*net.UDPAddr).Port)
+	}
+	reservation.addrPort = netip.AddrPortFrom(r.addr, reservation.port)
+
+	return
+}
+
+// handOut records ports as handed out
+//   - isNew false: a port was previously handed out
+func handOut(reservations []*PortReservation) (isNew bool) {
+	handedOut.lock.Lock()
+	defer handedOut.lock.Unlock()
+
+	for _, reservation := range reservations {
+		if _, exists := handedOut.ports[reservation.port]; exists {
+			return // previously handed out return
+		}
+	}
+	for _, reservation := range reservations {
+		handedOut.ports[reservation.port] = struct{}{}
+	}
+
+	return true
+}
+
+// PortReservation is a bound port
+type PortReservation struct {
+	port     uint16
+	addrPort netip.AddrPort
+	lock     sync.Mutex
+	// listener is bound TCP socket, nil if not reserved or handed off
+	//	- behind lock
+	listener *net.TCPListener
+	// packetConn is bound UDP socket, nil if not reserved or handed off
+	//	- behind lock
+	packetConn *net.UDPConn
+}
+
+// Port returns the reserved port number
+func (p *PortReservation) Port() (port uint16) { return p.port }
+
+// AddrPort returns the reserved socket address “127.0.0.1:1234”
+func (p *PortReservation) AddrPort() (addrPort netip.AddrPort) { return p.addrPort }
+
+// Listener hands off the bound TCP listener
+//   - the caller is responsible for closing listener
+//   - nil if TCP was not reserved or already handed off
+func (p *PortReservation) Listener() (listener net.Listener) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.listener == nil {
+		return // no listener return
+	}
+	listener = p.listener
+	p.listener = nil
+
+	return
+}
+
+// PacketConn hands off the bound UDP socket
+//   - the caller is responsible for closing packetConn
+//   - nil if UDP was not reserved or already handed off
+func (p *PortReservation) PacketConn() (packetConn net.PacketConn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.packetConn == nil {
+		return // no packetConn return
+	}
+	packetConn = p.packetConn
+	p.packetConn = nil
+
+	return
+}
+
+// Release closes sockets not handed off
+//   - after Release, another process may bind the port
+//   - idempotent
+func (p *PortReservation) Release() (err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.listener != nil {
+		parl.Close(p.listener, &err)
+		p.listener = nil
+	}
+	if p.packetConn != nil {
+		parl.Close(p.packetConn, &err)
+		p.packetConn = nil
+	}
+
+	return
+}
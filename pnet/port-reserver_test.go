@@ -0,0 +1,67 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"net"
+	"testing"
+)
+
+func TestPortReserver(t *testing.T) {
+	//t.Error("Logging on")
+	var n = 3
+
+	var reserver = NewPortReserver()
+	defer reserver.Close()
+
+	// contiguous range for TCP and UDP
+	var reservations, err = reserver.ReserveRange(n, PortTCPUDP)
+	if err != nil {
+		t.Fatalf("ReserveRange err: %s", err)
+	}
+	if len(reservations) != n {
+		t.Fatalf("reservations: %d exp %d", len(reservations), n)
+	}
+	for i, reservation := range reservations {
+		if reservation.Port() != reservations[0].Port()+uint16(i) {
+			t.Errorf("port %d not contiguous: %d", i, reservation.Port())
+		}
+	}
+
+	// a reserved port cannot be bound
+	if _, err = net.Listen("tcp", reservations[0].AddrPort().String()); err == nil {
+		t.Error("reserved port could be bound")
+	}
+
+	// handoff
+	var listener = reservations[0].Listener()
+	if listener == nil {
+		t.Fatal("Listener nil")
+	}
+	defer listener.Close()
+	if reservations[0].Listener() != nil {
+		t.Error("second Listener not nil")
+	}
+	if listener.Addr().String() != reservations[0].AddrPort().String() {
+		t.Errorf("listener %s exp %s", listener.Addr(), reservations[0].AddrPort())
+	}
+	var packetConn = reservations[1].PacketConn()
+	if packetConn == nil {
+		t.Fatal("PacketConn nil")
+	}
+	defer packetConn.Close()
+
+	// ports are not handed out twice
+	var port uint16
+	if port, err = FreePort(); err != nil {
+		t.Fatalf("FreePort err: %s", err)
+	}
+	for _, reservation := range reservations {
+		if reservation.Port() == port {
+			t.Errorf("FreePort returned reserved port %d", port)
+		}
+	}
+}
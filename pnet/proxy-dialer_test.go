@@ -0,0 +1,241 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestProxyDialerSOCKS5(t *testing.T) {
+	//t.Error("Logging on")
+	var user, password = "user", "secret"
+	var ctx = context.Background()
+	var target = proxyTestEcho(t)
+	var proxyAddress = proxyTestSOCKS5(t, user, password)
+
+	// correct credentials should connect to target
+	var dialer = NewProxyDialer(ProxyURL(&url.URL{Scheme: schemeSOCKS5, Host: proxyAddress, User: url.UserPassword(user, password)}))
+	var conn, err = dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		t.Fatalf("DialContext err %s", err)
+	}
+	proxyTestRoundTrip(t, conn)
+
+	// bad credentials should be ProxyAuth
+	dialer = NewProxyDialer(ProxyURL(&url.URL{Scheme: schemeSOCKS5, Host: proxyAddress, User: url.UserPassword(user, "bad")}))
+	_, err = dialer.DialContext(ctx, "tcp", target)
+	var proxyError *ProxyError
+	if !errors.As(err, &proxyError) || proxyError.Kind != ProxyAuth || !errors.Is(err, ErrProxy) {
+		t.Errorf("bad credentials err %v", err)
+	}
+
+	// unreachable proxy should be ProxyConnect
+	var closed = proxyTestClosedAddress(t)
+	dialer = NewProxyDialer(ProxyURL(&url.URL{Scheme: schemeSOCKS5, Host: closed}))
+	if _, err = dialer.DialContext(ctx, "tcp", target); !errors.As(err, &proxyError) || proxyError.Kind != ProxyConnect {
+		t.Errorf("closed proxy err %v", err)
+	}
+}
+
+func TestProxyDialerHTTPConnect(t *testing.T) {
+	//t.Error("Logging on")
+	var ctx = context.Background()
+	var target = proxyTestEcho(t)
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		} else if r.Host != target {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var upstream, err = net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		// hijack before responding: a written header would be chunked
+		var conn, _, _ = w.(http.Hijacker).Hijack()
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go proxyTestPipe(conn, upstream)
+	}))
+	defer server.Close()
+	var proxyURL, _ = url.Parse(server.URL)
+
+	// CONNECT should connect to target
+	var dialer = NewProxyDialer(ProxyURL(proxyURL))
+	var conn, err = dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		t.Fatalf("DialContext err %s", err)
+	}
+	proxyTestRoundTrip(t, conn)
+
+	// refused target should be ProxyRefused with status code
+	var proxyError *ProxyError
+	if _, err = dialer.DialContext(ctx, "tcp", "127.0.0.1:1"); !errors.As(err, &proxyError) ||
+		proxyError.Kind != ProxyRefused || proxyError.Code != http.StatusForbidden {
+		t.Errorf("refused err %v", err)
+	}
+}
+
+func TestIsNoProxy(t *testing.T) {
+	//t.Error("Logging on")
+	t.Setenv(noProxyEnv, "example.com, 10.0.0.0/8,::1,host:8080")
+	for _, tc := range []struct {
+		target string
+		exp    bool
+	}{
+		{"example.com:443", true},
+		{"www.example.com:443", true},
+		{"badexample.com:443", false},
+		{"10.1.2.3:22", true},
+		{"[::1]:22", true},
+		{"host:8080", true},
+		{"host:80", false},
+	} {
+		if is := isNoProxy(tc.target); is != tc.exp {
+			t.Errorf("isNoProxy %q %t exp %t", tc.target, is, tc.exp)
+		}
+	}
+}
+
+// proxyTestRoundTrip verifies echo through conn
+func proxyTestRoundTrip(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	var text = "hello"
+	if _, err := io.WriteString(conn, text); err != nil {
+		t.Fatalf("Write err %s", err)
+	}
+	var p = make([]byte, len(text))
+	if _, err := io.ReadFull(conn, p); err != nil {
+		t.Fatalf("Read err %s", err)
+	} else if string(p) != text {
+		t.Errorf("echo %q exp %q", p, text)
+	}
+}
+
+// proxyTestEcho returns the address of an echo server
+func proxyTestEcho(t *testing.T) (address string) {
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen err %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			var conn, err = listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// proxyTestClosedAddress returns an address with no listener
+func proxyTestClosedAddress(t *testing.T) (address string) {
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen err %s", err)
+	}
+	address = listener.Addr().String()
+	listener.Close()
+	return
+}
+
+// proxyTestSOCKS5 returns the address of a SOCKS5 proxy requiring
+// username/password, supporting IPv4 CONNECT
+func proxyTestSOCKS5(t *testing.T, user, password string) (address string) {
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen err %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			var conn, err = listener.Accept()
+			if err != nil {
+				return
+			}
+			go proxyTestSOCKS5Conn(conn, user, password)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// proxyTestSOCKS5Conn serves one SOCKS5 connection
+func proxyTestSOCKS5Conn(conn net.Conn, user, password string) {
+	var isPipe bool
+	defer func() {
+		if !isPipe {
+			conn.Close()
+		}
+	}()
+
+	// methods
+	var header [2]byte
+	if _, err := io.ReadFull(conn, header[:]); err != nil {
+		return
+	}
+	var methods = make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+	conn.Write([]byte{socks5Version, socks5UserPass})
+
+	// username/password
+	var version, length [1]byte
+	io.ReadFull(conn, version[:])
+	io.ReadFull(conn, length[:])
+	var u = make([]byte, length[0])
+	io.ReadFull(conn, u)
+	io.ReadFull(conn, length[:])
+	var p = make([]byte, length[0])
+	io.ReadFull(conn, p)
+	if string(u) != user || string(p) != password {
+		conn.Write([]byte{socks5AuthVersion, 1})
+		return
+	}
+	conn.Write([]byte{socks5AuthVersion, socks5Succeeded})
+
+	// connect IPv4
+	var request [4 + 4 + 2]byte
+	if _, err := io.ReadFull(conn, request[:]); err != nil || request[3] != socks5IPv4 {
+		return
+	}
+	var host = net.IP(request[4:8]).String()
+	var port = strconv.Itoa(int(binary.BigEndian.Uint16(request[8:])))
+	var upstream, err = net.Dial("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		conn.Write([]byte{socks5Version, 5, 0, socks5IPv4, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	conn.Write([]byte{socks5Version, socks5Succeeded, 0, socks5IPv4, 0, 0, 0, 0, 0, 0})
+	isPipe = true
+	proxyTestPipe(conn, upstream)
+}
+
+// proxyTestPipe copies between a and b until either closes
+func proxyTestPipe(a, b net.Conn) {
+	defer a.Close()
+	defer b.Close()
+
+	go io.Copy(a, b)
+	io.Copy(b, a)
+}
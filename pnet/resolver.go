@@ -0,0 +1,244 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// DefaultResolverTTL is how long successful lookups are cached
+	DefaultResolverTTL = time.Minute
+	// DefaultResolverNegativeTTL is how long failed lookups are cached
+	DefaultResolverNegativeTTL = 5 * time.Second
+	// NoNegativeCache disables caching of failed lookups
+	NoNegativeCache time.Duration = -1
+	// timeout for a lookup shared by multiple callers
+	resolverLookupTimeout = 30 * time.Second
+)
+
+// Resolver is a caching DNS resolver
+//   - cache is keyed by name and network “ip” “ip4” “ip6”
+//   - concurrent lookups of the same key are deduplicated into one
+//     underlying lookup
+//   - each caller’s context cancels its wait, the shared lookup continues for
+//     other callers
+//   - net.Resolver does not expose record TTL, so cache lifetime is
+//     configured: ttl for answers, negativeTTL for failures.
+//     Honoring record TTL would require a DNS message parser
+//   - expired entries are evicted when entries are added,
+//     at most once per ttl, so the cache is bounded by names
+//     looked up recently
+//   - [Resolver.Stats] returns hit and miss metrics
+//   - thread-safe
+type Resolver struct {
+	resolver    *net.Resolver
+	ttl         time.Duration
+	negativeTTL time.Duration
+	// lock makes cache and inflight thread-safe
+	lock     sync.Mutex
+	cache    map[resolverKey]*resolverEntry
+	inflight map[resolverKey]*resolverCall
+	// sweepAt is when expired entries are next evicted, behind lock
+	sweepAt time.Time
+	// metrics
+	hits, misses, shared, errors atomic.Uint64
+}
+
+// resolverKey is cache key
+type resolverKey struct {
+	host string
+	// network is “ip” “ip4” “ip6”
+	network string
+}
+
+// resolverEntry is a cached lookup result
+type resolverEntry struct {
+	addrs   []netip.Addr
+	err     error
+	expires time.Time
+}
+
+// resolverCall is an ongoing lookup
+type resolverCall struct {
+	// done closes when entry is valid
+	done  chan struct{}
+	entry resolverEntry
+}
+
+// ResolverStats are metrics of [Resolver]
+type ResolverStats struct {
+	// Hits is lookups answered from cache
+	Hits uint64
+	// Misses is lookups requiring a DNS query
+	Misses uint64
+	// Shared is lookups that awaited another caller’s ongoing query
+	Shared uint64
+	// Errors is failed DNS queries
+	Errors uint64
+	// Entries is the current number of cached entries including expired
+	Entries int
+}
+
+// NewResolver returns a caching DNS resolver
+//   - resolver: nil is [net.DefaultResolver]
+//   - ttl: lifetime of successful answers, 0 is [DefaultResolverTTL]
+//   - negativeTTL: lifetime of failed lookups,
+//     0 is [DefaultResolverNegativeTTL], [NoNegativeCache] disables
+func NewResolver(resolver *net.Resolver, ttl, negativeTTL time.Duration) (r *Resolver) {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	if ttl <= 0 {
+		ttl = DefaultResolverTTL
+	}
+	if negativeTTL == 0 {
+		negativeTTL = DefaultResolverNegativeTTL
+	}
+	return &Resolver{
+		resolver:    resolver,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		cache:       make(map[resolverKey]*resolverEntry),
+		inflight:    make(map[resolverKey]*resolverCall),
+	}
+}
+
+// LookupNetIP returns addresses for host
+//   - network: “ip” “ip4” “ip6”
+//   - host: domain name. An IP literal is returned without lookup
+//   - addrs: IPv4 addresses are unmapped, ie. 4-byte
+//   - returned slice may be modified by the caller
+func (r *Resolver) LookupNetIP(ctx context.Context, network, host string) (addrs []netip.Addr, err error) {
+
+	// IP literal
+	if addr, e := netip.ParseAddr(host); e == nil {
+		addrs = []netip.Addr{addr.Unmap()}
+		return
+	}
+	var key = resolverKey{host: host, network: network}
+
+	// try cache or join ongoing lookup
+	r.lock.Lock()
+	if entry := r.cache[key]; entry != nil && time.Now().Before(entry.expires) {
+		r.lock.Unlock()
+		r.hits.Add(1)
+		return slices.Clone(entry.addrs), entry.err
+	}
+	var call = r.inflight[key]
+	if call != nil {
+		r.shared.Add(1)
+	} else {
+		r.misses.Add(1)
+		call = &resolverCall{done: make(chan struct{})}
+		r.inflight[key] = call
+		go r.lookup(ctx, key, call)
+	}
+	r.lock.Unlock()
+
+	// await lookup
+	select {
+	case <-call.done:
+		return slices.Clone(call.entry.addrs), call.entry.err
+	case <-ctx.Done():
+		err = perrors.ErrorfPF("lookup %q canceled: %w", host, context.Cause(ctx))
+		return
+	}
+}
+
+// LookupIP is [Resolver.LookupNetIP] returning legacy [net.IP]
+func (r *Resolver) LookupIP(ctx context.Context, network, host string) (IPs []net.IP, err error) {
+	var addrs []netip.Addr
+	if addrs, err = r.LookupNetIP(ctx, network, host); err != nil {
+		return
+	}
+	IPs = AddrsToIPs(addrs)
+
+	return
+}
+
+// Stats returns resolver metrics
+func (r *Resolver) Stats() (stats ResolverStats) {
+	r.lock.Lock()
+	stats.Entries = len(r.cache)
+	r.lock.Unlock()
+
+	stats.Hits = r.hits.Load()
+	stats.Misses = r.misses.Load()
+	stats.Shared = r.shared.Load()
+	stats.Errors = r.errors.Load()
+
+	return
+}
+
+// Flush discards cached entries
+//   - expired: only expired entries are discarded
+func (r *Resolver) Flush(expired ...bool) {
+	var onlyExpired = len(expired) > 0 && expired[0]
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if onlyExpired {
+		r.evict(time.Now())
+		return
+	}
+	clear(r.cache)
+}
+
+// evict deletes expired entries
+//   - invoked while holding lock
+func (r *Resolver) evict(now time.Time) {
+	for key, entry := range r.cache {
+		if !now.Before(entry.expires) {
+			delete(r.cache, key)
+		}
+	}
+}
+
+// lookup executes a shared DNS query
+//   - the query is not canceled by the first caller’s context cancel
+func (r *Resolver) lookup(ctx context.Context, key resolverKey, call *resolverCall) {
+	defer close(call.done)
+
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), resolverLookupTimeout)
+	defer cancel()
+
+	var entry = &call.entry
+	var ttl = r.ttl
+	if entry.addrs, entry.err = r.resolver.LookupNetIP(ctx, key.network, key.host); entry.err != nil {
+		r.errors.Add(1)
+		entry.err = perrors.ErrorfPF("LookupNetIP %s %q: %w", key.network, key.host, entry.err)
+		ttl = r.negativeTTL
+	} else {
+		for i, addr := range entry.addrs {
+			entry.addrs[i] = addr.Unmap()
+		}
+	}
+	var now = time.Now()
+	entry.expires = now.Add(ttl)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.inflight, key)
+	if ttl <= 0 {
+		return // not cached return
+	}
+	if !now.Before(r.sweepAt) {
+		r.evict(now)
+		r.sweepAt = now.Add(r.ttl)
+	}
+	r.cache[key] = entry
+}
@@ -0,0 +1,62 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestResolver(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		network = "ip"
+		literal = "1.2.3.4"
+	)
+	var (
+		ctx      = context.Background()
+		resolver = NewResolver(nil, 0, 0)
+		addrs    []netip.Addr
+		err      error
+	)
+
+	// IP literal is not looked up
+	if addrs, err = resolver.LookupNetIP(ctx, network, literal); err != nil {
+		t.Fatalf("LookupNetIP literal err: %s", err)
+	} else if len(addrs) != 1 || addrs[0] != netip.MustParseAddr(literal) {
+		t.Errorf("LookupNetIP literal %v", addrs)
+	}
+
+	// first lookup is a miss, second a hit
+	if _, err = resolver.LookupNetIP(ctx, network, LocalHost); err != nil {
+		t.Skipf("localhost does not resolve: %s", err)
+	}
+	var IPs, _ = resolver.LookupIP(ctx, network, LocalHost)
+	t.Logf("localhost: %v", IPs)
+	var stats = resolver.Stats()
+	if stats.Misses != 1 || stats.Hits != 1 || stats.Entries != 1 {
+		t.Errorf("Stats %+v exp 1 miss 1 hit 1 entry", stats)
+	}
+
+	// Flush
+	resolver.Flush()
+	if stats = resolver.Stats(); stats.Entries != 0 {
+		t.Errorf("Flush Entries %d exp 0", stats.Entries)
+	}
+
+	// expired entries are evicted when an entry is added
+	resolver = NewResolver(nil, time.Millisecond, 0)
+	if _, err = resolver.LookupNetIP(ctx, network, LocalHost); err != nil {
+		t.Fatalf("LookupNetIP err: %s", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	resolver.LookupNetIP(ctx, "ip4", LocalHost)
+	if stats = resolver.Stats(); stats.Entries != 1 {
+		t.Errorf("evict Entries %d exp 1", stats.Entries)
+	}
+}
@@ -114,6 +114,8 @@ func NewSocketListener[C net.Conn](
 //   - — — if host is blank, it is for localhost
 //   - — — to avoid DNS resolution host should be blank or literal IP address "1.2.3.4:0"
 //   - — for TCP UDP port must be literal port number 0…65534 where 0 means a temporary port
+//   - the process-wide [AddressPolicy] may narrow network and
+//     disallow the address
 //   - Listen can be repeatedly invoked until it succeeds
 func (s *SocketListener[C]) Listen(socketString string) (err error) {
 	s.stateLock.Lock()
@@ -131,14 +133,19 @@ func (s *SocketListener[C]) Listen(socketString string) (err error) {
 	switch s.transport {
 	case TransportTCP:
 		// resolve near socket address
+		var policy = GetAddressPolicy()
+		var network = policy.Network(s.network).String()
 		var tcpAddr *net.TCPAddr
-		if tcpAddr, err = net.ResolveTCPAddr(s.network.String(), socketString); perrors.Is(&err, "ResolveTCPAddr: '%w'", err) {
+		if tcpAddr, err = net.ResolveTCPAddr(network, socketString); perrors.Is(&err, "ResolveTCPAddr: '%w'", err) {
+			return
+		} else if addr := tcpAddr.AddrPort().Addr(); tcpAddr.IP != nil && !policy.Allows(addr) {
+			err = perrors.ErrorfPF("address policy does not allow listening on: %s", addr)
 			return
 		}
 
 		// attempt to listen
 		var netTCPListener *net.TCPListener
-		if netTCPListener, err = net.ListenTCP(s.network.String(), tcpAddr); perrors.Is(&err, "ListenTCP: %w", err) {
+		if netTCPListener, err = net.ListenTCP(network, tcpAddr); perrors.Is(&err, "ListenTCP: %w", err) {
 			return
 		}
 
@@ -0,0 +1,22 @@
+//go:build !darwin && !linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import "github.com/haraldrudell/parl/perrors"
+
+// setListenerOptions: socket options are not supported on this platform
+func setListenerOptions(fd uintptr, o SocketOptions) (err error) {
+	err = perrors.NewPF("SO_REUSEADDR SO_REUSEPORT not supported on this platform")
+	return
+}
+
+// setConnOptions: socket options are not supported on this platform
+func setConnOptions(fd uintptr, isIPv6 bool, o SocketOptions) (err error) {
+	err = perrors.NewPF("connection socket options not supported on this platform")
+	return
+}
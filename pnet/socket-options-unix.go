@@ -0,0 +1,64 @@
+//go:build darwin || linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"golang.org/x/sys/unix"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// setListenerOptions sets SO_REUSEADDR SO_REUSEPORT
+func setListenerOptions(fd uintptr, o SocketOptions) (err error) {
+	if o.ReuseAddr {
+		if err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); perrors.IsPF(&err, "SO_REUSEADDR %w", err) {
+			return
+		}
+	}
+	if o.ReusePort {
+		if err = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1); perrors.IsPF(&err, "SO_REUSEPORT %w", err) {
+			return
+		}
+	}
+	return
+}
+
+// setConnOptions sets TCP_NODELAY IP_TOS IPV6_TCLASS TCP_KEEPINTVL TCP_KEEPCNT
+func setConnOptions(fd uintptr, isIPv6 bool, o SocketOptions) (err error) {
+	var s = int(fd)
+	if o.NoDelay {
+		if err = unix.SetsockoptInt(s, unix.IPPROTO_TCP, unix.TCP_NODELAY, 1); perrors.IsPF(&err, "TCP_NODELAY %w", err) {
+			return
+		}
+	}
+	if o.TOS != 0 {
+		if isIPv6 {
+			err = unix.SetsockoptInt(s, unix.IPPROTO_IPV6, unix.IPV6_TCLASS, o.TOS)
+		} else {
+			err = unix.SetsockoptInt(s, unix.IPPROTO_IP, unix.IP_TOS, o.TOS)
+		}
+		if perrors.IsPF(&err, "TOS %d %w", o.TOS, err) {
+			return
+		}
+	}
+	if o.KeepAlive < 0 {
+		return // keep-alive disabled return
+	}
+	if o.KeepAliveInterval > 0 {
+		var seconds = max(int(o.KeepAliveInterval.Seconds()), 1)
+		if err = unix.SetsockoptInt(s, unix.IPPROTO_TCP, unix.TCP_KEEPINTVL, seconds); perrors.IsPF(&err, "TCP_KEEPINTVL %w", err) {
+			return
+		}
+	}
+	if o.KeepAliveCount > 0 {
+		if err = unix.SetsockoptInt(s, unix.IPPROTO_TCP, unix.TCP_KEEPCNT, o.KeepAliveCount); perrors.IsPF(&err, "TCP_KEEPCNT %w", err) {
+			return
+		}
+	}
+	return
+}
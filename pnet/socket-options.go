@@ -0,0 +1,260 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// ErrConnOptions is returned by Accept of a listener from
+// [SocketOptions.Listen] when socket options could not be set
+// on an accepted connection
+//   - the connection was closed and Accept may be invoked again
+var ErrConnOptions = errors.New("connection options failed")
+
+// SocketOptions are socket options of listeners created by
+// [SocketOptions.Listen] and [NewListenerGroup]
+//   - the zero-value uses Go defaults
+//   - ReuseAddr and ReusePort are set on the listening socket
+//   - NoDelay TOS KeepAliveInterval KeepAliveCount are set on
+//     accepted TCP connections
+//   - options other than KeepAlive are unsupported on platforms other than
+//     Linux and macOS
+type SocketOptions struct {
+	// ReuseAddr sets SO_REUSEADDR allowing bind while a previous
+	// socket is in TIME_WAIT
+	ReuseAddr bool
+	// ReusePort sets SO_REUSEPORT allowing multiple sockets to bind
+	// the same address with the kernel distributing connections
+	ReusePort bool
+	// NoDelay sets TCP_NODELAY disabling Nagle’s algorithm.
+	// Go default for TCP is no delay, ie. NoDelay false does not enable Nagle
+	NoDelay bool
+	// TOS sets IP_TOS or for IPv6 IPV6_TCLASS, 0: not set
+	TOS int
+	// KeepAlive is idle time before keep-alive probes
+	//	- 0: Go default 15 s
+	//	- negative: keep-alive disabled
+	KeepAlive time.Duration
+	// KeepAliveInterval sets TCP_KEEPINTVL time between keep-alive probes
+	//	- 0: KeepAlive
+	KeepAliveInterval time.Duration
+	// KeepAliveCount sets TCP_KEEPCNT unanswered probes before the
+	// connection is closed, 0: system default
+	KeepAliveCount int
+}
+
+// ListenConfig returns a listen configuration setting listener options
+//   - connection options are applied by listeners from [SocketOptions.Listen]
+func (o SocketOptions) ListenConfig() (listenConfig net.ListenConfig) {
+	listenConfig.KeepAlive = o.KeepAlive
+	if o.ReuseAddr || o.ReusePort {
+		listenConfig.Control = o.control
+	}
+	return
+}
+
+// Listen returns a listener with socket options
+//   - network: “tcp” “tcp4” “tcp6”
+//   - address: “127.0.0.1:0”
+func (o SocketOptions) Listen(ctx context.Context, network, address string) (listener net.Listener, err error) {
+	var listenConfig = o.ListenConfig()
+	if listener, err = listenConfig.Listen(ctx, network, address); perrors.IsPF(&err, "listen %s %s: %w", network, address, err) {
+		return
+	}
+	if o.hasConnOptions() {
+		listener = &optionListener{Listener: listener, options: o}
+	}
+
+	return
+}
+
+// control sets listener options on the socket prior to bind
+//   - [net.ListenConfig.Control]
+func (o SocketOptions) control(network, address string, rawConn syscall.RawConn) (err error) {
+	if e := rawConn.Control(func(fd uintptr) { err = setListenerOptions(fd, o) }); e != nil {
+		err = perrors.AppendError(err, e)
+	}
+	if err != nil {
+		err = perrors.ErrorfPF("socket options %s %s: %w", network, address, err)
+	}
+	return
+}
+
+// hasConnOptions is true if options are set on accepted connections
+func (o SocketOptions) hasConnOptions() (hasConnOptions bool) {
+	return o.NoDelay || o.TOS != 0 || o.KeepAlive >= 0 && (o.KeepAliveInterval > 0 || o.KeepAliveCount > 0)
+}
+
+// optionListener sets socket options on accepted connections
+type optionListener struct {
+	net.Listener
+	options SocketOptions
+}
+
+// Accept sets socket options on the accepted connection
+//   - a connection whose options fail is closed and
+//     an error is returned wrapping [ErrConnOptions]
+func (l *optionListener) Accept() (conn net.Conn, err error) {
+	if conn, err = l.Listener.Accept(); err != nil {
+		return
+	}
+	var syscallConn, ok = conn.(syscall.Conn)
+	if !ok {
+		return // not a socket return
+	}
+	var rawConn syscall.RawConn
+	if rawConn, err = syscallConn.SyscallConn(); err == nil {
+		var isIPv6 = isIPv6Addr(conn.LocalAddr())
+		if e := rawConn.Control(func(fd uintptr) { err = setConnOptions(fd, isIPv6, l.options) }); e != nil {
+			err = perrors.AppendError(err, e)
+		}
+	}
+	if err != nil {
+		err = perrors.ErrorfPF("%w %s: %w", ErrConnOptions, conn.RemoteAddr(), err)
+		conn.Close()
+		conn = nil
+	}
+
+	return
+}
+
+// isIPv6Addr is true for an IPv6 TCP address that is not IPv4-mapped
+func isIPv6Addr(addr net.Addr) (isIPv6 bool) {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		isIPv6 = tcpAddr.IP.To4() == nil
+	}
+	return
+}
+
+// ListenerGroup is a group of SO_REUSEPORT listeners on the same address
+//   - the kernel distributes incoming connections among listeners,
+//     sharding accept across cores
+//   - [ListenerGroup.Serve] runs an accept thread per listener in a thread-group
+//   - thread-safe
+//
+// Usage:
+//
+//	var group, err = pnet.NewListenerGroup(ctx, "tcp", ":8080", 0, pnet.SocketOptions{NoDelay: true})
+//	…
+//	defer parl.Close(group, &err)
+//	group.Serve(goGroup, func(conn net.Conn, g parl.Go) {
+//	  defer conn.Close()
+//	  …
+//	})
+type ListenerGroup struct {
+	// Listeners are the listening sockets
+	Listeners []net.Listener
+}
+
+// NewListenerGroup returns n listeners on address with SO_REUSEPORT
+//   - n: number of listeners, 0: runtime.NumCPU
+//   - address: a port 0 is resolved by the first listener so that
+//     all listeners share the port
+//   - options: optional socket options, ReusePort is always set
+func NewListenerGroup(ctx context.Context, network, address string, n int, options ...SocketOptions) (group *ListenerGroup, err error) {
+	var o SocketOptions
+	if len(options) > 0 {
+		o = options[0]
+	}
+	o.ReusePort = true
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+	group = &ListenerGroup{Listeners: make([]net.Listener, 0, n)}
+	for i := 0; i < n; i++ {
+		var listener net.Listener
+		if listener, err = o.Listen(ctx, network, address); err != nil {
+			if e := group.Close(); e != nil {
+				err = perrors.AppendError(err, e)
+			}
+			group = nil
+			return
+		}
+		if i == 0 {
+			// port 0 was resolved
+			address = listener.Addr().String()
+		}
+		group.Listeners = append(group.Listeners, listener)
+	}
+
+	return
+}
+
+// Addr returns the address all listeners are bound to
+func (g *ListenerGroup) Addr() (addr net.Addr) { return g.Listeners[0].Addr() }
+
+// Serve launches an accept thread per listener in goGen
+//   - handler is invoked in a new thread of goGen for each connection.
+//     handler closes conn. A handler panic is a fatal thread error
+//   - accept threads exit when the listener is closed or
+//     goGen’s context is canceled
+//   - cancel of goGen’s context closes the listeners
+//   - failure to set connection options is a non-fatal error
+//   - an accept error other than close is a fatal error of the accept thread
+func (g *ListenerGroup) Serve(goGen parl.GoGen, handler func(conn net.Conn, g parl.Go)) {
+	if goGen == nil {
+		panic(parl.NilError("goGen"))
+	} else if handler == nil {
+		panic(parl.NilError("handler"))
+	}
+	context.AfterFunc(goGen.Context(), func() { g.Close() })
+	for _, listener := range g.Listeners {
+		go acceptThread(listener, goGen, handler, goGen.Go())
+	}
+}
+
+// Close closes all listeners
+//   - idempotent
+func (g *ListenerGroup) Close() (err error) {
+	for _, listener := range g.Listeners {
+		if e := listener.Close(); e != nil && !errors.Is(e, net.ErrClosed) {
+			err = perrors.AppendError(err, perrors.ErrorfPF("listener close %s: %w", listener.Addr(), e))
+		}
+	}
+	return
+}
+
+// acceptThread accepts connections until listener close
+func acceptThread(listener net.Listener, goGen parl.GoGen, handler func(conn net.Conn, g parl.Go), g parl.Go) {
+	var err error
+	defer g.Done(&err)
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	for {
+		var conn net.Conn
+		if conn, err = listener.Accept(); err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				err = nil
+				return // listener closed return
+			} else if errors.Is(err, ErrConnOptions) {
+				g.AddError(err)
+				err = nil
+				continue
+			}
+			err = perrors.ErrorfPF("accept %s: %w", listener.Addr(), err)
+			return
+		}
+		go handlerThread(conn, handler, goGen.Go())
+	}
+}
+
+// handlerThread invokes handler for conn
+func handlerThread(conn net.Conn, handler func(conn net.Conn, g parl.Go), g parl.Go) {
+	var err error
+	defer g.Done(&err)
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	handler(conn, g)
+}
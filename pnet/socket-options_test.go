@@ -0,0 +1,73 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"io"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/g0"
+)
+
+func TestListenerGroup(t *testing.T) {
+	//t.Error("Logging on")
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("SO_REUSEPORT not supported")
+	}
+	var n = 2
+	var expText = "hello"
+	var options = SocketOptions{
+		ReuseAddr:         true,
+		NoDelay:           true,
+		KeepAliveInterval: time.Second,
+		KeepAliveCount:    3,
+	}
+
+	var group, err = NewListenerGroup(context.Background(), "tcp", "127.0.0.1:0", n, options)
+	if err != nil {
+		t.Fatalf("NewListenerGroup err: %s", err)
+	}
+	if len(group.Listeners) != n {
+		t.Fatalf("listeners %d exp %d", len(group.Listeners), n)
+	}
+	var addr = group.Addr().String()
+	for _, listener := range group.Listeners {
+		if a := listener.Addr().String(); a != addr {
+			t.Errorf("listener addr %s exp %s", a, addr)
+		}
+	}
+
+	var goGroup = g0.NewGoGroup(context.Background())
+	group.Serve(goGroup, func(conn net.Conn, g parl.Go) {
+		defer conn.Close()
+		io.WriteString(conn, expText)
+	})
+
+	for i := 0; i < 3; i++ {
+		var conn net.Conn
+		if conn, err = net.Dial("tcp", addr); err != nil {
+			t.Fatalf("Dial err: %s", err)
+		}
+		var byts []byte
+		byts, err = io.ReadAll(conn)
+		conn.Close()
+		if err != nil || string(byts) != expText {
+			t.Errorf("read %q err %v", byts, err)
+		}
+	}
+
+	// cancel closes listeners and ends accept threads
+	goGroup.Cancel()
+	goGroup.Wait()
+	if err = group.Close(); err != nil {
+		t.Errorf("Close err: %s", err)
+	}
+}
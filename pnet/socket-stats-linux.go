@@ -0,0 +1,175 @@
+//go:build linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/haraldrudell/parl/iana"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// procNet is directory of socket tables
+	procNet = "/proc/net/"
+	// socketLinkPrefix is prefix of an fd symlink target for a socket
+	socketLinkPrefix = "socket:["
+)
+
+// socketStats reads socket tables of protocols from /proc/net
+func socketStats(protocols []iana.Protocol) (stats []SocketStat, err error) {
+	for _, protocol := range protocols {
+		var name string
+		switch protocol {
+		case iana.IPtcp:
+			name = "tcp"
+		case iana.IPudp:
+			name = "udp"
+		default:
+			err = perrors.ErrorfPF("unsupported protocol: %s", protocol)
+			return
+		}
+		for _, file := range []string{name, name + "6"} {
+			if stats, err = readProcNet(procNet+file, protocol, stats); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// readProcNet appends sockets of a /proc/net table
+//   - a missing table, ie. IPv6 disabled, is empty
+func readProcNet(path string, protocol iana.Protocol, stats0 []SocketStat) (stats []SocketStat, err error) {
+	stats = stats0
+	var file *os.File
+	if file, err = os.Open(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+			return // no table return
+		}
+		err = perrors.ErrorfPF("os.Open %w", err)
+		return
+	}
+	defer file.Close()
+
+	var scanner = bufio.NewScanner(file)
+	// skip header line
+	scanner.Scan()
+	for scanner.Scan() {
+		// “0: 0100007F:BC8F 00000000:0000 0A 00000000:00000000 00:00000000 00000000 65534 0 1036 …”
+		var fields = strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		var stat = SocketStat{Protocol: protocol}
+		var state, uid, inode uint64
+		if stat.Local, err = parseProcAddrPort(fields[1]); err != nil {
+			break
+		} else if stat.Remote, err = parseProcAddrPort(fields[2]); err != nil {
+			break
+		} else if state, err = strconv.ParseUint(fields[3], 16, 8); err != nil {
+			break
+		} else if uid, err = strconv.ParseUint(fields[7], 10, 32); err != nil {
+			break
+		} else if inode, err = strconv.ParseUint(fields[9], 10, 64); err != nil {
+			break
+		}
+		stat.State = SocketState(state)
+		stat.UID = uint32(uid)
+		stat.Inode = inode
+		stats = append(stats, stat)
+	}
+	if err == nil {
+		err = scanner.Err()
+	}
+	if perrors.IsPF(&err, "%s: %w", path, err) {
+		return
+	}
+
+	return
+}
+
+// parseProcAddrPort parses “0100007F:BC8F”
+//   - address is 32-bit words in host byte order
+func parseProcAddrPort(s string) (addrPort netip.AddrPort, err error) {
+	var hexAddr, hexPort, ok = strings.Cut(s, ":")
+	if !ok {
+		err = perrors.ErrorfPF("bad address: %q", s)
+		return
+	}
+	var port uint64
+	if port, err = strconv.ParseUint(hexPort, 16, 16); err != nil {
+		return
+	}
+	var b []byte
+	if b, err = hex.DecodeString(hexAddr); err != nil {
+		return
+	} else if len(b) != 4 && len(b) != 16 {
+		err = perrors.ErrorfPF("bad address length: %q", s)
+		return
+	}
+	// convert host-order words to network byte order
+	for i := 0; i < len(b); i += 4 {
+		binary.BigEndian.PutUint32(b[i:], binary.NativeEndian.Uint32(b[i:]))
+	}
+	var addr, _ = netip.AddrFromSlice(b)
+	addrPort = netip.AddrPortFrom(addr.Unmap(), uint16(port))
+
+	return
+}
+
+// socketProcesses sets PID and Process of stats by
+// scanning file descriptors of processes
+//   - processes that cannot be inspected are ignored
+func socketProcesses(stats []SocketStat) {
+	var indexes = make(map[uint64][]int, len(stats))
+	for i := range stats {
+		if inode := stats[i].Inode; inode != 0 {
+			indexes[inode] = append(indexes[inode], i)
+		}
+	}
+	var pids, _ = filepath.Glob("/proc/[0-9]*")
+	for _, pidDir := range pids {
+		var fds, err = os.ReadDir(pidDir + "/fd")
+		if err != nil {
+			continue // not permitted or exited
+		}
+		var pid, _ = strconv.Atoi(filepath.Base(pidDir))
+		var process string
+		for _, fd := range fds {
+			var link, err = os.Readlink(pidDir + "/fd/" + fd.Name())
+			if err != nil || !strings.HasPrefix(link, socketLinkPrefix) {
+				continue
+			}
+			var inode, e = strconv.ParseUint(strings.TrimSuffix(link[len(socketLinkPrefix):], "]"), 10, 64)
+			if e != nil {
+				continue
+			}
+			for _, i := range indexes[inode] {
+				if process == "" {
+					if comm, err := os.ReadFile(pidDir + "/comm"); err == nil {
+						process = strings.TrimSpace(string(comm))
+					}
+				}
+				stats[i].PID = pid
+				stats[i].Process = process
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import "testing"
+
+func TestParseProcAddrPort(t *testing.T) {
+	var addrPort, err = parseProcAddrPort("00000000000000000000000001000000:0050")
+	if err != nil {
+		t.Fatalf("parseProcAddrPort err: %s", err)
+	} else if s := addrPort.String(); s != "[::1]:80" {
+		t.Errorf("parseProcAddrPort %q exp [::1]:80", s)
+	}
+
+	// IPv4 should be in host byte order
+	if addrPort, err = parseProcAddrPort("0100007F:BC8F"); err != nil {
+		t.Fatalf("parseProcAddrPort err: %s", err)
+	} else if s := addrPort.String(); s != "127.0.0.1:48271" {
+		t.Errorf("parseProcAddrPort %q exp 127.0.0.1:48271", s)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"github.com/haraldrudell/parl/iana"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// socketStats: socket tables are not available on this platform
+func socketStats(protocols []iana.Protocol) (stats []SocketStat, err error) {
+	err = perrors.NewPF("socket statistics not supported on this platform")
+	return
+}
+
+// socketProcesses: not available on this platform
+func socketProcesses(stats []SocketStat) {}
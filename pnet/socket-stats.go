@@ -0,0 +1,121 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/haraldrudell/parl/iana"
+)
+
+const (
+	// SocketEstablished is TCP ESTABLISHED or connected UDP
+	SocketEstablished SocketState = iota + 1
+	SocketSynSent
+	SocketSynRecv
+	SocketFinWait1
+	SocketFinWait2
+	SocketTimeWait
+	// SocketClose is TCP CLOSE or unconnected UDP
+	SocketClose
+	SocketCloseWait
+	SocketLastAck
+	// SocketListen is a listening TCP socket
+	SocketListen
+	SocketClosing
+)
+
+// SocketState is the state of a socket found by [SocketStats]
+//   - numbering is that of the Linux kernel
+type SocketState uint8
+
+// SocketStat is a TCP or UDP socket of the host
+type SocketStat struct {
+	// Protocol is [iana.IPtcp] or [iana.IPudp]
+	Protocol iana.Protocol
+	// State is socket state
+	State SocketState
+	// Local is local address and port
+	Local netip.AddrPort
+	// Remote is remote address and port, zero-port if not connected
+	Remote netip.AddrPort
+	// UID is the user ID owning the socket
+	UID uint32
+	// Inode identifies the socket
+	Inode uint64
+	// PID is the process owning the socket
+	//	- 0: not known, not permitted or [SocketFilter.NoProcess]
+	PID int
+	// Process is the command name of PID
+	Process string
+}
+
+// SocketFilter selects sockets returned by [SocketStats]
+type SocketFilter struct {
+	// Protocols is [iana.IPtcp] and/or [iana.IPudp]
+	//	- empty: both TCP and UDP
+	Protocols []iana.Protocol
+	// Port selects sockets whose local or remote port is Port
+	//	- 0: any port
+	Port uint16
+	// NoProcess omits the costly determining of owning process
+	NoProcess bool
+}
+
+// SocketStats returns TCP and UDP sockets of the host like ss or netstat
+//   - filter: optional protocol and port selection
+//   - the owning process is only found for sockets of processes
+//     that the current process is permitted to inspect
+//   - Linux: from /proc/net and /proc/[pid]/fd.
+//     Other platforms return error
+func SocketStats(filter ...SocketFilter) (stats []SocketStat, err error) {
+	var f SocketFilter
+	if len(filter) > 0 {
+		f = filter[0]
+	}
+	if len(f.Protocols) == 0 {
+		f.Protocols = []iana.Protocol{iana.IPtcp, iana.IPudp}
+	}
+	if stats, err = socketStats(f.Protocols); err != nil {
+		return
+	}
+	if f.Port != 0 {
+		stats = slices.DeleteFunc(stats, func(stat SocketStat) (doDelete bool) {
+			return stat.Local.Port() != f.Port && stat.Remote.Port() != f.Port
+		})
+	}
+	if !f.NoProcess && len(stats) > 0 {
+		socketProcesses(stats)
+	}
+
+	return
+}
+
+// “tcp 127.0.0.1:8080 0.0.0.0:0 LISTEN pid 123 myprocess”
+func (s SocketStat) String() (s2 string) {
+	s2 = fmt.Sprintf("%s %s %s %s", s.Protocol.String(), s.Local, s.Remote, s.State)
+	if s.PID != 0 {
+		s2 += fmt.Sprintf(" pid %d %s", s.PID, s.Process)
+	}
+	return
+}
+
+// "ESTABLISHED" "SYN_SENT" "SYN_RECV" "FIN_WAIT1" "FIN_WAIT2" "TIME_WAIT"
+// "CLOSE" "CLOSE_WAIT" "LAST_ACK" "LISTEN" "CLOSING"
+func (s SocketState) String() (s2 string) {
+	if s >= SocketEstablished && s <= SocketClosing {
+		return socketStateNames[s-SocketEstablished]
+	}
+	return fmt.Sprintf("?socketState%d", s)
+}
+
+// socketStateNames are names of socket states
+var socketStateNames = []string{
+	"ESTABLISHED", "SYN_SENT", "SYN_RECV", "FIN_WAIT1", "FIN_WAIT2", "TIME_WAIT",
+	"CLOSE", "CLOSE_WAIT", "LAST_ACK", "LISTEN", "CLOSING",
+}
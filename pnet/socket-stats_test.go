@@ -0,0 +1,54 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/haraldrudell/parl/iana"
+)
+
+func TestSocketStats(t *testing.T) {
+	//t.Error("Logging on")
+	if runtime.GOOS != "linux" {
+		t.Skip("socket statistics only on Linux")
+	}
+
+	var listener, err = net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen err: %s", err)
+	}
+	defer listener.Close()
+	var port = uint16(listener.Addr().(*net.TCPAddr).Port)
+
+	// SocketStats should find the listener with owning process
+	var stats []SocketStat
+	if stats, err = SocketStats(SocketFilter{Protocols: []iana.Protocol{iana.IPtcp}, Port: port}); err != nil {
+		t.Fatalf("SocketStats err: %s", err)
+	}
+	var stat *SocketStat
+	for i := range stats {
+		if stats[i].State == SocketListen {
+			stat = &stats[i]
+		}
+	}
+	if stat == nil {
+		t.Fatalf("listener not found: %v", stats)
+	}
+	t.Logf("stat: %s", stat)
+	if stat.Local.Port() != port || stat.Local.Addr().String() != "127.0.0.1" {
+		t.Errorf("Local %s exp 127.0.0.1:%d", stat.Local, port)
+	}
+	if stat.Protocol != iana.IPtcp {
+		t.Errorf("Protocol %s exp TCP", stat.Protocol)
+	}
+	if stat.PID != os.Getpid() {
+		t.Errorf("PID %d exp %d", stat.PID, os.Getpid())
+	}
+}
@@ -73,7 +73,7 @@ func (hp *Http) listen() (listener net.Listener, err error) {
 		err = error116.New("Already listening")
 		return
 	}
-	listener, err = net.Listen("tcp", addr)
+	listener, err = net.Listen(GetAddressPolicy().Network(NetworkTCP).String(), addr)
 	hp.Addr = listener.Addr()
 	return
 }
@@ -90,7 +90,7 @@ func (hp *Http) listenTLS() (listener net.Listener, err error) {
 		err = error116.New("Already listening")
 		return
 	}
-	listener, err = net.Listen("tcp", addr)
+	listener, err = net.Listen(GetAddressPolicy().Network(NetworkTCP).String(), addr)
 	hp.Addr = listener.Addr()
 	return
 }
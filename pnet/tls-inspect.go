@@ -0,0 +1,235 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// DefaultTLSInspectTimeout is dial and handshake timeout of [InspectTLS]
+	DefaultTLSInspectTimeout = 10 * time.Second
+)
+
+// TLSInspectConfig configures [InspectTLS] [InspectTLSConn]
+type TLSInspectConfig struct {
+	// MinVersion MaxVersion limit offered TLS versions
+	//	- 0: crypto/tls defaults
+	MinVersion, MaxVersion uint16
+	// CipherSuites are offered TLS 1.2 cipher suites
+	//	- nil: crypto/tls defaults
+	CipherSuites []uint16
+	// NextProtos are offered ALPN protocols “h2” “http/1.1”
+	NextProtos []string
+	// ServerName is SNI and verified host name
+	//	- empty: host of address
+	ServerName string
+	// RootCAs verify the chain
+	//	- nil: system roots
+	RootCAs *x509.CertPool
+	// InsecureSkipVerify does not fail on verification errors
+	//	- [TLSReport.VerifyError] holds any failure
+	InsecureSkipVerify bool
+	// Timeout is dial and handshake timeout
+	//	- 0: [DefaultTLSInspectTimeout]
+	Timeout time.Duration
+}
+
+// TLSReport is the outcome of a TLS handshake
+type TLSReport struct {
+	// Address is the inspected address, empty for [InspectTLSConn]
+	Address string
+	// ServerName is SNI and verified host name
+	ServerName string
+	// Version is negotiated TLS version [tls.VersionTLS13]
+	Version uint16
+	// CipherSuite is the negotiated cipher suite
+	CipherSuite uint16
+	// ALPN is the negotiated application protocol, empty if none
+	ALPN string
+	// PeerCertificates are certificates sent by the server, leaf first
+	PeerCertificates []*x509.Certificate
+	// VerifiedChains are chains from leaf to a trusted root
+	//	- empty if verification failed
+	VerifiedChains [][]*x509.Certificate
+	// VerifyError is chain or host-name verification failure
+	VerifyError error
+	// NotAfter is the earliest expiry of peer certificates
+	NotAfter time.Time
+	// OCSP is the status of any stapled OCSP response
+	OCSP OCSPStatus
+	// Handshake is handshake duration
+	Handshake time.Duration
+}
+
+// InspectTLS dials address and performs a TLS handshake returning a report
+//   - address: “host:port”
+//   - err: dial, handshake or verification failure.
+//     On verification failure, report is also returned
+//   - config: optional versions, ciphers, ALPN, roots and timeout
+//
+// Usage:
+//
+//	var report, err = pnet.InspectTLS(ctx, "example.com:443")
+//	if report != nil {
+//	  parl.Log("%s", report)
+//	}
+func InspectTLS(ctx context.Context, address string, config ...TLSInspectConfig) (report *TLSReport, err error) {
+	var c = tlsInspectConfig(config)
+	if c.ServerName == "" {
+		var host string
+		if host, _, err = net.SplitHostPort(address); perrors.IsPF(&err, "address %q: %w", address, err) {
+			return
+		}
+		c.ServerName = host
+	}
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	var conn net.Conn
+	if conn, err = dialer.DialContext(ctx, "tcp", address); perrors.IsPF(&err, "dial %q: %w", address, err) {
+		return
+	}
+	defer parl.Close(conn, &err)
+
+	if report, err = inspect(ctx, conn, &c); report != nil {
+		report.Address = address
+	}
+
+	return
+}
+
+// InspectTLSConn performs a client TLS handshake on an existing connection
+//   - conn: connected, typically TCP. conn is not closed
+//   - config: ServerName is required for verification
+//   - err: handshake or verification failure.
+//     On verification failure, report is also returned
+func InspectTLSConn(ctx context.Context, conn net.Conn, config ...TLSInspectConfig) (report *TLSReport, err error) {
+	if conn == nil {
+		panic(parl.NilError("conn"))
+	}
+	var c = tlsInspectConfig(config)
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	return inspect(ctx, conn, &c)
+}
+
+// inspect handshakes and verifies
+//   - the handshake does not verify so that an untrusted chain can be reported
+func inspect(ctx context.Context, conn net.Conn, c *TLSInspectConfig) (report *TLSReport, err error) {
+	var tlsConn = tls.Client(conn, &tls.Config{
+		ServerName:         c.ServerName,
+		MinVersion:         c.MinVersion,
+		MaxVersion:         c.MaxVersion,
+		CipherSuites:       c.CipherSuites,
+		NextProtos:         c.NextProtos,
+		InsecureSkipVerify: true,
+	})
+	var t0 = time.Now()
+	if err = tlsConn.HandshakeContext(ctx); perrors.IsPF(&err, "handshake %q: %w", c.ServerName, err) {
+		return
+	}
+	var state = tlsConn.ConnectionState()
+	report = &TLSReport{
+		ServerName:       c.ServerName,
+		Version:          state.Version,
+		CipherSuite:      state.CipherSuite,
+		ALPN:             state.NegotiatedProtocol,
+		PeerCertificates: state.PeerCertificates,
+		OCSP:             ParseOCSPStatus(state.OCSPResponse),
+		Handshake:        time.Since(t0),
+	}
+	for i, cert := range state.PeerCertificates {
+		if i == 0 || cert.NotAfter.Before(report.NotAfter) {
+			report.NotAfter = cert.NotAfter
+		}
+	}
+	report.VerifiedChains, report.VerifyError = verifyPeer(state.PeerCertificates, c)
+	if report.VerifyError != nil && !c.InsecureSkipVerify {
+		err = perrors.ErrorfPF("verify %q: %w", c.ServerName, report.VerifyError)
+	}
+
+	return
+}
+
+// verifyPeer verifies the chain and host name
+func verifyPeer(certs []*x509.Certificate, c *TLSInspectConfig) (chains [][]*x509.Certificate, err error) {
+	if len(certs) == 0 {
+		err = perrors.NewPF("no peer certificates")
+		return
+	}
+	var intermediates = x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	return certs[0].Verify(x509.VerifyOptions{
+		DNSName:       c.ServerName,
+		Roots:         c.RootCAs,
+		Intermediates: intermediates,
+	})
+}
+
+// tlsInspectConfig returns effective configuration
+func tlsInspectConfig(config []TLSInspectConfig) (c TLSInspectConfig) {
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTLSInspectTimeout
+	}
+	return
+}
+
+// ExpiresWithin returns true if a peer certificate expires within d
+//   - for renewal checks
+func (r *TLSReport) ExpiresWithin(d time.Duration) (expires bool) {
+	return time.Until(r.NotAfter) < d
+}
+
+// String is a multi-line report
+//
+//	example.com:443 TLS 1.3 TLS_AES_128_GCM_SHA256 alpn: h2 handshake: 12ms ocsp: good
+//	verified: true expires: 2025-01-02 15:04:05Z
+//	0 CN=example.com expires 2025-01-02 issuer CN=R3
+func (r *TLSReport) String() (s string) {
+	var alpn = r.ALPN
+	if alpn == "" {
+		alpn = "none"
+	}
+	var name = r.Address
+	if name == "" {
+		name = r.ServerName
+	}
+	var verified = "true"
+	if r.VerifyError != nil {
+		verified = "false: " + perrors.Short(r.VerifyError)
+	}
+	var sL = []string{
+		parl.Sprintf("%s %s %s alpn: %s handshake: %s ocsp: %s",
+			name, tls.VersionName(r.Version), tls.CipherSuiteName(r.CipherSuite),
+			alpn, r.Handshake.Round(time.Millisecond), r.OCSP,
+		),
+		parl.Sprintf("verified: %s expires: %s", verified, r.NotAfter.UTC().Format(parl.Rfc3339s)),
+	}
+	for i, cert := range r.PeerCertificates {
+		sL = append(sL, parl.Sprintf("%d %s expires %s issuer %s",
+			i, cert.Subject, cert.NotAfter.UTC().Format(time.DateOnly), cert.Issuer,
+		))
+	}
+	return strings.Join(sL, "\n")
+}
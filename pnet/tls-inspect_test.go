@@ -0,0 +1,104 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pnet
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInspectTLS(t *testing.T) {
+	//t.Error("Logging on")
+	var server = httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+	var address = server.Listener.Addr().String()
+	var roots = x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	// trusted
+	var report, err = InspectTLS(context.Background(), address, TLSInspectConfig{
+		RootCAs:    roots,
+		MaxVersion: tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatalf("InspectTLS err %s", err)
+	}
+	if report.Version != tls.VersionTLS12 || len(report.VerifiedChains) == 0 || report.VerifyError != nil {
+		t.Errorf("report %s", report)
+	}
+	if !report.NotAfter.Equal(server.Certificate().NotAfter) || report.OCSP != OCSPNone {
+		t.Errorf("NotAfter %s OCSP %s", report.NotAfter, report.OCSP)
+	}
+	if report.ExpiresWithin(time.Hour) {
+		t.Error("ExpiresWithin true")
+	}
+	if s := report.String(); !strings.Contains(s, "verified: true") {
+		t.Errorf("String %q", s)
+	}
+
+	// untrusted
+	if report, err = InspectTLS(context.Background(), address); err == nil {
+		t.Error("untrusted no error")
+	} else if report == nil || report.VerifyError == nil || len(report.PeerCertificates) == 0 {
+		t.Errorf("untrusted report %v", report)
+	}
+	if _, err = InspectTLS(context.Background(), address, TLSInspectConfig{InsecureSkipVerify: true}); err != nil {
+		t.Errorf("InsecureSkipVerify err %s", err)
+	}
+}
+
+func TestParseOCSPStatus(t *testing.T) {
+	//t.Error("Logging on")
+	var now = time.Now().UTC().Truncate(time.Second)
+
+	if s := ParseOCSPStatus(nil); s != OCSPNone {
+		t.Errorf("nil %s", s)
+	}
+	if s := ParseOCSPStatus([]byte{1, 2, 3}); s != OCSPMalformed {
+		t.Errorf("garbage %s", s)
+	}
+	for _, single := range []ocspSingleResponse{
+		{CertID: asn1.RawValue{FullBytes: []byte{0x30, 0}}, Good: true, ThisUpdate: now},
+		{CertID: asn1.RawValue{FullBytes: []byte{0x30, 0}}, Revoked: ocspRevokedInfo{RevocationTime: now}, ThisUpdate: now},
+	} {
+		var exp = OCSPGood
+		if single.Revoked.RevocationTime.Equal(now) {
+			exp = OCSPRevoked
+		}
+		var basic, err = asn1.Marshal(ocspBasicResponse{
+			TBSResponseData: ocspResponseData{
+				RawResponderID: asn1.RawValue{FullBytes: []byte{0xa2, 0x02, 0x04, 0}},
+				ProducedAt:     now,
+				Responses:      []ocspSingleResponse{single},
+			},
+			SignatureAlgorithm: ocspSignatureAlgorithm(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var der []byte
+		if der, err = asn1.Marshal(ocspResponse{Response: ocspResponseBytes{ResponseType: ocspBasicOID, Response: basic}}); err != nil {
+			t.Fatal(err)
+		}
+		if s := ParseOCSPStatus(der); s != exp {
+			t.Errorf("status %s exp %s", s, exp)
+		}
+	}
+}
+
+// ocspSignatureAlgorithm is sha256WithRSAEncryption
+func ocspSignatureAlgorithm() (alg pkix.AlgorithmIdentifier) {
+	alg.Algorithm = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	return
+}
@@ -0,0 +1,357 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/ptime"
+)
+
+const (
+	// DefaultPoolMaxIdle is max idle values when [PoolConfig.MaxIdle] is 0
+	DefaultPoolMaxIdle = 16
+	// NoPoolMaxIdle: idle values are not limited in number
+	NoPoolMaxIdle = -1
+	// minReapInterval is the shortest reaper period
+	minReapInterval = time.Millisecond
+)
+
+// ErrPoolClosed is returned by [Pool.Get] after [Pool.Close]
+var ErrPoolClosed = errors.New("pool closed")
+
+// PoolConfig configures [Pool]
+type PoolConfig[T comparable] struct {
+	// New creates a value, required
+	New func(ctx context.Context) (value T, err error)
+	// Reset is optional function preparing a returned value for reuse
+	Reset func(value T)
+	// Validate is optional function returning false for
+	// a value that should not be reused
+	//	- invoked by Put after Reset
+	Validate func(value T) (isValid bool)
+	// Discard is optional function releasing resources of
+	// a value leaving the pool
+	Discard func(value T)
+	// MaxIdle is the max number of idle values retained
+	//	- 0: [DefaultPoolMaxIdle]
+	//	- [NoPoolMaxIdle]: unlimited
+	MaxIdle int
+	// MaxIdleTime is how long a value may be idle before being reaped
+	//	- 0: no limit
+	MaxIdleTime time.Duration
+	// MaxAge is how long after creation a value is discarded
+	//	- 0: no limit
+	MaxAge time.Duration
+	// ReapInterval is the period of the idle reaper
+	//	- 0: half of the shortest of MaxIdleTime and MaxAge
+	ReapInterval time.Duration
+	// Clock is optional clock, default [ptime.SystemClock]
+	Clock ptime.Clock
+}
+
+// PoolStats is a snapshot of [Pool] statistics
+type PoolStats struct {
+	// Created is number of values created by New
+	Created uint64
+	// Reused is number of Get served by an idle value
+	Reused uint64
+	// Invalid is number of values failing Validate
+	Invalid uint64
+	// Expired is number of values discarded due to MaxAge or MaxIdleTime
+	Expired uint64
+	// Overflow is number of values discarded due to MaxIdle
+	Overflow uint64
+	// Idle is current number of idle values
+	Idle int
+	// InUse is current number of values obtained by Get and not yet Put
+	InUse int
+}
+
+// Pool is a generic object pool with lifetime validation
+//   - values are created by [PoolConfig.New] when no idle value is available
+//   - a returned value is reset, validated and retained up to MaxIdle
+//   - values older than MaxAge or idle longer than MaxIdleTime are discarded by
+//     a reaper thread launched from goGen
+//   - most recently returned values are reused first
+//   - T must be comparable for Pool to track value ages:
+//     buffers are pooled as pointers
+//   - thread-safe
+//
+// Usage:
+//
+//	var pool = parl.NewPool(goGroup.SubGo(), parl.PoolConfig[*bytes.Buffer]{
+//	  New:         func(ctx context.Context) (b *bytes.Buffer, err error) { return new(bytes.Buffer), nil },
+//	  Reset:       func(b *bytes.Buffer) { b.Reset() },
+//	  Validate:    func(b *bytes.Buffer) (isValid bool) { return b.Cap() <= 1<<20 },
+//	  MaxIdleTime: time.Minute,
+//	})
+//	defer pool.Close()
+//	var b, err = pool.Get(ctx)
+//	…
+//	defer pool.Put(b)
+type Pool[T comparable] struct {
+	newFn       func(ctx context.Context) (value T, err error)
+	reset       func(value T)
+	validate    func(value T) (isValid bool)
+	discard     func(value T)
+	maxIdle     int
+	maxIdleTime time.Duration
+	maxAge      time.Duration
+	clock       ptime.Clock
+	// cancel ends the reaper thread
+	cancel context.CancelFunc
+	// lock makes fields below thread-safe
+	lock sync.Mutex
+	// idle values, most recently returned last
+	idle []poolValue[T]
+	// inUse holds creation time of values obtained by Get
+	inUse    map[T]time.Time
+	isClosed bool
+	stats    PoolStats
+}
+
+// poolValue is an idle value
+type poolValue[T comparable] struct {
+	value T
+	// created is when New returned value
+	created time.Time
+	// returned is when value was Put
+	returned time.Time
+}
+
+// NewPool returns a generic object pool
+//   - goGen: the reaper thread is launched from goGen.
+//     If nil or without MaxIdleTime and MaxAge, there is no reaper thread
+//     and expired values are discarded by Get
+//   - config.New is required
+func NewPool[T comparable](goGen GoGen, config PoolConfig[T]) (pool *Pool[T]) {
+	if config.New == nil {
+		panic(NilError("config.New"))
+	}
+	var maxIdle = config.MaxIdle
+	if maxIdle == 0 {
+		maxIdle = DefaultPoolMaxIdle
+	}
+	pool = &Pool[T]{
+		newFn:       config.New,
+		reset:       config.Reset,
+		validate:    config.Validate,
+		discard:     config.Discard,
+		maxIdle:     maxIdle,
+		maxIdleTime: config.MaxIdleTime,
+		maxAge:      config.MaxAge,
+		clock:       ptime.GetClock(config.Clock),
+		inUse:       make(map[T]time.Time),
+	}
+	if goGen == nil || (pool.maxIdleTime <= 0 && pool.maxAge <= 0) {
+		return // no reaper return
+	}
+
+	// launch reaper
+	var interval = config.ReapInterval
+	if interval <= 0 {
+		interval = pool.maxIdleTime
+		if pool.maxAge > 0 && (interval <= 0 || pool.maxAge < interval) {
+			interval = pool.maxAge
+		}
+		interval = max(interval/2, minReapInterval)
+	}
+	var subGo = goGen.SubGo()
+	pool.cancel = subGo.Cancel
+	go pool.reaperThread(interval, subGo.Go())
+
+	return
+}
+
+// Get returns an idle value or a value created by New
+//   - err: New failure or [ErrPoolClosed]
+func (p *Pool[T]) Get(ctx context.Context) (value T, err error) {
+	var expired []T
+	defer p.discardValues(&expired)
+
+	p.lock.Lock()
+	if p.isClosed {
+		p.lock.Unlock()
+		err = perrors.ErrorfPF("%w", ErrPoolClosed)
+		return // closed return
+	}
+	var now = p.clock.Now()
+	for len(p.idle) > 0 {
+		var v = p.idle[len(p.idle)-1]
+		p.idle[len(p.idle)-1] = poolValue[T]{}
+		p.idle = p.idle[:len(p.idle)-1]
+		if p.isExpired(&v, now) {
+			p.stats.Expired++
+			expired = append(expired, v.value)
+			continue
+		}
+		p.inUse[v.value] = v.created
+		p.stats.Reused++
+		p.lock.Unlock()
+		value = v.value
+		return // idle value return
+	}
+	p.lock.Unlock()
+
+	// create value outside of lock
+	if value, err = p.newFn(ctx); err != nil {
+		err = perrors.ErrorfPF("pool New: %w", err)
+		return // New failure return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.inUse[value] = p.clock.Now()
+	p.stats.Created++
+
+	return // created value return
+}
+
+// Put returns a value obtained by Get to the pool
+//   - the value is reset, validated and retained or discarded
+//   - a value not obtained from this pool is treated as newly created
+func (p *Pool[T]) Put(value T) {
+	if p.reset != nil {
+		p.reset(value)
+	}
+	var isValid = p.validate == nil || p.validate(value)
+
+	p.lock.Lock()
+	var now = p.clock.Now()
+	var created, ok = p.inUse[value]
+	if ok {
+		delete(p.inUse, value)
+	} else {
+		created = now
+	}
+	var v = poolValue[T]{value: value, created: created, returned: now}
+	var doDiscard = true
+	switch {
+	case p.isClosed:
+	case !isValid:
+		p.stats.Invalid++
+	case p.isExpired(&v, now):
+		p.stats.Expired++
+	case p.maxIdle != NoPoolMaxIdle && len(p.idle) >= p.maxIdle:
+		p.stats.Overflow++
+	default:
+		p.idle = append(p.idle, v)
+		doDiscard = false
+	}
+	p.lock.Unlock()
+
+	if doDiscard && p.discard != nil {
+		p.discard(value)
+	}
+}
+
+// Stats returns a snapshot of pool statistics
+func (p *Pool[T]) Stats() (stats PoolStats) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	stats = p.stats
+	stats.Idle = len(p.idle)
+	stats.InUse = len(p.inUse)
+
+	return
+}
+
+// Close discards idle values and ends the reaper thread
+//   - values subsequently Put are discarded
+//   - Get returns [ErrPoolClosed]
+//   - idempotent
+func (p *Pool[T]) Close() {
+	var values []T
+	defer p.discardValues(&values)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.isClosed {
+		return // already closed return
+	}
+	p.isClosed = true
+	for _, v := range p.idle {
+		values = append(values, v.value)
+	}
+	p.idle = nil
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+// reaperThread periodically discards expired idle values
+func (p *Pool[T]) reaperThread(interval time.Duration, g Go) {
+	var err error
+	defer g.Register("Pool-reaper").Done(&err)
+	defer PanicToErr(&err)
+
+	var ticker = p.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	var done = g.Context().Done()
+	for {
+		select {
+		case <-done:
+			return // canceled return
+		case <-ticker.C():
+		}
+		p.reap()
+	}
+}
+
+// reap discards expired idle values
+func (p *Pool[T]) reap() {
+	var expired []T
+	defer p.discardValues(&expired)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var now = p.clock.Now()
+	var retained = p.idle[:0]
+	for _, v := range p.idle {
+		if p.isExpired(&v, now) {
+			p.stats.Expired++
+			expired = append(expired, v.value)
+			continue
+		}
+		retained = append(retained, v)
+	}
+	clear(p.idle[len(retained):])
+	p.idle = retained
+}
+
+// isExpired returns true if v exceeded MaxAge or MaxIdleTime
+//   - behind lock
+func (p *Pool[T]) isExpired(v *poolValue[T], now time.Time) (isExpired bool) {
+	return p.maxAge > 0 && now.Sub(v.created) >= p.maxAge ||
+		p.maxIdleTime > 0 && now.Sub(v.returned) >= p.maxIdleTime
+}
+
+// discardValues invokes Discard outside of lock
+func (p *Pool[T]) discardValues(values *[]T) {
+	if p.discard == nil {
+		return
+	}
+	for _, value := range *values {
+		p.discard(value)
+	}
+}
+
+// “created 3 reused 5 invalid 0 expired 1 overflow 0 idle 2 in-use 1”
+func (s PoolStats) String() (s2 string) {
+	return fmt.Sprintf("created %d reused %d invalid %d expired %d overflow %d idle %d in-use %d",
+		s.Created, s.Reused, s.Invalid, s.Expired, s.Overflow, s.Idle, s.InUse,
+	)
+}
@@ -0,0 +1,84 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl/ptime"
+)
+
+func TestPool(t *testing.T) {
+	//t.Error("Logging on")
+	var ctx = context.Background()
+	var clock = ptime.NewTestClock()
+	var resets, discards int
+	var pool = NewPool(nil, PoolConfig[*int]{
+		New:      func(ctx context.Context) (value *int, err error) { return new(int), nil },
+		Reset:    func(value *int) { resets++ },
+		Validate: func(value *int) (isValid bool) { return *value >= 0 },
+		Discard:  func(value *int) { discards++ },
+		MaxIdle:  1,
+		MaxAge:   time.Minute,
+		Clock:    clock,
+	})
+
+	// Get creates, Put retains, Get reuses
+	var a, err = pool.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get err %s", err)
+	}
+	pool.Put(a)
+	var b, _ = pool.Get(ctx)
+	if b != a {
+		t.Error("value not reused")
+	}
+	if stats := pool.Stats(); stats.Created != 1 || stats.Reused != 1 || stats.InUse != 1 {
+		t.Errorf("stats %s", stats)
+	}
+
+	// MaxIdle overflow
+	var c, _ = pool.Get(ctx)
+	pool.Put(b)
+	pool.Put(c)
+	if stats := pool.Stats(); stats.Overflow != 1 || stats.Idle != 1 || discards != 1 {
+		t.Errorf("overflow stats %s discards %d", stats, discards)
+	}
+	if resets != 3 {
+		t.Errorf("resets %d exp 3", resets)
+	}
+
+	// invalid value
+	b, _ = pool.Get(ctx)
+	*b = -1
+	pool.Put(b)
+	if stats := pool.Stats(); stats.Invalid != 1 || stats.Idle != 0 || discards != 2 {
+		t.Errorf("invalid stats %s discards %d", stats, discards)
+	}
+
+	// MaxAge expiry by reaper
+	b, _ = pool.Get(ctx)
+	pool.Put(b)
+	clock.Advance(time.Minute)
+	pool.reap()
+	if stats := pool.Stats(); stats.Expired != 1 || stats.Idle != 0 || discards != 3 {
+		t.Errorf("expired stats %s discards %d", stats, discards)
+	}
+
+	// Close
+	b, _ = pool.Get(ctx)
+	pool.Put(b)
+	pool.Close()
+	if discards != 4 {
+		t.Errorf("Close discards %d exp 4", discards)
+	}
+	if _, err = pool.Get(ctx); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Get after Close err %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pos
+
+import (
+	"syscall"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// prctl options from linux/prctl.h
+	prSetNoNewPrivs = 38
+	prGetNoNewPrivs = 39
+)
+
+// NoNewPrivs returns whether the process is unable to gain
+// privileges via execve of setuid binaries or file capabilities
+//   - Linux: prctl PR_GET_NO_NEW_PRIVS
+func NoNewPrivs() (isSet bool, err error) {
+	var result, _, errno = syscall.RawSyscall(syscall.SYS_PRCTL, prGetNoNewPrivs, 0, 0)
+	if errno != 0 {
+		err = perrors.ErrorfPF("prctl PR_GET_NO_NEW_PRIVS: %w", errno)
+		return
+	}
+	isSet = result == 1
+	return
+}
+
+// SetNoNewPrivs prevents the process and its children from
+// gaining privileges. Irreversible
+//   - Linux: prctl PR_SET_NO_NEW_PRIVS
+func SetNoNewPrivs() (err error) {
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		err = perrors.ErrorfPF("prctl PR_SET_NO_NEW_PRIVS: %w", errno)
+	}
+	return
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pos
+
+import (
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// NoNewPrivs: no-new-privileges is Linux only
+func NoNewPrivs() (isSet bool, err error) {
+	err = perrors.NewPF("no-new-privileges not supported on this platform")
+	return
+}
+
+// SetNoNewPrivs: no-new-privileges is Linux only
+func SetNoNewPrivs() (err error) {
+	err = perrors.NewPF("no-new-privileges not supported on this platform")
+	return
+}
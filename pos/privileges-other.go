@@ -0,0 +1,21 @@
+//go:build !darwin && !freebsd && !linux && !netbsd && !openbsd
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pos
+
+import (
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// setIdentity: changing identity is not supported on this platform
+func setIdentity(uid, gid int, groups []int) (err error) {
+	err = perrors.NewPF("changing user and group not supported on this platform")
+	return
+}
+
+// regainRoot: not supported on this platform
+func regainRoot() (didRegain bool) { return }
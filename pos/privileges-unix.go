@@ -0,0 +1,32 @@
+//go:build darwin || freebsd || linux || netbsd || openbsd
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pos
+
+import (
+	"syscall"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// setIdentity sets supplementary groups, group ID and user ID
+//   - on Linux, the change applies to all threads of the process
+func setIdentity(uid, gid int, groups []int) (err error) {
+	if err = syscall.Setgroups(groups); perrors.IsPF(&err, "setgroups %v: %w", groups, err) {
+		return
+	}
+	if err = syscall.Setgid(gid); perrors.IsPF(&err, "setgid %d: %w", gid, err) {
+		return
+	}
+	if err = syscall.Setuid(uid); perrors.IsPF(&err, "setuid %d: %w", uid, err) {
+		return
+	}
+	return
+}
+
+// regainRoot returns true if the process was able to become root
+func regainRoot() (didRegain bool) { return syscall.Setuid(rootUID) == nil }
@@ -0,0 +1,158 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pos
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// rootUID is the user ID of the superuser
+	rootUID = 0
+)
+
+// Identity is the user and group identity of the process
+type Identity struct {
+	// UID and GID are real user and group ID
+	UID, GID int
+	// EUID and EGID are effective user and group ID
+	EUID, EGID int
+	// Groups are supplementary group IDs
+	Groups []int
+	// Username is the name of the effective user, empty if unknown
+	Username string
+	// NoNewPrivs is true if the process cannot gain privileges by execve
+	//	- Linux only, false on other platforms
+	NoNewPrivs bool
+}
+
+// EffectiveIdentity returns user, groups and privilege state of the process
+//   - on Windows, user and group IDs are -1
+func EffectiveIdentity() (identity *Identity, err error) {
+	var i = Identity{
+		UID:  os.Getuid(),
+		GID:  os.Getgid(),
+		EUID: os.Geteuid(),
+		EGID: os.Getegid(),
+	}
+	if i.Groups, err = SupplementaryGroups(); err != nil {
+		return
+	}
+	if i.EUID != -1 {
+		if u, e := user.LookupId(strconv.Itoa(i.EUID)); e == nil {
+			i.Username = u.Username
+		}
+	}
+	i.NoNewPrivs, _ = NoNewPrivs()
+	identity = &i
+
+	return
+}
+
+// IsRoot returns true if the effective user is the superuser
+func IsRoot() (isRoot bool) { return os.Geteuid() == rootUID }
+
+// SupplementaryGroups returns the supplementary group IDs of the process
+func SupplementaryGroups() (groups []int, err error) {
+	if groups, err = os.Getgroups(); perrors.IsPF(&err, "os.Getgroups: %w", err) {
+		return
+	}
+	return
+}
+
+// DropPrivileges permanently changes the identity of a process started as root
+//   - username: user name or numeric user ID
+//   - group: group name or numeric group ID.
+//     empty: the user’s primary group
+//   - supplementary groups are set to those of the user
+//   - used by services after binding to privileged ports
+//   - on return, the process is verified to be unable to regain root
+//   - err: not root, unknown user or group or platform not supported.
+//     On error, the process identity may be partially changed and
+//     the process should exit
+func DropPrivileges(username string, group ...string) (err error) {
+	if !IsRoot() {
+		err = perrors.ErrorfPF("process must be root: euid %d", os.Geteuid())
+		return
+	}
+
+	// resolve user and groups
+	var u *user.User
+	if u, err = lookupUser(username); err != nil {
+		return
+	}
+	var uid, gid int
+	if uid, err = strconv.Atoi(u.Uid); perrors.IsPF(&err, "user %q uid %q: %w", username, u.Uid, err) {
+		return
+	}
+	var groupName string
+	if len(group) > 0 {
+		groupName = group[0]
+	}
+	if groupName == "" {
+		groupName = u.Gid
+	}
+	if gid, err = lookupGroupID(groupName); err != nil {
+		return
+	}
+	var groupIDs []string
+	if groupIDs, err = u.GroupIds(); perrors.IsPF(&err, "user %q GroupIds: %w", username, err) {
+		return
+	}
+	var groups = make([]int, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		var g int
+		if g, err = strconv.Atoi(groupID); perrors.IsPF(&err, "user %q group %q: %w", username, groupID, err) {
+			return
+		}
+		groups = append(groups, g)
+	}
+
+	// groups must be changed before uid
+	if err = setIdentity(uid, gid, groups); err != nil {
+		return
+	}
+
+	// verify that privileges were dropped
+	if uid != rootUID && regainRoot() {
+		err = perrors.ErrorfPF("privileges could be regained after dropping to user %q", username)
+	}
+
+	return
+}
+
+// lookupUser looks up a user by name or numeric ID
+func lookupUser(username string) (u *user.User, err error) {
+	if _, e := strconv.Atoi(username); e == nil {
+		if u, err = user.LookupId(username); perrors.IsPF(&err, "user.LookupId %q: %w", username, err) {
+			return
+		}
+		return
+	}
+	if u, err = user.Lookup(username); perrors.IsPF(&err, "user.Lookup %q: %w", username, err) {
+		return
+	}
+	return
+}
+
+// lookupGroupID returns the ID of a group by name or numeric ID
+func lookupGroupID(group string) (gid int, err error) {
+	if gid, err = strconv.Atoi(group); err == nil {
+		return // numeric group ID return
+	}
+	var g *user.Group
+	if g, err = user.LookupGroup(group); perrors.IsPF(&err, "user.LookupGroup %q: %w", group, err) {
+		return
+	}
+	if gid, err = strconv.Atoi(g.Gid); perrors.IsPF(&err, "group %q gid %q: %w", group, g.Gid, err) {
+		return
+	}
+	return
+}
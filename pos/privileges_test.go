@@ -0,0 +1,40 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pos
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestEffectiveIdentity(t *testing.T) {
+	//t.Error("Logging on")
+
+	var identity, err = EffectiveIdentity()
+	if err != nil {
+		t.Fatalf("EffectiveIdentity err: %s", err)
+	}
+	if identity.EUID != os.Geteuid() || identity.EGID != os.Getegid() {
+		t.Errorf("EUID %d EGID %d exp %d %d", identity.EUID, identity.EGID, os.Geteuid(), os.Getegid())
+	}
+	if runtime.GOOS == "linux" {
+		if _, err = NoNewPrivs(); err != nil {
+			t.Errorf("NoNewPrivs err: %s", err)
+		}
+	}
+}
+
+func TestDropPrivileges(t *testing.T) {
+	if IsRoot() {
+		t.Skip("dropping privileges of the test process as root is irreversible")
+	}
+
+	// non-root process cannot drop privileges
+	if err := DropPrivileges("nobody"); err == nil {
+		t.Error("DropPrivileges missing error")
+	}
+}
@@ -0,0 +1,109 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pruntime
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+const (
+	// counts [runtime.Callers] [callerPC] and the public function
+	callerPCFrames = 3
+)
+
+// symbols is the per-PC symbolization cache
+var symbols symbolCache
+
+// symbolCache caches code locations by program counter
+//   - the number of program counters is bounded by code size so
+//     the cache is not evicted
+//   - thread-safe
+type symbolCache struct {
+	// lock makes m thread-safe
+	lock sync.RWMutex
+	// m is symbols by program counter, behind lock
+	m map[uintptr]*symbol
+}
+
+// symbol is an interned code location
+type symbol struct {
+	CodeLocation
+	// packFunc is [CodeLocation.PackFunc] “mains.AddErr”
+	packFunc string
+}
+
+// CodeLocationFast returns the interned code location of a stack frame
+//   - for stackFramesToSkip 0, CodeLocationFast returns the location of
+//     its immediate caller, like [NewCodeLocation]
+//   - for hot paths like error and thread creation:
+//     symbolization takes place once per code location,
+//     subsequent invocations are allocation-free
+//   - the returned value is shared and must not be modified
+//   - thread-safe
+func CodeLocationFast(stackFramesToSkip int) (cl *CodeLocation) {
+	return &symbols.lookup(callerPC(stackFramesToSkip)).CodeLocation
+}
+
+// PackFuncFast returns base package name and function
+// “mains.AddErr”
+//   - like NewCodeLocation(stackFramesToSkip).PackFunc() but
+//     allocation-free once cached
+//   - thread-safe
+func PackFuncFast(stackFramesToSkip int) (packageDotFunction string) {
+	return symbols.lookup(callerPC(stackFramesToSkip)).packFunc
+}
+
+// SymbolCacheLen returns the number of cached code locations
+func SymbolCacheLen() (length int) {
+	symbols.lock.RLock()
+	defer symbols.lock.RUnlock()
+
+	return len(symbols.m)
+}
+
+// callerPC returns the program counter of the invoker of the
+// public function invoking callerPC
+func callerPC(stackFramesToSkip int) (pc uintptr) {
+	if stackFramesToSkip < 0 {
+		stackFramesToSkip = 0
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(callerPCFrames+stackFramesToSkip, pcs[:]) == 0 {
+		panic(errors.New("runtime.Callers failed"))
+	}
+	return pcs[0]
+}
+
+// lookup returns the symbol for pc, symbolizing on cache miss
+func (c *symbolCache) lookup(pc uintptr) (s *symbol) {
+	c.lock.RLock()
+	s = c.m[pc]
+	c.lock.RUnlock()
+	if s != nil {
+		return // cache hit return
+	}
+
+	// symbolize outside lock like [runtime.Caller]
+	var frame, _ = runtime.CallersFrames([]uintptr{pc}).Next()
+	s = &symbol{CodeLocation: CodeLocation{File: frame.File, Line: frame.Line, FuncName: frame.Function}}
+	if s.FuncName != "" {
+		s.packFunc = s.CodeLocation.PackFunc()
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if s2 := c.m[pc]; s2 != nil {
+		return s2 // another thread cached pc return
+	} else if c.m == nil {
+		c.m = make(map[uintptr]*symbol)
+	}
+	c.m[pc] = s
+
+	return
+}
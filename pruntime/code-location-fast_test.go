@@ -0,0 +1,51 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pruntime
+
+import (
+	"testing"
+)
+
+func TestCodeLocationFast(t *testing.T) {
+	//t.Error("Logging on")
+	var cl, exp, clFast = codeLocationFastLocations()
+
+	// CodeLocationFast should match NewCodeLocation
+	if *clFast != *exp {
+		t.Errorf("CodeLocationFast %+v exp %+v", *clFast, *exp)
+	}
+	// second invocation should return interned value
+	if cl != clFast {
+		t.Error("CodeLocationFast not interned")
+	}
+	if packFunc, expPackFunc := codeLocationFastPackFunc(); packFunc != expPackFunc {
+		t.Errorf("PackFuncFast %q exp %q", packFunc, expPackFunc)
+	}
+	if SymbolCacheLen() == 0 {
+		t.Error("SymbolCacheLen 0")
+	}
+
+	// cached invocations should not allocate
+	if allocs := testing.AllocsPerRun(100, func() { PackFuncFast(0) }); allocs != 0 {
+		t.Errorf("PackFuncFast allocs %.0f exp 0", allocs)
+	}
+}
+
+// codeLocationFastLocations returns the same location twice from
+// CodeLocationFast and once from NewCodeLocation
+func codeLocationFastLocations() (cl, exp, clFast *CodeLocation) {
+	for i := 0; i < 2; i++ {
+		cl, clFast = clFast, CodeLocationFast(0)
+		exp = NewCodeLocation(0)
+		exp.Line--
+	}
+	return
+}
+
+// codeLocationFastPackFunc returns PackFuncFast and the expected value
+func codeLocationFastPackFunc() (packFunc, exp string) {
+	return PackFuncFast(0), NewCodeLocation(0).PackFunc()
+}
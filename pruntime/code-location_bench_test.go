@@ -27,3 +27,21 @@ func BenchmarkPackFunc(b *testing.B) {
 		NewCodeLocation(0).PackFunc()
 	}
 }
+
+// CodeLocationFast is allocation-free once cached
+//   - 263.7 ns/op 0 B/op 0 allocs/op on linux amd64
+func BenchmarkCodeLocationFast(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		CodeLocationFast(0)
+	}
+}
+
+// PackFuncFast is allocation-free once cached,
+// compare [BenchmarkPackFunc]
+//   - 341.0 ns/op 0 B/op 0 allocs/op on linux amd64,
+//     PackFunc: 833.0 ns/op 296 B/op 3 allocs/op
+func BenchmarkPackFuncFast(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		PackFuncFast(0)
+	}
+}
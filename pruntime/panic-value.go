@@ -0,0 +1,234 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pruntime
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+const (
+	// PanicRuntimeError is a [runtime.Error] like nil pointer dereference
+	PanicRuntimeError PanicKind = iota + 1
+	// PanicErrorWithStack is an error value with an attached stack trace
+	PanicErrorWithStack
+	// PanicError is an error value without stack trace
+	PanicError
+	// PanicStringer is a non-error value implementing [fmt.Stringer]
+	PanicStringer
+	// PanicString is a string value
+	PanicString
+	// PanicOther is any other value
+	PanicOther
+)
+
+const (
+	// function name of the Go runtime’s panic implementation
+	runtimeGopanic = "runtime.gopanic"
+	// prefix of Go runtime function names
+	runtimeFuncPrefix = "runtime."
+	// max frames examined for panic site
+	panicMaxFrames = 64
+)
+
+// PanicKind classifies a recovered panic value
+//   - [PanicRuntimeError] [PanicErrorWithStack] [PanicError]
+//     [PanicStringer] [PanicString] [PanicOther]
+type PanicKind uint8
+
+// PanicValue is introspection of a value returned by recover()
+//   - [NewPanicValue] classifies the value and finds code locations
+//   - [PanicValue.Short] is a single-line description
+//   - [PanicValue.Long] is a multi-line description
+type PanicValue struct {
+	// Value is the value returned by recover()
+	Value any
+	// Kind classifies Value
+	Kind PanicKind
+	// Err is Value if it implements error
+	Err error
+	// Error0 is the innermost error of Err’s chain
+	Error0 error
+	// PanicSite is the function that invoked panic or
+	// caused a runtime error
+	//	- zero-value if not found, ie. not invoked during a panic
+	PanicSite CodeLocation
+	// RecoverySite is the deferred function that invoked recover
+	//	- zero-value if not found
+	RecoverySite CodeLocation
+	// ErrorStack is the innermost stack trace attached to Err
+	//	- nil if none
+	ErrorStack Stack
+}
+
+// errorStacker is an error with attached stack trace like
+// those of [github.com/haraldrudell/parl/perrors]
+type errorStacker interface {
+	StackTrace() (stack Stack)
+}
+
+// NewPanicValue returns introspection of recoverValue
+//   - recoverValue: value returned by recover(), may be nil
+//   - NewPanicValue must be invoked from a deferred function
+//     while the panic is being recovered for
+//     PanicSite and RecoverySite to be found
+//   - panicValue nil: recoverValue was nil, ie. no panic
+//
+// Usage:
+//
+//	defer func() {
+//	  if panicValue := pruntime.NewPanicValue(recover()); panicValue != nil {
+//	    log.Print(panicValue.Long())
+//	  }
+//	}()
+func NewPanicValue(recoverValue any) (panicValue *PanicValue) {
+	if recoverValue == nil {
+		return // not a panic return
+	}
+	var p = PanicValue{Value: recoverValue}
+	p.classify()
+	p.findSites()
+
+	return &p
+}
+
+// Short returns a single-line description
+//   - “panic: runtime error: index out of range [3] with length 1
+//     at mypackage.MyFunc-myfile.go:19 recovered in mypackage.main.func1-main.go:12”
+func (p *PanicValue) Short() (s string) {
+	s = "panic: " + p.valueString()
+	if p.PanicSite.IsSet() {
+		s += " at " + p.PanicSite.Short()
+	}
+	if p.RecoverySite.IsSet() {
+		s += " recovered in " + p.RecoverySite.Short()
+	}
+	return
+}
+
+// Long returns a multi-line description with types,
+// fully qualified code locations and any error stack trace
+func (p *PanicValue) Long() (s string) {
+	var sList = []string{
+		fmt.Sprintf("panic: %s kind: %s type: %T", p.valueString(), p.Kind, p.Value),
+	}
+	if p.Error0 != nil && p.Error0 != p.Err {
+		sList = append(sList, fmt.Sprintf("innermost error: %T %q", p.Error0, p.Error0.Error()))
+	}
+	if p.PanicSite.IsSet() {
+		sList = append(sList, "panic site: "+p.PanicSite.Long())
+	}
+	if p.RecoverySite.IsSet() {
+		sList = append(sList, "recovery site: "+p.RecoverySite.Long())
+	}
+	if p.ErrorStack != nil {
+		sList = append(sList, "error stack:", p.ErrorStack.String())
+	}
+	return strings.Join(sList, "\n")
+}
+
+// "runtime-error" "error-with-stack" "error" "stringer" "string" "other"
+func (k PanicKind) String() (s string) {
+	switch k {
+	case PanicRuntimeError:
+		return "runtime-error"
+	case PanicErrorWithStack:
+		return "error-with-stack"
+	case PanicError:
+		return "error"
+	case PanicStringer:
+		return "stringer"
+	case PanicString:
+		return "string"
+	case PanicOther:
+		return "other"
+	}
+	return fmt.Sprintf("?panicKind%d", k)
+}
+
+// classify determines Kind Err Error0 and ErrorStack
+func (p *PanicValue) classify() {
+	switch v := p.Value.(type) {
+	case error:
+		p.Err = v
+		var runtimeError runtime.Error
+		for e := v; e != nil; e = unwrap(e) {
+			if s, ok := e.(errorStacker); ok {
+				p.ErrorStack = s.StackTrace()
+			}
+			if r, ok := e.(runtime.Error); ok && runtimeError == nil {
+				runtimeError = r
+			}
+			p.Error0 = e
+		}
+		if runtimeError != nil {
+			p.Kind = PanicRuntimeError
+		} else if p.ErrorStack != nil {
+			p.Kind = PanicErrorWithStack
+		} else {
+			p.Kind = PanicError
+		}
+	case fmt.Stringer:
+		p.Kind = PanicStringer
+	case string:
+		p.Kind = PanicString
+	default:
+		p.Kind = PanicOther
+	}
+}
+
+// findSites locates runtime.gopanic on the stack
+//   - the frame preceding gopanic is the deferred function invoking recover
+//   - the first non-runtime frame following gopanic is the panic site
+func (p *PanicValue) findSites() {
+	var pcs = make([]uintptr, panicMaxFrames)
+	var frames = runtime.CallersFrames(pcs[:runtime.Callers(0, pcs)])
+	var previous runtime.Frame
+	var isPanicFound bool
+	for {
+		var frame, more = frames.Next()
+		if !isPanicFound {
+			if frame.Function == runtimeGopanic {
+				isPanicFound = true
+				p.RecoverySite = codeLocationFromFrame(previous)
+			}
+			previous = frame
+		} else if !strings.HasPrefix(frame.Function, runtimeFuncPrefix) {
+			p.PanicSite = codeLocationFromFrame(frame)
+			return // panic site found return
+		}
+		if !more {
+			return // end of stack return
+		}
+	}
+}
+
+// valueString is the value as string
+func (p *PanicValue) valueString() (s string) {
+	switch p.Kind {
+	case PanicString:
+		return p.Value.(string)
+	case PanicRuntimeError, PanicErrorWithStack, PanicError:
+		return p.Err.Error()
+	case PanicStringer:
+		return p.Value.(fmt.Stringer).String()
+	}
+	return fmt.Sprintf("%v", p.Value)
+}
+
+// codeLocationFromFrame converts [runtime.Frame]
+func codeLocationFromFrame(frame runtime.Frame) (cl CodeLocation) {
+	return CodeLocation{File: frame.File, Line: frame.Line, FuncName: frame.Function}
+}
+
+// unwrap returns the next error in a single-error chain
+func unwrap(err error) (err2 error) {
+	if w, ok := err.(interface{ Unwrap() error }); ok {
+		err2 = w.Unwrap()
+	}
+	return
+}
@@ -0,0 +1,83 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pruntime
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewPanicValue(t *testing.T) {
+	//t.Error("Logging on")
+
+	// no panic
+	if p := NewPanicValue(nil); p != nil {
+		t.Errorf("NewPanicValue nil: %v", p)
+	}
+
+	var testList = []struct {
+		name string
+		fn   func()
+		kind PanicKind
+	}{
+		{"string", func() { panic("x") }, PanicString},
+		{"error", func() { panic(errors.New("x")) }, PanicError},
+		{"stack", func() { panic(&testStackError{}) }, PanicErrorWithStack},
+		{"runtime", testNilDereference, PanicRuntimeError},
+		{"stringer", func() { panic(testStringer{}) }, PanicStringer},
+		{"other", func() { panic(1) }, PanicOther},
+	}
+	for _, test := range testList {
+		var p = testRecover(test.fn)
+		if p == nil {
+			t.Fatalf("%s: nil", test.name)
+		}
+		if p.Kind != test.kind {
+			t.Errorf("%s: Kind %s exp %s", test.name, p.Kind, test.kind)
+		}
+		if p.RecoverySite.Name() != "testRecover.func1" {
+			t.Errorf("%s: RecoverySite %q", test.name, p.RecoverySite.FuncName)
+		}
+		if !strings.HasPrefix(p.PanicSite.FuncName, "github.com/haraldrudell/parl/pruntime.") ||
+			strings.Contains(p.PanicSite.FuncName, "testRecover") {
+			t.Errorf("%s: PanicSite %q", test.name, p.PanicSite.FuncName)
+		}
+		if s := p.Short(); !strings.Contains(s, " at ") || !strings.Contains(s, " recovered in ") {
+			t.Errorf("%s: Short %q", test.name, s)
+		}
+		if s := p.Long(); !strings.Contains(s, "panic site: ") {
+			t.Errorf("%s: Long %q", test.name, s)
+		}
+	}
+}
+
+// testRecover returns introspection of a panic in fn
+func testRecover(fn func()) (panicValue *PanicValue) {
+	defer func() {
+		panicValue = NewPanicValue(recover())
+	}()
+
+	fn()
+	return
+}
+
+// testNilDereference causes a runtime error
+func testNilDereference() {
+	var p *int
+	_ = *p
+}
+
+// testStackError is an error with stack
+type testStackError struct{}
+
+func (e *testStackError) Error() (s string)         { return "stack error" }
+func (e *testStackError) StackTrace() (stack Stack) { return NewStack(0) }
+
+// testStringer is a [fmt.Stringer]
+type testStringer struct{}
+
+func (s testStringer) String() (s2 string) { return "stringer" }
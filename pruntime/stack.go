@@ -31,6 +31,12 @@ Stack can determine where a goroutine was created and whether this is the main t
 	pruntime.NewStack(0).Creator.Short()  → main.main-pruntime.go:30
 	fmt.Println(pruntime.NewStack(0).IsMainThread)  → true
 	pruntime.NewStack(0).Frames[0].Args  → (0x104c12c60?)
+
+PanicValue classifies a recovered panic value and finds where the panic occurred
+
+	defer func() {
+	  if panicValue := pruntime.NewPanicValue(recover()); panicValue != nil {
+	    panicValue.Short()  → panic: x at main.f-main.go:9 recovered in main.main.func1-main.go:5
 */
 package pruntime
 
@@ -0,0 +1,254 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/iters"
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/pstrings"
+)
+
+const (
+	// SQLiteMaxVariables is SQLite3’s host-parameter limit prior to 3.32.0
+	//	- the default limit for [DBMap.BulkInsert]
+	SQLiteMaxVariables = 999
+	// DefaultBulkRowsPerTx is rows per transaction for [DBMap.BulkInsert]
+	DefaultBulkRowsPerTx = 10_000
+)
+
+const (
+	// BindQuestion is “?” placeholders: SQLite3 MySQL
+	BindQuestion BindStyle = iota
+	// BindDollar is “$1” placeholders: PostgreSQL
+	BindDollar
+)
+
+// BindStyle is the placeholder syntax of a database
+//   - [BindQuestion] [BindDollar]
+type BindStyle uint8
+
+// BulkInsertConfig is optional configuration for [DBMap.BulkInsert]
+type BulkInsertConfig struct {
+	// MaxVariables is the max number of placeholders per statement
+	//	- 0: [SQLiteMaxVariables]
+	MaxVariables int
+	// RowsPerTx is rows inserted per transaction
+	//	- 0: [DefaultBulkRowsPerTx]
+	RowsPerTx int
+	// Bind is placeholder syntax, default [BindQuestion]
+	Bind BindStyle
+	// Suffix is appended to each INSERT statement
+	//	- “ON CONFLICT DO NOTHING”
+	Suffix string
+	// Tx is isolation and retry configuration for each transaction
+	Tx *TxConfig
+	// Progress is optionally invoked after each transaction commits
+	Progress func(progress BulkProgress)
+}
+
+// BulkProgress is progress of [DBMap.BulkInsert]
+type BulkProgress struct {
+	// Rows is number of rows committed
+	Rows int64
+	// Statements is number of INSERT statements executed
+	Statements int
+	// Elapsed is time since BulkInsert began
+	Elapsed time.Duration
+}
+
+// BulkInsert inserts rows into table using multi-valued INSERT statements
+//   - columns: column names, each row has one value per column
+//   - rows: iterator of rows. Cancel is invoked by BulkInsert
+//   - each statement has as many rows as fit MaxVariables placeholders
+//   - statements are grouped in transactions of RowsPerTx rows.
+//     A transaction failing with busy errors is retried, see [DBMap.InTx]
+//   - count: number of committed rows.
+//     On error, rows of committed transactions remain inserted
+//   - config: optional limits, placeholder syntax and progress callback
+//
+// Usage:
+//
+//	var count, err = dbMap.BulkInsert(ctx, partition, "users", []string{"id", "name"},
+//	  iters.NewSliceIterator(rows), &psql.BulkInsertConfig{
+//	    Progress: func(p psql.BulkProgress) { parl.Log("%s", p) },
+//	  })
+func (d *DBMap) BulkInsert(
+	ctx context.Context, partition parl.DBPartition,
+	table string, columns []string, rows iters.Iterator[[]any],
+	config ...*BulkInsertConfig,
+) (count int64, err error) {
+	if rows == nil {
+		panic(parl.NilError("rows"))
+	} else if len(columns) == 0 {
+		panic(perrors.NewPF("columns cannot be empty"))
+	}
+	defer rows.Cancel(&err)
+
+	var c BulkInsertConfig
+	if len(config) > 0 && config[0] != nil {
+		c = *config[0]
+	}
+	if c.MaxVariables <= 0 {
+		c.MaxVariables = SQLiteMaxVariables
+	}
+	if c.RowsPerTx <= 0 {
+		c.RowsPerTx = DefaultBulkRowsPerTx
+	}
+	var rowsPerStatement = c.MaxVariables / len(columns)
+	if rowsPerStatement == 0 {
+		err = perrors.ErrorfPF("%d columns exceed %d variables", len(columns), c.MaxVariables)
+		return
+	}
+	var builder = bulkInsertBuilder{
+		table:   table,
+		columns: columns,
+		bind:    c.Bind,
+		suffix:  c.Suffix,
+	}
+
+	var t0 = time.Now()
+	var statements int
+	var buffer = make([][]any, 0, min(c.RowsPerTx, 1024))
+	for {
+
+		// read rows for one transaction
+		buffer = buffer[:0]
+		for row := []any(nil); len(buffer) < c.RowsPerTx && rows.Cond(&row); {
+			if len(row) != len(columns) {
+				err = perrors.ErrorfPF("row %d has %d values exp %d", count+int64(len(buffer)), len(row), len(columns))
+				return
+			}
+			buffer = append(buffer, row)
+		}
+		if len(buffer) == 0 {
+			return // all rows inserted return
+		}
+
+		// insert within a transaction, possibly retried
+		var n int
+		if err = d.InTx(ctx, partition, func(ctx context.Context, tx *Tx) (err error) {
+			n = 0
+			for i := 0; i < len(buffer); i += rowsPerStatement {
+				var batch = buffer[i:min(i+rowsPerStatement, len(buffer))]
+				var query, args = builder.statement(batch)
+				if _, err = tx.Exec(query, ctx, args...); err != nil {
+					return
+				}
+				n++
+			}
+			return
+		}, c.Tx); err != nil {
+			err = perrors.ErrorfPF("BulkInsert %s: %w", table, err)
+			return
+		}
+		count += int64(len(buffer))
+		statements += n
+		if c.Progress != nil {
+			c.Progress(BulkProgress{Rows: count, Statements: statements, Elapsed: time.Since(t0)})
+		}
+	}
+}
+
+// bulkInsertBuilder creates multi-valued INSERT statements
+type bulkInsertBuilder struct {
+	table   string
+	columns []string
+	bind    BindStyle
+	suffix  string
+}
+
+// statement returns query and arguments inserting batch
+//   - “INSERT INTO t (a, b) VALUES (?, ?), (?, ?)”
+func (b *bulkInsertBuilder) statement(batch [][]any) (query string, args []any) {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO " + b.table + " (" + strings.Join(b.columns, ", ") + ") VALUES ")
+	args = make([]any, 0, len(batch)*len(b.columns))
+	for i, row := range batch {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for j, value := range row {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			args = append(args, value)
+			sb.WriteString(b.bind.Placeholder(len(args)))
+		}
+		sb.WriteByte(')')
+	}
+	if b.suffix != "" {
+		sb.WriteString("\x20" + b.suffix)
+	}
+	query = sb.String()
+
+	return
+}
+
+// Placeholder returns the placeholder for 1-based argument n
+//   - “?” “$1”
+func (b BindStyle) Placeholder(n int) (placeholder string) {
+	if b == BindDollar {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// Rebind converts a query with “?” placeholders to style b
+//   - question marks within single-quoted string literals are not converted
+func (b BindStyle) Rebind(query string) (query2 string) {
+	if b == BindQuestion {
+		return query
+	}
+	var sb strings.Builder
+	var n int
+	var inLiteral bool
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inLiteral = !inLiteral
+		case r == '?' && !inLiteral:
+			n++
+			sb.WriteString(b.Placeholder(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// “question” “dollar”
+func (b BindStyle) String() (s string) {
+	switch b {
+	case BindQuestion:
+		return "question"
+	case BindDollar:
+		return "dollar"
+	}
+	return fmt.Sprintf("?bindStyle%d", b)
+}
+
+// “rows 20,000 statements 101 1.2s 16.7 krows/s”
+func (p BulkProgress) String() (s string) {
+	return parl.Sprintf("rows %d statements %d %s %s",
+		p.Rows, p.Statements, pstrings.Duration(p.Elapsed), pstrings.SI(p.RowsPerSecond(), "rows/s"),
+	)
+}
+
+// RowsPerSecond returns the insert rate
+func (p BulkProgress) RowsPerSecond() (rate float64) {
+	if p.Elapsed <= 0 {
+		return
+	}
+	return float64(p.Rows) / p.Elapsed.Seconds()
+}
@@ -0,0 +1,75 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/iters"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestBulkInsert(t *testing.T) {
+	//t.Error("Logging on")
+	var partition = parl.DBPartition("2024")
+	var ctx = context.Background()
+	var rows = [][]any{{1, "a"}, {2, "b"}, {3, "c"}, {4, "d"}, {5, "e"}}
+	// 4 variables: 2 rows per statement, 3 rows per transaction
+	var config = BulkInsertConfig{MaxVariables: 4, RowsPerTx: 3}
+	var expProgress = []int64{3, 5}
+
+	var dbMap, sqlMock = newTxTestDBMap(t)
+	// statements are prepared once by the statement cache and
+	// once per transaction connection by sql.Tx.StmtContext
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectPrepare(`^INSERT INTO t \(id, name\) VALUES \(\?, \?\), \(\?, \?\)$`)
+	sqlMock.ExpectPrepare(`^INSERT INTO t \(id, name\) VALUES \(\?, \?\), \(\?, \?\)$`)
+	sqlMock.ExpectExec(`^INSERT INTO t \(id, name\) VALUES \(\?, \?\), \(\?, \?\)$`).WithArgs(1, "a", 2, "b").WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectPrepare(`^INSERT INTO t \(id, name\) VALUES \(\?, \?\)$`)
+	sqlMock.ExpectPrepare(`^INSERT INTO t \(id, name\) VALUES \(\?, \?\)$`)
+	sqlMock.ExpectExec(`^INSERT INTO t \(id, name\) VALUES \(\?, \?\)$`).WithArgs(3, "c").WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+	// the second transaction has a cache hit for a statement
+	// already prepared on both connections
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`^INSERT INTO t \(id, name\) VALUES \(\?, \?\), \(\?, \?\)$`).WithArgs(4, "d", 5, "e").WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectCommit()
+	var progress []int64
+	config.Progress = func(p BulkProgress) { progress = append(progress, p.Rows) }
+
+	var count, err = dbMap.BulkInsert(ctx, partition, "t", []string{"id", "name"}, iters.NewSliceIterator(rows), &config)
+	if err != nil {
+		t.Fatalf("BulkInsert err: %s", perrors.Short(err))
+	}
+	if count != int64(len(rows)) {
+		t.Errorf("count %d exp %d", count, len(rows))
+	}
+	if len(progress) != len(expProgress) || progress[0] != expProgress[0] || progress[1] != expProgress[1] {
+		t.Errorf("progress %v exp %v", progress, expProgress)
+	}
+	if err = sqlMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations: %s", err)
+	}
+}
+
+func TestBindStyle(t *testing.T) {
+	var builder = bulkInsertBuilder{table: "t", columns: []string{"a", "b"}, bind: BindDollar, suffix: "ON CONFLICT DO NOTHING"}
+	var expQuery = "INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4) ON CONFLICT DO NOTHING"
+
+	var query, args = builder.statement([][]any{{1, 2}, {3, 4}})
+	if query != expQuery {
+		t.Errorf("query %q exp %q", query, expQuery)
+	}
+	if len(args) != 4 || args[3] != 4 {
+		t.Errorf("args %v", args)
+	}
+	if s := BindDollar.Rebind("SELECT '?' WHERE a = ? AND b = ?"); s != "SELECT '?' WHERE a = $1 AND b = $2" {
+		t.Errorf("Rebind %q", s)
+	}
+}
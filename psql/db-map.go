@@ -10,7 +10,26 @@ ISC License
 //     database access is using cached prepared statements and
 //     access using application name and partition name like year.
 //   - [NewResultSetIterator] provides a Go for-statements abstract result-set iterator
+//   - [NewMergeIterator] merges the sorted result sets of a query executed
+//     concurrently against multiple partitions
 //   - [ScanFunc] is the signature for preparing custom result-set iterators
+//   - [DBMap.SetTracer] observes statement executions via [QueryTracer].
+//     [NewQueryStats] provides per-statement latency histograms and slow-query logging
+//   - [DBMap.InTx] executes a function in a transaction retried on busy errors,
+//     with nested scopes using savepoints [Tx.InTx]
+//   - a data source namer implementing [ReaderDSNr] has reads routed to
+//     read replicas with failover, configured by [DBMap.SetReplicaConfig]
+//   - [DBMap.SetMaxStatements] bounds prepared-statement caches using LRU eviction,
+//     [DBMap.Invalidate] removes a statement following schema changes.
+//     Cache events are observed by a tracer implementing [StatementCacheTracer]
+//   - [DBMap.BulkInsert] inserts rows using batched multi-valued INSERT statements
+//     in retried transactions
+//   - [DBMap.OpenPartition] creates a partition ahead of time,
+//     [DBMap.ClosePartition] closes a partition preventing its re-creation
+//   - [DBMap.Schema] introspects tables, columns, indexes and foreign keys of a partition,
+//     [DiffSchema] compares partitions or a partition against a declared [Schema]
+//   - [DBMap.SetTimeouts] applies default read and write deadlines to statements
+//     whose context has no deadline, with a [TimeoutPolicy] for canceled statements
 //   - seamless statement-retry, remedying concurrency-deficient databases such as
 //     SQLite3
 //   - —
@@ -29,6 +48,7 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/haraldrudell/parl"
 	"github.com/haraldrudell/parl/perrors"
@@ -54,6 +74,16 @@ type DBMap struct {
 	stateLock sync.Mutex
 	m         map[parl.DataSourceName]*psql2.StatementCache // behind stateLock
 	closeErr  atomic.Pointer[error]                         // written behind stateLock
+	// closed are data sources closed by [DBMap.ClosePartition], behind stateLock
+	closed map[parl.DataSourceName]struct{}
+	// tracer observes statement executions, see [DBMap.SetTracer]
+	tracer atomic.Pointer[QueryTracer]
+	// replicas routes reads when dsnr implements [ReaderDSNr]
+	replicas replicaRouter
+	// maxStatements is max size of statement caches, see [DBMap.SetMaxStatements]
+	maxStatements atomic.Int64
+	// timeouts are default statement deadlines, see [DBMap.SetTimeouts]
+	timeouts atomic.Pointer[StatementTimeouts]
 }
 
 // NewDBMap returns a database connection and prepared statement cache
@@ -84,10 +114,24 @@ func NewDBMap2(
 func (d *DBMap) Exec(
 	partition parl.DBPartition, query string, ctx context.Context,
 	args ...any) (execResult parl.ExecResult, err error) {
+	if tracer := d.tracer.Load(); tracer != nil {
+		var rows int64
+		defer d.trace(*tracer, partition, query, time.Now(), &rows, &err)
+		defer func() {
+			if execResult != nil {
+				_, rows = execResult.Get()
+			}
+		}()
+	}
+	var timeout statementTimeout
+	ctx, timeout = d.withTimeout(ctx, isWriteStatement)
+	defer timeout.end(ctx, query, partition, isDoneStatement, &err)
 	var stmt psql2.Stmt
-	if stmt, err = d.getStmt(partition, query, ctx); err != nil {
+	var release func()
+	if stmt, release, err = d.getStmt(partition, query, ctx); err != nil {
 		return
 	}
+	defer release()
 	if execResult, err = psql2.NewExecResult(stmt.ExecContext(ctx, args...)); err != nil {
 		err = perrors.Errorf("Exec: %w", err)
 		return
@@ -100,11 +144,17 @@ func (d *DBMap) Exec(
 func (d *DBMap) Query(
 	partition parl.DBPartition, query string, ctx context.Context,
 	args ...any) (sqlRows *sql.Rows, err error) {
-	var stmt psql2.Stmt
-	if stmt, err = d.getStmt(partition, query, ctx); err != nil {
-		return
+	if tracer := d.tracer.Load(); tracer != nil {
+		var rows = RowsUnknown
+		defer d.trace(*tracer, partition, query, time.Now(), &rows, &err)
 	}
-	if sqlRows, err = stmt.QueryContext(ctx, args...); err != nil {
+	var timeout statementTimeout
+	ctx, timeout = d.withTimeout(ctx, isReadStatement)
+	defer timeout.end(ctx, query, partition, isRowsStatement, &err)
+	if err = d.read(partition, query, ctx, func(stmt psql2.Stmt) (err error) {
+		sqlRows, err = stmt.QueryContext(ctx, args...)
+		return
+	}); err != nil {
 		err = perrors.Errorf("Query: %w", err)
 		return
 	}
@@ -117,12 +167,17 @@ func (d *DBMap) Query(
 func (d *DBMap) QueryRow(
 	partition parl.DBPartition, query string, ctx context.Context,
 	args ...any) (sqlRow *sql.Row, err error) {
-	var stmt psql2.Stmt
-	if stmt, err = d.getStmt(partition, query, ctx); err != nil {
-		return
+	if tracer := d.tracer.Load(); tracer != nil {
+		var rows int64 = 1
+		defer d.trace(*tracer, partition, query, time.Now(), &rows, &err)
 	}
-	sqlRow = stmt.QueryRowContext(ctx, args...)
-	if err = sqlRow.Err(); err != nil {
+	var timeout statementTimeout
+	ctx, timeout = d.withTimeout(ctx, isReadStatement)
+	defer timeout.end(ctx, query, partition, isRowsStatement, &err)
+	if err = d.read(partition, query, ctx, func(stmt psql2.Stmt) (err error) {
+		sqlRow = stmt.QueryRowContext(ctx, args...)
+		return sqlRow.Err()
+	}); err != nil {
 		err = perrors.Errorf("QueryRow: %w", err)
 		return
 	}
@@ -137,19 +192,22 @@ func (d *DBMap) QueryString(
 	ctx context.Context,
 	args ...any,
 ) (value string, hasValue bool, err error) {
-
-	// retrieve a possibly cached prepared statement
-	var stmt psql2.Stmt
-	if stmt, err = d.getStmt(partition, query, ctx); err != nil {
-		return
+	if tracer := d.tracer.Load(); tracer != nil {
+		defer d.traceValue(*tracer, partition, query, time.Now(), &hasValue, &err)
 	}
-
-	if err = stmt.QueryRowContext(ctx, args...).Scan(&value); err != nil {
+	var timeout statementTimeout
+	ctx, timeout = d.withTimeout(ctx, isReadStatement)
+	defer timeout.end(ctx, query, partition, isDoneStatement, &err)
+
+	// execute using a possibly cached prepared statement
+	if err = d.read(partition, query, ctx, func(stmt psql2.Stmt) (err error) {
+		return stmt.QueryRowContext(ctx, args...).Scan(&value)
+	}); err != nil {
 		if noRowsOk == parl.NoRowsOK && errors.Is(err, sql.ErrNoRows) {
 			err = nil
 			return
 		}
-		err = perrors.Errorf("QueryString.Scan: %w", err)
+		err = perrors.Errorf("QueryString.Scan: %w", noRowsClass(err))
 		return
 	}
 	hasValue = true
@@ -164,19 +222,22 @@ func (d *DBMap) QueryInt(
 	ctx context.Context,
 	args ...any,
 ) (value int, hasValue bool, err error) {
-
-	// retrieve a possibly cached prepared statement
-	var stmt psql2.Stmt
-	if stmt, err = d.getStmt(partition, query, ctx); err != nil {
-		return
+	if tracer := d.tracer.Load(); tracer != nil {
+		defer d.traceValue(*tracer, partition, query, time.Now(), &hasValue, &err)
 	}
-
-	if err = stmt.QueryRowContext(ctx, args...).Scan(&value); err != nil {
+	var timeout statementTimeout
+	ctx, timeout = d.withTimeout(ctx, isReadStatement)
+	defer timeout.end(ctx, query, partition, isDoneStatement, &err)
+
+	// execute using a possibly cached prepared statement
+	if err = d.read(partition, query, ctx, func(stmt psql2.Stmt) (err error) {
+		return stmt.QueryRowContext(ctx, args...).Scan(&value)
+	}); err != nil {
 		if noRowsOk == parl.NoRowsOK && errors.Is(err, sql.ErrNoRows) {
 			err = nil
 			return
 		}
-		err = perrors.Errorf("QueryInt.Scan: %w", err)
+		err = perrors.Errorf("QueryInt.Scan: %w", noRowsClass(err))
 		return
 	}
 	hasValue = true
@@ -184,6 +245,19 @@ func (d *DBMap) QueryInt(
 	return
 }
 
+// SetTracer installs an observer of every statement executed
+//   - tracer nil: tracing is disabled
+//   - [NewQueryStats] provides per-statement latency histograms and
+//     slow-query logging
+//   - thread-safe
+func (d *DBMap) SetTracer(tracer QueryTracer) {
+	if tracer == nil {
+		d.tracer.Store(nil)
+		return
+	}
+	d.tracer.Store(&tracer)
+}
+
 // Close shuts down the statement cache and the data source
 func (d *DBMap) Close() (err error) {
 
@@ -263,20 +337,50 @@ func (d *DBMap) length() (length int, isInitialized bool) {
 	return
 }
 
+// trace is deferred to provide a statement execution to tracer
+func (d *DBMap) trace(
+	tracer QueryTracer, partition parl.DBPartition, query string,
+	t0 time.Time, rowsp *int64, errp *error,
+) {
+	tracer.TraceQuery(query, partition, time.Since(t0), *rowsp, *errp)
+}
+
+// traceValue is deferred to provide a single-value query to tracer
+func (d *DBMap) traceValue(
+	tracer QueryTracer, partition parl.DBPartition, query string,
+	t0 time.Time, hasValuep *bool, errp *error,
+) {
+	var rows int64
+	if *hasValuep {
+		rows = 1
+	}
+	tracer.TraceQuery(query, partition, time.Since(t0), rows, *errp)
+}
+
 // getStmt obtains a cached statemnt or prepares the statement and caches it
+//   - release: must be invoked after the statement was executed
 func (d *DBMap) getStmt(
 	partition parl.DBPartition, query string, ctx context.Context,
-) (stmt psql2.Stmt, err error) {
+) (stmt psql2.Stmt, release func(), err error) {
+	return d.getStmtDSN(d.dsnr.DSN(partition), query, ctx, isWriterDSN)
+}
+
+// getStmtDSN obtains a cached statement of a particular data source
+//   - isReader: the data source is a read replica
+//   - release: must be invoked after the statement was executed
+func (d *DBMap) getStmtDSN(
+	dataSourceName parl.DataSourceName, query string, ctx context.Context, isReader bool,
+) (stmt psql2.Stmt, release func(), err error) {
 
 	// obtain the statement cache
 	var dbCache *psql2.StatementCache
-	if dbCache, err = d.getOrCreateDBCache(d.dsnr.DSN(partition), ctx); err != nil {
+	if dbCache, err = d.getOrCreateCache(dataSourceName, ctx, isReader); err != nil {
 		return // closed or failure return
 	}
 
 	// obtain the statement
 	var sqlStmt *sql.Stmt
-	if sqlStmt, err = dbCache.Stmt(query, ctx); err != nil {
+	if sqlStmt, release, err = dbCache.Acquire(query, ctx); err != nil {
 		return // closed or failure returrn
 	}
 	// possibly wrap the statement
@@ -289,6 +393,14 @@ func (d *DBMap) getStmt(
 // creates, caches and returns a database object
 func (d *DBMap) getOrCreateDBCache(dataSourceName parl.DataSourceName,
 	ctx context.Context) (dbStatementCache *psql2.StatementCache, err error) {
+	return d.getOrCreateCache(dataSourceName, ctx, isWriterDSN)
+}
+
+// getOrCreateCache returns a cached or new database object
+//   - isReader: the data source is a read replica whose
+//     schema is not initialized
+func (d *DBMap) getOrCreateCache(dataSourceName parl.DataSourceName,
+	ctx context.Context, isReader bool) (dbStatementCache *psql2.StatementCache, err error) {
 
 	// status check outside lock
 	if ep := d.closeErr.Load(); ep != nil {
@@ -307,6 +419,9 @@ func (d *DBMap) getOrCreateDBCache(dataSourceName parl.DataSourceName,
 	// try cache
 	if dbStatementCache = d.m[dataSourceName]; dbStatementCache != nil {
 		return // cached DB object exit
+	} else if _, isClosed := d.closed[dataSourceName]; isClosed {
+		err = perrors.ErrorfPF("%w: %s", ErrPartitionClosed, dataSourceName)
+		return // closed partition exit
 	}
 
 	// create dataSource for new dbCache instance
@@ -316,11 +431,13 @@ func (d *DBMap) getOrCreateDBCache(dataSourceName parl.DataSourceName,
 	}
 	defer d.getEnd(&err, dataSourceName, &dataSource, &dbStatementCache)
 
-	// initialize schema
-	if err = d.schema(dataSource, ctx); err != nil {
-		return // schema failure exit
+	// initialize schema of writer
+	if !isReader {
+		if err = d.schema(dataSource, ctx); err != nil {
+			return // schema failure exit
+		}
 	}
-	dbStatementCache = psql2.NewStatementCache(dataSource)
+	dbStatementCache = psql2.NewStatementCache(dataSource, d.cacheConfig(dataSourceName))
 
 	return // good exit
 }
@@ -349,3 +466,11 @@ func (d *DBMap) getEnd(
 
 // type name of [parl.DB] implementation: “psql.DBMap”
 var dbMapTypeName = fmt.Sprintf("%T", DBMap{})
+
+// noRowsClass tags [sql.ErrNoRows] with [perrors.ClassNotFound]
+func noRowsClass(err error) (err2 error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return perrors.WithClass(err, perrors.ClassNotFound)
+	}
+	return err
+}
@@ -0,0 +1,230 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"container/heap"
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/iters"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// number of records buffered per partition
+	mergeBufferSize = 16
+)
+
+// MergeIterator merges the sorted result sets of the same query
+// executed against multiple partitions into one ordered iteration
+//   - k-way merge: memory use is proportional to the number of partitions
+type MergeIterator[T any] struct {
+	// ctx is canceled on iteration cancel, ending partition threads
+	ctx    context.Context
+	cancel context.CancelFunc
+	// compare orders records
+	compare func(a, b T) (result int)
+	// errorSink receives partition errors, may be nil
+	errorSink parl.ErrorSink1
+	// sources are the per-partition record streams
+	sources []*mergeSource[T]
+	// heads is a min-heap of sources with a pending record
+	heads mergeHeap[T]
+	// isStarted is true once the first record of each source was read
+	isStarted bool
+	// err is partition errors when errorSink is nil
+	err error
+	// wg awaits partition threads
+	wg sync.WaitGroup
+}
+
+// mergeSource is the record stream of one partition
+type mergeSource[T any] struct {
+	partition parl.DBPartition
+	// recordCh is closed by the partition thread on end of records or error
+	recordCh chan T
+	// err is partition failure, valid after recordCh closes
+	err error
+	// head is the next record of this partition
+	head T
+}
+
+// NewMergeIterator executes query concurrently against each partition and
+// returns an iterator merging the sorted result sets
+//   - query must return records ordered consistently with compare
+//   - scanFunc: scans a record, see [ScanFunc]
+//   - compare: returns negative if a sorts before b, 0 if equal, positive otherwise,
+//     like [cmp.Compare]
+//   - errorSink: optional. A failing partition is isolated: its error is
+//     sent to errorSink and remaining partitions are merged.
+//     With no errorSink, partition errors are returned once all other records
+//     were iterated
+//   - ctx cancel ends iteration with error.
+//     Iterator Cancel ends partition threads and closes result sets
+//
+// Usage:
+//
+//	var iterator = psql.NewMergeIterator(ctx, db, []parl.DBPartition{"2023", "2024"},
+//	  "SELECT t, v FROM log ORDER BY t", scanFunc, compareFunc, nil)
+//	defer iterator.Cancel(&err)
+//	for item, _ := iterator.Init(); iterator.Cond(&item, &err); {
+//	  …
+func NewMergeIterator[T any](
+	ctx context.Context, db parl.DB,
+	partitions []parl.DBPartition, query string,
+	scanFunc ScanFunc[T], compare func(a, b T) (result int),
+	errorSink parl.ErrorSink1, args ...any,
+) (iterator iters.Iterator[T]) {
+	if db == nil {
+		panic(parl.NilError("db"))
+	} else if scanFunc == nil {
+		panic(parl.NilError("scanFunc"))
+	} else if compare == nil {
+		panic(parl.NilError("compare"))
+	}
+	var m = MergeIterator[T]{
+		compare:   compare,
+		errorSink: errorSink,
+		sources:   make([]*mergeSource[T], len(partitions)),
+	}
+	m.ctx, m.cancel = context.WithCancel(ctx)
+	m.heads.compare = compare
+
+	// launch partition threads
+	m.wg.Add(len(partitions))
+	for i, partition := range partitions {
+		var source = &mergeSource[T]{partition: partition, recordCh: make(chan T, mergeBufferSize)}
+		m.sources[i] = source
+		go m.partitionThread(source, db, query, scanFunc, args)
+	}
+
+	return iters.NewFunctionIterator(m.iteratorFunction)
+}
+
+// iteratorFunction returns the least pending record
+func (m *MergeIterator[T]) iteratorFunction(isCancel bool) (t T, err error) {
+	if isCancel {
+		m.cancel()
+		m.wg.Wait()
+		return // cancel notification return
+	}
+
+	// first invocation: await the first record from each partition
+	if !m.isStarted {
+		m.isStarted = true
+		for _, source := range m.sources {
+			if err = m.next(source); err != nil {
+				return // context canceled return
+			}
+		}
+	}
+
+	// end of records
+	if m.heads.Len() == 0 {
+		m.cancel()
+		if err = m.err; err == nil {
+			err = parl.ErrEndCallbacks
+		}
+		return // end of data return
+	}
+
+	// the least record is returned, its source is advanced
+	var source = heap.Pop(&m.heads).(*mergeSource[T])
+	t = source.head
+	err = m.next(source)
+
+	return
+}
+
+// next reads the next record from source onto the heap
+//   - source at end is dropped, a failed source has its error reported
+//   - err: context canceled
+func (m *MergeIterator[T]) next(source *mergeSource[T]) (err error) {
+	var isOpen bool
+	select {
+	case source.head, isOpen = <-source.recordCh:
+	case <-m.ctx.Done():
+		err = perrors.ErrorfPF("merge canceled: %w", context.Cause(m.ctx))
+		return // context canceled return
+	}
+	if isOpen {
+		heap.Push(&m.heads, source)
+		return // record read return
+	}
+
+	// end of records for partition
+	if source.err == nil {
+		return // partition completed successfully return
+	} else if m.errorSink != nil {
+		m.errorSink.AddError(source.err)
+	} else {
+		m.err = perrors.AppendError(m.err, source.err)
+	}
+
+	return
+}
+
+// partitionThread executes query for one partition, sending records to recordCh
+func (m *MergeIterator[T]) partitionThread(
+	source *mergeSource[T], db parl.DB, query string,
+	scanFunc ScanFunc[T], args []any,
+) {
+	defer m.wg.Done()
+	defer close(source.recordCh)
+	var err error
+	defer m.partitionEnd(source, &err)
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	var sqlRows *sql.Rows
+	if sqlRows, err = db.Query(source.partition, query, m.ctx, args...); err != nil {
+		return // query failed return
+	}
+	defer parl.Close(sqlRows, &err)
+
+	for sqlRows.Next() {
+		var t T
+		if t, err = scanFunc(sqlRows); err != nil {
+			return // scan failed return
+		}
+		select {
+		case source.recordCh <- t:
+		case <-m.ctx.Done():
+			return // iteration canceled return
+		}
+	}
+	err = sqlRows.Err()
+}
+
+// partitionEnd stores any partition error prior to recordCh close
+func (m *MergeIterator[T]) partitionEnd(source *mergeSource[T], errp *error) {
+	if err := *errp; err != nil && m.ctx.Err() == nil {
+		source.err = perrors.ErrorfPF("partition %q: %w", source.partition, err)
+	}
+}
+
+// mergeHeap is a min-heap of sources ordered by head record
+//   - implements [heap.Interface]
+type mergeHeap[T any] struct {
+	compare func(a, b T) (result int)
+	sources []*mergeSource[T]
+}
+
+func (h *mergeHeap[T]) Len() (length int) { return len(h.sources) }
+func (h *mergeHeap[T]) Less(i, j int) (isLess bool) {
+	return h.compare(h.sources[i].head, h.sources[j].head) < 0
+}
+func (h *mergeHeap[T]) Swap(i, j int) { h.sources[i], h.sources[j] = h.sources[j], h.sources[i] }
+func (h *mergeHeap[T]) Push(x any)    { h.sources = append(h.sources, x.(*mergeSource[T])) }
+func (h *mergeHeap[T]) Pop() (x any) {
+	var last = len(h.sources) - 1
+	x = h.sources[last]
+	h.sources[last] = nil
+	h.sources = h.sources[:last]
+	return
+}
@@ -0,0 +1,111 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestMergeIterator(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		partition1   parl.DBPartition = "2023"
+		partition2   parl.DBPartition = "2024"
+		badPartition parl.DBPartition = "bad"
+	)
+	var (
+		expValues = []int{1, 2, 3, 4, 5, 6}
+		db        = &mergeTestDB{values: map[parl.DBPartition][]int{
+			partition1: {1, 4, 5},
+			partition2: {2, 3, 6},
+		}}
+		partitions = []parl.DBPartition{partition1, badPartition, partition2}
+		values     []int
+		err        error
+		errs       parl.ErrSlice
+	)
+
+	// with errorSink: bad partition is isolated
+	var iterator = NewMergeIterator(context.Background(), db, partitions, queryName, scanMergeTest, cmp.Compare[int], &errs)
+	for value, _ := iterator.Init(); iterator.Cond(&value, &err); {
+		values = append(values, value)
+	}
+	if err != nil {
+		t.Errorf("iteration err: %s", perrors.Short(err))
+	}
+	if !slices.Equal(values, expValues) {
+		t.Errorf("values %v exp %v", values, expValues)
+	}
+	if e, _ := errs.Error(); !errors.Is(e, errMergeTest) {
+		t.Errorf("errorSink %v exp %v", e, errMergeTest)
+	}
+
+	// without errorSink: error is returned after records
+	values = nil
+	iterator = NewMergeIterator(context.Background(), db, partitions, queryName, scanMergeTest, cmp.Compare[int], nil)
+	for value, _ := iterator.Init(); iterator.Cond(&value, &err); {
+		values = append(values, value)
+	}
+	if !errors.Is(err, errMergeTest) {
+		t.Errorf("iteration err %v exp %v", err, errMergeTest)
+	}
+	if !slices.Equal(values, expValues) {
+		t.Errorf("values %v exp %v", values, expValues)
+	}
+}
+
+// errMergeTest is returned by the bad partition
+var errMergeTest = errors.New("bad partition")
+
+// mergeTestDB is a [parl.DB] returning mocked result sets by partition
+type mergeTestDB struct {
+	parl.DB
+	values map[parl.DBPartition][]int
+}
+
+// Query returns a result set of single-column records
+func (d *mergeTestDB) Query(partition parl.DBPartition, query string, ctx context.Context,
+	args ...any) (sqlRows *sql.Rows, err error) {
+	var values, ok = d.values[partition]
+	if !ok {
+		err = errMergeTest
+		return
+	}
+	var anys = make([]any, len(values))
+	for i, v := range values {
+		anys[i] = v
+	}
+	sqlRows = newMockDB().sqlRowsN(anys)
+	return
+}
+
+// scanMergeTest scans an int
+func scanMergeTest(sqlRows *sql.Rows) (value int, err error) {
+	err = sqlRows.Scan(&value)
+	return
+}
+
+// sqlRowsN returns sql.Rows with single-column values content
+func (m *mockDB) sqlRowsN(values []any) (sqlRows *sql.Rows) {
+	var rows = m.sqlMock.NewRows([]string{"col1"})
+	for _, value := range values {
+		rows.AddRow(value)
+	}
+	m.sqlMock.ExpectQuery(queryName).WillReturnRows(rows)
+	var err error
+	if sqlRows, err = m.mockDb.Query(queryName); perrors.Is(&err, "mockDb.Query %w", err) {
+		panic(err)
+	}
+	return
+}
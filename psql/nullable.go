@@ -0,0 +1,114 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// conversions for drivers with native time, bool and binary types.
+// [github.com/haraldrudell/parl/sqliter] has SQLite TEXT and INTEGER
+// equivalents
+
+// TimeToNullable converts Go time.Time to a nullable database value
+//   - time.Time{} [time.Time.IsZero] true is NULL
+//   - other values are UTC
+func TimeToNullable(t time.Time) (dbValue any) {
+	if t.IsZero() {
+		return // NULL return
+	}
+	return t.UTC()
+}
+
+// NullableToTime converts a nullable database time to Go time.Time
+//   - NULL is time.Time{} [time.Time.IsZero] true
+//   - other values are in Local location
+func NullableToTime(nullTime sql.NullTime) (t time.Time) {
+	if !nullTime.Valid {
+		return // NULL return
+	}
+	return nullTime.Time.Local()
+}
+
+// StringToNullable converts Go string to a nullable database value
+//   - empty string is NULL
+func StringToNullable(s string) (dbValue any) {
+	if s == "" {
+		return // NULL return
+	}
+	return s
+}
+
+// NullableToString converts a nullable database string to Go string
+//   - NULL is empty string
+func NullableToString(nullString sql.NullString) (s string) { return nullString.String }
+
+// ToBool converts a scanned database value to Go bool
+//   - bool: native boolean
+//   - int64 0 or 1: INTEGER boolean
+//   - []byte or string: “0” “1” “f” “t” “false” “true”
+func ToBool(dbValue any) (b bool, err error) {
+	switch v := dbValue.(type) {
+	case bool:
+		return v, nil
+	case int64:
+		if v == 0 || v == 1 {
+			return v == 1, nil
+		}
+	case []byte:
+		return textToBool(string(v))
+	case string:
+		return textToBool(v)
+	}
+	err = perrors.ErrorfPF("illegal database value for boolean: %T %v", dbValue, dbValue)
+
+	return
+}
+
+// textToBool converts text boolean representations
+func textToBool(s string) (b bool, err error) {
+	switch s {
+	case "1", "t", "true":
+		return true, nil
+	case "0", "f", "false":
+		return false, nil
+	}
+	err = perrors.ErrorfPF("illegal database text for boolean: %q", s)
+
+	return
+}
+
+// UUIDToDB converts a Go 128-bit UUID to a database value
+//   - 36-character text “01234567-89ab-cdef-0123-456789abcdef”
+//     accepted by uuid, text and varchar columns
+func UUIDToDB(ID uuid.UUID) (dbValue string) { return ID.String() }
+
+// ToUUID converts a scanned database value to Go uuid.UUID
+//   - []byte of length 16: binary uuid
+//   - []byte or string of length 36: text uuid
+func ToUUID(dbValue any) (ID uuid.UUID, err error) {
+	switch v := dbValue.(type) {
+	case []byte:
+		if len(v) == len(ID) {
+			copy(ID[:], v)
+			return // binary return
+		}
+		dbValue = string(v)
+	}
+	var s, ok = dbValue.(string)
+	if !ok {
+		err = perrors.ErrorfPF("illegal database value for uuid: %T", dbValue)
+		return
+	} else if ID, err = uuid.Parse(s); err != nil {
+		err = perrors.ErrorfPF("uuid.Parse: %w", err)
+	}
+
+	return
+}
@@ -0,0 +1,40 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestToBool(t *testing.T) {
+	//t.Error("Logging on")
+	for _, value := range []any{true, int64(1), []byte("t"), "true"} {
+		if b, err := ToBool(value); err != nil || !b {
+			t.Errorf("ToBool %v: %t %v", value, b, err)
+		}
+	}
+	for _, value := range []any{int64(2), "yes", 1.5} {
+		if _, err := ToBool(value); err == nil {
+			t.Errorf("ToBool %v: missing error", value)
+		}
+	}
+}
+
+func TestToUUID(t *testing.T) {
+	//t.Error("Logging on")
+	var ID = uuid.UUID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+	for _, value := range []any{ID[:], UUIDToDB(ID), []byte(UUIDToDB(ID))} {
+		if id, err := ToUUID(value); err != nil || id != ID {
+			t.Errorf("ToUUID %v: %s %v", value, id, err)
+		}
+	}
+	if _, err := ToUUID(int64(1)); err == nil {
+		t.Error("ToUUID int64: missing error")
+	}
+}
@@ -0,0 +1,85 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/psql/psql2"
+)
+
+// ErrPartitionClosed indicates access to a partition closed by [DBMap.ClosePartition]
+//
+// Usage:
+//
+//	if errors.Is(err, psql.ErrPartitionClosed) {
+var ErrPartitionClosed = errors.New("partition closed")
+
+// OpenPartition ensures that the database object of partition is cached
+//   - a new data source has its schema initialized,
+//     ie. a partition can be created ahead of time
+//   - a partition closed by [DBMap.ClosePartition] becomes accessible again
+//   - thread-safe
+func (d *DBMap) OpenPartition(partition parl.DBPartition, ctx context.Context) (err error) {
+	var dataSourceName = d.dsnr.DSN(partition)
+
+	// remove closed state
+	d.stateLock.Lock()
+	delete(d.closed, dataSourceName)
+	d.stateLock.Unlock()
+
+	_, err = d.getOrCreateDBCache(dataSourceName, ctx)
+	return
+}
+
+// ClosePartition closes the cached database object of partition
+//   - subsequent access to partition fails with [ErrPartitionClosed]
+//     until [DBMap.OpenPartition].
+//     This prevents a concurrent query from re-creating a partition that
+//     is being archived or deleted
+//   - statements executing when ClosePartition is invoked are allowed to complete
+//   - closing a partition that is not cached is not an error
+//   - thread-safe, idempotent
+func (d *DBMap) ClosePartition(partition parl.DBPartition) (err error) {
+	var dataSourceName = d.dsnr.DSN(partition)
+
+	var dbCache *psql2.StatementCache
+	if dbCache, err = d.closePartition(dataSourceName); err != nil || dbCache == nil {
+		return // closed DBMap or partition not cached return
+	}
+
+	// close outside lock
+	if err = dbCache.Close(); err != nil {
+		err = perrors.ErrorfPF("close partition %q: %w", partition, err)
+	}
+
+	return
+}
+
+// closePartition marks dataSourceName closed and removes its database object
+//   - dbCache: cached object to be closed, nil if none
+func (d *DBMap) closePartition(dataSourceName parl.DataSourceName) (dbCache *psql2.StatementCache, err error) {
+	d.stateLock.Lock()
+	defer d.stateLock.Unlock()
+
+	if ep := d.closeErr.Load(); ep != nil {
+		err = perrors.NewPF("invocation after parl.DB close")
+		return // bad status exit
+	}
+
+	if d.closed == nil {
+		d.closed = make(map[parl.DataSourceName]struct{})
+	}
+	d.closed[dataSourceName] = struct{}{}
+	if dbCache = d.m[dataSourceName]; dbCache != nil {
+		delete(d.m, dataSourceName)
+	}
+
+	return
+}
@@ -0,0 +1,62 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestDBMapPartition(t *testing.T) {
+	var partition = parl.DBPartition("2024")
+	var ctx = context.Background()
+
+	var err error
+
+	var dbMap, sqlMock = newTxTestDBMap(t)
+
+	// OpenPartition caches the database object
+	if err = dbMap.OpenPartition(partition, ctx); err != nil {
+		t.Fatalf("OpenPartition err: %s", perrors.Short(err))
+	}
+	if length, _ := dbMap.length(); length != 1 {
+		t.Errorf("OpenPartition length %d exp 1", length)
+	}
+
+	// ClosePartition closes the data source
+	sqlMock.ExpectClose()
+	if err = dbMap.ClosePartition(partition); err != nil {
+		t.Errorf("ClosePartition err: %s", perrors.Short(err))
+	}
+	if err = sqlMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("ClosePartition: %s", err)
+	}
+	if length, _ := dbMap.length(); length != 0 {
+		t.Errorf("ClosePartition length %d exp 0", length)
+	}
+
+	// closed partition is not re-created
+	if _, err = dbMap.Exec(partition, "DELETE FROM t", ctx); !errors.Is(err, ErrPartitionClosed) {
+		t.Errorf("Exec err: %v exp %v", err, ErrPartitionClosed)
+	}
+
+	// ClosePartition is idempotent
+	if err = dbMap.ClosePartition(partition); err != nil {
+		t.Errorf("ClosePartition 2 err: %s", perrors.Short(err))
+	}
+
+	// OpenPartition re-opens
+	if err = dbMap.OpenPartition(partition, ctx); err != nil {
+		t.Errorf("OpenPartition 2 err: %s", perrors.Short(err))
+	}
+	if length, _ := dbMap.length(); length != 1 {
+		t.Errorf("OpenPartition 2 length %d exp 1", length)
+	}
+}
@@ -6,8 +6,10 @@ ISC License
 package psql2
 
 import (
+	"container/list"
 	"context"
 	"database/sql"
+	"fmt"
 	"sync"
 	"sync/atomic"
 
@@ -15,30 +17,122 @@ import (
 	"github.com/haraldrudell/parl/perrors"
 )
 
+const (
+	// CacheHit is a prepared statement found in the cache
+	CacheHit CacheEvent = iota + 1
+	// CacheMiss is a statement prepared and cached
+	CacheMiss
+	// CacheEvict is the least recently used statement removed
+	// due to [StatementCacheConfig.MaxStatements]
+	CacheEvict
+	// CacheInvalidate is a statement removed by
+	// [StatementCache.Invalidate]
+	CacheInvalidate
+)
+
+// NoMaxStatements is an unbounded statement cache
+const NoMaxStatements = 0
+
+// CacheEvent is an event of [StatementCache]
+//   - [CacheHit] [CacheMiss] [CacheEvict] [CacheInvalidate]
+type CacheEvent uint8
+
+// StatementCacheConfig configures [StatementCache]
+type StatementCacheConfig struct {
+	// MaxStatements is the maximum number of cached statements
+	//	- [NoMaxStatements] 0: unbounded
+	//	- once reached, the least recently used statement is closed
+	MaxStatements int
+	// OnEvent is an optional observer of cache events
+	//	- invoked outside of the cache’s lock, must be thread-safe
+	OnEvent func(event CacheEvent, query string)
+}
+
+// StatementCacheMetrics are counters of [StatementCache]
+type StatementCacheMetrics struct {
+	// Hits is the number of statements found in the cache
+	Hits uint64
+	// Misses is the number of statements prepared
+	Misses uint64
+	// Evictions is the number of statements removed by LRU eviction
+	Evictions uint64
+	// Invalidations is the number of statements removed by Invalidate
+	Invalidations uint64
+	// Size is the current number of cached statements
+	Size int
+}
+
 // StatementCache caches prepared statements for a data source
+//   - with [StatementCacheConfig.MaxStatements], least recently used
+//     statements are closed to bound driver statement handles
+//   - a statement removed while acquired is closed on its release
 type StatementCache struct {
 	// the datasource containing SQL tables
 	DataSource parl.DataSource
 
+	// onEvent observes cache events, may be nil
+	onEvent func(event CacheEvent, query string)
+	// hits misses evictions invalidations are counters
+	hits, misses, evictions, invalidations atomic.Uint64
+
 	mLock sync.Mutex
 	//	- key: SQL statement
 	//	- value: a cached prepared statement
 	//	- behind mlock
-	m        map[string]*sql.Stmt
-	closeErr atomic.Pointer[error] // written behind mLock
+	m map[string]*cacheEntry
+	// lru is cache entries, most recently used first, behind mLock
+	lru list.List
+	// maxStatements is max cache size, 0 for unbounded, behind mLock
+	maxStatements int
+	closeErr      atomic.Pointer[error] // written behind mLock
+}
+
+// cacheEntry is a cached statement
+type cacheEntry struct {
+	query string
+	stmt  *sql.Stmt
+	// element is the entry’s position in lru, nil if removed
+	element *list.Element
+	// refs is the number of acquirers yet to release, behind mLock
+	refs int
 }
 
 // NewStatementCache returns a cache for prepared statements for a data source
-func NewStatementCache(dataSource parl.DataSource) (cache *StatementCache) {
-	return &StatementCache{
+//   - config: optional max size and event observer
+func NewStatementCache(dataSource parl.DataSource, config ...StatementCacheConfig) (cache *StatementCache) {
+	var c = StatementCache{
 		DataSource: dataSource,
-		m:          make(map[string]*sql.Stmt),
+		m:          make(map[string]*cacheEntry),
 	}
+	if len(config) > 0 {
+		c.maxStatements = max(config[0].MaxStatements, 0)
+		c.onEvent = config[0].OnEvent
+	}
+	return &c
 }
 
 // Stmt returns a cached prepared statement from the cache or
 // prepares, caches and returns a new prepared statement
+//   - with MaxStatements, the statement may be closed by eviction
+//     before use. [StatementCache.Acquire] prevents this
 func (c *StatementCache) Stmt(query string, ctx context.Context) (stmt *sql.Stmt, err error) {
+	var release func()
+	if stmt, release, err = c.Acquire(query, ctx); err != nil {
+		return
+	}
+	release()
+
+	return
+}
+
+// Acquire returns a cached or newly prepared statement that is not
+// closed by eviction or invalidation until release is invoked
+//   - release: must be invoked once the statement has been executed.
+//     A result set obtained by the statement remains valid after release
+func (c *StatementCache) Acquire(query string, ctx context.Context) (stmt *sql.Stmt, release func(), err error) {
+	var events []CacheEvent
+	var evicted []*cacheEntry
+	defer c.end(query, &events, &evicted)
 
 	// close check outside lock
 	if c.DataSource == nil {
@@ -49,24 +143,92 @@ func (c *StatementCache) Stmt(query string, ctx context.Context) (stmt *sql.Stmt
 	defer c.mLock.Unlock()
 
 	// close check inside lock
-	if c.DataSource == nil {
+	if c.DataSource == nil || c.m == nil {
 		err = perrors.NewPF("Stmt after Close")
 		return // state error exit
 	}
 
 	// try cache
-	if stmt = c.m[query]; stmt != nil {
-		return // found cached statement exit
+	var entry = c.m[query]
+	if entry != nil {
+		c.lru.MoveToFront(entry.element)
+		c.hits.Add(1)
+		events = append(events, CacheHit)
+	} else {
+		// prepare and cache a new statement
+		if stmt, err = c.DataSource.PrepareContext(ctx, query); err != nil {
+			err = perrors.Errorf("Prepare: %w", err)
+			return // statement prepare failed exit
+		}
+		entry = &cacheEntry{query: query, stmt: stmt}
+		entry.element = c.lru.PushFront(entry)
+		c.m[query] = entry
+		c.misses.Add(1)
+		events = append(events, CacheMiss)
+		evicted = c.evict()
+	}
+	entry.refs++
+	stmt = entry.stmt
+	release = func() { c.release(entry) }
+
+	return // cached statement exit
+}
+
+// Invalidate removes query from the cache, ie. following a schema change
+//   - the statement is closed once no longer acquired
+//   - isCached: query was cached
+func (c *StatementCache) Invalidate(query string) (isCached bool) {
+	var closing *cacheEntry
+	c.mLock.Lock()
+	var entry = c.m[query]
+	if isCached = entry != nil; isCached {
+		if c.remove(entry) {
+			closing = entry
+		}
+		c.invalidations.Add(1)
 	}
+	c.mLock.Unlock()
 
-	// prepare and cache a new statement
-	if stmt, err = c.DataSource.PrepareContext(ctx, query); err != nil {
-		err = perrors.Errorf("Prepare: %w", err)
-		return // statement prepare failed exit
+	if !isCached {
+		return // not cached return
+	}
+	if closing != nil {
+		closing.close()
+	}
+	if c.onEvent != nil {
+		c.onEvent(CacheInvalidate, query)
+	}
+
+	return
+}
+
+// SetMaxStatements changes the maximum number of cached statements
+//   - [NoMaxStatements] 0: unbounded
+//   - excess statements are evicted
+func (c *StatementCache) SetMaxStatements(maxStatements int) {
+	c.mLock.Lock()
+	c.maxStatements = max(maxStatements, 0)
+	var evicted []*cacheEntry
+	if c.m != nil {
+		evicted = c.evict()
 	}
-	c.m[query] = stmt
+	c.mLock.Unlock()
 
-	return // new cached statement exit
+	c.closeEvicted(evicted)
+}
+
+// Metrics returns cache counters
+func (c *StatementCache) Metrics() (metrics StatementCacheMetrics) {
+	c.mLock.Lock()
+	metrics.Size = len(c.m)
+	c.mLock.Unlock()
+
+	metrics.Hits = c.hits.Load()
+	metrics.Misses = c.misses.Load()
+	metrics.Evictions = c.evictions.Load()
+	metrics.Invalidations = c.invalidations.Load()
+
+	return
 }
 
 // WrapStmt retruns a wrapped statement if the data source support it
@@ -109,9 +271,10 @@ func (c *StatementCache) Close() (err error) {
 	// close cached statements
 	var statements = c.m
 	c.m = nil // drop stmt references
-	for _, stmt := range statements {
-		if e := stmt.Close(); e != nil {
-			err = perrors.AppendError(err, perrors.Errorf("stmt.Close: %w", err))
+	c.lru.Init()
+	for _, entry := range statements {
+		if e := entry.stmt.Close(); e != nil {
+			err = perrors.AppendError(err, perrors.Errorf("stmt.Close: %w", e))
 		}
 	}
 
@@ -119,3 +282,89 @@ func (c *StatementCache) Close() (err error) {
 
 	return
 }
+
+// evict removes least recently used entries exceeding maxStatements
+//   - evicted: entries to close outside the lock
+//   - behind mLock
+func (c *StatementCache) evict() (evicted []*cacheEntry) {
+	if c.maxStatements == NoMaxStatements {
+		return // unbounded return
+	}
+	for len(c.m) > c.maxStatements {
+		var entry = c.lru.Back().Value.(*cacheEntry)
+		c.evictions.Add(1)
+		var isClosing = c.remove(entry)
+		if !isClosing {
+			// still acquired: closed on release
+			entry = &cacheEntry{query: entry.query}
+		}
+		evicted = append(evicted, entry)
+	}
+	return
+}
+
+// remove removes entry from the cache
+//   - isClosing: entry is not acquired and should be closed
+//   - behind mLock
+func (c *StatementCache) remove(entry *cacheEntry) (isClosing bool) {
+	delete(c.m, entry.query)
+	c.lru.Remove(entry.element)
+	entry.element = nil
+	return entry.refs == 0
+}
+
+// release ends an acquisition
+func (c *StatementCache) release(entry *cacheEntry) {
+	c.mLock.Lock()
+	entry.refs--
+	var isClosing = entry.refs == 0 && entry.element == nil && c.m != nil
+	c.mLock.Unlock()
+
+	if isClosing {
+		entry.close()
+	}
+}
+
+// end closes evicted statements and emits events outside the lock
+func (c *StatementCache) end(query string, eventsp *[]CacheEvent, evictedp *[]*cacheEntry) {
+	c.closeEvicted(*evictedp)
+	if c.onEvent == nil {
+		return
+	}
+	for _, event := range *eventsp {
+		c.onEvent(event, query)
+	}
+}
+
+// closeEvicted closes evicted statements and emits evict events
+func (c *StatementCache) closeEvicted(evicted []*cacheEntry) {
+	for _, entry := range evicted {
+		entry.close()
+		if c.onEvent != nil {
+			c.onEvent(CacheEvict, entry.query)
+		}
+	}
+}
+
+// close closes the statement of a removed entry
+//   - close errors are not actionable and are ignored
+func (e *cacheEntry) close() {
+	if e.stmt != nil {
+		e.stmt.Close()
+	}
+}
+
+// “hit” “miss” “evict” “invalidate”
+func (e CacheEvent) String() (s string) {
+	switch e {
+	case CacheHit:
+		return "hit"
+	case CacheMiss:
+		return "miss"
+	case CacheEvict:
+		return "evict"
+	case CacheInvalidate:
+		return "invalidate"
+	}
+	return fmt.Sprintf("?cacheEvent%d", e)
+}
@@ -20,3 +20,16 @@ type Stmt interface {
 type StmtWrapper interface {
 	WrapStmt(stmt *sql.Stmt) (stm Stmt)
 }
+
+// TxBeginner is a data source supporting transactions
+//   - implemented by [sql.DB]
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (tx *sql.Tx, err error)
+}
+
+// BusyDetector is a data source that can identify errors caused by
+// database contention that may succeed if retried
+//   - used to retry transactions of databases like SQLite3
+type BusyDetector interface {
+	IsBusy(err error) (isBusy bool)
+}
@@ -0,0 +1,243 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/psql/psql2"
+)
+
+const (
+	// RowsUnknown is the rows value provided to [QueryTracer] for
+	// [DBMap.Query]: the number of rows is unknown until the result set is read
+	RowsUnknown int64 = -1
+	// NoSlowQueryLog disables slow-query logging for [NewQueryStats]
+	NoSlowQueryLog time.Duration = 0
+	// max length of query in slow-query log and statistics printout
+	queryStatsMaxQuery = 60
+)
+
+// queryLatencyBuckets are upper bounds of latency-histogram buckets
+//   - a final bucket holds any greater latency
+var queryLatencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// QueryTracer observes every statement executed by [DBMap]
+//   - query: the SQL statement
+//   - partition: the partition, often [parl.NoPartition]
+//   - duration: the time the statement took to execute.
+//     For Query, the time to obtain the result set
+//   - rows: rows affected for Exec, 0 or 1 for single-row queries,
+//     [RowsUnknown] for Query
+//   - err: error from preparing or executing the statement
+//   - TraceQuery is invoked synchronously by the thread executing the statement
+//     and must be thread-safe
+type QueryTracer interface {
+	TraceQuery(query string, partition parl.DBPartition, duration time.Duration, rows int64, err error)
+}
+
+// StatementCacheTracer is optionally implemented by a [QueryTracer]
+// to observe prepared-statement cache events
+//   - event: [psql2.CacheHit] [psql2.CacheMiss] [psql2.CacheEvict] [psql2.CacheInvalidate]
+//   - dataSourceName: the data source whose cache had the event
+//   - invoked synchronously and must be thread-safe
+type StatementCacheTracer interface {
+	TraceStatementCache(event psql2.CacheEvent, query string, dataSourceName parl.DataSourceName)
+}
+
+// QueryStats is a [QueryTracer] aggregating per-statement
+// latency histograms and logging slow queries
+//   - [QueryStats.Stats] returns statistics by statement
+//   - [QueryStats.String] is a printable table
+//   - thread-safe
+type QueryStats struct {
+	// slowThreshold is latency at or above which statements are logged
+	//	- [NoSlowQueryLog] 0: no logging
+	slowThreshold time.Duration
+	// lock makes m thread-safe
+	lock sync.Mutex
+	// m is statistics by query
+	m map[string]*StatementStats
+}
+
+// StatementStats is latency statistics for one SQL statement
+type StatementStats struct {
+	Query string
+	// Count is the number of executions
+	Count uint64
+	// Errors is the number of executions that failed
+	Errors uint64
+	// Total is the accumulated latency
+	Total time.Duration
+	// Max is the greatest latency
+	Max time.Duration
+	// Histogram is execution counts by latency
+	//	- Histogram[i] counts latencies up to [StatementStats.Buckets][i]
+	//	- the final element counts latencies above the last bucket
+	Histogram []uint64
+	// CacheHits is the number of times a cached prepared statement was used
+	CacheHits uint64
+	// CacheMisses is the number of times the statement was prepared
+	CacheMisses uint64
+	// CacheEvictions is the number of times the statement was
+	// evicted or invalidated
+	CacheEvictions uint64
+}
+
+// QueryStats is a query tracer
+var _ QueryTracer = &QueryStats{}
+
+// QueryStats is a statement-cache tracer
+var _ StatementCacheTracer = &QueryStats{}
+
+// NewQueryStats returns a per-statement latency aggregator for [DBMap.SetTracer]
+//   - slowThreshold: statements with at least this latency are logged
+//     using [parl.Log]. [NoSlowQueryLog] 0: no slow-query logging
+func NewQueryStats(slowThreshold time.Duration) (queryStats *QueryStats) {
+	return &QueryStats{
+		slowThreshold: slowThreshold,
+		m:             make(map[string]*StatementStats),
+	}
+}
+
+// TraceQuery records a statement execution
+//   - thread-safe
+func (q *QueryStats) TraceQuery(query string, partition parl.DBPartition, duration time.Duration, rows int64, err error) {
+	if q.slowThreshold > 0 && duration >= q.slowThreshold {
+		var errS string
+		if err != nil {
+			errS = " error: " + perrors.Short(err)
+		}
+		parl.Log("psql slow query: %s partition: %q rows: %d: %s%s",
+			duration.Round(time.Microsecond), partition, rows, shortenQuery(query), errS,
+		)
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	var s = q.statementStats(query)
+	s.Count++
+	if err != nil {
+		s.Errors++
+	}
+	s.Total += duration
+	if duration > s.Max {
+		s.Max = duration
+	}
+	var i, _ = slices.BinarySearch(queryLatencyBuckets, duration)
+	s.Histogram[i]++
+}
+
+// TraceStatementCache records a prepared-statement cache event
+//   - thread-safe
+func (q *QueryStats) TraceStatementCache(event psql2.CacheEvent, query string, dataSourceName parl.DataSourceName) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	var s = q.statementStats(query)
+	switch event {
+	case psql2.CacheHit:
+		s.CacheHits++
+	case psql2.CacheMiss:
+		s.CacheMisses++
+	case psql2.CacheEvict, psql2.CacheInvalidate:
+		s.CacheEvictions++
+	}
+}
+
+// statementStats returns statistics for query, behind lock
+func (q *QueryStats) statementStats(query string) (s *StatementStats) {
+	if s = q.m[query]; s == nil {
+		s = &StatementStats{Query: query, Histogram: make([]uint64, len(queryLatencyBuckets)+1)}
+		q.m[query] = s
+	}
+	return
+}
+
+// Stats returns statistics by statement, greatest total latency first
+//   - thread-safe
+func (q *QueryStats) Stats() (stats []StatementStats) {
+	q.lock.Lock()
+	stats = make([]StatementStats, 0, len(q.m))
+	for _, s := range q.m {
+		var s2 = *s
+		s2.Histogram = slices.Clone(s.Histogram)
+		stats = append(stats, s2)
+	}
+	q.lock.Unlock()
+
+	slices.SortFunc(stats, func(a, b StatementStats) (result int) {
+		if a.Total != b.Total {
+			if a.Total > b.Total {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Query, b.Query)
+	})
+
+	return
+}
+
+// Reset discards all statistics
+func (q *QueryStats) Reset() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	clear(q.m)
+}
+
+// String is a printable table of statement latency statistics
+func (q *QueryStats) String() (s string) {
+	var stats = q.Stats()
+	var sL = []string{parl.Sprintf("statements: %d buckets: %s", len(stats), StatementStats{}.Buckets())}
+	for _, st := range stats {
+		sL = append(sL, st.String())
+	}
+	return strings.Join(sL, "\n")
+}
+
+// Buckets returns the upper bounds of histogram buckets
+func (StatementStats) Buckets() (buckets []time.Duration) { return slices.Clone(queryLatencyBuckets) }
+
+// Average is average latency
+func (s StatementStats) Average() (average time.Duration) {
+	if s.Count == 0 {
+		return
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// “count: 3 errors: 0 avg: 1ms max: 2ms [0 3 0 0 0 0 0] cache: 2/1/0 SELECT …”
+//   - cache is hits/misses/evictions
+func (s StatementStats) String() (s2 string) {
+	return parl.Sprintf("count: %d errors: %d avg: %s max: %s %v cache: %d/%d/%d %s",
+		s.Count, s.Errors,
+		s.Average().Round(time.Microsecond), s.Max.Round(time.Microsecond),
+		s.Histogram, s.CacheHits, s.CacheMisses, s.CacheEvictions, shortenQuery(s.Query),
+	)
+}
+
+// shortenQuery makes query a single line of limited length
+func shortenQuery(query string) (s string) {
+	s = strings.Join(strings.Fields(query), "\x20")
+	if len(s) > queryStatsMaxQuery {
+		s = s[:queryStatsMaxQuery] + "…"
+	}
+	return
+}
@@ -0,0 +1,66 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+func TestQueryStats(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		query1     = "SELECT 1"
+		query2     = "SELECT\n  2"
+		duration1  = 2 * time.Millisecond
+		duration2  = 50 * time.Microsecond
+		expCount   = 2
+		expBucket1 = 2
+		expStats   = 2
+	)
+	var (
+		err1       = errors.New("bad")
+		queryStats = NewQueryStats(NoSlowQueryLog)
+		stats      []StatementStats
+	)
+
+	queryStats.TraceQuery(query1, parl.NoPartition, duration1, 1, nil)
+	queryStats.TraceQuery(query1, parl.NoPartition, duration1, 0, err1)
+	queryStats.TraceQuery(query2, parl.NoPartition, duration2, RowsUnknown, nil)
+	t.Logf("QueryStats:\n%s", queryStats)
+
+	// query1 has greatest total and sorts first
+	stats = queryStats.Stats()
+	if len(stats) != expStats {
+		t.Fatalf("Stats len %d exp %d", len(stats), expStats)
+	}
+	var s = stats[0]
+	if s.Query != query1 {
+		t.Errorf("Query %q exp %q", s.Query, query1)
+	}
+	if s.Count != expCount {
+		t.Errorf("Count %d exp %d", s.Count, expCount)
+	}
+	if s.Errors != 1 {
+		t.Errorf("Errors %d exp 1", s.Errors)
+	}
+	if s.Max != duration1 || s.Average() != duration1 {
+		t.Errorf("Max %s Average %s exp %s", s.Max, s.Average(), duration1)
+	}
+	// 2 ms is in the 10 ms bucket
+	if s.Histogram[expBucket1] != expCount {
+		t.Errorf("Histogram %v exp %d at %d", s.Histogram, expCount, expBucket1)
+	}
+
+	// Reset
+	queryStats.Reset()
+	if len(queryStats.Stats()) != 0 {
+		t.Error("Reset failed")
+	}
+}
@@ -0,0 +1,231 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/psql/psql2"
+)
+
+const (
+	// ReadRoundRobin distributes reads evenly across available readers
+	ReadRoundRobin ReadSelection = iota
+	// ReadLeastLatency prefers the reader with lowest average latency
+	ReadLeastLatency
+)
+
+const (
+	// isReaderDSN: the data source is a read replica without schema initialization
+	isReaderDSN = true
+	// isWriterDSN: the data source is initialized by schema
+	isWriterDSN = false
+	// default duration a failed reader is skipped
+	defaultReaderRetry = 30 * time.Second
+	// weight of new sample in reader latency average: 1/8
+	latencyWeightShift = 3
+)
+
+// ReadSelection is how a reader is selected among read replicas
+//   - [ReadRoundRobin] [ReadLeastLatency]
+type ReadSelection uint8
+
+// ReaderDSNr is a [parl.DataSourceNamer] that also provides
+// read replicas of partitions
+//   - when the data source namer of [DBMap] implements ReaderDSNr,
+//     Query QueryRow QueryString QueryInt are routed to readers
+//   - Exec and transactions always use the writer from [parl.DataSourceNamer.DSN]
+type ReaderDSNr interface {
+	// ReaderDSNs returns data source names of read replicas of partition
+	//	- empty: reads use the writer
+	ReaderDSNs(partition ...parl.DBPartition) (dataSourceNames []parl.DataSourceName)
+}
+
+// ReplicaConfig configures read routing of [DBMap]
+type ReplicaConfig struct {
+	// Selection is how a reader is selected
+	//	- default [ReadRoundRobin]
+	Selection ReadSelection
+	// RetryAfter is how long a failed reader is skipped before
+	// it is used again
+	//	- 0: 30 s
+	RetryAfter time.Duration
+}
+
+// replicaRouter selects readers and tracks their health
+//   - initialization-free, thread-safe
+type replicaRouter struct {
+	config atomic.Pointer[ReplicaConfig]
+	// next is round-robin counter
+	next atomic.Uint64
+	// lock makes m thread-safe
+	lock sync.Mutex
+	// m is state by reader data source name, behind lock
+	m map[parl.DataSourceName]*readerState
+}
+
+// readerState is health and latency of a reader
+type readerState struct {
+	dataSourceName parl.DataSourceName
+	// downUntil is unix nanoseconds until which the reader is skipped
+	downUntil atomic.Int64
+	// latency is moving average in nanoseconds, 0: not measured
+	latency atomic.Int64
+}
+
+// SetReplicaConfig configures read routing
+//   - read routing is active when the data source namer
+//     implements [ReaderDSNr]
+//   - a reader failing is skipped for [ReplicaConfig.RetryAfter]
+//     with reads failing over to other readers and then the writer.
+//     The reader is automatically used again once that period has elapsed
+//   - thread-safe
+func (d *DBMap) SetReplicaConfig(config ReplicaConfig) { d.replicas.config.Store(&config) }
+
+// read executes a read statement on a reader of partition
+//   - readers are tried in order of selection, then the writer
+//   - a reader failing with other than no-rows or context error
+//     is marked down and the next reader is tried.
+//     A failure after ctx is canceled ends the read
+//   - without readers, the writer is used
+func (d *DBMap) read(
+	partition parl.DBPartition, query string, ctx context.Context,
+	readFn func(stmt psql2.Stmt) (err error),
+) (err error) {
+	if readerDSNr, ok := d.dsnr.(ReaderDSNr); ok {
+		for _, reader := range d.replicas.candidates(readerDSNr.ReaderDSNs(partition)) {
+			var t0 = time.Now()
+			err = d.readDSN(reader.dataSourceName, query, ctx, isReaderDSN, readFn)
+			// an expired context is not a reader failure
+			if err == nil || !isReaderFailure(err) || ctx.Err() != nil {
+				d.replicas.success(reader, time.Since(t0))
+				return // reader completed return
+			}
+			d.replicas.failure(reader)
+		}
+	}
+
+	// writer
+	err = d.readDSN(d.dsnr.DSN(partition), query, ctx, isWriterDSN, readFn)
+
+	return
+}
+
+// readDSN executes readFn using a cached statement of a data source
+func (d *DBMap) readDSN(
+	dataSourceName parl.DataSourceName, query string, ctx context.Context, isReader bool,
+	readFn func(stmt psql2.Stmt) (err error),
+) (err error) {
+	var stmt psql2.Stmt
+	var release func()
+	if stmt, release, err = d.getStmtDSN(dataSourceName, query, ctx, isReader); err != nil {
+		return
+	}
+	defer release()
+
+	return readFn(stmt)
+}
+
+// candidates returns readers of dataSourceNames that are not down in
+// order of selection
+func (r *replicaRouter) candidates(dataSourceNames []parl.DataSourceName) (readers []*readerState) {
+	if len(dataSourceNames) == 0 {
+		return // no readers return
+	}
+	var now = time.Now().UnixNano()
+	readers = make([]*readerState, 0, len(dataSourceNames))
+	r.lock.Lock()
+	if r.m == nil {
+		r.m = make(map[parl.DataSourceName]*readerState)
+	}
+	for _, dataSourceName := range dataSourceNames {
+		var reader = r.m[dataSourceName]
+		if reader == nil {
+			reader = &readerState{dataSourceName: dataSourceName}
+			r.m[dataSourceName] = reader
+		}
+		if reader.downUntil.Load() <= now {
+			readers = append(readers, reader)
+		}
+	}
+	r.lock.Unlock()
+	if len(readers) < 2 {
+		return // no ordering needed return
+	}
+
+	var config ReplicaConfig
+	if cp := r.config.Load(); cp != nil {
+		config = *cp
+	}
+	if config.Selection == ReadLeastLatency {
+		// unmeasured readers first so that they are measured
+		slices.SortStableFunc(readers, func(a, b *readerState) (result int) {
+			return cmp.Compare(a.latency.Load(), b.latency.Load())
+		})
+		return
+	}
+
+	// round-robin: rotate by counter
+	var start = int((r.next.Add(1) - 1) % uint64(len(readers)))
+	readers = append(readers[start:], readers[:start]...)
+
+	return
+}
+
+// success records latency of a completed read
+func (r *replicaRouter) success(reader *readerState, latency time.Duration) {
+	var sample = int64(latency)
+	if sample < 1 {
+		sample = 1
+	}
+	for {
+		var average = reader.latency.Load()
+		var newAverage = sample
+		if average != 0 {
+			newAverage = average + (sample-average)>>latencyWeightShift
+		}
+		if reader.latency.CompareAndSwap(average, newAverage) {
+			return
+		}
+	}
+}
+
+// failure marks a reader down
+func (r *replicaRouter) failure(reader *readerState) {
+	var retryAfter = defaultReaderRetry
+	if cp := r.config.Load(); cp != nil && cp.RetryAfter > 0 {
+		retryAfter = cp.RetryAfter
+	}
+	reader.downUntil.Store(time.Now().Add(retryAfter).UnixNano())
+}
+
+// isReaderFailure returns true if err may be remedied by another reader
+//   - no rows and context errors are not reader failures
+func isReaderFailure(err error) (isFailure bool) {
+	return !errors.Is(err, sql.ErrNoRows) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// "round-robin" "least-latency"
+func (s ReadSelection) String() (s2 string) {
+	switch s {
+	case ReadRoundRobin:
+		return "round-robin"
+	case ReadLeastLatency:
+		return "least-latency"
+	}
+	return fmt.Sprintf("?readSelection%d", s)
+}
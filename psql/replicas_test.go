@@ -0,0 +1,160 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestDBMapReplicas(t *testing.T) {
+	//t.Error("Logging on")
+	var partition = parl.DBPartition("2024")
+	var query = "SELECT n FROM t"
+	var ctx = context.Background()
+	var errReader = errors.New("reader down")
+
+	var err error
+	var value int
+
+	var dsnr = newReplicaTestDSNr(t, "w", "r1", "r2")
+	var schemaCount int
+	var dbMap = NewDBMap(dsnr, func(dataSource parl.DataSource, ctx context.Context) (err error) {
+		schemaCount++
+		return
+	})
+	var queryInt = func() (value int) {
+		t.Helper()
+		var err error
+		if value, _, err = dbMap.QueryInt(partition, query, parl.NoRowsError, ctx); err != nil {
+			t.Fatalf("QueryInt err: %s", perrors.Short(err))
+		}
+		return
+	}
+
+	// round-robin should alternate readers
+	dsnr.mocks["r1"].ExpectPrepare("SELECT").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	dsnr.mocks["r2"].ExpectPrepare("SELECT").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2))
+	if value = queryInt(); value != 1 {
+		t.Errorf("first read %d exp 1", value)
+	}
+	if value = queryInt(); value != 2 {
+		t.Errorf("second read %d exp 2", value)
+	}
+
+	// failing reader should fail over to next reader
+	dsnr.mocks["r1"].ExpectQuery("SELECT").WillReturnError(errReader)
+	dsnr.mocks["r2"].ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2))
+	if value = queryInt(); value != 2 {
+		t.Errorf("failover read %d exp 2", value)
+	}
+
+	// down reader should be skipped
+	dsnr.mocks["r2"].ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2))
+	if value = queryInt(); value != 2 {
+		t.Errorf("skip read %d exp 2", value)
+	}
+
+	// all readers down should read writer
+	dbMap.replicas.m["r2"].downUntil.Store(dbMap.replicas.m["r1"].downUntil.Load())
+	dsnr.mocks["w"].ExpectPrepare("SELECT").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(3))
+	if value = queryInt(); value != 3 {
+		t.Errorf("writer read %d exp 3", value)
+	}
+
+	// reader should be used again after retry period
+	dbMap.replicas.m["r1"].downUntil.Store(0)
+	dsnr.mocks["r1"].ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	if value = queryInt(); value != 1 {
+		t.Errorf("failback read %d exp 1", value)
+	}
+
+	// Exec should use writer
+	dsnr.mocks["w"].ExpectPrepare("INSERT").ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	if _, err = dbMap.Exec(partition, "INSERT INTO t VALUES (1)", ctx); err != nil {
+		t.Errorf("Exec err: %s", perrors.Short(err))
+	}
+
+	// schema should only be initialized for writer
+	if schemaCount != 1 {
+		t.Errorf("schema invocations %d exp 1", schemaCount)
+	}
+	for dsn, mock := range dsnr.mocks {
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: %s", dsn, err)
+		}
+	}
+}
+
+func TestDBMapReplicasLeastLatency(t *testing.T) {
+	var partition = parl.DBPartition("2024")
+	var query = "SELECT n FROM t"
+	var ctx = context.Background()
+
+	var dsnr = newReplicaTestDSNr(t, "w", "r1", "r2")
+	var dbMap = NewDBMap(dsnr, func(dataSource parl.DataSource, ctx context.Context) (err error) { return })
+	dbMap.SetReplicaConfig(ReplicaConfig{Selection: ReadLeastLatency})
+
+	// lower latency reader should be preferred
+	dbMap.replicas.candidates([]parl.DataSourceName{"r1", "r2"})
+	dbMap.replicas.m["r1"].latency.Store(1000)
+	dbMap.replicas.m["r2"].latency.Store(10)
+	dsnr.mocks["r2"].ExpectPrepare("SELECT").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2))
+	var value, _, err = dbMap.QueryInt(partition, query, parl.NoRowsError, ctx)
+	if err != nil {
+		t.Fatalf("QueryInt err: %s", perrors.Short(err))
+	} else if value != 2 {
+		t.Errorf("least-latency read %d exp 2", value)
+	}
+	if err = dsnr.mocks["r2"].ExpectationsWereMet(); err != nil {
+		t.Errorf("r2: %s", err)
+	}
+}
+
+// replicaTestDSNr is a [ReaderDSNr] with a sqlmock data source per name
+type replicaTestDSNr struct {
+	writer  parl.DataSourceName
+	readers []parl.DataSourceName
+	dbs     map[parl.DataSourceName]*sql.DB
+	mocks   map[parl.DataSourceName]sqlmock.Sqlmock
+}
+
+// newReplicaTestDSNr returns a data source namer for writer and readers
+func newReplicaTestDSNr(t *testing.T, writer parl.DataSourceName, readers ...parl.DataSourceName) (dsnr *replicaTestDSNr) {
+	dsnr = &replicaTestDSNr{
+		writer:  writer,
+		readers: readers,
+		dbs:     make(map[parl.DataSourceName]*sql.DB),
+		mocks:   make(map[parl.DataSourceName]sqlmock.Sqlmock),
+	}
+	for _, dsn := range append([]parl.DataSourceName{writer}, readers...) {
+		var db, mock, err = sqlmock.New()
+		if err != nil {
+			t.Fatalf("sqlmock.New: %s", err)
+		}
+		dsnr.dbs[dsn] = db
+		dsnr.mocks[dsn] = mock
+	}
+	return
+}
+
+func (n *replicaTestDSNr) DSN(partition ...parl.DBPartition) (dataSourceName parl.DataSourceName) {
+	return n.writer
+}
+
+func (n *replicaTestDSNr) ReaderDSNs(partition ...parl.DBPartition) (dataSourceNames []parl.DataSourceName) {
+	return n.readers
+}
+
+func (n *replicaTestDSNr) DataSource(dsn parl.DataSourceName) (dataSource parl.DataSource, err error) {
+	return n.dbs[dsn], nil
+}
@@ -0,0 +1,364 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// DiffMissing: the item is in the expected schema but not in the actual
+	DiffMissing DiffKind = iota + 1
+	// DiffExtra: the item is in the actual schema but not in the expected
+	DiffExtra
+	// DiffChanged: the item differs
+	DiffChanged
+)
+
+// DiffKind is the type of a [SchemaDiff]
+//   - [DiffMissing] [DiffExtra] [DiffChanged]
+type DiffKind uint8
+
+// Schema is a driver-neutral description of the tables of a partition
+//   - returned by [DBMap.Schema]
+//   - may be declared as a literal to be compared using [DiffSchema]
+type Schema struct {
+	// Tables in name order
+	Tables []Table
+}
+
+// Table describes a table
+type Table struct {
+	Name string
+	// Columns in table order
+	Columns []Column
+	// Indexes in name order
+	Indexes []Index
+	// ForeignKeys in declaration order
+	ForeignKeys []ForeignKey
+}
+
+// Column describes a column using [sql.ColumnType]
+type Column struct {
+	Name string
+	// Type is database type name “INTEGER” “TEXT” “VARCHAR”,
+	// upper-case, possibly empty
+	Type string
+	// Nullable is true if the column may be NULL and the
+	// driver supports nullability
+	Nullable bool
+}
+
+// Index describes an index
+type Index struct {
+	Name string
+	// Columns are indexed columns in index order
+	Columns []string
+	Unique  bool
+}
+
+// ForeignKey describes a foreign-key constraint
+type ForeignKey struct {
+	// Column is the referencing column
+	Column string
+	// RefTable is the referenced table
+	RefTable string
+	// RefColumn is the referenced column
+	RefColumn string
+}
+
+// SchemaDialect provides database-specific introspection queries
+//   - when the data source namer of [DBMap] implements SchemaDialect,
+//     it is used by [DBMap.Schema], otherwise [SQLiteDialect]
+type SchemaDialect interface {
+	// TablesQuery returns a query whose rows are table names
+	TablesQuery() (query string)
+	// IndexesQuery returns a query for indexes of table whose rows are:
+	// index name, column name, unique as integer 0 or 1,
+	// ordered by index name and column order
+	IndexesQuery(table string) (query string, args []any)
+	// ForeignKeysQuery returns a query for foreign keys of table whose rows are:
+	// column, referenced table, referenced column
+	ForeignKeysQuery(table string) (query string, args []any)
+	// QuoteIdentifier returns identifier quoted for use in SQL
+	QuoteIdentifier(identifier string) (quoted string)
+}
+
+// SQLiteDialect is [SchemaDialect] for SQLite3
+type SQLiteDialect struct{}
+
+var _ SchemaDialect = SQLiteDialect{}
+
+// TablesQuery returns user tables in name order
+func (SQLiteDialect) TablesQuery() (query string) {
+	return `SELECT name FROM sqlite_master
+WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+ORDER BY name`
+}
+
+// IndexesQuery returns explicit and automatic indexes of table
+func (SQLiteDialect) IndexesQuery(table string) (query string, args []any) {
+	query = `SELECT il.name, ii.name, il."unique"
+FROM pragma_index_list(?) AS il JOIN pragma_index_info(il.name) AS ii
+ORDER BY il.name, ii.seqno`
+	args = []any{table}
+	return
+}
+
+// ForeignKeysQuery returns foreign keys of table
+func (SQLiteDialect) ForeignKeysQuery(table string) (query string, args []any) {
+	query = `SELECT "from", "table", "to" FROM pragma_foreign_key_list(?)
+ORDER BY id, seq`
+	args = []any{table}
+	return
+}
+
+// QuoteIdentifier quotes identifier using double quotes
+func (SQLiteDialect) QuoteIdentifier(identifier string) (quoted string) {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// Schema returns tables, columns, indexes and foreign keys of partition
+//   - queries are provided by [SchemaDialect]
+//   - column types are obtained from [sql.ColumnType] of an empty result set
+func (d *DBMap) Schema(partition parl.DBPartition, ctx context.Context) (schema Schema, err error) {
+	var dialect SchemaDialect = SQLiteDialect{}
+	if sd, ok := d.dsnr.(SchemaDialect); ok {
+		dialect = sd
+	}
+
+	var tableNames []string
+	if err = d.schemaQuery(partition, ctx, dialect.TablesQuery(), nil, func(rows *sql.Rows) (err error) {
+		var name string
+		if err = rows.Scan(&name); err == nil {
+			tableNames = append(tableNames, name)
+		}
+		return
+	}); err != nil {
+		return
+	}
+
+	schema.Tables = make([]Table, len(tableNames))
+	for i, name := range tableNames {
+		if schema.Tables[i], err = d.table(partition, ctx, dialect, name); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// table introspects a single table
+func (d *DBMap) table(partition parl.DBPartition, ctx context.Context, dialect SchemaDialect, name string) (table Table, err error) {
+	table.Name = name
+
+	// columns from an empty result set
+	var sqlRows *sql.Rows
+	var query = "SELECT * FROM " + dialect.QuoteIdentifier(name) + " WHERE 1 = 0"
+	if sqlRows, err = d.Query(partition, query, ctx); err != nil {
+		return
+	}
+	var columnTypes []*sql.ColumnType
+	columnTypes, err = sqlRows.ColumnTypes()
+	if e := sqlRows.Close(); err == nil {
+		err = e
+	}
+	if perrors.IsPF(&err, "table %q columns: %w", name, err) {
+		return
+	}
+	table.Columns = make([]Column, len(columnTypes))
+	for i, columnType := range columnTypes {
+		var nullable, _ = columnType.Nullable()
+		table.Columns[i] = Column{
+			Name:     columnType.Name(),
+			Type:     strings.ToUpper(columnType.DatabaseTypeName()),
+			Nullable: nullable,
+		}
+	}
+
+	// indexes: one row per indexed column
+	query, args := dialect.IndexesQuery(name)
+	if err = d.schemaQuery(partition, ctx, query, args, func(rows *sql.Rows) (err error) {
+		var indexName, column string
+		var unique int
+		if err = rows.Scan(&indexName, &column, &unique); err != nil {
+			return
+		}
+		if n := len(table.Indexes); n == 0 || table.Indexes[n-1].Name != indexName {
+			table.Indexes = append(table.Indexes, Index{Name: indexName, Unique: unique != 0})
+		}
+		var index = &table.Indexes[len(table.Indexes)-1]
+		index.Columns = append(index.Columns, column)
+		return
+	}); err != nil {
+		return
+	}
+
+	// foreign keys
+	query, args = dialect.ForeignKeysQuery(name)
+	err = d.schemaQuery(partition, ctx, query, args, func(rows *sql.Rows) (err error) {
+		var foreignKey ForeignKey
+		if err = rows.Scan(&foreignKey.Column, &foreignKey.RefTable, &foreignKey.RefColumn); err == nil {
+			table.ForeignKeys = append(table.ForeignKeys, foreignKey)
+		}
+		return
+	})
+
+	return
+}
+
+// schemaQuery executes query invoking rowFn for each row
+func (d *DBMap) schemaQuery(
+	partition parl.DBPartition, ctx context.Context, query string, args []any,
+	rowFn func(rows *sql.Rows) (err error),
+) (err error) {
+	var sqlRows *sql.Rows
+	if sqlRows, err = d.Query(partition, query, ctx, args...); err != nil {
+		return
+	}
+	defer parl.Close(sqlRows, &err)
+
+	for sqlRows.Next() {
+		if err = rowFn(sqlRows); perrors.IsPF(&err, "schema scan: %w", err) {
+			return
+		}
+	}
+	if err = sqlRows.Err(); perrors.IsPF(&err, "schema rows: %w", err) {
+		return
+	}
+
+	return
+}
+
+// SchemaDiff is a difference between two schemas found by [DiffSchema]
+type SchemaDiff struct {
+	Kind DiffKind
+	// Table is the table name
+	Table string
+	// Item is empty for a table, otherwise “column a” “index i” “foreign key a”
+	Item string
+	// Expected and Actual describe a changed item
+	Expected, Actual string
+}
+
+// DiffSchema compares actual to expected
+//   - expected is a schema of another partition or a declared schema
+//   - diffs: empty if the schemas are equal
+//   - table names, column order within tables and index names are
+//     significant
+func DiffSchema(expected, actual Schema) (diffs []SchemaDiff) {
+	var actualTables = make(map[string]*Table, len(actual.Tables))
+	for i := range actual.Tables {
+		actualTables[actual.Tables[i].Name] = &actual.Tables[i]
+	}
+	var expectedTables = make(map[string]bool, len(expected.Tables))
+	for i := range expected.Tables {
+		var exp = &expected.Tables[i]
+		expectedTables[exp.Name] = true
+		if act := actualTables[exp.Name]; act != nil {
+			diffs = append(diffs, diffTable(exp, act)...)
+		} else {
+			diffs = append(diffs, SchemaDiff{Kind: DiffMissing, Table: exp.Name})
+		}
+	}
+	for i := range actual.Tables {
+		if name := actual.Tables[i].Name; !expectedTables[name] {
+			diffs = append(diffs, SchemaDiff{Kind: DiffExtra, Table: name})
+		}
+	}
+
+	return
+}
+
+// diffTable compares two tables of the same name
+func diffTable(expected, actual *Table) (diffs []SchemaDiff) {
+	var items = func(table *Table) (m map[string]string, order []string) {
+		m = make(map[string]string)
+		for _, c := range table.Columns {
+			var key = "column " + c.Name
+			m[key], order = c.String(), append(order, key)
+		}
+		for _, index := range table.Indexes {
+			var key = "index " + index.Name
+			m[key], order = index.String(), append(order, key)
+		}
+		for _, foreignKey := range table.ForeignKeys {
+			var key = "foreign key " + foreignKey.Column
+			m[key], order = foreignKey.String(), append(order, key)
+		}
+		return
+	}
+	var expMap, expOrder = items(expected)
+	var actMap, actOrder = items(actual)
+	for _, key := range expOrder {
+		if act, ok := actMap[key]; !ok {
+			diffs = append(diffs, SchemaDiff{Kind: DiffMissing, Table: expected.Name, Item: key})
+		} else if exp := expMap[key]; act != exp {
+			diffs = append(diffs, SchemaDiff{Kind: DiffChanged, Table: expected.Name, Item: key, Expected: exp, Actual: act})
+		}
+	}
+	for _, key := range actOrder {
+		if _, ok := expMap[key]; !ok {
+			diffs = append(diffs, SchemaDiff{Kind: DiffExtra, Table: expected.Name, Item: key})
+		}
+	}
+
+	return
+}
+
+// “a INTEGER NULL”
+func (c Column) String() (s string) {
+	s = c.Name + " " + c.Type
+	if c.Nullable {
+		s += " NULL"
+	}
+	return
+}
+
+// “UNIQUE (a, b)”
+func (i Index) String() (s string) {
+	s = "(" + strings.Join(i.Columns, ", ") + ")"
+	if i.Unique {
+		s = "UNIQUE " + s
+	}
+	return
+}
+
+// “a REFERENCES t(b)”
+func (f ForeignKey) String() (s string) {
+	return f.Column + " REFERENCES " + f.RefTable + "(" + f.RefColumn + ")"
+}
+
+// “missing table t” “changed t column a: a INTEGER != a TEXT”
+func (d SchemaDiff) String() (s string) {
+	if d.Item == "" {
+		return fmt.Sprintf("%s table %s", d.Kind, d.Table)
+	}
+	s = fmt.Sprintf("%s %s %s", d.Kind, d.Table, d.Item)
+	if d.Kind == DiffChanged {
+		s += fmt.Sprintf(": %s != %s", d.Expected, d.Actual)
+	}
+	return
+}
+
+// “missing” “extra” “changed”
+func (k DiffKind) String() (s string) {
+	switch k {
+	case DiffMissing:
+		return "missing"
+	case DiffExtra:
+		return "extra"
+	case DiffChanged:
+		return "changed"
+	}
+	return fmt.Sprintf("?diffKind%d", k)
+}
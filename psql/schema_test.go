@@ -0,0 +1,57 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffSchema(t *testing.T) {
+	//t.Error("Logging on")
+	var expected = Schema{Tables: []Table{
+		{
+			Name:        "a",
+			Columns:     []Column{{Name: "id", Type: "INTEGER"}, {Name: "name", Type: "TEXT", Nullable: true}},
+			Indexes:     []Index{{Name: "a_name", Columns: []string{"name"}, Unique: true}},
+			ForeignKeys: []ForeignKey{{Column: "id", RefTable: "b", RefColumn: "id"}},
+		},
+		{Name: "b", Columns: []Column{{Name: "id", Type: "INTEGER"}}},
+	}}
+	var actual = Schema{Tables: []Table{
+		{
+			Name:    "a",
+			Columns: []Column{{Name: "id", Type: "INTEGER"}, {Name: "name", Type: "VARCHAR", Nullable: true}, {Name: "x", Type: "TEXT"}},
+			Indexes: []Index{{Name: "a_name", Columns: []string{"name"}, Unique: true}},
+		},
+		{Name: "c"},
+	}}
+	var expDiffs = []string{
+		"changed a column name: name TEXT NULL != name VARCHAR NULL",
+		"missing a foreign key id",
+		"extra a column x",
+		"missing table b",
+		"extra table c",
+	}
+
+	if diffs := DiffSchema(expected, expected); len(diffs) != 0 {
+		t.Errorf("equal diffs: %v", diffs)
+	}
+	var diffs = DiffSchema(expected, actual)
+	var sL = make([]string, len(diffs))
+	for i, diff := range diffs {
+		sL[i] = diff.String()
+	}
+	if !slices.Equal(sL, expDiffs) {
+		t.Errorf("diffs:\n%q exp\n%q", sL, expDiffs)
+	}
+}
+
+func TestSQLiteDialect(t *testing.T) {
+	if s := (SQLiteDialect{}).QuoteIdentifier(`a"b`); s != `"a""b"` {
+		t.Errorf("QuoteIdentifier %s", s)
+	}
+}
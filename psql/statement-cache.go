@@ -0,0 +1,81 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/psql/psql2"
+)
+
+// SetMaxStatements bounds the prepared-statement cache of
+// each data source
+//   - [psql2.NoMaxStatements] 0: unbounded, the default
+//   - once reached, the least recently used statement is closed,
+//     preventing long-lived services from exhausting
+//     driver statement handles
+//   - applies to existing and future data sources
+//   - thread-safe
+func (d *DBMap) SetMaxStatements(maxStatements int) {
+	d.maxStatements.Store(int64(max(maxStatements, 0)))
+	for _, cache := range d.caches() {
+		cache.SetMaxStatements(maxStatements)
+	}
+}
+
+// Invalidate removes query from the statement caches of all data sources
+//   - used after schema changes that invalidate prepared statements
+//   - the statement is prepared again on next use
+//   - thread-safe
+func (d *DBMap) Invalidate(query string) {
+	for _, cache := range d.caches() {
+		cache.Invalidate(query)
+	}
+}
+
+// StatementCacheMetrics returns the sum of statement-cache
+// counters of all data sources
+//   - per-statement hits, misses and evictions are provided
+//     to a tracer implementing [StatementCacheTracer]
+//   - thread-safe
+func (d *DBMap) StatementCacheMetrics() (metrics psql2.StatementCacheMetrics) {
+	for _, cache := range d.caches() {
+		var m = cache.Metrics()
+		metrics.Hits += m.Hits
+		metrics.Misses += m.Misses
+		metrics.Evictions += m.Evictions
+		metrics.Invalidations += m.Invalidations
+		metrics.Size += m.Size
+	}
+	return
+}
+
+// caches returns the current statement caches
+func (d *DBMap) caches() (caches []*psql2.StatementCache) {
+	d.stateLock.Lock()
+	defer d.stateLock.Unlock()
+
+	caches = make([]*psql2.StatementCache, 0, len(d.m))
+	for _, cache := range d.m {
+		caches = append(caches, cache)
+	}
+	return
+}
+
+// cacheConfig returns configuration for a new statement cache
+func (d *DBMap) cacheConfig(dataSourceName parl.DataSourceName) (config psql2.StatementCacheConfig) {
+	return psql2.StatementCacheConfig{
+		MaxStatements: int(d.maxStatements.Load()),
+		OnEvent: func(event psql2.CacheEvent, query string) {
+			var tracer = d.tracer.Load()
+			if tracer == nil {
+				return // tracing off return
+			}
+			if cacheTracer, ok := (*tracer).(StatementCacheTracer); ok {
+				cacheTracer.TraceStatementCache(event, query, dataSourceName)
+			}
+		},
+	}
+}
@@ -0,0 +1,76 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/psql/psql2"
+)
+
+func TestDBMapStatementCache(t *testing.T) {
+	//t.Error("Logging on")
+	var partition = parl.DBPartition("2024")
+	var queryA = "SELECT a FROM t"
+	var queryB = "SELECT b FROM t"
+	var ctx = context.Background()
+	var expMetrics = psql2.StatementCacheMetrics{Hits: 1, Misses: 4, Evictions: 2, Invalidations: 2, Size: 0}
+
+	var err error
+
+	var dsnr = newReplicaTestDSNr(t, "w")
+	var mock = dsnr.mocks["w"]
+	var dbMap = NewDBMap(dsnr, func(dataSource parl.DataSource, ctx context.Context) (err error) { return })
+	var queryStats = NewQueryStats(NoSlowQueryLog)
+	dbMap.SetTracer(queryStats)
+	dbMap.SetMaxStatements(1)
+	var queryInt = func(query string) {
+		t.Helper()
+		if _, _, err = dbMap.QueryInt(partition, query, parl.NoRowsError, ctx); err != nil {
+			t.Fatalf("QueryInt err: %s", perrors.Short(err))
+		}
+	}
+
+	// A miss, A hit, B miss evicting A, A miss evicting B
+	mock.ExpectPrepare("SELECT a").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1))
+	mock.ExpectQuery("SELECT a").WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1))
+	mock.ExpectPrepare("SELECT b").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"b"}).AddRow(2))
+	mock.ExpectPrepare("SELECT a").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1))
+	queryInt(queryA)
+	queryInt(queryA)
+	queryInt(queryB)
+	queryInt(queryA)
+
+	// Invalidate causes prepare
+	dbMap.Invalidate(queryA)
+	mock.ExpectPrepare("SELECT a").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1))
+	queryInt(queryA)
+	dbMap.Invalidate(queryA)
+
+	if m := dbMap.StatementCacheMetrics(); m != expMetrics {
+		t.Errorf("metrics %+v exp %+v", m, expMetrics)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// tracer should have received cache events
+	var statsA StatementStats
+	for _, s := range queryStats.Stats() {
+		if s.Query == queryA {
+			statsA = s
+		}
+	}
+	t.Logf("QueryStats:\n%s", queryStats)
+	if statsA.CacheHits != 1 || statsA.CacheMisses != 3 || statsA.CacheEvictions != 3 {
+		t.Errorf("cache hits/misses/evictions %d/%d/%d exp 1/3/3",
+			statsA.CacheHits, statsA.CacheMisses, statsA.CacheEvictions)
+	}
+}
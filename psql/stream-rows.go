@@ -0,0 +1,180 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// default number of rows sent to the consumer at a time
+	defaultStreamBatchSize = 100
+	// default period for checking whether the consumer drained values
+	defaultStreamPollInterval = time.Millisecond
+)
+
+// StreamConfig configures [StreamRows]
+type StreamConfig struct {
+	// BatchSize is the number of rows sent to values at a time
+	//	- 0: 100
+	BatchSize int
+	// BackPressure limits memory use by awaiting the consumer
+	//	- false: rows are sent without awaiting the consumer
+	//	- true: a batch is only sent once values is empty,
+	//		ie. at most BatchSize rows are pending.
+	//		Memory use is about two batches for result sets of any size
+	BackPressure bool
+	// PollInterval is how often BackPressure checks whether values was drained
+	//	- 0: 1 ms
+	PollInterval time.Duration
+}
+
+// StreamRows executes query and sends scanned rows to values
+//   - scanFunc invokes [sql.Rows.Scan] and returns the record
+//   - values: rows are sent in batches using [parl.AwaitableSlice.SendSlice]
+//     allowing a consumer to process a large result-set incrementally.
+//     values is closed on return by [parl.AwaitableSlice.EmptyCh]
+//   - config: optional. nil: no back-pressure and 100-row batches
+//   - rows: the number of rows sent to values
+//   - err: query, scan or result-set error.
+//     Cancel of ctx ends streaming early and returns ctx’s error
+//   - works with any [parl.DB] including sqlite databases of the sqliter package
+//   - StreamRows blocks, execute in a separate goroutine
+//
+// Usage:
+//
+//	var records parl.AwaitableSlice[Record]
+//	go func() {
+//	  var _, err = psql.StreamRows(ctx, db, parl.NoPartition, query, scanRecord, &records, &psql.StreamConfig{BackPressure: true})
+//	  …
+//	}()
+//	for record := records.Init(); records.Condition(&record); {
+//	  …
+func StreamRows[T any](
+	ctx context.Context, db parl.DB, partition parl.DBPartition, query string,
+	scanFunc ScanFunc[T], values *parl.AwaitableSlice[T], config *StreamConfig,
+	args ...any,
+) (rows int64, err error) {
+	if db == nil {
+		panic(parl.NilError("db"))
+	} else if scanFunc == nil {
+		panic(parl.NilError("scanFunc"))
+	} else if values == nil {
+		panic(parl.NilError("values"))
+	}
+	defer values.EmptyCh()
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+	var s = newRowStreamer(ctx, values, config)
+
+	var sqlRows *sql.Rows
+	if sqlRows, err = db.Query(partition, query, ctx, args...); perrors.IsPF(&err, "query: %w", err) {
+		return // query failed return
+	}
+	defer parl.Close(sqlRows, &err)
+
+	var batch = make([]T, 0, s.batchSize)
+	for sqlRows.Next() {
+		var t T
+		if t, err = scanFunc(sqlRows); perrors.IsPF(&err, "scan row %d: %w", rows, err) {
+			return // scan failed return
+		}
+		if batch = append(batch, t); len(batch) < s.batchSize {
+			continue
+		}
+		if err = s.send(batch); err != nil {
+			return // context canceled return
+		}
+		rows += int64(len(batch))
+		batch = make([]T, 0, s.batchSize)
+	}
+	if err = sqlRows.Err(); perrors.IsPF(&err, "rows: %w", err) {
+		return // result-set error return
+	}
+	if len(batch) > 0 {
+		if err = s.send(batch); err != nil {
+			return // context canceled return
+		}
+		rows += int64(len(batch))
+	}
+
+	return
+}
+
+// rowStreamer sends batches to an awaitable slice
+type rowStreamer[T any] struct {
+	ctx          context.Context
+	values       *parl.AwaitableSlice[T]
+	batchSize    int
+	backPressure bool
+	pollInterval time.Duration
+}
+
+// newRowStreamer returns a streamer with defaults applied
+func newRowStreamer[T any](ctx context.Context, values *parl.AwaitableSlice[T], config *StreamConfig) (s *rowStreamer[T]) {
+	s = &rowStreamer[T]{
+		ctx:          ctx,
+		values:       values,
+		batchSize:    defaultStreamBatchSize,
+		pollInterval: defaultStreamPollInterval,
+	}
+	if config == nil {
+		return
+	}
+	if config.BatchSize > 0 {
+		s.batchSize = config.BatchSize
+	}
+	s.backPressure = config.BackPressure
+	if config.PollInterval > 0 {
+		s.pollInterval = config.PollInterval
+	}
+
+	return
+}
+
+// send sends batch once the consumer drained values
+//   - err: ctx was canceled
+func (s *rowStreamer[T]) send(batch []T) (err error) {
+	if s.backPressure {
+		if err = s.awaitDrain(); err != nil {
+			return
+		}
+	}
+	if s.ctx.Err() != nil {
+		err = perrors.ErrorfPF("%w", context.Cause(s.ctx))
+		return
+	}
+	s.values.SendSlice(batch)
+
+	return
+}
+
+// awaitDrain waits until values is empty
+//   - values is empty when [parl.AwaitableSlice.DataWaitCh] is open
+func (s *rowStreamer[T]) awaitDrain() (err error) {
+	var ticker *time.Ticker
+	for {
+		select {
+		case <-s.values.DataWaitCh():
+		default:
+			return // values is empty return
+		}
+		if ticker == nil {
+			ticker = time.NewTicker(s.pollInterval)
+			defer ticker.Stop()
+		}
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			err = perrors.ErrorfPF("%w", context.Cause(s.ctx))
+			return // context canceled return
+		}
+	}
+}
@@ -0,0 +1,67 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestStreamRows(t *testing.T) {
+	//t.Error("Logging on")
+	const partition parl.DBPartition = "2024"
+	var (
+		expValues = []int{1, 2, 3, 4, 5}
+		db        = &mergeTestDB{values: map[parl.DBPartition][]int{partition: expValues}}
+		config    = StreamConfig{BatchSize: 2, BackPressure: true}
+		values    parl.AwaitableSlice[int]
+		received  []int
+		rows      int64
+		err       error
+	)
+
+	// back-pressure: consumer in a separate thread
+	var done = make(chan struct{})
+	go func() {
+		defer close(done)
+		rows, err = StreamRows(context.Background(), db, partition, queryName, scanMergeTest, &values, &config)
+	}()
+	for value := values.Init(); values.Condition(&value); {
+		received = append(received, value)
+	}
+	<-done
+	if err != nil {
+		t.Errorf("StreamRows err: %s", perrors.Short(err))
+	}
+	if rows != int64(len(expValues)) {
+		t.Errorf("rows %d exp %d", rows, len(expValues))
+	}
+	if !slices.Equal(received, expValues) {
+		t.Errorf("values %v exp %v", received, expValues)
+	}
+
+	// canceled context
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	var values2 parl.AwaitableSlice[int]
+	if _, err = StreamRows(ctx, db, partition, queryName, scanMergeTest, &values2, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("StreamRows err %v exp %v", err, context.Canceled)
+	}
+	if !values2.IsClosed() {
+		t.Error("values not closed")
+	}
+
+	// query error
+	var values3 parl.AwaitableSlice[int]
+	if _, err = StreamRows(context.Background(), db, "bad", queryName, scanMergeTest, &values3, nil); !errors.Is(err, errMergeTest) {
+		t.Errorf("StreamRows err %v exp %v", err, errMergeTest)
+	}
+}
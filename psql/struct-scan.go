@@ -0,0 +1,162 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// StructTag is the struct tag naming the column of a field “db”
+	//	- `db:"created_at"`
+	//	- `db:"-"`: field is not scanned
+	StructTag = "db"
+)
+
+// structFieldsCache is per-type field index of [NewStructScanFunc]
+//   - key: reflect.Type, value: *structFields
+var structFieldsCache sync.Map
+
+// structFields maps lower-case column names to field index paths of a struct type
+type structFields struct {
+	typeName string
+	fields   map[string][]int
+}
+
+// structScanner scans result-set rows into fields of a struct T
+type structScanner[T any] struct {
+	fields *structFields
+	// indexes are field index paths in result-set column order
+	//	- resolved on first row
+	indexes [][]int
+	// dest are scan destinations, reused for each row
+	dest []any
+}
+
+// NewStructScanFunc returns a [ScanFunc] scanning rows into a struct T
+// for [NewResultSetIterator]
+//   - columns are matched against tag “db” or, if untagged,
+//     the field name case-insensitively
+//   - fields of embedded structs are promoted
+//   - nullable columns scan into pointer fields: nil for NULL,
+//     or sql.Null types such as [sql.NullString] [sql.NullTime]
+//   - a result-set column without a field is an error
+//   - the field index of T is reflected once per type and cached
+//   - the returned scanFunc maps columns on its first row:
+//     use a new scanFunc for each result-set
+//   - panics if T is not a struct
+//
+// Usage:
+//
+//	type User struct {
+//	  ID      int64          `db:"id"`
+//	  Name    string         `db:"name"`
+//	  Email   *string        `db:"email"`
+//	  Deleted sql.NullTime   `db:"deleted_at"`
+//	}
+//	…
+//	iterator = psql.NewResultSetIterator(sqlRows, psql.NewStructScanFunc[User]())
+func NewStructScanFunc[T any]() (scanFunc ScanFunc[T]) {
+	var s = structScanner[T]{
+		fields: getStructFields(reflect.TypeOf((*T)(nil)).Elem()),
+	}
+	return s.scan
+}
+
+// scan scans the current row into a new T
+func (s *structScanner[T]) scan(sqlRows *sql.Rows) (t T, err error) {
+	if s.indexes == nil {
+		if err = s.mapColumns(sqlRows); err != nil {
+			return
+		}
+	}
+	var value = reflect.ValueOf(&t).Elem()
+	for i, index := range s.indexes {
+		s.dest[i] = value.FieldByIndex(index).Addr().Interface()
+	}
+	if err = sqlRows.Scan(s.dest...); perrors.IsPF(&err, "Scan %s: %w", s.fields.typeName, err) {
+		return
+	}
+	// do not retain pointers into t
+	clear(s.dest)
+
+	return
+}
+
+// mapColumns resolves result-set columns to field index paths
+func (s *structScanner[T]) mapColumns(sqlRows *sql.Rows) (err error) {
+	var columns []string
+	if columns, err = sqlRows.Columns(); perrors.IsPF(&err, "Columns %w", err) {
+		return
+	}
+	var indexes = make([][]int, len(columns))
+	for i, column := range columns {
+		var index, ok = s.fields.fields[strings.ToLower(column)]
+		if !ok {
+			err = perrors.ErrorfPF("column %q has no field in %s", column, s.fields.typeName)
+			return
+		}
+		indexes[i] = index
+	}
+	s.indexes = indexes
+	s.dest = make([]any, len(columns))
+
+	return
+}
+
+// getStructFields returns the cached field index of struct type t
+func getStructFields(t reflect.Type) (fields *structFields) {
+	if v, ok := structFieldsCache.Load(t); ok {
+		return v.(*structFields)
+	} else if t.Kind() != reflect.Struct {
+		panic(perrors.ErrorfPF("type must be struct: %s", t))
+	}
+	fields = &structFields{
+		typeName: t.String(),
+		fields:   make(map[string][]int),
+	}
+	addStructFields(t, nil, fields.fields)
+	var v, _ = structFieldsCache.LoadOrStore(t, fields)
+
+	return v.(*structFields)
+}
+
+// addStructFields adds the fields of struct type t to fields
+//   - index is the index path of t within the scanned struct
+//   - fields of t take precedence over fields of embedded structs
+func addStructFields(t reflect.Type, index []int, fields map[string][]int) {
+	// embedded structs are processed after outer fields
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		var field = t.Field(i)
+		var tag, hasTag = field.Tag.Lookup(StructTag)
+		if tag == "-" {
+			continue
+		} else if field.Anonymous && !hasTag && field.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, field)
+			continue
+		} else if !field.IsExported() {
+			continue
+		}
+		var name = tag
+		if name == "" {
+			name = field.Name
+		}
+		name = strings.ToLower(name)
+		if _, exists := fields[name]; exists {
+			continue
+		}
+		fields[name] = append(append([]int(nil), index...), i)
+	}
+	for _, field := range embedded {
+		addStructFields(field.Type, append(append([]int(nil), index...), field.Index...), fields)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/haraldrudell/parl/iters"
+)
+
+// scanBase is embedded in scanRow
+type scanBase struct {
+	ID int64 `db:"id"`
+}
+
+type scanRow struct {
+	scanBase
+	Name    string
+	Email   *string        `db:"email"`
+	Comment sql.NullString `db:"comment"`
+	Ignored string         `db:"-"`
+}
+
+func TestNewStructScanFunc(t *testing.T) {
+	//t.Error("Logging on")
+	var m = newMockDB()
+	m.sqlMock.ExpectQuery(queryName).WillReturnRows(
+		m.sqlMock.NewRows([]string{"id", "NAME", "email", "comment"}).
+			AddRow(int64(1), "a", "a@b", nil).
+			AddRow(int64(2), "b", nil, "c"),
+	)
+	var sqlRows, err = m.mockDb.Query(queryName)
+	if err != nil {
+		t.Fatalf("Query err: %s", err)
+	}
+
+	var rows []scanRow
+	var iterator iters.Iterator[scanRow] = NewResultSetIterator(sqlRows, NewStructScanFunc[scanRow]())
+	for row, _ := iterator.Init(); iterator.Cond(&row, &err); {
+		rows = append(rows, row)
+	}
+	if err != nil {
+		t.Fatalf("iteration err: %s", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows: %d exp 2", len(rows))
+	}
+	if r := rows[0]; r.ID != 1 || r.Name != "a" || r.Email == nil || *r.Email != "a@b" || r.Comment.Valid {
+		t.Errorf("bad row 0: %+v", r)
+	}
+	if r := rows[1]; r.ID != 2 || r.Email != nil || r.Comment.String != "c" {
+		t.Errorf("bad row 1: %+v", r)
+	}
+}
+
+func TestNewStructScanFuncUnknownColumn(t *testing.T) {
+	//t.Error("Logging on")
+	var m = newMockDB()
+	var sqlRows = m.sqlRows(int64(1))
+
+	var iterator = NewResultSetIterator(sqlRows, NewStructScanFunc[scanRow]())
+	var err error
+	for row, _ := iterator.Init(); iterator.Cond(&row, &err); {
+	}
+	if err == nil {
+		t.Error("missing error for column col1")
+	}
+}
@@ -0,0 +1,164 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// isWriteStatement: Exec, the [StatementTimeouts.Write] deadline applies
+	isWriteStatement = true
+	// isReadStatement: queries, the [StatementTimeouts.Read] deadline applies
+	isReadStatement = false
+	// isRowsStatement: the statement returns rows read after return
+	// so its context is not canceled on success
+	isRowsStatement = true
+	// isDoneStatement: the statement is complete on return
+	isDoneStatement = false
+)
+
+// ErrStatementTimeout is wrapped by errors of statements
+// failing after their deadline expired
+//   - errors.Is(err, psql.ErrStatementTimeout)
+var ErrStatementTimeout = errors.New("statement timeout")
+
+// StatementTimeouts configures default deadlines of statements
+// executed by [DBMap] and [Tx]
+//   - a default deadline applies when the caller’s context has no deadline
+//   - for Query and QueryRow, the deadline also applies to reading the result.
+//     Its resources are released on error or when the deadline expires
+type StatementTimeouts struct {
+	// Read is the deadline of Query QueryRow QueryString QueryInt
+	//	- 0: no default deadline
+	Read time.Duration
+	// Write is the deadline of Exec
+	//	- 0: no default deadline
+	Write time.Duration
+	// OnTimeout is invoked when a statement fails after its deadline expired,
+	// whether default or the caller’s
+	//	- nil: the error wrapping [ErrStatementTimeout] is returned
+	OnTimeout TimeoutPolicy
+}
+
+// TimeoutPolicy handles a statement canceled by deadline
+//   - used to log, count or transform the error
+//   - err: the error returned by the statement method, typically event.Err
+//   - invoked synchronously by the thread executing the statement
+//     and must be thread-safe
+type TimeoutPolicy func(event TimeoutEvent) (err error)
+
+// TimeoutEvent describes a statement canceled by deadline
+type TimeoutEvent struct {
+	// Query is the SQL statement
+	Query     string
+	Partition parl.DBPartition
+	// IsWrite is true for Exec
+	IsWrite bool
+	// Timeout is the default deadline applied
+	//	- 0: the deadline was the caller’s
+	Timeout time.Duration
+	// Elapsed is how long the statement executed
+	Elapsed time.Duration
+	// Err is the statement error wrapping [ErrStatementTimeout]
+	Err error
+}
+
+// statementTimeout is the deadline state of one statement execution
+type statementTimeout struct {
+	// cancel releases the default deadline, nil if none
+	cancel context.CancelFunc
+	// timeout is the default deadline applied, 0 if none
+	timeout time.Duration
+	// isActive is true if timeouts are configured
+	isActive bool
+	isWrite  bool
+	t0       time.Time
+	policy   TimeoutPolicy
+}
+
+// SetTimeouts configures default statement deadlines and timeout policy
+//   - prevents stuck statements from hanging the application
+//   - thread-safe
+//
+// Usage:
+//
+//	dbMap.SetTimeouts(psql.StatementTimeouts{
+//	  Read: 5 * time.Second, Write: 10 * time.Second,
+//	  OnTimeout: psql.LogTimeout,
+//	})
+func (d *DBMap) SetTimeouts(timeouts StatementTimeouts) { d.timeouts.Store(&timeouts) }
+
+// LogTimeout is a [TimeoutPolicy] logging the canceled statement using [parl.Log]
+//   - the error is returned unchanged
+func LogTimeout(event TimeoutEvent) (err error) {
+	parl.Log("psql statement timeout: %s partition: %q: %s error: %s",
+		event.Elapsed.Round(time.Microsecond), event.Partition,
+		shortenQuery(event.Query), perrors.Short(event.Err),
+	)
+	return event.Err
+}
+
+// withTimeout returns a context with any default deadline for a statement
+//   - t.end must be deferred
+func (d *DBMap) withTimeout(ctx context.Context, isWrite bool) (ctx2 context.Context, t statementTimeout) {
+	ctx2 = ctx
+	t = statementTimeout{isWrite: isWrite, t0: time.Now()}
+	var timeouts = d.timeouts.Load()
+	if timeouts == nil {
+		return // no configuration return
+	}
+	t.isActive = true
+	t.policy = timeouts.OnTimeout
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		return // caller’s deadline return
+	}
+	if isWrite {
+		t.timeout = timeouts.Write
+	} else {
+		t.timeout = timeouts.Read
+	}
+	if t.timeout > 0 {
+		ctx2, t.cancel = context.WithTimeout(ctx, t.timeout)
+	}
+
+	return
+}
+
+// end releases the deadline and applies timeout policy
+//   - isRows: the context remains active on success
+//     until the deadline expires
+func (t *statementTimeout) end(
+	ctx context.Context, query string, partition parl.DBPartition,
+	isRows bool, errp *error,
+) {
+	var err = *errp
+	var isTimeout = err != nil && t.isActive && errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if t.cancel != nil && (err != nil || !isRows) {
+		t.cancel()
+	}
+	if !isTimeout {
+		return // not a timeout return
+	}
+
+	err = perrors.Errorf("%w: %w", ErrStatementTimeout, err)
+	if t.policy != nil {
+		err = t.policy(TimeoutEvent{
+			Query:     query,
+			Partition: partition,
+			IsWrite:   t.isWrite,
+			Timeout:   t.timeout,
+			Elapsed:   time.Since(t.t0),
+			Err:       err,
+		})
+	}
+	*errp = err
+}
@@ -0,0 +1,83 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestDBMapTimeouts(t *testing.T) {
+	//t.Error("Logging on")
+	var partition = parl.DBPartition("2024")
+	var query = "SELECT n FROM t"
+	var insert = "INSERT INTO t VALUES (1)"
+	var readTimeout = 10 * time.Millisecond
+	var delay = time.Second
+	var ctx = context.Background()
+	var errTransform = errors.New("transformed")
+
+	var err error
+	var value int
+
+	var dsnr = newReplicaTestDSNr(t, "w")
+	var mock = dsnr.mocks["w"]
+	var dbMap = NewDBMap(dsnr, func(dataSource parl.DataSource, ctx context.Context) (err error) { return })
+	var events []TimeoutEvent
+	dbMap.SetTimeouts(StatementTimeouts{
+		Read: readTimeout,
+		OnTimeout: func(event TimeoutEvent) (err error) {
+			events = append(events, event)
+			if event.IsWrite {
+				return errTransform
+			}
+			return event.Err
+		},
+	})
+
+	// fast read should succeed
+	mock.ExpectPrepare("SELECT").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	if value, _, err = dbMap.QueryInt(partition, query, parl.NoRowsError, ctx); err != nil {
+		t.Fatalf("QueryInt err: %s", perrors.Short(err))
+	} else if value != 1 {
+		t.Errorf("QueryInt %d exp 1", value)
+	}
+
+	// slow read should be canceled by default deadline
+	mock.ExpectQuery("SELECT").WillDelayFor(delay).WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	var t0 = time.Now()
+	_, _, err = dbMap.QueryInt(partition, query, parl.NoRowsError, ctx)
+	if !errors.Is(err, ErrStatementTimeout) {
+		t.Errorf("slow QueryInt err: %v", err)
+	}
+	if elapsed := time.Since(t0); elapsed >= delay {
+		t.Errorf("slow QueryInt elapsed %s", elapsed)
+	}
+	if len(events) != 1 {
+		t.Fatalf("events %d exp 1", len(events))
+	} else if e := events[0]; e.IsWrite || e.Timeout != readTimeout || e.Query != query || e.Partition != partition {
+		t.Errorf("bad read event %+v", e)
+	}
+
+	// caller deadline should apply policy with Timeout 0
+	mock.ExpectPrepare("INSERT").ExpectExec().WillDelayFor(delay).WillReturnResult(sqlmock.NewResult(0, 1))
+	var ctxDeadline, cancel = context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+	if _, err = dbMap.Exec(partition, insert, ctxDeadline); !errors.Is(err, errTransform) {
+		t.Errorf("slow Exec err: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("events %d exp 2", len(events))
+	} else if e := events[1]; !e.IsWrite || e.Timeout != 0 || !errors.Is(e.Err, ErrStatementTimeout) {
+		t.Errorf("bad write event %+v", e)
+	}
+}
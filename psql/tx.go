@@ -0,0 +1,304 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/psql/psql2"
+)
+
+const (
+	// default number of transaction attempts on busy errors
+	defaultTxMaxAttempts = 5
+	// default delay prior to a second transaction attempt
+	defaultTxInitialDelay = 10 * time.Millisecond
+	// default cap for delay between transaction attempts
+	defaultTxMaxDelay = time.Second
+	// default randomization of delay between transaction attempts
+	defaultTxJitter = 0.25
+	// savepointPrefix is prefix for savepoint names “parl_sp1”
+	savepointPrefix = "parl_sp"
+)
+
+// TxFunc is the function executed by [DBMap.InTx] and [Tx.InTx]
+//   - err non-nil: the transaction or savepoint scope is rolled back
+//   - a panic is recovered and rolls back
+//   - TxFunc may be invoked multiple times when a transaction is retried
+type TxFunc func(ctx context.Context, tx *Tx) (err error)
+
+// TxConfig is optional configuration for [DBMap.InTx]
+type TxConfig struct {
+	// Options are isolation level and read-only options
+	//	- nil: driver default
+	Options *sql.TxOptions
+	// Retry is retry policy for transactions failing with busy errors
+	//	- nil: 5 attempts with 10 ms initial delay and jitter
+	//	- Classifier is ignored: retries only take place if
+	//		the data source implements [psql2.BusyDetector]
+	Retry *parl.RetryPolicy
+}
+
+// Tx is a transactional executor provided to [TxFunc]
+//   - statements are obtained from the partition’s
+//     prepared-statement cache
+//   - statements are retried by the data source’s statement wrapper
+//   - nested scopes are created by [Tx.InTx] using savepoints
+//   - Tx is valid until TxFunc returns
+//   - not thread-safe
+type Tx struct {
+	// tx is the underlying database transaction
+	tx *sql.Tx
+	// dbMap provides tracing
+	dbMap     *DBMap
+	partition parl.DBPartition
+	// cache is the partition’s prepared-statement cache
+	cache *psql2.StatementCache
+	// releases end statement acquisitions when the transaction ends,
+	// shared by nested scopes
+	releases *[]func()
+	// depth is savepoint nesting level, 0 for transaction scope
+	depth int
+}
+
+// InTx executes fn in a database transaction on partition
+//   - the transaction is committed if fn returns nil,
+//     otherwise rolled back
+//   - if the data source implements [psql2.BusyDetector] like SQLite3,
+//     transactions failing with busy errors are retried
+//     invoking fn again
+//   - config: optional isolation level and retry policy
+//   - nested scopes: [Tx.InTx]
+//
+// Usage:
+//
+//	err = dbMap.InTx(ctx, partition, func(ctx context.Context, tx *psql.Tx) (err error) {
+//	  if _, err = tx.Exec(insertSQL, ctx, value); err != nil {
+//	    return
+//	  }
+//	  …
+//	})
+func (d *DBMap) InTx(ctx context.Context, partition parl.DBPartition, fn TxFunc, config ...*TxConfig) (err error) {
+	if fn == nil {
+		panic(parl.NilError("fn"))
+	}
+	var cfg TxConfig
+	if len(config) > 0 && config[0] != nil {
+		cfg = *config[0]
+	}
+
+	// obtain the statement cache
+	var dbCache *psql2.StatementCache
+	if dbCache, err = d.getOrCreateDBCache(d.dsnr.DSN(partition), ctx); err != nil {
+		return // closed or failure return
+	}
+	var beginner, ok = dbCache.DataSource.(psql2.TxBeginner)
+	if !ok {
+		err = perrors.ErrorfPF("data source does not support transactions: %T", dbCache.DataSource)
+		return // transactions not supported return
+	}
+
+	// retry policy
+	var policy parl.RetryPolicy
+	if cfg.Retry != nil {
+		policy = *cfg.Retry
+	} else {
+		policy = parl.RetryPolicy{
+			MaxAttempts:  defaultTxMaxAttempts,
+			InitialDelay: defaultTxInitialDelay,
+			MaxDelay:     defaultTxMaxDelay,
+			Jitter:       defaultTxJitter,
+		}
+	}
+	if busyDetector, ok := dbCache.DataSource.(psql2.BusyDetector); ok {
+		policy.Classifier = func(err error, attempt int) (isRetryable bool) {
+			return busyDetector.IsBusy(err)
+		}
+	} else {
+		policy.MaxAttempts = 1
+	}
+
+	_, err = parl.Retry(ctx, func(ctx context.Context) (value struct{}, err error) {
+		var t = Tx{dbMap: d, partition: partition, cache: dbCache, releases: new([]func())}
+		err = t.attempt(ctx, beginner, cfg.Options, fn)
+		return
+	}, &policy)
+
+	return
+}
+
+// InTx executes fn in a nested scope using a savepoint
+//   - fn returning nil releases the savepoint making its changes
+//     part of the enclosing transaction
+//   - fn returning error or panicking rolls back to the savepoint
+//     and the enclosing transaction may continue
+//   - err is the error of fn or of savepoint statements
+func (t *Tx) InTx(ctx context.Context, fn TxFunc) (err error) {
+	if fn == nil {
+		panic(parl.NilError("fn"))
+	}
+	var nested = Tx{
+		tx:        t.tx,
+		dbMap:     t.dbMap,
+		partition: t.partition,
+		cache:     t.cache,
+		releases:  t.releases,
+		depth:     t.depth + 1,
+	}
+	var name = savepointPrefix + strconv.Itoa(nested.depth)
+	if _, err = t.tx.ExecContext(ctx, "SAVEPOINT "+name); perrors.IsPF(&err, "SAVEPOINT: %w", err) {
+		return // savepoint failed return
+	}
+	defer nested.endSavepoint(ctx, name, &err)
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	err = fn(ctx, &nested)
+
+	return
+}
+
+// Exec executes a query not returning any rows
+func (t *Tx) Exec(query string, ctx context.Context, args ...any) (execResult parl.ExecResult, err error) {
+	if tracer := t.dbMap.tracer.Load(); tracer != nil {
+		var rows int64
+		defer t.dbMap.trace(*tracer, t.partition, query, time.Now(), &rows, &err)
+		defer func() {
+			if execResult != nil {
+				_, rows = execResult.Get()
+			}
+		}()
+	}
+	var timeout statementTimeout
+	ctx, timeout = t.dbMap.withTimeout(ctx, isWriteStatement)
+	defer timeout.end(ctx, query, t.partition, isDoneStatement, &err)
+	var stmt psql2.Stmt
+	if stmt, err = t.stmt(query, ctx); err != nil {
+		return
+	}
+	if execResult, err = psql2.NewExecResult(stmt.ExecContext(ctx, args...)); err != nil {
+		err = perrors.Errorf("Exec: %w", err)
+		return
+	}
+
+	return
+}
+
+// Query executes a query returning zero or more rows
+func (t *Tx) Query(query string, ctx context.Context, args ...any) (sqlRows *sql.Rows, err error) {
+	if tracer := t.dbMap.tracer.Load(); tracer != nil {
+		var rows = RowsUnknown
+		defer t.dbMap.trace(*tracer, t.partition, query, time.Now(), &rows, &err)
+	}
+	var timeout statementTimeout
+	ctx, timeout = t.dbMap.withTimeout(ctx, isReadStatement)
+	defer timeout.end(ctx, query, t.partition, isRowsStatement, &err)
+	var stmt psql2.Stmt
+	if stmt, err = t.stmt(query, ctx); err != nil {
+		return
+	}
+	if sqlRows, err = stmt.QueryContext(ctx, args...); err != nil {
+		err = perrors.Errorf("Query: %w", err)
+		return
+	}
+
+	return
+}
+
+// QueryRow executes a query returning only its first row
+//   - zero rows returns error: sql: no rows in result set: use [Tx.Query]
+func (t *Tx) QueryRow(query string, ctx context.Context, args ...any) (sqlRow *sql.Row, err error) {
+	if tracer := t.dbMap.tracer.Load(); tracer != nil {
+		var rows int64 = 1
+		defer t.dbMap.trace(*tracer, t.partition, query, time.Now(), &rows, &err)
+	}
+	var timeout statementTimeout
+	ctx, timeout = t.dbMap.withTimeout(ctx, isReadStatement)
+	defer timeout.end(ctx, query, t.partition, isRowsStatement, &err)
+	var stmt psql2.Stmt
+	if stmt, err = t.stmt(query, ctx); err != nil {
+		return
+	}
+	sqlRow = stmt.QueryRowContext(ctx, args...)
+	if err = sqlRow.Err(); err != nil {
+		err = perrors.Errorf("QueryRow: %w", err)
+		return
+	}
+
+	return
+}
+
+// Depth returns savepoint nesting level, 0 for the transaction scope
+func (t *Tx) Depth() (depth int) { return t.depth }
+
+// attempt executes fn in a new transaction
+func (t *Tx) attempt(ctx context.Context, beginner psql2.TxBeginner, opts *sql.TxOptions, fn TxFunc) (err error) {
+	if t.tx, err = beginner.BeginTx(ctx, opts); perrors.IsPF(&err, "BeginTx: %w", err) {
+		return // begin failed return
+	}
+	// commit or rollback after recovering any panic
+	defer t.release()
+	defer t.endTx(&err)
+	defer parl.RecoverErr(func() parl.DA { return parl.A() }, &err)
+
+	err = fn(ctx, t)
+
+	return
+}
+
+// endTx commits or rolls back the transaction
+func (t *Tx) endTx(errp *error) {
+	if *errp == nil {
+		if err := t.tx.Commit(); perrors.IsPF(&err, "Commit: %w", err) {
+			*errp = err
+		}
+		return // commit return
+	}
+	if err := t.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		*errp = perrors.AppendError(*errp, perrors.ErrorfPF("Rollback: %w", err))
+	}
+}
+
+// release ends acquisitions of cached statements
+func (t *Tx) release() {
+	for _, release := range *t.releases {
+		release()
+	}
+	*t.releases = nil
+}
+
+// endSavepoint releases or rolls back to savepoint name
+func (t *Tx) endSavepoint(ctx context.Context, name string, errp *error) {
+	if *errp != nil {
+		if _, err := t.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); perrors.IsPF(&err, "ROLLBACK TO SAVEPOINT: %w", err) {
+			*errp = perrors.AppendError(*errp, err)
+			return // rollback failed: savepoint state unknown return
+		}
+	}
+	if _, err := t.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); perrors.IsPF(&err, "RELEASE SAVEPOINT: %w", err) {
+		*errp = perrors.AppendError(*errp, err)
+	}
+}
+
+// stmt returns the cached prepared statement for query bound to the transaction
+//   - the cached statement is not evicted until the transaction ends
+func (t *Tx) stmt(query string, ctx context.Context) (stmt psql2.Stmt, err error) {
+	var sqlStmt *sql.Stmt
+	var release func()
+	if sqlStmt, release, err = t.cache.Acquire(query, ctx); err != nil {
+		return // closed or failure return
+	}
+	*t.releases = append(*t.releases, release)
+	// possibly wrap the statement
+	stmt = t.cache.WrapStmt(t.tx.StmtContext(ctx, sqlStmt))
+
+	return
+}
@@ -0,0 +1,152 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestDBMapInTx(t *testing.T) {
+	var partition = parl.DBPartition("2024")
+	var insert = "INSERT INTO t VALUES (1)"
+	var ctx = context.Background()
+	var errFn = errors.New("fn error")
+
+	var err error
+	var invocations, depth int
+
+	// commit on success
+	var dbMap, sqlMock = newTxTestDBMap(t)
+	sqlMock.ExpectBegin()
+	// Tx.Exec prepares through the statement cache,
+	// then sql.Tx.StmtContext prepares on the transaction connection
+	sqlMock.ExpectPrepare("INSERT")
+	sqlMock.ExpectPrepare("INSERT")
+	sqlMock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectCommit()
+	err = dbMap.InTx(ctx, partition, func(ctx context.Context, tx *Tx) (err error) {
+		_, err = tx.Exec(insert, ctx)
+		return
+	})
+	if err != nil {
+		t.Errorf("InTx err: %s", perrors.Short(err))
+	}
+	if err = sqlMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("commit: %s", err)
+	}
+
+	// rollback on error
+	dbMap, sqlMock = newTxTestDBMap(t)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectRollback()
+	err = dbMap.InTx(ctx, partition, func(ctx context.Context, tx *Tx) (err error) {
+		return errFn
+	})
+	if !errors.Is(err, errFn) {
+		t.Errorf("InTx err: %v exp %v", err, errFn)
+	}
+	if err = sqlMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("rollback: %s", err)
+	}
+
+	// rollback on panic
+	dbMap, sqlMock = newTxTestDBMap(t)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectRollback()
+	err = dbMap.InTx(ctx, partition, func(ctx context.Context, tx *Tx) (err error) {
+		panic(errFn)
+	})
+	if !errors.Is(err, errFn) {
+		t.Errorf("InTx panic err: %v exp %v", err, errFn)
+	}
+	if err = sqlMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("panic: %s", err)
+	}
+
+	// failing savepoint scope is rolled back, transaction commits
+	dbMap, sqlMock = newTxTestDBMap(t)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec("^SAVEPOINT parl_sp1$").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("^ROLLBACK TO SAVEPOINT parl_sp1$").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec("^RELEASE SAVEPOINT parl_sp1$").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectCommit()
+	err = dbMap.InTx(ctx, partition, func(ctx context.Context, tx *Tx) (err error) {
+		if e := tx.InTx(ctx, func(ctx context.Context, tx *Tx) (err error) {
+			depth = tx.Depth()
+			return errFn
+		}); !errors.Is(e, errFn) {
+			t.Errorf("nested InTx err: %v exp %v", e, errFn)
+		}
+		return
+	})
+	if err != nil {
+		t.Errorf("InTx savepoint err: %s", perrors.Short(err))
+	}
+	if depth != 1 {
+		t.Errorf("Depth %d exp 1", depth)
+	}
+	if err = sqlMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("savepoint: %s", err)
+	}
+
+	// busy error is retried
+	dbMap, sqlMock = newTxTestDBMap(t)
+	sqlMock.ExpectBegin().WillReturnError(errTxTestBusy)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectCommit()
+	invocations = 0
+	err = dbMap.InTx(ctx, partition, func(ctx context.Context, tx *Tx) (err error) {
+		invocations++
+		return
+	}, &TxConfig{Retry: &parl.RetryPolicy{MaxAttempts: 2, InitialDelay: time.Millisecond}})
+	if err != nil {
+		t.Errorf("InTx busy err: %s", perrors.Short(err))
+	}
+	if invocations != 1 {
+		t.Errorf("invocations %d exp 1", invocations)
+	}
+	if err = sqlMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("busy: %s", err)
+	}
+}
+
+// errTxTestBusy is the busy error of txTestDataSource
+var errTxTestBusy = errors.New("database is busy")
+
+// newTxTestDBMap returns a DBMap using sqlmock
+func newTxTestDBMap(t *testing.T) (dbMap *DBMap, sqlMock sqlmock.Sqlmock) {
+	var db *sql.DB
+	var err error
+	if db, sqlMock, err = sqlmock.New(); err != nil {
+		t.Fatalf("sqlmock.New: %s", err)
+	}
+	dbMap = NewDBMap(&txTestDSNr{db: db}, func(dataSource parl.DataSource, ctx context.Context) (err error) { return })
+	return
+}
+
+// txTestDSNr is a [parl.DataSourceNamer] providing a sqlmock data source
+type txTestDSNr struct{ db *sql.DB }
+
+func (n *txTestDSNr) DSN(partition ...parl.DBPartition) (dataSourceName parl.DataSourceName) {
+	return "mock"
+}
+
+func (n *txTestDSNr) DataSource(dsn parl.DataSourceName) (dataSource parl.DataSource, err error) {
+	return &txTestDataSource{DB: n.db}, nil
+}
+
+// txTestDataSource implements [psql2.TxBeginner] and [psql2.BusyDetector]
+type txTestDataSource struct{ *sql.DB }
+
+func (d *txTestDataSource) IsBusy(err error) (isBusy bool) { return errors.Is(err, errTxTestBusy) }
@@ -4,6 +4,7 @@ ISC License
 */
 
 // Package pstrings provides string fitting, filtered join and quoting of a string slice.
+//   - [Table] renders aligned columns with ANSI-aware widths
 package pstrings
 
 import (
@@ -0,0 +1,190 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pstrings
+
+import (
+	"strings"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/pfmt"
+)
+
+const (
+	// AlignLeft pads cells on the right, default
+	AlignLeft Alignment = iota
+	// AlignRight pads cells on the left, suitable for numbers
+	AlignRight
+	// AlignCenter pads cells on both sides
+	AlignCenter
+)
+
+const (
+	// default separator between columns
+	defaultTableSeparator = "\x20\x20"
+)
+
+// Alignment is horizontal alignment of a table column
+//   - [AlignLeft] [AlignRight] [AlignCenter]
+type Alignment uint8
+
+// TableColumn describes a column of [Table]
+type TableColumn struct {
+	// Heading is the optional column heading
+	//	- if no column has a heading, no heading line is output
+	Heading string
+	// Align is cell alignment, default [AlignLeft]
+	Align Alignment
+	// MaxWidth truncates longer cells with ellipsis “…”
+	//	- 0: no truncation
+	//	- a truncated cell has its ANSI escape sequences removed
+	MaxWidth int
+	// Format is format verb for non-string cell values
+	//	- empty: “%d” for integers, “%v” for other values.
+	//		Numbers have thousands separators from [parl.Sprintf]
+	Format string
+}
+
+// Table renders rows of values as aligned columns
+//   - column width ignores ANSI escape sequences so that colored cells align,
+//     ie. tables render correctly in StatusTerminal logs
+//   - not thread-safe
+//
+// Usage:
+//
+//	var table = pstrings.NewTable(
+//	  pstrings.TableColumn{Heading: "name", MaxWidth: 20},
+//	  pstrings.TableColumn{Heading: "bytes", Align: pstrings.AlignRight},
+//	)
+//	table.AddRow("file.txt", 12345)
+//	println(table.String())
+type Table struct {
+	// Separator is output between columns, default two spaces
+	Separator string
+	columns   []TableColumn
+	// rows are formatted cells
+	rows [][]string
+}
+
+// NewTable returns a table formatter with columns
+//   - rows may have more cells than columns.
+//     Such additional cells are left-aligned
+func NewTable(columns ...TableColumn) (table *Table) {
+	return &Table{Separator: defaultTableSeparator, columns: columns}
+}
+
+// AddRow adds a row of cells
+//   - string and [fmt.Stringer] values are used as is
+//   - other values are formatted using the column’s Format
+func (t *Table) AddRow(values ...any) {
+	var row = make([]string, len(values))
+	for i, value := range values {
+		var column = t.column(i)
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case interface{ String() string }:
+			s = v.String()
+		default:
+			var format = column.Format
+			if format == "" {
+				switch value.(type) {
+				case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
+					format = "%d"
+				default:
+					format = "%v"
+				}
+			}
+			s = parl.Sprintf(format, value)
+		}
+		if column.MaxWidth > 0 && Width(s) > column.MaxWidth {
+			s = pfmt.Truncate(TrimANSIEscapes(s), column.MaxWidth)
+		}
+		row[i] = s
+	}
+	t.rows = append(t.rows, row)
+}
+
+// Lines returns the rendered table, one string per line without newline
+//   - trailing whitespace is removed
+func (t *Table) Lines() (lines []string) {
+
+	// rows to render including any heading
+	var rows = t.rows
+	var hasHeading bool
+	for _, column := range t.columns {
+		if column.Heading != "" {
+			hasHeading = true
+			break
+		}
+	}
+	if hasHeading {
+		var heading = make([]string, len(t.columns))
+		for i, column := range t.columns {
+			heading[i] = column.Heading
+		}
+		rows = append([][]string{heading}, rows...)
+	}
+
+	// column widths
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := Width(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	lines = make([]string, len(rows))
+	for rowNo, row := range rows {
+		var cells = make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = pad(cell, widths[i], t.column(i).Align)
+		}
+		lines[rowNo] = strings.TrimRight(strings.Join(cells, t.Separator), "\x20")
+	}
+
+	return
+}
+
+// String returns the rendered table with a newline after each line
+//   - empty table: empty string
+func (t *Table) String() (s string) {
+	var lines = t.Lines()
+	if len(lines) == 0 {
+		return
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// column returns the column description for index i
+func (t *Table) column(i int) (column TableColumn) {
+	if i < len(t.columns) {
+		column = t.columns[i]
+	}
+	return
+}
+
+// pad pads cell to width according to align
+func pad(cell string, width int, align Alignment) (s string) {
+	var padding = width - Width(cell)
+	if padding <= 0 {
+		return cell
+	}
+	switch align {
+	case AlignRight:
+		return strings.Repeat("\x20", padding) + cell
+	case AlignCenter:
+		var left = padding / 2
+		return strings.Repeat("\x20", left) + cell + strings.Repeat("\x20", padding-left)
+	default:
+		return cell + strings.Repeat("\x20", padding)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pstrings
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		red   = "\x1b[31m"
+		reset = "\x1b[0m"
+	)
+	var (
+		expLines = []string{
+			"name     count",
+			"a            1",
+			red + "bb" + reset + "          22",
+			"abcdef…    333",
+		}
+		table = NewTable(
+			TableColumn{Heading: "name", MaxWidth: 7},
+			TableColumn{Heading: "count", Align: AlignRight},
+		)
+	)
+
+	table.AddRow("a", 1)
+	table.AddRow(red+"bb"+reset, 22)
+	table.AddRow("abcdefghij", 333)
+	var lines = table.Lines()
+	t.Logf("table:\n%s", table)
+
+	if !slices.Equal(lines, expLines) {
+		t.Errorf("Lines:\n%q exp\n%q", lines, expLines)
+	}
+
+	// Width ignores ANSI escapes
+	if w := Width(red + "bb" + reset); w != 2 {
+		t.Errorf("Width %d exp 2", w)
+	}
+	// Width is grapheme and wide-rune aware like pfmt.Width
+	if w := Width(red + "e\u0301日" + reset); w != 3 {
+		t.Errorf("Width %d exp 3", w)
+	}
+}
@@ -0,0 +1,32 @@
+/*
+© 2022–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pstrings
+
+import (
+	"regexp"
+
+	"github.com/haraldrudell/parl/pfmt"
+)
+
+const ansi = "[\u001B\u009B]" +
+	"[[\\]()#;?]*(?:" +
+	"(?:(?:[a-zA-Z\\d]*(?:;[a-zA-Z\\d]*)*)?\u0007)|" +
+	"(?:(?:\\d{1,4}(?:;\\d{0,4})*)?[\\dA-PRZcf-ntqry=><~]))"
+
+var ansiRegexp = regexp.MustCompile(ansi)
+
+// TrimANSIEscapes returns a string where ANSI espace sequences
+// have been removed.
+func TrimANSIEscapes(s string) (s1 string) {
+	return ansiRegexp.ReplaceAllString(s, "")
+}
+
+// Width returns the number of columns s occupies on a terminal
+//   - ANSI escape sequences do not count
+//   - columns are grapheme clusters and wide runes as of [pfmt.Width]
+func Width(s string) (width int) {
+	return pfmt.Width(TrimANSIEscapes(s))
+}
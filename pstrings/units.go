@@ -0,0 +1,150 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pstrings
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+const (
+	// siBase is the factor between SI prefixes: kilo is 1000
+	siBase = 1000
+	// iecBase is the factor between IEC binary prefixes: kibi is 1024
+	iecBase = 1024
+	// bytesUnit is the unit of [SIBytes] [IECBytes]
+	bytesUnit = "B"
+	// bytesPerSecond is the unit of [ByteRate]
+	bytesPerSecond = "B/s"
+)
+
+var (
+	// siPrefixes are SI prefixes for [SI] [SIBytes]
+	siPrefixes = []string{"", "k", "M", "G", "T", "P", "E"}
+	// iecPrefixes are IEC binary prefixes for [IECBytes]
+	iecPrefixes = []string{"", "Ki", "Mi", "Gi", "Ti", "Pi", "Ei"}
+)
+
+// IECBytes returns a byte count using binary prefixes
+//   - “512 B” “1.50 KiB” “12.3 MiB” “123 GiB”
+//   - counts below 1 KiB are exact, otherwise three significant digits
+func IECBytes(n int64) (s string) { return scaled(float64(n), iecBase, iecPrefixes, bytesUnit) }
+
+// SIBytes returns a byte count using decimal prefixes
+//   - “512 B” “1.50 kB” “12.3 MB” “123 GB”
+//   - counts below 1 kB are exact, otherwise three significant digits
+func SIBytes(n int64) (s string) { return scaled(float64(n), siBase, siPrefixes, bytesUnit) }
+
+// ByteRate returns a transfer rate using decimal prefixes
+//   - “950 B/s” “1.50 kB/s” “12.3 MB/s”
+func ByteRate(bytesPerSec float64) (s string) {
+	return scaled(bytesPerSec, siBase, siPrefixes, bytesPerSecond)
+}
+
+// SI returns value with SI prefix and unit
+//   - “950 op/s” “1.50 kop/s” “12.3 Mop/s”
+//   - unit may be empty: “12.3 M”
+//   - a unit beginning with “/” is not preceded by space: “12.3/s” “1.50k/s”
+//   - three significant digits, integral values below 1000 are exact
+func SI(value float64, unit string) (s string) { return scaled(value, siBase, siPrefixes, unit) }
+
+// Thousands returns n with thousands separators consistent with [parl.Sprintf]
+//   - “1,234,567”
+func Thousands(n int64) (s string) { return parl.Sprintf("%d", n) }
+
+// Duration returns a compact duration
+//   - “120ns” “12.3µs” “3.4ms” “12.3s” “3m04s” “1h02m” “2d03h”
+//   - units below a minute have one decimal below 100,
+//     larger durations are rounded to the smaller of two units
+func Duration(d time.Duration) (s string) {
+	if d < 0 {
+		if d == math.MinInt64 {
+			d++
+		}
+		return "-" + Duration(-d)
+	}
+	switch {
+	case d < time.Microsecond:
+		return strconv.FormatInt(int64(d), 10) + "ns"
+	case d < time.Millisecond-time.Microsecond/2:
+		return decimal(float64(d)/float64(time.Microsecond)) + "µs"
+	case d < time.Second-time.Millisecond/2:
+		return decimal(float64(d)/float64(time.Millisecond)) + "ms"
+	case d < time.Minute-time.Second/20:
+		return decimal(float64(d)/float64(time.Second)) + "s"
+	}
+	if d = d.Round(time.Second); d < time.Hour {
+		return parl.Sprintf("%dm%02ds", d/time.Minute, d%time.Minute/time.Second)
+	}
+	if d = d.Round(time.Minute); d < 24*time.Hour {
+		return parl.Sprintf("%dh%02dm", d/time.Hour, d%time.Hour/time.Minute)
+	}
+	d = d.Round(time.Hour)
+	return parl.Sprintf("%dd%02dh", d/(24*time.Hour), d%(24*time.Hour)/time.Hour)
+}
+
+// scaled returns value scaled by base with prefix and unit
+func scaled(value float64, base float64, prefixes []string, unit string) (s string) {
+	var sign string
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	// find prefix
+	var i int
+	if value >= base {
+		for i = 0; i < len(prefixes)-1 && value >= base; i++ {
+			value /= base
+		}
+		// rounding to three digits may reach base
+		if value >= 999.5 && base == siBase && i < len(prefixes)-1 {
+			value /= base
+			i++
+		}
+	}
+
+	var number string
+	if i == 0 && value == math.Trunc(value) {
+		number = parl.Sprintf("%.0f", value)
+	} else {
+		number = significant(value)
+	}
+	if suffix := prefixes[i] + unit; suffix == "" {
+		s = sign + number
+	} else if strings.HasPrefix(unit, "/") {
+		s = sign + number + suffix
+	} else {
+		s = sign + number + "\x20" + suffix
+	}
+
+	return
+}
+
+// significant returns value with three significant digits
+//   - “1.50” “12.3” “123” “1,023”
+func significant(value float64) (s string) {
+	switch {
+	case value < 9.995:
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	case value < 99.95:
+		return strconv.FormatFloat(value, 'f', 1, 64)
+	}
+	return parl.Sprintf("%.0f", value)
+}
+
+// decimal returns value with one decimal below 100
+//   - “3.4” “12.3” “345”
+func decimal(value float64) (s string) {
+	if value < 99.95 {
+		return strconv.FormatFloat(value, 'f', 1, 64)
+	}
+	return strconv.FormatFloat(value, 'f', 0, 64)
+}
@@ -0,0 +1,48 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pstrings
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnits(t *testing.T) {
+	//t.Error("Logging on")
+	var tests = []struct{ s, exp string }{
+		{IECBytes(0), "0 B"},
+		{IECBytes(512), "512 B"},
+		{IECBytes(1536), "1.50 KiB"},
+		{IECBytes(12_900_000), "12.3 MiB"},
+		{IECBytes(-2048), "-2.00 KiB"},
+		{SIBytes(1500), "1.50 kB"},
+		{SIBytes(123_400_000_000), "123 GB"},
+		{SIBytes(999_700), "1.00 MB"},
+		{ByteRate(950), "950 B/s"},
+		{ByteRate(12_345_678), "12.3 MB/s"},
+		{SI(0.5, "op/s"), "0.50 op/s"},
+		{SI(1500, "/s"), "1.50k/s"},
+		{SI(12.34, "/s"), "12.3/s"},
+		{SI(12_300_000, ""), "12.3 M"},
+		{Duration(0), "0ns"},
+		{Duration(120), "120ns"},
+		{Duration(12_340 * time.Nanosecond), "12.3µs"},
+		{Duration(3_400 * time.Microsecond), "3.4ms"},
+		{Duration(999_700 * time.Microsecond), "1.0s"},
+		{Duration(12_340 * time.Millisecond), "12.3s"},
+		{Duration(59_970 * time.Millisecond), "1m00s"},
+		{Duration(3*time.Minute + 4*time.Second), "3m04s"},
+		{Duration(time.Hour + 2*time.Minute + 10*time.Second), "1h02m"},
+		{Duration(51 * time.Hour), "2d03h"},
+		{Duration(-3400 * time.Microsecond), "-3.4ms"},
+		{Thousands(123), "123"},
+	}
+	for i, tt := range tests {
+		if tt.s != tt.exp {
+			t.Errorf("%d: %q exp %q", i, tt.s, tt.exp)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	// ColorNone: the terminal does not display colors
+	ColorNone ColorDepth = iota
+	// Color16: 8 colors and their bright variants
+	Color16
+	// Color256: xterm 256-color palette
+	Color256
+	// ColorTrue: 24-bit color
+	ColorTrue
+)
+
+// ColorDepth is the number of colors a terminal displays
+//   - [ColorNone] [Color16] [Color256] [ColorTrue]
+type ColorDepth uint8
+
+// environment variables examined by [DetectCapabilities]
+const (
+	envTerm      = "TERM"
+	envColorTerm = "COLORTERM"
+	envNoColor   = "NO_COLOR"
+	envCI        = "CI"
+)
+
+// VT52 escape sequences used by vt52 terminals
+const (
+	vt52CursorUp          = "\x1bA"
+	vt52EraseEndOfLine    = "\x1bK"
+	vt52EraseEndOfDisplay = "\x1bJ"
+)
+
+// xterm alternate-screen sequences
+const (
+	xtermEnterAltScreen = "\x1b[?1049h"
+	xtermExitAltScreen  = "\x1b[?1049l"
+)
+
+// Capabilities are escape sequences and features of a terminal type
+//   - a terminfo-like subset: cursor movement, erase, color depth and
+//     alternate screen
+//   - an empty sequence means the capability is not supported
+//   - [DetectCapabilities] obtains capabilities from environment
+type Capabilities struct {
+	// Term is the terminal type, value of TERM
+	Term string
+	// CursorUp moves the cursor one line up
+	CursorUp string
+	// CarriageReturn moves the cursor to column zero
+	CarriageReturn string
+	// EraseEndOfLine erases from cursor to end of line
+	EraseEndOfLine string
+	// EraseEndOfDisplay erases from cursor to end of display
+	EraseEndOfDisplay string
+	// EnterAltScreen switches to the alternate screen
+	EnterAltScreen string
+	// ExitAltScreen returns from the alternate screen
+	ExitAltScreen string
+	// Colors is color depth
+	Colors ColorDepth
+}
+
+// ansiCapabilities are capabilities of an ANSI terminal without alternate screen
+var ansiCapabilities = Capabilities{
+	CursorUp:          CursorUp,
+	CarriageReturn:    MoveCursorToColumnZero,
+	EraseEndOfLine:    EraseEndOfLine,
+	EraseEndOfDisplay: EraseEndOfDisplay,
+	Colors:            Color16,
+}
+
+// DetectCapabilities returns capabilities based on environment variables
+//   - TERM selects the terminal type
+//   - COLORTERM “truecolor” or “24bit” indicates 24-bit color
+//   - NO_COLOR present disables color
+//   - CI present and TERM absent, ie. a CI log: colors but no cursor movement
+func DetectCapabilities() (capabilities Capabilities) {
+	return LookupCapabilities(os.Getenv(envTerm), os.LookupEnv)
+}
+
+// LookupCapabilities returns capabilities for terminal type term
+//   - lookupEnv: optional environment lookup like [os.LookupEnv]
+//   - unknown terminal types are treated as ANSI
+func LookupCapabilities(term string, lookupEnv func(key string) (value string, ok bool)) (capabilities Capabilities) {
+	if lookupEnv == nil {
+		lookupEnv = noEnv
+	}
+	var name = strings.ToLower(term)
+	switch {
+	case name == "" || name == "dumb" || name == "unknown":
+		// no cursor control: pipes, emacs shell, CI logs
+		if _, isCI := lookupEnv(envCI); isCI {
+			capabilities.Colors = Color16
+		}
+	case name == "vt52":
+		capabilities = Capabilities{
+			CursorUp:          vt52CursorUp,
+			CarriageReturn:    MoveCursorToColumnZero,
+			EraseEndOfLine:    vt52EraseEndOfLine,
+			EraseEndOfDisplay: vt52EraseEndOfDisplay,
+		}
+	case strings.HasPrefix(name, "vt1"), strings.HasPrefix(name, "vt2"):
+		// serial consoles: vt100 vt102 vt220 have no color
+		capabilities = ansiCapabilities
+		capabilities.Colors = ColorNone
+	case name == "linux", name == "ansi", strings.HasPrefix(name, "cons"):
+		capabilities = ansiCapabilities
+	default:
+		// xterm screen tmux rxvt alacritty kitty and unknown types
+		capabilities = ansiCapabilities
+		capabilities.EnterAltScreen = xtermEnterAltScreen
+		capabilities.ExitAltScreen = xtermExitAltScreen
+	}
+	capabilities.Term = term
+
+	// color depth
+	if capabilities.Colors != ColorNone {
+		if strings.Contains(name, "256color") {
+			capabilities.Colors = Color256
+		}
+		if colorTerm, _ := lookupEnv(envColorTerm); colorTerm == "truecolor" || colorTerm == "24bit" {
+			capabilities.Colors = ColorTrue
+		}
+	}
+	if _, noColor := lookupEnv(envNoColor); noColor {
+		capabilities.Colors = ColorNone
+	}
+
+	return
+}
+
+// CanStatus returns true if the terminal supports the cursor movement and
+// erase required by a status area
+func (c *Capabilities) CanStatus() (canStatus bool) {
+	return c.CursorUp != "" && c.CarriageReturn != "" && c.EraseEndOfDisplay != ""
+}
+
+// Colorize returns s in color if the terminal displays colors
+//   - color: [Red] [Green] or other SGR sequence
+func (c *Capabilities) Colorize(color, s string) (s2 string) {
+	if c.Colors == ColorNone {
+		return s
+	}
+	return color + s + ResetColors
+}
+
+// noEnv is an empty environment
+func noEnv(key string) (value string, ok bool) { return }
@@ -0,0 +1,65 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"testing"
+)
+
+func TestLookupCapabilities(t *testing.T) {
+	//t.Error("Logging on")
+	type env map[string]string
+	var tests = []struct {
+		term        string
+		env         env
+		canStatus   bool
+		colors      ColorDepth
+		hasAlt      bool
+		eraseToLine string
+	}{
+		{"", nil, false, ColorNone, false, ""},
+		{"dumb", env{envCI: "true"}, false, Color16, false, ""},
+		{"vt52", nil, true, ColorNone, false, vt52EraseEndOfLine},
+		{"vt100", nil, true, ColorNone, false, EraseEndOfLine},
+		{"linux", nil, true, Color16, false, EraseEndOfLine},
+		{"xterm-256color", nil, true, Color256, true, EraseEndOfLine},
+		{"xterm", env{envColorTerm: "truecolor"}, true, ColorTrue, true, EraseEndOfLine},
+		{"xterm-256color", env{envNoColor: ""}, true, ColorNone, true, EraseEndOfLine},
+	}
+
+	for _, tt := range tests {
+		var lookupEnv = func(key string) (value string, ok bool) {
+			value, ok = tt.env[key]
+			return
+		}
+		var c = LookupCapabilities(tt.term, lookupEnv)
+		if c.Term != tt.term {
+			t.Errorf("%q Term %q", tt.term, c.Term)
+		}
+		if c.CanStatus() != tt.canStatus {
+			t.Errorf("%q CanStatus %t exp %t", tt.term, c.CanStatus(), tt.canStatus)
+		}
+		if c.Colors != tt.colors {
+			t.Errorf("%q %v Colors %d exp %d", tt.term, tt.env, c.Colors, tt.colors)
+		}
+		if hasAlt := c.EnterAltScreen != ""; hasAlt != tt.hasAlt {
+			t.Errorf("%q alt screen %t exp %t", tt.term, hasAlt, tt.hasAlt)
+		}
+		if c.EraseEndOfLine != tt.eraseToLine {
+			t.Errorf("%q EraseEndOfLine %q exp %q", tt.term, c.EraseEndOfLine, tt.eraseToLine)
+		}
+	}
+
+	// Colorize
+	var c = LookupCapabilities("vt100", nil)
+	if s := c.Colorize(Red, "x"); s != "x" {
+		t.Errorf("Colorize %q exp %q", s, "x")
+	}
+	c = LookupCapabilities("xterm", nil)
+	if s, exp := c.Colorize(Red, "x"), Red+"x"+ResetColors; s != exp {
+		t.Errorf("Colorize %q exp %q", s, exp)
+	}
+}
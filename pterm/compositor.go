@@ -0,0 +1,169 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Compositor lays out widgets into status lines
+//   - each line has one or more widgets separated by space
+//   - widgets get their preferred width.
+//     Remaining width is shared by flexible widgets like [Bar]
+//   - narrow terminal: widgets are reduced to their minimum width.
+//     If still too wide, widgets at the end of the line are omitted
+//   - thread-safe
+//
+// Usage:
+//
+//	var files, bytes = pterm.NewCounter("files"), pterm.NewRate(pterm.NoLabel, "B/s")
+//	var bar = pterm.NewBar(pterm.NoLabel, total)
+//	var compositor = pterm.NewCompositor()
+//	compositor.AddLine(pterm.NewSpinner(), files, bytes)
+//	compositor.AddLine(bar)
+//	…
+//	statusTerminal.Status(compositor.Render(statusTerminal.Width()))
+type Compositor struct {
+	lock  sync.Mutex
+	lines [][]Widget // behind lock
+}
+
+// NewCompositor returns a layout of widgets
+func NewCompositor() (compositor *Compositor) { return &Compositor{} }
+
+// AddLine appends a status line of widgets
+//   - widgets are listed in priority order: on narrow terminal,
+//     the last widgets are omitted first
+func (c *Compositor) AddLine(widgets ...Widget) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lines = append(c.lines, widgets)
+}
+
+// Clear removes all lines
+func (c *Compositor) Clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lines = nil
+}
+
+// Render returns the status lines for a terminal width
+//   - lines are separated by newline
+//   - width: 0 or less: widgets are rendered at preferred width
+//     and flexible widgets at minimum width
+func (c *Compositor) Render(width int) (statusLines string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var lines = make([]string, len(c.lines))
+	for i, widgets := range c.lines {
+		lines[i] = RenderLine(width, widgets...)
+	}
+
+	return strings.Join(lines, NewLine)
+}
+
+// RenderLine lays out widgets on a single line of width
+//   - see [Compositor]
+func RenderLine(width int, widgets ...Widget) (line string) {
+	var n = len(widgets)
+	if n == 0 {
+		return
+	}
+	var minWidths = make([]int, n)
+	var preferredWidths = make([]int, n)
+	var widths = make([]int, n)
+	var isFlex = make([]bool, n)
+	for i, widget := range widgets {
+		minWidths[i], preferredWidths[i] = widget.Widths()
+		if isFlex[i] = preferredWidths[i] == 0; isFlex[i] {
+			widths[i] = minWidths[i]
+		} else {
+			preferredWidths[i] = max(preferredWidths[i], minWidths[i])
+			widths[i] = preferredWidths[i]
+		}
+	}
+	if width <= 0 {
+		return renderWidgets(widgets, widths)
+	}
+
+	// narrow terminal: degrade to minimum widths,
+	// then omit widgets from the end
+	for sum(widths, n) > width {
+		if degradeOne(widths, minWidths, n) {
+			continue
+		} else if n == 1 {
+			widths[0] = width // single widget is truncated
+			break
+		}
+		n--
+	}
+	widgets = widgets[:n]
+	widths = widths[:n]
+
+	// restore degraded widgets in priority order,
+	// then share remaining width between flexible widgets
+	var remaining = width - sum(widths, n)
+	var flex []int
+	for i := range widths {
+		if isFlex[i] {
+			flex = append(flex, i)
+		} else if grow := min(preferredWidths[i]-widths[i], remaining); grow > 0 {
+			widths[i] += grow
+			remaining -= grow
+		}
+	}
+	for j, i := range flex {
+		var share = remaining / (len(flex) - j)
+		widths[i] += share
+		remaining -= share
+	}
+
+	return renderWidgets(widgets, widths)
+}
+
+// degradeOne reduces the last widget wider than its minimum width to minimum
+//   - isDegraded false: all widgets are at minimum width
+func degradeOne(widths, minWidths []int, n int) (isDegraded bool) {
+	for i := n - 1; i >= 0; i-- {
+		if widths[i] > minWidths[i] {
+			widths[i] = minWidths[i]
+			return true
+		}
+	}
+	return
+}
+
+// sum returns the width of n widgets including separating spaces
+func sum(widths []int, n int) (total int) {
+	for _, w := range widths[:n] {
+		total += w
+	}
+	if n > 1 {
+		total += n - 1
+	}
+	return
+}
+
+// renderWidgets renders each widget truncated to its width
+func renderWidgets(widgets []Widget, widths []int) (line string) {
+	var sb strings.Builder
+	for i, widget := range widgets {
+		if i > 0 {
+			sb.WriteString(Space)
+		}
+		var s = widget.Render(widths[i])
+		if utf8.RuneCountInString(s) > widths[i] {
+			s = string([]rune(s)[:widths[i]])
+		}
+		sb.WriteString(s)
+	}
+	return sb.String()
+}
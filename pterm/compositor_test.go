@@ -0,0 +1,70 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCompositor(t *testing.T) {
+	//t.Error("Logging on")
+	var (
+		counter    = NewCounter("files")
+		bar        = NewBar("copy", 200)
+		spinner    = NewSpinner()
+		compositor = NewCompositor()
+	)
+	counter.Set(1234)
+	bar.Set(50)
+	compositor.AddLine(spinner, counter, bar)
+	compositor.AddLine(NewText("second line"))
+
+	// wide terminal: bar takes remaining width
+	var width = 60
+	var lines = strings.Split(compositor.Render(width), NewLine)
+	if len(lines) != 2 {
+		t.Fatalf("lines %d exp 2: %q", len(lines), lines)
+	}
+	if n := utf8.RuneCountInString(lines[0]); n != width {
+		t.Errorf("line width %d exp %d: %q", n, width, lines[0])
+	}
+	if !strings.HasPrefix(lines[0], "| files: 1234 copy [") || !strings.HasSuffix(lines[0], "]  25%") {
+		t.Errorf("bad line %q", lines[0])
+	}
+	if lines[1] != "second line" {
+		t.Errorf("line 2 %q", lines[1])
+	}
+
+	// narrow terminal: counter loses label, bar is percent
+	if s, exp := RenderLine(12, spinner, counter, bar), "/ 1234 25%"; s != exp {
+		t.Errorf("narrow %q exp %q", s, exp)
+	}
+
+	// very narrow: trailing widgets are omitted
+	if s, exp := RenderLine(7, spinner, counter, bar), "- 1234"; s != exp {
+		t.Errorf("very narrow %q exp %q", s, exp)
+	}
+	if s, exp := RenderLine(3, NewText("abcdef")), "abc"; s != exp {
+		t.Errorf("truncated %q exp %q", s, exp)
+	}
+}
+
+func TestRate(t *testing.T) {
+	//t.Error("Logging on")
+	var rate = NewRate("speed", "B/s")
+	if s, exp := rate.Render(20), "speed: 0 B/s"; s != exp {
+		t.Errorf("Render %q exp %q", s, exp)
+	}
+	rate.Add(100)
+	if s := rate.Render(20); s == "speed: 0 B/s" {
+		t.Errorf("Render no rate %q", s)
+	}
+	if s := rate.Render(5); strings.HasPrefix(s, "speed") {
+		t.Errorf("narrow Render %q", s)
+	}
+}
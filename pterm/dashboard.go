@@ -0,0 +1,471 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+	"golang.org/x/term"
+)
+
+const (
+	// DefaultDashboardRefresh is refresh interval when [DashboardConfig.Refresh] is 0
+	DefaultDashboardRefresh = 250 * time.Millisecond
+	// DefaultDashboardLogLines is log-pane scrollback when [DashboardConfig.LogLines] is 0
+	DefaultDashboardLogLines = 1000
+)
+
+// xterm sequences used by [Dashboard]
+const (
+	cursorHome = "\x1b[H"
+	hideCursor = "\x1b[?25l"
+	showCursor = "\x1b[?25h"
+	// crlf ends a line in raw mode where newline does not return the carriage
+	crlf = "\r\n"
+	// paneRule is the character of pane title lines
+	paneRule = "─"
+	// dashboardLogTitle is title of the log pane
+	dashboardLogTitle = "log"
+)
+
+// keys decoded from keyboard input
+const (
+	keyQuit dashboardKey = iota + 1
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+)
+
+// dashboardKey is a decoded keypress
+type dashboardKey uint8
+
+// DashboardConfig configures [Dashboard]
+type DashboardConfig struct {
+	// Refresh is the interval of periodic rendering
+	//	- 0: [DefaultDashboardRefresh]
+	Refresh time.Duration
+	// Input is the keyboard, default [os.Stdin]
+	Input *os.File
+	// Output is the terminal, default [os.Stderr]
+	Output *os.File
+	// LogLines is the number of lines retained by the log pane
+	//	- 0: [DefaultDashboardLogLines]
+	LogLines int
+	// OnQuit is optionally invoked on the key thread when q is pressed
+	OnQuit func()
+}
+
+// Dashboard is a full-screen terminal mode with panes of widgets
+// above a scrollable log pane
+//   - uses the alternate screen so that the shell’s screen
+//     is unchanged on exit
+//   - panes are [Compositor] layouts of widgets, each with a title line
+//   - the log pane uses remaining lines, following the newest line
+//   - keyboard: q or ctrl-C quits, arrows scroll the log pane one line,
+//     page-up page-down one page, home end to oldest and newest line
+//   - rendering is periodic and on [Dashboard.Refresh]
+//   - [Dashboard.Restore] leaves alternate screen and raw mode.
+//     Restore should be registered with mains.Executable.AddRestore so that
+//     the terminal is restored prior to printing errors on exit or panic
+//   - requires a terminal with alternate screen, see [Capabilities]
+//   - thread-safe
+//
+// Usage:
+//
+//	var compositor = pterm.NewCompositor()
+//	compositor.AddLine(pterm.NewSpinner(), files, bytes)
+//	var dashboard = pterm.NewDashboard()
+//	dashboard.AddPane("progress", compositor)
+//	ex.AddRestore(dashboard.Restore)
+//	if err = dashboard.Start(); err != nil {
+//	  return
+//	}
+//	defer dashboard.Restore()
+//	dashboard.Log("starting %d workers", n)
+//	…
+//	<-dashboard.QuitCh()
+type Dashboard struct {
+	refresh  time.Duration
+	input    *os.File
+	output   *os.File
+	logLines int
+	onQuit   func()
+	// getSize is [term.GetSize]
+	getSize func(fd int) (width, height int, err error)
+	// capabilities are escape sequences of the terminal
+	capabilities Capabilities
+	// quit closes on q or Restore
+	quit parl.Awaitable
+	// isStarted is true once Start was invoked
+	isStarted atomic.Bool
+	// isRunning is true once threads were launched
+	isRunning atomic.Bool
+	// isRestored is true once Restore was invoked
+	isRestored atomic.Bool
+	// done closes when the refresh thread exits
+	done parl.Awaitable
+	// writeLock serializes output and makes oldState thread-safe
+	writeLock sync.Mutex
+	// oldState is terminal state prior to raw mode, behind writeLock
+	oldState *term.State
+	// lock makes fields below thread-safe
+	lock sync.Mutex
+	// panes are widget panes, behind lock
+	panes []dashboardPane
+	// log is the log pane’s lines, behind lock
+	log []string
+	// scroll is log lines scrolled up from newest, behind lock
+	scroll int
+	// logHeight is log-pane lines at last render, behind lock
+	logHeight int
+}
+
+// dashboardPane is a titled widget layout
+type dashboardPane struct {
+	title      string
+	compositor *Compositor
+}
+
+// NewDashboard returns a full-screen dashboard
+//   - config: optional refresh, input, output and log size
+//   - the terminal is not changed until [Dashboard.Start]
+func NewDashboard(config ...DashboardConfig) (dashboard *Dashboard) {
+	var c DashboardConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.Refresh <= 0 {
+		c.Refresh = DefaultDashboardRefresh
+	}
+	if c.Input == nil {
+		c.Input = os.Stdin
+	}
+	if c.Output == nil {
+		c.Output = os.Stderr
+	}
+	if c.LogLines <= 0 {
+		c.LogLines = DefaultDashboardLogLines
+	}
+	return &Dashboard{
+		refresh:      c.Refresh,
+		input:        c.Input,
+		output:       c.Output,
+		logLines:     c.LogLines,
+		onQuit:       c.OnQuit,
+		getSize:      term.GetSize,
+		capabilities: DetectCapabilities(),
+	}
+}
+
+// AddPane appends a titled pane of widgets
+//   - panes are displayed top to bottom in order of addition
+func (d *Dashboard) AddPane(title string, compositor *Compositor) {
+	if compositor == nil {
+		panic(parl.NilError("compositor"))
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.panes = append(d.panes, dashboardPane{title: title, compositor: compositor})
+}
+
+// Log appends a line to the log pane
+//   - Printf-style format
+//   - multi-line text is split into lines
+func (d *Dashboard) Log(format string, a ...any) {
+	var lines = strings.Split(parl.Sprintf(format, a...), NewLine)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.log = append(d.log, lines...)
+	if excess := len(d.log) - d.logLines; excess > 0 {
+		d.log = append(d.log[:0], d.log[excess:]...)
+	}
+	if d.scroll > 0 {
+		// keep a scrolled view stationary
+		d.scroll = min(d.scroll+len(lines), d.maxScroll())
+	}
+}
+
+// Start enters alternate screen and raw mode and launches
+// rendering and keyboard threads
+//   - err: output is not a terminal, the terminal type lacks alternate screen
+//     or raw mode failed
+//   - Start can only be invoked once
+func (d *Dashboard) Start() (err error) {
+	if !d.isStarted.CompareAndSwap(false, true) {
+		err = perrors.NewPF("Start invoked more than once")
+		return
+	}
+	var outFd = int(d.output.Fd())
+	if !term.IsTerminal(outFd) {
+		err = perrors.NewPF("dashboard output is not a terminal")
+		return
+	} else if d.capabilities.EnterAltScreen == "" {
+		err = perrors.ErrorfPF("terminal type %q lacks alternate screen", d.capabilities.Term)
+		return
+	}
+	var oldState *term.State
+	if oldState, err = term.MakeRaw(int(d.input.Fd())); perrors.IsPF(&err, "MakeRaw %w", err) {
+		return
+	}
+	d.writeLock.Lock()
+	d.oldState = oldState
+	d.writeLock.Unlock()
+	if err = d.write(d.capabilities.EnterAltScreen + hideCursor); err != nil {
+		d.Restore()
+		return
+	}
+
+	d.isRunning.Store(true)
+	go d.refreshThread()
+	go d.keyThread()
+
+	return
+}
+
+// Refresh renders the dashboard now
+func (d *Dashboard) Refresh() {
+	if !d.isRunning.Load() || d.isRestored.Load() {
+		return
+	}
+	var width, height, err = d.getSize(int(d.output.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return // size unavailable return
+	}
+	d.write(d.render(width, height))
+}
+
+// QuitCh returns a channel that closes when q is pressed or
+// the dashboard is restored
+func (d *Dashboard) QuitCh() (ch parl.AwaitableCh) { return d.quit.Ch() }
+
+// Restore leaves alternate screen and raw mode
+//   - idempotent, deferrable, panic-safe
+//   - suitable for mains.Executable.AddRestore
+//   - the keyboard thread exits on the next keypress
+func (d *Dashboard) Restore() {
+	if !d.isRestored.CompareAndSwap(false, true) {
+		return // already restored return
+	}
+	d.quit.Close()
+	if d.isRunning.Load() {
+		<-d.done.Ch()
+	}
+
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+
+	if d.oldState == nil {
+		return // raw mode not entered return
+	}
+	d.output.Write([]byte(showCursor + d.capabilities.ExitAltScreen))
+	term.Restore(int(d.input.Fd()), d.oldState)
+	d.oldState = nil
+}
+
+// refreshThread renders periodically until Restore
+func (d *Dashboard) refreshThread() {
+	defer d.done.Close()
+	defer parl.Recover(func() parl.DA { return parl.A() }, parl.NoErrp, parl.Infallible)
+
+	var ticker = time.NewTicker(d.refresh)
+	defer ticker.Stop()
+
+	var quit = d.quit.Ch()
+	for {
+		d.Refresh()
+		select {
+		case <-quit:
+			return // restored return
+		case <-ticker.C:
+		}
+	}
+}
+
+// keyThread reads and acts on keyboard input until Restore
+func (d *Dashboard) keyThread() {
+	defer parl.Recover(func() parl.DA { return parl.A() }, parl.NoErrp, parl.Infallible)
+
+	var buffer = make([]byte, 64)
+	for {
+		var n, err = d.input.Read(buffer)
+		if err != nil || d.isRestored.Load() {
+			return // input closed or restored return
+		}
+		for _, key := range parseKeys(buffer[:n]) {
+			if key == keyQuit {
+				if d.onQuit != nil {
+					d.onQuit()
+				}
+				d.quit.Close()
+				return // quit return
+			}
+			d.scrollKey(key)
+		}
+		d.Refresh()
+	}
+}
+
+// scrollKey scrolls the log pane
+func (d *Dashboard) scrollKey(key dashboardKey) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var page = max(d.logHeight, 1)
+	switch key {
+	case keyUp:
+		d.scroll++
+	case keyDown:
+		d.scroll--
+	case keyPageUp:
+		d.scroll += page
+	case keyPageDown:
+		d.scroll -= page
+	case keyHome:
+		d.scroll = d.maxScroll()
+	case keyEnd:
+		d.scroll = 0
+	}
+	d.scroll = max(min(d.scroll, d.maxScroll()), 0)
+}
+
+// maxScroll is scroll showing the oldest log line, behind lock
+func (d *Dashboard) maxScroll() (scroll int) { return max(len(d.log)-d.logHeight, 0) }
+
+// render returns a frame for a terminal of width and height
+func (d *Dashboard) render(width, height int) (frame string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	var lines = make([]string, 0, height)
+	for _, pane := range d.panes {
+		lines = append(lines, titleLine(pane.title, width))
+		if s := pane.compositor.Render(width); s != "" {
+			lines = append(lines, strings.Split(s, NewLine)...)
+		}
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	// log pane uses remaining lines below its title
+	d.logHeight = max(height-len(lines)-1, 0)
+	d.scroll = min(d.scroll, d.maxScroll())
+	if d.logHeight > 0 {
+		var title = dashboardLogTitle
+		if d.scroll > 0 {
+			title += parl.Sprintf(" ↑%d", d.scroll)
+		}
+		lines = append(lines, titleLine(title, width))
+		var end = len(d.log) - d.scroll
+		lines = append(lines, d.log[max(end-d.logHeight, 0):end]...)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(cursorHome)
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteString(crlf)
+		}
+		sb.WriteString(truncate(line, width))
+		sb.WriteString(d.capabilities.EraseEndOfLine)
+	}
+	sb.WriteString(d.capabilities.EraseEndOfDisplay)
+
+	return sb.String()
+}
+
+// write outputs s to the terminal
+func (d *Dashboard) write(s string) (err error) {
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+
+	if _, err = io.WriteString(d.output, s); perrors.IsPF(&err, "dashboard write %w", err) {
+		return
+	}
+	return
+}
+
+// parseKeys decodes keyboard input
+//   - unknown keys and escape sequences are ignored
+func parseKeys(input []byte) (keys []dashboardKey) {
+	for len(input) > 0 {
+		var c = input[0]
+		input = input[1:]
+		switch c {
+		case 'q', 'Q', '\x03':
+			keys = append(keys, keyQuit)
+			continue
+		case '\x1b':
+		default:
+			continue
+		}
+
+		// escape sequence “ESC [ A” or “ESC O A”
+		if len(input) < 2 || (input[0] != '[' && input[0] != 'O') {
+			continue
+		}
+		var final = input[1]
+		input = input[2:]
+		switch final {
+		case 'A':
+			keys = append(keys, keyUp)
+		case 'B':
+			keys = append(keys, keyDown)
+		case 'H':
+			keys = append(keys, keyHome)
+		case 'F':
+			keys = append(keys, keyEnd)
+		case '1':
+			keys = appendTilde(keys, &input, keyHome)
+		case '4':
+			keys = appendTilde(keys, &input, keyEnd)
+		case '5':
+			keys = appendTilde(keys, &input, keyPageUp)
+		case '6':
+			keys = appendTilde(keys, &input, keyPageDown)
+		}
+	}
+	return
+}
+
+// appendTilde appends key for a sequence ending with tilde “ESC [ 5 ~”
+func appendTilde(keys []dashboardKey, input *[]byte, key dashboardKey) (keys2 []dashboardKey) {
+	if len(*input) == 0 || (*input)[0] != '~' {
+		return keys
+	}
+	*input = (*input)[1:]
+	return append(keys, key)
+}
+
+// titleLine returns “── title ───…” of width
+func titleLine(title string, width int) (line string) {
+	line = strings.Repeat(paneRule, 2) + Space + title + Space
+	if n := width - utf8.RuneCountInString(line); n > 0 {
+		line += strings.Repeat(paneRule, n)
+	}
+	return
+}
+
+// truncate returns s of at most width code points
+func truncate(s string, width int) (s2 string) {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
@@ -0,0 +1,81 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	//t.Error("Logging on")
+	var input = []byte("x\x1b[A\x1b[B\x1bOA\x1b[5~\x1b[6~\x1b[H\x1b[4~q")
+	var expKeys = []dashboardKey{keyUp, keyDown, keyUp, keyPageUp, keyPageDown, keyHome, keyEnd, keyQuit}
+
+	if keys := parseKeys(input); !slices.Equal(keys, expKeys) {
+		t.Errorf("keys %v exp %v", keys, expKeys)
+	}
+	if keys := parseKeys([]byte{'\x03'}); !slices.Equal(keys, []dashboardKey{keyQuit}) {
+		t.Errorf("ctrl-C keys %v", keys)
+	}
+}
+
+func TestDashboardRender(t *testing.T) {
+	var width, height = 20, 6
+	var expLines = []string{
+		"── pane ────────────",
+		"files: 3",
+		"── log ─────────────",
+		"line7",
+		"line8",
+		"line9",
+	}
+
+	var counter = NewCounter("files")
+	counter.Set(3)
+	var compositor = NewCompositor()
+	compositor.AddLine(counter)
+	var dashboard = NewDashboard(DashboardConfig{LogLines: 8})
+	dashboard.capabilities = Capabilities{}
+	dashboard.AddPane("pane", compositor)
+	for i := 0; i < 10; i++ {
+		dashboard.Log("line" + strconv.Itoa(i))
+	}
+
+	// log pane follows newest line
+	var lines = frameLines(dashboard.render(width, height))
+	if !slices.Equal(lines, expLines) {
+		t.Errorf("lines:\n%s\nexp:\n%s", strings.Join(lines, "\n"), strings.Join(expLines, "\n"))
+	}
+
+	// scroll up one line
+	dashboard.scrollKey(keyUp)
+	lines = frameLines(dashboard.render(width, height))
+	if lines[2] != "── log ↑1 ──────────" || lines[3] != "line6" {
+		t.Errorf("scrolled lines %q", lines)
+	}
+
+	// home: oldest retained line
+	dashboard.scrollKey(keyHome)
+	lines = frameLines(dashboard.render(width, height))
+	if lines[3] != "line2" {
+		t.Errorf("home line %q exp line2", lines[3])
+	}
+
+	// end: follow newest
+	dashboard.scrollKey(keyEnd)
+	lines = frameLines(dashboard.render(width, height))
+	if lines[5] != "line9" {
+		t.Errorf("end line %q exp line9", lines[5])
+	}
+}
+
+// frameLines returns the lines of a frame without escape sequences
+func frameLines(frame string) (lines []string) {
+	return strings.Split(strings.TrimPrefix(frame, cursorHome), crlf)
+}
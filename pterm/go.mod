@@ -8,11 +8,11 @@ replace github.com/haraldrudell/parl => ../../parl
 
 require (
 	github.com/haraldrudell/parl v0.4.187
+	golang.org/x/sys v0.21.0
 	golang.org/x/term v0.21.0
 )
 
 require (
 	golang.org/x/exp v0.0.0-20240613232115-7f521ea00fb8 // indirect
-	golang.org/x/sys v0.21.0 // indirect
 	golang.org/x/text v0.16.0 // indirect
 )
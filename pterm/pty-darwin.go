@@ -0,0 +1,70 @@
+//go:build darwin
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/haraldrudell/parl/perrors"
+	"golang.org/x/sys/unix"
+)
+
+// OpenPty allocates a pseudo-terminal
+//   - pty: the master side read and written by the controlling process
+//   - tty: the slave side provided to a child process as its terminal
+//   - the caller closes both
+//   - usable as [github.com/haraldrudell/parl/pexec.ProcessConfig.Pty]
+func OpenPty() (pty, tty *os.File, err error) {
+	if pty, err = os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0); perrors.IsPF(&err, "open ptmx %w", err) {
+		return
+	}
+	defer func() {
+		if err != nil {
+			pty.Close()
+			pty = nil
+		}
+	}()
+
+	var rawConn, e = pty.SyscallConn()
+	if err = e; perrors.IsPF(&err, "SyscallConn %w", err) {
+		return
+	}
+	// TIOCPTYGNAME provides a 128-byte name buffer
+	var name [128]byte
+	if e = rawConn.Control(func(fd uintptr) {
+		if err = ioctl(fd, unix.TIOCPTYGRANT, 0); perrors.IsPF(&err, "TIOCPTYGRANT %w", err) {
+			return
+		} else if err = ioctl(fd, unix.TIOCPTYUNLK, 0); perrors.IsPF(&err, "TIOCPTYUNLK %w", err) {
+			return
+		}
+		err = ioctl(fd, unix.TIOCPTYGNAME, uintptr(unsafe.Pointer(&name[0])))
+		perrors.IsPF(&err, "TIOCPTYGNAME %w", err)
+	}); err == nil {
+		err = e
+	}
+	if err != nil {
+		return
+	}
+	var ttyName, _, _ = bytes.Cut(name[:], []byte{0})
+	if tty, err = os.OpenFile(string(ttyName), os.O_RDWR|unix.O_NOCTTY, 0); perrors.IsPF(&err, "open tty %w", err) {
+		return
+	}
+
+	return
+}
+
+// ioctl invokes ioctl with a pointer or value argument
+func ioctl(fd, request, arg uintptr) (err error) {
+	if _, _, errno := syscall.Syscall(unix.SYS_IOCTL, fd, request, arg); errno != 0 {
+		err = errno
+	}
+	return
+}
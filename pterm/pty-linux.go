@@ -0,0 +1,58 @@
+//go:build linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/haraldrudell/parl/perrors"
+	"golang.org/x/sys/unix"
+)
+
+// OpenPty allocates a pseudo-terminal
+//   - pty: the master side read and written by the controlling process
+//   - tty: the slave side provided to a child process as its terminal
+//   - the caller closes both
+//   - usable as [github.com/haraldrudell/parl/pexec.ProcessConfig.Pty]
+func OpenPty() (pty, tty *os.File, err error) {
+	if pty, err = os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0); perrors.IsPF(&err, "open ptmx %w", err) {
+		return
+	}
+	defer func() {
+		if err != nil {
+			pty.Close()
+			pty = nil
+		}
+	}()
+
+	var rawConn, e = pty.SyscallConn()
+	if err = e; perrors.IsPF(&err, "SyscallConn %w", err) {
+		return
+	}
+	var ptyNumber uint32
+	if e = rawConn.Control(func(fd uintptr) {
+		// unlock the slave side
+		if err = unix.IoctlSetPointerInt(int(fd), unix.TIOCSPTLCK, 0); perrors.IsPF(&err, "TIOCSPTLCK %w", err) {
+			return
+		}
+		ptyNumber, err = unix.IoctlGetUint32(int(fd), unix.TIOCGPTN)
+		perrors.IsPF(&err, "TIOCGPTN %w", err)
+	}); err == nil {
+		err = e
+	}
+	if err != nil {
+		return
+	}
+	var ttyName = "/dev/pts/" + strconv.Itoa(int(ptyNumber))
+	if tty, err = os.OpenFile(ttyName, os.O_RDWR|unix.O_NOCTTY, 0); perrors.IsPF(&err, "open tty %w", err) {
+		return
+	}
+
+	return
+}
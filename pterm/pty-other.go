@@ -0,0 +1,20 @@
+//go:build !darwin && !linux
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"os"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// OpenPty: pseudo-terminals are not supported on this platform
+func OpenPty() (pty, tty *os.File, err error) {
+	err = perrors.NewPF("pseudo-terminal not supported on this platform")
+	return
+}
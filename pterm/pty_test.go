@@ -0,0 +1,36 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/haraldrudell/parl/pexec"
+)
+
+func TestOpenPty(t *testing.T) {
+	//t.Error("Logging on")
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("pseudo-terminal not supported")
+	}
+	var expLines = []string{"terminal"}
+
+	var process, err = pexec.Spawn(context.Background(), pexec.ProcessConfig{Pty: OpenPty},
+		"sh", "-c", "test -t 1 && echo terminal")
+	if err != nil {
+		t.Fatalf("Spawn err: %s", err)
+	}
+	var statusCode int
+	if statusCode, err = process.Wait(); err != nil || statusCode != 0 {
+		t.Errorf("Wait %d err: %v", statusCode, err)
+	}
+	if lines := process.Stdout().GetAll(); !slices.Equal(lines, expLines) {
+		t.Errorf("lines %q exp %q", lines, expLines)
+	}
+}
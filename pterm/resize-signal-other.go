@@ -0,0 +1,13 @@
+//go:build !unix
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import "os"
+
+// resizeSignals: no resize signal on this platform, polling is used
+var resizeSignals []os.Signal
@@ -0,0 +1,16 @@
+//go:build unix
+
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"os"
+	"syscall"
+)
+
+// resizeSignals are signals indicating terminal window resize
+var resizeSignals = []os.Signal{syscall.SIGWINCH}
@@ -0,0 +1,163 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"golang.org/x/term"
+)
+
+const (
+	// default poll interval on platforms without resize signal
+	defaultResizePoll = time.Second
+)
+
+// ResizeConfig configures [ResizeWatcher]
+type ResizeConfig struct {
+	// PollInterval is how often terminal size is polled
+	//	- 0: only resize signal SIGWINCH is used.
+	//		On platforms without resize signal, 1 s
+	PollInterval time.Duration
+	// OnResize is optionally invoked with the new size on each resize
+	//	- invoked on the watcher’s thread
+	OnResize func(width, height int)
+}
+
+// ResizeWatcher detects resizes of a terminal window
+//   - on unix, resize is detected via signal SIGWINCH
+//   - other platforms and the configured poll interval use polling
+//   - [ResizeWatcher.ResizeCh] is an awaitable closing on the next resize
+//   - [ResizeWatcher.Size] is the most recent size
+//   - [ResizeWatcher.Close] ends the watcher thread
+//   - thread-safe
+//
+// Usage:
+//
+//	var watcher = pterm.NewResizeWatcher(int(os.Stderr.Fd()))
+//	defer watcher.Close()
+//	for {
+//	  select {
+//	  case <-watcher.ResizeCh():
+//	    var width, height = watcher.Size()
+//	    …
+type ResizeWatcher struct {
+	fd       int
+	onResize func(width, height int)
+	// getSize is [term.GetSize]
+	getSize func(fd int) (width, height int, err error)
+	// size is [2]int{width, height}
+	size atomic.Pointer[[2]int]
+	// resize is the awaitable closed on next resize
+	resize atomic.Pointer[parl.Awaitable]
+	// signalCh receives resize signals
+	signalCh chan os.Signal
+	// ticker is non-nil when polling
+	ticker *time.Ticker
+	// closeCh closes on Close
+	closeCh parl.Awaitable
+	// done closes when the watcher thread exits
+	done parl.Awaitable
+}
+
+// NewResizeWatcher returns a watcher for terminal fd
+//   - fd: file descriptor of a terminal, typically standard error
+//   - config: optional poll interval and resize callback
+//   - a watcher thread runs until [ResizeWatcher.Close]
+func NewResizeWatcher(fd int, config ...ResizeConfig) (watcher *ResizeWatcher) {
+	return newResizeWatcher(fd, term.GetSize, config...)
+}
+
+// Size returns the most recently observed terminal size
+//   - zero if the size could not be determined
+func (w *ResizeWatcher) Size() (width, height int) {
+	var size = w.size.Load()
+	return size[0], size[1]
+}
+
+// ResizeCh returns a channel that closes on the next resize
+//   - a new channel is returned after each resize
+//   - the channel also closes on Close
+func (w *ResizeWatcher) ResizeCh() (ch parl.AwaitableCh) { return w.resize.Load().Ch() }
+
+// Close stops the watcher and awaits its thread exit
+//   - idempotent
+func (w *ResizeWatcher) Close() {
+	w.closeCh.Close()
+	<-w.done.Ch()
+}
+
+// newResizeWatcher returns a running watcher using getSize
+func newResizeWatcher(fd int, getSize func(fd int) (width, height int, err error), config ...ResizeConfig) (watcher *ResizeWatcher) {
+	var cfg ResizeConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	var w = ResizeWatcher{fd: fd, onResize: cfg.OnResize, getSize: getSize}
+	w.resize.Store(&parl.Awaitable{})
+	var size [2]int
+	size[0], size[1], _ = getSize(fd)
+	w.size.Store(&size)
+
+	// resize signals
+	if len(resizeSignals) > 0 {
+		w.signalCh = make(chan os.Signal, 1)
+		signal.Notify(w.signalCh, resizeSignals...)
+	} else if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultResizePoll
+	}
+	if cfg.PollInterval > 0 {
+		w.ticker = time.NewTicker(cfg.PollInterval)
+	}
+	go w.watcherThread()
+
+	return &w
+}
+
+// watcherThread checks size on resize signal or poll tick
+func (w *ResizeWatcher) watcherThread() {
+	defer w.done.Close()
+	defer func() { w.resize.Load().Close() }()
+	if w.signalCh != nil {
+		defer signal.Stop(w.signalCh)
+	}
+	var tickerC <-chan time.Time
+	if w.ticker != nil {
+		defer w.ticker.Stop()
+		tickerC = w.ticker.C
+	}
+	var closeCh = w.closeCh.Ch()
+
+	for {
+		select {
+		case <-closeCh:
+			return
+		case <-w.signalCh:
+		case <-tickerC:
+		}
+		w.checkSize()
+	}
+}
+
+// checkSize triggers resize if the terminal size changed
+func (w *ResizeWatcher) checkSize() {
+	var size [2]int
+	var err error
+	if size[0], size[1], err = w.getSize(w.fd); err != nil {
+		return // size unavailable, keep previous size
+	} else if size == *w.size.Load() {
+		return // size unchanged return
+	}
+	w.size.Store(&size)
+	if w.onResize != nil {
+		w.onResize(size[0], size[1])
+	}
+	w.resize.Swap(&parl.Awaitable{}).Close()
+}
@@ -0,0 +1,73 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResizeWatcher(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		width0, height0 = 80, 24
+		width1, height1 = 120, 40
+	)
+
+	var width, height int
+	var onResizeWidth atomic.Int64
+	var sizeWidth atomic.Int64
+	sizeWidth.Store(width0)
+	var getSize = func(fd int) (width, height int, err error) {
+		if width = int(sizeWidth.Load()); width == width0 {
+			height = height0
+		} else {
+			height = height1
+		}
+		return
+	}
+	var config = ResizeConfig{
+		PollInterval: time.Millisecond,
+		OnResize:     func(width, height int) { onResizeWidth.Store(int64(width)) },
+	}
+
+	// Size should return initial size
+	var watcher = newResizeWatcher(0, getSize, config)
+	defer watcher.Close()
+	if width, height = watcher.Size(); width != width0 || height != height0 {
+		t.Errorf("Size %d %d exp %d %d", width, height, width0, height0)
+	}
+	var resizeCh = watcher.ResizeCh()
+	select {
+	case <-resizeCh:
+		t.Error("ResizeCh closed without resize")
+	default:
+	}
+
+	// resize should close ResizeCh and invoke OnResize
+	sizeWidth.Store(width1)
+	select {
+	case <-resizeCh:
+	case <-time.After(time.Second):
+		t.Fatal("ResizeCh not closed on resize")
+	}
+	if width, height = watcher.Size(); width != width1 || height != height1 {
+		t.Errorf("Size %d %d exp %d %d", width, height, width1, height1)
+	}
+	if w := onResizeWidth.Load(); w != width1 {
+		t.Errorf("OnResize width %d exp %d", w, width1)
+	}
+
+	// Close should close ResizeCh
+	resizeCh = watcher.ResizeCh()
+	watcher.Close()
+	select {
+	case <-resizeCh:
+	default:
+		t.Error("ResizeCh not closed by Close")
+	}
+}
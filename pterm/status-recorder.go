@@ -0,0 +1,241 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// FrameStatus: status lines were rendered
+	FrameStatus FrameKind = iota + 1
+	// FrameLog: log lines were output to the log stream
+	FrameLog
+	// FrameStdout: log lines were output to standard output
+	FrameStdout
+	// FrameEnd: status output ended
+	FrameEnd
+)
+
+// FrameKind is the type of a recorded [StatusFrame]
+//   - [FrameStatus] [FrameLog] [FrameStdout] [FrameEnd]
+type FrameKind uint8
+
+// StatusFrame is one output operation of a [StatusTerminal]
+// recorded by [StatusRecorder]
+type StatusFrame struct {
+	// Kind is the type of output operation
+	Kind FrameKind `json:"kind"`
+	// Elapsed is time since the recording began
+	Elapsed time.Duration `json:"elapsed"`
+	// Text is status lines for FrameStatus,
+	// log lines ending with newline for FrameLog and FrameStdout
+	Text string `json:"text,omitempty"`
+	// Width is terminal width used to render FrameStatus
+	Width int `json:"width,omitempty"`
+	// Lines is the number of terminal lines occupied by FrameStatus
+	Lines int `json:"lines,omitempty"`
+	// Output is rendered status text excluding cursor movement for FrameStatus
+	Output string `json:"output,omitempty"`
+}
+
+// StatusRecorder captures output of a [StatusTerminal] for tests
+//   - [StatusRecorder.Frames] is the structured sequence of status renders
+//     and log lines that tests can assert on
+//   - [StatusRecorder.Bytes] is the raw output stream including
+//     escape sequences
+//   - [StatusRecorder.WriteJSON] and [ReadStatusFrames] save and load
+//     recorded sessions, [Replay] re-renders them
+//   - StatusRecorder is an io.Writer so that it can be the output backend
+//     of a StatusTerminal without a terminal
+//   - thread-safe
+//
+// Usage:
+//
+//	var recorder, statusTerminal = pterm.NewStatusRecorder(80)
+//	statusTerminal.Status("files: 3")
+//	statusTerminal.Log("copied a.txt")
+//	for _, frame := range recorder.Frames() {
+//	  t.Log(frame)
+//	}
+type StatusRecorder struct {
+	// t0 is when recording began
+	t0   time.Time
+	lock sync.Mutex
+	// frames are recorded frames
+	//	- behind lock
+	frames []StatusFrame
+	// output is raw output written
+	//	- behind lock
+	output bytes.Buffer
+}
+
+// NewStatusRecorder returns a recorder and a status terminal
+// recording to it
+//   - width: terminal width used to render status, minimum 1
+//   - statusTerminal displays status without being a terminal
+//   - ANSI escape sequences are used regardless of environment
+func NewStatusRecorder(width int) (recorder *StatusRecorder, statusTerminal *StatusTerminal) {
+	recorder = &StatusRecorder{t0: time.Now()}
+	statusTerminal = NewStatusTerminalFd(nil, STDefaultFd, recorder)
+	statusTerminal.SetCapabilities(ansiCapabilities)
+	statusTerminal.SetTerminal(true, width)
+	statusTerminal.SetRecorder(recorder)
+
+	return
+}
+
+// Write captures raw output
+//   - io.Writer
+func (r *StatusRecorder) Write(p []byte) (n int, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.output.Write(p)
+}
+
+// Frames returns a copy of recorded frames
+func (r *StatusRecorder) Frames() (frames []StatusFrame) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]StatusFrame(nil), r.frames...)
+}
+
+// Bytes returns a copy of raw output written to the recorder
+func (r *StatusRecorder) Bytes() (output []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return bytes.Clone(r.output.Bytes())
+}
+
+// Reset discards recorded frames and output
+func (r *StatusRecorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.frames = nil
+	r.output.Reset()
+	r.t0 = time.Now()
+}
+
+// WriteJSON writes recorded frames as a JSON array
+func (r *StatusRecorder) WriteJSON(writer io.Writer) (err error) {
+	var encoder = json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(r.Frames()); perrors.IsPF(&err, "encode %w", err) {
+		return
+	}
+	return
+}
+
+// ReadStatusFrames reads frames written by [StatusRecorder.WriteJSON]
+func ReadStatusFrames(reader io.Reader) (frames []StatusFrame, err error) {
+	if err = json.NewDecoder(reader).Decode(&frames); perrors.IsPF(&err, "decode %w", err) {
+		return
+	}
+	return
+}
+
+// Replay re-renders recorded frames on statusTerminal
+//   - realTime true: frames are output at their recorded pace
+//   - statusTerminal not being a terminal renders status at the
+//     recorded width
+//   - err: ctx cancel while awaiting a frame
+func Replay(ctx context.Context, frames []StatusFrame, statusTerminal *StatusTerminal, realTime ...bool) (err error) {
+	if statusTerminal == nil {
+		panic(parl.NilError("statusTerminal"))
+	}
+	var isRealTime = len(realTime) > 0 && realTime[0]
+	var t0 = time.Now()
+	for i := range frames {
+		var frame = &frames[i]
+		if isRealTime {
+			if d := frame.Elapsed - time.Since(t0); d > 0 {
+				var timer = time.NewTimer(d)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					err = perrors.ErrorfPF("replay frame %d: %w", i, context.Cause(ctx))
+					return
+				case <-timer.C:
+				}
+			}
+		}
+		switch frame.Kind {
+		case FrameStatus:
+			if !statusTerminal.isTermTerminal && frame.Width > 0 {
+				statusTerminal.width.Store(int64(frame.Width))
+			}
+			statusTerminal.Status(frame.Text)
+		case FrameLog:
+			statusTerminal.Log(frame.Text)
+		case FrameStdout:
+			statusTerminal.LogStdout(frame.Text)
+		case FrameEnd:
+			statusTerminal.EndStatus()
+		}
+	}
+
+	return
+}
+
+// record appends a frame
+func (r *StatusRecorder) record(frame StatusFrame) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	frame.Elapsed = time.Since(r.t0)
+	r.frames = append(r.frames, frame)
+}
+
+// “status 80 2 "files: 3"”
+func (f StatusFrame) String() (s string) {
+	if f.Kind == FrameStatus {
+		return parl.Sprintf("%s %d %d %q", f.Kind, f.Width, f.Lines, f.Text)
+	}
+	return parl.Sprintf("%s %q", f.Kind, f.Text)
+}
+
+// “status” “log” “stdout” “end”
+func (k FrameKind) String() (s string) {
+	switch k {
+	case FrameStatus:
+		return "status"
+	case FrameLog:
+		return "log"
+	case FrameStdout:
+		return "stdout"
+	case FrameEnd:
+		return "end"
+	}
+	return parl.Sprintf("?frameKind%d", k)
+}
+
+// MarshalText encodes kind as its name
+func (k FrameKind) MarshalText() (text []byte, err error) { return []byte(k.String()), nil }
+
+// UnmarshalText decodes a kind name
+func (k *FrameKind) UnmarshalText(text []byte) (err error) {
+	for kind := FrameStatus; kind <= FrameEnd; kind++ {
+		if kind.String() == string(text) {
+			*k = kind
+			return
+		}
+	}
+	err = perrors.ErrorfPF("bad frame kind: %q", text)
+
+	return
+}
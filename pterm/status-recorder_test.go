@@ -0,0 +1,101 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStatusRecorder(t *testing.T) {
+	//t.Error("Logging on")
+	var width = 10
+	var expKinds = []FrameKind{FrameStatus, FrameLog, FrameStatus, FrameEnd}
+	var expTexts = []string{"status1", "log1\n", "0123456789ab\nline2", ""}
+
+	var recorder, statusTerminal = NewStatusRecorder(width)
+	statusTerminal.Status("status1")
+	statusTerminal.Log("log1")
+	statusTerminal.Status(expTexts[2])
+	statusTerminal.EndStatus()
+	// after EndStatus, status is ignored
+	statusTerminal.Status("ignored")
+
+	var frames = recorder.Frames()
+	var kinds = make([]FrameKind, len(frames))
+	var texts = make([]string, len(frames))
+	for i, frame := range frames {
+		kinds[i] = frame.Kind
+		texts[i] = frame.Text
+	}
+	if !slices.Equal(kinds, expKinds) {
+		t.Fatalf("kinds %v exp %v", kinds, expKinds)
+	}
+	if !slices.Equal(texts, expTexts) {
+		t.Errorf("texts %q exp %q", texts, expTexts)
+	}
+	// first status occupies 1 line: 0 lines above cursor
+	if frames[0].Width != width || frames[0].Lines != 0 {
+		t.Errorf("frame0 width %d lines %d", frames[0].Width, frames[0].Lines)
+	}
+	// 12 characters wrap at width 10, plus a newline: 2 lines above cursor
+	if frames[2].Lines != 2 {
+		t.Errorf("frame2 lines %d exp 2", frames[2].Lines)
+	}
+	// log output clears and restores status
+	var output = string(recorder.Bytes())
+	if !strings.Contains(output, "log1\nstatus1") {
+		t.Errorf("output %q", output)
+	}
+
+	// JSON round-trip
+	var buffer bytes.Buffer
+	if err := recorder.WriteJSON(&buffer); err != nil {
+		t.Fatalf("WriteJSON err: %s", err)
+	}
+	if !strings.Contains(buffer.String(), `"kind": "status"`) {
+		t.Errorf("json %s", buffer.String())
+	}
+	var frames2, err = ReadStatusFrames(&buffer)
+	if err != nil {
+		t.Fatalf("ReadStatusFrames err: %s", err)
+	}
+	if !slices.Equal(frames2, frames) {
+		t.Errorf("frames2 %v exp %v", frames2, frames)
+	}
+
+	// replay re-renders the same output
+	var recorder2, statusTerminal2 = NewStatusRecorder(1)
+	if err = Replay(context.Background(), frames2, statusTerminal2); err != nil {
+		t.Fatalf("Replay err: %s", err)
+	}
+	if output2 := string(recorder2.Bytes()); output2 != output {
+		t.Errorf("replay output\n%q exp\n%q", output2, output)
+	}
+	var frames3 = recorder2.Frames()
+	for i := range frames3 {
+		frames3[i].Elapsed = frames[i].Elapsed
+	}
+	if !slices.Equal(frames3, frames) {
+		t.Errorf("replay frames %v exp %v", frames3, frames)
+	}
+}
+
+func TestFrameKindText(t *testing.T) {
+	var kind FrameKind
+	if err := kind.UnmarshalText([]byte("stdout")); err != nil || kind != FrameStdout {
+		t.Errorf("UnmarshalText %s err %v", kind, err)
+	}
+	if err := kind.UnmarshalText([]byte("x")); err == nil {
+		t.Error("UnmarshalText missing error")
+	}
+	if s := FrameKind(0).String(); s != "?frameKind0" {
+		t.Errorf("String %q", s)
+	}
+}
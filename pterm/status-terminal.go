@@ -4,6 +4,10 @@ ISC License
 */
 
 // Package pterm provides an ANSI-based status terminal and password-input.
+//   - terminal types with other or no escape sequences are handled by [Capabilities]
+//   - status lines can be composed from widgets like [Bar] using [Compositor]
+//   - [Dashboard] is a full-screen mode of widget panes and a scrollable log
+//   - [StatusRecorder] records status output for tests, [Replay] re-renders it
 package pterm
 
 import (
@@ -15,6 +19,7 @@ import (
 
 	"github.com/haraldrudell/parl"
 	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/pstrings"
 	"golang.org/x/term"
 )
 
@@ -54,6 +59,9 @@ type WriterWrite func(p []byte) (n int, err error)
 //   - when in status mode, each Print and Write invocations must
 //     output in discrete lines
 //   - LogTimeStamp prepends compact and specific timestamping
+//   - escape sequences are from [Capabilities] detected from TERM.
+//     A terminal type without cursor movement does not display status
+//   - [StatusTerminal.WatchResize] re-renders status on window resize
 //
 // [ANSI escape codes]: https://en.wikipedia.org/wiki/ANSI_escape_code
 type StatusTerminal struct {
@@ -73,6 +81,14 @@ type StatusTerminal struct {
 
 	// no more status should be output
 	statusEnded atomic.Bool
+	// capabilities are escape sequences of the terminal type
+	capabilities atomic.Pointer[Capabilities]
+	// statusLines is the most recent Status argument used for re-render
+	statusLines atomic.Pointer[string]
+	// resizeWatcher is set by WatchResize
+	resizeWatcher atomic.Pointer[ResizeWatcher]
+	// recorder is set by SetRecorder
+	recorder atomic.Pointer[StatusRecorder]
 
 	lock             sync.Mutex
 	displayLineCount int                // behind lock: number of terminal lines occupied by the current status
@@ -135,8 +151,11 @@ func NewStatusTerminalFd(fieldp *StatusTerminal, fd int, writer io.Writer, copyL
 	// IsTerminal
 	//	- isTermTerminal is if the stream actually is a terminal
 	//	- IsTerminal is whether the stream should be treated as a terminal
+	//	- a terminal type without cursor movement, like “dumb”, does not display status
+	var capabilities = DetectCapabilities()
+	statusTerminal.capabilities.Store(&capabilities)
 	if statusTerminal.isTermTerminal = term.IsTerminal(statusTerminal.Fd); statusTerminal.isTermTerminal {
-		statusTerminal.IsTerminal.Store(true)
+		statusTerminal.IsTerminal.Store(capabilities.CanStatus())
 	}
 
 	return
@@ -148,10 +167,12 @@ func (s *StatusTerminal) Status(statusLines string) {
 	if !s.IsTerminal.Load() || s.statusEnded.Load() {
 		return // no status if not terminal or EndStatus
 	}
+	s.statusLines.Store(&statusLines)
 	width := s.Width()
 	if width == 0 {
 		return // zero window width return
 	}
+	var capabilities = s.getCapabilities()
 
 	// split s into lines
 	var lines = strings.Split(statusLines, NewLine) // empty string has slice length 1, empty line
@@ -191,8 +212,7 @@ func (s *StatusTerminal) Status(statusLines string) {
 	output := ""
 	lastIndex := len(lines) - 1
 	for i, line := range lines {
-		printablesLine := TrimANSIEscapes(line)
-		length := len([]rune(printablesLine)) // length in unicode code points
+		length := pstrings.Width(line) // terminal columns
 		var cursorAtEndOfLine bool
 		// if length exactly matches width, cursor is still on the same line
 		// therefore subtract 1 character from length
@@ -205,7 +225,7 @@ func (s *StatusTerminal) Status(statusLines string) {
 
 		if i < lastIndex {
 			if !cursorAtEndOfLine {
-				output += EraseEndOfLine
+				output += capabilities.EraseEndOfLine
 			}
 			displayLineCount++ // count the newline
 			d.metaCountedNewlines++
@@ -225,11 +245,21 @@ func (s *StatusTerminal) Status(statusLines string) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	s.Print(s.clearStatus() + output + EraseEndOfDisplay)
+	s.Print(s.clearStatus() + output + capabilities.EraseEndOfDisplay)
 
 	// save display status
 	s.output = output
 	s.displayLineCount = displayLineCount
+
+	if recorder := s.recorder.Load(); recorder != nil {
+		recorder.record(StatusFrame{
+			Kind:   FrameStatus,
+			Text:   statusLines,
+			Width:  width,
+			Lines:  displayLineCount,
+			Output: output,
+		})
+	}
 }
 
 // LogTimeStamp outputs text ending with at least one newline while maintaining status information
@@ -270,6 +300,31 @@ func (s *StatusTerminal) SetTerminal(isTerminal bool, width int) {
 	s.IsTerminal.Store(isTerminal)
 }
 
+// SetRecorder records output operations to recorder
+//   - nil: stop recording
+//   - [NewStatusRecorder] returns a recording status terminal
+//   - thread-safe
+func (s *StatusTerminal) SetRecorder(recorder *StatusRecorder) { s.recorder.Store(recorder) }
+
+// SetCapabilities sets the escape sequences used for the terminal type
+//   - default is [DetectCapabilities] from environment
+//   - a terminal stream displays status if capabilities have cursor movement
+//   - thread-safe
+func (s *StatusTerminal) SetCapabilities(capabilities Capabilities) {
+	s.capabilities.Store(&capabilities)
+	if s.isTermTerminal {
+		s.IsTerminal.Store(capabilities.CanStatus())
+	}
+}
+
+// Capabilities returns the escape sequences used for the terminal type
+func (s *StatusTerminal) Capabilities() (capabilities Capabilities) {
+	if c := s.capabilities.Load(); c != nil {
+		capabilities = *c
+	}
+	return
+}
+
 // Width returns the current column width of the window
 //   - if not a terminal, the stored width value
 func (s *StatusTerminal) Width() (width int) {
@@ -284,6 +339,40 @@ func (s *StatusTerminal) Width() (width int) {
 	return
 }
 
+// WatchResize re-renders the current status when the terminal window is resized
+//   - without WatchResize, a resize is only reflected by the next Status invocation
+//   - watcher: provides [ResizeWatcher.ResizeCh] for applications that
+//     need to re-layout on resize
+//   - config: optional poll interval and resize callback. The callback is
+//     invoked after status re-render
+//   - the watcher ends on EndStatus
+//   - idempotent: config is only used by the first invocation
+func (s *StatusTerminal) WatchResize(config ...ResizeConfig) (watcher *ResizeWatcher) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if watcher = s.resizeWatcher.Load(); watcher != nil {
+		return // already watching return
+	}
+	var cfg ResizeConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	var onResize = cfg.OnResize
+	cfg.OnResize = func(width, height int) {
+		if statusLines := s.statusLines.Load(); statusLines != nil {
+			s.Status(*statusLines)
+		}
+		if onResize != nil {
+			onResize(width, height)
+		}
+	}
+	watcher = NewResizeWatcher(s.Fd, cfg)
+	s.resizeWatcher.Store(watcher)
+
+	return
+}
+
 // CopyLog adds writers that receives copies of non-status logging
 //   - remove true stops output for a writer
 func (s *StatusTerminal) CopyLog(writer io.Writer, remove ...bool) {
@@ -316,6 +405,10 @@ func (s *StatusTerminal) EndStatus() {
 	if s.statusEnded.Load() {
 		return //already end
 	}
+	// close outside lock: the watcher thread may be awaiting lock
+	if watcher := s.resizeWatcher.Load(); watcher != nil {
+		watcher.Close()
+	}
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
@@ -324,6 +417,9 @@ func (s *StatusTerminal) EndStatus() {
 	}
 	s.output = ""
 	s.Print(NewLine)
+	if recorder := s.recorder.Load(); recorder != nil {
+		recorder.record(StatusFrame{Kind: FrameEnd})
+	}
 }
 
 func (s *StatusTerminal) doLog(isStdout bool, format string, a ...any) {
@@ -349,6 +445,13 @@ func (s *StatusTerminal) doLog(isStdout bool, format string, a ...any) {
 	for writer := range s.copyLog {
 		s.write(logLinesNewline, writer.Write)
 	}
+	if recorder := s.recorder.Load(); recorder != nil {
+		var kind = FrameLog
+		if isStdout {
+			kind = FrameStdout
+		}
+		recorder.record(StatusFrame{Kind: kind, Text: logLinesNewline})
+	}
 }
 
 func (s *StatusTerminal) doStatus(isStdout bool, logLines string) {
@@ -367,7 +470,10 @@ func (s *StatusTerminal) doStatus(isStdout bool, logLines string) {
 // clearStatus returns ANSI codes to clear the status area if any
 func (s *StatusTerminal) clearStatus() (clearStatusSequence string) {
 	if len(s.output) > 0 {
-		clearStatusSequence = MoveCursorToColumnZero + strings.Repeat(CursorUp, s.displayLineCount) + EraseEndOfDisplay
+		var capabilities = s.getCapabilities()
+		clearStatusSequence = capabilities.CarriageReturn +
+			strings.Repeat(capabilities.CursorUp, s.displayLineCount) +
+			capabilities.EraseEndOfDisplay
 	}
 	return
 }
@@ -375,7 +481,15 @@ func (s *StatusTerminal) clearStatus() (clearStatusSequence string) {
 // restoreStatus returns the ANSI code to restore status if any
 func (s *StatusTerminal) restoreStatus() (restoreStatusSequence string) {
 	if len(s.output) > 0 {
-		restoreStatusSequence = s.output + EraseEndOfDisplay
+		restoreStatusSequence = s.output + s.getCapabilities().EraseEndOfDisplay
+	}
+	return
+}
+
+// getCapabilities returns capabilities, ANSI for a zero-value StatusTerminal
+func (s *StatusTerminal) getCapabilities() (capabilities *Capabilities) {
+	if capabilities = s.capabilities.Load(); capabilities == nil {
+		capabilities = &ansiCapabilities
 	}
 	return
 }
@@ -6,18 +6,12 @@ ISC License
 package pterm
 
 import (
-	"regexp"
+	"github.com/haraldrudell/parl/pstrings"
 )
 
-const ansi = "[\u001B\u009B]" +
-	"[[\\]()#;?]*(?:" +
-	"(?:(?:[a-zA-Z\\d]*(?:;[a-zA-Z\\d]*)*)?\u0007)|" +
-	"(?:(?:\\d{1,4}(?:;\\d{0,4})*)?[\\dA-PRZcf-ntqry=><~]))"
-
-var re = regexp.MustCompile(ansi)
-
 // TrimANSIEscapes returns a string where ANSI espace sequences
 // have been removed.
+//   - implemented by [pstrings.TrimANSIEscapes]
 func TrimANSIEscapes(s string) (s1 string) {
-	return re.ReplaceAllString(s, "")
+	return pstrings.TrimANSIEscapes(s)
 }
@@ -0,0 +1,304 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package pterm
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/haraldrudell/parl/pstrings"
+)
+
+const (
+	// [NewBar] [NewCounter] [NewRate] no label
+	NoLabel = ""
+	// spinner frames
+	spinnerFrames = `|/-\`
+	// bar characters
+	barFull, barHead, barEmpty = '=', '>', '\x20'
+	// bar minimum width for brackets, head and one empty
+	barMinBarWidth = 4
+	// widest percent: “100%”
+	barPercentWidth = 4
+)
+
+// Widget is a status-area element rendering to a string of limited width
+//   - widgets are composed into status lines by [Compositor]
+//   - width is in unicode code points as used by [StatusTerminal.Width]
+//   - Widget implementations are thread-safe:
+//     values may be updated while another thread renders
+type Widget interface {
+	// Widths returns the widget’s minimum and preferred width
+	//	- minWidth: the narrowest useful rendering
+	//	- preferredWidth: the widest useful rendering.
+	//		0 means flexible: the widget uses any remaining width
+	Widths() (minWidth, preferredWidth int)
+	// Render returns a string of at most width code points
+	//	- width is at least minWidth
+	Render(width int) (s string)
+}
+
+// Bar is a progress bar “[=====>    ] 45%”
+//   - narrow terminal: only percent “45%”
+//   - flexible width
+type Bar struct {
+	label string
+	value atomic.Int64
+	total atomic.Int64
+}
+
+// NewBar returns a progress bar
+//   - label: optional text preceding the bar: [NoLabel]
+//   - total: the value for 100%. 0: unknown total
+func NewBar(label string, total int64) (bar *Bar) {
+	bar = &Bar{label: label}
+	bar.total.Store(total)
+	return
+}
+
+// Set updates the current value
+func (b *Bar) Set(value int64) { b.value.Store(value) }
+
+// Add adds delta to the current value
+func (b *Bar) Add(delta int64) { b.value.Add(delta) }
+
+// SetTotal updates the value for 100%
+func (b *Bar) SetTotal(total int64) { b.total.Store(total) }
+
+// Widths: minimum is percent, flexible
+func (b *Bar) Widths() (minWidth, preferredWidth int) { return barPercentWidth, 0 }
+
+// Render returns the bar using at most width
+func (b *Bar) Render(width int) (s string) {
+	var fraction = b.fraction()
+	var percent = strconv.Itoa(int(fraction*100)) + "%"
+	var label string
+	if b.label != "" {
+		label = b.label + Space
+	}
+	var barWidth = width - utf8.RuneCountInString(label) - len(Space) - barPercentWidth
+	if barWidth < barMinBarWidth {
+		if barWidth = width - len(Space) - barPercentWidth; barWidth < barMinBarWidth {
+			return percent // narrow: only percent
+		}
+		label = "" // drop label prior to bar
+	}
+
+	// inner bar excluding brackets
+	var inner = barWidth - 2
+	var full = int(fraction * float64(inner))
+	var sb strings.Builder
+	sb.WriteString(label)
+	sb.WriteByte('[')
+	for i := 0; i < inner; i++ {
+		switch {
+		case i < full:
+			sb.WriteRune(barFull)
+		case i == full && full < inner:
+			sb.WriteRune(barHead)
+		default:
+			sb.WriteRune(barEmpty)
+		}
+	}
+	sb.WriteString("]" + Space)
+	sb.WriteString(pad(percent, barPercentWidth))
+
+	return sb.String()
+}
+
+// fraction returns progress 0…1
+func (b *Bar) fraction() (fraction float64) {
+	var total = b.total.Load()
+	if total <= 0 {
+		return
+	}
+	if fraction = float64(b.value.Load()) / float64(total); fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+	return
+}
+
+// Spinner is a one-character activity indicator “|/-\”
+//   - each Render advances the spinner
+type Spinner struct {
+	frame atomic.Uint64
+}
+
+// NewSpinner returns an activity indicator
+func NewSpinner() (spinner *Spinner) { return &Spinner{} }
+
+// Widths is one character
+func (p *Spinner) Widths() (minWidth, preferredWidth int) { return 1, 1 }
+
+// Render returns the next frame
+func (p *Spinner) Render(width int) (s string) {
+	var i = int((p.frame.Add(1) - 1) % uint64(len(spinnerFrames)))
+	return spinnerFrames[i : i+1]
+}
+
+// Counter is a labeled count “files: 1234”
+//   - narrow terminal: only count “1234”
+type Counter struct {
+	label string
+	value atomic.Int64
+}
+
+// NewCounter returns a labeled counter
+//   - label: optional: [NoLabel]
+func NewCounter(label string) (counter *Counter) { return &Counter{label: label} }
+
+// Set updates the count
+func (c *Counter) Set(value int64) { c.value.Store(value) }
+
+// Add adds delta to the count
+func (c *Counter) Add(delta int64) { c.value.Add(delta) }
+
+// Value returns the count
+func (c *Counter) Value() (value int64) { return c.value.Load() }
+
+// Widths: minimum is count, preferred has label
+func (c *Counter) Widths() (minWidth, preferredWidth int) {
+	var count = c.count()
+	minWidth = utf8.RuneCountInString(count)
+	preferredWidth = utf8.RuneCountInString(withLabel(c.label, count))
+	return
+}
+
+// Render returns the counter using at most width
+func (c *Counter) Render(width int) (s string) {
+	var count = c.count()
+	if s = withLabel(c.label, count); utf8.RuneCountInString(s) > width {
+		s = count
+	}
+	return
+}
+
+// count returns count as string
+func (c *Counter) count() (s string) { return strconv.FormatInt(c.value.Load(), 10) }
+
+// Rate is the per-second rate of a count “12.3/s” “1.50 MB/s”
+//   - the rate is calculated for the time between renderings
+type Rate struct {
+	label string
+	unit  string
+	count atomic.Int64
+
+	lock      sync.Mutex
+	lastCount int64     // behind lock: count at last rendering
+	lastTime  time.Time // behind lock: time of last rendering
+	rate      float64   // behind lock: last calculated rate
+}
+
+// NewRate returns a rate
+//   - label: optional: [NoLabel]
+//   - unit: printed with rate “/s” “B/s”. empty: “/s”
+//   - the rate has SI prefix and three significant digits: [pstrings.SI]
+func NewRate(label, unit string) (rate *Rate) {
+	if unit == "" {
+		unit = "/s"
+	}
+	return &Rate{label: label, unit: unit}
+}
+
+// Add adds to the count
+func (r *Rate) Add(delta int64) { r.count.Add(delta) }
+
+// Widths: minimum is rate, preferred has label
+func (r *Rate) Widths() (minWidth, preferredWidth int) {
+	var rate = r.format(r.peekRate())
+	minWidth = utf8.RuneCountInString(rate)
+	preferredWidth = utf8.RuneCountInString(withLabel(r.label, rate))
+	return
+}
+
+// Render returns the rate using at most width
+func (r *Rate) Render(width int) (s string) {
+	var rate = r.format(r.sample())
+	if s = withLabel(r.label, rate); utf8.RuneCountInString(s) > width {
+		s = rate
+	}
+	return
+}
+
+// sample updates and returns the rate
+func (r *Rate) sample() (rate float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var now = time.Now()
+	var count = r.count.Load()
+	if !r.lastTime.IsZero() {
+		if elapsed := now.Sub(r.lastTime); elapsed > 0 {
+			r.rate = float64(count-r.lastCount) / elapsed.Seconds()
+		}
+	}
+	r.lastCount = count
+	r.lastTime = now
+
+	return r.rate
+}
+
+// peekRate returns the last calculated rate
+func (r *Rate) peekRate() (rate float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.rate
+}
+
+// format returns rate with unit “12.3/s” “1.50 kB/s”
+func (r *Rate) format(rate float64) (s string) { return pstrings.SI(rate, r.unit) }
+
+// Text is static text truncated to available width
+type Text struct {
+	text atomic.Pointer[string]
+}
+
+// NewText returns a text widget
+func NewText(text string) (t *Text) {
+	t = &Text{}
+	t.Set(text)
+	return
+}
+
+// Set updates the text
+func (t *Text) Set(text string) { t.text.Store(&text) }
+
+// Widths: minimum is one character, preferred is the text
+func (t *Text) Widths() (minWidth, preferredWidth int) {
+	return 1, utf8.RuneCountInString(*t.text.Load())
+}
+
+// Render returns text truncated to width
+func (t *Text) Render(width int) (s string) {
+	s = *t.text.Load()
+	if utf8.RuneCountInString(s) > width {
+		s = string([]rune(s)[:width])
+	}
+	return
+}
+
+// withLabel returns “label: value”
+func withLabel(label, value string) (s string) {
+	if label == "" {
+		return value
+	}
+	return label + ":" + Space + value
+}
+
+// pad left-pads s with spaces to width
+func pad(s string, width int) (s2 string) {
+	if n := width - utf8.RuneCountInString(s); n > 0 {
+		return strings.Repeat(Space, n) + s
+	}
+	return s
+}
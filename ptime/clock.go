@@ -0,0 +1,127 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package ptime
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a source of time and timers
+//   - [SystemClock] is the time package
+//   - [TestClock] is a controllable clock for deterministic tests
+//   - time-dependent code receives a Clock rather than
+//     using the time package directly
+type Clock interface {
+	// Now returns the current time
+	Now() (now time.Time)
+	// NewTimer returns a running timer expiring after d
+	NewTimer(d time.Duration) (timer Timer)
+	// NewTicker returns a ticker sending every d
+	//	- d must be positive or panic
+	NewTicker(d time.Duration) (ticker Ticker)
+	// Sleep blocks for d
+	Sleep(d time.Duration)
+}
+
+// Timer is a timer provided by a [Clock]
+//   - similar to [time.Timer] but C is a method
+//   - Stop and Reset are thread-safe
+//   - Reset can be invoked at any time: it stops and drains the timer
+type Timer interface {
+	// C returns the channel on which the time is delivered
+	C() (ch <-chan time.Time)
+	// Stop prevents the timer from firing
+	Stop() (wasRunning bool)
+	// Reset changes the timer to expire after d
+	Reset(d time.Duration) (wasRunning bool)
+}
+
+// Ticker is a ticker provided by a [Clock]
+//   - similar to [time.Ticker] but C is a method
+type Ticker interface {
+	// C returns the channel on which ticks are delivered
+	C() (ch <-chan time.Time)
+	// Stop turns off the ticker
+	Stop()
+	// Reset stops the ticker and resets its period to d
+	Reset(d time.Duration)
+}
+
+// SystemClock is a [Clock] using the time package
+var SystemClock Clock = systemClock{}
+
+// GetClock returns optional clock or [SystemClock]
+//   - used by functions taking an optional clock argument
+func GetClock(clock ...Clock) (clock0 Clock) {
+	if len(clock) > 0 {
+		if clock0 = clock[0]; clock0 != nil {
+			return
+		}
+	}
+	return SystemClock
+}
+
+// systemClock is [Clock] implemented using the time package
+type systemClock struct{}
+
+// systemTimer is a [Timer] wrapping [time.Timer]
+type systemTimer struct {
+	// resetLock makes Stop, drain and Reset sequence atomic
+	resetLock sync.Mutex
+	timer     *time.Timer
+}
+
+// systemTicker is a [Ticker] wrapping [time.Ticker]
+type systemTicker struct{ *time.Ticker }
+
+// Now returns time.Now()
+func (systemClock) Now() (now time.Time) { return time.Now() }
+
+// NewTimer returns a time.Timer based timer
+func (systemClock) NewTimer(d time.Duration) (timer Timer) {
+	return &systemTimer{timer: time.NewTimer(d)}
+}
+
+// NewTicker returns a time.Ticker based ticker
+func (systemClock) NewTicker(d time.Duration) (ticker Ticker) {
+	return systemTicker{Ticker: time.NewTicker(d)}
+}
+
+// Sleep invokes time.Sleep
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// C returns the channel of the time.Timer
+func (t *systemTimer) C() (ch <-chan time.Time) { return t.timer.C }
+
+// Stop stops the time.Timer
+func (t *systemTimer) Stop() (wasRunning bool) {
+	t.resetLock.Lock()
+	defer t.resetLock.Unlock()
+
+	return t.timer.Stop()
+}
+
+// Reset is Stop-drain-Reset of the time.Timer
+func (t *systemTimer) Reset(d time.Duration) (wasRunning bool) {
+	t.resetLock.Lock()
+	defer t.resetLock.Unlock()
+
+	// Reset should be invoked only on:
+	//	- stopped or expired timers
+	//	- with drained channels
+	wasRunning = t.timer.Stop()
+	select {
+	case <-t.timer.C:
+	default:
+	}
+	t.timer.Reset(d)
+
+	return
+}
+
+// C returns the channel of the time.Ticker
+func (t systemTicker) C() (ch <-chan time.Time) { return t.Ticker.C }
@@ -29,7 +29,8 @@ type Go interface {
 //   - OnTickerThread eliminates channel receive actions at
 //     the cost of an additional OnTickerThread thread
 //   - a thread-less on-period ticker is OnTicker
-func OnTickerThread(callback func(at time.Time), period time.Duration, loc *time.Location, g Go) {
+//   - clock: optional clock, default [SystemClock]
+func OnTickerThread(callback func(at time.Time), period time.Duration, loc *time.Location, g Go, clock ...Clock) {
 	var err error
 	if g != nil {
 		defer g.Done(&err)
@@ -42,12 +43,19 @@ func OnTickerThread(callback func(at time.Time), period time.Duration, loc *time
 	if callback == nil {
 		err = perrors.NewPF("callback cannot be nil")
 		return
+	} else if period <= 0 {
+		panic(perrors.NewPF("period must be positive"))
 	}
+	if loc == nil {
+		loc = time.Local
+	}
+	var clock0 = GetClock(clock...)
 
-	var ticker = NewOnTicker(period, loc)
-	defer ticker.Stop()
+	// timer is re-armed to the next period multiple after each tick
+	var timer = clock0.NewTimer(Duro(period, clock0.Now().In(loc)))
+	defer timer.Stop()
 
-	C := ticker.C
+	C := timer.C()
 	var done <-chan struct{}
 	if g != nil {
 		done = g.Context().Done()
@@ -59,6 +67,7 @@ func OnTickerThread(callback func(at time.Time), period time.Duration, loc *time
 			return // context canceled return
 		case t = <-C:
 		}
+		timer.Reset(Duro(period, clock0.Now().In(loc)))
 
 		callback(t)
 	}
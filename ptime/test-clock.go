@@ -0,0 +1,217 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package ptime
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// TestClock is a controllable [Clock] for deterministic tests
+//   - time only changes by [TestClock.Advance] or [TestClock.Set]
+//   - timers and tickers fire during Advance in expiry order
+//   - like the time package, timer and ticker channels have
+//     capacity 1 and ticks are dropped if not read
+//   - [TestClock.AwaitWaiters] synchronizes with threads
+//     creating timers, sleeping or resetting
+//   - thread-safe
+//
+// Usage:
+//
+//	var clock = ptime.NewTestClock()
+//	go threadUsing(clock)
+//	clock.AwaitWaiters(1)
+//	clock.Advance(time.Second)
+type TestClock struct {
+	// lock makes fields thread-safe
+	lock sync.Mutex
+	// cond signals change in waiters, uses lock
+	cond sync.Cond
+	// now is current time, behind lock
+	now time.Time
+	// waiters are running timers and tickers, behind lock
+	waiters []*testTimer
+}
+
+// testTimer is a timer or ticker of [TestClock]
+type testTimer struct {
+	clock *TestClock
+	ch    chan time.Time
+	// period is non-zero for tickers, behind clock.lock
+	period time.Duration
+	// expires is when the timer fires, behind clock.lock
+	expires time.Time
+}
+
+// testTicker is [Ticker] implementation
+type testTicker struct{ *testTimer }
+
+// NewTestClock returns a controllable clock
+//   - t0: optional initial time, default 2024-01-01 00:00:00 UTC
+func NewTestClock(t0 ...time.Time) (clock *TestClock) {
+	var c = TestClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if len(t0) > 0 {
+		c.now = t0[0]
+	}
+	c.cond.L = &c.lock
+	return &c
+}
+
+// Now returns the test clock’s current time
+func (c *TestClock) Now() (now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+// NewTimer returns a timer expiring when the clock has advanced d
+//   - d zero or negative: the timer fires on next Advance
+func (c *TestClock) NewTimer(d time.Duration) (timer Timer) {
+	var t = &testTimer{clock: c, ch: make(chan time.Time, 1)}
+	c.start(t, d, 0)
+	return t
+}
+
+// NewTicker returns a ticker firing every d of clock advance
+func (c *TestClock) NewTicker(d time.Duration) (ticker Ticker) {
+	if d <= 0 {
+		panic(perrors.ErrorfPF("non-positive interval: %s", d))
+	}
+	var t = &testTimer{clock: c, ch: make(chan time.Time, 1)}
+	c.start(t, d, d)
+	return testTicker{testTimer: t}
+}
+
+// Sleep blocks until the clock has advanced d
+func (c *TestClock) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	<-c.NewTimer(d).C()
+}
+
+// Advance moves time forward by d firing expired timers and tickers
+func (c *TestClock) Advance(d time.Duration) { c.Set(c.Now().Add(d)) }
+
+// Set moves time to t firing expired timers and tickers
+//   - t before current time is ignored
+func (c *TestClock) Set(t time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for {
+		// find the earliest expiring timer
+		var index = -1
+		for i, w := range c.waiters {
+			if !w.expires.After(t) && (index == -1 || w.expires.Before(c.waiters[index].expires)) {
+				index = i
+			}
+		}
+		if index == -1 {
+			break // no more expired timers
+		}
+		var w = c.waiters[index]
+		if w.expires.After(c.now) {
+			c.now = w.expires
+		}
+		select {
+		case w.ch <- c.now:
+		default: // dropped tick
+		}
+		if w.period > 0 {
+			w.expires = w.expires.Add(w.period)
+		} else {
+			c.waiters = slices.Delete(c.waiters, index, index+1)
+			c.cond.Broadcast()
+		}
+	}
+	if t.After(c.now) {
+		c.now = t
+	}
+}
+
+// Waiters returns the number of running timers and tickers
+func (c *TestClock) Waiters() (count int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.waiters)
+}
+
+// AwaitWaiters blocks until at least count timers and tickers are running
+//   - used to ensure a thread is waiting prior to Advance
+func (c *TestClock) AwaitWaiters(count int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for len(c.waiters) < count {
+		c.cond.Wait()
+	}
+}
+
+// start makes t expire after d
+//   - period: non-zero for tickers
+func (c *TestClock) start(t *testTimer, d, period time.Duration) (wasRunning bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	wasRunning = c.remove(t)
+	t.period = period
+	t.expires = c.now.Add(d)
+	c.waiters = append(c.waiters, t)
+	c.cond.Broadcast()
+
+	return
+}
+
+// remove stops t, behind lock
+func (c *TestClock) remove(t *testTimer) (wasRunning bool) {
+	var index = slices.Index(c.waiters, t)
+	if wasRunning = index != -1; wasRunning {
+		c.waiters = slices.Delete(c.waiters, index, index+1)
+	}
+	return
+}
+
+// C returns the channel receiving the expiry time
+func (t *testTimer) C() (ch <-chan time.Time) { return t.ch }
+
+// Stop prevents the timer from firing
+func (t *testTimer) Stop() (wasRunning bool) {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	return t.clock.remove(t)
+}
+
+// Reset drains the channel and makes the timer expire after d
+func (t *testTimer) Reset(d time.Duration) (wasRunning bool) {
+	t.drain()
+	return t.clock.start(t, d, 0)
+}
+
+// drain empties the channel
+func (t *testTimer) drain() {
+	select {
+	case <-t.ch:
+	default:
+	}
+}
+
+// Stop turns off the ticker
+func (t testTicker) Stop() { t.testTimer.Stop() }
+
+// Reset restarts the ticker with period d
+func (t testTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic(perrors.ErrorfPF("non-positive interval: %s", d))
+	}
+	t.clock.start(t.testTimer, d, d)
+}
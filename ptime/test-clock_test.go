@@ -0,0 +1,89 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package ptime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTestClock(t *testing.T) {
+	//t.Error("Logging on")
+	var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var clock = NewTestClock(t0)
+	var timer = clock.NewTimer(time.Second)
+	var ticker = clock.NewTicker(400 * time.Millisecond)
+
+	// advance less than timer duration: ticker fires, timer does not
+	clock.Advance(500 * time.Millisecond)
+	if tick := <-ticker.C(); !tick.Equal(t0.Add(400 * time.Millisecond)) {
+		t.Errorf("tick %s", tick)
+	}
+	select {
+	case <-timer.C():
+		t.Error("timer fired early")
+	default:
+	}
+
+	// timer fires at its expiry time
+	clock.Advance(500 * time.Millisecond)
+	if at := <-timer.C(); !at.Equal(t0.Add(time.Second)) {
+		t.Errorf("timer %s exp %s", at, t0.Add(time.Second))
+	}
+	if n := clock.Waiters(); n != 1 {
+		t.Errorf("Waiters %d exp 1", n)
+	}
+	if now := clock.Now(); !now.Equal(t0.Add(time.Second)) {
+		t.Errorf("Now %s", now)
+	}
+
+	// Stop and Reset
+	ticker.Stop()
+	if timer.Stop() {
+		t.Error("Stop of expired timer returned true")
+	}
+	timer.Reset(time.Second)
+	if !timer.Stop() {
+		t.Error("Stop of running timer returned false")
+	}
+
+	// Sleep
+	var isAwake = make(chan struct{})
+	go func() {
+		defer close(isAwake)
+		clock.Sleep(time.Minute)
+	}()
+	clock.AwaitWaiters(1)
+	clock.Advance(time.Minute)
+	<-isAwake
+}
+
+func TestOnTickerThreadClock(t *testing.T) {
+	const period = time.Minute
+	var t0 = time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)
+	var exp = []time.Time{t0.Add(30 * time.Second), t0.Add(90 * time.Second)}
+
+	var clock = NewTestClock(t0)
+	var ctx, cancel = context.WithCancel(context.Background())
+	var o = NewOnTickerThreadTester(ctx, len(exp))
+	go OnTickerThread(o.callback, period, time.UTC, o, clock)
+
+	clock.AwaitWaiters(1)
+	clock.Advance(30 * time.Second)
+	clock.AwaitWaiters(1)
+	clock.Advance(time.Minute)
+	o.wg.Wait()
+	cancel()
+	o.done.Wait()
+
+	for i, at := range o.ats {
+		if !at.Equal(exp[i]) {
+			t.Errorf("tick %d %s exp %s", i, at, exp[i])
+		}
+	}
+}
@@ -175,6 +175,7 @@ func doRecovery(annotation string, deferredAnnotation annotationLiteral, errp *e
 		}
 		annotation = getDeferredAnnotation(annotation, deferredAnnotation)
 		var panicError = processRecoverValue(annotation, recoverValue, doRecoveryFrames)
+		InvokePanicHooks(panicError)
 		err = perrors.AppendError(err, panicError)
 		if onErrorStrategy == recoverOnErrrorMultiple {
 			sendError(eSink, panicError)
@@ -0,0 +1,231 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"sync"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// ResultConfig configures [NewResultCollector]
+type ResultConfig struct {
+	// CancelOnError cancels the collector’s context on the first error
+	//	- other goroutines should observe the context and exit
+	CancelOnError bool
+}
+
+// ResultCollector launches goroutines returning typed results and errors
+//   - more than [GoResult]: typed results collected by index or label,
+//     cancellation and awaitable completion
+//   - less than GoGroup: no thread-group hierarchy, non-fatal errors or
+//     thread information
+//   - results are collected in Go invocation order.
+//     A goroutine returning error or panicking has zero-value result
+//   - the first error is returned with any subsequent errors associated
+//   - [ResultCollector.Close] or [ResultCollector.Wait] ends launching.
+//     Then, [ResultCollector.DoneCh] closes once all goroutines exited
+//   - thread-safe
+//
+// Usage:
+//
+//	var c = parl.NewResultCollector[*Page](ctx, parl.ResultConfig{CancelOnError: true})
+//	for _, url := range urls {
+//	  c.GoLabel(url, func(ctx context.Context) (page *Page, err error) {
+//	    return fetch(ctx, url)
+//	  })
+//	}
+//	var pages []*Page
+//	if pages, err = c.Wait(); err != nil {
+//	…
+//	var pageMap = c.Labeled()
+type ResultCollector[T any] struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	// cancelOnError is configured first-error cancel
+	cancelOnError bool
+	lock          sync.Mutex
+	// results are outcomes in Go invocation order
+	//	- behind lock
+	results []collectedResult[T]
+	// remaining is number of goroutines not yet exited
+	//	- behind lock
+	remaining int
+	// isClosed is true once no more goroutines may be launched
+	//	- behind lock
+	isClosed bool
+	// err is first error with subsequent errors associated
+	//	- behind lock
+	err error
+	// done closes once closed and all goroutines exited
+	done Awaitable
+}
+
+// collectedResult is the outcome of one goroutine
+type collectedResult[T any] struct {
+	label string
+	value T
+}
+
+// NewResultCollector returns a collector of goroutine results
+//   - ctx: parent of the context provided to goroutines
+//   - config: optional [ResultConfig]
+func NewResultCollector[T any](ctx context.Context, config ...ResultConfig) (collector *ResultCollector[T]) {
+	if ctx == nil {
+		panic(NilError("ctx"))
+	}
+	var c ResultConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	collector = &ResultCollector[T]{cancelOnError: c.CancelOnError}
+	collector.ctx, collector.cancel = context.WithCancelCause(ctx)
+
+	return
+}
+
+// Go launches fn in a new goroutine
+//   - index: position of the result in [ResultCollector.Values]
+//   - fn receives the collector’s context
+//   - panics after [ResultCollector.Close] or [ResultCollector.Wait]
+func (c *ResultCollector[T]) Go(fn func(ctx context.Context) (value T, err error)) (index int) {
+	return c.GoLabel("", fn)
+}
+
+// GoLabel launches fn in a new goroutine whose result is labeled
+//   - label: key in [ResultCollector.Labeled], empty for none
+func (c *ResultCollector[T]) GoLabel(label string, fn func(ctx context.Context) (value T, err error)) (index int) {
+	if fn == nil {
+		panic(NilError("fn"))
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.isClosed {
+		panic(perrors.NewPF("Go after Close or Wait"))
+	}
+	index = len(c.results)
+	c.results = append(c.results, collectedResult[T]{label: label})
+	c.remaining++
+	go c.run(index, fn)
+
+	return
+}
+
+// Context returns the context provided to goroutines
+//   - canceled by [ResultCollector.Cancel], parent context or
+//     with CancelOnError, the first error
+func (c *ResultCollector[T]) Context() (ctx context.Context) { return c.ctx }
+
+// Cancel cancels the context provided to goroutines
+func (c *ResultCollector[T]) Cancel() { c.cancel(context.Canceled) }
+
+// Close ends launching of goroutines
+//   - after Close, DoneCh closes once all goroutines exited
+//   - idempotent
+func (c *ResultCollector[T]) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.isClosed = true
+	c.checkDone()
+}
+
+// DoneCh returns a channel that closes once [ResultCollector.Close]
+// was invoked and all goroutines exited
+func (c *ResultCollector[T]) DoneCh() (ch AwaitableCh) { return c.done.Ch() }
+
+// Wait ends launching, awaits all goroutines and returns results
+//   - values: results in Go invocation order
+//   - err: the first error with subsequent errors associated
+func (c *ResultCollector[T]) Wait() (values []T, err error) {
+	c.Close()
+	<-c.done.Ch()
+
+	return c.Values(), c.Err()
+}
+
+// Values returns results of exited goroutines in Go invocation order
+//   - a goroutine not yet exited or that failed has zero-value
+func (c *ResultCollector[T]) Values() (values []T) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	values = make([]T, len(c.results))
+	for i := range c.results {
+		values[i] = c.results[i].value
+	}
+
+	return
+}
+
+// Labeled returns results of goroutines launched by GoLabel
+//   - key: label
+func (c *ResultCollector[T]) Labeled() (labeled map[string]T) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	labeled = make(map[string]T)
+	for i := range c.results {
+		if r := &c.results[i]; r.label != "" {
+			labeled[r.label] = r.value
+		}
+	}
+
+	return
+}
+
+// Err returns the first error with subsequent errors associated
+func (c *ResultCollector[T]) Err() (err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.err
+}
+
+// run executes fn in a goroutine
+func (c *ResultCollector[T]) run(index int, fn func(ctx context.Context) (value T, err error)) {
+	var value T
+	var err error
+	defer c.result(index, &value, &err)
+	defer RecoverErr(func() DA { return A() }, &err)
+
+	value, err = fn(c.ctx)
+}
+
+// result stores the outcome of goroutine index
+func (c *ResultCollector[T]) result(index int, valuep *T, errp *error) {
+	var err = *errp
+	if err != nil {
+		if c.cancelOnError {
+			c.cancel(err)
+		}
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err == nil {
+		c.results[index].value = *valuep
+	} else {
+		if label := c.results[index].label; label != "" {
+			err = perrors.Errorf("%s: %w", label, err)
+		}
+		c.err = perrors.AppendError(c.err, err)
+	}
+	c.remaining--
+	c.checkDone()
+}
+
+// checkDone closes done if closed and all goroutines exited
+//   - behind lock
+func (c *ResultCollector[T]) checkDone() {
+	if c.isClosed && c.remaining == 0 {
+		c.done.Close()
+		// release context resources
+		c.cancel(context.Canceled)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestResultCollector(t *testing.T) {
+	//t.Error("Logging on")
+	var c = NewResultCollector[int](context.Background())
+	for i := 1; i <= 3; i++ {
+		var value = i
+		c.GoLabel(string(rune('a'+i-1)), func(ctx context.Context) (v int, err error) { return value, nil })
+	}
+	var values, err = c.Wait()
+	if err != nil {
+		t.Errorf("Wait err: %s", err)
+	}
+	if !slices.Equal(values, []int{1, 2, 3}) {
+		t.Errorf("values %v exp [1 2 3]", values)
+	}
+	if labeled := c.Labeled(); len(labeled) != 3 || labeled["b"] != 2 {
+		t.Errorf("Labeled %v", labeled)
+	}
+	select {
+	case <-c.DoneCh():
+	default:
+		t.Error("DoneCh not closed")
+	}
+}
+
+func TestResultCollectorCancelOnError(t *testing.T) {
+	//t.Error("Logging on")
+	var errX = errors.New("x")
+
+	var c = NewResultCollector[int](context.Background(), ResultConfig{CancelOnError: true})
+	// awaits cancel caused by the failing goroutine
+	c.Go(func(ctx context.Context) (v int, err error) {
+		<-ctx.Done()
+		return 0, context.Cause(ctx)
+	})
+	c.Go(func(ctx context.Context) (v int, err error) { return 0, errX })
+	// a panic is an error
+	c.Go(func(ctx context.Context) (v int, err error) { <-ctx.Done(); panic(1) })
+	var _, err = c.Wait()
+	if !errors.Is(err, errX) {
+		t.Errorf("Wait err: %v", err)
+	}
+	if n := len(perrors.ErrorList(err)); n != 3 {
+		t.Errorf("errors: %d exp 3", n)
+	}
+}
@@ -0,0 +1,275 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// AtLeastOnce checkpoints periodically: after crash, items processed
+	// since the last checkpoint are processed again
+	AtLeastOnce CheckpointSemantics = iota
+	// ExactlyOnce checkpoints after every item
+	//	- exactly-once requires that the checkpoint is persisted atomically with
+	//		the item’s effects, ie. the [CursorStore] writes to the same
+	//		transaction or file as the processing.
+	//		Otherwise a crash between effect and checkpoint reprocesses one item
+	ExactlyOnce
+)
+
+const (
+	// default interval between checkpoints for [AtLeastOnce]
+	defaultCheckpointInterval = 10 * time.Second
+	// permissions for cursor file
+	cursorFilePerm = 0o600
+)
+
+// CheckpointSemantics is delivery semantics of [Resumable]
+//   - [AtLeastOnce] [ExactlyOnce]
+type CheckpointSemantics uint8
+
+// CursorStore persists the cursor of a [Resumable] scan
+//   - [NewFileCursorStore] stores the cursor as a JSON file
+//   - implementation must be thread-safe
+type CursorStore[T any] interface {
+	// LoadCursor returns the last saved cursor
+	//	- hasCursor false: no checkpoint, the scan starts from the beginning
+	LoadCursor() (cursor T, hasCursor bool, err error)
+	// SaveCursor persists cursor, replacing any previous cursor
+	SaveCursor(cursor T) (err error)
+	// ClearCursor removes any cursor when the scan has completed
+	ClearCursor() (err error)
+}
+
+// ResumablePolicy configures [Resumable] checkpointing
+//   - nil or zero-value policy: at-least-once checkpointing every 10 s
+type ResumablePolicy struct {
+	// Semantics is [AtLeastOnce] or [ExactlyOnce]
+	Semantics CheckpointSemantics
+	// Interval is the time between checkpoints for AtLeastOnce
+	//	- 0: 10 s
+	Interval time.Duration
+	// EveryN checkpoints after every EveryN items for AtLeastOnce,
+	// whichever comes first of Interval and EveryN
+	//	- 0: only Interval applies
+	EveryN int
+}
+
+// Resumable persists the cursor of a long scan so that it can
+// resume from the last checkpoint after crash or restart
+//   - T is the cursor: a file offset, a row key, a queue sequence number
+//   - a scan invokes [Resumable.Start] to obtain any resume cursor,
+//     [Resumable.Done] after processing each item and
+//     [Resumable.Complete] at end of scan
+//   - checkpointing is cooperative: it takes place during Done
+//   - thread-safe
+//
+// Usage:
+//
+//	var r = parl.NewResumable(parl.NewFileCursorStore[int64]("scan.cursor"), nil)
+//	var offset, isResume, err = r.Start()
+//	…
+//	for … {
+//	  processRecord(record)
+//	  if err = r.Done(record.Offset); err != nil {
+//	    return
+//	  }
+//	}
+//	err = r.Complete()
+type Resumable[T any] struct {
+	store     CursorStore[T]
+	semantics CheckpointSemantics
+	interval  time.Duration
+	everyN    int
+	// lock makes fields below thread-safe
+	lock sync.Mutex
+	// cursor is the cursor of the last processed item
+	cursor T
+	// isDirty means cursor has not been saved
+	isDirty bool
+	// count is the number of items since last checkpoint
+	count int
+	// lastSave is the time of the last checkpoint
+	lastSave time.Time
+}
+
+// NewResumable returns a checkpointing helper for a long scan
+//   - store: persists the cursor
+//   - policy: nil is at-least-once every 10 s
+func NewResumable[T any](store CursorStore[T], policy *ResumablePolicy) (resumable *Resumable[T]) {
+	if store == nil {
+		panic(NilError("store"))
+	} else if policy == nil {
+		policy = &ResumablePolicy{}
+	}
+	var r = Resumable[T]{
+		store:     store,
+		semantics: policy.Semantics,
+		interval:  policy.Interval,
+		everyN:    policy.EveryN,
+		lastSave:  time.Now(),
+	}
+	if r.interval <= 0 {
+		r.interval = defaultCheckpointInterval
+	}
+	return &r
+}
+
+// Start returns the cursor of the last checkpoint
+//   - isResume false: no checkpoint exists, scan from the beginning
+//   - isResume true: the scan should continue after cursor
+func (r *Resumable[T]) Start() (cursor T, isResume bool, err error) {
+	if cursor, isResume, err = r.store.LoadCursor(); err != nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.cursor = cursor
+	r.isDirty = false
+	r.count = 0
+	r.lastSave = time.Now()
+
+	return
+}
+
+// Done records that the item at cursor was processed
+//   - Done must be invoked after the item’s effects are complete
+//   - the cursor is persisted when a checkpoint is due
+func (r *Resumable[T]) Done(cursor T) (err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.cursor = cursor
+	r.isDirty = true
+	r.count++
+	if r.semantics == AtLeastOnce &&
+		(r.everyN == 0 || r.count < r.everyN) &&
+		time.Since(r.lastSave) < r.interval {
+		return // checkpoint not due return
+	}
+
+	return r.save()
+}
+
+// Checkpoint persists the cursor of the last processed item
+//   - used prior to graceful shutdown
+func (r *Resumable[T]) Checkpoint() (err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.isDirty {
+		return // nothing to save return
+	}
+	return r.save()
+}
+
+// Complete removes the checkpoint when the scan has completed
+//   - a subsequent Start begins from the beginning
+func (r *Resumable[T]) Complete() (err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err = r.store.ClearCursor(); err != nil {
+		return
+	}
+	var zeroValue T
+	r.cursor = zeroValue
+	r.isDirty = false
+	r.count = 0
+
+	return
+}
+
+// Cursor returns the cursor of the last processed item
+func (r *Resumable[T]) Cursor() (cursor T) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.cursor
+}
+
+// save persists cursor while holding lock
+func (r *Resumable[T]) save() (err error) {
+	if err = r.store.SaveCursor(r.cursor); err != nil {
+		return
+	}
+	r.isDirty = false
+	r.count = 0
+	r.lastSave = time.Now()
+
+	return
+}
+
+// FileCursorStore is a [CursorStore] storing the cursor as a JSON file
+type FileCursorStore[T any] struct {
+	filename string
+}
+
+// NewFileCursorStore returns a cursor store using filename
+//   - the cursor is JSON-encoded
+//   - writes are to a temporary file that is renamed,
+//     so a crash during write retains the previous checkpoint
+func NewFileCursorStore[T any](filename string) (store *FileCursorStore[T]) {
+	return &FileCursorStore[T]{filename: filename}
+}
+
+// LoadCursor reads the cursor file
+func (s *FileCursorStore[T]) LoadCursor() (cursor T, hasCursor bool, err error) {
+	var data []byte
+	if data, err = os.ReadFile(s.filename); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+			return // no checkpoint return
+		}
+		err = perrors.ErrorfPF("os.ReadFile %w", err)
+		return
+	}
+	if err = json.Unmarshal(data, &cursor); perrors.IsPF(&err, "json.Unmarshal %q %w", s.filename, err) {
+		return
+	}
+	hasCursor = true
+
+	return
+}
+
+// SaveCursor writes the cursor file
+func (s *FileCursorStore[T]) SaveCursor(cursor T) (err error) {
+	var data []byte
+	if data, err = json.Marshal(cursor); perrors.IsPF(&err, "json.Marshal %w", err) {
+		return
+	}
+	var tempName = s.filename + ".tmp"
+	if err = os.WriteFile(tempName, data, cursorFilePerm); perrors.IsPF(&err, "os.WriteFile %w", err) {
+		return
+	}
+	if err = os.Rename(tempName, s.filename); perrors.IsPF(&err, "os.Rename %w", err) {
+		return
+	}
+
+	return
+}
+
+// ClearCursor removes the cursor file
+func (s *FileCursorStore[T]) ClearCursor() (err error) {
+	if err = os.Remove(s.filename); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+			return
+		}
+		err = perrors.ErrorfPF("os.Remove %w", err)
+	}
+
+	return
+}
@@ -0,0 +1,70 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResumable(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		everyN      = 3
+		items       = 5
+		expCheckpnt = 3
+	)
+	var (
+		filename = filepath.Join(t.TempDir(), "scan.cursor")
+		store    = NewFileCursorStore[int](filename)
+		policy   = ResumablePolicy{EveryN: everyN, Interval: time.Hour}
+		cursor   int
+		isResume bool
+		err      error
+	)
+
+	// first run: no checkpoint
+	var r = NewResumable[int](store, &policy)
+	if cursor, isResume, err = r.Start(); err != nil {
+		t.Fatalf("Start err: %s", err)
+	} else if isResume {
+		t.Error("Start isResume true")
+	}
+	// process 5 items, crash without Checkpoint
+	for i := 1; i <= items; i++ {
+		if err = r.Done(i); err != nil {
+			t.Fatalf("Done err: %s", err)
+		}
+	}
+
+	// second run: resumes from item 3
+	r = NewResumable[int](store, &policy)
+	if cursor, isResume, err = r.Start(); err != nil {
+		t.Fatalf("Start err: %s", err)
+	}
+	if !isResume || cursor != expCheckpnt {
+		t.Errorf("Start cursor %d isResume %t exp %d true", cursor, isResume, expCheckpnt)
+	}
+
+	// Checkpoint persists latest
+	if err = r.Done(items); err != nil {
+		t.Fatalf("Done err: %s", err)
+	} else if err = r.Checkpoint(); err != nil {
+		t.Fatalf("Checkpoint err: %s", err)
+	}
+	if cursor, _, _ = store.LoadCursor(); cursor != items {
+		t.Errorf("LoadCursor %d exp %d", cursor, items)
+	}
+
+	// Complete clears the checkpoint
+	if err = r.Complete(); err != nil {
+		t.Fatalf("Complete err: %s", err)
+	}
+	if _, isResume, _ = NewResumable[int](store, nil).Start(); isResume {
+		t.Error("Start after Complete isResume true")
+	}
+}
@@ -0,0 +1,237 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// [RetryPolicy.MaxAttempts] retries until success, fatal error or context cancel
+	RetryUnlimited = 0
+	// default delay prior to the second attempt
+	defaultRetryInitialDelay = 100 * time.Millisecond
+	// default cap for the delay between attempts
+	defaultRetryMaxDelay = 30 * time.Second
+	// default factor by which delay grows between attempts
+	defaultRetryMultiplier = 2.0
+)
+
+// RetryFunc is a function invoked by [Retry]
+//   - ctx is canceled when Retry’s context is canceled or
+//     when the per-attempt timeout expires
+type RetryFunc[T any] func(ctx context.Context) (value T, err error)
+
+// RetryClassifier decides whether an error returned by an attempt
+// should cause another attempt
+//   - attempt is the 1-based number of the failed attempt
+//   - isRetryable false: the error is fatal and Retry returns immediately
+type RetryClassifier func(err error, attempt int) (isRetryable bool)
+
+// RetryTransient is a [RetryClassifier] retrying timeout and unavailable errors
+//   - see [perrors.IsTransient]
+func RetryTransient(err error, attempt int) (isRetryable bool) {
+	return perrors.IsTransient(err)
+}
+
+// RetryPolicy configures [Retry]
+//   - the zero-value policy retries any non-context error without limit
+//     with exponential backoff from 100 ms doubling to 30 s without jitter
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of invocations
+	//	- [RetryUnlimited] 0: retry until success, fatal error or context cancel
+	MaxAttempts int
+	// InitialDelay is the delay prior to the second attempt
+	//	- 0: 100 ms
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between attempts
+	//	- 0: 30 s
+	MaxDelay time.Duration
+	// Multiplier is the factor by which delay grows between attempts
+	//	- less than 1: 2
+	Multiplier float64
+	// Jitter is the fraction of delay that is randomized
+	//	- 0.25 randomizes delay within ±25%
+	//	- 0: no jitter, values are capped to 1
+	Jitter float64
+	// AttemptTimeout is an optional timeout for each attempt
+	//	- 0: attempts only end on Retry context cancel
+	AttemptTimeout time.Duration
+	// Classifier decides retryable vs fatal errors
+	//	- nil: any error but context cancel of Retry’s context is retryable
+	Classifier RetryClassifier
+}
+
+// Retry invokes fn until it succeeds, fails fatally, attempts are exhausted
+// or ctx is canceled
+//   - policy: nil is zero-value policy. See [RetryPolicy]
+//   - errorSink: optional, receives the error of each failed attempt
+//     that is followed by another attempt
+//   - err: the error of the last attempt, on context cancel also matching the context error
+//   - a panic in fn is recovered as an error and treated as fatal
+//   - delay between attempts is exponential backoff with optional jitter,
+//     capped by MaxDelay
+//   - Retry blocks, invoking fn on the calling thread
+//
+// Usage:
+//
+//	var policy = parl.RetryPolicy{MaxAttempts: 5, Jitter: 0.2, AttemptTimeout: time.Second}
+//	var value, err = parl.Retry(ctx, func(ctx context.Context) (value int, err error) {
+//	  …
+//	}, &policy)
+func Retry[T any](ctx context.Context, fn RetryFunc[T], policy *RetryPolicy, errorSink ...ErrorSink1) (value T, err error) {
+	if fn == nil {
+		panic(NilError("fn"))
+	}
+	var r = newRetrier(policy)
+	var eSink ErrorSink1
+	if len(errorSink) > 0 {
+		eSink = errorSink[0]
+	}
+
+	for attempt := 1; ; attempt++ {
+
+		// execute attempt
+		var isPanic bool
+		if value, isPanic, err = retryAttempt(ctx, fn, r.attemptTimeout); err == nil {
+			return // success return
+		} else if isPanic {
+			return // panic is fatal return
+		}
+
+		// parent context canceled: retries are futile
+		if ctx.Err() != nil {
+			err = retryCanceled(ctx, attempt, err)
+			return // context canceled return
+		}
+
+		// check for fatal error or attempts exhausted
+		if !r.isRetryable(err, attempt) {
+			return // fatal error return
+		} else if r.maxAttempts != RetryUnlimited && attempt >= r.maxAttempts {
+			err = perrors.ErrorfPF("attempts: %d: %w", attempt, err)
+			return // attempts exhausted return
+		}
+		if eSink != nil {
+			eSink.AddError(perrors.ErrorfPF("attempt %d: %w", attempt, err))
+		}
+
+		// backoff wait
+		var timer = time.NewTimer(r.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			err = retryCanceled(ctx, attempt, err)
+			return // context canceled while waiting return
+		case <-timer.C:
+		}
+	}
+}
+
+// retryAttempt invokes fn once recovering any panic
+func retryAttempt[T any](ctx context.Context, fn RetryFunc[T], attemptTimeout time.Duration) (value T, isPanic bool, err error) {
+	defer RecoverErr(func() DA { return A() }, &err, &isPanic)
+
+	if attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, attemptTimeout)
+		defer cancel()
+	}
+	value, err = fn(ctx)
+
+	return
+}
+
+// retryCanceled returns an error matching both the context cause and
+// the error of the last attempt
+func retryCanceled(ctx context.Context, attempt int, err error) (err2 error) {
+	return perrors.ErrorfPF("canceled after attempt %d: %w: %w", attempt, context.Cause(ctx), err)
+}
+
+// retrier holds the effective values of a [RetryPolicy]
+type retrier struct {
+	maxAttempts    int
+	initialDelay   time.Duration
+	maxDelay       time.Duration
+	multiplier     float64
+	jitter         float64
+	attemptTimeout time.Duration
+	classifier     RetryClassifier
+}
+
+// newRetrier applies defaults to policy
+func newRetrier(policy *RetryPolicy) (r *retrier) {
+	if policy == nil {
+		policy = &RetryPolicy{}
+	}
+	r = &retrier{
+		maxAttempts:    policy.MaxAttempts,
+		initialDelay:   policy.InitialDelay,
+		maxDelay:       policy.MaxDelay,
+		multiplier:     policy.Multiplier,
+		jitter:         policy.Jitter,
+		attemptTimeout: policy.AttemptTimeout,
+		classifier:     policy.Classifier,
+	}
+	if r.maxAttempts < 0 {
+		r.maxAttempts = RetryUnlimited
+	}
+	if r.initialDelay <= 0 {
+		r.initialDelay = defaultRetryInitialDelay
+	}
+	if r.maxDelay <= 0 {
+		r.maxDelay = defaultRetryMaxDelay
+	}
+	if r.maxDelay < r.initialDelay {
+		r.maxDelay = r.initialDelay
+	}
+	if r.multiplier < 1 {
+		r.multiplier = defaultRetryMultiplier
+	}
+	if r.jitter < 0 {
+		r.jitter = 0
+	} else if r.jitter > 1 {
+		r.jitter = 1
+	}
+
+	return
+}
+
+// isRetryable classifies err from a failed attempt
+//   - without classifier, all errors but context cancel are retryable
+func (r *retrier) isRetryable(err error, attempt int) (isRetryable bool) {
+	if r.classifier != nil {
+		return r.classifier(err, attempt)
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// delay returns the backoff delay following failed attempt 1…
+func (r *retrier) delay(attempt int) (d time.Duration) {
+
+	// exponential backoff capped by maxDelay
+	var f = float64(r.initialDelay) * math.Pow(r.multiplier, float64(attempt-1))
+	if f > float64(r.maxDelay) || math.IsInf(f, 0) || math.IsNaN(f) {
+		f = float64(r.maxDelay)
+	}
+
+	// jitter within ±jitter fraction
+	if r.jitter > 0 {
+		f += f * r.jitter * (2*rand.Float64() - 1)
+	}
+
+	if d = time.Duration(f); d < 0 {
+		d = 0
+	}
+
+	return
+}
@@ -0,0 +1,129 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	const (
+		expValue     = 3
+		expAttempts  = 3
+		maxAttempts  = 5
+		initialDelay = time.Millisecond
+	)
+	var (
+		errTransient = errors.New("transient")
+		errFatal     = errors.New("fatal")
+		policy       = RetryPolicy{MaxAttempts: maxAttempts, InitialDelay: initialDelay, Jitter: 0.5}
+		ctx          = context.Background()
+		attempts     int
+		value        int
+		err          error
+		errs         ErrSlice
+	)
+
+	// success on third attempt
+	value, err = Retry(ctx, func(ctx context.Context) (value int, err error) {
+		if attempts++; attempts < expAttempts {
+			err = errTransient
+			return
+		}
+		value = attempts
+		return
+	}, &policy, &errs)
+	if err != nil {
+		t.Errorf("Retry err: %s", err)
+	}
+	if value != expValue {
+		t.Errorf("Retry value %d exp %d", value, expValue)
+	}
+	if n := len(errs.Errors()); n != expAttempts-1 {
+		t.Errorf("ErrorSink errors %d exp %d", n, expAttempts-1)
+	}
+
+	// attempts exhausted
+	attempts = 0
+	_, err = Retry(ctx, func(ctx context.Context) (value int, err error) {
+		attempts++
+		err = errTransient
+		return
+	}, &policy)
+	if !errors.Is(err, errTransient) {
+		t.Errorf("Retry exhausted err: %v", err)
+	}
+	if attempts != maxAttempts {
+		t.Errorf("Retry exhausted attempts %d exp %d", attempts, maxAttempts)
+	}
+
+	// classifier fatal error
+	attempts = 0
+	policy.Classifier = func(err error, attempt int) (isRetryable bool) { return !errors.Is(err, errFatal) }
+	_, err = Retry(ctx, func(ctx context.Context) (value int, err error) {
+		attempts++
+		err = errFatal
+		return
+	}, &policy)
+	if !errors.Is(err, errFatal) {
+		t.Errorf("Retry fatal err: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Retry fatal attempts %d exp 1", attempts)
+	}
+
+	// panic is fatal
+	attempts = 0
+	_, err = Retry(ctx, func(ctx context.Context) (value int, err error) {
+		attempts++
+		panic(1)
+	}, nil)
+	if err == nil {
+		t.Error("Retry panic missing error")
+	}
+	if attempts != 1 {
+		t.Errorf("Retry panic attempts %d exp 1", attempts)
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		policy      = RetryPolicy{InitialDelay: time.Hour}
+		errTimeout  = errors.New("timeout")
+		err         error
+	)
+
+	// cancel while waiting for backoff
+	go func() {
+		time.Sleep(time.Millisecond)
+		cancel()
+	}()
+	_, err = Retry(ctx, func(ctx context.Context) (value int, err error) {
+		err = errTimeout
+		return
+	}, &policy)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Retry cancel err: %v", err)
+	}
+	if !errors.Is(err, errTimeout) {
+		t.Errorf("Retry cancel missing attempt error: %v", err)
+	}
+
+	// per-attempt timeout
+	policy = RetryPolicy{MaxAttempts: 2, InitialDelay: time.Millisecond, AttemptTimeout: time.Millisecond}
+	_, err = Retry(context.Background(), func(ctx context.Context) (value int, err error) {
+		<-ctx.Done()
+		err = ctx.Err()
+		return
+	}, &policy)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Retry attempt timeout err: %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// RingOverwrite: Send on a full ring buffer discards the oldest value
+	RingOverwrite RingMode = iota
+	// RingReject: Send on a full ring buffer discards the new value
+	RingReject
+)
+
+// RingMode is how [RingBuffer.Send] behaves when the buffer is full
+//   - [RingOverwrite] [RingReject]
+type RingMode uint8
+
+// RingBuffer is a thread-safe awaitable queue of fixed capacity
+//   - bounded complement to [AwaitableSlice]:
+//     memory use is fixed at creation by a single slice
+//   - full buffer: [RingOverwrite] default discards the oldest value,
+//     [RingReject] discards the new value
+//   - [RingBuffer.DataWaitCh] closes while values are available,
+//     [RingBuffer.SpaceWaitCh] closes while there is room
+//   - Send Get and GetSlice with sufficient capacity are allocation-free.
+//     Once DataWaitCh or SpaceWaitCh is used, the channel is
+//     renewed each time the buffer becomes empty or full
+//   - discarded values are counted by [RingBuffer.Dropped]
+//
+// Usage:
+//
+//	var logLines = parl.NewRingBuffer[string](1000)
+//	…
+//	logLines.Send(line)
+//	…
+//	var lines []string
+//	for {
+//	  select {
+//	  case <-ctx.Done():
+//	    return
+//	  case <-logLines.DataWaitCh():
+//	  }
+//	  lines = logLines.GetSlice(lines[:0])
+type RingBuffer[T any] struct {
+	// mode is behavior of Send when full
+	mode RingMode
+	// lock makes ring thread-safe
+	lock sync.Mutex
+	// ring holds values, its length is capacity
+	//	- behind lock
+	ring []T
+	// head is index of the oldest value
+	//	- behind lock
+	head int
+	// count is number of values in ring
+	//	- behind lock
+	count int
+	// dropped is number of discarded values
+	dropped atomic.Uint64
+	// dataWait is closed while count is non-zero
+	//	- lazy: only maintained once DataWaitCh was invoked
+	//	- Cyclic updated behind lock
+	dataWait LazyCyclic
+	// spaceWait is closed while count is less than capacity
+	//	- lazy: only maintained once SpaceWaitCh was invoked
+	//	- Cyclic updated behind lock
+	spaceWait LazyCyclic
+}
+
+// NewRingBuffer returns a ring buffer holding at most capacity values
+//   - capacity: positive
+//   - mode: [RingOverwrite] default or [RingReject]
+func NewRingBuffer[T any](capacity int, mode ...RingMode) (ringBuffer *RingBuffer[T]) {
+	if capacity < 1 {
+		panic(perrors.ErrorfPF("capacity must be positive: %d", capacity))
+	}
+	var m = RingOverwrite
+	if len(mode) > 0 {
+		m = mode[0]
+	}
+	return &RingBuffer[T]{
+		mode: m,
+		ring: make([]T, capacity),
+	}
+}
+
+// Send enqueues value
+//   - isAccepted false: [RingReject] buffer is full and value was discarded
+//   - [RingOverwrite] full buffer: the oldest value is discarded
+//   - thread-safe
+func (r *RingBuffer[T]) Send(value T) (isAccepted bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.count == len(r.ring) {
+		r.dropped.Add(1)
+		if r.mode == RingReject {
+			return // buffer full return
+		}
+		// overwrite oldest
+		r.ring[r.head] = value
+		r.head = r.index(1)
+		return true
+	}
+	r.ring[r.index(r.count)] = value
+	r.count++
+	r.updateWait()
+
+	return true
+}
+
+// Get dequeues the oldest value
+//   - hasValue false: buffer is empty
+//   - thread-safe
+func (r *RingBuffer[T]) Get() (value T, hasValue bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.count == 0 {
+		return // empty return
+	}
+	value, hasValue = r.pop(), true
+	r.updateWait()
+
+	return
+}
+
+// GetSlice dequeues all values appending them to buffer
+//   - values: buffer with values appended, oldest first
+//   - allocation-free if buffer has sufficient capacity:
+//     GetSlice(buffer[:0])
+//   - thread-safe
+func (r *RingBuffer[T]) GetSlice(buffer []T) (values []T) {
+	values = buffer
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.count == 0 {
+		return // empty return
+	}
+	for r.count > 0 {
+		values = append(values, r.pop())
+	}
+	r.updateWait()
+
+	return
+}
+
+// DataWaitCh returns a channel that is closed while values are available
+//   - each invocation may return a different channel
+//   - thread-safe
+func (r *RingBuffer[T]) DataWaitCh() (ch AwaitableCh) { return r.lazyCh(&r.dataWait) }
+
+// SpaceWaitCh returns a channel that is closed while the buffer is not full
+//   - each invocation may return a different channel
+//   - thread-safe
+func (r *RingBuffer[T]) SpaceWaitCh() (ch AwaitableCh) { return r.lazyCh(&r.spaceWait) }
+
+// Len returns the number of values in the buffer. Thread-safe
+func (r *RingBuffer[T]) Len() (length int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.count
+}
+
+// Cap returns the capacity of the buffer
+func (r *RingBuffer[T]) Cap() (capacity int) { return len(r.ring) }
+
+// Dropped returns the number of values discarded by Send due to
+// the buffer being full. Thread-safe
+func (r *RingBuffer[T]) Dropped() (dropped uint64) { return r.dropped.Load() }
+
+// pop removes the oldest value
+//   - behind lock, count non-zero
+func (r *RingBuffer[T]) pop() (value T) {
+	var zeroValue T
+	value = r.ring[r.head]
+	// zero-out to avoid temporary memory leaks
+	r.ring[r.head] = zeroValue
+	r.head = r.index(1)
+	r.count--
+	return
+}
+
+// index returns ring index of the value offset from head
+func (r *RingBuffer[T]) index(offset int) (index int) {
+	if index = r.head + offset; index >= len(r.ring) {
+		index -= len(r.ring)
+	}
+	return
+}
+
+// lazyCh activates lazy and returns its channel
+func (r *RingBuffer[T]) lazyCh(lazy *LazyCyclic) (ch AwaitableCh) {
+	if lazy.IsActive.Load() {
+		return lazy.Cyclic.Ch() // already active return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// set initial state inside lock
+	if lazy.IsActive.CompareAndSwap(false, true) {
+		r.updateWait()
+	}
+
+	return lazy.Cyclic.Ch()
+}
+
+// updateWait updates active data and space awaitables after count changed
+//   - behind lock
+func (r *RingBuffer[T]) updateWait() {
+	if r.dataWait.IsActive.Load() {
+		updateCyclic(&r.dataWait.Cyclic, r.count > 0)
+	}
+	if r.spaceWait.IsActive.Load() {
+		updateCyclic(&r.spaceWait.Cyclic, r.count < len(r.ring))
+	}
+}
+
+// updateCyclic closes or opens cyclic
+func updateCyclic(cyclic *CyclicAwaitable, isClosed bool) {
+	if isClosed {
+		cyclic.Close()
+	} else {
+		cyclic.Open()
+	}
+}
+
+// “overwrite” “reject”
+func (m RingMode) String() (s string) {
+	switch m {
+	case RingOverwrite:
+		return "overwrite"
+	case RingReject:
+		return "reject"
+	}
+	return Sprintf("?ringMode%d", m)
+}
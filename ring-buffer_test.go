@@ -0,0 +1,93 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRingBuffer(t *testing.T) {
+	//t.Error("Logging on")
+	var values []int
+
+	// overwrite discards oldest
+	var r = NewRingBuffer[int](3)
+	for i := 1; i <= 4; i++ {
+		if !r.Send(i) {
+			t.Errorf("Send %d false", i)
+		}
+	}
+	if r.Len() != 3 || r.Cap() != 3 || r.Dropped() != 1 {
+		t.Errorf("Len %d Cap %d Dropped %d", r.Len(), r.Cap(), r.Dropped())
+	}
+	if value, hasValue := r.Get(); !hasValue || value != 2 {
+		t.Errorf("Get %d %t exp 2", value, hasValue)
+	}
+	values = r.GetSlice(values[:0])
+	if !slices.Equal(values, []int{3, 4}) {
+		t.Errorf("GetSlice %v exp [3 4]", values)
+	}
+	if _, hasValue := r.Get(); hasValue {
+		t.Error("Get hasValue on empty")
+	}
+
+	// reject discards newest
+	r = NewRingBuffer[int](2, RingReject)
+	r.Send(1)
+	r.Send(2)
+	if r.Send(3) {
+		t.Error("Send on full true")
+	}
+	if values = r.GetSlice(values[:0]); !slices.Equal(values, []int{1, 2}) {
+		t.Errorf("GetSlice %v exp [1 2]", values)
+	}
+}
+
+func TestRingBufferWait(t *testing.T) {
+	//t.Error("Logging on")
+	var r = NewRingBuffer[int](1)
+
+	if isClosed(r.DataWaitCh()) {
+		t.Error("DataWaitCh closed on empty")
+	}
+	if !isClosed(r.SpaceWaitCh()) {
+		t.Error("SpaceWaitCh open on empty")
+	}
+	r.Send(1)
+	if !isClosed(r.DataWaitCh()) {
+		t.Error("DataWaitCh open with data")
+	}
+	if isClosed(r.SpaceWaitCh()) {
+		t.Error("SpaceWaitCh closed on full")
+	}
+	r.Get()
+	if isClosed(r.DataWaitCh()) {
+		t.Error("DataWaitCh closed after Get")
+	}
+
+	// allocation-free without awaitables
+	var r2 = NewRingBuffer[int](2)
+	var values = make([]int, 0, 2)
+	if n := testing.AllocsPerRun(100, func() {
+		r2.Send(1)
+		r2.Get()
+		r2.Send(2)
+		values = r2.GetSlice(values[:0])
+	}); n != 0 {
+		t.Errorf("allocations: %.0f", n)
+	}
+}
+
+// isClosed returns whether ch is closed
+func isClosed(ch AwaitableCh) (isClosed bool) {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,160 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import "context"
+
+// FromSeq returns a source receiving the values of a Go iterator
+//   - seq: a push iterator like iter.Seq[T]
+//   - seq is iterated by a new thread as fast as it produces values.
+//     Values not yet consumed are buffered
+//   - the source closes when seq ends, ctx is canceled or seq panics.
+//     Values received prior to close remain available
+//   - ctx cancel ends the iteration on the next value:
+//     the only way to stop an infinite seq
+//   - errorSink: receives a panic in seq, default: logged to standard error
+//
+// Usage:
+//
+//	var source = parl.FromSeq(ctx, maps.Keys(m))
+//	for key := source.Init(); source.Condition(&key); {
+//	  …
+func FromSeq[T any](ctx context.Context, seq func(yield func(T) bool), errorSink ...ErrorSink1) (source IterableSource[T]) {
+	if ctx == nil {
+		panic(NilError("ctx"))
+	} else if seq == nil {
+		panic(NilError("seq"))
+	}
+	var slice = &AwaitableSlice[T]{}
+	go fromSeqThread(ctx, seq, slice, errorSink...)
+	return slice
+}
+
+// FromChan returns a source receiving the values of a channel
+//   - ch is read by a new thread until ch closes or ctx is canceled.
+//     Values not yet consumed are buffered
+//   - the source closes when ch closes or ctx is canceled.
+//     Values received prior to close remain available
+//   - ctx cancel does not drain ch
+func FromChan[T any](ctx context.Context, ch <-chan T) (source IterableSource[T]) {
+	if ctx == nil {
+		panic(NilError("ctx"))
+	} else if ch == nil {
+		panic(NilError("ch"))
+	}
+	var slice = &AwaitableSlice[T]{}
+	go fromChanThread(ctx, ch, slice)
+	return slice
+}
+
+// ToSeq returns a Go iterator like iter.Seq[T] of the values of source
+//   - iteration ends when source is closed and empty or ctx is canceled
+//   - a consumer ending iteration early leaves remaining values in source
+//   - the iterator blocks awaiting values.
+//     Only one iteration should be active at any time
+//   - for an [AwaitableSlice], closing is only detected if
+//     [AwaitableSlice.EmptyCh] was invoked
+//
+// Usage:
+//
+//	for value := range parl.ToSeq(ctx, source) {
+//	  …
+func ToSeq[T any](ctx context.Context, source IterableSource[T]) (seq func(yield func(T) bool)) {
+	if ctx == nil {
+		panic(NilError("ctx"))
+	} else if source == nil {
+		panic(NilError("source"))
+	}
+	return func(yield func(T) bool) {
+		var endCh = source.EmptyCh(CloseAwaiter)
+		for {
+			if value, hasValue := source.Get(); hasValue {
+				if !yield(value) {
+					return // consumer break return
+				}
+				continue
+			}
+			select {
+			case <-source.DataWaitCh():
+			case <-endCh:
+				// closed: values sent concurrently with close
+				for value, hasValue := source.Get(); hasValue; value, hasValue = source.Get() {
+					if !yield(value) {
+						return // consumer break return
+					}
+				}
+				return // source closed return
+			case <-ctx.Done():
+				return // cancel return
+			}
+		}
+	}
+}
+
+// ToChan returns an unbuffered channel receiving the values of source
+//   - values are forwarded by a new thread
+//   - ch closes when source is closed and empty or ctx is canceled
+//   - a value obtained from source when ctx is canceled is discarded.
+//     Other values remain in source
+//   - the consumer must read ch until it closes or cancel ctx,
+//     otherwise the thread leaks
+func ToChan[T any](ctx context.Context, source IterableSource[T]) (ch <-chan T) {
+	var seq = ToSeq(ctx, source)
+	var c = make(chan T)
+	go toChanThread(ctx, seq, c)
+	return c
+}
+
+// fromSeqThread iterates seq into slice
+func fromSeqThread[T any](ctx context.Context, seq func(yield func(T) bool), slice *AwaitableSlice[T], errorSink ...ErrorSink1) {
+	var err error
+	defer slice.EmptyCh()
+	defer Recover(func() DA { return A() }, &err, errorSink...)
+
+	var done = ctx.Done()
+	seq(func(value T) (keepGoing bool) {
+		slice.Send(value)
+		select {
+		case <-done:
+			return // cancel: keepGoing false
+		default:
+			return true
+		}
+	})
+}
+
+// fromChanThread reads ch into slice
+func fromChanThread[T any](ctx context.Context, ch <-chan T, slice *AwaitableSlice[T]) {
+	defer slice.EmptyCh()
+
+	var done = ctx.Done()
+	for {
+		select {
+		case value, ok := <-ch:
+			if !ok {
+				return // ch closed return
+			}
+			slice.Send(value)
+		case <-done:
+			return // cancel return
+		}
+	}
+}
+
+// toChanThread sends the values of seq on ch
+func toChanThread[T any](ctx context.Context, seq func(yield func(T) bool), ch chan<- T) {
+	defer close(ch)
+
+	var done = ctx.Done()
+	seq(func(value T) (keepGoing bool) {
+		select {
+		case ch <- value:
+			return true
+		case <-done:
+			return // cancel: keepGoing false
+		}
+	})
+}
@@ -0,0 +1,146 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestFromSeq(t *testing.T) {
+	//t.Error("Logging on")
+	var seq = func(yield func(int) bool) {
+		for i := 1; i <= 3; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	// finite seq closes the source
+	var values []int
+	var source = FromSeq(context.Background(), seq)
+	for value := source.Init(); source.Condition(&value); {
+		values = append(values, value)
+	}
+	if fmt.Sprint(values) != "[1 2 3]" {
+		t.Errorf("values %v", values)
+	}
+
+	// cancel stops an infinite seq
+	var ctx, cancel = context.WithCancel(context.Background())
+	source = FromSeq(ctx, func(yield func(int) bool) {
+		for i := 0; yield(i); i++ {
+			if i == 5 {
+				cancel()
+			}
+		}
+	})
+	values = values[:0]
+	for value := source.Init(); source.Condition(&value); {
+		values = append(values, value)
+	}
+	if len(values) != 7 {
+		t.Errorf("infinite values %v", values)
+	}
+
+	// panic goes to errorSink and closes the source
+	var errorSink AtomicError
+	source = FromSeq(context.Background(), func(yield func(int) bool) {
+		yield(1)
+		panic(1)
+	}, &errorSink)
+	values = values[:0]
+	for value := source.Init(); source.Condition(&value); {
+		values = append(values, value)
+	}
+	if _, hasValue := errorSink.Error(); len(values) != 1 || !hasValue {
+		t.Errorf("panic values %v hasValue %t", values, hasValue)
+	}
+}
+
+func TestFromChan(t *testing.T) {
+	//t.Error("Logging on")
+	var ch = make(chan int, 2)
+	ch <- 1
+	ch <- 2
+	close(ch)
+
+	var values []int
+	var source = FromChan(context.Background(), ch)
+	for value := source.Init(); source.Condition(&value); {
+		values = append(values, value)
+	}
+	if fmt.Sprint(values) != "[1 2]" {
+		t.Errorf("values %v", values)
+	}
+
+	// cancel closes the source
+	var ctx, cancel = context.WithCancel(context.Background())
+	source = FromChan(ctx, make(chan int))
+	cancel()
+	<-source.EmptyCh(CloseAwaiter)
+}
+
+func TestToSeq(t *testing.T) {
+	//t.Error("Logging on")
+	var source AwaitableSlice[int]
+	source.SendSlice([]int{1, 2, 3})
+	var seq = ToSeq[int](context.Background(), &source)
+
+	// break leaves values in source
+	var values []int
+	seq(func(value int) bool {
+		values = append(values, value)
+		return value < 2
+	})
+	if fmt.Sprint(values) != "[1 2]" {
+		t.Errorf("break values %v", values)
+	}
+
+	// iteration ends on close
+	source.EmptyCh()
+	values = values[:0]
+	seq(func(value int) bool {
+		values = append(values, value)
+		return true
+	})
+	if fmt.Sprint(values) != "[3]" {
+		t.Errorf("close values %v", values)
+	}
+
+	// cancel ends iteration
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	ToSeq[int](ctx, &AwaitableSlice[int]{})(func(value int) bool {
+		t.Error("value after cancel")
+		return true
+	})
+}
+
+func TestToChan(t *testing.T) {
+	//t.Error("Logging on")
+	var source AwaitableSlice[int]
+	source.SendSlice([]int{1, 2})
+	source.EmptyCh()
+
+	var values []int
+	for value := range ToChan[int](context.Background(), &source) {
+		values = append(values, value)
+	}
+	if fmt.Sprint(values) != "[1 2]" {
+		t.Errorf("values %v", values)
+	}
+
+	// cancel closes ch
+	var ctx, cancel = context.WithCancel(context.Background())
+	var ch = ToChan[int](ctx, &AwaitableSlice[int]{})
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Error("ch not closed")
+	}
+}
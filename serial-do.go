@@ -0,0 +1,268 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// NoSlowThreshold: [SerialDo] does not report slow invocations
+	NoSlowThreshold time.Duration = 0
+)
+
+// SerialDoConfig configures [SerialDo]
+type SerialDoConfig struct {
+	// SlowThreshold is the duration at which an invocation is slow
+	//	- [NoSlowThreshold] 0: no slow reporting
+	SlowThreshold time.Duration
+	// OnSlow is invoked on its own thread once an invocation has
+	// been executing for SlowThreshold
+	//	- start: when the slow invocation began
+	OnSlow func(start time.Time, threshold time.Duration)
+}
+
+// SerialDoStats is a snapshot of [SerialDo] state
+type SerialDoStats struct {
+	// Queued is number of invocations awaiting execution
+	Queued int
+	// IsBusy is true while an invocation is executing
+	IsBusy bool
+	// Start is when the executing invocation began
+	Start time.Time
+	// Processed is number of completed invocations
+	Processed uint64
+	// Slow is number of invocations exceeding the slow threshold
+	Slow uint64
+	// LastLatency is queue wait of the most recently started invocation
+	LastLatency time.Duration
+	// MaxLatency is the longest queue wait
+	MaxLatency time.Duration
+	// MaxDuration is the longest execution time
+	MaxDuration time.Duration
+}
+
+// SerialDo serializes invocations
+//   - functions provided to [SerialDo.Do] execute one at a time
+//     in order on a thread that exits when the queue is empty
+//   - observable: queue length, executing invocation’s start time,
+//     processed count and queue latency from [SerialDo.Stats]
+//   - optional slow-invocation callback
+//   - [SerialDo.Wait] awaits quiescence: empty queue and no executing invocation
+//   - a panic in an invocation is provided to the error sink
+//   - thread-safe
+//
+// Usage:
+//
+//	var serialDo = parl.NewSerialDo(errorSink, parl.SerialDoConfig{
+//	  SlowThreshold: time.Second,
+//	  OnSlow: func(start time.Time, threshold time.Duration) { parl.Log("slow save since %s", start) },
+//	})
+//	serialDo.Do(func() { save(state) })
+//	…
+//	serialDo.Wait(ctx)
+type SerialDo struct {
+	errorSink     ErrorSink1
+	slowThreshold time.Duration
+	onSlow        func(start time.Time, threshold time.Duration)
+	// idle is closed when there is no queued or executing invocation
+	idle CyclicAwaitable
+	// lock makes fields below thread-safe
+	lock sync.Mutex
+	// queue is pending invocations, behind lock
+	queue []serialDoJob
+	// isBusy is true while the thread runs, behind lock
+	isBusy bool
+	// start is when the executing invocation began, behind lock
+	start time.Time
+	// stats is counters, behind lock
+	stats SerialDoStats
+}
+
+// serialDoJob is a queued invocation
+type serialDoJob struct {
+	fn func()
+	// t0 is when the invocation was queued
+	t0 time.Time
+}
+
+// NewSerialDo returns an invocation serializer
+//   - errorSink: receives panics of invocations
+//   - config: optional slow threshold and callback
+func NewSerialDo(errorSink ErrorSink1, config ...SerialDoConfig) (serialDo *SerialDo) {
+	if errorSink == nil {
+		panic(NilError("errorSink"))
+	}
+	serialDo = &SerialDo{errorSink: errorSink}
+	if len(config) > 0 {
+		serialDo.slowThreshold = config[0].SlowThreshold
+		serialDo.onSlow = config[0].OnSlow
+	}
+	serialDo.idle.Close()
+
+	return
+}
+
+// Do queues fn for serialized execution
+//   - Do does not block
+func (s *SerialDo) Do(fn func()) {
+	if fn == nil {
+		panic(NilError("fn"))
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.queue = append(s.queue, serialDoJob{fn: fn, t0: time.Now()})
+	if s.isBusy {
+		return // thread already running return
+	}
+	s.isBusy = true
+	s.idle.Open()
+	go s.doThread()
+}
+
+// QueueLength returns number of invocations awaiting execution
+func (s *SerialDo) QueueLength() (length int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.queue)
+}
+
+// Current returns the start time of the executing invocation
+//   - isBusy false: no invocation is executing
+func (s *SerialDo) Current() (start time.Time, isBusy bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if isBusy = !s.start.IsZero(); isBusy {
+		start = s.start
+	}
+	return
+}
+
+// Processed returns number of completed invocations
+func (s *SerialDo) Processed() (processed uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.stats.Processed
+}
+
+// Stats returns a snapshot of queue and latency statistics
+func (s *SerialDo) Stats() (stats SerialDoStats) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats = s.stats
+	stats.Queued = len(s.queue)
+	stats.Start = s.start
+	stats.IsBusy = !s.start.IsZero()
+
+	return
+}
+
+// IdleCh returns a channel that is closed when SerialDo is quiescent
+//   - the channel is replaced when a subsequent invocation is queued
+func (s *SerialDo) IdleCh() (ch AwaitableCh) { return s.idle.Ch() }
+
+// Wait awaits quiescence: empty queue and no executing invocation
+//   - ctx: optional context canceling the wait
+//   - err: ctx error if canceled
+func (s *SerialDo) Wait(ctx ...context.Context) (err error) {
+	var done <-chan struct{}
+	if len(ctx) > 0 && ctx[0] != nil {
+		done = ctx[0].Done()
+	}
+	select {
+	case <-s.idle.Ch():
+	case <-done:
+		err = perrors.ErrorfPF("SerialDo Wait: %w", context.Cause(ctx[0]))
+	}
+	return
+}
+
+// doThread executes queued invocations until the queue is empty
+func (s *SerialDo) doThread() {
+	for {
+		var job, ok = s.next()
+		if !ok {
+			return // queue empty return
+		}
+		s.invoke(job)
+	}
+}
+
+// next dequeues an invocation
+//   - ok false: queue is empty and the thread is to exit
+func (s *SerialDo) next() (job serialDoJob, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.queue) == 0 {
+		s.isBusy = false
+		s.idle.Close()
+		return // queue empty return
+	}
+	job = s.queue[0]
+	s.queue[0] = serialDoJob{}
+	s.queue = s.queue[1:]
+	ok = true
+
+	s.start = time.Now()
+	var latency = s.start.Sub(job.t0)
+	s.stats.LastLatency = latency
+	s.stats.MaxLatency = max(s.stats.MaxLatency, latency)
+
+	return
+}
+
+// invoke executes one invocation recovering panics
+func (s *SerialDo) invoke(job serialDoJob) {
+	defer s.invokeEnd()
+	defer Recover(func() DA { return A() }, NoErrp, s.errorSink)
+
+	if s.slowThreshold > 0 && s.onSlow != nil {
+		var timer = time.AfterFunc(s.slowThreshold, s.slow)
+		defer timer.Stop()
+	}
+	job.fn()
+}
+
+// slow invokes the slow callback for the executing invocation
+func (s *SerialDo) slow() {
+	s.lock.Lock()
+	var start = s.start
+	s.stats.Slow++
+	s.lock.Unlock()
+
+	s.onSlow(start, s.slowThreshold)
+}
+
+// invokeEnd updates statistics on completion
+func (s *SerialDo) invokeEnd() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stats.MaxDuration = max(s.stats.MaxDuration, time.Since(s.start))
+	s.stats.Processed++
+	s.start = time.Time{}
+}
+
+// “queued 2 busy processed 17 slow 0 latency 1ms max 4ms”
+func (s SerialDoStats) String() (s2 string) {
+	var busy = "idle"
+	if s.IsBusy {
+		busy = "busy"
+	}
+	return Sprintf("queued %d %s processed %d slow %d latency %s max %s",
+		s.Queued, busy, s.Processed, s.Slow, s.LastLatency, s.MaxLatency,
+	)
+}
@@ -0,0 +1,75 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSerialDo(t *testing.T) {
+	//t.Error("Logging on")
+	var expOrder = []int{1, 2, 3}
+	var errBad = errors.New("bad")
+
+	var errs ErrSlice
+	var slowCh = make(chan time.Time, 1)
+	var serialDo = NewSerialDo(&errs, SerialDoConfig{
+		SlowThreshold: time.Millisecond,
+		OnSlow:        func(start time.Time, threshold time.Duration) { slowCh <- start },
+	})
+	if err := serialDo.Wait(); err != nil {
+		t.Errorf("Wait idle err %s", err)
+	}
+
+	// block the thread to observe the queue
+	var blockCh = make(chan struct{})
+	var startedCh = make(chan struct{})
+	var order []int
+	serialDo.Do(func() {
+		close(startedCh)
+		<-blockCh
+	})
+	<-startedCh
+	for _, i := range expOrder {
+		var i = i
+		serialDo.Do(func() { order = append(order, i) })
+	}
+	serialDo.Do(func() { panic(errBad) })
+	if n := serialDo.QueueLength(); n != len(expOrder)+1 {
+		t.Errorf("QueueLength %d exp %d", n, len(expOrder)+1)
+	}
+	if _, isBusy := serialDo.Current(); !isBusy {
+		t.Error("Current not busy")
+	}
+	var start = <-slowCh
+	if start.IsZero() {
+		t.Error("OnSlow zero start")
+	}
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := serialDo.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait canceled err %v", err)
+	}
+
+	close(blockCh)
+	if err := serialDo.Wait(); err != nil {
+		t.Errorf("Wait err %s", err)
+	}
+	if !slices.Equal(order, expOrder) {
+		t.Errorf("order %v exp %v", order, expOrder)
+	}
+	var stats = serialDo.Stats()
+	if stats.Processed != uint64(len(expOrder)+2) || stats.Queued != 0 || stats.IsBusy || stats.Slow != 1 {
+		t.Errorf("stats %s", stats)
+	}
+	if err, _ := errs.Error(); !errors.Is(err, errBad) {
+		t.Errorf("panic err %v", err)
+	}
+}
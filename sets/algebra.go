@@ -0,0 +1,161 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package sets
+
+import (
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/pfmt"
+)
+
+// Union returns a set of elements present in any of the sets
+//   - element order is order of first occurrence
+//   - the returned set is [BasicSet]
+func Union[E comparable](a, b Set[E], more ...Set[E]) (set Set[E]) {
+	var builder = NewSetBuilder[E]()
+	for _, s := range append([]Set[E]{a, b}, more...) {
+		forEach(s, func(value E) {
+			if !builder.Contains(value) {
+				builder.Add(value)
+			}
+		})
+	}
+	set, _ = builder.Set()
+
+	return
+}
+
+// Intersect returns a set of elements present in both a and b
+//   - element order is the order of a
+func Intersect[E comparable](a, b Set[E]) (set Set[E]) {
+	return filterSet(a, b.IsValid)
+}
+
+// Difference returns a set of elements of a not present in b
+//   - element order is the order of a
+func Difference[E comparable](a, b Set[E]) (set Set[E]) {
+	return filterSet(a, func(value E) (keep bool) { return !b.IsValid(value) })
+}
+
+// SymmetricDifference returns a set of elements present in
+// exactly one of a and b
+//   - element order is elements of a followed by elements of b
+func SymmetricDifference[E comparable](a, b Set[E]) (set Set[E]) {
+	var builder = NewSetBuilder[E]()
+	forEach(a, func(value E) {
+		if !b.IsValid(value) {
+			builder.Add(value)
+		}
+	})
+	forEach(b, func(value E) {
+		if !a.IsValid(value) {
+			builder.Add(value)
+		}
+	})
+	set, _ = builder.Set()
+
+	return
+}
+
+// ContainsFunc returns the first element of set for which predicate is true
+//   - isContained false: no element matched
+func ContainsFunc[E comparable](set Set[E], predicate func(value E) (isMatch bool)) (value E, isContained bool) {
+	var iterator = set.Iterator()
+	for v, hasValue := iterator.Next(); hasValue; v, hasValue = iterator.Next() {
+		if predicate(v) {
+			iterator.Cancel()
+			return v, true
+		}
+	}
+	return
+}
+
+// SetBuilder collects set elements validating that they are unique
+//   - unlike [NewBasicSet], duplicates are returned as error
+//     rather than causing panic
+//   - not thread-safe
+//
+// Usage:
+//
+//	var builder = sets.NewSetBuilder[int]()
+//	for _, port := range ports {
+//	  builder.Add(port)
+//	}
+//	var portSet, err = builder.Set()
+type SetBuilder[E comparable] struct {
+	elementMap map[E]struct{}
+	elements   []E
+	// duplicates are repeated values in order of Add
+	duplicates []E
+}
+
+// NewSetBuilder returns a builder of a [BasicSet]
+func NewSetBuilder[E comparable]() (builder *SetBuilder[E]) {
+	return &SetBuilder[E]{elementMap: make(map[E]struct{})}
+}
+
+// Add adds elements to the set being built
+//   - a value already added is recorded as duplicate
+//   - Add supports functional chaining
+func (b *SetBuilder[E]) Add(elements ...E) (builder *SetBuilder[E]) {
+	builder = b
+	for _, e := range elements {
+		if _, ok := b.elementMap[e]; ok {
+			b.duplicates = append(b.duplicates, e)
+			continue
+		}
+		b.elementMap[e] = emptyStuct
+		b.elements = append(b.elements, e)
+	}
+	return
+}
+
+// Contains returns whether value was added
+func (b *SetBuilder[E]) Contains(value E) (isContained bool) {
+	_, isContained = b.elementMap[value]
+	return
+}
+
+// Set returns the set of unique added elements
+//   - err: non-nil if duplicates were added, set is still valid
+func (b *SetBuilder[E]) Set() (set Set[E], err error) {
+	set = &BasicSet[E]{
+		elementMap: b.elementMap,
+		elements:   b.elements,
+	}
+	b.elementMap = make(map[E]struct{})
+	b.elements = nil
+	if len(b.duplicates) == 0 {
+		return // no duplicates return
+	}
+	var e E
+	err = perrors.ErrorfPF("duplicate set-elements: type E: %T count: %d first duplicate: ‘%s’",
+		e, len(b.duplicates), pfmt.NoRecurseVPrint(b.duplicates[0]),
+	)
+	b.duplicates = nil
+
+	return
+}
+
+// filterSet returns a set of elements of set for which keep is true
+func filterSet[E comparable](set Set[E], keep func(value E) (keep bool)) (result Set[E]) {
+	var builder = NewSetBuilder[E]()
+	forEach(set, func(value E) {
+		if keep(value) {
+			builder.Add(value)
+		}
+	})
+	result, _ = builder.Set()
+
+	return
+}
+
+// forEach invokes f for each element of set
+func forEach[E comparable](set Set[E], f func(value E)) {
+	var iterator = set.Iterator()
+	for value, hasValue := iterator.Next(); hasValue; value, hasValue = iterator.Next() {
+		f(value)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package sets
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAlgebra(t *testing.T) {
+	//t.Error("Logging on")
+	var a = NewBasicSet([]int{1, 2, 3})
+	var b = NewBasicSet([]int{3, 4})
+
+	var elements = func(set Set[int]) (values []int) {
+		forEach(set, func(value int) { values = append(values, value) })
+		return
+	}
+
+	if v := elements(Union(a, b)); !slices.Equal(v, []int{1, 2, 3, 4}) {
+		t.Errorf("Union %v", v)
+	}
+	if v := elements(Intersect(a, b)); !slices.Equal(v, []int{3}) {
+		t.Errorf("Intersect %v", v)
+	}
+	if v := elements(Difference(a, b)); !slices.Equal(v, []int{1, 2}) {
+		t.Errorf("Difference %v", v)
+	}
+	if v := elements(SymmetricDifference(a, b)); !slices.Equal(v, []int{1, 2, 4}) {
+		t.Errorf("SymmetricDifference %v", v)
+	}
+	if value, ok := ContainsFunc(a, func(value int) bool { return value > 1 }); !ok || value != 2 {
+		t.Errorf("ContainsFunc %d %t", value, ok)
+	}
+	if _, ok := ContainsFunc(a, func(value int) bool { return value > 3 }); ok {
+		t.Error("ContainsFunc true")
+	}
+}
+
+func TestSetBuilder(t *testing.T) {
+	//t.Error("Logging on")
+	var set, err = NewSetBuilder[string]().Add("a", "b").Add("a").Set()
+	if err == nil {
+		t.Error("missing duplicate error")
+	}
+	if !set.IsValid("a") || !set.IsValid("b") || set.IsValid("c") {
+		t.Errorf("bad set %s", set)
+	}
+	if _, err = NewSetBuilder[string]().Add("a").Set(); err != nil {
+		t.Errorf("Set err %s", err)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// Setting is a hot-reloadable configuration value
+//   - [Setting.Get] is lock-free and returns the current value
+//   - [Setting.Set] validates and atomically replaces the value
+//   - [Setting.ChangeCh] closes on the next change allowing
+//     subscribers to await updates
+//   - typically updated by [github.com/haraldrudell/parl/mains.YamlReloader]
+//     when the process receives SIGHUP
+//   - thread-safe
+//
+// Usage:
+//
+//	var maxConns = parl.NewSetting(10, func(n int) (err error) {
+//	  if n < 1 {
+//	    err = perrors.ErrorfPF("maxConns must be positive: %d", n)
+//	  }
+//	  return
+//	})
+//	…
+//	for {
+//	  var changeCh = maxConns.ChangeCh()
+//	  pool.Resize(maxConns.Get())
+//	  select {
+//	  case <-changeCh:
+//	  case <-ctx.Done():
+//	    return
+//	  }
+//	}
+type Setting[T any] struct {
+	// validate checks values provided to Set, may be nil
+	validate func(value T) (err error)
+	// value is the current value
+	value atomic.Pointer[T]
+	// version is incremented by every change
+	version atomic.Uint64
+	// lock makes Set atomic with changeCh update
+	lock sync.Mutex
+	// changeCh is closed and replaced by every change
+	changeCh atomic.Pointer[Awaitable]
+}
+
+// NewSetting returns a hot-reloadable setting
+//   - value: initial value, not validated
+//   - validate: optional check of values provided to [Setting.Set]
+func NewSetting[T any](value T, validate ...func(value T) (err error)) (setting *Setting[T]) {
+	setting = &Setting[T]{}
+	if len(validate) > 0 {
+		setting.validate = validate[0]
+	}
+	setting.value.Store(&value)
+	setting.changeCh.Store(&Awaitable{})
+
+	return
+}
+
+// Get returns the current value
+func (s *Setting[T]) Get() (value T) { return *s.value.Load() }
+
+// Set validates and stores value
+//   - err: validation failure, the value is not changed
+//   - on success, the current change channel closes
+func (s *Setting[T]) Set(value T) (err error) {
+	if s.validate != nil {
+		if err = s.validate(value); err != nil {
+			err = perrors.ErrorfPF("invalid setting: %w", err)
+			return
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.value.Store(&value)
+	s.version.Add(1)
+	s.changeCh.Swap(&Awaitable{}).Close()
+
+	return
+}
+
+// ChangeCh returns a channel that closes on the next change
+//   - obtain the channel prior to Get to not miss a change
+func (s *Setting[T]) ChangeCh() (ch AwaitableCh) { return s.changeCh.Load().Ch() }
+
+// Version returns the number of changes since creation
+func (s *Setting[T]) Version() (version uint64) { return s.version.Load() }
@@ -0,0 +1,64 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetting(t *testing.T) {
+	//t.Error("Logging on")
+	var errBad = errors.New("bad")
+
+	var setting = NewSetting(1, func(n int) (err error) {
+		if n < 1 {
+			err = errBad
+		}
+		return
+	})
+	if v := setting.Get(); v != 1 {
+		t.Errorf("Get %d exp 1", v)
+	}
+	var changeCh = setting.ChangeCh()
+	select {
+	case <-changeCh:
+		t.Fatal("changeCh closed")
+	default:
+	}
+
+	// invalid value does not change
+	if err := setting.Set(0); !errors.Is(err, errBad) {
+		t.Errorf("Set 0 err %v", err)
+	}
+	if v := setting.Get(); v != 1 || setting.Version() != 0 {
+		t.Errorf("Get %d version %d", v, setting.Version())
+	}
+	select {
+	case <-changeCh:
+		t.Fatal("changeCh closed by invalid")
+	default:
+	}
+
+	// valid value closes changeCh
+	if err := setting.Set(2); err != nil {
+		t.Fatalf("Set err %s", err)
+	}
+	if v := setting.Get(); v != 2 || setting.Version() != 1 {
+		t.Errorf("Get %d version %d", v, setting.Version())
+	}
+	select {
+	case <-changeCh:
+	default:
+		t.Fatal("changeCh not closed")
+	}
+	// a new change channel is open
+	select {
+	case <-setting.ChangeCh():
+		t.Fatal("new changeCh closed")
+	default:
+	}
+}
@@ -0,0 +1,192 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"math"
+	"math/bits"
+	"math/rand"
+	"runtime"
+	"slices"
+	"sync/atomic"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// cacheLineSize is the size of padding to avoid false sharing
+	cacheLineSize = 64
+)
+
+// ShardedCounter is a monotonic counter for high-contention updates
+//   - the counter is split into padded shards, one or more per CPU,
+//     so that concurrent Add does not contend for a cache line
+//   - Value sums the shards and is O(number of CPUs)
+//   - initialization-free, thread-safe
+type ShardedCounter struct {
+	// shards is created on first use
+	shards atomic.Pointer[[]counterShard]
+}
+
+// counterShard is a counter occupying its own cache line
+type counterShard struct {
+	value atomic.Int64
+	_     [cacheLineSize - 8]byte
+}
+
+// Gauge is a value that can go up and down
+//   - initialization-free, thread-safe
+type Gauge struct{ value atomic.Int64 }
+
+// Histogram counts observed values into buckets
+//   - bounded memory: latencies and sizes are recorded without
+//     external metrics libraries
+//   - bounds are inclusive upper bounds of buckets in increasing order.
+//     A final bucket counts values greater than the last bound
+//   - thread-safe
+type Histogram struct {
+	// bounds are upper bounds of buckets
+	bounds []float64
+	// counts has one more element than bounds
+	counts []atomic.Uint64
+	// count is number of observations
+	count atomic.Uint64
+	// sum is the sum of observations
+	sum AtomicFloat64
+	// max is the greatest observation, -Inf if none
+	max AtomicFloat64
+}
+
+// Inc adds one to the counter
+func (c *ShardedCounter) Inc() { c.Add(1) }
+
+// Add adds delta to the counter
+//   - delta should not be negative
+func (c *ShardedCounter) Add(delta int64) {
+	var shards = c.getShards()
+	// rand.Uint32 of the global source is lock-free and does not contend
+	shards[rand.Uint32()&uint32(len(shards)-1)].value.Add(delta)
+}
+
+// Value returns the sum of all shards
+func (c *ShardedCounter) Value() (value int64) {
+	var shards = c.shards.Load()
+	if shards == nil {
+		return // no Add yet return
+	}
+	for i := range *shards {
+		value += (*shards)[i].value.Load()
+	}
+	return
+}
+
+// getShards returns shards, a power of two at least GOMAXPROCS
+func (c *ShardedCounter) getShards() (shards []counterShard) {
+	if sp := c.shards.Load(); sp != nil {
+		return *sp
+	}
+	var n = 1 << bits.Len(uint(runtime.GOMAXPROCS(0)-1))
+	shards = make([]counterShard, n)
+	if !c.shards.CompareAndSwap(nil, &shards) {
+		shards = *c.shards.Load()
+	}
+	return
+}
+
+// Set sets the gauge value
+func (g *Gauge) Set(value int64) { g.value.Store(value) }
+
+// Add adds a positive or negative delta
+func (g *Gauge) Add(delta int64) { g.value.Add(delta) }
+
+// Value returns the gauge value
+func (g *Gauge) Value() (value int64) { return g.value.Load() }
+
+// NewHistogram returns a histogram with bucket upper bounds
+//   - bounds must be increasing or panic
+func NewHistogram(bounds ...float64) (histogram *Histogram) {
+	if !slices.IsSorted(bounds) {
+		panic(perrors.ErrorfPF("bounds not increasing: %v", bounds))
+	}
+	histogram = &Histogram{
+		bounds: slices.Clone(bounds),
+		counts: make([]atomic.Uint64, len(bounds)+1),
+	}
+	histogram.max.Store(math.Inf(-1))
+
+	return
+}
+
+// Observe records a value
+func (h *Histogram) Observe(value float64) {
+	var index, _ = slices.BinarySearch(h.bounds, value)
+	h.counts[index].Add(1)
+	h.count.Add(1)
+	h.sum.Add(value)
+	for {
+		var current = h.max.Load()
+		if value <= current || h.max.CompareAndSwap(current, value) {
+			return
+		}
+	}
+}
+
+// Bounds returns the bucket upper bounds
+func (h *Histogram) Bounds() (bounds []float64) { return slices.Clone(h.bounds) }
+
+// Buckets returns per-bucket counts, count and sum of observations
+//   - buckets has one more element than bounds
+func (h *Histogram) Buckets() (buckets []uint64, count uint64, sum float64) {
+	buckets = make([]uint64, len(h.counts))
+	for i := range h.counts {
+		buckets[i] = h.counts[i].Load()
+	}
+	count = h.count.Load()
+	sum = h.sum.Load()
+	return
+}
+
+// Max returns the greatest observation
+//   - hasValue false: no observations
+func (h *Histogram) Max() (max float64, hasValue bool) {
+	if max = h.max.Load(); math.IsInf(max, -1) {
+		max = 0
+		return // no observations return
+	}
+	hasValue = true
+	return
+}
+
+// Quantile returns the upper bound of the bucket containing quantile q
+//   - q: 0…1, 0.99 is the 99th percentile
+//   - isOverflow: the quantile is in the final bucket above the last bound.
+//     bound is then [Histogram.Max]
+//   - no observations: zero-value
+func (h *Histogram) Quantile(q float64) (bound float64, isOverflow bool) {
+	var buckets, _, _ = h.Buckets()
+	var total uint64
+	for _, c := range buckets {
+		total += c
+	}
+	if total == 0 {
+		return // no observations return
+	}
+	var rank = uint64(q * float64(total))
+	var cumulative uint64
+	for i, c := range buckets {
+		if cumulative += c; cumulative > rank || cumulative == total {
+			if i < len(h.bounds) {
+				bound = h.bounds[i]
+				return
+			}
+			break
+		}
+	}
+	bound, _ = h.Max()
+	isOverflow = true
+
+	return
+}
@@ -0,0 +1,44 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	//t.Error("Logging on")
+	var values = []float64{0, 1, 5, 10, 11, 100}
+	var expMax = 100.
+
+	var histogram = NewHistogram(1, 10)
+	if _, hasValue := histogram.Max(); hasValue {
+		t.Error("Max hasValue without observations")
+	}
+	var wg sync.WaitGroup
+	for _, value := range values {
+		wg.Add(1)
+		go func(value float64) {
+			defer wg.Done()
+			histogram.Observe(value)
+		}(value)
+	}
+	wg.Wait()
+
+	if max, hasValue := histogram.Max(); !hasValue || max != expMax {
+		t.Errorf("Max %g %t exp %g", max, hasValue, expMax)
+	}
+	if bound, isOverflow := histogram.Quantile(0.5); bound != 10 || isOverflow {
+		t.Errorf("median %g %t", bound, isOverflow)
+	}
+	if bound, isOverflow := histogram.Quantile(0.99); bound != expMax || !isOverflow {
+		t.Errorf("p99 %g %t", bound, isOverflow)
+	}
+	if _, count, sum := histogram.Buckets(); count != uint64(len(values)) || sum != 127 {
+		t.Errorf("count %d sum %g", count, sum)
+	}
+}
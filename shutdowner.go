@@ -0,0 +1,238 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// DefaultHookTimeout is timeout of a shutdown hook registered with timeout 0
+	DefaultHookTimeout = 5 * time.Second
+	// NoShutdownDeadline: [Shutdowner] does not force exit
+	NoShutdownDeadline time.Duration = 0
+)
+
+// ShutdownConfig configures [Shutdowner]
+type ShutdownConfig struct {
+	// Deadline is the maximum duration of shutdown
+	//	- [NoShutdownDeadline] 0: shutdown is not forced
+	Deadline time.Duration
+	// ForceExit is invoked when Deadline is exceeded,
+	// typically terminating the process
+	//	- default: os.Exit(1)
+	ForceExit func()
+}
+
+// Shutdowner coordinates ordered shutdown of subsystems
+//   - subsystems register named hooks with priority and timeout
+//   - on trigger, hooks are executed one at a time in priority order,
+//     each with its own timeout
+//   - hook errors, panics and timeouts are provided to an error sink
+//   - a hook exceeding its timeout is abandoned and shutdown proceeds
+//   - trigger is programmatic [Shutdowner.Shutdown] or
+//     signal [Shutdowner.Notify]
+//   - with [ShutdownConfig.Deadline], ForceExit is invoked if
+//     shutdown does not complete in time
+//   - thread-safe
+//
+// Usage:
+//
+//	var shutdowner = parl.NewShutdowner(errorSink, parl.ShutdownConfig{Deadline: 30 * time.Second})
+//	shutdowner.Register("status", 0, 0, func(ctx context.Context) (err error) { statusTerminal.EndStatus(); return })
+//	shutdowner.Register("threads", 10, 10*time.Second, func(ctx context.Context) (err error) {
+//	  goGroup.Cancel()
+//	  select { case <-goGroup.WaitCh(): case <-ctx.Done(): err = ctx.Err() }
+//	  return
+//	})
+//	shutdowner.Register("db", 20, 0, func(ctx context.Context) (err error) { return db.Close() })
+//	shutdowner.Notify()
+//	<-shutdowner.Ch()
+type Shutdowner struct {
+	errorSink ErrorSink1
+	deadline  time.Duration
+	forceExit func()
+	// lock makes hooks and isTriggered thread-safe
+	lock sync.Mutex
+	// hooks are registered hooks, behind lock
+	hooks []*shutdownHook
+	// isTriggered is true once shutdown has begun, behind lock
+	isTriggered bool
+	// isDone closes when shutdown completed
+	isDone Awaitable
+	// signalCh receives signals for Notify
+	signalCh chan os.Signal
+}
+
+// shutdownHook is a registered hook
+type shutdownHook struct {
+	name     string
+	priority int
+	timeout  time.Duration
+	hook     func(ctx context.Context) (err error)
+}
+
+// NewShutdowner returns a shutdown coordinator
+//   - errorSink: receives hook failures
+//   - config: optional deadline and force exit
+func NewShutdowner(errorSink ErrorSink1, config ...ShutdownConfig) (shutdowner *Shutdowner) {
+	if errorSink == nil {
+		panic(NilError("errorSink"))
+	}
+	var s = Shutdowner{errorSink: errorSink}
+	if len(config) > 0 {
+		s.deadline = config[0].Deadline
+		s.forceExit = config[0].ForceExit
+	}
+	if s.forceExit == nil {
+		s.forceExit = func() { os.Exit(1) }
+	}
+	return &s
+}
+
+// Register adds a shutdown hook
+//   - name: used in error messages
+//   - priority: hooks execute in ascending priority,
+//     equal priority in order of registration
+//   - timeout: 0 is [DefaultHookTimeout]
+//   - hook: ctx is canceled at timeout
+//   - hooks registered after shutdown was triggered are not executed
+func (s *Shutdowner) Register(name string, priority int, timeout time.Duration, hook func(ctx context.Context) (err error)) {
+	if hook == nil {
+		panic(NilError("hook"))
+	}
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.hooks = append(s.hooks, &shutdownHook{
+		name:     name,
+		priority: priority,
+		timeout:  timeout,
+		hook:     hook,
+	})
+}
+
+// Notify triggers shutdown on signal
+//   - sig: default SIGINT SIGTERM
+//   - shutdown is executed by a new thread
+func (s *Shutdowner) Notify(sig ...os.Signal) {
+	if len(sig) == 0 {
+		sig = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	s.lock.Lock()
+	if s.signalCh != nil {
+		s.lock.Unlock()
+		signal.Notify(s.signalCh, sig...)
+		return // additional signals return
+	}
+	s.signalCh = make(chan os.Signal, 1)
+	s.lock.Unlock()
+
+	signal.Notify(s.signalCh, sig...)
+	go s.signalThread()
+}
+
+// Shutdown executes shutdown hooks
+//   - blocks until shutdown completes
+//   - subsequent invocations await the first shutdown
+func (s *Shutdowner) Shutdown() {
+	s.lock.Lock()
+	if s.isTriggered {
+		s.lock.Unlock()
+		<-s.isDone.Ch()
+		return // already triggered return
+	}
+	s.isTriggered = true
+	var hooks = slices.Clone(s.hooks)
+	var signalCh = s.signalCh
+	s.lock.Unlock()
+	defer s.isDone.Close()
+
+	if signalCh != nil {
+		signal.Stop(signalCh)
+	}
+	if s.deadline > 0 {
+		var timer = time.AfterFunc(s.deadline, s.forceExit)
+		defer timer.Stop()
+	}
+
+	slices.SortStableFunc(hooks, func(a, b *shutdownHook) (result int) {
+		return cmp.Compare(a.priority, b.priority)
+	})
+	for _, h := range hooks {
+		if err := h.run(); err != nil {
+			s.errorSink.AddError(err)
+		}
+	}
+}
+
+// IsShutdown returns true once shutdown was triggered
+func (s *Shutdowner) IsShutdown() (isShutdown bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.isTriggered
+}
+
+// Ch returns a channel that closes when shutdown has completed
+func (s *Shutdowner) Ch() (ch AwaitableCh) { return s.isDone.Ch() }
+
+// signalThread triggers shutdown on signal
+func (s *Shutdowner) signalThread() {
+	defer Recover(func() DA { return A() }, NoErrp, s.errorSink)
+
+	select {
+	case <-s.signalCh:
+		s.Shutdown()
+	case <-s.isDone.Ch():
+	}
+}
+
+// run executes the hook with timeout
+func (h *shutdownHook) run() (err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	var errCh = make(chan error, 1)
+	go h.hookThread(ctx, errCh)
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = perrors.ErrorfPF("shutdown hook %q timed out after %s", h.name, h.timeout)
+	}
+
+	return
+}
+
+// hookThread executes the hook recovering a panic
+func (h *shutdownHook) hookThread(ctx context.Context, errCh chan<- error) {
+	var err error
+	defer h.hookEnd(&err, errCh)
+	defer RecoverErr(func() DA { return A() }, &err)
+
+	err = h.hook(ctx)
+}
+
+// hookEnd provides the outcome of the hook
+func (h *shutdownHook) hookEnd(errp *error, errCh chan<- error) {
+	var err = *errp
+	if err != nil {
+		err = perrors.ErrorfPF("shutdown hook %q: %w", h.name, err)
+	}
+	errCh <- err
+}
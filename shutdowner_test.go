@@ -0,0 +1,93 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdowner(t *testing.T) {
+	//t.Error("Logging on")
+	var errBad = errors.New("bad")
+	var expOrder = []string{"first", "second", "third", "panic", "slow"}
+	var expErrors = 3
+
+	var errs ErrSlice
+	var shutdowner = NewShutdowner(&errs)
+	var lock sync.Mutex
+	var order []string
+	var hook = func(name string, err error) (hook func(ctx context.Context) (err error)) {
+		return func(ctx context.Context) error {
+			lock.Lock()
+			order = append(order, name)
+			lock.Unlock()
+			return err
+		}
+	}
+
+	// registration order differs from priority order
+	shutdowner.Register("third", 2, 0, hook("third", errBad))
+	shutdowner.Register("first", 0, 0, hook("first", nil))
+	shutdowner.Register("second", 1, 0, hook("second", nil))
+	shutdowner.Register("panic", 3, 0, func(ctx context.Context) (err error) {
+		hook("panic", nil)(ctx)
+		panic(errBad)
+	})
+	shutdowner.Register("slow", 4, time.Millisecond, func(ctx context.Context) (err error) {
+		hook("slow", nil)(ctx)
+		time.Sleep(time.Second)
+		return
+	})
+
+	if shutdowner.IsShutdown() {
+		t.Error("IsShutdown true")
+	}
+	shutdowner.Shutdown()
+	<-shutdowner.Ch()
+	if !shutdowner.IsShutdown() {
+		t.Error("IsShutdown false")
+	}
+	lock.Lock()
+	if !slices.Equal(order, expOrder) {
+		t.Errorf("order %v exp %v", order, expOrder)
+	}
+	lock.Unlock()
+	var errList = errs.Errors()
+	if len(errList) != expErrors {
+		t.Fatalf("errors %d exp %d: %v", len(errList), expErrors, errList)
+	}
+	for i, err := range errList[:2] {
+		if !errors.Is(err, errBad) {
+			t.Errorf("error %d: %v", i, err)
+		}
+	}
+
+	// second Shutdown does not execute hooks
+	shutdowner.Shutdown()
+	lock.Lock()
+	if len(order) != len(expOrder) {
+		t.Error("hooks executed twice")
+	}
+	lock.Unlock()
+}
+
+func TestShutdownerForceExit(t *testing.T) {
+	var forceCh = make(chan struct{})
+	var shutdowner = NewShutdowner(&ErrSlice{}, ShutdownConfig{
+		Deadline:  time.Millisecond,
+		ForceExit: func() { close(forceCh) },
+	})
+	shutdowner.Register("hang", 0, time.Second, func(ctx context.Context) (err error) {
+		<-forceCh
+		return
+	})
+	shutdowner.Shutdown()
+}
@@ -0,0 +1,82 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// SignalValue is a one-shot awaitable event carrying a value
+//   - like [Awaitable] but the event carries a typed value set exactly once
+//   - unlike [Future], there is no error or calculation outcome
+//   - typical values: a ready signal carrying a bound address, an opened
+//     database handle or a first error between supervised threads
+//   - initialization-free, thread-safe
+//
+// Usage:
+//
+//	var ready parl.SignalValue[netip.AddrPort]
+//	go serverThread(&ready)
+//	var addrPort, err = ready.Get(ctx)
+//	…
+//	func serverThread(ready *parl.SignalValue[netip.AddrPort]) {
+//	  var listener, err = net.Listen("tcp", "127.0.0.1:0")
+//	  …
+//	  ready.Set(listener.Addr().(*net.TCPAddr).AddrPort())
+type SignalValue[T any] struct {
+	// value is the value once set
+	value atomic.Pointer[T]
+	// isSet closes once value was set
+	isSet Awaitable
+}
+
+// Set provides the value and triggers the event
+//   - didSet false: the value was already set, value is ignored
+func (s *SignalValue[T]) Set(value T) (didSet bool) {
+	if didSet = s.value.CompareAndSwap(nil, &value); didSet {
+		s.isSet.Close()
+	}
+	return
+}
+
+// Ch returns a channel that closes once the value was set
+func (s *SignalValue[T]) Ch() (ch AwaitableCh) { return s.isSet.Ch() }
+
+// IsSet returns true once the value was set
+func (s *SignalValue[T]) IsSet() (isSet bool) { return s.value.Load() != nil }
+
+// TryGet returns the value if set
+//   - isSet false: value is zero-value
+//   - does not block
+func (s *SignalValue[T]) TryGet() (value T, isSet bool) {
+	var vp = s.value.Load()
+	if isSet = vp != nil; isSet {
+		value = *vp
+	}
+	return
+}
+
+// Get awaits the value or ctx cancel
+//   - err: on ctx cancel, the context error
+//   - may block
+func (s *SignalValue[T]) Get(ctx context.Context) (value T, err error) {
+	select {
+	case <-s.isSet.Ch():
+	case <-ctx.Done():
+		if vp := s.value.Load(); vp != nil {
+			value = *vp
+			return // value present at cancel return
+		}
+		err = perrors.ErrorfPF("SignalValue: %w", context.Cause(ctx))
+		return
+	}
+	value = *s.value.Load()
+
+	return
+}
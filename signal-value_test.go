@@ -0,0 +1,51 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSignalValue(t *testing.T) {
+	//t.Error("Logging on")
+	var value1, value2 = "a", "b"
+
+	var signal SignalValue[string]
+	if _, isSet := signal.TryGet(); isSet || signal.IsSet() {
+		t.Error("zero-value is set")
+	}
+	select {
+	case <-signal.Ch():
+		t.Fatal("Ch closed")
+	default:
+	}
+
+	// Get with canceled context
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := signal.Get(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Get err %v", err)
+	}
+
+	// Set from another thread
+	go signal.Set(value1)
+	if value, err := signal.Get(context.Background()); err != nil || value != value1 {
+		t.Errorf("Get %q err %v", value, err)
+	}
+	<-signal.Ch()
+	if signal.Set(value2) {
+		t.Error("second Set didSet")
+	}
+	if value, isSet := signal.TryGet(); !isSet || value != value1 {
+		t.Errorf("TryGet %q %t", value, isSet)
+	}
+	// value is returned even if ctx is canceled
+	if value, err := signal.Get(ctx); err != nil || value != value1 {
+		t.Errorf("Get canceled %q err %v", value, err)
+	}
+}
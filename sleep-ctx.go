@@ -0,0 +1,119 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"time"
+)
+
+// SleepCtx sleeps for d or until ctx is canceled
+//   - err: nil if d elapsed, otherwise [context.Cause] of ctx
+//   - d zero or negative: returns immediately, err is any ctx cause
+//   - replaces time.NewTimer and select in threads that must exit on cancel
+//
+// Usage:
+//
+//	if err = parl.SleepCtx(ctx, time.Second); err != nil {
+//	  return // canceled return
+//	}
+func SleepCtx(ctx context.Context, d time.Duration) (err error) {
+	if d <= 0 {
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+		return
+	}
+	var timer = time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		err = context.Cause(ctx)
+	case <-timer.C:
+	}
+
+	return
+}
+
+// TickerCtx is a ticker that stops when its context is canceled
+//   - [TickerCtx.Next] awaits the next tick returning false on cancel or Stop
+//   - [TickerCtx.Ch] is an awaitable channel closing on cancel or Stop
+//   - [TickerCtx.C] provides ticks for select statements
+//   - thread-safe
+//
+// Usage:
+//
+//	var ticker = parl.NewTickerCtx(ctx, time.Second)
+//	defer ticker.Stop()
+//	for ticker.Next() {
+//	  poll()
+//	}
+type TickerCtx struct {
+	ctx    context.Context
+	ticker *time.Ticker
+	// done closes on context cancel or Stop
+	done Awaitable
+	// stopAfter removes the context callback
+	stopAfter func() bool
+}
+
+// NewTickerCtx returns a ticker with period d stopping on ctx cancel
+//   - d must be positive
+//   - Stop should be invoked to release resources
+func NewTickerCtx(ctx context.Context, d time.Duration) (ticker *TickerCtx) {
+	if ctx == nil {
+		panic(NilError("ctx"))
+	}
+	ticker = &TickerCtx{ctx: ctx, ticker: time.NewTicker(d)}
+	ticker.stopAfter = context.AfterFunc(ctx, ticker.stop)
+
+	return
+}
+
+// Next blocks until the next tick
+//   - isTick false: ctx was canceled or the ticker stopped
+func (t *TickerCtx) Next() (isTick bool) {
+	if t.done.IsClosed() {
+		return // stopped return
+	}
+	select {
+	case <-t.ticker.C:
+		isTick = !t.done.IsClosed()
+	case <-t.done.Ch():
+	}
+	return
+}
+
+// C returns the channel of ticks
+//   - the channel does not close, select also on [TickerCtx.Ch]
+func (t *TickerCtx) C() (ch <-chan time.Time) { return t.ticker.C }
+
+// Ch returns an awaitable channel that closes on ctx cancel or Stop
+func (t *TickerCtx) Ch() (ch AwaitableCh) { return t.done.Ch() }
+
+// Err returns the context’s cause if canceled
+//   - nil if not canceled, including after Stop
+func (t *TickerCtx) Err() (err error) {
+	if t.ctx.Err() != nil {
+		err = context.Cause(t.ctx)
+	}
+	return
+}
+
+// Stop stops the ticker
+//   - idempotent, thread-safe
+func (t *TickerCtx) Stop() {
+	t.stop()
+	t.stopAfter()
+}
+
+// stop stops the ticker and closes done
+//   - invoked by Stop or on context cancel
+func (t *TickerCtx) stop() {
+	t.ticker.Stop()
+	t.done.Close()
+}
@@ -0,0 +1,61 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSleepCtx(t *testing.T) {
+	//t.Error("Logging on")
+	var errCause = errors.New("cause")
+
+	if err := SleepCtx(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("SleepCtx err %s", err)
+	}
+	var ctx, cancel = context.WithCancelCause(context.Background())
+	cancel(errCause)
+	if err := SleepCtx(ctx, time.Hour); !errors.Is(err, errCause) {
+		t.Errorf("SleepCtx canceled err %v", err)
+	}
+	if err := SleepCtx(ctx, 0); !errors.Is(err, errCause) {
+		t.Errorf("SleepCtx zero err %v", err)
+	}
+}
+
+func TestTickerCtx(t *testing.T) {
+	//t.Error("Logging on")
+	var ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	var ticker = NewTickerCtx(ctx, time.Millisecond)
+	defer ticker.Stop()
+	if !ticker.Next() {
+		t.Fatal("Next false")
+	}
+	if err := ticker.Err(); err != nil {
+		t.Errorf("Err %s", err)
+	}
+	cancel()
+	<-ticker.Ch()
+	if ticker.Next() {
+		t.Error("Next true after cancel")
+	}
+	if err := ticker.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Err %v", err)
+	}
+
+	// Stop without cancel
+	var ticker2 = NewTickerCtx(context.Background(), time.Hour)
+	ticker2.Stop()
+	ticker2.Stop()
+	if ticker2.Next() {
+		t.Error("Next true after Stop")
+	}
+}
@@ -0,0 +1,255 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/ptime"
+)
+
+const (
+	// initial sample capacity of [SlidingWindow]
+	slidingWindowInitialCap = 16
+)
+
+// SlidingWindow provides statistics over a trailing time window
+//   - records events or durations like query latencies
+//   - count, rate, mean and percentiles over the window
+//   - insert is O(1) amortized. Memory is proportional to
+//     the number of samples within the window and is reused
+//   - percentiles are calculated on read by sorting a reused buffer
+//   - time is optional for every method, default is now of
+//     the optional clock. Times should be non-decreasing
+//   - thread-safe
+//
+// Usage:
+//
+//	var latency = parl.NewSlidingWindow(time.Minute)
+//	…
+//	var t0 = time.Now()
+//	query()
+//	latency.Add(time.Since(t0))
+//	…
+//	var stats = latency.Stats()
+//	fmt.Printf("%s p99: %s\n", stats, stats.P99)
+type SlidingWindow struct {
+	// window is the length of the trailing window
+	window time.Duration
+	// clock provides default time
+	clock ptime.Clock
+	// lock makes fields thread-safe
+	lock sync.Mutex
+	// samples is a ring buffer of samples, behind lock
+	samples []slidingSample
+	// head is the index of the oldest sample, behind lock
+	head int
+	// n is number of samples, behind lock
+	n int
+	// sum is the sum of sample durations, behind lock
+	sum time.Duration
+	// sorted is buffer for percentiles, behind lock
+	sorted []time.Duration
+}
+
+// slidingSample is a recorded event
+type slidingSample struct {
+	// t is time of the sample, unix nanoseconds
+	t int64
+	// d is sample duration, 0 for events
+	d time.Duration
+}
+
+// SlidingWindowStats is a snapshot of [SlidingWindow] statistics
+type SlidingWindowStats struct {
+	// Count is number of samples in the window
+	Count int
+	// Rate is samples per second over the window
+	Rate float64
+	// Mean is average sample duration
+	Mean time.Duration
+	// P50 is the median sample duration
+	P50 time.Duration
+	// P99 is the 99th percentile sample duration
+	P99 time.Duration
+}
+
+// NewSlidingWindow returns statistics over a trailing window
+//   - window: length of the window, must be positive or panic
+//   - clock: optional clock, default [ptime.SystemClock]
+func NewSlidingWindow(window time.Duration, clock ...ptime.Clock) (slidingWindow *SlidingWindow) {
+	if window <= 0 {
+		panic(perrors.ErrorfPF("window must be positive: %s", window))
+	}
+	return &SlidingWindow{window: window, clock: ptime.GetClock(clock...)}
+}
+
+// Event records an event without duration
+//   - t: optional time of event, default clock now
+func (w *SlidingWindow) Event(t ...time.Time) { w.Add(0, t...) }
+
+// Add records a sample duration
+//   - d: duration, for example latency
+//   - t: optional time of sample, default clock now
+func (w *SlidingWindow) Add(d time.Duration, t ...time.Time) {
+	var now = w.now(t)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.expire(now)
+	if w.n == len(w.samples) {
+		w.grow()
+	}
+	var index = w.head + w.n
+	if index >= len(w.samples) {
+		index -= len(w.samples)
+	}
+	w.samples[index] = slidingSample{t: now, d: d}
+	w.n++
+	w.sum += d
+}
+
+// Count returns the number of samples in the window
+//   - t: optional time of the window end, default clock now
+func (w *SlidingWindow) Count(t ...time.Time) (count int) {
+	var now = w.now(t)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.expire(now)
+	return w.n
+}
+
+// Rate returns samples per second over the window
+//   - t: optional time of the window end, default clock now
+func (w *SlidingWindow) Rate(t ...time.Time) (rate float64) {
+	return float64(w.Count(t...)) / w.window.Seconds()
+}
+
+// Mean returns average sample duration
+//   - t: optional time of the window end, default clock now
+//   - no samples: 0
+func (w *SlidingWindow) Mean(t ...time.Time) (mean time.Duration) {
+	var now = w.now(t)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.expire(now)
+	if w.n > 0 {
+		mean = w.sum / time.Duration(w.n)
+	}
+	return
+}
+
+// Percentile returns the nearest-rank percentile of sample durations
+//   - percent: 0…100, 50 is the median
+//   - t: optional time of the window end, default clock now
+//   - no samples: 0
+func (w *SlidingWindow) Percentile(percent float64, t ...time.Time) (d time.Duration) {
+	var now = w.now(t)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.expire(now)
+	return w.percentile(w.sort(), percent)
+}
+
+// Stats returns count, rate, mean, median and 99th percentile
+//   - t: optional time of the window end, default clock now
+func (w *SlidingWindow) Stats(t ...time.Time) (stats SlidingWindowStats) {
+	var now = w.now(t)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.expire(now)
+	if stats.Count = w.n; stats.Count == 0 {
+		return // no samples return
+	}
+	stats.Rate = float64(w.n) / w.window.Seconds()
+	stats.Mean = w.sum / time.Duration(w.n)
+	var sorted = w.sort()
+	stats.P50 = w.percentile(sorted, 50)
+	stats.P99 = w.percentile(sorted, 99)
+
+	return
+}
+
+// Window returns the length of the trailing window
+func (w *SlidingWindow) Window() (window time.Duration) { return w.window }
+
+// "count 3 rate 0.05/s mean 1ms p50 1ms p99 2ms"
+func (s SlidingWindowStats) String() (s2 string) {
+	return Sprintf("count %d rate %.3g/s mean %s p50 %s p99 %s",
+		s.Count, s.Rate, s.Mean, s.P50, s.P99,
+	)
+}
+
+// now returns optional time as unix nanoseconds
+func (w *SlidingWindow) now(t []time.Time) (now int64) {
+	if len(t) > 0 {
+		return t[0].UnixNano()
+	}
+	return w.clock.Now().UnixNano()
+}
+
+// expire removes samples older than the window, behind lock
+func (w *SlidingWindow) expire(now int64) {
+	var oldest = now - int64(w.window)
+	for w.n > 0 {
+		var sample = &w.samples[w.head]
+		if sample.t > oldest {
+			return // remaining samples are within window return
+		}
+		w.sum -= sample.d
+		w.n--
+		if w.head++; w.head == len(w.samples) {
+			w.head = 0
+		}
+	}
+	// empty: restart at index 0
+	w.head = 0
+}
+
+// grow doubles sample capacity, behind lock
+func (w *SlidingWindow) grow() {
+	var samples = make([]slidingSample, max(2*len(w.samples), slidingWindowInitialCap))
+	var copied = copy(samples, w.samples[w.head:])
+	copy(samples[copied:], w.samples[:w.head])
+	w.samples = samples
+	w.head = 0
+}
+
+// sort returns sample durations in ascending order, behind lock
+func (w *SlidingWindow) sort() (sorted []time.Duration) {
+	sorted = w.sorted[:0]
+	for i, index := 0, w.head; i < w.n; i++ {
+		sorted = append(sorted, w.samples[index].d)
+		if index++; index == len(w.samples) {
+			index = 0
+		}
+	}
+	slices.Sort(sorted)
+	w.sorted = sorted
+	return
+}
+
+// percentile returns nearest-rank percentile of sorted
+func (w *SlidingWindow) percentile(sorted []time.Duration, percent float64) (d time.Duration) {
+	var n = len(sorted)
+	if n == 0 {
+		return // no samples return
+	}
+	// nearest rank: ceil(percent/100 × n), 1-based
+	var rank = int(percent / 100 * float64(n))
+	if float64(rank) < percent/100*float64(n) {
+		rank++
+	}
+	rank = min(max(rank, 1), n)
+	return sorted[rank-1]
+}
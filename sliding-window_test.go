@@ -0,0 +1,65 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	//t.Error("Logging on")
+	const window = 10 * time.Second
+	var t0 = time.Now()
+
+	var stats SlidingWindowStats
+	var w = NewSlidingWindow(window)
+
+	// 100 samples 1…100 ms 10 ms apart: all within window
+	for i := 1; i <= 100; i++ {
+		w.Add(time.Duration(i)*time.Millisecond, t0.Add(time.Duration(i)*10*time.Millisecond))
+	}
+	var t1 = t0.Add(time.Second)
+	stats = w.Stats(t1)
+	if stats.Count != 100 {
+		t.Errorf("Count %d exp %d", stats.Count, 100)
+	}
+	if stats.Rate != 10 {
+		t.Errorf("Rate %g exp %g", stats.Rate, 10.)
+	}
+	if exp := 50500 * time.Microsecond; stats.Mean != exp {
+		t.Errorf("Mean %s exp %s", stats.Mean, exp)
+	}
+	if exp := 50 * time.Millisecond; stats.P50 != exp {
+		t.Errorf("P50 %s exp %s", stats.P50, exp)
+	}
+	if exp := 99 * time.Millisecond; stats.P99 != exp {
+		t.Errorf("P99 %s exp %s", stats.P99, exp)
+	}
+	if d := w.Percentile(100, t1); d != 100*time.Millisecond {
+		t.Errorf("Percentile 100 %s", d)
+	}
+
+	// samples expire: at t0 + window + 505 ms, first 50 samples expired
+	var t2 = t0.Add(window + 505*time.Millisecond)
+	if count := w.Count(t2); count != 50 {
+		t.Errorf("Count %d exp %d", count, 50)
+	}
+	if mean := w.Mean(t2); mean != 75500*time.Microsecond {
+		t.Errorf("Mean %s exp %s", mean, 75500*time.Microsecond)
+	}
+
+	// events after all expired: buffer is reused
+	var t3 = t2.Add(window)
+	w.Event(t3)
+	stats = w.Stats(t3)
+	if stats.Count != 1 || stats.Mean != 0 || stats.P99 != 0 {
+		t.Errorf("Stats %s", stats)
+	}
+	if n := len(w.samples); n != 128 {
+		t.Errorf("samples capacity %d exp %d", n, 128)
+	}
+}
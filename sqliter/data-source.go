@@ -77,3 +77,10 @@ func OpenDataSource(dataSourceName parl.DataSourceName) (dataSource parl.DataSou
 func (ds *DataSource) WrapStmt(stmt *sql.Stmt) (stm psql2.Stmt) {
 	return &Stmt{Stmt: stmt, ds: ds}
 }
+
+// IsBusy returns true if err is SQLite3 busy or locked
+//   - used by [github.com/haraldrudell/parl/psql.DBMap.InTx] to retry transactions
+func (ds *DataSource) IsBusy(err error) (isBusy bool) {
+	var code, _ = Code(err)
+	return code == CodeBusy || code == CodeDatabaseIsLocked
+}
@@ -0,0 +1,343 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package sqliter
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// archiveExtension is appended to archived partition files: “.db.gz”
+	archiveExtension = ".gz"
+	// SQLite3 write-ahead log and shared-memory files of a database file
+	walSuffix, shmSuffix = "-wal", "-shm"
+	// partition files are readable by the owning user only
+	urw os.FileMode = 0600
+)
+
+// PartitionDB controls cached database objects of partitions
+//   - implemented by [github.com/haraldrudell/parl/psql.DBMap]
+type PartitionDB interface {
+	// OpenPartition creates partition with schema and allows access to it
+	OpenPartition(partition parl.DBPartition, ctx context.Context) (err error)
+	// ClosePartition closes partition preventing its re-creation
+	ClosePartition(partition parl.DBPartition) (err error)
+}
+
+// PartitionPolicy configures the partition lifecycle of [PartitionManager]
+//   - partitions are years like “2024”
+type PartitionPolicy struct {
+	// Ahead is the number of upcoming years created ahead of time
+	//	- 0: only the current year is created
+	Ahead int
+	// Retention is the number of years including the current year
+	// kept open
+	//	- older partitions are closed
+	//	- 0: partitions are never closed
+	Retention int
+	// Archive gzip-compresses closed partitions into
+	// “appName-2022.db.gz”, removing the database file
+	Archive bool
+	// DropAfter is the number of years including the current year
+	// after which partitions and archives are deleted
+	//	- 0: partitions are never deleted
+	DropAfter int
+}
+
+// PartitionManager maintains year-partitioned SQLite3 database files
+// of [DSNrFactory]:
+//   - pre-creates upcoming partitions
+//   - closes and possibly archives partitions beyond retention
+//   - deletes partitions beyond DropAfter
+//   - provides the active partition set
+//
+// Partitions are closed via [PartitionDB] prior to file operations,
+// so that concurrent queries cannot re-create a partition being
+// archived or deleted
+type PartitionManager struct {
+	db      PartitionDB
+	policy  PartitionPolicy
+	dir     string
+	appName string
+
+	// maintainLock serializes Maintain
+	maintainLock sync.Mutex
+	// activeLock makes active thread-safe
+	activeLock sync.RWMutex
+	// active is sorted open partitions, behind activeLock
+	active []parl.DBPartition
+}
+
+// NewPartitionManager returns a partition manager for the
+// database files of appName
+//   - db: typically [github.com/haraldrudell/parl/psql.DBMap] using
+//     the data source namer from [DSNrFactory] for appName
+//   - [PartitionManager.Maintain] or [PartitionManager.Run] applies policy
+func NewPartitionManager(appName string, db PartitionDB, policy PartitionPolicy) (manager *PartitionManager, err error) {
+	if db == nil {
+		err = perrors.NewPF("db cannot be nil")
+		return
+	}
+	var namer = newDataSourceNamer(appName)
+	if err = namer.create(); err != nil {
+		return
+	}
+	manager = newPartitionManager(namer.dir, appName, db, policy)
+
+	return
+}
+
+// newPartitionManager returns a partition manager for files in dir
+func newPartitionManager(dir, appName string, db PartitionDB, policy PartitionPolicy) (manager *PartitionManager) {
+	return &PartitionManager{
+		db:      db,
+		policy:  policy,
+		dir:     dir,
+		appName: appName,
+	}
+}
+
+// YearPartition returns the partition for the year of t: “2024”
+func YearPartition(t time.Time) (partition parl.DBPartition) {
+	return parl.DBPartition(strconv.Itoa(t.Year()))
+}
+
+// Maintain applies the partition policy for the time now
+//   - creates the current and upcoming partitions
+//   - closes, archives and deletes older partitions
+//   - updates the active partition set
+//   - errors do not stop processing of other partitions
+//   - thread-safe
+func (m *PartitionManager) Maintain(ctx context.Context, now time.Time) (err error) {
+	m.maintainLock.Lock()
+	defer m.maintainLock.Unlock()
+
+	var year = now.Year()
+	var open = make(map[int]bool)
+
+	// create ahead
+	for y := year; y <= year+max(m.policy.Ahead, 0); y++ {
+		if e := m.db.OpenPartition(m.partition(y), ctx); e != nil {
+			err = perrors.AppendError(err, e)
+			continue
+		}
+		open[y] = true
+	}
+
+	// existing partitions
+	var files map[int]partitionFile
+	var e error
+	if files, e = m.files(); e != nil {
+		err = perrors.AppendError(err, e)
+	}
+	for y, file := range files {
+		var isDrop = m.policy.DropAfter > 0 && y <= year-m.policy.DropAfter
+		var isClose = isDrop || m.policy.Retention > 0 && y <= year-m.policy.Retention
+		if !isClose {
+			if file.hasDB {
+				open[y] = true
+			}
+			continue
+		}
+		if e = m.retire(y, file, isDrop); e != nil {
+			err = perrors.AppendError(err, e)
+		}
+	}
+
+	// update active set
+	var active = make([]parl.DBPartition, 0, len(open))
+	for y := range open {
+		active = append(active, m.partition(y))
+	}
+	slices.Sort(active)
+	m.activeLock.Lock()
+	m.active = active
+	m.activeLock.Unlock()
+
+	return
+}
+
+// Run invokes Maintain every interval until ctx is canceled
+//   - the first pass is immediate
+//   - errors are submitted to errorSink
+//   - Run blocks and is typically invoked in its own thread
+func (m *PartitionManager) Run(ctx context.Context, interval time.Duration, errorSink parl.ErrorSink1) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Maintain(ctx, time.Now()); err != nil {
+			errorSink.AddError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return // cancel return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Active returns the sorted partitions that are open
+//   - available after the first [PartitionManager.Maintain]
+//   - thread-safe
+func (m *PartitionManager) Active() (partitions []parl.DBPartition) {
+	m.activeLock.RLock()
+	defer m.activeLock.RUnlock()
+
+	return slices.Clone(m.active)
+}
+
+// IsActive returns true if partition is open
+//   - thread-safe
+func (m *PartitionManager) IsActive(partition parl.DBPartition) (isActive bool) {
+	m.activeLock.RLock()
+	defer m.activeLock.RUnlock()
+
+	_, isActive = slices.BinarySearch(m.active, partition)
+	return
+}
+
+// partitionFile is the files present for a partition year
+type partitionFile struct {
+	// hasDB: “appName-2022.db” exists
+	hasDB bool
+	// hasArchive: “appName-2022.db.gz” exists
+	hasArchive bool
+}
+
+// files returns partition files present in the directory by year
+func (m *PartitionManager) files() (files map[int]partitionFile, err error) {
+	var entries []os.DirEntry
+	if entries, err = os.ReadDir(m.dir); perrors.IsPF(&err, "os.ReadDir: %w %q", err, m.dir) {
+		return
+	}
+	files = make(map[int]partitionFile)
+	var prefix = m.appName + hyphen
+	for _, entry := range entries {
+		var name = entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		name = name[len(prefix):]
+		var isArchive bool
+		if name, isArchive = strings.CutSuffix(name, extension+archiveExtension); !isArchive {
+			var isDB bool
+			if name, isDB = strings.CutSuffix(name, extension); !isDB {
+				continue // not a partition file
+			}
+		}
+		var year, e = strconv.Atoi(name)
+		if e != nil {
+			continue // not a year partition
+		}
+		var file = files[year]
+		if isArchive {
+			file.hasArchive = true
+		} else {
+			file.hasDB = true
+		}
+		files[year] = file
+	}
+
+	return
+}
+
+// retire closes a partition and archives or deletes its files
+func (m *PartitionManager) retire(year int, file partitionFile, isDrop bool) (err error) {
+	var partition = m.partition(year)
+
+	// prevent concurrent re-creation
+	if err = m.db.ClosePartition(partition); err != nil {
+		return
+	}
+	var dbPath = m.path(year)
+
+	if isDrop {
+		for _, path := range []string{dbPath, dbPath + walSuffix, dbPath + shmSuffix, dbPath + archiveExtension} {
+			if e := os.Remove(path); e != nil && !errors.Is(e, fs.ErrNotExist) {
+				err = perrors.AppendError(err, perrors.ErrorfPF("os.Remove: %w", e))
+			}
+		}
+		return // dropped return
+	} else if !m.policy.Archive || !file.hasDB {
+		return // closed return
+	}
+
+	// archive
+	if err = gzipFile(dbPath, dbPath+archiveExtension); err != nil {
+		return
+	}
+	for _, path := range []string{dbPath, dbPath + walSuffix, dbPath + shmSuffix} {
+		if e := os.Remove(path); e != nil && !errors.Is(e, fs.ErrNotExist) {
+			err = perrors.AppendError(err, perrors.ErrorfPF("os.Remove: %w", e))
+		}
+	}
+
+	return
+}
+
+// partition returns partition name for year
+func (m *PartitionManager) partition(year int) (partition parl.DBPartition) {
+	return parl.DBPartition(strconv.Itoa(year))
+}
+
+// path returns the database file path for year: “…/appName-2022.db”
+func (m *PartitionManager) path(year int) (path string) {
+	return filepath.Join(m.dir, m.appName+hyphen+strconv.Itoa(year)+extension)
+}
+
+// gzipFile compresses src into dst
+//   - dst is written to a temporary file that is renamed on success,
+//     so that a partial archive never exists
+func gzipFile(src, dst string) (err error) {
+	var in *os.File
+	if in, err = os.Open(src); perrors.IsPF(&err, "os.Open: %w", err) {
+		return
+	}
+	defer parl.Close(in, &err)
+
+	var tmp = dst + ".tmp"
+	var out *os.File
+	if out, err = os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, urw); perrors.IsPF(&err, "os.OpenFile: %w", err) {
+		return
+	}
+	var isOk bool
+	defer func() {
+		if !isOk {
+			os.Remove(tmp)
+		}
+	}()
+
+	var zw = gzip.NewWriter(out)
+	if _, err = io.Copy(zw, in); perrors.IsPF(&err, "io.Copy: %w", err) {
+		out.Close()
+		return
+	} else if err = zw.Close(); perrors.IsPF(&err, "gzip.Close: %w", err) {
+		out.Close()
+		return
+	} else if err = out.Close(); perrors.IsPF(&err, "os.File.Close: %w", err) {
+		return
+	} else if err = os.Rename(tmp, dst); perrors.IsPF(&err, "os.Rename: %w", err) {
+		return
+	}
+	isOk = true
+
+	return
+}
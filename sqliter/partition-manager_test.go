@@ -0,0 +1,90 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package sqliter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+func TestPartitionManager(t *testing.T) {
+	//t.Error("Logging on")
+	var appName = "app"
+	var now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var ctx = context.Background()
+	var dir = t.TempDir()
+	var expActive = []parl.DBPartition{"2023", "2024", "2025"}
+
+	var err error
+
+	var db = &partitionTestDB{dir: dir, appName: appName}
+	var manager = newPartitionManager(dir, appName, db, PartitionPolicy{
+		Ahead:     1,
+		Retention: 2,
+		Archive:   true,
+		DropAfter: 4,
+	})
+	for _, name := range []string{"app-2019.db.gz", "app-2021.db", "app-2022.db", "app-2023.db", "other.db"} {
+		if err = os.WriteFile(filepath.Join(dir, name), []byte(name), urw); err != nil {
+			t.Fatalf("os.WriteFile err: %s", err)
+		}
+	}
+
+	if err = manager.Maintain(ctx, now); err != nil {
+		t.Fatalf("Maintain err: %s", perrors.Short(err))
+	}
+
+	// create ahead
+	if !slices.Equal(db.opened, []parl.DBPartition{"2024", "2025"}) {
+		t.Errorf("opened %v", db.opened)
+	}
+	// partitions beyond retention are closed
+	slices.Sort(db.closed)
+	if !slices.Equal(db.closed, []parl.DBPartition{"2019", "2021", "2022"}) {
+		t.Errorf("closed %v", db.closed)
+	}
+	if active := manager.Active(); !slices.Equal(active, expActive) {
+		t.Errorf("Active %v exp %v", active, expActive)
+	}
+	if !manager.IsActive("2024") || manager.IsActive("2022") {
+		t.Error("IsActive bad")
+	}
+
+	// files: 2019 dropped, 2021 2022 archived
+	var entries, _ = os.ReadDir(dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	var expNames = []string{"app-2021.db.gz", "app-2022.db.gz", "app-2023.db", "app-2024.db", "app-2025.db", "other.db"}
+	if !slices.Equal(names, expNames) {
+		t.Errorf("files %v exp %v", names, expNames)
+	}
+}
+
+// partitionTestDB is a [PartitionDB] creating empty partition files
+type partitionTestDB struct {
+	dir, appName   string
+	opened, closed []parl.DBPartition
+}
+
+func (d *partitionTestDB) OpenPartition(partition parl.DBPartition, ctx context.Context) (err error) {
+	d.opened = append(d.opened, partition)
+	var path = filepath.Join(d.dir, d.appName+hyphen+string(partition)+extension)
+	return os.WriteFile(path, nil, urw)
+}
+
+func (d *partitionTestDB) ClosePartition(partition parl.DBPartition) (err error) {
+	d.closed = append(d.closed, partition)
+	return
+}
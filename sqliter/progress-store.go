@@ -0,0 +1,313 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package sqliter
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// progressTable is the table holding checkpointed task progress
+	progressTable = "progress"
+	// default interval between checkpoints of [ProgressStore.Run]
+	defaultProgressInterval = 10 * time.Second
+)
+
+const (
+	// progressSchema creates the progress table
+	//	- isComplete is 0 or 1
+	//	- updated is [TimeToDB] text
+	progressSchema = "CREATE TABLE IF NOT EXISTS " + progressTable + " (" +
+		"task TEXT PRIMARY KEY, " +
+		"done INTEGER NOT NULL, " +
+		"total INTEGER NOT NULL, " +
+		"isComplete INTEGER NOT NULL, " +
+		"updated TEXT NOT NULL)"
+	// progressUpsert writes the state of a task
+	progressUpsert = "INSERT INTO " + progressTable + " (task, done, total, isComplete, updated) " +
+		"VALUES (?, ?, ?, ?, ?) ON CONFLICT(task) DO UPDATE SET " +
+		"done = excluded.done, total = excluded.total, " +
+		"isComplete = excluded.isComplete, updated = excluded.updated"
+	// progressSelect reads all tasks
+	progressSelect = "SELECT task, done, total, isComplete, updated FROM " + progressTable
+	// progressDelete removes all tasks
+	progressDelete = "DELETE FROM " + progressTable
+)
+
+// ProgressState is the progress of a task
+type ProgressState struct {
+	// Task identifies the unit of work: a filename, a batch key
+	Task string
+	// Done is the amount of work completed
+	Done int64
+	// Total is the amount of work in the task, 0 if unknown
+	Total int64
+	// IsComplete is true when the task completed
+	IsComplete bool
+	// Updated is when the state last changed
+	Updated time.Time
+}
+
+// ProgressStore checkpoints per-task progress of a long-running batch job
+// to a small SQLite3 database so that progress survives restarts
+//   - [ProgressStore.Load] on startup restores the state of the previous run.
+//     The caller may consult [ProgressStore.IsComplete] to skip completed work
+//     and [ProgressStore.State] to resume reporting
+//   - [ProgressStore.Update] and [ProgressStore.Complete] record progress in memory
+//   - [ProgressStore.Checkpoint] writes changed tasks, [ProgressStore.Run]
+//     checkpoints periodically
+//   - thread-safe
+//
+// Usage:
+//
+//	var dbMap = psql.NewDBMap(dsnr, nil)
+//	var store, err = sqliter.NewProgressStore(dbMap, parl.NoPartition, ctx)
+//	if _, err = store.Load(ctx); err != nil {
+//	…
+//	go store.Run(ctx, 0, errorSink)
+//	for _, file := range files {
+//	  if store.IsComplete(file) {
+//	    continue
+//	  }
+//	  …
+//	  store.Update(file, n, size)
+//	  …
+//	  store.Complete(file)
+//	}
+type ProgressStore struct {
+	db        parl.DB
+	partition parl.DBPartition
+
+	// checkpointLock serializes Checkpoint
+	checkpointLock sync.Mutex
+	// lock makes fields below thread-safe
+	lock sync.Mutex
+	// tasks is the current state by task
+	tasks map[string]ProgressState
+	// dirty is tasks changed since the last checkpoint
+	dirty map[string]struct{}
+}
+
+// NewProgressStore returns a progress store in partition of db
+//   - db: typically [github.com/haraldrudell/parl/psql.DBMap] for
+//     a data source namer from [DSNrFactory]
+//   - partition: typically [parl.NoPartition]
+//   - the progress table is created if it does not exist
+func NewProgressStore(db parl.DB, partition parl.DBPartition, ctx context.Context) (store *ProgressStore, err error) {
+	if db == nil {
+		err = perrors.NewPF("db cannot be nil")
+		return
+	}
+	if _, err = db.Exec(partition, progressSchema, ctx); err != nil {
+		return
+	}
+	store = &ProgressStore{
+		db:        db,
+		partition: partition,
+		tasks:     make(map[string]ProgressState),
+		dirty:     make(map[string]struct{}),
+	}
+
+	return
+}
+
+// Load reads checkpointed progress replacing any in-memory state
+//   - invoked on startup prior to processing
+//   - states: the restored tasks
+func (s *ProgressStore) Load(ctx context.Context) (states map[string]ProgressState, err error) {
+	var sqlRows *sql.Rows
+	if sqlRows, err = s.db.Query(s.partition, progressSelect, ctx); err != nil {
+		return
+	}
+	defer parl.Close(sqlRows, &err)
+
+	var tasks = make(map[string]ProgressState)
+	for sqlRows.Next() {
+		var state ProgressState
+		var isComplete int
+		var updated string
+		if err = sqlRows.Scan(&state.Task, &state.Done, &state.Total, &isComplete, &updated); perrors.IsPF(&err, "Scan %w", err) {
+			return
+		}
+		state.IsComplete = isComplete != 0
+		if state.Updated, err = ToTime(updated); err != nil {
+			return
+		}
+		tasks[state.Task] = state
+	}
+	if err = sqlRows.Err(); perrors.IsPF(&err, "sql.Rows %w", err) {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.tasks = tasks
+	clear(s.dirty)
+	states = maps.Clone(tasks)
+
+	return
+}
+
+// Update records progress of task
+//   - total: 0 if unknown
+//   - the state is persisted by the next checkpoint
+func (s *ProgressStore) Update(task string, done, total int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var state = s.tasks[task]
+	state.Task = task
+	state.Done = done
+	state.Total = total
+	state.Updated = time.Now()
+	s.tasks[task] = state
+	s.dirty[task] = struct{}{}
+}
+
+// Complete records that task completed
+//   - Done is set to Total if Total is known
+func (s *ProgressStore) Complete(task string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var state = s.tasks[task]
+	state.Task = task
+	state.IsComplete = true
+	if state.Total > 0 {
+		state.Done = state.Total
+	}
+	state.Updated = time.Now()
+	s.tasks[task] = state
+	s.dirty[task] = struct{}{}
+}
+
+// State returns the state of task
+//   - hasState false: task has no recorded progress
+func (s *ProgressStore) State(task string) (state ProgressState, hasState bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	state, hasState = s.tasks[task]
+	return
+}
+
+// IsComplete returns true if task completed in this or a previous run
+func (s *ProgressStore) IsComplete(task string) (isComplete bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.tasks[task].IsComplete
+}
+
+// States returns the state of all tasks ordered by task
+func (s *ProgressStore) States() (states []ProgressState) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	states = make([]ProgressState, 0, len(s.tasks))
+	for _, state := range s.tasks {
+		states = append(states, state)
+	}
+	slices.SortFunc(states, func(a, b ProgressState) (result int) { return strings.Compare(a.Task, b.Task) })
+
+	return
+}
+
+// Checkpoint writes tasks changed since the last checkpoint
+//   - tasks failing to be written remain to be checkpointed
+func (s *ProgressStore) Checkpoint(ctx context.Context) (err error) {
+	s.checkpointLock.Lock()
+	defer s.checkpointLock.Unlock()
+
+	// collect changed tasks
+	s.lock.Lock()
+	var states = make([]ProgressState, 0, len(s.dirty))
+	for task := range s.dirty {
+		states = append(states, s.tasks[task])
+	}
+	clear(s.dirty)
+	s.lock.Unlock()
+
+	for i, state := range states {
+		var isComplete int
+		if state.IsComplete {
+			isComplete = 1
+		}
+		if _, err = s.db.Exec(s.partition, progressUpsert, ctx,
+			state.Task, state.Done, state.Total, isComplete, TimeToDB(state.Updated),
+		); err != nil {
+			s.redirty(states[i:])
+			return
+		}
+	}
+
+	return
+}
+
+// Run invokes Checkpoint every interval until ctx is canceled
+//   - interval 0: 10 s
+//   - a final checkpoint is made on cancel using a context without cancel
+//   - errors are submitted to errorSink
+//   - Run blocks and is typically invoked in its own thread
+func (s *ProgressStore) Run(ctx context.Context, interval time.Duration, errorSink parl.ErrorSink1) {
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Checkpoint(context.WithoutCancel(ctx)); err != nil {
+				errorSink.AddError(err)
+			}
+			return // cancel return
+		case <-ticker.C:
+		}
+		if err := s.Checkpoint(ctx); err != nil {
+			errorSink.AddError(err)
+		}
+	}
+}
+
+// Reset removes all progress, in memory and in the database
+//   - used once a batch job has completed in full
+func (s *ProgressStore) Reset(ctx context.Context) (err error) {
+	s.checkpointLock.Lock()
+	defer s.checkpointLock.Unlock()
+
+	if _, err = s.db.Exec(s.partition, progressDelete, ctx); err != nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	clear(s.tasks)
+	clear(s.dirty)
+
+	return
+}
+
+// redirty marks states not written as changed
+func (s *ProgressStore) redirty(states []ProgressState) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, state := range states {
+		s.dirty[state.Task] = struct{}{}
+	}
+}
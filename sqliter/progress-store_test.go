@@ -0,0 +1,81 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package sqliter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+	"github.com/haraldrudell/parl/psql"
+)
+
+func TestProgressStore(t *testing.T) {
+	//t.Error("Logging on")
+	var (
+		// empty schema function
+		schema             = func(dataSource parl.DataSource, ctx context.Context) (err error) { return }
+		taskA, taskB       = "a.txt", "b.txt"
+		done, total  int64 = 5, 10
+	)
+
+	var (
+		ctx    = context.Background()
+		dbMap  = psql.DBFactory.NewDB(newMemDataSourceNamer(), schema)
+		store  *ProgressStore
+		states map[string]ProgressState
+		state  ProgressState
+		ok     bool
+		err    error
+	)
+	defer dbMap.Close()
+
+	// first run: record and checkpoint progress
+	if store, err = NewProgressStore(dbMap, parl.NoPartition, ctx); err != nil {
+		t.Fatalf("NewProgressStore err %s", perrors.Short(err))
+	}
+	store.Update(taskA, 1, total)
+	store.Complete(taskA)
+	store.Update(taskB, done, total)
+	if err = store.Checkpoint(ctx); err != nil {
+		t.Fatalf("Checkpoint err %s", perrors.Short(err))
+	}
+
+	// restart: Load should restore progress
+	if store, err = NewProgressStore(dbMap, parl.NoPartition, ctx); err != nil {
+		t.Fatalf("NewProgressStore err %s", perrors.Short(err))
+	}
+	if states, err = store.Load(ctx); err != nil {
+		t.Fatalf("Load err %s", perrors.Short(err))
+	}
+	if len(states) != 2 {
+		t.Errorf("Load states %d exp 2", len(states))
+	}
+	if !store.IsComplete(taskA) || store.IsComplete(taskB) {
+		t.Error("IsComplete bad")
+	}
+	if state, ok = store.State(taskB); !ok || state.Done != done || state.Total != total || state.Updated.IsZero() {
+		t.Errorf("State %t %+v", ok, state)
+	}
+	if state, _ = store.State(taskA); state.Done != total {
+		t.Errorf("Complete Done %d exp %d", state.Done, total)
+	}
+	if s := store.States(); len(s) != 2 || s[0].Task != taskA {
+		t.Errorf("States %+v", s)
+	}
+
+	// Reset should remove all progress
+	if err = store.Reset(ctx); err != nil {
+		t.Fatalf("Reset err %s", perrors.Short(err))
+	}
+	if states, err = store.Load(ctx); err != nil {
+		t.Fatalf("Load err %s", perrors.Short(err))
+	}
+	if len(states) != 0 {
+		t.Errorf("Reset states %d", len(states))
+	}
+}
@@ -29,6 +29,7 @@ ISC License
 //   - data-source that do not create database-files [OpenDataSourceNamerRO] for querying existing
 //     databases
 //   - retrieval of actionable SQLite3 error codes [Code]
+//   - per-task progress of batch jobs checkpointed across restarts [ProgressStore]
 package sqliter
 
 import (
@@ -0,0 +1,239 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// default number of events kept by [StateMachine.History]
+	defaultStateHistory = 16
+)
+
+// ErrIllegalTransition is returned by [StateMachine.Transition]
+// for a transition that was not declared
+//   - errors.Is(err, parl.ErrIllegalTransition)
+var ErrIllegalTransition = errors.New("illegal state transition")
+
+// TransitionHook is invoked by [StateMachine] for each transition
+//   - from to: the previous and the new state
+//   - err: returned by [StateMachine.Transition] and recorded in history.
+//     The transition is not undone
+//   - a panic is recovered into err
+//   - invoked behind the transition lock: must not invoke Transition
+type TransitionHook[S comparable] func(from, to S) (err error)
+
+// StateEvent is a transition recorded by [StateMachine]
+type StateEvent[S comparable] struct {
+	// Seq is the transition number, the first transition is 1
+	Seq       uint64
+	From, To  S
+	Timestamp time.Time
+	// Err is errors and panics of transition hooks
+	Err error
+}
+
+// StateMachine is a state with declared transitions
+//   - [StateMachine.State] is an atomic read of the current state
+//   - [StateMachine.Transition] changes state if the transition was declared,
+//     invoking hooks registered by [StateMachine.OnTransition]
+//   - [StateMachine.WaitForState] awaits a state with context cancel,
+//     [StateMachine.StateCh] is a channel closing when a state is entered
+//   - [StateMachine.History] returns the most recent transitions
+//   - for connection lifecycles, job states and GoGroup-managed service states
+//   - thread-safe
+//
+// Usage:
+//
+//	type ConnState uint8
+//	const ( Disconnected ConnState = iota; Connecting; Connected )
+//	var s = parl.NewStateMachine(Disconnected, map[ConnState][]ConnState{
+//	  Disconnected: {Connecting},
+//	  Connecting:   {Connected, Disconnected},
+//	  Connected:    {Disconnected},
+//	})
+//	…
+//	err = s.WaitForState(Connected, ctx)
+type StateMachine[S comparable] struct {
+	// transitions are allowed states by state, nil: any transition
+	transitions map[S]map[S]struct{}
+	// state is the current state, written behind lock
+	state atomic.Pointer[S]
+	// lock makes transitions serial and fields below thread-safe
+	lock sync.Mutex
+	// hooks are invoked for each transition, behind lock
+	hooks []TransitionHook[S]
+	// waits are awaitables closing on entering a state, behind lock
+	waits map[S]*Awaitable
+	// seq is the number of transitions, behind lock
+	seq uint64
+	// history is a ring of the most recent events, behind lock
+	history []StateEvent[S]
+	// historyNext is index in history of the next event, behind lock
+	historyNext int
+}
+
+// NewStateMachine returns a state machine in state initial
+//   - transitions: allowed next states by state.
+//     nil: any transition is allowed
+//   - historyCap: number of events kept by History, default 16
+func NewStateMachine[S comparable](initial S, transitions map[S][]S, historyCap ...int) (stateMachine *StateMachine[S]) {
+	var capacity = defaultStateHistory
+	if len(historyCap) > 0 && historyCap[0] > 0 {
+		capacity = historyCap[0]
+	}
+	var s = StateMachine[S]{
+		waits:   make(map[S]*Awaitable),
+		history: make([]StateEvent[S], 0, capacity),
+	}
+	if transitions != nil {
+		s.transitions = make(map[S]map[S]struct{}, len(transitions))
+		for from, tos := range transitions {
+			var m = make(map[S]struct{}, len(tos))
+			for _, to := range tos {
+				m[to] = struct{}{}
+			}
+			s.transitions[from] = m
+		}
+	}
+	s.state.Store(&initial)
+	return &s
+}
+
+// State returns the current state
+//   - thread-safe, atomic
+func (s *StateMachine[S]) State() (state S) { return *s.state.Load() }
+
+// CanTransition returns true if transition from the current state to to
+// is declared
+func (s *StateMachine[S]) CanTransition(to S) (canTransition bool) {
+	return s.isAllowed(s.State(), to)
+}
+
+// Transition changes the state to to
+//   - err: [ErrIllegalTransition] if the transition was not declared,
+//     the state is then unchanged
+//   - err: errors and panics of transition hooks.
+//     The state was changed
+//   - transition to the current state is a transition that must be declared
+func (s *StateMachine[S]) Transition(to S) (err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var from = s.State()
+	if !s.isAllowed(from, to) {
+		err = perrors.ErrorfPF("%w: %v to %v", ErrIllegalTransition, from, to)
+		return // illegal transition return
+	}
+	s.state.Store(&to)
+	for _, hook := range s.hooks {
+		if e := s.invokeHook(hook, from, to); e != nil {
+			err = perrors.AppendError(err, e)
+		}
+	}
+	s.seq++
+	s.record(StateEvent[S]{Seq: s.seq, From: from, To: to, Timestamp: time.Now(), Err: err})
+	if a := s.waits[to]; a != nil {
+		delete(s.waits, to)
+		a.Close()
+	}
+
+	return
+}
+
+// OnTransition adds a hook invoked for every subsequent transition
+//   - hooks are invoked in order of addition
+func (s *StateMachine[S]) OnTransition(hook TransitionHook[S]) {
+	if hook == nil {
+		panic(NilError("hook"))
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.hooks = append(s.hooks, hook)
+}
+
+// StateCh returns a channel that closes when state is entered
+//   - if the current state is state, the channel is closed
+//   - once closed, the state may have changed again
+func (s *StateMachine[S]) StateCh(state S) (ch AwaitableCh) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.State() == state {
+		var a Awaitable
+		a.Close()
+		return a.Ch() // in state return
+	}
+	var a = s.waits[state]
+	if a == nil {
+		a = &Awaitable{}
+		s.waits[state] = a
+	}
+	return a.Ch()
+}
+
+// WaitForState blocks until state is entered or ctx is canceled
+//   - err: nil if the current state is state or
+//     state was entered while waiting
+//   - err: on ctx cancel, [context.Cause]
+func (s *StateMachine[S]) WaitForState(state S, ctx context.Context) (err error) {
+	if s.State() == state {
+		return // in state return
+	}
+	select {
+	case <-s.StateCh(state):
+	case <-ctx.Done():
+		err = perrors.ErrorfPF("WaitForState: %w", context.Cause(ctx))
+	}
+	return
+}
+
+// History returns the most recent transitions, oldest first
+func (s *StateMachine[S]) History() (events []StateEvent[S]) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	events = make([]StateEvent[S], 0, len(s.history))
+	events = append(events, s.history[s.historyNext:]...)
+	events = append(events, s.history[:s.historyNext]...)
+	return
+}
+
+// isAllowed returns true if from to is a declared transition
+func (s *StateMachine[S]) isAllowed(from, to S) (isAllowed bool) {
+	if s.transitions == nil {
+		return true
+	}
+	_, isAllowed = s.transitions[from][to]
+	return
+}
+
+// invokeHook invokes hook recovering any panic
+func (s *StateMachine[S]) invokeHook(hook TransitionHook[S], from, to S) (err error) {
+	defer RecoverErr(func() DA { return A() }, &err)
+
+	return hook(from, to)
+}
+
+// record appends event to history, behind lock
+func (s *StateMachine[S]) record(event StateEvent[S]) {
+	if len(s.history) < cap(s.history) {
+		s.history = append(s.history, event)
+		return
+	}
+	s.history[s.historyNext] = event
+	if s.historyNext++; s.historyNext == len(s.history) {
+		s.historyNext = 0
+	}
+}
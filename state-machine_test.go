@@ -0,0 +1,100 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStateMachine(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		disconnected = "disconnected"
+		connecting   = "connecting"
+		connected    = "connected"
+	)
+	var transitions = map[string][]string{
+		disconnected: {connecting},
+		connecting:   {connected, disconnected},
+		connected:    {disconnected},
+	}
+	var errHook = errors.New("hook")
+
+	var err error
+
+	var s = NewStateMachine(disconnected, transitions, 2)
+	if state := s.State(); state != disconnected {
+		t.Errorf("State %q exp %q", state, disconnected)
+	}
+
+	// undeclared transition should fail
+	if err = s.Transition(connected); !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("illegal Transition err %v", err)
+	} else if s.State() != disconnected || s.CanTransition(connected) {
+		t.Error("illegal Transition changed state")
+	}
+
+	// WaitForState should return on transition
+	var waitErr = make(chan error, 1)
+	go func() { waitErr <- s.WaitForState(connected, context.Background()) }()
+	var hookCount int
+	s.OnTransition(func(from, to string) (err error) {
+		hookCount++
+		return
+	})
+	if err = s.Transition(connecting); err != nil {
+		t.Fatalf("Transition err %s", err)
+	}
+	select {
+	case <-s.StateCh(connecting):
+	default:
+		t.Error("StateCh of current state not closed")
+	}
+	if err = s.Transition(connected); err != nil {
+		t.Fatalf("Transition err %s", err)
+	}
+	select {
+	case err = <-waitErr:
+		if err != nil {
+			t.Errorf("WaitForState err %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForState did not return")
+	}
+	if hookCount != 2 {
+		t.Errorf("hookCount %d exp 2", hookCount)
+	}
+
+	// hook panic should be error with state changed
+	s.OnTransition(func(from, to string) (err error) { panic(errHook) })
+	if err = s.Transition(disconnected); !errors.Is(err, errHook) {
+		t.Errorf("panic hook err %v", err)
+	} else if s.State() != disconnected {
+		t.Errorf("State %q exp %q", s.State(), disconnected)
+	}
+
+	// history should be the two most recent events
+	var history = s.History()
+	if len(history) != 2 {
+		t.Fatalf("history length %d exp 2", len(history))
+	}
+	if e := history[0]; e.Seq != 2 || e.From != connecting || e.To != connected || e.Err != nil {
+		t.Errorf("history[0] %+v", e)
+	}
+	if e := history[1]; e.Seq != 3 || e.To != disconnected || !errors.Is(e.Err, errHook) {
+		t.Errorf("history[1] %+v", e)
+	}
+
+	// WaitForState should return on context cancel
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err = s.WaitForState(connected, ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled WaitForState err %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"errors"
+	"path"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/haraldrudell/parl/perrors"
+)
+
+// ErrTopicClosed is returned by [Topic.Send] on a closed topic
+var ErrTopicClosed = errors.New("topic closed")
+
+// TopicMessage is a value received by a pattern subscription
+// of [Topics.SubscribePattern]
+type TopicMessage[T any] struct {
+	// Topic is the name of the topic the value was sent to
+	Topic string
+	Value T
+}
+
+// TopicMetrics is a snapshot of a topic’s activity
+type TopicMetrics struct {
+	// Sent is number of values sent to the topic
+	Sent uint64
+	// Subscribers is number of subscriptions to the topic
+	Subscribers int
+	// Patterns is number of pattern subscriptions matching the topic
+	Patterns int
+}
+
+// Topics is an in-process publish-subscribe registry of named topics
+//   - [Topics.Topic] returns the topic for a name, creating it if needed
+//   - publishers use [Topic.Send], subscribers [Topic.Subscribe].
+//     Each subscriber has its own [Broadcaster] queue: slow subscribers
+//     do not block publishers
+//   - [Topics.SubscribePattern] receives values of all topics matching a
+//     [path.Match] pattern like “orders/*”, including topics created later
+//   - a closed topic is removed from the registry:
+//     subsequent Send on it fails with [ErrTopicClosed] and
+//     a later Topics.Topic for the same name creates a new topic
+//   - [Topics.Close] closes all topics and pattern subscriptions
+//   - initialization-free, thread-safe
+//
+// Usage:
+//
+//	var bus parl.Topics[Event]
+//	var orders = bus.Topic("orders/created")
+//	var subscription, err = bus.SubscribePattern("orders/*")
+//	if err != nil {
+//	  return
+//	}
+//	defer subscription.Close()
+//	…
+//	if err = orders.Send(event); err != nil {
+//	…
+//	for message := subscription.Init(); subscription.Condition(&message); {
+//	  parl.Log("%s: %v", message.Topic, message.Value)
+type Topics[T any] struct {
+	// lock makes fields thread-safe
+	lock sync.Mutex
+	// topics are open topics by name, behind lock
+	topics map[string]*Topic[T]
+	// patterns are pattern subscriptions, behind lock
+	patterns []*topicPattern[T]
+	// isClosed is true after Close, behind lock
+	isClosed bool
+}
+
+// Topic is a named publish-subscribe topic of [Topics]
+//   - thread-safe
+type Topic[T any] struct {
+	name   string
+	topics *Topics[T]
+	// broadcaster delivers to topic subscribers
+	broadcaster Broadcaster[T]
+	// patterns are matching pattern subscriptions
+	//	- read atomically, written behind topics.lock
+	patterns atomic.Pointer[[]*topicPattern[T]]
+	// sent is number of values sent
+	sent atomic.Uint64
+	// isClosed is true after Close
+	isClosed atomic.Bool
+}
+
+// topicPattern is a pattern subscription
+type topicPattern[T any] struct {
+	pattern     string
+	broadcaster Broadcaster[TopicMessage[T]]
+}
+
+// Topic returns the open topic name, creating it if it does not exist
+//   - on closed Topics, the returned topic is closed
+func (t *Topics[T]) Topic(name string) (topic *Topic[T]) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if topic = t.topics[name]; topic != nil {
+		return // existing topic return
+	}
+	topic = &Topic[T]{name: name, topics: t}
+	if t.isClosed {
+		topic.isClosed.Store(true)
+		topic.broadcaster.Close()
+		return // closed registry return
+	}
+	t.prunePatterns()
+	var patterns []*topicPattern[T]
+	for _, p := range t.patterns {
+		if isMatch, _ := path.Match(p.pattern, name); isMatch {
+			patterns = append(patterns, p)
+		}
+	}
+	topic.patterns.Store(&patterns)
+	if t.topics == nil {
+		t.topics = make(map[string]*Topic[T])
+	}
+	t.topics[name] = topic
+
+	return
+}
+
+// SubscribePattern returns a subscription receiving values sent to
+// any topic whose name matches pattern
+//   - pattern: [path.Match] syntax: “*” matches any sequence of
+//     non-slash characters
+//   - config: optional queue limit and overflow policy
+//   - err: malformed pattern
+//   - on closed Topics, the returned subscription is closed
+func (t *Topics[T]) SubscribePattern(pattern string, config ...SubscribeConfig) (subscription *Subscription[TopicMessage[T]], err error) {
+	if _, err = path.Match(pattern, ""); perrors.IsPF(&err, "pattern %q: %w", pattern, err) {
+		return
+	}
+	var p = &topicPattern[T]{pattern: pattern}
+	subscription = p.broadcaster.Subscribe(config...)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.isClosed {
+		p.broadcaster.Close()
+		return // closed registry return
+	}
+	t.prunePatterns()
+	t.patterns = append(t.patterns, p)
+	for name, topic := range t.topics {
+		if isMatch, _ := path.Match(pattern, name); isMatch {
+			var patterns = append(slices.Clone(*topic.patterns.Load()), p)
+			topic.patterns.Store(&patterns)
+		}
+	}
+
+	return
+}
+
+// Names returns the names of open topics
+func (t *Topics[T]) Names() (names []string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	names = make([]string, 0, len(t.topics))
+	for name := range t.topics {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return
+}
+
+// Metrics returns metrics of open topics by name
+func (t *Topics[T]) Metrics() (metrics map[string]TopicMetrics) {
+	t.lock.Lock()
+	var topics = make([]*Topic[T], 0, len(t.topics))
+	for _, topic := range t.topics {
+		topics = append(topics, topic)
+	}
+	t.lock.Unlock()
+
+	metrics = make(map[string]TopicMetrics, len(topics))
+	for _, topic := range topics {
+		metrics[topic.name] = topic.Metrics()
+	}
+
+	return
+}
+
+// Close closes all topics and pattern subscriptions
+//   - subscribers receive queued values prior to their subscription closing
+//   - idempotent
+func (t *Topics[T]) Close() {
+	t.lock.Lock()
+	t.isClosed = true
+	var topics = t.topics
+	var patterns = t.patterns
+	t.topics = nil
+	t.patterns = nil
+	t.lock.Unlock()
+
+	for _, topic := range topics {
+		topic.close()
+	}
+	for _, p := range patterns {
+		p.broadcaster.Close()
+	}
+}
+
+// prunePatterns removes pattern subscriptions closed by their subscriber
+//   - behind lock
+func (t *Topics[T]) prunePatterns() {
+	t.patterns = slices.DeleteFunc(t.patterns, func(p *topicPattern[T]) (isClosed bool) {
+		return p.broadcaster.Subscribers() == 0
+	})
+}
+
+// Name returns the topic’s name
+func (t *Topic[T]) Name() (name string) { return t.name }
+
+// Send publishes value to the topic’s subscribers and
+// matching pattern subscriptions
+//   - non-blocking
+//   - err: [ErrTopicClosed]
+func (t *Topic[T]) Send(value T) (err error) {
+	if t.isClosed.Load() {
+		err = perrors.ErrorfPF("%q: %w", t.name, ErrTopicClosed)
+		return
+	}
+	t.sent.Add(1)
+	t.broadcaster.Send(value)
+	if pp := t.patterns.Load(); pp != nil {
+		for _, p := range *pp {
+			p.broadcaster.Send(TopicMessage[T]{Topic: t.name, Value: value})
+		}
+	}
+
+	return
+}
+
+// Subscribe attaches a subscriber receiving values sent after Subscribe
+//   - config: optional queue limit and overflow policy
+//   - on closed topic, the returned subscription is closed
+func (t *Topic[T]) Subscribe(config ...SubscribeConfig) (subscription *Subscription[T]) {
+	return t.broadcaster.Subscribe(config...)
+}
+
+// Metrics returns a snapshot of the topic’s activity
+func (t *Topic[T]) Metrics() (metrics TopicMetrics) {
+	metrics.Sent = t.sent.Load()
+	metrics.Subscribers = t.broadcaster.Subscribers()
+	if pp := t.patterns.Load(); pp != nil {
+		metrics.Patterns = len(*pp)
+	}
+	return
+}
+
+// IsClosed returns true if the topic is closed
+func (t *Topic[T]) IsClosed() (isClosed bool) { return t.isClosed.Load() }
+
+// Close closes the topic’s subscriptions and removes it from the registry
+//   - pattern subscriptions remain open
+//   - idempotent
+func (t *Topic[T]) Close() {
+	var topics = t.topics
+	topics.lock.Lock()
+	if topics.topics[t.name] == t {
+		delete(topics.topics, t.name)
+	}
+	topics.lock.Unlock()
+
+	t.close()
+}
+
+// close closes the topic’s subscriptions
+func (t *Topic[T]) close() {
+	t.isClosed.Store(true)
+	t.broadcaster.Close()
+}
+
+// “sent 3 subscribers 2 patterns 1”
+func (m TopicMetrics) String() (s string) {
+	return Sprintf("sent %d subscribers %d patterns %d", m.Sent, m.Subscribers, m.Patterns)
+}
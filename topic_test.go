@@ -0,0 +1,69 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestTopics(t *testing.T) {
+	//t.Error("Logging on")
+	var expNames = []string{"orders/created", "orders/shipped"}
+
+	var bus Topics[int]
+	var created = bus.Topic("orders/created")
+	if bus.Topic("orders/created") != created {
+		t.Error("Topic not reused")
+	}
+	var subscription = created.Subscribe()
+	var patternSubscription, err = bus.SubscribePattern("orders/*")
+	if err != nil {
+		t.Fatalf("SubscribePattern err %s", err)
+	}
+	if _, err = bus.SubscribePattern("["); err == nil {
+		t.Error("bad pattern no error")
+	}
+	// topic created after pattern subscription
+	var shipped = bus.Topic("orders/shipped")
+	bus.Topic("users/created").Send(9)
+
+	created.Send(1)
+	shipped.Send(2)
+	if values := subscription.GetAll(); !slices.Equal(values, []int{1}) {
+		t.Errorf("subscription %v exp [1]", values)
+	}
+	var messages = patternSubscription.GetAll()
+	if len(messages) != 2 || messages[0] != (TopicMessage[int]{Topic: "orders/created", Value: 1}) ||
+		messages[1] != (TopicMessage[int]{Topic: "orders/shipped", Value: 2}) {
+		t.Errorf("pattern messages %v", messages)
+	}
+	if metrics := created.Metrics(); metrics.Sent != 1 || metrics.Subscribers != 1 || metrics.Patterns != 1 {
+		t.Errorf("metrics %s", metrics)
+	}
+
+	// closed topic
+	shipped.Close()
+	if err = shipped.Send(3); !errors.Is(err, ErrTopicClosed) {
+		t.Errorf("Send closed err %v", err)
+	}
+	if bus.Topic("orders/shipped") == shipped {
+		t.Error("closed topic reused")
+	}
+	if names := bus.Names(); !slices.Equal(names, append(expNames, "users/created")) {
+		t.Errorf("Names %v", names)
+	}
+
+	// Close closes subscriptions
+	bus.Close()
+	if !subscription.IsClosed() || !patternSubscription.IsClosed() {
+		t.Error("subscriptions not closed")
+	}
+	if err = bus.Topic("x").Send(1); !errors.Is(err, ErrTopicClosed) {
+		t.Errorf("Send after Close err %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+// Package tracer records and exports task event lists of [parl.Tracer].
+//   - [NewTracer] is an in-memory [parl.Tracer]
+//   - [ExportSpans] converts tasks to spans of an OpenTelemetry-like
+//     [SpanStarter] without depending on OpenTelemetry packages
+//   - [OTLPJSON] renders tasks as an OTLP/JSON trace export request
+//     that can be posted to a collector’s /v1/traces endpoint
+//     to view task timelines in Jaeger or Tempo
+package tracer
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/haraldrudell/parl"
+	"github.com/haraldrudell/parl/perrors"
+)
+
+const (
+	// TaskIDAttribute is span attribute key for task ID
+	TaskIDAttribute = "parl.task.id"
+	// EventCountAttribute is span attribute key for number of events
+	EventCountAttribute = "parl.task.events"
+	// scopeName is OTLP instrumentation scope
+	scopeName = "github.com/haraldrudell/parl/tracer"
+	// spanKindInternal is OTLP SPAN_KIND_INTERNAL
+	spanKindInternal = 1
+)
+
+// SpanStarter creates spans, typically an adapter to an OpenTelemetry tracer
+//
+// Usage:
+//
+//	type otelStarter struct{ trace.Tracer }
+//	func (s otelStarter) StartSpan(name string, start time.Time, attributes map[string]string) (span tracer.Span) {
+//	  var _, otelSpan = s.Start(ctx, name, trace.WithTimestamp(start), …attributes)
+//	  return otelSpan adapted to tracer.Span
+//	}
+type SpanStarter interface {
+	// StartSpan creates a span starting at start
+	StartSpan(name string, start time.Time, attributes map[string]string) (span Span)
+}
+
+// Span is a span created by [SpanStarter]
+type Span interface {
+	// AddEvent adds a timestamped event
+	AddEvent(name string, at time.Time)
+	// End ends the span at end
+	End(end time.Time)
+}
+
+// TaskSpan is a task converted to span form
+type TaskSpan struct {
+	// TaskID is the task
+	TaskID parl.TracerTaskID
+	// Start is time of first event
+	Start time.Time
+	// End is time of last event
+	End time.Time
+	// Events are the task’s events in order
+	Events []TaskEvent
+}
+
+// TaskEvent is an event of a task
+type TaskEvent struct {
+	At   time.Time
+	Text string
+}
+
+// TaskSpans converts tracer records to spans ordered by start time
+//   - records: from [parl.Tracer.Records]
+//   - tasks without events are omitted
+func TaskSpans(records map[parl.TracerTaskID][]parl.TracerRecord) (spans []TaskSpan) {
+	spans = make([]TaskSpan, 0, len(records))
+	for taskID, taskRecords := range records {
+		if len(taskRecords) == 0 {
+			continue
+		}
+		var span = TaskSpan{TaskID: taskID, Events: make([]TaskEvent, len(taskRecords))}
+		for i, record := range taskRecords {
+			var event = &span.Events[i]
+			event.At, event.Text = record.Values()
+			if span.Start.IsZero() || event.At.Before(span.Start) {
+				span.Start = event.At
+			}
+			if event.At.After(span.End) {
+				span.End = event.At
+			}
+		}
+		spans = append(spans, span)
+	}
+	slices.SortFunc(spans, func(a, b TaskSpan) (result int) {
+		if result = a.Start.Compare(b.Start); result == 0 {
+			result = cmp.Compare(string(a.TaskID), string(b.TaskID))
+		}
+		return
+	})
+
+	return
+}
+
+// ExportSpans creates a span for each task with its events
+//   - span name is task ID, start and end are times of first and last event
+//   - records: from [parl.Tracer.Records]
+func ExportSpans(records map[parl.TracerTaskID][]parl.TracerRecord, starter SpanStarter) {
+	if starter == nil {
+		panic(parl.NilError("starter"))
+	}
+	for _, taskSpan := range TaskSpans(records) {
+		var span = starter.StartSpan(string(taskSpan.TaskID), taskSpan.Start, taskSpan.attributes())
+		for _, event := range taskSpan.Events {
+			span.AddEvent(event.Text, event.At)
+		}
+		span.End(taskSpan.End)
+	}
+}
+
+// OTLPJSON returns an OTLP/JSON ExportTraceServiceRequest
+//   - all tasks are spans of a single new trace
+//   - serviceName: resource attribute service.name
+//   - records: from [parl.Tracer.Records]
+func OTLPJSON(records map[parl.TracerTaskID][]parl.TracerRecord, serviceName string) (data []byte, err error) {
+	var traceID string
+	if traceID, err = randomHex(16); err != nil {
+		return
+	}
+	var taskSpans = TaskSpans(records)
+	var spans = make([]otlpSpan, len(taskSpans))
+	for i, taskSpan := range taskSpans {
+		var span = &spans[i]
+		span.TraceID = traceID
+		if span.SpanID, err = randomHex(8); err != nil {
+			return
+		}
+		span.Name = string(taskSpan.TaskID)
+		span.Kind = spanKindInternal
+		span.StartTimeUnixNano = unixNano(taskSpan.Start)
+		span.EndTimeUnixNano = unixNano(taskSpan.End)
+		for key, value := range taskSpan.attributes() {
+			span.Attributes = append(span.Attributes, otlpAttribute(key, value))
+		}
+		slices.SortFunc(span.Attributes, func(a, b otlpKeyValue) (result int) { return cmp.Compare(a.Key, b.Key) })
+		span.Events = make([]otlpEvent, len(taskSpan.Events))
+		for j, event := range taskSpan.Events {
+			span.Events[j] = otlpEvent{TimeUnixNano: unixNano(event.At), Name: event.Text}
+		}
+	}
+	var request = otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{otlpAttribute("service.name", serviceName)}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: scopeName},
+			Spans: spans,
+		}},
+	}}}
+	if data, err = json.Marshal(&request); perrors.IsPF(&err, "json.Marshal %w", err) {
+		return
+	}
+
+	return
+}
+
+// attributes returns span attributes of a task
+func (s *TaskSpan) attributes() (attributes map[string]string) {
+	return map[string]string{
+		TaskIDAttribute:     string(s.TaskID),
+		EventCountAttribute: strconv.Itoa(len(s.Events)),
+	}
+}
+
+// OTLP/JSON ExportTraceServiceRequest structure
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string `json:"timeUnixNano"`
+	Name         string `json:"name"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// otlpAttribute returns a string attribute
+func otlpAttribute(key, value string) (keyValue otlpKeyValue) {
+	return otlpKeyValue{Key: key, Value: otlpValue{StringValue: value}}
+}
+
+// unixNano is OTLP/JSON 64-bit integer encoded as decimal string
+func unixNano(t time.Time) (s string) { return strconv.FormatInt(t.UnixNano(), 10) }
+
+// randomHex returns n random bytes as lower-case hex
+func randomHex(n int) (s string, err error) {
+	var b = make([]byte, n)
+	if _, err = rand.Read(b); perrors.IsPF(&err, "rand.Read %w", err) {
+		return
+	}
+	s = hex.EncodeToString(b)
+
+	return
+}
@@ -0,0 +1,92 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package tracer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+func TestExportSpans(t *testing.T) {
+	//t.Error("Logging on")
+	var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var records = map[parl.TracerTaskID][]parl.TracerRecord{
+		"taskB": {testRecord{t0.Add(2 * time.Second), "b1"}},
+		"taskA": {testRecord{t0, "a1"}, testRecord{t0.Add(time.Second), "a2"}},
+		"empty": {},
+	}
+
+	// ExportSpans
+	var starter testStarter
+	ExportSpans(records, &starter)
+	if len(starter.spans) != 2 {
+		t.Fatalf("spans %d exp 2", len(starter.spans))
+	}
+	var span = starter.spans[0]
+	if span.name != "taskA" || !span.start.Equal(t0) || !span.end.Equal(t0.Add(time.Second)) {
+		t.Errorf("span %s %s %s", span.name, span.start, span.end)
+	}
+	if len(span.events) != 2 || span.events[1] != "a2" {
+		t.Errorf("events %v exp [a1 a2]", span.events)
+	}
+	if span.attributes[TaskIDAttribute] != "taskA" || span.attributes[EventCountAttribute] != "2" {
+		t.Errorf("attributes %v", span.attributes)
+	}
+
+	// OTLPJSON
+	var data, err = OTLPJSON(records, "svc")
+	if err != nil {
+		t.Fatalf("OTLPJSON err: %s", err)
+	}
+	var request otlpRequest
+	if err = json.Unmarshal(data, &request); err != nil {
+		t.Fatalf("Unmarshal err: %s", err)
+	}
+	var spans = request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("OTLP spans %d exp 2", len(spans))
+	}
+	if spans[0].TraceID != spans[1].TraceID || len(spans[0].TraceID) != 32 || len(spans[0].SpanID) != 16 {
+		t.Errorf("bad IDs %q %q %q", spans[0].TraceID, spans[1].TraceID, spans[0].SpanID)
+	}
+	if spans[0].StartTimeUnixNano != "1704067200000000000" {
+		t.Errorf("startTimeUnixNano %s", spans[0].StartTimeUnixNano)
+	}
+	if v := request.ResourceSpans[0].Resource.Attributes[0].Value.StringValue; v != "svc" {
+		t.Errorf("service.name %q exp svc", v)
+	}
+}
+
+// testRecord is [parl.TracerRecord]
+type testRecord struct {
+	at   time.Time
+	text string
+}
+
+func (r testRecord) Values() (at time.Time, text string) { return r.at, r.text }
+
+// testStarter is [SpanStarter]
+type testStarter struct{ spans []*testSpan }
+
+type testSpan struct {
+	name       string
+	start, end time.Time
+	attributes map[string]string
+	events     []string
+}
+
+func (s *testStarter) StartSpan(name string, start time.Time, attributes map[string]string) (span Span) {
+	var t = &testSpan{name: name, start: start, attributes: attributes}
+	s.spans = append(s.spans, t)
+	return t
+}
+
+func (s *testSpan) AddEvent(name string, at time.Time) { s.events = append(s.events, name) }
+
+func (s *testSpan) End(end time.Time) { s.end = end }
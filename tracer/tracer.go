@@ -0,0 +1,107 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package tracer
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/haraldrudell/parl"
+)
+
+// Tracer is an in-memory [parl.Tracer]. Thread-safe
+//   - tasks are event lists keyed by [parl.TracerTaskID]
+//   - a thread recording an event without being assigned is
+//     assigned to a task named by its thread ID
+type Tracer struct {
+	lock sync.Mutex
+	// threads is the task each thread is currently assigned to
+	//	- behind lock
+	threads map[parl.ThreadID]parl.TracerTaskID
+	// tasks are the events of each task in recording order
+	//	- behind lock
+	tasks map[parl.TracerTaskID][]parl.TracerRecord
+}
+
+// NewTracer returns an in-memory task tracer
+//
+// Usage:
+//
+//	var t = tracer.NewTracer()
+//	goGroup.SetTracer(t)
+//	…
+//	data, err = tracer.OTLPJSON(t.Records(false), "myService")
+func NewTracer() (tracer *Tracer) {
+	return &Tracer{
+		threads: make(map[parl.ThreadID]parl.TracerTaskID),
+		tasks:   make(map[parl.TracerTaskID][]parl.TracerRecord),
+	}
+}
+
+// AssignTaskToThread assigns threadID to task
+//   - an empty task removes any assignment of threadID
+func (t *Tracer) AssignTaskToThread(threadID parl.ThreadID, task parl.TracerTaskID) (tracer parl.Tracer) {
+	tracer = t
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if task == "" {
+		delete(t.threads, threadID)
+		return
+	}
+	t.threads[threadID] = task
+
+	return
+}
+
+// RecordTaskEvent adds an event to the task threadID is assigned to
+func (t *Tracer) RecordTaskEvent(threadID parl.ThreadID, text string) (tracer parl.Tracer) {
+	tracer = t
+	var record = &tracerRecord{at: time.Now(), text: text}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var task, ok = t.threads[threadID]
+	if !ok {
+		task = parl.TracerTaskID(strconv.FormatUint(uint64(threadID), 10))
+		t.threads[threadID] = task
+	}
+	t.tasks[task] = append(t.tasks[task], record)
+
+	return
+}
+
+// Records returns the tasks and their events
+//   - clear true: recorded events and thread assignments are discarded
+func (t *Tracer) Records(clear bool) (records map[parl.TracerTaskID][]parl.TracerRecord) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if clear {
+		records = t.tasks
+		t.tasks = make(map[parl.TracerTaskID][]parl.TracerRecord)
+		t.threads = make(map[parl.ThreadID]parl.TracerTaskID)
+		return
+	}
+	records = make(map[parl.TracerTaskID][]parl.TracerRecord, len(t.tasks))
+	for task, recordList := range t.tasks {
+		records[task] = append([]parl.TracerRecord(nil), recordList...)
+	}
+
+	return
+}
+
+// tracerRecord is [parl.TracerRecord]
+type tracerRecord struct {
+	at   time.Time
+	text string
+}
+
+// Values returns time and text of the event
+func (r *tracerRecord) Values() (at time.Time, text string) { return r.at, r.text }
+
+var _ parl.Tracer = &Tracer{}
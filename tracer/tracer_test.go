@@ -0,0 +1,35 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package tracer
+
+import (
+	"testing"
+
+	"github.com/haraldrudell/parl"
+)
+
+func TestTracer(t *testing.T) {
+	//t.Error("Logging on")
+	var threadID1, threadID2 = parl.ThreadID(1), parl.ThreadID(2)
+	var task = parl.TracerTaskID("task")
+
+	var tracer = NewTracer()
+	tracer.AssignTaskToThread(threadID1, task).
+		RecordTaskEvent(threadID1, "a")
+	// unassigned thread records to a task named by thread ID
+	tracer.RecordTaskEvent(threadID2, "b")
+
+	var records = tracer.Records(true)
+	if len(records) != 2 || len(records[task]) != 1 || len(records["2"]) != 1 {
+		t.Fatalf("records: %v", records)
+	}
+	if _, text := records[task][0].Values(); text != "a" {
+		t.Errorf("text %q exp a", text)
+	}
+	if records = tracer.Records(false); len(records) != 0 {
+		t.Errorf("records after clear: %v", records)
+	}
+}
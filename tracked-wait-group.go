@@ -0,0 +1,193 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/haraldrudell/parl/pruntime"
+)
+
+const (
+	// frames to skip for the caller of TrackedWaitGroup.Add
+	trackedAddFrames = 1
+)
+
+// TrackedWaitGroup is a [WaitGroupCh] where each participant is
+// named and has the code location of its Add
+//   - [TrackedWaitGroup.Add] returns a [Participant] whose Done ends
+//     its participation
+//   - [TrackedWaitGroup.Outstanding] lists participants that have not
+//     invoked Done
+//   - [TrackedWaitGroup.DiagnoseAfter] makes Wait log outstanding
+//     participants when waiting exceeds a duration,
+//     turning a hung Wait into actionable output
+//   - initialization-free, thread-safe
+//
+// Usage:
+//
+//	var w parl.TrackedWaitGroup
+//	w.DiagnoseAfter(10 * time.Second)
+//	go worker(w.Add("worker"))
+//	w.Wait()
+//	func worker(participant *parl.Participant) {
+//	  defer participant.Done()
+//	  …
+//	// logs: “TrackedWaitGroup: Wait 10s: 1 outstanding: worker main.main-main.go:12 age 10s”
+type TrackedWaitGroup struct {
+	wg WaitGroupCh
+	// lock makes participants and nextID thread-safe
+	lock sync.Mutex
+	// participants are outstanding participants, behind lock
+	participants map[uint64]*Participant
+	// nextID is ID of the next participant, behind lock
+	nextID uint64
+	// diagnose is diagnostic configuration, nil if off
+	diagnose atomic.Pointer[trackedDiagnose]
+}
+
+// Participant is a named participant of [TrackedWaitGroup]
+type Participant struct {
+	// Label is the name provided to Add
+	Label string
+	// Location is the code location invoking Add
+	Location pruntime.CodeLocation
+	// Added is when Add was invoked
+	Added time.Time
+	wg    *TrackedWaitGroup
+	id    uint64
+	// isDone makes Done idempotent
+	isDone atomic.Bool
+}
+
+// trackedDiagnose is configuration of DiagnoseAfter
+type trackedDiagnose struct {
+	after time.Duration
+	log   PrintfFunc
+}
+
+// Add adds a participant
+//   - label: name used in diagnostics
+//   - participant: Done ends participation
+func (w *TrackedWaitGroup) Add(label string) (participant *Participant) {
+	var p = Participant{
+		Label:    label,
+		Location: *pruntime.NewCodeLocation(trackedAddFrames),
+		Added:    time.Now(),
+		wg:       w,
+	}
+	w.lock.Lock()
+	if w.participants == nil {
+		w.participants = make(map[uint64]*Participant)
+	}
+	w.nextID++
+	p.id = w.nextID
+	w.participants[p.id] = &p
+	w.wg.Add(1)
+	w.lock.Unlock()
+
+	return &p
+}
+
+// Done ends participation
+//   - idempotent
+func (p *Participant) Done() {
+	if !p.isDone.CompareAndSwap(false, true) {
+		return // already done return
+	}
+	var w = p.wg
+	w.lock.Lock()
+	delete(w.participants, p.id)
+	w.lock.Unlock()
+	w.wg.Done()
+}
+
+// “worker main.main-main.go:12 age 10s”
+func (p *Participant) String() (s string) {
+	return p.Label + " " + p.Location.Short() + " age " + time.Since(p.Added).Round(time.Second).String()
+}
+
+// DiagnoseAfter makes Wait log outstanding participants
+// each time waiting exceeds another period d
+//   - d 0: diagnostics off
+//   - log: optional logging function, default [Log]
+//   - thread-safe
+func (w *TrackedWaitGroup) DiagnoseAfter(d time.Duration, log ...PrintfFunc) {
+	if d <= 0 {
+		w.diagnose.Store(nil)
+		return
+	}
+	var diagnose = trackedDiagnose{after: d, log: Log}
+	if len(log) > 0 && log[0] != nil {
+		diagnose.log = log[0]
+	}
+	w.diagnose.Store(&diagnose)
+}
+
+// Outstanding returns participants that have not invoked Done
+//   - ordered by time of Add
+func (w *TrackedWaitGroup) Outstanding() (participants []*Participant) {
+	w.lock.Lock()
+	participants = make([]*Participant, 0, len(w.participants))
+	for _, p := range w.participants {
+		participants = append(participants, p)
+	}
+	w.lock.Unlock()
+
+	slices.SortFunc(participants, func(a, b *Participant) (result int) {
+		if result = a.Added.Compare(b.Added); result == 0 {
+			result = cmp.Compare(a.id, b.id)
+		}
+		return
+	})
+
+	return
+}
+
+// Count returns the number of outstanding participants
+func (w *TrackedWaitGroup) Count() (count int) { return w.wg.Count() }
+
+// Ch returns a channel that closes once all participants are done
+func (w *TrackedWaitGroup) Ch() (ch AwaitableCh) { return w.wg.Ch() }
+
+// Wait blocks until all participants are done
+//   - with DiagnoseAfter, outstanding participants are logged
+//     periodically while waiting
+func (w *TrackedWaitGroup) Wait() {
+	var ch = w.wg.Ch()
+	var diagnose = w.diagnose.Load()
+	if diagnose == nil {
+		<-ch
+		return // no diagnostics return
+	}
+
+	var t0 = time.Now()
+	var ticker = time.NewTicker(diagnose.after)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ch:
+			return
+		case <-ticker.C:
+		}
+		var participants = w.Outstanding()
+		if len(participants) == 0 {
+			continue
+		}
+		var sList = make([]string, len(participants))
+		for i, p := range participants {
+			sList[i] = p.String()
+		}
+		diagnose.log("TrackedWaitGroup: Wait %s: %d outstanding: %s",
+			time.Since(t0).Round(time.Second), len(participants), strings.Join(sList, "; "),
+		)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTrackedWaitGroup(t *testing.T) {
+	//t.Error("Logging on")
+	const (
+		label1, label2 = "worker1", "worker2"
+	)
+
+	var w TrackedWaitGroup
+	var participants []*Participant
+
+	// Add should record label and location
+	var p1 = w.Add(label1)
+	var p2 = w.Add(label2)
+	if c := w.Count(); c != 2 {
+		t.Errorf("Count %d exp 2", c)
+	}
+	participants = w.Outstanding()
+	if len(participants) != 2 || participants[0].Label != label1 || participants[1].Label != label2 {
+		t.Fatalf("Outstanding %v", participants)
+	}
+	if f := participants[0].Location.FuncName; !strings.Contains(f, "TestTrackedWaitGroup") {
+		t.Errorf("Location %q", f)
+	}
+
+	// Done should be idempotent
+	p1.Done()
+	p1.Done()
+	if participants = w.Outstanding(); len(participants) != 1 || participants[0] != p2 {
+		t.Errorf("Outstanding after Done %v", participants)
+	}
+
+	// Wait should log outstanding participants
+	var lock sync.Mutex
+	var logs []string
+	w.DiagnoseAfter(time.Millisecond, func(format string, a ...any) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if logs = append(logs, fmt.Sprintf(format, a...)); len(logs) == 1 {
+			p2.Done()
+		}
+	})
+	w.Wait()
+	lock.Lock()
+	defer lock.Unlock()
+
+	if len(logs) == 0 {
+		t.Fatal("no diagnostics")
+	}
+	if !strings.Contains(logs[0], "1 outstanding: "+label2) {
+		t.Errorf("bad diagnostics: %q", logs[0])
+	}
+}
@@ -0,0 +1,137 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"reflect"
+)
+
+// WaitAll returns an awaitable that closes when all of chs have closed
+//   - composite ready or shutdown conditions:
+//     database ready and listener ready and configuration loaded
+//   - no chs: the returned channel is closed
+//   - a thread awaits chs until all have closed.
+//     [AwaitAll] is the blocking version with context cancel
+//   - nil chs are ignored
+//
+// Usage:
+//
+//	var ready = parl.WaitAll(db.ReadyCh(), listener.ReadyCh(), config.LoadedCh())
+//	select {
+//	case <-ready:
+//	case <-ctx.Done():
+//	}
+func WaitAll(chs ...AwaitableCh) (ch AwaitableCh) {
+	var a Awaitable
+	ch = a.Ch()
+	if isAllClosed(chs) {
+		a.Close()
+		return // all closed return
+	}
+	go waitAllThread(&a, chs)
+
+	return
+}
+
+// WaitAny returns an awaitable that closes when any of chs has closed
+//   - no chs: the returned channel never closes
+//   - a thread awaits chs until one has closed.
+//     [AwaitAny] is the blocking version with context cancel
+//   - nil chs are ignored
+func WaitAny(chs ...AwaitableCh) (ch AwaitableCh) {
+	var a Awaitable
+	ch = a.Ch()
+	if index, _ := AwaitAny(nil, chs...); index >= 0 {
+		a.Close()
+		return // closed return
+	} else if len(chs) == 0 {
+		return // never closes return
+	}
+	go waitAnyThread(&a, chs)
+
+	return
+}
+
+// AwaitAll blocks until all of chs have closed or ctx is canceled
+//   - err: nil if all closed, otherwise [context.Cause] of ctx
+//   - nil chs are ignored
+func AwaitAll(ctx context.Context, chs ...AwaitableCh) (err error) {
+	var done = ctx.Done()
+	for _, ch := range chs {
+		if ch == nil {
+			continue
+		}
+		select {
+		case <-ch:
+		case <-done:
+			err = context.Cause(ctx)
+			return // canceled return
+		}
+	}
+
+	return
+}
+
+// AwaitAny blocks until any of chs has closed or ctx is canceled
+//   - index: the index in chs of a closed channel, -1 on cancel
+//   - err: non-nil [context.Cause] of ctx on cancel
+//   - ctx nil: AwaitAny does not block.
+//     index is -1 if no channel is closed
+//   - no chs: blocks until ctx is canceled
+//   - nil chs are ignored
+func AwaitAny(ctx context.Context, chs ...AwaitableCh) (index int, err error) {
+	var cases = make([]reflect.SelectCase, len(chs)+1)
+	for i, ch := range chs {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv}
+		if ch != nil {
+			cases[i].Chan = reflect.ValueOf(ch)
+		}
+	}
+	if ctx != nil {
+		cases[len(chs)] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	} else {
+		cases[len(chs)] = reflect.SelectCase{Dir: reflect.SelectDefault}
+	}
+	if index, _, _ = reflect.Select(cases); index < len(chs) {
+		return // a channel closed return
+	}
+	index = -1
+	if ctx != nil {
+		err = context.Cause(ctx)
+	}
+
+	return
+}
+
+// isAllClosed returns true if all of chs are closed
+func isAllClosed(chs []AwaitableCh) (isClosed bool) {
+	for _, ch := range chs {
+		if ch == nil {
+			continue
+		}
+		select {
+		case <-ch:
+		default:
+			return // not closed return
+		}
+	}
+	return true
+}
+
+// waitAllThread closes a when all of chs have closed
+func waitAllThread(a *Awaitable, chs []AwaitableCh) {
+	defer a.Close()
+
+	AwaitAll(context.Background(), chs...)
+}
+
+// waitAnyThread closes a when any of chs has closed
+func waitAnyThread(a *Awaitable, chs []AwaitableCh) {
+	defer a.Close()
+
+	AwaitAny(context.Background(), chs...)
+}
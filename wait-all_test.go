@@ -0,0 +1,75 @@
+/*
+© 2024–present Harald Rudell <harald.rudell@gmail.com> (https://haraldrudell.github.io/haraldrudell/)
+ISC License
+*/
+
+package parl
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWaitAll(t *testing.T) {
+	//t.Error("Logging on")
+	var a1, a2 Awaitable
+	var ch = WaitAll(a1.Ch(), nil, a2.Ch())
+
+	// WaitAll should close when all closed
+	a1.Close()
+	select {
+	case <-ch:
+		t.Fatal("WaitAll closed early")
+	default:
+	}
+	a2.Close()
+	<-ch
+
+	// no channels is closed
+	<-WaitAll()
+}
+
+func TestWaitAny(t *testing.T) {
+	//t.Error("Logging on")
+	var a1, a2 Awaitable
+	var ch = WaitAny(a1.Ch(), nil, a2.Ch())
+
+	select {
+	case <-ch:
+		t.Fatal("WaitAny closed early")
+	default:
+	}
+	// WaitAny should close when any closed
+	a2.Close()
+	<-ch
+
+	// closed channel returns closed
+	<-WaitAny(a2.Ch())
+}
+
+func TestAwaitAllAny(t *testing.T) {
+	//t.Error("Logging on")
+	var a1, a2 Awaitable
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	// AwaitAll should return ctx error on cancel
+	a1.Close()
+	if err := AwaitAll(ctx, a1.Ch(), a2.Ch()); !errors.Is(err, context.Canceled) {
+		t.Errorf("AwaitAll err %v", err)
+	}
+
+	// AwaitAny should return index
+	if index, err := AwaitAny(context.Background(), a2.Ch(), a1.Ch()); index != 1 || err != nil {
+		t.Errorf("AwaitAny %d %v", index, err)
+	}
+	// AwaitAny should return ctx error on cancel
+	if index, err := AwaitAny(ctx, a2.Ch()); index != -1 || !errors.Is(err, context.Canceled) {
+		t.Errorf("AwaitAny cancel %d %v", index, err)
+	}
+	// AwaitAny nil ctx should not block
+	if index, err := AwaitAny(nil, a2.Ch()); index != -1 || err != nil {
+		t.Errorf("AwaitAny nil ctx %d %v", index, err)
+	}
+}
@@ -36,11 +36,15 @@ const (
 //   - The value for yamlDictionaryKey must be a dictionary
 //   - the remainder of the yamlDictionaryKey is read when it matches
 //     the YamData struct
+//   - layers: optional configuration layering recording options updated from yaml.
+//     Options from the command line are not updated.
+//     Environment variables are applied afterwards by [pflags.ConfigLayers.ApplyEnvironment]
 //   - -verbose=yamlo.ApplyYaml “github.com/haraldrudell/parl/yamlo.ApplyYaml”
 func ApplyYaml(
 	program, yamlFile, yamlDictionaryKey string, doYaml bool,
 	genericYaml GenericYaml,
 	optionData []pflags.OptionData,
+	layers ...*pflags.ConfigLayers,
 ) (err error) {
 	if genericYaml == nil {
 		panic(perrors.NewPF("genericYaml cannot be nil"))
@@ -118,6 +122,9 @@ func ApplyYaml(
 		if err = optionData.ApplyYaml(); err != nil {
 			return
 		}
+		if len(layers) > 0 && layers[0] != nil {
+			layers[0].SetSource(optionData.Name, pflags.SourceYaml)
+		}
 	}
 
 	if parl.IsThisDebug() {